@@ -3,19 +3,44 @@ package config
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"time"
 	"usermanagement/app/internal"
+	"usermanagement/app/internal/service"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
 )
 
 type AppConfiguration struct {
-	config       Config
-	engine       *gin.Engine
-	server       *http.Server
-	userService  internal.UserService
-	groupService internal.GroupService
+	config                  Config
+	engine                  *gin.Engine
+	server                  *http.Server
+	trustedProxies          []*net.IPNet
+	userService             internal.UserService
+	userImportService       internal.UserImportService
+	attributeService        internal.AttributeService
+	groupService            internal.GroupService
+	authService             internal.AuthService
+	mfaService              internal.MFAService
+	passkeyService          internal.PasskeyService
+	lockoutService          internal.LockoutService
+	userStatusService       internal.UserStatusService
+	retentionService        internal.RetentionService
+	privacyService          internal.PrivacyService
+	idempotencyStore        internal.IdempotencyStore
+	jobData                 internal.JobData
+	jobRunner               internal.JobRunner
+	membershipExpiryService internal.MembershipExpiryService
+	dynamicGroupService     internal.DynamicGroupService
+	accessRequestService    internal.AccessRequestService
+	accessReviewService     internal.AccessReviewService
+	organizationService     internal.OrganizationService
+	accessTokenService      internal.AccessTokenService
+	oauthService            internal.OAuthService
+	oidcService             internal.OIDCService
+	keyManager              internal.KeyManager
 }
 
 func NewAppService(config Config) *AppConfiguration {
@@ -33,11 +58,53 @@ func NewAppService(config Config) *AppConfiguration {
 	return app
 }
 
+// JobRunner works off the asynchronous jobs, the server runs it as a
+// background service.
+func (a *AppConfiguration) JobRunner() internal.JobRunner {
+	return a.jobRunner
+}
+
+// MembershipExpiryService removes expired group memberships, the server runs
+// it as a background service.
+func (a *AppConfiguration) MembershipExpiryService() internal.MembershipExpiryService {
+	return a.membershipExpiryService
+}
+
+// DynamicGroupService reconciles dynamic groups with their rules, the server
+// runs it as a background service.
+func (a *AppConfiguration) DynamicGroupService() internal.DynamicGroupService {
+	return a.dynamicGroupService
+}
+
+// AccessRequestService expires access requests nobody decided, the server
+// runs it as a background service.
+func (a *AppConfiguration) AccessRequestService() internal.AccessRequestService {
+	return a.accessRequestService
+}
+
+// AccessReviewService closes review campaigns at their deadline, the server
+// runs it as a background service.
+func (a *AppConfiguration) AccessReviewService() internal.AccessReviewService {
+	return a.accessReviewService
+}
+
+// KeyManager rotates the signing keys on schedule, the server runs it as a
+// background service.
+func (a *AppConfiguration) KeyManager() internal.KeyManager {
+	return a.keyManager
+}
+
 func (a *AppConfiguration) Init() (err error) {
 	a.initialiseRoutes()
 	return nil
 }
 
+const (
+	defaultReactivateInterval = time.Minute
+	defaultPurgeInterval      = time.Hour
+	defaultJobRetention       = 7 * 24 * time.Hour
+)
+
 func (a *AppConfiguration) Run(ctx context.Context) error {
 	go func() {
 		<-ctx.Done()
@@ -49,3 +116,62 @@ func (a *AppConfiguration) Run(ctx context.Context) error {
 	}
 	return nil
 }
+
+// BackgroundTasks reactivate suspended users and purge expired records, the
+// server runs them as background services.
+func (a *AppConfiguration) BackgroundTasks() []*service.PeriodicTask {
+	interval := a.config.UserStatus.ReactivateInterval
+	if interval == 0 {
+		interval = defaultReactivateInterval
+	}
+	tasks := []*service.PeriodicTask{{
+		Name:     "reactivate suspended users",
+		Interval: interval,
+		Task:     a.userStatusService.ReactivateExpired,
+	}}
+
+	interval = a.config.Idempotency.PurgeInterval
+	if interval == 0 {
+		interval = defaultPurgeInterval
+	}
+	tasks = append(tasks, &service.PeriodicTask{
+		Name:     "purge idempotency keys",
+		Interval: interval,
+		Task: func() error {
+			return a.idempotencyStore.PurgeIdempotencyKeys(time.Now())
+		},
+	})
+
+	interval = a.config.Jobs.PurgeInterval
+	if interval == 0 {
+		interval = defaultPurgeInterval
+	}
+	retention := a.config.Jobs.Retention
+	if retention == 0 {
+		retention = defaultJobRetention
+	}
+	tasks = append(tasks, &service.PeriodicTask{
+		Name:     "purge finished jobs",
+		Interval: interval,
+		Task: func() error {
+			_, err := a.jobData.PurgeJobs(time.Now().Add(-retention))
+			return err
+		},
+	})
+
+	if a.config.Retention.Period == 0 {
+		return tasks
+	}
+	interval = a.config.Retention.PurgeInterval
+	if interval == 0 {
+		interval = defaultPurgeInterval
+	}
+	return append(tasks, &service.PeriodicTask{
+		Name:     "queue purge of deleted records",
+		Interval: interval,
+		Task: func() error {
+			_, err := a.jobRunner.Enqueue(internal.JobPurgeRetention, 0, nil)
+			return err
+		},
+	})
+}
@@ -1,10 +1,21 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/attempts"
 	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
 	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/webauthn"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
 	log "github.com/sirupsen/logrus"
@@ -19,9 +30,159 @@ type Postgres struct {
 	Sslmode  string
 }
 
-type Config struct {
-	Postgres Postgres
+type SMTP struct {
+	Host     string
 	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type Notifier struct {
+	Type string
+	File string
+	SMTP SMTP
+}
+
+// Auth configures logins, access tokens are signed with the signing keys.
+// Admins are the emails of users of the default organization who may manage
+// every group once they verified their email.
+type Auth struct {
+	TokenTTL             time.Duration
+	ResetTokenTTL        time.Duration
+	VerificationTokenTTL time.Duration
+	MFAIssuer            string
+	Admins               []string
+}
+
+type WebAuthn struct {
+	RPID             string
+	RPName           string
+	Origins          []string
+	UserVerification string
+	Timeout          time.Duration
+}
+
+type Redis struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// Lockout configures login throttling, Store is "db", "memory" or "redis".
+type Lockout struct {
+	Store           string
+	Redis           Redis
+	Window          time.Duration
+	UserThreshold   int64
+	IPThreshold     int64
+	LockoutDuration time.Duration
+	DelayAfter      int64
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+}
+
+// UserStatus configures how often ended suspensions are lifted.
+type UserStatus struct {
+	ReactivateInterval time.Duration
+}
+
+// Memberships configures how often expired group memberships are removed.
+type Memberships struct {
+	ExpiryInterval time.Duration
+}
+
+// DynamicGroups configures how often the members of dynamic groups are
+// reconciled with their rules.
+type DynamicGroups struct {
+	ReconcileInterval time.Duration
+}
+
+// AccessRequests configures how long a request to join a group waits for a
+// decision and how often expired requests are swept.
+type AccessRequests struct {
+	TTL            time.Duration
+	ExpiryInterval time.Duration
+}
+
+// AccessReviews configures how often review campaigns past their deadline
+// are closed.
+type AccessReviews struct {
+	CloseInterval time.Duration
+}
+
+// Retention configures how long deleted users and groups can be restored
+// before they are purged, a zero Period keeps them forever.
+type Retention struct {
+	Period        time.Duration
+	PurgeInterval time.Duration
+}
+
+// Idempotency configures how long responses to requests with an
+// Idempotency-Key are kept for replay.
+type Idempotency struct {
+	TTL           time.Duration
+	PurgeInterval time.Duration
+}
+
+// Jobs configures the workers of asynchronous jobs and how long finished
+// jobs are kept.
+type Jobs struct {
+	Workers       int
+	PollInterval  time.Duration
+	Lease         time.Duration
+	MaxAttempts   int
+	RetryBackoff  time.Duration
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+// OAuth configures the authorization server, how long its access tokens are
+// valid and how long an authorization code waits to be exchanged.
+type OAuth struct {
+	TokenTTL time.Duration
+	CodeTTL  time.Duration
+}
+
+// OIDC configures the OpenID Connect provider. Issuer is the URL the service
+// is reached at, ID tokens are valid for IDTokenTTL.
+type OIDC struct {
+	Issuer     string
+	IDTokenTTL time.Duration
+}
+
+// SigningKeys configures the keys tokens are signed with. They are stored
+// encrypted with the master key, MasterKey or the content of MasterKeyFile,
+// one of them is required.
+// A new key of Algorithm, RS256 or EdDSA, signs every RotationInterval, which
+// is checked every CheckInterval.
+type SigningKeys struct {
+	MasterKey        string
+	MasterKeyFile    string
+	Algorithm        string
+	RotationInterval time.Duration
+	CheckInterval    time.Duration
+}
+
+type Config struct {
+	Postgres       Postgres
+	Port           int
+	TrustedProxies []string
+	Notifier       Notifier
+	Auth           Auth
+	WebAuthn       WebAuthn
+	Lockout        Lockout
+	UserStatus     UserStatus
+	Retention      Retention
+	Idempotency    Idempotency
+	Jobs           Jobs
+	Memberships    Memberships
+	DynamicGroups  DynamicGroups
+	AccessRequests AccessRequests
+	AccessReviews  AccessReviews
+	OAuth          OAuth
+	OIDC           OIDC
+	SigningKeys    SigningKeys
 }
 
 func initializeServices(appConfig *AppConfiguration) {
@@ -29,11 +190,144 @@ func initializeServices(appConfig *AppConfiguration) {
 	if err != nil {
 		log.WithField("err", err).Fatal("intialising DB")
 	}
-	userData := data.NewUserService(db)
-	appConfig.userService = service.NewUserService(userData)
+	notifier, err := NewNotifier(appConfig.config.Notifier)
+	if err != nil {
+		log.WithField("err", err).Fatal("intialising notifier")
+	}
+
+	appConfig.trustedProxies, err = httpservice.ParseTrustedProxies(appConfig.config.TrustedProxies)
+	if err != nil {
+		log.WithField("err", err).Fatal("intialising trusted proxies")
+	}
 
+	attemptStore, err := NewAttemptStore(appConfig.config.Lockout, db)
+	if err != nil {
+		log.WithField("err", err).Fatal("intialising attempt store")
+	}
+
+	userData := data.NewUserService(db)
+	attributeData := data.NewAttributeService(db)
 	groupData := data.NewGroupService(db)
-	appConfig.groupService = service.NewGroupService(groupData)
+	appConfig.dynamicGroupService = service.NewDynamicGroupService(groupData, userData, attributeData, service.DynamicGroupOptions{
+		ReconcileInterval: appConfig.config.DynamicGroups.ReconcileInterval,
+	})
+	appConfig.attributeService = service.NewAttributeService(attributeData)
+	appConfig.userService = service.NewUserService(userData, attributeData, appConfig.dynamicGroupService, notifier, service.UserOptions{
+		VerificationTokenTTL: appConfig.config.Auth.VerificationTokenTTL,
+	})
+
+	appConfig.idempotencyStore = data.NewIdempotencyService(db)
+
+	appConfig.jobData = data.NewJobService(db)
+	jobRunner := service.NewJobService(appConfig.jobData, service.JobOptions{
+		Workers:      appConfig.config.Jobs.Workers,
+		PollInterval: appConfig.config.Jobs.PollInterval,
+		Lease:        appConfig.config.Jobs.Lease,
+		MaxAttempts:  appConfig.config.Jobs.MaxAttempts,
+		RetryBackoff: appConfig.config.Jobs.RetryBackoff,
+	})
+	appConfig.jobRunner = jobRunner
+
+	appConfig.groupService = service.NewGroupService(groupData, appConfig.dynamicGroupService)
+	userImportService := service.NewUserImportService(appConfig.userService, userData, attributeData, groupData, jobRunner)
+	appConfig.userImportService = userImportService
+	jobRunner.Register(internal.JobImportUsers, userImportService.RunImportJob)
+	appConfig.retentionService = service.NewRetentionService(userData, groupData, service.RetentionOptions{
+		Period: appConfig.config.Retention.Period,
+	})
+	jobRunner.Register(internal.JobPurgeRetention, func(ctx context.Context, payload []byte, progress func(internal.JobProgress)) (interface{}, error) {
+		return nil, appConfig.retentionService.Purge()
+	})
+
+	mfaData := data.NewMFAService(db)
+	appConfig.mfaService = service.NewMFAService(userData, mfaData, service.MFAOptions{
+		Issuer: appConfig.config.Auth.MFAIssuer,
+	})
+
+	masterKey, err := NewMasterKey(appConfig.config.SigningKeys)
+	if err != nil {
+		log.WithField("err", err).Fatal("intialising master key")
+	}
+	// retired keys verify as long as the longest lived tokens they signed
+	tokenTTL := appConfig.config.OIDC.IDTokenTTL
+	if appConfig.config.Auth.TokenTTL > tokenTTL {
+		tokenTTL = appConfig.config.Auth.TokenTTL
+	}
+	appConfig.keyManager = service.NewKeyManager(data.NewSigningKeyService(db), service.KeyManagerOptions{
+		MasterKey:        masterKey,
+		Algorithm:        appConfig.config.SigningKeys.Algorithm,
+		RotationInterval: appConfig.config.SigningKeys.RotationInterval,
+		CheckInterval:    appConfig.config.SigningKeys.CheckInterval,
+		TokenTTL:         tokenTTL,
+	})
+
+	passkeyData := data.NewPasskeyService(db)
+	appConfig.passkeyService = service.NewPasskeyService(userData, passkeyData, service.PasskeyOptions{
+		RelyingParty: webauthn.New(webauthn.Config{
+			RPID:             appConfig.config.WebAuthn.RPID,
+			RPName:           appConfig.config.WebAuthn.RPName,
+			Origins:          appConfig.config.WebAuthn.Origins,
+			UserVerification: appConfig.config.WebAuthn.UserVerification,
+			Timeout:          appConfig.config.WebAuthn.Timeout,
+		}),
+		Keys:     appConfig.keyManager,
+		TokenTTL: appConfig.config.Auth.TokenTTL,
+	})
+
+	auditData := data.NewAuditService(db)
+	auditor := service.NewAuditor(auditData)
+	appConfig.membershipExpiryService = service.NewMembershipExpiryService(groupData, auditor, service.MembershipExpiryOptions{
+		Interval: appConfig.config.Memberships.ExpiryInterval,
+	})
+	appConfig.accessRequestService = service.NewAccessRequestService(data.NewAccessRequestService(db), appConfig.groupService, groupData, userData,
+		notifier, auditor, service.AccessRequestOptions{
+			TTL:            appConfig.config.AccessRequests.TTL,
+			ExpiryInterval: appConfig.config.AccessRequests.ExpiryInterval,
+			Admins:         appConfig.config.Auth.Admins,
+		})
+	appConfig.accessReviewService = service.NewAccessReviewService(data.NewReviewService(db), appConfig.groupService, groupData, userData,
+		auditor, service.AccessReviewOptions{
+			CloseInterval: appConfig.config.AccessReviews.CloseInterval,
+			Keys:          appConfig.keyManager,
+		})
+	appConfig.organizationService = service.NewOrganizationService(data.NewOrganizationService(db))
+	appConfig.userStatusService = service.NewUserStatusService(userData, auditor)
+	appConfig.privacyService = service.NewPrivacyService(userData, groupData, mfaData, passkeyData, auditData, auditor)
+
+	lockoutService := service.NewLockoutService(userData, attemptStore, auditor, service.LockoutOptions{
+		Window:          appConfig.config.Lockout.Window,
+		UserThreshold:   appConfig.config.Lockout.UserThreshold,
+		IPThreshold:     appConfig.config.Lockout.IPThreshold,
+		LockoutDuration: appConfig.config.Lockout.LockoutDuration,
+		DelayAfter:      appConfig.config.Lockout.DelayAfter,
+		BaseDelay:       appConfig.config.Lockout.BaseDelay,
+		MaxDelay:        appConfig.config.Lockout.MaxDelay,
+	})
+	appConfig.lockoutService = lockoutService
+
+	accessTokenData := data.NewAccessTokenService(db)
+	appConfig.accessTokenService = service.NewAccessTokenService(userData, accessTokenData)
+	issuer := appConfig.config.OIDC.Issuer
+	if issuer == "" {
+		issuer = fmt.Sprintf("http://localhost:%d", appConfig.config.Port)
+	}
+	appConfig.oidcService = service.NewOIDCService(userData, groupData, appConfig.keyManager, service.OIDCOptions{
+		Issuer:     issuer,
+		IDTokenTTL: appConfig.config.OIDC.IDTokenTTL,
+	})
+	appConfig.oauthService = service.NewOAuthService(data.NewOAuthService(db), userData, accessTokenData, appConfig.oidcService, service.OAuthOptions{
+		TokenTTL: appConfig.config.OAuth.TokenTTL,
+		CodeTTL:  appConfig.config.OAuth.CodeTTL,
+	})
+
+	authService := service.NewAuthService(userData, mfaData, accessTokenData, lockoutService, notifier, appConfig.jobRunner, service.AuthOptions{
+		ResetTokenTTL: appConfig.config.Auth.ResetTokenTTL,
+		Keys:          appConfig.keyManager,
+		TokenTTL:      appConfig.config.Auth.TokenTTL,
+		Admins:        appConfig.config.Auth.Admins,
+	})
+	appConfig.jobRunner.Register(internal.JobSendPasswordReset, authService.RunPasswordResetJob)
+	appConfig.authService = authService
 }
 
 func NewDatabase(config Postgres) (db *gorm.DB, err error) {
@@ -44,3 +338,53 @@ func NewDatabase(config Postgres) (db *gorm.DB, err error) {
 	}
 	return db, err
 }
+
+func NewNotifier(config Notifier) (internal.Notifier, error) {
+	switch config.Type {
+	case "smtp":
+		return notifier.NewSMTPNotifier(config.SMTP.Host, config.SMTP.Port, config.SMTP.Username,
+			config.SMTP.Password, config.SMTP.From), nil
+	case "log", "":
+		return notifier.NewLogNotifier(config.File), nil
+	}
+	return nil, fmt.Errorf("unknown notifier type %s", config.Type)
+}
+
+func NewAttemptStore(config Lockout, db *gorm.DB) (internal.AttemptStore, error) {
+	switch config.Store {
+	case "db", "":
+		return data.NewAttemptService(db), nil
+	case "memory":
+		return attempts.NewMemoryStore(), nil
+	case "redis":
+		return attempts.NewRedisStore(redis.NewClient(&redis.Options{
+			Addr:     config.Redis.Addr,
+			Password: config.Redis.Password,
+			DB:       config.Redis.DB,
+		})), nil
+	}
+	return nil, fmt.Errorf("unknown attempt store %s", config.Store)
+}
+
+// NewMasterKey reads the master key the signing keys are encrypted with. The
+// server doesn't start without one, keys encrypted with a key it made up
+// wouldn't decrypt after a restart and every login would end.
+func NewMasterKey(config SigningKeys) ([]byte, error) {
+	switch {
+	case config.MasterKey != "" && config.MasterKeyFile != "":
+		return nil, errors.New("either a master key or a master key file")
+	case config.MasterKey != "":
+		return []byte(config.MasterKey), nil
+	case config.MasterKeyFile != "":
+		key, err := os.ReadFile(config.MasterKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key = bytes.TrimSpace(key)
+		if len(key) == 0 {
+			return nil, fmt.Errorf("master key file %s is empty", config.MasterKeyFile)
+		}
+		return key, nil
+	}
+	return nil, errors.New("no master key configured, set signingKeys.masterKey or signingKeys.masterKeyFile")
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"expvar"
 	"usermanagement/app/internal/httpservice"
 
 	"github.com/gin-contrib/cors"
@@ -10,31 +11,199 @@ import (
 func (a *AppConfiguration) initialiseRoutes() {
 	a.engine.Use(gin.Recovery())
 	a.engine.Use(cors.Default())
+	a.engine.Use(httpservice.ClientIPMiddleware(a.trustedProxies))
+	a.engine.GET("/debug/vars", a.authenticate(), a.admin(), gin.WrapH(expvar.Handler()))
+	a.engine.GET("/.well-known/openid-configuration", httpservice.DiscoveryHandler(a.oidcService))
+	a.engine.GET("/jwks.json", httpservice.JWKSHandler(a.oidcService))
 	v1 := a.engine.Group("api/v1")
 	a.addV1Routes(v1)
 }
 
 func (a *AppConfiguration) addV1Routes(router *gin.RouterGroup) {
-	users := router.Group("/users")
+	users := router.Group("/users", a.identify(), a.scope("users"), a.inOrganization())
 	a.addUserRouters(users)
-	groups := router.Group("/groups")
+	groups := router.Group("/groups", a.identify(), a.scope("groups"), a.inOrganization())
 	a.addGroupRouters(groups)
+	auth := router.Group("/auth", a.loginOrganization())
+	a.addAuthRouters(auth)
+	organizations := router.Group("/organizations", a.identify(), a.scope("organizations"), a.inOrganization())
+	a.addOrganizationRouters(organizations)
+	attributes := router.Group("/attributes", a.identify(), a.scope("attributes"))
+	a.addAttributeRouters(attributes)
+	jobs := router.Group("/jobs", a.identify(), a.scope("jobs"))
+	a.addJobRouters(jobs)
+	accessRequests := router.Group("/accessRequests", a.identify(), a.scope("accessRequests"), a.inOrganization())
+	a.addAccessRequestRouters(accessRequests)
+	reviews := router.Group("/reviews", a.identify(), a.scope("reviews"), a.inOrganization())
+	a.addReviewRouters(reviews)
+	oauth := router.Group("/oauth")
+	a.addOAuthRouters(oauth)
+	router.GET("/signingKeys", a.authenticate(), a.admin(), httpservice.GetSigningKeysHandler(a.keyManager))
+	router.POST("/signingKeys:method", a.authenticate(), a.admin(), httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"rotate": httpservice.RotateSigningKeyHandler(a.keyManager),
+	}))
+	router.POST("/users:method", a.identify(), a.scope("users"), a.inOrganization(), httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"import": httpservice.ImportUsersHandler(a.userImportService),
+	}))
+	router.GET("/users:method", a.identify(), a.scope("users"), a.inOrganization(), httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"export": httpservice.ExportUsersHandler(a.userImportService),
+	}))
+	router.POST("/groups:method", a.authenticate(), a.scope("groups"), a.inOrganization(), httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"previewRule": httpservice.PreviewRuleHandler(a.dynamicGroupService),
+	}))
+}
+
+// idempotent lets clients retry a POST with the same Idempotency-Key without
+// creating duplicates.
+func (a *AppConfiguration) idempotent() gin.HandlerFunc {
+	return httpservice.IdempotencyMiddleware(a.idempotencyStore, a.config.Idempotency.TTL)
+}
+
+// authenticate identifies the caller and refuses requests without token, for
+// endpoints that check the caller.
+func (a *AppConfiguration) authenticate() gin.HandlerFunc {
+	return httpservice.AuthenticationMiddleware(a.authService, true)
+}
+
+// admin stops callers who aren't admins who logged in.
+func (a *AppConfiguration) admin() gin.HandlerFunc {
+	return httpservice.AdminMiddleware(a.authService)
+}
+
+// identify identifies the caller if there is a token, for the organization
+// and the scopes of the request.
+func (a *AppConfiguration) identify() gin.HandlerFunc {
+	return httpservice.AuthenticationMiddleware(a.authService, false)
+}
+
+// scope limits callers using a personal access token to its scopes.
+func (a *AppConfiguration) scope(resource string) gin.HandlerFunc {
+	return httpservice.ScopeMiddleware(resource)
+}
+
+// inOrganization scopes users and groups to the organization of the request.
+func (a *AppConfiguration) inOrganization() gin.HandlerFunc {
+	return httpservice.OrganizationMiddleware(a.organizationService, false)
+}
+
+// loginOrganization lets the users of an organization log in, they have no
+// token to tell it by yet.
+func (a *AppConfiguration) loginOrganization() gin.HandlerFunc {
+	return httpservice.OrganizationMiddleware(a.organizationService, true)
+}
+
+// userInOrganization keeps services that don't know about organizations to
+// users of the organization of the request.
+func (a *AppConfiguration) userInOrganization() gin.HandlerFunc {
+	return httpservice.UserInOrganization(a.userService)
 }
 
 func (a *AppConfiguration) addUserRouters(router *gin.RouterGroup) {
-	router.POST("", httpservice.CreateUserHandler(a.userService))
+	router.POST("", a.idempotent(), httpservice.CreateUserHandler(a.userService))
+	router.GET("/:id", httpservice.GetUserHandler(a.userService))
 	router.PUT("/:id", httpservice.UpdateUserHandler(a.userService))
+	router.PATCH("/:id", httpservice.PatchUserHandler(a.userService))
 	router.DELETE("/:id", httpservice.DeleteUserHandler(a.userService))
+	router.POST("/:id/restore", httpservice.RestoreUserHandler(a.userService))
+	router.GET("/:id/export", a.authenticate(), a.userInOrganization(), httpservice.ExportUserHandler(a.privacyService))
+	router.POST("/:id/erase", a.authenticate(), a.userInOrganization(), httpservice.EraseUserHandler(a.privacyService))
 	router.GET("", httpservice.GetUsersHandler(a.userService))
 	router.PUT("/:id/password", httpservice.ChangePasswordHandler(a.userService))
+	router.POST("/:id/verification", httpservice.ResendVerificationHandler(a.userService))
+	router.POST("/:id/mfa", a.authenticate(), a.userInOrganization(), httpservice.EnrollMFAHandler(a.mfaService))
+	router.POST("/:id/mfa/activate", a.authenticate(), a.userInOrganization(), httpservice.ActivateMFAHandler(a.mfaService))
+	router.DELETE("/:id/mfa", a.authenticate(), a.userInOrganization(), httpservice.ResetMFAHandler(a.mfaService))
+	router.POST("/:id/unlock", a.authenticate(), a.userInOrganization(), httpservice.UnlockUserHandler(a.lockoutService))
+	router.POST("/:id/suspend", a.authenticate(), a.userInOrganization(), httpservice.SuspendUserHandler(a.userStatusService))
+	router.POST("/:id/reactivate", a.authenticate(), a.userInOrganization(), httpservice.ReactivateUserHandler(a.userStatusService))
+	router.POST("/:id/disable", a.authenticate(), a.userInOrganization(), httpservice.DisableUserHandler(a.userStatusService))
+	router.POST("/:id/passkeys/register/begin", a.authenticate(), a.userInOrganization(), httpservice.BeginPasskeyRegistrationHandler(a.passkeyService))
+	router.POST("/:id/passkeys/register/finish", a.authenticate(), a.userInOrganization(), httpservice.FinishPasskeyRegistrationHandler(a.passkeyService))
+	router.GET("/:id/passkeys", a.authenticate(), a.userInOrganization(), httpservice.GetPasskeysHandler(a.passkeyService))
+	router.PUT("/:id/passkeys/:passkeyid", a.authenticate(), a.userInOrganization(), httpservice.RenamePasskeyHandler(a.passkeyService))
+	router.DELETE("/:id/passkeys/:passkeyid", a.authenticate(), a.userInOrganization(), httpservice.DeletePasskeyHandler(a.passkeyService))
+	router.POST("/:id/tokens", a.authenticate(), a.userInOrganization(), httpservice.CreateAccessTokenHandler(a.accessTokenService))
+	router.GET("/:id/tokens", a.authenticate(), a.userInOrganization(), httpservice.GetAccessTokensHandler(a.accessTokenService))
+	router.DELETE("/:id/tokens/:tokenid", a.authenticate(), a.userInOrganization(), httpservice.RevokeAccessTokenHandler(a.accessTokenService))
 }
 
 func (a *AppConfiguration) addGroupRouters(router *gin.RouterGroup) {
-	router.POST("", httpservice.CreateGroupHandler(a.groupService))
+	router.POST("", a.authenticate(), a.idempotent(), httpservice.CreateGroupHandler(a.groupService))
+	router.GET("/:id", httpservice.GetGroupHandler(a.groupService))
 	router.PUT("/:id", httpservice.UpdateGroupHandler(a.groupService))
+	router.PATCH("/:id", httpservice.PatchGroupHandler(a.groupService))
 	router.DELETE("/:id", httpservice.DeleteGroupHandler(a.groupService))
+	router.POST("/:id/restore", httpservice.RestoreGroupHandler(a.groupService))
+	router.PUT("/:id/rule", a.authenticate(), httpservice.SetGroupRuleHandler(a.dynamicGroupService, a.groupService))
 	router.GET("/:id/users", httpservice.GetGroupUsersHandler(a.groupService))
 	router.GET("", httpservice.GetGroupsHandler(a.groupService))
-	router.POST("/:id/users", httpservice.AddUserHandler(a.groupService))
-	router.DELETE("/:id/users/:userid", httpservice.RemoveUserHandler(a.groupService))
+	router.POST("/:id/users", a.authenticate(), a.idempotent(), httpservice.AddUserHandler(a.groupService))
+	router.PUT("/:id/users", a.authenticate(), httpservice.ReplaceUsersHandler(a.groupService))
+	router.POST("/:id/users:method", a.authenticate(), httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"batchAdd":    httpservice.BatchAddUsersHandler(a.groupService),
+		"batchRemove": httpservice.BatchRemoveUsersHandler(a.groupService),
+	}))
+	router.DELETE("/:id/users/:userid", a.authenticate(), httpservice.RemoveUserHandler(a.groupService))
+	router.PUT("/:id/users/:userid/role", a.authenticate(), httpservice.SetMemberRoleHandler(a.groupService))
+}
+
+func (a *AppConfiguration) addAttributeRouters(router *gin.RouterGroup) {
+	router.GET("", httpservice.GetAttributesHandler(a.attributeService))
+	// the attributes are defined for every organization alike
+	router.PUT("/:name", a.authenticate(), a.admin(), httpservice.SaveAttributeHandler(a.attributeService))
+	router.DELETE("/:name", a.authenticate(), a.admin(), httpservice.DeleteAttributeHandler(a.attributeService))
+}
+
+func (a *AppConfiguration) addJobRouters(router *gin.RouterGroup) {
+	router.GET("/:id", a.authenticate(), httpservice.GetJobHandler(a.jobRunner))
+	router.POST("/:id/cancel", a.authenticate(), httpservice.CancelJobHandler(a.jobRunner))
+}
+
+func (a *AppConfiguration) addAccessRequestRouters(router *gin.RouterGroup) {
+	router.POST("", a.authenticate(), a.idempotent(), httpservice.RequestAccessHandler(a.accessRequestService))
+	router.GET("", a.authenticate(), httpservice.GetAccessRequestsHandler(a.accessRequestService))
+	router.GET("/:id", a.authenticate(), httpservice.GetAccessRequestHandler(a.accessRequestService))
+	router.POST("/:id/approve", a.authenticate(), httpservice.ApproveAccessHandler(a.accessRequestService))
+	router.POST("/:id/deny", a.authenticate(), httpservice.DenyAccessHandler(a.accessRequestService))
+}
+
+func (a *AppConfiguration) addReviewRouters(router *gin.RouterGroup) {
+	router.POST("", a.authenticate(), httpservice.CreateReviewCampaignHandler(a.accessReviewService))
+	router.GET("", httpservice.GetReviewCampaignsHandler(a.accessReviewService))
+	router.GET("/:id", httpservice.GetReviewCampaignHandler(a.accessReviewService))
+	router.POST("/:id/close", a.authenticate(), httpservice.CloseReviewCampaignHandler(a.accessReviewService))
+	router.GET("/:id/report", a.authenticate(), httpservice.ReviewReportHandler(a.accessReviewService))
+	router.GET("/:id/items", a.authenticate(), httpservice.GetReviewItemsHandler(a.accessReviewService))
+	router.POST("/:id/items/:itemid/certify", a.authenticate(), httpservice.CertifyReviewItemHandler(a.accessReviewService))
+	router.POST("/:id/items/:itemid/revoke", a.authenticate(), httpservice.RevokeReviewItemHandler(a.accessReviewService))
+}
+
+func (a *AppConfiguration) addOrganizationRouters(router *gin.RouterGroup) {
+	router.POST("", a.authenticate(), httpservice.CreateOrganizationHandler(a.organizationService))
+	router.GET("", httpservice.GetOrganizationsHandler(a.organizationService))
+	router.GET("/:id", httpservice.GetOrganizationHandler(a.organizationService))
+}
+
+func (a *AppConfiguration) addAuthRouters(router *gin.RouterGroup) {
+	router.POST("/login", httpservice.LoginHandler(a.authService))
+	router.POST("/login/mfa", httpservice.LoginMFAHandler(a.authService))
+	router.POST("/login/passkey/begin", httpservice.BeginPasskeyLoginHandler(a.passkeyService))
+	router.POST("/login/passkey/finish", httpservice.FinishPasskeyLoginHandler(a.passkeyService))
+	router.POST("/password/forgot", httpservice.ForgotPasswordHandler(a.authService))
+	router.POST("/password/reset", httpservice.ResetPasswordHandler(a.authService))
+	router.POST("/email/verify", httpservice.VerifyEmailHandler(a.authService))
+}
+
+func (a *AppConfiguration) addOAuthRouters(router *gin.RouterGroup) {
+	router.POST("/clients", a.authenticate(), httpservice.RegisterOAuthClientHandler(a.oauthService))
+	router.GET("/clients/:clientid", httpservice.GetOAuthClientHandler(a.oauthService))
+	router.GET("/authorize", a.identify(), httpservice.OAuthAuthorizeHandler(a.oauthService))
+	router.POST("/authorize", a.loginOrganization(), httpservice.OAuthLoginHandler(a.oauthService, a.authService))
+	router.POST("/token", httpservice.OAuthTokenHandler(a.oauthService))
+	router.POST("/introspect", httpservice.OAuthIntrospectHandler(a.oauthService))
+	router.POST("/revoke", httpservice.OAuthRevokeHandler(a.oauthService))
+	// userinfo always needs the access token the client was issued
+	userInfo := httpservice.AuthenticationMiddleware(a.authService, true)
+	router.GET("/userinfo", userInfo, httpservice.UserInfoHandler(a.oidcService))
+	router.POST("/userinfo", userInfo, httpservice.UserInfoHandler(a.oidcService))
 }
@@ -0,0 +1,117 @@
+package docs
+
+import (
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+)
+
+// swagger:route POST /accessRequests accessRequests requestAccessRequest
+// Request to join a group, its owners are notified or the admins if it has none.
+// With an access token the request is for the caller, unless it is an admin.
+// Pending requests expire after a week unless configured otherwise.
+// A retry by the same caller with the same Idempotency-Key replays the first response.
+// responses:
+//   201: accessRequestResponse
+//   400: serviceError
+//   401: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:route GET /accessRequests accessRequests getAccessRequestsRequest
+// Get access requests for groups of the caller's organization, of a user, for a group or with a status.
+// Admins get any requests, other callers their own or those for a group they own.
+// responses:
+//   200: accessRequestsResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route GET /accessRequests/{id} accessRequests getAccessRequestRequest
+// Get an access request for a group of the caller's organization.
+// The caller must be the requester, an owner of the group or an admin.
+// responses:
+//   200: accessRequestResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route POST /accessRequests/{id}/approve accessRequests approveAccessRequest
+// Approve a pending request and add the user to the group.
+// The caller must own the group. The user is told about the decision.
+// responses:
+//   200: accessRequestResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:route POST /accessRequests/{id}/deny accessRequests denyAccessRequest
+// Deny a pending request.
+// The caller must own the group. The user is told about the decision.
+// responses:
+//   200: accessRequestResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:response accessRequestResponse
+type accessRequestResponse struct {
+	// in:body
+	Body internal.AccessRequest
+}
+
+// swagger:response accessRequestsResponse
+type accessRequestsResponse struct {
+	// in:body
+	Body internal.AccessRequestsResponse
+}
+
+// swagger:parameters requestAccessRequest
+type requestAccessRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in: header
+	IdempotencyKey string `json:"Idempotency-Key"`
+	// in:body
+	Body httpservice.RequestAccess
+}
+
+// swagger:parameters getAccessRequestsRequest
+type getAccessRequestsRequest struct {
+	// in: query
+	Page uint `json:"page"`
+	// in: query
+	PerPage uint `json:"perPage"`
+	// in: query
+	UserID uint `json:"userId"`
+	// in: query
+	GroupID uint `json:"groupId"`
+	// pending, approved, denied or expired
+	// in: query
+	Status string `json:"status"`
+	// in: header
+	Authorization string `json:"Authorization"`
+}
+
+// swagger:parameters getAccessRequestRequest
+type getAccessRequestRequest struct {
+	// in:path
+	ID uint `json:"id"`
+	// in: header
+	Authorization string `json:"Authorization"`
+}
+
+// swagger:parameters approveAccessRequest denyAccessRequest
+type decideAccessRequest struct {
+	// in:path
+	ID uint `json:"id"`
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in:body
+	Body httpservice.DecideAccess
+}
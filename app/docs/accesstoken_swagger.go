@@ -0,0 +1,78 @@
+package docs
+
+import (
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+)
+
+// swagger:route POST /users/{id}/tokens tokens createAccessTokenRequest
+// Create a personal access token, the token is only shown in this response.
+// It is sent as a Bearer access token in place of the one from a login and limits the caller to its scopes.
+// Scopes are <resource>:read or <resource>:write of users, groups, organizations, accessRequests, reviews, attributes and jobs.
+// The caller must be the user or an admin and must have logged in.
+// responses:
+//   201: createdAccessTokenResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route GET /users/{id}/tokens tokens getAccessTokensRequest
+// List the personal access tokens of a user.
+// The caller must be the user or an admin and must have logged in.
+// responses:
+//   200: accessTokensResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route DELETE /users/{id}/tokens/{tokenid} tokens revokeAccessTokenRequest
+// Revoke a personal access token.
+// The caller must be the user or an admin and must have logged in.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:response createdAccessTokenResponse
+type createdAccessTokenResponse struct {
+	// in:body
+	Body internal.CreatedAccessToken
+}
+
+// swagger:response accessTokensResponse
+type accessTokensResponse struct {
+	// in:body
+	Body internal.AccessTokensResponse
+}
+
+// swagger:parameters createAccessTokenRequest
+type createAccessTokenRequest struct {
+	// in:path
+	ID uint `json:"id"`
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in:body
+	Body httpservice.CreateAccessToken
+}
+
+// swagger:parameters getAccessTokensRequest
+type getAccessTokensRequest struct {
+	// in:path
+	ID uint `json:"id"`
+	// in: header
+	Authorization string `json:"Authorization"`
+}
+
+// swagger:parameters revokeAccessTokenRequest
+type revokeAccessTokenRequest struct {
+	// in:path
+	ID uint `json:"id"`
+	// in:path
+	TokenID uint `json:"tokenid"`
+	// in: header
+	Authorization string `json:"Authorization"`
+}
@@ -0,0 +1,55 @@
+package docs
+
+import (
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+)
+
+// swagger:route GET /attributes attributes getAttributesRequest
+// Get the definitions of custom user attributes.
+// responses:
+//   200: getAttributesResponse
+//   500: serviceError
+
+// swagger:route PUT /attributes/{name} attributes saveAttributeRequest
+// Create or replace the definition of a custom user attribute. Values users already have are checked on their next update.
+// The definitions apply to every organization, the caller must be an admin.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route DELETE /attributes/{name} attributes deleteAttributeRequest
+// Delete the definition of a custom user attribute. The caller must be an admin.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:response getAttributesResponse
+type getAttributesResponse struct {
+	// in:body
+	Body internal.AttributeDefinitionsResponse
+}
+
+// swagger:parameters saveAttributeRequest
+type saveAttributeRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in: path
+	Name string `json:"name"`
+	// in:body
+	Body httpservice.SaveAttribute
+}
+
+// swagger:parameters deleteAttributeRequest
+type deleteAttributeRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in: path
+	Name string `json:"name"`
+}
@@ -0,0 +1,84 @@
+package docs
+
+import (
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+)
+
+// swagger:route POST /auth/login auth loginRequest
+// Login with email and password. When MFA is enabled an mfa token is returned instead of an access token.
+// Access tokens are JWTs of type at+jwt signed with the active signing key, the kid header names it.
+// responses:
+//   200: loginResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   429: serviceError
+//   500: serviceError
+
+// swagger:route POST /auth/login/mfa auth loginMFARequest
+// Complete a login with a TOTP or recovery code.
+// responses:
+//   200: loginResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   429: serviceError
+//   500: serviceError
+
+// swagger:route POST /auth/password/forgot auth forgotPasswordRequest
+// Request a password reset token. The mail is queued, the response is the same whether the email is known or not.
+// responses:
+//   202:
+//   400: serviceError
+//   500: serviceError
+
+// swagger:route POST /auth/password/reset auth resetPasswordRequest
+// Reset password with a token received through forgot password.
+// responses:
+//   200:
+//   400: serviceError
+//   500: serviceError
+
+// swagger:route POST /auth/email/verify auth verifyEmailRequest
+// Verify an email with a token sent on signup or email change.
+// responses:
+//   200:
+//   400: serviceError
+//   500: serviceError
+
+// swagger:response loginResponse
+type loginResponse struct {
+	// in:body
+	Body internal.LoginResponse
+}
+
+// swagger:parameters loginRequest
+type loginRequest struct {
+	// in:body
+	Body httpservice.Login
+}
+
+// swagger:parameters loginMFARequest
+type loginMFARequest struct {
+	// in:body
+	Body httpservice.LoginMFA
+}
+
+// swagger:parameters forgotPasswordRequest
+type forgotPasswordRequest struct {
+	// in:body
+	Body httpservice.ForgotPassword
+}
+
+// swagger:parameters resetPasswordRequest
+type resetPasswordRequest struct {
+	// in:body
+	Body httpservice.ResetPassword
+}
+
+// swagger:parameters verifyEmailRequest
+type verifyEmailRequest struct {
+	// in:body
+	Body httpservice.VerifyEmail
+}
@@ -7,53 +7,181 @@ import (
 
 // swagger:route POST /groups groups createGroupRequest
 // Create new group.
+// The members of a dynamic group are the users matching its rule, such as department = "Sales" or email endsWith "@eng.example.com".
+// They follow created and updated users and are reconciled periodically, they can't be changed by hand.
+// The caller becomes its first owner, only an admin can name another user with ownerId.
+// A retry by the same caller with the same Idempotency-Key replays the first response.
 // responses:
 //   201: createGroupResponse
 //   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   422: serviceError
+//   500: serviceError
+
+// swagger:route GET /groups/{id} groups getGroupRequest
+// Get a group, the ETag header holds its version.
+// responses:
+//   200: createGroupResponse
+//   304:
+//   400: serviceError
 //   500: serviceError
 
 // swagger:route PUT /groups/{id} groups updateGroupRequest
-// Update  a group.
+// Update  a group, only while at the version given in If-Match if there is one.
 // responses:
 //   200:
 //   400: serviceError
+//   412: serviceError
+//   500: serviceError
+
+// swagger:route PATCH /groups/{id} groups patchGroupRequest
+// Patch group with a JSON merge patch (application/merge-patch+json) or a JSON patch (application/json-patch+json).
+// consumes:
+//   - application/merge-patch+json
+//   - application/json-patch+json
+// responses:
+//   200: createGroupResponse
+//   400: serviceError
+//   409: serviceError
+//   412: serviceError
+//   415: serviceError
 //   500: serviceError
 
 // swagger:route DELETE /groups/{id} groups deleteGroupRequest
-// Delete a group.
+// Delete a group, only while at the version given in If-Match if there is one.
+// responses:
+//   200:
+//   400: serviceError
+//   412: serviceError
+//   500: serviceError
+
+// swagger:route POST /groups/{id}/restore groups restoreGroupRequest
+// Restore a deleted group with the memberships deleted with it.
 // responses:
 //   200:
 //   400: serviceError
 //   500: serviceError
 
 // swagger:route GET /groups groups getGroupsRequest
-// Get groups.
+// Get groups, or only the deleted ones or those of a type.
 // responses:
 //   200: getGroupsResponse
+//   304:
+//   400: serviceError
+//   500: serviceError
+
+// swagger:route PUT /groups/{id}/rule groups setGroupRuleRequest
+// Change the rule of a dynamic group, its members change with it. The caller must own the group.
+// responses:
+//   200: batchMembershipResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route POST /groups:previewRule groups previewRuleRequest
+// List the users of the caller's organization a rule matches without changing any group.
+// The caller must be an admin or own a group.
+// responses:
+//   200: getUsersResponse
 //   400: serviceError
+//   401: serviceError
+//   403: serviceError
 //   500: serviceError
 
 // swagger:route GET /groups/{id}/users groups getGroupUsersRequest
 // Get group users.
 // responses:
 //   200: getUsersResponse
+//   304:
 //   400: serviceError
 //   500: serviceError
 
 // swagger:route POST /groups/{id}/users groups addUserRequest
 // Add user to a group.
+// With expiresAt the membership is removed once it passed, with startsAt the user is only listed as member from then on.
+// A retry by the same caller with the same Idempotency-Key replays the first response.
+// The caller must own or manage the group.
+// Members of a dynamic group can't be changed by hand.
 // responses:
 //   200:
 //   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   422: serviceError
 //   500: serviceError
 
 // swagger:route DELETE /groups/{id}/users/{userid} groups removeUserRequest
 // Remove user from a group.
+// The caller must own the group, or manage it and the user be a plain member.
+// The last owner of a group can't be removed.
+// Members of a dynamic group can't be changed by hand.
 // responses:
 //   200:
 //   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
 //   500: serviceError
 
+// swagger:route POST /groups/{id}/users:batchAdd groups batchAddUsersRequest
+// Add up to 10000 users to a group in one transaction.
+// Every user is reported as added, alreadyMember, unknownUser or memberOfOtherGroup.
+// The caller must own the group.
+// Members of a dynamic group can't be changed by hand.
+// responses:
+//   200: batchMembershipResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:route POST /groups/{id}/users:batchRemove groups batchRemoveUsersRequest
+// Remove up to 10000 users from a group in one transaction.
+// Every user is reported as removed, notMember or lastOwner.
+// The caller must own the group.
+// Members of a dynamic group can't be changed by hand.
+// responses:
+//   200: batchMembershipResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:route PUT /groups/{id}/users groups replaceUsersRequest
+// Replace the members of a group in one transaction, members not listed are removed.
+// The caller must own the group.
+// Members of a dynamic group can't be changed by hand.
+// responses:
+//   200: batchMembershipResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:route PUT /groups/{id}/users/{userid}/role groups setMemberRoleRequest
+// Set the role of a member to member, manager or owner.
+// The caller must own the group. The last owner can't step down.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:response batchMembershipResponse
+type batchMembershipResponse struct {
+	// in:body
+	Body internal.BatchMembershipResponse
+}
+
 // swagger:response createGroupResponse
 type createGroupResponse struct {
 	// in:body
@@ -68,22 +196,54 @@ type getGroupsResponse struct {
 
 // swagger:parameters createGroupRequest
 type createGroupRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in: header
+	IdempotencyKey string `json:"Idempotency-Key"`
 	// in:body
 	Body httpservice.CreateGroup
 }
 
+// swagger:parameters getGroupRequest
+type getGroupRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in: header
+	IfNoneMatch string `json:"If-None-Match"`
+}
+
 // swagger:parameters updateGroupRequest
 type updateGroupRequest struct {
 	// in: path
 	Id uint `json:"id"`
+	// in: header
+	IfMatch string `json:"If-Match"`
 	// in:body
 	Body httpservice.UpdateGroup
 }
 
+// swagger:parameters patchGroupRequest
+type patchGroupRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in: header
+	IfMatch string `json:"If-Match"`
+	// in:body
+	Body httpservice.PatchGroup
+}
+
 // swagger:parameters deleteGroupRequest
 type deleteGroupRequest struct {
 	// in: path
 	Id uint `json:"id"`
+	// in: header
+	IfMatch string `json:"If-Match"`
+}
+
+// swagger:parameters restoreGroupRequest
+type restoreGroupRequest struct {
+	// in: path
+	Id uint `json:"id"`
 }
 
 // swagger:parameters getGroupUsersRequest
@@ -94,6 +254,8 @@ type getGroupUsersRequest struct {
 	Page uint `json:"page"`
 	// in: query
 	PerPage uint `json:"perPage"`
+	// in: header
+	IfNoneMatch string `json:"If-None-Match"`
 }
 
 // swagger:parameters getGroupsRequest
@@ -102,12 +264,43 @@ type getGroupsRequest struct {
 	Page uint `json:"page"`
 	// in: query
 	PerPage uint `json:"perPage"`
+	// in: query
+	Deleted bool `json:"deleted"`
+	// static or dynamic
+	// in: query
+	Type string `json:"type"`
+	// in: header
+	IfNoneMatch string `json:"If-None-Match"`
+}
+
+// swagger:parameters setGroupRuleRequest
+type setGroupRuleRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in: path
+	Id uint `json:"id"`
+	// in:body
+	Body httpservice.GroupRule
+}
+
+// swagger:parameters previewRuleRequest
+type previewRuleRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in: query
+	Page uint `json:"page"`
+	// in: query
+	PerPage uint `json:"perPage"`
+	// in:body
+	Body httpservice.GroupRule
 }
 
 // swagger:parameters addUserRequest
 type addUserRequest struct {
 	// in: path
 	Id uint `json:"id"`
+	// in: header
+	IdempotencyKey string `json:"Idempotency-Key"`
 	// in:body
 	Body httpservice.AddUser
 }
@@ -119,3 +312,36 @@ type removeUserRequest struct {
 	// in: path
 	UserID uint `json:"userid"`
 }
+
+// swagger:parameters batchAddUsersRequest batchRemoveUsersRequest
+type batchUsersRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in:body
+	Body httpservice.BatchUsers
+}
+
+// swagger:parameters replaceUsersRequest
+type replaceUsersRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in:body
+	Body httpservice.ReplaceUsers
+}
+
+// swagger:parameters setMemberRoleRequest
+type setMemberRoleRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in: path
+	UserID uint `json:"userid"`
+	// in:body
+	Body httpservice.MemberRole
+}
+
+// swagger:parameters addUserRequest removeUserRequest batchAddUsersRequest batchRemoveUsersRequest replaceUsersRequest setMemberRoleRequest
+type membershipAuthorization struct {
+	// Bearer access token of the caller, required when the server requires tokens
+	// in: header
+	Authorization string `json:"Authorization"`
+}
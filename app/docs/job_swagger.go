@@ -0,0 +1,40 @@
+package docs
+
+import "usermanagement/app/internal"
+
+// swagger:route GET /jobs/{id} jobs getJobRequest
+// Get the status, progress and result of an asynchronous job.
+// Only the user who started the job and admins can follow it.
+// responses:
+//   200: jobResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route POST /jobs/{id}/cancel jobs cancelJobRequest
+// Cancel a job. A queued job is cancelled at once, a running job stops soon after and keeps its partial result.
+// Only the user who started the job and admins can cancel it.
+// responses:
+//   200: jobResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:response jobResponse
+type jobResponse struct {
+	// in: header
+	Location string `json:"Location"`
+	// in:body
+	Body internal.Job
+}
+
+// swagger:parameters getJobRequest cancelJobRequest
+type jobRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in:path
+	ID uint `json:"id"`
+}
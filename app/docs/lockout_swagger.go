@@ -0,0 +1,17 @@
+package docs
+
+// swagger:route POST /users/{id}/unlock users unlockUserRequest
+// Clear the failed login attempts of a user and lift a lockout.
+// The caller must be an admin and must have logged in.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:parameters unlockUserRequest
+type unlockUserRequest struct {
+	// in: path
+	Id uint `json:"id"`
+}
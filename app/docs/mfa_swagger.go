@@ -0,0 +1,65 @@
+package docs
+
+import (
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+)
+
+// swagger:route POST /users/{id}/mfa mfa enrollMFARequest
+// Start MFA enrollment. Returns the TOTP secret, otpauth URI and QR code, or only the QR PNG with Accept: image/png.
+// produces:
+// - application/json
+// - image/png
+// The caller must be the user and must have logged in.
+// responses:
+//   201: enrollMFAResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route POST /users/{id}/mfa/activate mfa activateMFARequest
+// Activate MFA with a code from the authenticator. Recovery codes are only returned here.
+// The caller must be the user and must have logged in.
+// responses:
+//   200: recoveryCodesResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route DELETE /users/{id}/mfa mfa resetMFARequest
+// Reset MFA of a user, removing the secret and recovery codes.
+// The caller must be an admin and must have logged in.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:response enrollMFAResponse
+type enrollMFAResponse struct {
+	// in:body
+	Body internal.MFAEnrollment
+}
+
+// swagger:response recoveryCodesResponse
+type recoveryCodesResponse struct {
+	// in:body
+	Body internal.RecoveryCodesResponse
+}
+
+// swagger:parameters enrollMFARequest resetMFARequest
+type mfaUserRequest struct {
+	// in: path
+	Id uint `json:"id"`
+}
+
+// swagger:parameters activateMFARequest
+type activateMFARequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in:body
+	Body httpservice.ActivateMFA
+}
@@ -0,0 +1,220 @@
+package docs
+
+import (
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+)
+
+// swagger:route POST /oauth/clients oauth registerOAuthClientRequest
+// Register an OAuth client, the secret of a confidential client is only shown in this response.
+// Public clients may only use the authorization_code grant, client_credentials needs a confidential client acting as a service account.
+// Scopes are those of personal access tokens.
+// The caller must be an admin and must have logged in.
+// responses:
+//   201: registeredOAuthClientResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route GET /oauth/clients/{clientid} oauth getOAuthClientRequest
+// Get an OAuth client.
+// responses:
+//   200: oauthClientResponse
+//   400: serviceError
+//   500: serviceError
+
+// swagger:route GET /oauth/authorize oauth oauthAuthorizeRequest
+// The authorization endpoint, the user grants the client an authorization code with the access token of their login.
+// Without it, as from a browser, an HTML page is shown to log in and consent, which posts to the same path.
+// PKCE with code_challenge_method S256 is required. The user is redirected to the redirect_uri with the code and state,
+// or with error and error_description. Errors about the client or the redirect_uri are not redirected.
+// produces:
+//   - text/html
+// responses:
+//   200:
+//   302:
+//   400: oauthError
+//   500: serviceError
+
+// swagger:route POST /oauth/authorize oauth oauthLoginRequest
+// The login and consent page of the authorization endpoint, the user logs in with email and password and,
+// if MFA is enabled, the code on a second page. With action allow the user is redirected as from GET /oauth/authorize,
+// with action deny the redirect has error access_denied. A failed login shows the page again with the reason.
+// consumes:
+//   - application/x-www-form-urlencoded
+// produces:
+//   - text/html
+// responses:
+//   200:
+//   302:
+//   400: oauthError
+//   401:
+//   429:
+//   500: serviceError
+
+// swagger:route POST /oauth/token oauth oauthTokenRequest
+// The token endpoint for the authorization_code and client_credentials grants.
+// Clients authenticate with HTTP basic authentication or client_id and client_secret, public clients with client_id only.
+// Access tokens are sent as Bearer access tokens and limit the caller to their scopes.
+// An authorization code granted the openid scope also returns an id_token.
+// consumes:
+//   - application/x-www-form-urlencoded
+// responses:
+//   200: oauthTokenResponse
+//   400: oauthError
+//   401: oauthError
+//   500: serviceError
+
+// swagger:route POST /oauth/introspect oauth oauthIntrospectRequest
+// Token introspection as of RFC 7662, only confidential clients may introspect.
+// consumes:
+//   - application/x-www-form-urlencoded
+// responses:
+//   200: oauthIntrospectionResponse
+//   400: oauthError
+//   401: oauthError
+//   500: serviceError
+
+// swagger:route POST /oauth/revoke oauth oauthRevokeRequest
+// Token revocation as of RFC 7009, a client can only revoke its own tokens. Unknown tokens are no error.
+// consumes:
+//   - application/x-www-form-urlencoded
+// responses:
+//   200:
+//   400: oauthError
+//   401: oauthError
+//   500: serviceError
+
+// swagger:response registeredOAuthClientResponse
+type registeredOAuthClientResponse struct {
+	// in:body
+	Body internal.RegisteredOAuthClient
+}
+
+// swagger:response oauthClientResponse
+type oauthClientResponse struct {
+	// in:body
+	Body internal.OAuthClient
+}
+
+// swagger:response oauthTokenResponse
+type oauthTokenResponse struct {
+	// in:body
+	Body internal.OAuthTokenResponse
+}
+
+// swagger:response oauthIntrospectionResponse
+type oauthIntrospectionResponse struct {
+	// in:body
+	Body internal.OAuthIntrospection
+}
+
+// swagger:response oauthError
+type oauthError struct {
+	// in:body
+	Body struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+}
+
+// swagger:parameters registerOAuthClientRequest
+type registerOAuthClientRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in:body
+	Body httpservice.RegisterOAuthClient
+}
+
+// swagger:parameters getOAuthClientRequest
+type getOAuthClientRequest struct {
+	// in:path
+	ClientID string `json:"clientid"`
+}
+
+// swagger:parameters oauthAuthorizeRequest
+type oauthAuthorizeRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in:query
+	ResponseType string `json:"response_type"`
+	// in:query
+	ClientID string `json:"client_id"`
+	// in:query
+	RedirectURI string `json:"redirect_uri"`
+	// in:query
+	Scope string `json:"scope"`
+	// in:query
+	State string `json:"state"`
+	// in:query
+	CodeChallenge string `json:"code_challenge"`
+	// in:query
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	// in:query
+	Nonce string `json:"nonce"`
+}
+
+// swagger:parameters oauthLoginRequest
+type oauthLoginRequest struct {
+	// in:formData
+	ResponseType string `json:"response_type"`
+	// in:formData
+	ClientID string `json:"client_id"`
+	// in:formData
+	RedirectURI string `json:"redirect_uri"`
+	// in:formData
+	Scope string `json:"scope"`
+	// in:formData
+	State string `json:"state"`
+	// in:formData
+	CodeChallenge string `json:"code_challenge"`
+	// in:formData
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	// in:formData
+	Nonce string `json:"nonce"`
+	// in:formData
+	Email string `json:"email"`
+	// in:formData
+	Password string `json:"password"`
+	// in:formData
+	MFAToken string `json:"mfa_token"`
+	// in:formData
+	Code string `json:"code"`
+	// in:formData
+	Action string `json:"action"`
+}
+
+// swagger:parameters oauthTokenRequest
+type oauthTokenRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in:formData
+	GrantType string `json:"grant_type"`
+	// in:formData
+	ClientID string `json:"client_id"`
+	// in:formData
+	ClientSecret string `json:"client_secret"`
+	// in:formData
+	Code string `json:"code"`
+	// in:formData
+	RedirectURI string `json:"redirect_uri"`
+	// in:formData
+	CodeVerifier string `json:"code_verifier"`
+	// in:formData
+	Scope string `json:"scope"`
+}
+
+// swagger:parameters oauthIntrospectRequest oauthRevokeRequest
+type oauthTokenActionRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in:formData
+	Token string `json:"token"`
+	// in:formData
+	TokenTypeHint string `json:"token_type_hint"`
+	// in:formData
+	ClientID string `json:"client_id"`
+	// in:formData
+	ClientSecret string `json:"client_secret"`
+}
@@ -0,0 +1,45 @@
+package docs
+
+import "usermanagement/app/internal"
+
+// swagger:route GET /oauth/userinfo oidc userInfoRequest
+// The OpenID Connect userinfo endpoint, answers the claims of the scopes of the access token.
+// The access token must have the openid scope, profile adds the name, email the email and groups the active groups.
+// The discovery document at /.well-known/openid-configuration and the signing keys at /jwks.json are served outside the base path.
+// responses:
+//   200: userInfoResponse
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route POST /oauth/userinfo oidc userInfoRequest
+// The OpenID Connect userinfo endpoint, same as GET.
+// responses:
+//   200: userInfoResponse
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:response userInfoResponse
+type userInfoResponse struct {
+	// in:body
+	Body internal.UserInfo
+}
+
+// swagger:response oidcDiscoveryResponse
+type oidcDiscoveryResponse struct {
+	// in:body
+	Body internal.OIDCDiscovery
+}
+
+// swagger:response jsonWebKeySetResponse
+type jsonWebKeySetResponse struct {
+	// in:body
+	Body internal.JSONWebKeySet
+}
+
+// swagger:parameters userInfoRequest
+type userInfoRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+}
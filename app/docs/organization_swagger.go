@@ -0,0 +1,71 @@
+package docs
+
+import (
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+)
+
+// swagger:route POST /organizations organizations createOrganizationRequest
+// Create an organization, users and groups of one organization are hidden from the others.
+// Requests to /users, /groups and /auth are for the organization of the caller's access token,
+// or the one named in the X-Org-ID header, which only admins may set to another organization.
+// Without access token only requests to /auth may name one, requests without access token naming none are for the
+// default organization. Only admins naming none are served across organizations. The caller must be an admin.
+// responses:
+//   201: organizationResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route GET /organizations organizations getOrganizationsRequest
+// Get organizations. The caller must be an admin.
+// responses:
+//   200: organizationsResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route GET /organizations/{id} organizations getOrganizationRequest
+// Get an organization, callers who aren't admins only find their own.
+// responses:
+//   200: organizationResponse
+//   400: serviceError
+//   500: serviceError
+
+// swagger:response organizationResponse
+type organizationResponse struct {
+	// in:body
+	Body internal.Organization
+}
+
+// swagger:response organizationsResponse
+type organizationsResponse struct {
+	// in:body
+	Body internal.OrganizationsResponse
+}
+
+// swagger:parameters createOrganizationRequest
+type createOrganizationRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in:body
+	Body httpservice.CreateOrganization
+}
+
+// swagger:parameters getOrganizationsRequest
+type getOrganizationsRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in: query
+	Page uint `json:"page"`
+	// in: query
+	PerPage uint `json:"perPage"`
+}
+
+// swagger:parameters getOrganizationRequest
+type getOrganizationRequest struct {
+	// in:path
+	ID uint `json:"id"`
+}
@@ -0,0 +1,141 @@
+package docs
+
+import (
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/webauthn"
+)
+
+// swagger:route POST /users/{id}/passkeys/register/begin passkeys beginPasskeyRegistrationRequest
+// Start registering a passkey. Returns PublicKeyCredentialCreationOptions for navigator.credentials.create().
+// The caller must be the user or an admin and must have logged in.
+// responses:
+//   200: passkeyCreationOptionsResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route POST /users/{id}/passkeys/register/finish passkeys finishPasskeyRegistrationRequest
+// Finish registering a passkey with the credential returned by the authenticator.
+// The caller must be the user or an admin and must have logged in.
+// responses:
+//   201: passkeyResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route GET /users/{id}/passkeys passkeys getPasskeysRequest
+// List the passkeys of a user.
+// The caller must be the user or an admin and must have logged in.
+// responses:
+//   200: passkeysResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route PUT /users/{id}/passkeys/{passkeyid} passkeys renamePasskeyRequest
+// Rename a passkey.
+// The caller must be the user or an admin and must have logged in.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route DELETE /users/{id}/passkeys/{passkeyid} passkeys deletePasskeyRequest
+// Revoke a passkey.
+// The caller must be the user or an admin and must have logged in.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route POST /auth/login/passkey/begin auth beginPasskeyLoginRequest
+// Start a passkey login with the discoverable passkeys of the authenticator. The options never list passkeys, an email only binds the login to its user.
+// responses:
+//   200: passkeyRequestOptionsResponse
+//   400: serviceError
+//   500: serviceError
+
+// swagger:route POST /auth/login/passkey/finish auth finishPasskeyLoginRequest
+// Finish a passkey login with the assertion returned by the authenticator.
+// responses:
+//   200: loginResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:response passkeyCreationOptionsResponse
+type passkeyCreationOptionsResponse struct {
+	// in:body
+	Body webauthn.CreationOptions
+}
+
+// swagger:response passkeyRequestOptionsResponse
+type passkeyRequestOptionsResponse struct {
+	// in:body
+	Body webauthn.RequestOptions
+}
+
+// swagger:response passkeyResponse
+type passkeyResponse struct {
+	// in:body
+	Body internal.PasskeyResponse
+}
+
+// swagger:response passkeysResponse
+type passkeysResponse struct {
+	// in:body
+	Body internal.PasskeysResponse
+}
+
+// swagger:parameters beginPasskeyRegistrationRequest getPasskeysRequest
+type passkeyUserRequest struct {
+	// in: path
+	Id uint `json:"id"`
+}
+
+// swagger:parameters finishPasskeyRegistrationRequest
+type finishPasskeyRegistrationRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in:body
+	Body httpservice.FinishPasskeyRegistration
+}
+
+// swagger:parameters renamePasskeyRequest
+type renamePasskeyRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in: path
+	PasskeyID uint `json:"passkeyid"`
+	// in:body
+	Body httpservice.RenamePasskey
+}
+
+// swagger:parameters deletePasskeyRequest
+type deletePasskeyRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in: path
+	PasskeyID uint `json:"passkeyid"`
+}
+
+// swagger:parameters beginPasskeyLoginRequest
+type beginPasskeyLoginRequest struct {
+	// in:body
+	Body httpservice.BeginPasskeyLogin
+}
+
+// swagger:parameters finishPasskeyLoginRequest
+type finishPasskeyLoginRequest struct {
+	// in:body
+	Body webauthn.Assertion
+}
@@ -0,0 +1,50 @@
+package docs
+
+import (
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+)
+
+// swagger:route GET /users/{id}/export users exportUserRequest
+// Export everything kept about a user as a JSON attachment. The caller must be the user or an admin.
+// responses:
+//   200: userExportResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route POST /users/{id}/erase users eraseUserRequest
+// Irreversibly anonymize a user and its audit trail. Unlike delete it can't be restored.
+// The caller must be the user or an admin. The last owner of a group can't be erased.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:response userExportResponse
+type userExportResponse struct {
+	// in:body
+	Body internal.UserExport
+}
+
+// swagger:parameters exportUserRequest
+type exportUserRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in: path
+	Id uint `json:"id"`
+}
+
+// swagger:parameters eraseUserRequest
+type eraseUserRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in: path
+	Id uint `json:"id"`
+	// in:body
+	Body httpservice.EraseUser
+}
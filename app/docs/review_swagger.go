@@ -0,0 +1,186 @@
+package docs
+
+import (
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+)
+
+// swagger:route POST /reviews reviews createReviewCampaignRequest
+// Start an access review campaign over the current members of static groups.
+// Each membership is reviewed by one of the given reviewers in turn, or else by an owner of its group.
+// Memberships no one else can review are left to the admins. The caller must be an admin.
+// responses:
+//   201: reviewCampaignResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route GET /reviews reviews getReviewCampaignsRequest
+// Get review campaigns, optionally with a status.
+// responses:
+//   200: reviewCampaignsResponse
+//   400: serviceError
+//   500: serviceError
+
+// swagger:route GET /reviews/{id} reviews getReviewCampaignRequest
+// Get a review campaign with a summary of its decisions.
+// responses:
+//   200: reviewCampaignResponse
+//   400: serviceError
+//   500: serviceError
+
+// swagger:route POST /reviews/{id}/close reviews closeReviewCampaignRequest
+// Close a campaign before its deadline, campaigns are closed at their deadline otherwise.
+// If the campaign revokes undecided memberships they are removed, the rest is marked undecided.
+// The caller must be an admin.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:route GET /reviews/{id}/report reviews reviewReportRequest
+// Download the signed report of a campaign of the caller's organization.
+// The report bytes are signed with the signing key the kid names among the published JSON Web Keys.
+// The caller must be an admin.
+// responses:
+//   200: reviewReportResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route GET /reviews/{id}/items reviews getReviewItemsRequest
+// Get the memberships under review in a campaign of the caller's organization, of a reviewer or with a decision.
+// Admins get the items of every reviewer, other callers only their own.
+// responses:
+//   200: reviewItemsResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route POST /reviews/{id}/items/{itemid}/certify reviews certifyReviewItemRequest
+// Certify a membership and keep it.
+// The caller must be its reviewer or an admin, no one reviews their own membership.
+// responses:
+//   200: reviewItemResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:route POST /reviews/{id}/items/{itemid}/revoke reviews revokeReviewItemRequest
+// Revoke a membership and remove the user from the group.
+// The caller must be its reviewer or an admin, no one reviews their own membership.
+// responses:
+//   200: reviewItemResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:response reviewCampaignResponse
+type reviewCampaignResponse struct {
+	// in:body
+	Body internal.ReviewCampaign
+}
+
+// swagger:response reviewCampaignsResponse
+type reviewCampaignsResponse struct {
+	// in:body
+	Body internal.ReviewCampaignsResponse
+}
+
+// swagger:response reviewItemResponse
+type reviewItemResponse struct {
+	// in:body
+	Body internal.ReviewItem
+}
+
+// swagger:response reviewItemsResponse
+type reviewItemsResponse struct {
+	// in:body
+	Body internal.ReviewItemsResponse
+}
+
+// swagger:response reviewReportResponse
+type reviewReportResponse struct {
+	// in:body
+	Body internal.SignedReviewReport
+}
+
+// swagger:parameters createReviewCampaignRequest
+type createReviewCampaignRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in:body
+	Body httpservice.CreateReviewCampaign
+}
+
+// swagger:parameters getReviewCampaignsRequest
+type getReviewCampaignsRequest struct {
+	// in: query
+	Page uint `json:"page"`
+	// in: query
+	PerPage uint `json:"perPage"`
+	// open or closed
+	// in: query
+	Status string `json:"status"`
+}
+
+// swagger:parameters getReviewCampaignRequest
+type getReviewCampaignRequest struct {
+	// in:path
+	ID uint `json:"id"`
+}
+
+// swagger:parameters reviewReportRequest
+type reviewReportRequest struct {
+	// in:path
+	ID uint `json:"id"`
+	// in: header
+	Authorization string `json:"Authorization"`
+}
+
+// swagger:parameters closeReviewCampaignRequest
+type closeReviewCampaignRequest struct {
+	// in:path
+	ID uint `json:"id"`
+	// in: header
+	Authorization string `json:"Authorization"`
+}
+
+// swagger:parameters getReviewItemsRequest
+type getReviewItemsRequest struct {
+	// in:path
+	ID uint `json:"id"`
+	// in: query
+	Page uint `json:"page"`
+	// in: query
+	PerPage uint `json:"perPage"`
+	// in: query
+	ReviewerID uint `json:"reviewerId"`
+	// pending, certified, revoked or undecided
+	// in: query
+	Decision string `json:"decision"`
+	// in: header
+	Authorization string `json:"Authorization"`
+}
+
+// swagger:parameters certifyReviewItemRequest revokeReviewItemRequest
+type decideReviewItemRequest struct {
+	// in:path
+	ID uint `json:"id"`
+	// in:path
+	ItemID uint `json:"itemid"`
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in:body
+	Body httpservice.ReviewComment
+}
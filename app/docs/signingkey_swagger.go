@@ -0,0 +1,40 @@
+package docs
+
+import "usermanagement/app/internal"
+
+// swagger:route GET /signingKeys signingKeys getSigningKeysRequest
+// List the keys tokens are signed with, newest first and without their private keys.
+// The active key signs, retired keys verify the tokens they signed until expiresAt.
+// The caller must be an admin and must have logged in.
+// responses:
+//   200: signingKeysResponse
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:route POST /signingKeys:rotate signingKeys rotateSigningKeyRequest
+// Make a new key sign ahead of schedule, the key before is retired and keeps verifying until the tokens it signed expired.
+// The caller must be an admin and must have logged in.
+// responses:
+//   201: signingKeyResponse
+//   401: serviceError
+//   403: serviceError
+//   500: serviceError
+
+// swagger:response signingKeysResponse
+type signingKeysResponse struct {
+	// in:body
+	Body internal.SigningKeysResponse
+}
+
+// swagger:response signingKeyResponse
+type signingKeyResponse struct {
+	// in:body
+	Body internal.SigningKeyInfo
+}
+
+// swagger:parameters getSigningKeysRequest rotateSigningKeyRequest
+type signingKeysRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+}
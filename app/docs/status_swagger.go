@@ -0,0 +1,52 @@
+package docs
+
+import "usermanagement/app/internal/httpservice"
+
+// swagger:route POST /users/{id}/suspend users suspendUserRequest
+// Suspend a user. Without an end the suspension lasts until the user is reactivated.
+// The caller must be an admin and must have logged in.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:route POST /users/{id}/reactivate users reactivateUserRequest
+// Activate a suspended, disabled or pending user.
+// The caller must be an admin and must have logged in.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:route POST /users/{id}/disable users disableUserRequest
+// Disable a user.
+// The caller must be an admin and must have logged in.
+// responses:
+//   200:
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   409: serviceError
+//   500: serviceError
+
+// swagger:parameters suspendUserRequest
+type suspendUserRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in:body
+	Body httpservice.SuspendUser
+}
+
+// swagger:parameters reactivateUserRequest disableUserRequest
+type changeUserStatusRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in:body
+	Body httpservice.ChangeUserStatus
+}
@@ -6,30 +6,97 @@ import (
 )
 
 // swagger:route POST /users users createUserRequest
-// Create new user.
+// Create new user. A service account, with type service, has no password and authenticates with personal access tokens.
+// A retry by the same caller with the same Idempotency-Key replays the first response.
 // responses:
 //   201: createUserResponse
 //   400: serviceError
+//   409: serviceError
+//   422: serviceError
+//   500: serviceError
+
+// swagger:route GET /users/{id} users getUserRequest
+// Get a user, the ETag header holds its version.
+// responses:
+//   200: createUserResponse
+//   304:
+//   400: serviceError
 //   500: serviceError
 
 // swagger:route PUT /users/{id} users updateUserRequest
-// Update user.
+// Update user, only while at the version given in If-Match if there is one.
 // responses:
 //   200:
 //   400: serviceError
+//   412: serviceError
+//   500: serviceError
+
+// swagger:route PATCH /users/{id} users patchUserRequest
+// Patch user with a JSON merge patch (application/merge-patch+json) or a JSON patch (application/json-patch+json).
+// A null or removed attribute is cleared, a changed email stays pending until confirmed.
+// consumes:
+//   - application/merge-patch+json
+//   - application/json-patch+json
+// responses:
+//   200: createUserResponse
+//   400: serviceError
+//   409: serviceError
+//   412: serviceError
+//   415: serviceError
 //   500: serviceError
 
 // swagger:route DELETE /users/{id} users deleteUserRequest
-// Delete a user.
+// Delete a user, only while at the version given in If-Match if there is one.
+// The last owner of a group can't be deleted.
+// responses:
+//   200:
+//   400: serviceError
+//   409: serviceError
+//   412: serviceError
+//   500: serviceError
+
+// swagger:route POST /users/{id}/restore users restoreUserRequest
+// Restore a deleted user with the memberships deleted with it.
 // responses:
 //   200:
 //   400: serviceError
 //   500: serviceError
 
 // swagger:route GET /users users getUsersRequest
-// Get users.
+// Get users, optionally only those with a status, of a type or only the deleted ones.
+// Filter by custom attributes with attributes[name]=value query parameters.
 // responses:
 //   200: getUsersResponse
+//   304:
+//   400: serviceError
+//   500: serviceError
+
+// swagger:route POST /users:import users importUsersRequest
+// Create or update users from CSV (text/csv) or NDJSON (application/x-ndjson), matching them by email.
+// CSV has a header row with the columns name, email, password, status, group and attributes.<name>.
+// A password is only needed for new users, with dryRun=true rows are only checked.
+// With async=true the import runs as a job for the caller, Location links to it and its result is the import report.
+// The job holds the passwords hashed only.
+// Only admins may import rows with a group.
+// consumes:
+//   - text/csv
+//   - application/x-ndjson
+// responses:
+//   200: importUsersResponse
+//   202: jobResponse
+//   400: serviceError
+//   401: serviceError
+//   403: serviceError
+//   415: serviceError
+//   500: serviceError
+
+// swagger:route GET /users:export users exportUsersRequest
+// Stream all users as CSV, with the columns an import reads, or as NDJSON.
+// produces:
+//   - text/csv
+//   - application/x-ndjson
+// responses:
+//   200:
 //   400: serviceError
 //   500: serviceError
 
@@ -40,6 +107,13 @@ import (
 //   400: serviceError
 //   500: serviceError
 
+// swagger:route POST /users/{id}/verification users resendVerificationRequest
+// Resend the verification mail for an unverified or pending email.
+// responses:
+//   202:
+//   400: serviceError
+//   500: serviceError
+
 // swagger:response createUserResponse
 type createUserResponse struct {
 	// in:body
@@ -52,6 +126,12 @@ type getUsersResponse struct {
 	Body internal.UsersResponse
 }
 
+// swagger:response importUsersResponse
+type importUsersResponse struct {
+	// in:body
+	Body internal.ImportUsersResponse
+}
+
 // swagger:response serviceError
 type serviceErrorResponse struct {
 	// in:body
@@ -62,22 +142,52 @@ type serviceErrorResponse struct {
 
 // swagger:parameters createUserRequest
 type createUserRequest struct {
+	// in: header
+	IdempotencyKey string `json:"Idempotency-Key"`
 	// in:body
 	Body httpservice.CreateUser
 }
 
+// swagger:parameters getUserRequest
+type getUserRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in: header
+	IfNoneMatch string `json:"If-None-Match"`
+}
+
 // swagger:parameters updateUserRequest
 type updateUserRequest struct {
 	// in: path
 	Id uint `json:"id"`
+	// in: header
+	IfMatch string `json:"If-Match"`
 	// in:body
 	Body httpservice.UpdateUser
 }
 
+// swagger:parameters patchUserRequest
+type patchUserRequest struct {
+	// in: path
+	Id uint `json:"id"`
+	// in: header
+	IfMatch string `json:"If-Match"`
+	// in:body
+	Body httpservice.PatchUser
+}
+
 // swagger:parameters deleteUserRequest
 type deleteUserRequest struct {
 	// in: path
 	Id uint `json:"id"`
+	// in: header
+	IfMatch string `json:"If-Match"`
+}
+
+// swagger:parameters restoreUserRequest
+type restoreUserRequest struct {
+	// in: path
+	Id uint `json:"id"`
 }
 
 // swagger:parameters getUsersRequest
@@ -86,6 +196,40 @@ type getUsersRequest struct {
 	Page uint `json:"page"`
 	// in: query
 	PerPage uint `json:"perPage"`
+	// in: query
+	// enum: active,pending,suspended,disabled
+	Status string `json:"status"`
+	// in: query
+	// enum: person,service
+	Type string `json:"type"`
+	// in: query
+	Deleted bool `json:"deleted"`
+	// in: header
+	IfNoneMatch string `json:"If-None-Match"`
+}
+
+// swagger:parameters importUsersRequest
+type importUsersRequest struct {
+	// in: header
+	Authorization string `json:"Authorization"`
+	// in: query
+	DryRun bool `json:"dryRun"`
+	// in: query
+	Async bool `json:"async"`
+	// in:body
+	Body []httpservice.ImportUser
+}
+
+// swagger:parameters exportUsersRequest
+type exportUsersRequest struct {
+	// in: query
+	// enum: csv,ndjson
+	Format string `json:"format"`
+	// in: query
+	// enum: active,pending,suspended,disabled
+	Status string `json:"status"`
+	// in: query
+	Deleted bool `json:"deleted"`
 }
 
 // swagger:parameters changePwdRequest
@@ -95,3 +239,9 @@ type changePwdRequest struct {
 	// in:body
 	Body httpservice.CreatePassword
 }
+
+// swagger:parameters resendVerificationRequest
+type resendVerificationRequest struct {
+	// in: path
+	Id uint `json:"id"`
+}
@@ -0,0 +1,113 @@
+package integration_test
+
+import (
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestAccessRequests() {
+	groupData := data.NewGroupService(suite.testDB)
+	accessData := data.NewAccessRequestService(suite.testDB)
+	groupService := service.NewGroupService(groupData, suite.dynamicGroups())
+	accessService := service.NewAccessRequestService(accessData, groupService, groupData, data.NewUserService(suite.testDB),
+		notifier.NewLogNotifier(""), service.NewAuditor(data.NewAuditService(suite.testDB)), service.AccessRequestOptions{})
+	grp1, grp2, usr1, usr2 := suite.addUsersAndGroups()
+	hasCode := func(err error, code serviceerror.ErrorCode) bool {
+		var srvError *serviceerror.ServiceError
+		return errors.As(err, &srvError) && srvError.Code == code
+	}
+
+	suite.T().Run("approve request", func(t *testing.T) {
+		request, err := accessService.RequestAccess(internal.AccessRequestRequest{UserID: usr1.ID, GroupID: grp1.ID, Justification: "on call"})
+		assert.NoError(t, err)
+		assert.Equal(t, internal.AccessPending, request.Status)
+		_, err = accessService.RequestAccess(internal.AccessRequestRequest{UserID: usr1.ID, GroupID: grp1.ID})
+		assert.True(t, hasCode(err, serviceerror.InvalidAccessRequest))
+
+		request, err = accessService.Approve(request.ID, usr2.ID, "ok")
+		assert.NoError(t, err)
+		assert.Equal(t, internal.AccessApproved, request.Status)
+		assert.Equal(t, usr2.ID, request.DecidedBy)
+		role, err := groupData.GetMemberRole(grp1.ID, usr1.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.RoleMember, role)
+
+		_, err = accessService.Deny(request.ID, usr2.ID, "")
+		assert.True(t, hasCode(err, serviceerror.AccessRequestDecided))
+	})
+
+	suite.T().Run("resolve owners as approvers", func(t *testing.T) {
+		assert.NoError(t, groupData.SetMemberRole(grp1.ID, usr1.ID, internal.RoleOwner))
+		owners, err := groupData.GetGroupOwners(grp1.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, owners, 1) {
+			assert.Equal(t, usr1.ID, owners[0].ID)
+		}
+		request, err := accessService.RequestAccess(internal.AccessRequestRequest{UserID: usr2.ID, GroupID: grp1.ID})
+		assert.NoError(t, err)
+		assert.NoError(t, accessService.AuthorizeDecision(internal.Caller{UserID: usr1.ID}, request.ID))
+		err = accessService.AuthorizeDecision(internal.Caller{UserID: usr2.ID}, request.ID)
+		assert.True(t, hasCode(err, serviceerror.Forbidden))
+		assert.NoError(t, accessService.AuthorizeView(internal.Caller{UserID: usr1.ID}, request.ID))
+		assert.NoError(t, accessService.AuthorizeView(internal.Caller{UserID: usr2.ID}, request.ID))
+		assert.NoError(t, accessService.AuthorizeList(internal.Caller{UserID: usr1.ID}, internal.AccessRequestsFilter{GroupID: grp1.ID}))
+		err = accessService.AuthorizeList(internal.Caller{UserID: usr2.ID}, internal.AccessRequestsFilter{GroupID: grp1.ID})
+		assert.True(t, hasCode(err, serviceerror.Forbidden))
+		_, err = accessService.Deny(request.ID, usr1.ID, "")
+		assert.NoError(t, err)
+	})
+
+	suite.T().Run("keep requests of other organizations apart", func(t *testing.T) {
+		request, err := accessService.RequestAccess(internal.AccessRequestRequest{UserID: usr2.ID, GroupID: grp1.ID})
+		assert.NoError(t, err)
+		other := accessService.ForOrganization(99)
+		_, err = other.GetAccessRequest(request.ID)
+		assert.True(t, hasCode(err, serviceerror.AccessRequestNotFound))
+		response, err := other.GetAccessRequests(1, 10, internal.AccessRequestsFilter{})
+		assert.NoError(t, err)
+		assert.Empty(t, response.AccessRequests)
+		_, err = other.Deny(request.ID, 0, "")
+		assert.True(t, hasCode(err, serviceerror.AccessRequestNotFound))
+		_, err = accessService.Deny(request.ID, usr1.ID, "")
+		assert.NoError(t, err)
+	})
+
+	suite.T().Run("deny and expire requests", func(t *testing.T) {
+		request, err := accessService.RequestAccess(internal.AccessRequestRequest{UserID: usr2.ID, GroupID: grp2.ID})
+		assert.NoError(t, err)
+		request, err = accessService.Deny(request.ID, 0, "not needed")
+		assert.NoError(t, err)
+		assert.Equal(t, "not needed", request.Reason)
+
+		request, err = accessData.CreateAccessRequest(internal.AccessRequestRequest{UserID: usr2.ID, GroupID: grp2.ID}, time.Now().Add(-time.Minute))
+		assert.NoError(t, err)
+		_, err = accessService.Approve(request.ID, 0, "")
+		assert.True(t, hasCode(err, serviceerror.AccessRequestDecided))
+		assert.NoError(t, accessService.ExpireRequests())
+		request, err = accessService.GetAccessRequest(request.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.AccessExpired, request.Status)
+
+		response, err := accessService.GetAccessRequests(1, 10, internal.AccessRequestsFilter{UserID: usr2.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(4), response.Total)
+	})
+
+	suite.cleanUsers()
+	suite.cleanGroups()
+	suite.cleanUserGroups()
+	suite.cleanAccessRequests()
+}
+
+func (suite *IntegrationTestSuite) cleanAccessRequests() {
+	err := suite.testDB.Where("1 = 1").Delete(&data.AccessRequest{}).Error
+	assert.NoError(suite.T(), err)
+}
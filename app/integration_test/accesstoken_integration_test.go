@@ -0,0 +1,67 @@
+package integration_test
+
+import (
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestServiceAccountTokens() {
+	keys := suite.keyManager()
+	userData := data.NewUserService(suite.testDB)
+	accessTokenData := data.NewAccessTokenService(suite.testDB)
+	accessTokenService := service.NewAccessTokenService(userData, accessTokenData)
+	authService := service.NewAuthService(userData, data.NewMFAService(suite.testDB), accessTokenData,
+		suite.newLockoutService(userData), notifier.NewLogNotifier(""), nil, service.AuthOptions{Keys: keys})
+	hasCode := func(err error, code serviceerror.ErrorCode) bool {
+		var srvError *serviceerror.ServiceError
+		return errors.As(err, &srvError) && srvError.Code == code
+	}
+	account, err := userData.CreateUser(internal.UserRequest{Name: "ci", Email: "ci@example.com", Type: internal.UserServiceAccount})
+	assert.NoError(suite.T(), err)
+	var created internal.CreatedAccessToken
+
+	suite.T().Run("refuse password of service account", func(t *testing.T) {
+		assert.Equal(t, internal.UserServiceAccount, account.Type)
+		_, err := authService.Login("ci@example.com", "123455664546", "10.0.0.1")
+		assert.True(t, hasCode(err, serviceerror.InvalidCredentials))
+		assert.True(t, hasCode(userData.ChangePassword(account.ID, "123455664546"), serviceerror.InvalidUserRequest))
+	})
+
+	suite.T().Run("authenticate with token", func(t *testing.T) {
+		created, err = accessTokenService.CreateAccessToken(account.ID, internal.AccessTokenRequest{Name: "deploy", Scopes: []string{"groups:write"}})
+		assert.NoError(t, err)
+		caller, err := authService.Authenticate(created.Token)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Caller{UserID: account.ID, Scopes: []string{"groups:write"}}, caller)
+
+		response, err := accessTokenService.GetAccessTokens(account.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, response.Tokens, 1) {
+			assert.Equal(t, "deploy", response.Tokens[0].Name)
+			assert.NotNil(t, response.Tokens[0].LastUsedAt)
+		}
+	})
+
+	suite.T().Run("fail on revoked token", func(t *testing.T) {
+		assert.NoError(t, accessTokenService.RevokeAccessToken(account.ID, created.ID))
+		_, err := authService.Authenticate(created.Token)
+		assert.True(t, hasCode(err, serviceerror.Unauthenticated))
+		assert.True(t, hasCode(accessTokenService.RevokeAccessToken(account.ID, created.ID), serviceerror.AccessTokenNotFound))
+	})
+
+	suite.cleanUsers()
+	suite.cleanAccessTokens()
+	suite.cleanSigningKeys()
+}
+
+func (suite *IntegrationTestSuite) cleanAccessTokens() {
+	err := suite.testDB.Where("1 = 1").Delete(&data.PersonalAccessToken{}).Error
+	assert.NoError(suite.T(), err)
+}
@@ -0,0 +1,92 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestUserAttributes() {
+	attributeData := data.NewAttributeService(suite.testDB)
+	attributeService := service.NewAttributeService(attributeData)
+	userService := service.NewUserService(data.NewUserService(suite.testDB), attributeData, suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
+	router := gin.Default()
+	router.PUT("/attributes/:name", httpservice.SaveAttributeHandler(attributeService))
+	router.DELETE("/attributes/:name", httpservice.DeleteAttributeHandler(attributeService))
+	router.POST("/users", httpservice.CreateUserHandler(userService))
+	router.PUT("/users/:id", httpservice.UpdateUserHandler(userService))
+	router.GET("/users", httpservice.GetUsersHandler(userService))
+
+	request := func(method string, path string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	createUser := func(email string, attributes string) *httptest.ResponseRecorder {
+		return request("POST", "/users", fmt.Sprintf(`{"name":"test","email":"%s","password":"12345678","attributes":%s}`, email, attributes))
+	}
+	getUsers := func(t *testing.T, query string) internal.UsersResponse {
+		recorder := request("GET", "/users?"+query, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response internal.UsersResponse
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		return response
+	}
+
+	assert.Equal(suite.T(), http.StatusOK, request("PUT", "/attributes/employeeId", `{"type":"string","required":true,"unique":true,"pattern":"^E[0-9]+$"}`).Code)
+	assert.Equal(suite.T(), http.StatusOK, request("PUT", "/attributes/department", `{"type":"string","enum":["eng","ops"]}`).Code)
+	assert.Equal(suite.T(), http.StatusOK, request("PUT", "/attributes/level", `{"type":"number"}`).Code)
+
+	var user internal.UserResponse
+	suite.T().Run("create users with attributes", func(t *testing.T) {
+		recorder := createUser("test1@gmail.com", `{"employeeId":"E1","department":"eng","level":3}`)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&user))
+		assert.Equal(t, map[string]interface{}{"employeeId": "E1", "department": "eng", "level": float64(3)}, user.Attributes)
+
+		assert.Equal(t, http.StatusCreated, createUser("test2@gmail.com", `{"employeeId":"E2","department":"ops","level":3}`).Code)
+		assert.Equal(t, http.StatusBadRequest, createUser("test3@gmail.com", `{"department":"eng"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, createUser("test3@gmail.com", `{"employeeId":"E1"}`).Code)
+	})
+
+	suite.T().Run("filter users by attributes", func(t *testing.T) {
+		response := getUsers(t, "attributes[level]=3")
+		assert.Equal(t, uint(2), response.Total)
+		response = getUsers(t, "attributes[level]=3&attributes[department]=eng")
+		if assert.Len(t, response.Users, 1) {
+			assert.Equal(t, user.ID, response.Users[0].ID)
+		}
+		assert.Equal(t, http.StatusBadRequest, request("GET", "/users?attributes[phone]=1", "").Code)
+	})
+
+	suite.T().Run("merge and remove attributes on update", func(t *testing.T) {
+		recorder := request("PUT", fmt.Sprintf("/users/%d", user.ID), `{"attributes":{"department":"ops","level":null}}`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		response := getUsers(t, "attributes[employeeId]=E1")
+		if assert.Len(t, response.Users, 1) {
+			assert.Equal(t, map[string]interface{}{"employeeId": "E1", "department": "ops"}, response.Users[0].Attributes)
+		}
+
+		recorder = request("PUT", fmt.Sprintf("/users/%d", user.ID), `{"attributes":{"employeeId":"E2"}}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		recorder = request("PUT", fmt.Sprintf("/users/%d", user.ID), `{"attributes":{"employeeId":null}}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	for _, name := range []string{"employeeId", "department", "level"} {
+		assert.Equal(suite.T(), http.StatusOK, request("DELETE", "/attributes/"+name, "").Code)
+	}
+	suite.cleanUsers()
+}
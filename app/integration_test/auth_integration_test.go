@@ -0,0 +1,246 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/notifier/smtptest"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/totp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	forgotPasswordObj = `{"email":"%s"}`
+	resetPasswordObj  = `{"token":"%s","password":"%s"}`
+	verifyEmailObj    = `{"token":"%s"}`
+	loginObj          = `{"email":"%s","password":"%s"}`
+	loginMFAObj       = `{"mfaToken":"%s","code":"%s"}`
+)
+
+func (suite *IntegrationTestSuite) TestForgotAndResetPassword() {
+	file := filepath.Join(suite.T().TempDir(), "notifications.log")
+	dataService := data.NewUserService(suite.testDB)
+	jobService := service.NewJobService(data.NewJobService(suite.testDB), service.JobOptions{})
+	authService := service.NewAuthService(dataService, data.NewMFAService(suite.testDB),
+		data.NewAccessTokenService(suite.testDB), suite.newLockoutService(dataService), notifier.NewLogNotifier(file),
+		jobService, service.AuthOptions{})
+	jobService.Register(internal.JobSendPasswordReset, authService.RunPasswordResetJob)
+	router := gin.Default()
+	router.POST("/forgot", httpservice.ForgotPasswordHandler(authService))
+	router.POST("/reset", httpservice.ResetPasswordHandler(authService))
+
+	response, err := dataService.CreateUser(internal.UserRequest{
+		Name:     "test",
+		Email:    "test@gmail.com",
+		Password: "123455664546",
+	})
+	assert.NoError(suite.T(), err)
+
+	suite.T().Run("accept unknown email without notification", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/forgot", strings.NewReader(fmt.Sprintf(forgotPasswordObj, "unknown@gmail.com")))
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		ran, err := jobService.RunNext(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, ran)
+		_, err = os.Stat(file)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	suite.T().Run("reset password with token once", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/forgot", strings.NewReader(fmt.Sprintf(forgotPasswordObj, "test@gmail.com")))
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		ran, err := jobService.RunNext(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, ran)
+
+		content, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		var message internal.Message
+		assert.NoError(t, json.Unmarshal(content, &message))
+		assert.Equal(t, "test@gmail.com", message.To)
+		token := lastLine(message.Body)
+
+		before := data.User{ID: response.ID}
+		assert.NoError(t, suite.testDB.Find(&before).Error)
+
+		recorder = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/reset", strings.NewReader(fmt.Sprintf(resetPasswordObj, token, "453453535353435")))
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		after := data.User{ID: response.ID}
+		assert.NoError(t, suite.testDB.Find(&after).Error)
+		assert.NotEqual(t, before.Password, after.Password)
+
+		recorder = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/reset", strings.NewReader(fmt.Sprintf(resetPasswordObj, token, "453453535353435")))
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	suite.cleanUsers()
+	suite.cleanJobs()
+}
+
+func (suite *IntegrationTestSuite) TestVerifyEmail() {
+	mailServer, err := smtptest.NewServer()
+	assert.NoError(suite.T(), err)
+	defer mailServer.Close()
+	mailer := notifier.NewSMTPNotifier(mailServer.Host, mailServer.Port, "", "", "no-reply@test.com")
+	dataService := data.NewUserService(suite.testDB)
+	userService := service.NewUserService(dataService, data.NewAttributeService(suite.testDB), suite.dynamicGroups(), mailer, service.UserOptions{})
+	authService := service.NewAuthService(dataService, data.NewMFAService(suite.testDB),
+		data.NewAccessTokenService(suite.testDB), suite.newLockoutService(dataService), mailer, nil, service.AuthOptions{})
+	router := gin.Default()
+	router.POST("/users", httpservice.CreateUserHandler(userService))
+	router.PUT("/users/:id", httpservice.UpdateUserHandler(userService))
+	router.POST("/verify", httpservice.VerifyEmailHandler(authService))
+
+	verify := func(t *testing.T, token string) int {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/verify", strings.NewReader(fmt.Sprintf(verifyEmailObj, token)))
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	var user internal.UserResponse
+	suite.T().Run("verify email after signup", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/users", strings.NewReader(fmt.Sprintf(createUserObj, "test", "test@gmail.com", "12345678")))
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&user))
+		assert.False(t, user.EmailVerified)
+
+		messages := mailServer.Messages()
+		assert.Len(t, messages, 1)
+		assert.Equal(t, []string{"test@gmail.com"}, messages[0].To)
+		assert.Equal(t, http.StatusOK, verify(t, lastLine(messages[0].Data)))
+
+		verified := data.User{ID: user.ID}
+		assert.NoError(t, suite.testDB.Find(&verified).Error)
+		assert.True(t, verified.EmailVerified)
+	})
+
+	suite.T().Run("change email only after confirmation", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/users/%d", user.ID), strings.NewReader(fmt.Sprintf(updateUserEmailObj, "new@gmail.com")))
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		messages := mailServer.Messages()
+		assert.Len(t, messages, 3)
+		assert.Equal(t, []string{"new@gmail.com"}, messages[1].To)
+		assert.Equal(t, []string{"test@gmail.com"}, messages[2].To)
+
+		pending := data.User{ID: user.ID}
+		assert.NoError(t, suite.testDB.Find(&pending).Error)
+		assert.Equal(t, "test@gmail.com", pending.Email)
+
+		token := lastLine(messages[1].Data)
+		assert.Equal(t, http.StatusOK, verify(t, token))
+		assert.Equal(t, http.StatusBadRequest, verify(t, token))
+
+		changed := data.User{ID: user.ID}
+		assert.NoError(t, suite.testDB.Find(&changed).Error)
+		assert.Equal(t, "new@gmail.com", changed.Email)
+		assert.Empty(t, changed.PendingEmail)
+
+		messages = mailServer.Messages()
+		assert.Len(t, messages, 4)
+		assert.Equal(t, []string{"test@gmail.com"}, messages[3].To)
+	})
+
+	suite.cleanUsers()
+}
+
+func lastLine(body string) string {
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func (suite *IntegrationTestSuite) TestLoginWithMFA() {
+	keys := suite.keyManager()
+	userData := data.NewUserService(suite.testDB)
+	mfaData := data.NewMFAService(suite.testDB)
+	mfaService := service.NewMFAService(userData, mfaData, service.MFAOptions{})
+	authService := service.NewAuthService(userData, mfaData, data.NewAccessTokenService(suite.testDB),
+		suite.newLockoutService(userData), notifier.NewLogNotifier(""), nil, service.AuthOptions{Keys: keys})
+	router := gin.Default()
+	router.POST("/login", httpservice.LoginHandler(authService))
+	router.POST("/login/mfa", httpservice.LoginMFAHandler(authService))
+
+	user, err := userData.CreateUser(internal.UserRequest{
+		Name:     "test",
+		Email:    "test@gmail.com",
+		Password: "123455664546",
+	})
+	assert.NoError(suite.T(), err)
+
+	login := func(t *testing.T, path string, body string) (int, internal.LoginResponse) {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		router.ServeHTTP(recorder, req)
+		var response internal.LoginResponse
+		if recorder.Code == http.StatusOK {
+			assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		}
+		return recorder.Code, response
+	}
+
+	suite.T().Run("fail on wrong password", func(t *testing.T) {
+		status, _ := login(t, "/login", fmt.Sprintf(loginObj, "test@gmail.com", "wrong"))
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	suite.T().Run("challenge and accept recovery code once", func(t *testing.T) {
+		enrollment, err := mfaService.Enroll(user.ID)
+		assert.NoError(t, err)
+		code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+		assert.NoError(t, err)
+		codes, err := mfaService.Activate(user.ID, code)
+		assert.NoError(t, err)
+
+		status, response := login(t, "/login", fmt.Sprintf(loginObj, "test@gmail.com", "123455664546"))
+		assert.Equal(t, http.StatusOK, status)
+		assert.True(t, response.MFARequired)
+		assert.Empty(t, response.AccessToken)
+
+		status, completed := login(t, "/login/mfa", fmt.Sprintf(loginMFAObj, response.MFAToken, codes.RecoveryCodes[0]))
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, completed.AccessToken)
+
+		_, response = login(t, "/login", fmt.Sprintf(loginObj, "test@gmail.com", "123455664546"))
+		status, _ = login(t, "/login/mfa", fmt.Sprintf(loginMFAObj, response.MFAToken, codes.RecoveryCodes[0]))
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	suite.T().Run("login without challenge after reset", func(t *testing.T) {
+		assert.NoError(t, mfaService.Reset(user.ID))
+		status, response := login(t, "/login", fmt.Sprintf(loginObj, "test@gmail.com", "123455664546"))
+		assert.Equal(t, http.StatusOK, status)
+		assert.False(t, response.MFARequired)
+		assert.NotEmpty(t, response.AccessToken)
+	})
+
+	suite.cleanUsers()
+	suite.cleanSigningKeys()
+}
@@ -0,0 +1,72 @@
+package integration_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestETags() {
+	userService := service.NewUserService(data.NewUserService(suite.testDB), data.NewAttributeService(suite.testDB), suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
+	groupService := service.NewGroupService(data.NewGroupService(suite.testDB), suite.dynamicGroups())
+	router := gin.Default()
+	router.GET("/users/:id", httpservice.GetUserHandler(userService))
+	router.PUT("/users/:id", httpservice.UpdateUserHandler(userService))
+	router.DELETE("/users/:id", httpservice.DeleteUserHandler(userService))
+	router.PUT("/groups/:id", httpservice.UpdateGroupHandler(groupService))
+
+	user, err := userService.CreateUser(internal.UserRequest{Name: "test", Email: "test@gmail.com", Password: "123455664546"})
+	assert.NoError(suite.T(), err)
+	group, err := groupService.CreateGroup(internal.GroupRequest{Name: "test"})
+	assert.NoError(suite.T(), err)
+
+	request := func(method string, path string, header map[string]string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	userPath := fmt.Sprintf("/users/%d", user.ID)
+
+	suite.T().Run("update user at its version", func(t *testing.T) {
+		recorder := request("GET", userPath, nil, "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		tag := recorder.Header().Get("ETag")
+		assert.Equal(t, `"1"`, tag)
+		assert.Equal(t, http.StatusNotModified, request("GET", userPath, map[string]string{"If-None-Match": tag}, "").Code)
+
+		recorder = request("PUT", userPath, map[string]string{"If-Match": tag}, `{"name":"new"}`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `"2"`, recorder.Header().Get("ETag"))
+		assert.Equal(t, http.StatusPreconditionFailed, request("PUT", userPath, map[string]string{"If-Match": tag}, `{"name":"old"}`).Code)
+		assert.Equal(t, http.StatusOK, request("GET", userPath, map[string]string{"If-None-Match": tag}, "").Code)
+	})
+
+	suite.T().Run("update group at its version", func(t *testing.T) {
+		groupPath := fmt.Sprintf("/groups/%d", group.ID)
+		assert.Equal(t, http.StatusPreconditionFailed, request("PUT", groupPath, map[string]string{"If-Match": `"2"`}, `{"name":"new"}`).Code)
+		recorder := request("PUT", groupPath, map[string]string{"If-Match": `"1"`}, `{"name":"new"}`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `"2"`, recorder.Header().Get("ETag"))
+	})
+
+	suite.T().Run("delete user at its version", func(t *testing.T) {
+		assert.Equal(t, http.StatusPreconditionFailed, request("DELETE", userPath, map[string]string{"If-Match": `"1"`}, "").Code)
+		assert.Equal(t, http.StatusOK, request("DELETE", userPath, map[string]string{"If-Match": `"2"`}, "").Code)
+	})
+	suite.cleanUsers()
+	suite.cleanGroups()
+}
@@ -2,15 +2,19 @@ package integration_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 	"usermanagement/app/internal"
 	"usermanagement/app/internal/data"
 	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
 	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -24,29 +28,67 @@ const (
 )
 
 func (suite *IntegrationTestSuite) TestCreateGroup() {
+	userData := data.NewUserService(suite.testDB)
 	dataService := data.NewGroupService(suite.testDB)
-	groupService := service.NewGroupService(dataService)
+	groupService := service.NewGroupService(dataService, suite.dynamicGroups())
+	authService, adminToken := suite.loginAdmin(userData)
 	router := gin.Default()
-	router.POST("/", httpservice.CreateGroupHandler(groupService))
+	router.POST("/", httpservice.AuthenticationMiddleware(authService, false), httpservice.CreateGroupHandler(groupService))
+	owner, err := userData.CreateUser(internal.UserRequest{Name: "owner", Email: "owner@gmail.com", Password: "123455664546"})
+	assert.NoError(suite.T(), err)
+	other, err := userData.CreateUser(internal.UserRequest{Name: "other", Email: "other@gmail.com", Password: "123455664546"})
+	assert.NoError(suite.T(), err)
 
-	suite.T().Run("create group successfully", func(t *testing.T) {
+	createGroup := func(token string, body string) *httptest.ResponseRecorder {
 		recorder := httptest.NewRecorder()
-		req, _ := http.NewRequest("POST", "/", strings.NewReader(fmt.Sprintf(createGroupObj, "test")))
+		req, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
 		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	suite.T().Run("fail without caller", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, createGroup("", fmt.Sprintf(createGroupObj, "test")).Code)
+	})
+
+	suite.T().Run("create group successfully", func(t *testing.T) {
+		recorder := createGroup(adminToken, fmt.Sprintf(`{"name":"test","ownerId":%d}`, owner.ID))
 		assert.Equal(t, http.StatusCreated, recorder.Code)
 		if recorder.Code == http.StatusCreated {
-			var response internal.UserResponse
+			var response internal.GroupResponse
 			err := json.NewDecoder(recorder.Body).Decode(&response)
 			assert.NoError(t, err)
 			assert.Equal(t, "test", response.Name)
+			role, err := dataService.GetMemberRole(response.ID, owner.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, internal.RoleOwner, role)
 		}
 	})
 
-	suite.T().Run("fail on duplicate group with same name", func(t *testing.T) {
-		recorder := httptest.NewRecorder()
-		req, _ := http.NewRequest("POST", "/", strings.NewReader(fmt.Sprintf(createGroupObj, "test")))
-		router.ServeHTTP(recorder, req)
+	suite.T().Run("make the caller owner", func(t *testing.T) {
+		login, err := authService.Login("other@gmail.com", "123455664546", "10.0.0.1")
+		assert.NoError(t, err)
+		recorder := createGroup(login.AccessToken, fmt.Sprintf(createGroupObj, "test2"))
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		var response internal.GroupResponse
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		role, err := dataService.GetMemberRole(response.ID, other.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.RoleOwner, role)
+	})
+
+	suite.T().Run("fail on owner in other group", func(t *testing.T) {
+		recorder := createGroup(adminToken, fmt.Sprintf(`{"name":"test4","ownerId":%d}`, owner.ID))
 		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		groups, err := dataService.GetGroups(0, 10, internal.GroupsFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), groups.Total)
+	})
+
+	suite.T().Run("fail on duplicate group with same name", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, createGroup(adminToken, fmt.Sprintf(`{"name":"test","ownerId":%d}`, owner.ID)).Code)
 	})
 
 	suite.T().Run("recreate deleted group", func(t *testing.T) {
@@ -57,9 +99,9 @@ func (suite *IntegrationTestSuite) TestCreateGroup() {
 		err = suite.testDB.Delete(&data.Group{}, grp.ID).Error
 		assert.NoError(t, err)
 
-		recorder := httptest.NewRecorder()
-		req, _ := http.NewRequest("POST", "/", strings.NewReader(fmt.Sprintf(createGroupObj, "test3")))
-		router.ServeHTTP(recorder, req)
+		third, err := userData.CreateUser(internal.UserRequest{Name: "third", Email: "third@gmail.com", Password: "123455664546"})
+		assert.NoError(t, err)
+		recorder := createGroup(adminToken, fmt.Sprintf(`{"name":"test3","ownerId":%d}`, third.ID))
 		assert.Equal(t, http.StatusCreated, recorder.Code)
 		if recorder.Code == http.StatusCreated {
 			var recreatedResponse internal.UserResponse
@@ -70,12 +112,15 @@ func (suite *IntegrationTestSuite) TestCreateGroup() {
 		}
 	})
 
+	suite.cleanUserGroups()
 	suite.cleanGroups()
+	suite.cleanUsers()
+	suite.cleanSigningKeys()
 }
 
 func (suite *IntegrationTestSuite) TestUpdateGroup() {
 	dataService := data.NewGroupService(suite.testDB)
-	grpService := service.NewGroupService(dataService)
+	grpService := service.NewGroupService(dataService, suite.dynamicGroups())
 	router := gin.Default()
 	router.PUT("/groups/:id", httpservice.UpdateGroupHandler(grpService))
 
@@ -118,7 +163,7 @@ func (suite *IntegrationTestSuite) TestUpdateGroup() {
 
 func (suite *IntegrationTestSuite) TestDeleteGroup() {
 	dataService := data.NewGroupService(suite.testDB)
-	grpService := service.NewGroupService(dataService)
+	grpService := service.NewGroupService(dataService, suite.dynamicGroups())
 	router := gin.Default()
 	router.DELETE("/groups/:id", httpservice.DeleteGroupHandler(grpService))
 
@@ -142,7 +187,7 @@ func (suite *IntegrationTestSuite) TestDeleteGroup() {
 
 func (suite *IntegrationTestSuite) TestGetGroups() {
 	dataService := data.NewGroupService(suite.testDB)
-	grpService := service.NewGroupService(dataService)
+	grpService := service.NewGroupService(dataService, suite.dynamicGroups())
 	router := gin.Default()
 	router.GET("/", httpservice.GetGroupsHandler(grpService))
 
@@ -185,7 +230,7 @@ func (suite *IntegrationTestSuite) TestGetGroups() {
 
 func (suite *IntegrationTestSuite) TestAddUser() {
 	dataService := data.NewGroupService(suite.testDB)
-	groupService := service.NewGroupService(dataService)
+	groupService := service.NewGroupService(dataService, suite.dynamicGroups())
 	router := gin.Default()
 	router.POST("/groups/:id/users", httpservice.AddUserHandler(groupService))
 	grp1, grp2, usr1, usr2 := suite.addUsersAndGroups()
@@ -222,7 +267,7 @@ func (suite *IntegrationTestSuite) TestAddUser() {
 		assert.Len(t, response.Users, 1)
 
 		userDataService := data.NewUserService(suite.testDB)
-		userDataService.DeleteUser(usr2.ID)
+		userDataService.DeleteUser(usr2.ID, 0)
 
 		response, err = dataService.GetUsersByGroupID(grp2.ID, 0, 100)
 		assert.NoError(suite.T(), err)
@@ -230,7 +275,7 @@ func (suite *IntegrationTestSuite) TestAddUser() {
 	})
 
 	suite.T().Run("deleting group clean user group", func(t *testing.T) {
-		err := dataService.DeleteGroup(grp1.ID)
+		err := dataService.DeleteGroup(grp1.ID, 0)
 		assert.NoError(suite.T(), err)
 
 		var count int64
@@ -246,7 +291,7 @@ func (suite *IntegrationTestSuite) TestAddUser() {
 
 func (suite *IntegrationTestSuite) TestRemoveUser() {
 	dataService := data.NewGroupService(suite.testDB)
-	groupService := service.NewGroupService(dataService)
+	groupService := service.NewGroupService(dataService, suite.dynamicGroups())
 	router := gin.Default()
 	router.POST("/groups/:id/users", httpservice.AddUserHandler(groupService))
 	router.DELETE("/groups/:id/users/:userid", httpservice.RemoveUserHandler(groupService))
@@ -278,7 +323,7 @@ func (suite *IntegrationTestSuite) TestRemoveUser() {
 
 func (suite *IntegrationTestSuite) TestGetGroupUsers() {
 	dataService := data.NewGroupService(suite.testDB)
-	groupService := service.NewGroupService(dataService)
+	groupService := service.NewGroupService(dataService, suite.dynamicGroups())
 	router := gin.Default()
 	router.POST("/groups/:id/users", httpservice.AddUserHandler(groupService))
 	router.GET("/groups/:id/users", httpservice.GetGroupUsersHandler(groupService))
@@ -315,6 +360,259 @@ func (suite *IntegrationTestSuite) TestGetGroupUsers() {
 	suite.cleanUserGroups()
 }
 
+func (suite *IntegrationTestSuite) TestBatchUsers() {
+	dataService := data.NewGroupService(suite.testDB)
+	groupService := service.NewGroupService(dataService, suite.dynamicGroups())
+	router := gin.Default()
+	router.PUT("/groups/:id/users", httpservice.ReplaceUsersHandler(groupService))
+	router.POST("/groups/:id/users:method", httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"batchAdd":    httpservice.BatchAddUsersHandler(groupService),
+		"batchRemove": httpservice.BatchRemoveUsersHandler(groupService),
+	}))
+	grp1, grp2, usr1, usr2 := suite.addUsersAndGroups()
+	assert.NoError(suite.T(), dataService.AddUser(internal.AddUserRequest{UserID: usr2.ID, GroupID: grp2.ID}))
+	unknown := usr2.ID + 100
+
+	batch := func(t *testing.T, method string, path string, ids ...uint) internal.BatchMembershipResponse {
+		body, _ := json.Marshal(map[string][]uint{"user_ids": ids})
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, fmt.Sprintf("/groups/%d/users%s", grp1.ID, path), strings.NewReader(string(body)))
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response internal.BatchMembershipResponse
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		return response
+	}
+
+	suite.T().Run("batch add reports every user", func(t *testing.T) {
+		response := batch(t, "POST", ":batchAdd", usr1.ID, usr2.ID, unknown, usr1.ID)
+		assert.Equal(t, []internal.MembershipResult{
+			{UserID: usr1.ID, Outcome: internal.MembershipAdded},
+			{UserID: usr2.ID, Outcome: internal.MembershipOtherGroup},
+			{UserID: unknown, Outcome: internal.MembershipUnknownUser},
+		}, response.Results)
+		response = batch(t, "POST", ":batchAdd", usr1.ID)
+		assert.Equal(t, 1, response.Unchanged)
+		users, err := dataService.GetUsersByGroupID(grp1.ID, 0, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), users.Total)
+	})
+
+	suite.T().Run("replace removes members left out", func(t *testing.T) {
+		assert.NoError(t, dataService.RemoveUser(grp2.ID, usr2.ID))
+		response := batch(t, "PUT", "", usr2.ID)
+		assert.Equal(t, []internal.MembershipResult{
+			{UserID: usr2.ID, Outcome: internal.MembershipAdded},
+			{UserID: usr1.ID, Outcome: internal.MembershipRemoved},
+		}, response.Results)
+		users, err := dataService.GetUsersByGroupID(grp1.ID, 0, 100)
+		assert.NoError(t, err)
+		if assert.Len(t, users.Users, 1) {
+			assert.Equal(t, usr2.ID, users.Users[0].ID)
+		}
+	})
+
+	suite.T().Run("batch remove", func(t *testing.T) {
+		response := batch(t, "POST", ":batchRemove", usr1.ID, usr2.ID)
+		assert.Equal(t, 1, response.Removed)
+		assert.Equal(t, 1, response.Unchanged)
+		users, err := dataService.GetUsersByGroupID(grp1.ID, 0, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), users.Total)
+	})
+
+	suite.T().Run("fail on unknown group", func(t *testing.T) {
+		_, err := dataService.AddUsers(grp2.ID+100, []uint{usr1.ID})
+		assert.Error(t, err)
+	})
+
+	suite.cleanUsers()
+	suite.cleanGroups()
+	suite.cleanUserGroups()
+}
+
+func (suite *IntegrationTestSuite) TestMembershipExpiry() {
+	dataService := data.NewGroupService(suite.testDB)
+	auditData := data.NewAuditService(suite.testDB)
+	expiry := service.NewMembershipExpiryService(dataService, service.NewAuditor(auditData), service.MembershipExpiryOptions{})
+	grp1, grp2, usr1, usr2 := suite.addUsersAndGroups()
+	tomorrow := time.Now().Add(24 * time.Hour)
+	assert.NoError(suite.T(), dataService.AddUser(internal.AddUserRequest{UserID: usr1.ID, GroupID: grp1.ID, ExpiresAt: &tomorrow}))
+	assert.NoError(suite.T(), dataService.AddUser(internal.AddUserRequest{UserID: usr2.ID, GroupID: grp1.ID, StartsAt: &tomorrow}))
+
+	suite.T().Run("list only started memberships", func(t *testing.T) {
+		users, err := dataService.GetUsersByGroupID(grp1.ID, 0, 100)
+		assert.NoError(t, err)
+		if assert.Len(t, users.Users, 1) {
+			assert.Equal(t, usr1.ID, users.Users[0].ID)
+		}
+	})
+
+	suite.T().Run("hide and remove expired memberships", func(t *testing.T) {
+		err := suite.testDB.Model(&data.UserGroup{}).Where("user_id = ?", usr1.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error
+		assert.NoError(t, err)
+		users, err := dataService.GetUsersByGroupID(grp1.ID, 0, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), users.Total)
+
+		assert.NoError(t, expiry.ExpireMemberships())
+		memberships, err := dataService.GetMemberships(usr1.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, memberships, 1) {
+			assert.NotNil(t, memberships[0].RemovedAt)
+		}
+		events, err := auditData.GetAuditEvents(usr1.ID, "")
+		assert.NoError(t, err)
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, internal.EventMembershipExpired, events[0].Type)
+		}
+		assert.NoError(t, dataService.AddUser(internal.AddUserRequest{UserID: usr1.ID, GroupID: grp2.ID}))
+	})
+
+	suite.cleanUsers()
+	suite.cleanGroups()
+	suite.cleanUserGroups()
+}
+
+func (suite *IntegrationTestSuite) TestMemberRoles() {
+	dataService := data.NewGroupService(suite.testDB)
+	grp1, _, usr1, usr2 := suite.addUsersAndGroups()
+	assert.NoError(suite.T(), dataService.AddUser(internal.AddUserRequest{UserID: usr1.ID, GroupID: grp1.ID}))
+	assert.NoError(suite.T(), dataService.AddUser(internal.AddUserRequest{UserID: usr2.ID, GroupID: grp1.ID}))
+	hasCode := func(err error, code serviceerror.ErrorCode) bool {
+		var srvError *serviceerror.ServiceError
+		return errors.As(err, &srvError) && srvError.Code == code
+	}
+
+	suite.T().Run("add users as members", func(t *testing.T) {
+		role, err := dataService.GetMemberRole(grp1.ID, usr1.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.RoleMember, role)
+	})
+
+	suite.T().Run("keep last owner", func(t *testing.T) {
+		assert.NoError(t, dataService.SetMemberRole(grp1.ID, usr1.ID, internal.RoleOwner))
+		err := dataService.SetMemberRole(grp1.ID, usr1.ID, internal.RoleMember)
+		assert.True(t, hasCode(err, serviceerror.LastGroupOwner))
+		err = dataService.RemoveUser(grp1.ID, usr1.ID)
+		assert.True(t, hasCode(err, serviceerror.LastGroupOwner))
+		response, err := dataService.RemoveUsers(grp1.ID, []uint{usr1.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, response.Failed)
+		userData := data.NewUserService(suite.testDB)
+		err = userData.DeleteUser(usr1.ID, 0)
+		assert.True(t, hasCode(err, serviceerror.LastGroupOwner))
+		_, err = userData.EraseUser(usr1.ID)
+		assert.True(t, hasCode(err, serviceerror.LastGroupOwner))
+	})
+
+	suite.T().Run("hand over ownership", func(t *testing.T) {
+		assert.NoError(t, dataService.SetMemberRole(grp1.ID, usr2.ID, internal.RoleOwner))
+		assert.NoError(t, dataService.RemoveUser(grp1.ID, usr1.ID))
+		role, err := dataService.GetMemberRole(grp1.ID, usr1.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, role)
+	})
+
+	suite.T().Run("fail on role of non member", func(t *testing.T) {
+		err := dataService.SetMemberRole(grp1.ID, usr1.ID, internal.RoleManager)
+		assert.True(t, hasCode(err, serviceerror.InvalidUserGroupRequest))
+	})
+
+	suite.cleanUsers()
+	suite.cleanGroups()
+	suite.cleanUserGroups()
+}
+
+func (suite *IntegrationTestSuite) TestDynamicGroups() {
+	groupData := data.NewGroupService(suite.testDB)
+	userData := data.NewUserService(suite.testDB)
+	attributeData := data.NewAttributeService(suite.testDB)
+	dynamicGroups := service.NewDynamicGroupService(groupData, userData, attributeData, service.DynamicGroupOptions{})
+	groupService := service.NewGroupService(groupData, dynamicGroups)
+	userService := service.NewUserService(userData, attributeData, dynamicGroups, notifier.NewLogNotifier(""), service.UserOptions{})
+	assert.NoError(suite.T(), attributeData.SaveAttributeDefinition(internal.AttributeDefinition{Name: "department", Type: internal.AttributeString}))
+	hasCode := func(err error, code serviceerror.ErrorCode) bool {
+		var srvError *serviceerror.ServiceError
+		return errors.As(err, &srvError) && srvError.Code == code
+	}
+	members := func(t *testing.T, groupID uint) (ids []uint) {
+		users, err := groupService.GetUsersByGroupID(groupID, 1, 100)
+		assert.NoError(t, err)
+		for _, user := range users.Users {
+			ids = append(ids, user.ID)
+		}
+		return ids
+	}
+	usr1, err := userService.CreateUser(internal.UserRequest{Name: "usr1", Email: "usr1@gmail.com", Password: "123455664546",
+		Attributes: map[string]interface{}{"department": "Sales"}})
+	assert.NoError(suite.T(), err)
+	usr2, err := userService.CreateUser(internal.UserRequest{Name: "usr2", Email: "usr2@gmail.com", Password: "123455664546"})
+	assert.NoError(suite.T(), err)
+
+	var sales internal.GroupResponse
+	suite.T().Run("create group with matching users", func(t *testing.T) {
+		sales, err = groupService.CreateGroup(internal.GroupRequest{Name: "sales", Type: internal.GroupDynamic, Rule: `department = "Sales"`})
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{usr1.ID}, members(t, sales.ID))
+	})
+
+	suite.T().Run("follow created and updated users", func(t *testing.T) {
+		usr3, err := userService.CreateUser(internal.UserRequest{Name: "usr3", Email: "usr3@gmail.com", Password: "123455664546",
+			Attributes: map[string]interface{}{"department": "Sales"}})
+		assert.NoError(t, err)
+		assert.NoError(t, userService.UpdateUser(internal.UpdateUserRequest{ID: usr1.ID, Attributes: map[string]interface{}{"department": "Ops"}}))
+		assert.Equal(t, []uint{usr3.ID}, members(t, sales.ID))
+	})
+
+	suite.T().Run("reject manual membership changes", func(t *testing.T) {
+		err := groupService.AddUser(internal.AddUserRequest{UserID: usr2.ID, GroupID: sales.ID})
+		assert.True(t, hasCode(err, serviceerror.DynamicGroupMembership))
+		_, err = groupService.AddUsers(sales.ID, []uint{usr2.ID})
+		assert.True(t, hasCode(err, serviceerror.DynamicGroupMembership))
+	})
+
+	suite.T().Run("reconcile after rule change", func(t *testing.T) {
+		response, err := dynamicGroups.SetRule(sales.ID, `email startsWith "usr2"`)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, response.Added)
+		assert.Equal(t, 1, response.Removed)
+		err = suite.testDB.Model(&data.User{}).Where("id = ?", usr1.ID).Update("email", "usr2-old@gmail.com").Error
+		assert.NoError(t, err)
+		assert.NoError(t, dynamicGroups.Reconcile())
+		assert.ElementsMatch(t, []uint{usr1.ID, usr2.ID}, members(t, sales.ID))
+	})
+
+	suite.T().Run("preview rule", func(t *testing.T) {
+		response, err := dynamicGroups.PreviewRule(`department = "Ops"`, 1, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, response.Users, 1) {
+			assert.Equal(t, usr1.ID, response.Users[0].ID)
+		}
+	})
+
+	suite.T().Run("keep last owner on rule change", func(t *testing.T) {
+		assert.NoError(t, groupData.SetMemberRole(sales.ID, usr2.ID, internal.RoleOwner))
+		response, err := dynamicGroups.SetRule(sales.ID, `department = "Ops"`)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, response.Removed)
+		assert.Equal(t, 1, response.Failed)
+		assert.ElementsMatch(t, []uint{usr1.ID, usr2.ID}, members(t, sales.ID))
+	})
+
+	assert.NoError(suite.T(), attributeData.DeleteAttributeDefinition("department"))
+	suite.cleanUsers()
+	suite.cleanGroups()
+	suite.cleanUserGroups()
+}
+
+// dynamicGroups builds the service keeping the dynamic groups of the test
+// database.
+func (suite *IntegrationTestSuite) dynamicGroups() internal.DynamicGroupService {
+	return service.NewDynamicGroupService(data.NewGroupService(suite.testDB), data.NewUserService(suite.testDB),
+		data.NewAttributeService(suite.testDB), service.DynamicGroupOptions{})
+}
+
 func (suite *IntegrationTestSuite) addUsersAndGroups() (internal.GroupResponse, internal.GroupResponse, internal.UserResponse, internal.UserResponse) {
 	grpDataService := data.NewGroupService(suite.testDB)
 	userDataService := data.NewUserService(suite.testDB)
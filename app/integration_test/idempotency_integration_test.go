@@ -0,0 +1,86 @@
+package integration_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestIdempotencyKeys() {
+	store := data.NewIdempotencyService(suite.testDB)
+	userData := data.NewUserService(suite.testDB)
+	groupService := service.NewGroupService(data.NewGroupService(suite.testDB), suite.dynamicGroups())
+	authService, adminToken := suite.loginAdmin(userData)
+	router := gin.Default()
+	router.POST("/groups", httpservice.AuthenticationMiddleware(authService, false), httpservice.IdempotencyMiddleware(store, time.Hour),
+		httpservice.CreateGroupHandler(groupService))
+	var tokens []string
+	for _, email := range []string{"first@gmail.com", "second@gmail.com"} {
+		_, err := userData.CreateUser(internal.UserRequest{Name: "test", Email: email, Password: "123455664546"})
+		assert.NoError(suite.T(), err)
+		login, err := authService.Login(email, "123455664546", "10.0.0.1")
+		assert.NoError(suite.T(), err)
+		tokens = append(tokens, login.AccessToken)
+	}
+	third, err := userData.CreateUser(internal.UserRequest{Name: "test", Email: "third@gmail.com", Password: "123455664546"})
+	assert.NoError(suite.T(), err)
+
+	createGroupAs := func(token string, key string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/groups", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(httpservice.IdempotencyKeyHeader, key)
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	createGroup := func(key string, body string) *httptest.ResponseRecorder {
+		return createGroupAs(tokens[0], key, body)
+	}
+
+	suite.T().Run("replay group creation", func(t *testing.T) {
+		first := createGroup("key1", `{"name":"test"}`)
+		assert.Equal(t, http.StatusCreated, first.Code)
+		second := createGroup("key1", `{"name":"test"}`)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "true", second.Header().Get(httpservice.IdempotentReplayedHeader))
+
+		groups, err := groupService.GetGroups(1, 10, internal.GroupsFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), groups.Total)
+	})
+
+	suite.T().Run("refuse key reuse with a different body", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnprocessableEntity, createGroup("key1", `{"name":"other"}`).Code)
+	})
+
+	suite.T().Run("keep keys of other callers apart", func(t *testing.T) {
+		recorder := createGroupAs(tokens[1], "key1", `{"name":"second"}`)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.Empty(t, recorder.Header().Get(httpservice.IdempotentReplayedHeader))
+	})
+
+	suite.T().Run("forget expired keys", func(t *testing.T) {
+		assert.NoError(t, store.PurgeIdempotencyKeys(time.Now().Add(2*time.Hour)))
+		recorder := createGroup("key1", `{"name":"other"}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Empty(t, recorder.Header().Get(httpservice.IdempotentReplayedHeader))
+		recorder = createGroupAs(adminToken, "key1", fmt.Sprintf(`{"name":"other","ownerId":%d}`, third.ID))
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+	})
+	suite.cleanUserGroups()
+	suite.cleanGroups()
+	suite.cleanUsers()
+	suite.cleanSigningKeys()
+	suite.testDB.Delete(&data.IdempotencyKey{})
+}
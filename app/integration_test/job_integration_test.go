@@ -0,0 +1,180 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestJobQueue() {
+	jobData := data.NewJobService(suite.testDB)
+	lease := time.Now().Add(time.Minute)
+
+	suite.T().Run("claim a job once", func(t *testing.T) {
+		job, err := jobData.CreateJob(internal.JobRequest{Type: "test", Payload: []byte(`{}`), MaxAttempts: 2})
+		assert.NoError(t, err)
+		claimed, ok, err := jobData.ClaimJob("worker1", lease)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, job.ID, claimed.ID)
+		assert.Equal(t, 1, claimed.Attempts)
+		assert.Equal(t, []byte(`{}`), claimed.Payload)
+		_, ok, err = jobData.ClaimJob("worker2", lease)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		cancel, err := jobData.RenewJob(job.ID, "worker2", internal.JobProgress{}, lease)
+		assert.NoError(t, err)
+		assert.True(t, cancel)
+		cancel, err = jobData.RenewJob(job.ID, "worker1", internal.JobProgress{Done: 1, Total: 2}, lease)
+		assert.NoError(t, err)
+		assert.False(t, cancel)
+
+		assert.NoError(t, jobData.FinishJob(job.ID, "worker1", internal.JobUpdate{
+			Status: internal.JobSucceeded, Progress: internal.JobProgress{Done: 2, Total: 2}, Result: []byte(`{"count":2}`),
+		}))
+		finished, err := jobData.GetJob(job.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.JobSucceeded, finished.Status)
+		assert.Equal(t, `{"count":2}`, string(finished.Result))
+		assert.Empty(t, finished.Payload)
+		assert.NotNil(t, finished.FinishedAt)
+	})
+
+	suite.T().Run("reclaim job with expired lease", func(t *testing.T) {
+		job, err := jobData.CreateJob(internal.JobRequest{Type: "test", MaxAttempts: 2})
+		assert.NoError(t, err)
+		_, ok, err := jobData.ClaimJob("worker1", time.Now().Add(-time.Second))
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		claimed, ok, err := jobData.ClaimJob("worker2", lease)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, job.ID, claimed.ID)
+		assert.Equal(t, 2, claimed.Attempts)
+		assert.NoError(t, jobData.FinishJob(job.ID, "worker1", internal.JobUpdate{Status: internal.JobFailed}))
+		running, err := jobData.GetJob(job.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.JobRunning, running.Status)
+	})
+
+	suite.T().Run("cancel queued and running jobs", func(t *testing.T) {
+		queued, err := jobData.CreateJob(internal.JobRequest{Type: "test", MaxAttempts: 1})
+		assert.NoError(t, err)
+		cancelled, err := jobData.CancelJob(queued.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.JobCancelled, cancelled.Status)
+		_, err = jobData.CancelJob(queued.ID)
+		var srvError *serviceerror.ServiceError
+		assert.True(t, errors.As(err, &srvError) && srvError.Code == serviceerror.JobFinished)
+
+		running, err := jobData.CreateJob(internal.JobRequest{Type: "test", MaxAttempts: 1})
+		assert.NoError(t, err)
+		_, ok, err := jobData.ClaimJob("worker1", lease)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		cancelled, err = jobData.CancelJob(running.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.JobRunning, cancelled.Status)
+		assert.True(t, cancelled.CancelRequested)
+		cancel, err := jobData.RenewJob(running.ID, "worker1", internal.JobProgress{}, lease)
+		assert.NoError(t, err)
+		assert.True(t, cancel)
+		assert.NoError(t, jobData.FinishJob(running.ID, "worker1", internal.JobUpdate{Status: internal.JobCancelled}))
+	})
+
+	suite.T().Run("purge finished jobs", func(t *testing.T) {
+		count, err := jobData.PurgeJobs(time.Now().Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+	suite.cleanJobs()
+}
+
+func (suite *IntegrationTestSuite) TestImportUsersJob() {
+	userData := data.NewUserService(suite.testDB)
+	attributeData := data.NewAttributeService(suite.testDB)
+	userService := service.NewUserService(userData, attributeData, suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
+	jobData := data.NewJobService(suite.testDB)
+	jobService := service.NewJobService(jobData, service.JobOptions{})
+	importService := service.NewUserImportService(userService, userData, attributeData, data.NewGroupService(suite.testDB), jobService)
+	jobService.Register(internal.JobImportUsers, importService.RunImportJob)
+	authService, adminToken := suite.loginAdmin(userData)
+	authenticate := httpservice.AuthenticationMiddleware(authService, false)
+	router := gin.Default()
+	router.POST("/users:method", authenticate, httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"import": httpservice.ImportUsersHandler(importService),
+	}))
+	router.GET("/jobs/:id", authenticate, httpservice.GetJobHandler(jobService))
+	var tokens []string
+	for _, email := range []string{"creator@gmail.com", "other@gmail.com"} {
+		_, err := userData.CreateUser(internal.UserRequest{Name: "test", Email: email, Password: "123455664546"})
+		assert.NoError(suite.T(), err)
+		login, err := authService.Login(email, "123455664546", "10.0.0.1")
+		assert.NoError(suite.T(), err)
+		tokens = append(tokens, login.AccessToken)
+	}
+	request := func(method string, path string, token string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", httpservice.CSVContentType)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := request("POST", "/users:import?async=true", tokens[0], "name,email,password\ntest,test@gmail.com,12345678\n")
+	assert.Equal(suite.T(), http.StatusAccepted, recorder.Code)
+	var job internal.Job
+	assert.NoError(suite.T(), json.NewDecoder(recorder.Body).Decode(&job))
+	assert.Equal(suite.T(), internal.JobQueued, job.Status)
+	queued, err := jobData.GetJob(job.ID)
+	assert.NoError(suite.T(), err)
+	assert.NotContains(suite.T(), string(queued.Payload), "12345678")
+
+	ran, err := jobService.RunNext(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), ran)
+
+	location := strings.TrimPrefix(recorder.Header().Get("Location"), "/api/v1")
+	assert.Equal(suite.T(), fmt.Sprintf("/jobs/%d", job.ID), location)
+	recorder = request("GET", location, tokens[0], "")
+	assert.Equal(suite.T(), http.StatusOK, recorder.Code)
+	var finished struct {
+		Status   string                       `json:"status"`
+		Progress internal.JobProgress         `json:"progress"`
+		Result   internal.ImportUsersResponse `json:"result"`
+	}
+	assert.NoError(suite.T(), json.NewDecoder(recorder.Body).Decode(&finished))
+	assert.Equal(suite.T(), internal.JobSucceeded, finished.Status)
+	assert.Equal(suite.T(), internal.JobProgress{Done: 1, Total: 1}, finished.Progress)
+	assert.Equal(suite.T(), 1, finished.Result.Created)
+	_, err = userData.Authenticate("test@gmail.com", "12345678")
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), http.StatusForbidden, request("GET", location, tokens[1], "").Code)
+	assert.Equal(suite.T(), http.StatusOK, request("GET", location, adminToken, "").Code)
+
+	suite.cleanUsers()
+	suite.cleanJobs()
+	suite.cleanSigningKeys()
+}
+
+func (suite *IntegrationTestSuite) cleanJobs() {
+	suite.testDB.Delete(&data.Job{})
+}
@@ -0,0 +1,81 @@
+package integration_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/attempts"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestLockout() {
+	keys := suite.keyManager()
+	userData := data.NewUserService(suite.testDB)
+	auditData := data.NewAuditService(suite.testDB)
+	lockoutService := service.NewLockoutService(userData, data.NewAttemptService(suite.testDB), service.NewAuditor(auditData),
+		service.LockoutOptions{UserThreshold: 3, DelayAfter: 10})
+	authService := service.NewAuthService(userData, data.NewMFAService(suite.testDB), data.NewAccessTokenService(suite.testDB),
+		lockoutService, notifier.NewLogNotifier(""), nil, service.AuthOptions{Keys: keys, Admins: []string{"admin@gmail.com"}})
+	router := gin.Default()
+	router.POST("/login", httpservice.LoginHandler(authService))
+	router.POST("/users/:id/unlock", httpservice.AuthenticationMiddleware(authService, true), httpservice.UnlockUserHandler(lockoutService))
+
+	user, err := userData.CreateUser(internal.UserRequest{
+		Name:     "test",
+		Email:    "test@gmail.com",
+		Password: "123455664546",
+	})
+	assert.NoError(suite.T(), err)
+	adminUser, err := userData.CreateUser(internal.UserRequest{Name: "admin", Email: "admin@gmail.com", Password: "123455664546"})
+	assert.NoError(suite.T(), err)
+	suite.verifyEmail(adminUser.ID)
+	admin, err := authService.Login("admin@gmail.com", "123455664546", "10.0.0.2")
+	assert.NoError(suite.T(), err)
+
+	request := func(path string, body string) int {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+admin.AccessToken)
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	suite.T().Run("lock after repeated failures", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusUnauthorized, request("/login", fmt.Sprintf(loginObj, "test@gmail.com", "wrong")))
+		}
+		assert.Equal(t, http.StatusTooManyRequests, request("/login", fmt.Sprintf(loginObj, "test@gmail.com", "123455664546")))
+	})
+
+	suite.T().Run("login after unlock", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(fmt.Sprintf("/users/%d/unlock", user.ID), ""))
+		assert.Equal(t, http.StatusOK, request("/login", fmt.Sprintf(loginObj, "test@gmail.com", "123455664546")))
+
+		events, err := auditData.GetAuditEvents(user.ID, "")
+		assert.NoError(t, err)
+		var types []string
+		for _, event := range events {
+			types = append(types, event.Type)
+		}
+		assert.Equal(t, []string{internal.EventAccountUnlocked, internal.EventLoginSucceeded}, types)
+	})
+
+	suite.testDB.Exec("DELETE FROM login_attempts")
+	suite.testDB.Exec("DELETE FROM audit_events")
+	suite.cleanUsers()
+	suite.cleanSigningKeys()
+}
+
+func (suite *IntegrationTestSuite) newLockoutService(users internal.UserData) internal.LockoutService {
+	return service.NewLockoutService(users, attempts.NewMemoryStore(), service.NewAuditor(data.NewAuditService(suite.testDB)),
+		service.LockoutOptions{})
+}
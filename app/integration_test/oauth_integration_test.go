@@ -0,0 +1,151 @@
+package integration_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestOAuth() {
+	keys := suite.keyManager()
+	userData := data.NewUserService(suite.testDB)
+	accessTokenData := data.NewAccessTokenService(suite.testDB)
+	oauthService := service.NewOAuthService(data.NewOAuthService(suite.testDB), userData, accessTokenData, nil, service.OAuthOptions{})
+	authService := service.NewAuthService(userData, data.NewMFAService(suite.testDB), accessTokenData,
+		suite.newLockoutService(userData), notifier.NewLogNotifier(""), nil, service.AuthOptions{Keys: keys})
+	hasCode := func(err error, code serviceerror.ErrorCode) bool {
+		var srvError *serviceerror.ServiceError
+		return errors.As(err, &srvError) && srvError.Code == code
+	}
+	// the code verifier and challenge of RFC 7636 appendix B
+	verifier, challenge := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	user, err := userData.CreateUser(internal.UserRequest{Name: "test", Email: "test@gmail.com", Password: "123455664546"})
+	assert.NoError(suite.T(), err)
+	account, err := userData.CreateUser(internal.UserRequest{Name: "ci", Email: "ci@example.com", Type: internal.UserServiceAccount})
+	assert.NoError(suite.T(), err)
+	web, err := oauthService.RegisterClient(internal.OAuthClientRequest{
+		Name:         "web",
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		GrantTypes:   []string{internal.GrantAuthorizationCode},
+		Scopes:       []string{"users:read", "groups:read"},
+	})
+	assert.NoError(suite.T(), err)
+	ci, err := oauthService.RegisterClient(internal.OAuthClientRequest{
+		Name:             "ci",
+		Confidential:     true,
+		GrantTypes:       []string{internal.GrantClientCredentials},
+		Scopes:           []string{"groups:write"},
+		ServiceAccountID: account.ID,
+	})
+	assert.NoError(suite.T(), err)
+	var token internal.OAuthTokenResponse
+
+	suite.T().Run("exchange authorization code once", func(t *testing.T) {
+		code, err := oauthService.Authorize(internal.OAuthAuthorizeRequest{
+			UserID: user.ID, ResponseType: "code", ClientID: web.ClientID, Scope: "users:read",
+			CodeChallenge: challenge, CodeChallengeMethod: "S256",
+		})
+		assert.NoError(t, err)
+		request := internal.OAuthTokenRequest{GrantType: internal.GrantAuthorizationCode, ClientID: web.ClientID, Code: code, CodeVerifier: verifier}
+		token, err = oauthService.Token(request)
+		assert.NoError(t, err)
+		assert.Equal(t, "users:read", token.Scope)
+		_, err = oauthService.Token(request)
+		assert.True(t, hasCode(err, serviceerror.OAuthInvalidGrant))
+
+		caller, err := authService.Authenticate(token.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Caller{UserID: user.ID, Scopes: []string{"users:read"}}, caller)
+		tokens, err := accessTokenData.GetAccessTokens(user.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	suite.T().Run("grant code through login page", func(t *testing.T) {
+		router := gin.Default()
+		router.GET("/oauth/authorize", httpservice.AuthenticationMiddleware(authService, false), httpservice.OAuthAuthorizeHandler(oauthService))
+		router.POST("/oauth/authorize", httpservice.OAuthLoginHandler(oauthService, authService))
+		params := url.Values{}
+		params.Set("response_type", "code")
+		params.Set("client_id", web.ClientID)
+		params.Set("scope", "users:read")
+		params.Set("state", "xyz")
+		params.Set("code_challenge", challenge)
+		params.Set("code_challenge_method", "S256")
+
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/oauth/authorize?"+params.Encode(), nil)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Sign in to web")
+
+		params.Set("email", user.Email)
+		params.Set("password", "123455664546")
+		params.Set("action", "allow")
+		recorder = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/oauth/authorize", strings.NewReader(params.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.RemoteAddr = "192.0.2.1:1234"
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusFound, recorder.Code)
+		location, err := url.Parse(recorder.Header().Get("Location"))
+		assert.NoError(t, err)
+		assert.Equal(t, "xyz", location.Query().Get("state"))
+		response, err := oauthService.Token(internal.OAuthTokenRequest{
+			GrantType: internal.GrantAuthorizationCode, ClientID: web.ClientID, Code: location.Query().Get("code"), CodeVerifier: verifier,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "users:read", response.Scope)
+	})
+
+	suite.T().Run("issue token to service account", func(t *testing.T) {
+		_, err := oauthService.Token(internal.OAuthTokenRequest{GrantType: internal.GrantClientCredentials, ClientID: ci.ClientID, ClientSecret: "wrong"})
+		assert.True(t, hasCode(err, serviceerror.OAuthInvalidClient))
+		response, err := oauthService.Token(internal.OAuthTokenRequest{GrantType: internal.GrantClientCredentials, ClientID: ci.ClientID, ClientSecret: ci.ClientSecret})
+		assert.NoError(t, err)
+		caller, err := authService.Authenticate(response.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, account.ID, caller.UserID)
+	})
+
+	suite.T().Run("introspect and revoke token", func(t *testing.T) {
+		introspection, err := oauthService.Introspect(ci.ClientID, ci.ClientSecret, token.AccessToken)
+		assert.NoError(t, err)
+		assert.True(t, introspection.Active)
+		assert.Equal(t, web.ClientID, introspection.ClientID)
+
+		assert.NoError(t, oauthService.Revoke(ci.ClientID, ci.ClientSecret, token.AccessToken))
+		_, err = authService.Authenticate(token.AccessToken)
+		assert.NoError(t, err)
+		assert.NoError(t, oauthService.Revoke(web.ClientID, "", token.AccessToken))
+		_, err = authService.Authenticate(token.AccessToken)
+		assert.True(t, hasCode(err, serviceerror.Unauthenticated))
+		introspection, err = oauthService.Introspect(ci.ClientID, ci.ClientSecret, token.AccessToken)
+		assert.NoError(t, err)
+		assert.False(t, introspection.Active)
+	})
+
+	suite.cleanUsers()
+	suite.cleanAccessTokens()
+	suite.cleanOAuth()
+	suite.cleanSigningKeys()
+}
+
+func (suite *IntegrationTestSuite) cleanOAuth() {
+	err := suite.testDB.Where("1 = 1").Delete(&data.OAuthClient{}).Error
+	assert.NoError(suite.T(), err)
+	err = suite.testDB.Where("1 = 1").Delete(&data.OAuthAuthorizationCode{}).Error
+	assert.NoError(suite.T(), err)
+}
@@ -0,0 +1,68 @@
+package integration_test
+
+import (
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/service"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestOpenIDConnect() {
+	userData := data.NewUserService(suite.testDB)
+	groupData := data.NewGroupService(suite.testDB)
+	keys := suite.keyManager()
+	oidcService := service.NewOIDCService(userData, groupData, keys, service.OIDCOptions{Issuer: "https://id.example.com"})
+	oauthService := service.NewOAuthService(data.NewOAuthService(suite.testDB), userData, data.NewAccessTokenService(suite.testDB), oidcService, service.OAuthOptions{})
+	// the code verifier and challenge of RFC 7636 appendix B
+	verifier, challenge := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	user, err := userData.CreateUser(internal.UserRequest{Name: "test", Email: "test@gmail.com", Password: "123455664546"})
+	assert.NoError(suite.T(), err)
+	group, err := groupData.CreateGroup(internal.GroupRequest{Name: "admins"})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), groupData.AddUser(internal.AddUserRequest{UserID: user.ID, GroupID: group.ID}))
+	client, err := oauthService.RegisterClient(internal.OAuthClientRequest{
+		Name:         "web",
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		GrantTypes:   []string{internal.GrantAuthorizationCode},
+		Scopes:       []string{internal.ScopeOpenID, internal.ScopeProfile, internal.ScopeGroups},
+	})
+	assert.NoError(suite.T(), err)
+
+	suite.T().Run("issue id token with claims of the scopes", func(t *testing.T) {
+		code, err := oauthService.Authorize(internal.OAuthAuthorizeRequest{
+			UserID: user.ID, ResponseType: "code", ClientID: client.ClientID, Scope: "openid groups", Nonce: "nonce",
+			CodeChallenge: challenge, CodeChallengeMethod: "S256",
+		})
+		assert.NoError(t, err)
+		response, err := oauthService.Token(internal.OAuthTokenRequest{
+			GrantType: internal.GrantAuthorizationCode, ClientID: client.ClientID, Code: code, CodeVerifier: verifier,
+		})
+		assert.NoError(t, err)
+		key, err := keys.Signing()
+		assert.NoError(t, err)
+		claims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(response.IDToken, claims, func(token *jwt.Token) (interface{}, error) {
+			assert.Equal(t, key.ID, token.Header["kid"])
+			return key.Key.Public(), nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "nonce", claims["nonce"])
+		assert.Equal(t, []interface{}{"admins"}, claims["groups"])
+		assert.NotContains(t, claims, "name")
+
+		info, err := oidcService.UserInfo(internal.Caller{UserID: user.ID, Scopes: []string{internal.ScopeOpenID, internal.ScopeProfile}})
+		assert.NoError(t, err)
+		assert.Equal(t, "test", info.Name)
+		assert.Empty(t, info.Groups)
+	})
+
+	suite.cleanUsers()
+	suite.cleanGroups()
+	suite.cleanUserGroups()
+	suite.cleanAccessTokens()
+	suite.cleanOAuth()
+	suite.cleanSigningKeys()
+}
@@ -0,0 +1,106 @@
+package integration_test
+
+import (
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestOrganizations() {
+	organizationData := data.NewOrganizationService(suite.testDB)
+	userData := data.NewUserService(suite.testDB)
+	groupData := data.NewGroupService(suite.testDB)
+	hasCode := func(err error, code serviceerror.ErrorCode) bool {
+		var srvError *serviceerror.ServiceError
+		return errors.As(err, &srvError) && srvError.Code == code
+	}
+	acme, err := organizationData.CreateOrganization(internal.OrganizationRequest{Name: "acme"})
+	assert.NoError(suite.T(), err)
+	globex, err := organizationData.CreateOrganization(internal.OrganizationRequest{Name: "globex"})
+	assert.NoError(suite.T(), err)
+	acmeUsers, globexUsers := userData.ForOrganization(acme.ID), userData.ForOrganization(globex.ID)
+	acmeGroups, globexGroups := groupData.ForOrganization(acme.ID), groupData.ForOrganization(globex.ID)
+
+	suite.T().Run("fail on duplicate organization", func(t *testing.T) {
+		_, err := organizationData.CreateOrganization(internal.OrganizationRequest{Name: "acme"})
+		assert.True(t, hasCode(err, serviceerror.DuplicateOrganization))
+	})
+
+	suite.T().Run("keep email and name unique per organization", func(t *testing.T) {
+		request := internal.UserRequest{Name: "test", Email: "test@gmail.com", Password: "123455664546"}
+		acmeUser, err := acmeUsers.CreateUser(request)
+		assert.NoError(t, err)
+		assert.Equal(t, acme.ID, acmeUser.OrganizationID)
+		globexUser, err := globexUsers.CreateUser(request)
+		assert.NoError(t, err)
+		assert.Equal(t, globex.ID, globexUser.OrganizationID)
+		_, err = acmeUsers.CreateUser(request)
+		assert.True(t, hasCode(err, serviceerror.DuplicateUser))
+
+		_, err = acmeGroups.CreateGroup(internal.GroupRequest{Name: "admins"})
+		assert.NoError(t, err)
+		_, err = globexGroups.CreateGroup(internal.GroupRequest{Name: "admins"})
+		assert.NoError(t, err)
+		_, err = acmeGroups.CreateGroup(internal.GroupRequest{Name: "admins"})
+		assert.True(t, hasCode(err, serviceerror.DuplicateGroup))
+	})
+
+	suite.T().Run("hide users and groups of other organizations", func(t *testing.T) {
+		response, err := acmeUsers.GetUsers(0, 10, internal.UsersFilter{})
+		assert.NoError(t, err)
+		if assert.Len(t, response.Users, 1) {
+			assert.Equal(t, acme.ID, response.Users[0].OrganizationID)
+			_, err = globexUsers.GetUser(response.Users[0].ID)
+			assert.True(t, hasCode(err, serviceerror.UserNotFound))
+		}
+		groups, err := globexGroups.GetGroups(0, 10, internal.GroupsFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, groups.Groups, 1)
+		response, err = userData.GetUsers(0, 10, internal.UsersFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, response.Users, 2)
+	})
+
+	suite.T().Run("look up email within one organization", func(t *testing.T) {
+		user, err := acmeUsers.Authenticate("test@gmail.com", "123455664546")
+		assert.NoError(t, err)
+		assert.Equal(t, acme.ID, user.OrganizationID)
+		user, err = globexUsers.GetUserByEmail("test@gmail.com")
+		assert.NoError(t, err)
+		assert.Equal(t, globex.ID, user.OrganizationID)
+		_, err = userData.Authenticate("test@gmail.com", "123455664546")
+		assert.True(t, hasCode(err, serviceerror.InvalidCredentials))
+		_, err = userData.GetUserByEmail("test@gmail.com")
+		assert.True(t, hasCode(err, serviceerror.UserNotFound))
+	})
+
+	suite.T().Run("refuse membership across organizations", func(t *testing.T) {
+		users, err := acmeUsers.GetUsers(0, 10, internal.UsersFilter{})
+		assert.NoError(t, err)
+		groups, err := globexGroups.GetGroups(0, 10, internal.GroupsFilter{})
+		assert.NoError(t, err)
+		request := internal.AddUserRequest{UserID: users.Users[0].ID, GroupID: groups.Groups[0].ID}
+		assert.True(t, hasCode(groupData.AddUser(request), serviceerror.CrossOrganizationMembership))
+		assert.True(t, hasCode(acmeGroups.AddUser(request), serviceerror.InvalidUserGroupRequest))
+
+		batch, err := groupData.AddUsers(groups.Groups[0].ID, []uint{users.Users[0].ID})
+		assert.NoError(t, err)
+		if assert.Len(t, batch.Results, 1) {
+			assert.Equal(t, internal.MembershipOtherOrganization, batch.Results[0].Outcome)
+		}
+	})
+
+	suite.cleanUsers()
+	suite.cleanGroups()
+	suite.cleanUserGroups()
+	suite.cleanOrganizations()
+}
+
+func (suite *IntegrationTestSuite) cleanOrganizations() {
+	err := suite.testDB.Where("1 = 1").Delete(&data.Organization{}).Error
+	assert.NoError(suite.T(), err)
+}
@@ -0,0 +1,126 @@
+package integration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/webauthn"
+	"usermanagement/app/internal/webauthn/webauthntest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestPasskeys() {
+	keys := suite.keyManager()
+	const origin = "https://localhost"
+	userData := data.NewUserService(suite.testDB)
+	passkeyService := service.NewPasskeyService(userData, data.NewPasskeyService(suite.testDB), service.PasskeyOptions{
+		RelyingParty: webauthn.New(webauthn.Config{RPID: "localhost", Origins: []string{origin}}),
+		Keys:         keys,
+	})
+	authService := service.NewAuthService(userData, data.NewMFAService(suite.testDB), data.NewAccessTokenService(suite.testDB),
+		suite.newLockoutService(userData), notifier.NewLogNotifier(""), nil, service.AuthOptions{Keys: keys})
+	router := gin.Default()
+	users := router.Group("/users", httpservice.AuthenticationMiddleware(authService, true))
+	users.POST("/:id/passkeys/register/begin", httpservice.BeginPasskeyRegistrationHandler(passkeyService))
+	users.POST("/:id/passkeys/register/finish", httpservice.FinishPasskeyRegistrationHandler(passkeyService))
+	users.GET("/:id/passkeys", httpservice.GetPasskeysHandler(passkeyService))
+	users.DELETE("/:id/passkeys/:passkeyid", httpservice.DeletePasskeyHandler(passkeyService))
+	router.POST("/login/passkey/begin", httpservice.BeginPasskeyLoginHandler(passkeyService))
+	router.POST("/login/passkey/finish", httpservice.FinishPasskeyLoginHandler(passkeyService))
+
+	user, err := userData.CreateUser(internal.UserRequest{
+		Name:     "test",
+		Email:    "test@gmail.com",
+		Password: "123455664546",
+	})
+	assert.NoError(suite.T(), err)
+	authenticator := webauthntest.NewAuthenticator(origin)
+	session, err := authService.Login("test@gmail.com", "123455664546", "10.0.0.1")
+	assert.NoError(suite.T(), err)
+	accessToken := session.AccessToken
+
+	call := func(t *testing.T, method string, path string, body interface{}, response interface{}) int {
+		payload, err := json.Marshal(body)
+		assert.NoError(t, err)
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader(payload))
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		router.ServeHTTP(recorder, req)
+		if response != nil && recorder.Code < http.StatusMultipleChoices {
+			assert.NoError(t, json.NewDecoder(recorder.Body).Decode(response))
+		}
+		return recorder.Code
+	}
+	login := func(t *testing.T) (int, internal.LoginResponse) {
+		var options webauthn.RequestOptions
+		status := call(t, "POST", "/login/passkey/begin", httpservice.BeginPasskeyLogin{Email: "test@gmail.com"}, &options)
+		assert.Equal(t, http.StatusOK, status)
+		var response internal.LoginResponse
+		assertion, err := authenticator.Login(options)
+		if err != nil {
+			return http.StatusUnauthorized, response
+		}
+		status = call(t, "POST", "/login/passkey/finish", assertion, &response)
+		return status, response
+	}
+
+	var passkey internal.PasskeyResponse
+	suite.T().Run("fail to register without token", func(t *testing.T) {
+		token := accessToken
+		accessToken = ""
+		defer func() { accessToken = token }()
+		status := call(t, "POST", fmt.Sprintf("/users/%d/passkeys/register/begin", user.ID), nil, nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	suite.T().Run("register passkey", func(t *testing.T) {
+		var options webauthn.CreationOptions
+		status := call(t, "POST", fmt.Sprintf("/users/%d/passkeys/register/begin", user.ID), nil, &options)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "test@gmail.com", options.User.Name)
+		registration, err := authenticator.Register(options)
+		assert.NoError(t, err)
+		status = call(t, "POST", fmt.Sprintf("/users/%d/passkeys/register/finish", user.ID),
+			httpservice.FinishPasskeyRegistration{Name: "laptop", Credential: registration}, &passkey)
+		assert.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, "laptop", passkey.Name)
+
+		status = call(t, "POST", fmt.Sprintf("/users/%d/passkeys/register/finish", user.ID),
+			httpservice.FinishPasskeyRegistration{Name: "laptop", Credential: registration}, nil)
+		assert.Equal(t, http.StatusBadRequest, status, "challenge must be single use")
+	})
+
+	suite.T().Run("login with passkey", func(t *testing.T) {
+		status, response := login(t)
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, response.AccessToken)
+
+		var passkeys internal.PasskeysResponse
+		status = call(t, "GET", fmt.Sprintf("/users/%d/passkeys", user.ID), nil, &passkeys)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Len(t, passkeys.Passkeys, 1)
+		assert.NotNil(t, passkeys.Passkeys[0].LastUsedAt)
+	})
+
+	suite.T().Run("fail login after revoke", func(t *testing.T) {
+		status := call(t, "DELETE", fmt.Sprintf("/users/%d/passkeys/%d", user.ID, passkey.ID), nil, nil)
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = login(t)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	suite.cleanUsers()
+	suite.cleanSigningKeys()
+}
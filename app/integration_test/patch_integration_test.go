@@ -0,0 +1,78 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestPatchUsersAndGroups() {
+	attributeData := data.NewAttributeService(suite.testDB)
+	userService := service.NewUserService(data.NewUserService(suite.testDB), attributeData, suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
+	groupService := service.NewGroupService(data.NewGroupService(suite.testDB), suite.dynamicGroups())
+	router := gin.Default()
+	router.PATCH("/users/:id", httpservice.PatchUserHandler(userService))
+	router.PATCH("/groups/:id", httpservice.PatchGroupHandler(groupService))
+
+	assert.NoError(suite.T(), attributeData.SaveAttributeDefinition(internal.AttributeDefinition{Name: "phone", Type: internal.AttributeString}))
+	user, err := userService.CreateUser(internal.UserRequest{
+		Name:       "test",
+		Email:      "test@gmail.com",
+		Password:   "123455664546",
+		Attributes: map[string]interface{}{"phone": "123"},
+	})
+	assert.NoError(suite.T(), err)
+	group, err := groupService.CreateGroup(internal.GroupRequest{Name: "test"})
+	assert.NoError(suite.T(), err)
+
+	patch := func(path string, contentType string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("PATCH", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	suite.T().Run("clear attribute with merge patch", func(t *testing.T) {
+		recorder := patch(fmt.Sprintf("/users/%d", user.ID), httpservice.MergePatchContentType, `{"name":"new","attributes":{"phone":null}}`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response internal.UserResponse
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		assert.Equal(t, "new", response.Name)
+		assert.Empty(t, response.Attributes)
+	})
+
+	suite.T().Run("change email with json patch", func(t *testing.T) {
+		recorder := patch(fmt.Sprintf("/users/%d", user.ID), httpservice.JSONPatchContentType,
+			`[{"op":"replace","path":"/email","value":"new@gmail.com"},{"op":"add","path":"/attributes/phone","value":"456"}]`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response internal.UserResponse
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		assert.Equal(t, "test@gmail.com", response.Email)
+		assert.Equal(t, "new@gmail.com", response.PendingEmail)
+		assert.Equal(t, map[string]interface{}{"phone": "456"}, response.Attributes)
+	})
+
+	suite.T().Run("rename group", func(t *testing.T) {
+		recorder := patch(fmt.Sprintf("/groups/%d", group.ID), httpservice.MergePatchContentType, `{"name":"renamed"}`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, fmt.Sprintf(`{"id":%d,"name":"renamed"}`, group.ID), recorder.Body.String())
+		recorder = patch(fmt.Sprintf("/groups/%d", group.ID), httpservice.MergePatchContentType, `{"name":null}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	assert.NoError(suite.T(), attributeData.DeleteAttributeDefinition("phone"))
+	suite.cleanUsers()
+	suite.cleanGroups()
+}
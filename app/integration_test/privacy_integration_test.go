@@ -0,0 +1,125 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestExportAndErase() {
+	keys := suite.keyManager()
+	userData := data.NewUserService(suite.testDB)
+	groupData := data.NewGroupService(suite.testDB)
+	mfaData := data.NewMFAService(suite.testDB)
+	auditData := data.NewAuditService(suite.testDB)
+	auditor := service.NewAuditor(auditData)
+	lockoutService := service.NewLockoutService(userData, data.NewAttemptService(suite.testDB), auditor, service.LockoutOptions{})
+	authService := service.NewAuthService(userData, mfaData, data.NewAccessTokenService(suite.testDB), lockoutService,
+		notifier.NewLogNotifier(""), nil, service.AuthOptions{Keys: keys})
+	privacyService := service.NewPrivacyService(userData, groupData, mfaData, data.NewPasskeyService(suite.testDB), auditData, auditor)
+	router := gin.Default()
+	router.POST("/login", httpservice.LoginHandler(authService))
+	authenticate := httpservice.AuthenticationMiddleware(authService, true)
+	router.GET("/users/:id/export", authenticate, httpservice.ExportUserHandler(privacyService))
+	router.POST("/users/:id/erase", authenticate, httpservice.EraseUserHandler(privacyService))
+
+	grp, _, usr, other := suite.addUsersAndGroups()
+	assert.NoError(suite.T(), groupData.AddUser(internal.AddUserRequest{UserID: usr.ID, GroupID: grp.ID}))
+	_, err := data.NewAccessRequestService(suite.testDB).CreateAccessRequest(internal.AccessRequestRequest{
+		UserID: usr.ID, GroupID: grp.ID, Justification: "my manager said so",
+	}, time.Now().Add(time.Hour))
+	assert.NoError(suite.T(), err)
+	data.NewReviewService(suite.testDB)
+	assert.NoError(suite.T(), suite.testDB.Create(&data.ReviewItem{UserID: usr.ID, GroupID: grp.ID, Comment: "on leave"}).Error)
+
+	var login internal.LoginResponse
+	request := func(method string, path string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		if login.AccessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+login.AccessToken)
+		}
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	request("POST", "/login", fmt.Sprintf(loginObj, usr.Email, "wrong"))
+	assert.NoError(suite.T(), json.NewDecoder(request("POST", "/login", fmt.Sprintf(loginObj, usr.Email, "123455664546")).Body).Decode(&login))
+
+	suite.T().Run("refuse export of other user", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("GET", fmt.Sprintf("/users/%d/export", other.ID), "").Code)
+	})
+
+	suite.T().Run("export profile, memberships and activity", func(t *testing.T) {
+		recorder := request("GET", fmt.Sprintf("/users/%d/export", usr.ID), "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response internal.UserExport
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		assert.Equal(t, usr.Email, response.Profile.Email)
+		if assert.Len(t, response.Memberships, 1) {
+			assert.Equal(t, "grp1", response.Memberships[0].GroupName)
+		}
+		if assert.Len(t, response.Sessions, 1) {
+			assert.Equal(t, "192.0.2.1", response.Sessions[0].IP)
+		}
+		var types []string
+		for _, event := range response.AuditEvents {
+			types = append(types, event.Type)
+		}
+		assert.Equal(t, []string{internal.EventLoginFailed, internal.EventLoginSucceeded}, types)
+	})
+
+	suite.T().Run("erase personal data", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("POST", fmt.Sprintf("/users/%d/erase", usr.ID), `{"reason":"request 42"}`).Code)
+
+		var user data.User
+		assert.NoError(t, suite.testDB.Unscoped().First(&user, usr.ID).Error)
+		assert.NotEqual(t, usr.Email, user.Email)
+		assert.Equal(t, "erased user", user.Name)
+		assert.Empty(t, user.Password)
+		assert.NotNil(t, user.DeletedAt)
+		assert.NotNil(t, user.ErasedAt)
+		var count int64
+		assert.NoError(t, suite.testDB.Model(&data.AccessRequest{}).Where("user_id = ?", usr.ID).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+		var item data.ReviewItem
+		assert.NoError(t, suite.testDB.Where("user_id = ?", usr.ID).First(&item).Error)
+		assert.Empty(t, item.Comment)
+
+		events, err := auditData.GetAuditEvents(usr.ID, usr.Email)
+		assert.NoError(t, err)
+		for _, event := range events {
+			assert.Empty(t, event.Email)
+			assert.Empty(t, event.IP)
+		}
+		if assert.NotEmpty(t, events) {
+			assert.Equal(t, internal.EventUserErased, events[len(events)-1].Type)
+			assert.Equal(t, "request 42", events[len(events)-1].Detail)
+		}
+
+		assert.Equal(t, http.StatusUnauthorized, request("POST", "/login", fmt.Sprintf(loginObj, usr.Email, "123455664546")).Code)
+		assert.Equal(t, http.StatusUnauthorized, request("POST", fmt.Sprintf("/users/%d/erase", usr.ID), "").Code)
+		assert.Error(t, userData.RestoreUser(usr.ID))
+	})
+
+	suite.testDB.Exec("DELETE FROM login_attempts")
+	suite.testDB.Exec("DELETE FROM audit_events")
+	suite.cleanAccessRequests()
+	suite.cleanReviews()
+	suite.cleanUserGroups()
+	suite.cleanGroups()
+	suite.cleanUsers()
+	suite.cleanSigningKeys()
+}
@@ -0,0 +1,123 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestRestoreAndPurge() {
+	userData := data.NewUserService(suite.testDB)
+	groupData := data.NewGroupService(suite.testDB)
+	userService := service.NewUserService(userData, data.NewAttributeService(suite.testDB), suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
+	groupService := service.NewGroupService(groupData, suite.dynamicGroups())
+	router := gin.Default()
+	router.GET("/users", httpservice.GetUsersHandler(userService))
+	router.DELETE("/users/:id", httpservice.DeleteUserHandler(userService))
+	router.POST("/users/:id/restore", httpservice.RestoreUserHandler(userService))
+	router.DELETE("/groups/:id", httpservice.DeleteGroupHandler(groupService))
+	router.POST("/groups/:id/restore", httpservice.RestoreGroupHandler(groupService))
+
+	grp1, grp2, usr1, usr2 := suite.addUsersAndGroups()
+	assert.NoError(suite.T(), groupData.AddUser(internal.AddUserRequest{UserID: usr1.ID, GroupID: grp1.ID}))
+	assert.NoError(suite.T(), groupData.AddUser(internal.AddUserRequest{UserID: usr2.ID, GroupID: grp1.ID}))
+
+	request := func(method string, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	members := func(t *testing.T, groupID uint) []uint {
+		response, err := groupData.GetUsersByGroupID(groupID, 0, 100)
+		assert.NoError(t, err)
+		var ids []uint
+		for _, user := range response.Users {
+			ids = append(ids, user.ID)
+		}
+		return ids
+	}
+
+	suite.T().Run("list and restore deleted user with membership", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("DELETE", fmt.Sprintf("/users/%d", usr1.ID)).Code)
+		assert.Equal(t, []uint{usr2.ID}, members(t, grp1.ID))
+
+		recorder := request("GET", "/users?deleted=true&perPage=1000")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response internal.UsersResponse
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		var deleted *internal.UserResponse
+		for i, user := range response.Users {
+			if user.ID == usr1.ID {
+				deleted = &response.Users[i]
+			}
+		}
+		if assert.NotNil(t, deleted) {
+			assert.NotNil(t, deleted.DeletedAt)
+		}
+
+		assert.Equal(t, http.StatusOK, request("POST", fmt.Sprintf("/users/%d/restore", usr1.ID)).Code)
+		assert.ElementsMatch(t, []uint{usr1.ID, usr2.ID}, members(t, grp1.ID))
+		assert.Equal(t, http.StatusBadRequest, request("POST", fmt.Sprintf("/users/%d/restore", usr1.ID)).Code)
+	})
+
+	suite.T().Run("keep membership removed before deletion", func(t *testing.T) {
+		assert.NoError(t, groupData.RemoveUser(grp1.ID, usr2.ID))
+		assert.Equal(t, http.StatusOK, request("DELETE", fmt.Sprintf("/groups/%d", grp1.ID)).Code)
+		assert.Equal(t, http.StatusOK, request("POST", fmt.Sprintf("/groups/%d/restore", grp1.ID)).Code)
+		assert.Equal(t, []uint{usr1.ID}, members(t, grp1.ID))
+	})
+
+	suite.T().Run("fail to restore user whose email is taken", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("DELETE", fmt.Sprintf("/users/%d", usr2.ID)).Code)
+		_, err := userData.CreateUser(internal.UserRequest{Name: "usr2", Email: usr2.Email, Password: "123455664546"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, request("POST", fmt.Sprintf("/users/%d/restore", usr2.ID)).Code)
+	})
+
+	suite.T().Run("purge deleted records", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("DELETE", fmt.Sprintf("/groups/%d", grp2.ID)).Code)
+		_, err := data.NewAccessRequestService(suite.testDB).CreateAccessRequest(internal.AccessRequestRequest{
+			UserID: usr2.ID, GroupID: grp1.ID, Justification: "my manager said so",
+		}, time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+		data.NewReviewService(suite.testDB)
+		assert.NoError(t, suite.testDB.Create(&data.ReviewItem{UserID: usr2.ID, GroupID: grp1.ID, Comment: "on leave"}).Error)
+		auditData := data.NewAuditService(suite.testDB)
+		assert.NoError(t, auditData.CreateAuditEvent(internal.AuditEvent{Type: internal.EventLoginFailed, Email: usr2.Email, IP: "192.0.2.1"}))
+		retention := service.NewRetentionService(userData, groupData, service.RetentionOptions{Period: -time.Minute})
+		assert.NoError(t, retention.Purge())
+		var count int64
+		assert.NoError(t, suite.testDB.Unscoped().Model(&data.User{}).Where("id = ?", usr2.ID).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+		assert.NoError(t, suite.testDB.Model(&data.AccessRequest{}).Where("user_id = ?", usr2.ID).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+		assert.NoError(t, suite.testDB.Model(&data.ReviewItem{}).Where("user_id = ?", usr2.ID).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+		var event data.AuditEvent
+		assert.NoError(t, suite.testDB.Where("type = ?", internal.EventLoginFailed).First(&event).Error)
+		assert.Empty(t, event.Email)
+		assert.Empty(t, event.IP)
+		assert.NoError(t, suite.testDB.Unscoped().Model(&data.Group{}).Where("id = ?", grp2.ID).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+		assert.Equal(t, http.StatusBadRequest, request("POST", fmt.Sprintf("/groups/%d/restore", grp2.ID)).Code)
+	})
+
+	suite.cleanAccessRequests()
+	suite.cleanReviews()
+	suite.testDB.Exec("DELETE FROM audit_events")
+	suite.cleanUserGroups()
+	suite.cleanGroups()
+	suite.cleanUsers()
+}
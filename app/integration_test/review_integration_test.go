@@ -0,0 +1,122 @@
+package integration_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/signing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestAccessReviews() {
+	groupData := data.NewGroupService(suite.testDB)
+	reviewData := data.NewReviewService(suite.testDB)
+	groupService := service.NewGroupService(groupData, suite.dynamicGroups())
+	keys := suite.keyManager()
+	reviewService := service.NewAccessReviewService(reviewData, groupService, groupData, data.NewUserService(suite.testDB),
+		service.NewAuditor(data.NewAuditService(suite.testDB)), service.AccessReviewOptions{Keys: keys})
+	grp1, grp2, usr1, usr2 := suite.addUsersAndGroups()
+	assert.NoError(suite.T(), groupData.AddUser(internal.AddUserRequest{UserID: usr1.ID, GroupID: grp1.ID}))
+	assert.NoError(suite.T(), groupData.SetMemberRole(grp1.ID, usr1.ID, internal.RoleOwner))
+	assert.NoError(suite.T(), groupData.AddUser(internal.AddUserRequest{UserID: usr2.ID, GroupID: grp2.ID}))
+	hasCode := func(err error, code serviceerror.ErrorCode) bool {
+		var srvError *serviceerror.ServiceError
+		return errors.As(err, &srvError) && srvError.Code == code
+	}
+	var campaign internal.ReviewCampaign
+
+	suite.T().Run("snapshot memberships", func(t *testing.T) {
+		var err error
+		campaign, err = reviewService.CreateCampaign(internal.ReviewCampaignRequest{
+			Name:            "q1",
+			GroupIDs:        []uint{grp1.ID, grp2.ID},
+			Reviewers:       []uint{usr1.ID},
+			Deadline:        time.Now().Add(time.Hour),
+			RevokeUndecided: true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, internal.CampaignOpen, campaign.Status)
+		assert.Equal(t, internal.ReviewSummary{Pending: 2}, campaign.Summary)
+
+		response, err := reviewService.GetReviewItems(campaign.ID, 1, 10, internal.ReviewItemsFilter{ReviewerID: usr1.ID})
+		assert.NoError(t, err)
+		if assert.Len(t, response.Items, 1) {
+			assert.Equal(t, usr2.ID, response.Items[0].UserID)
+		}
+	})
+
+	suite.T().Run("keep campaigns of other organizations apart", func(t *testing.T) {
+		other := reviewService.ForOrganization(99)
+		_, err := other.GetCampaign(campaign.ID)
+		assert.True(t, hasCode(err, serviceerror.ReviewNotFound))
+		response, err := other.GetReviewItems(campaign.ID, 1, 10, internal.ReviewItemsFilter{})
+		assert.NoError(t, err)
+		assert.Empty(t, response.Items)
+		_, err = other.Report(campaign.ID)
+		assert.True(t, hasCode(err, serviceerror.ReviewNotFound))
+		assert.True(t, hasCode(other.CloseCampaign(campaign.ID), serviceerror.ReviewNotFound))
+		_, err = other.CreateCampaign(internal.ReviewCampaignRequest{Name: "q1", GroupIDs: []uint{grp1.ID}, Deadline: time.Now().Add(time.Hour)})
+		assert.Error(t, err)
+	})
+
+	suite.T().Run("revoke membership", func(t *testing.T) {
+		response, err := reviewService.GetReviewItems(campaign.ID, 1, 10, internal.ReviewItemsFilter{ReviewerID: usr1.ID})
+		assert.NoError(t, err)
+		item := response.Items[0]
+		assert.NoError(t, reviewService.AuthorizeReview(internal.Caller{UserID: usr1.ID}, campaign.ID, item.ID))
+		assert.True(t, hasCode(reviewService.AuthorizeReview(internal.Caller{UserID: usr2.ID}, campaign.ID, item.ID), serviceerror.Forbidden))
+
+		item, err = reviewService.Revoke(campaign.ID, item.ID, usr1.ID, "left team")
+		assert.NoError(t, err)
+		assert.Equal(t, internal.ReviewRevoked, item.Decision)
+		role, err := groupData.GetMemberRole(grp2.ID, usr2.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, role)
+		_, err = reviewService.Certify(campaign.ID, item.ID, usr1.ID, "")
+		assert.True(t, hasCode(err, serviceerror.ReviewDecided))
+	})
+
+	suite.T().Run("close and report campaign", func(t *testing.T) {
+		assert.NoError(t, reviewService.CloseCampaign(campaign.ID))
+		assert.True(t, hasCode(reviewService.CloseCampaign(campaign.ID), serviceerror.ReviewDecided))
+		closed, err := reviewService.GetCampaign(campaign.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.CampaignClosed, closed.Status)
+		assert.Equal(t, internal.ReviewSummary{Revoked: 1, Undecided: 1}, closed.Summary)
+		role, err := groupData.GetMemberRole(grp1.ID, usr1.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.RoleOwner, role)
+
+		signed, err := reviewService.Report(campaign.ID)
+		assert.NoError(t, err)
+		var report internal.ReviewReport
+		assert.NoError(t, json.Unmarshal(signed.Report, &report))
+		assert.Len(t, report.Items, 2)
+		key, err := keys.Signing()
+		assert.NoError(t, err)
+		assert.Equal(t, key.ID, signed.KeyID)
+		signature, err := base64.RawURLEncoding.DecodeString(signed.Signature)
+		assert.NoError(t, err)
+		assert.NoError(t, signing.Verify(key.Key.Public(), signed.Report, signature))
+	})
+
+	suite.cleanUsers()
+	suite.cleanGroups()
+	suite.cleanUserGroups()
+	suite.cleanReviews()
+	suite.cleanSigningKeys()
+}
+
+func (suite *IntegrationTestSuite) cleanReviews() {
+	err := suite.testDB.Where("1 = 1").Delete(&data.ReviewItem{}).Error
+	assert.NoError(suite.T(), err)
+	err = suite.testDB.Where("1 = 1").Delete(&data.ReviewCampaign{}).Error
+	assert.NoError(suite.T(), err)
+}
@@ -0,0 +1,63 @@
+package integration_test
+
+import (
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestSigningKeys() {
+	signingKeyData := data.NewSigningKeyService(suite.testDB)
+	keyManager := service.NewKeyManager(signingKeyData, service.KeyManagerOptions{MasterKey: []byte("master"), TokenTTL: time.Hour})
+
+	suite.T().Run("rotate and keep retired key", func(t *testing.T) {
+		assert.NoError(t, keyManager.Init())
+		first, err := keyManager.Signing()
+		assert.NoError(t, err)
+		rotated, err := keyManager.Rotate()
+		assert.NoError(t, err)
+		assert.NotEqual(t, first.ID, rotated.ID)
+
+		keys, err := signingKeyData.GetSigningKeys()
+		assert.NoError(t, err)
+		if assert.Len(t, keys, 2) {
+			assert.Equal(t, rotated.ID, keys[0].ID)
+			assert.Nil(t, keys[0].RetiredAt)
+			assert.NotNil(t, keys[1].RetiredAt)
+		}
+		verification, err := keyManager.Verification()
+		assert.NoError(t, err)
+		assert.Len(t, verification, 2)
+
+		restarted := service.NewKeyManager(signingKeyData, service.KeyManagerOptions{MasterKey: []byte("master")})
+		key, err := restarted.Signing()
+		assert.NoError(t, err)
+		assert.Equal(t, rotated.ID, key.ID)
+	})
+
+	suite.T().Run("purge expired keys", func(t *testing.T) {
+		count, err := signingKeyData.PurgeSigningKeys(time.Now().Add(2 * time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+		keys, err := signingKeyData.GetSigningKeys()
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+	})
+
+	suite.cleanSigningKeys()
+}
+
+// keyManager returns signing keys for tests that issue tokens, they must
+// clean the signing keys.
+func (suite *IntegrationTestSuite) keyManager() internal.KeyManager {
+	return service.NewKeyManager(data.NewSigningKeyService(suite.testDB), service.KeyManagerOptions{MasterKey: []byte("master")})
+}
+
+func (suite *IntegrationTestSuite) cleanSigningKeys() {
+	err := suite.testDB.Where("1 = 1").Delete(&data.SigningKey{}).Error
+	assert.NoError(suite.T(), err)
+}
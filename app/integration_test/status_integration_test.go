@@ -0,0 +1,97 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestUserStatus() {
+	keys := suite.keyManager()
+	userData := data.NewUserService(suite.testDB)
+	statusService := service.NewUserStatusService(userData, service.NewAuditor(data.NewAuditService(suite.testDB)))
+	authService := service.NewAuthService(userData, data.NewMFAService(suite.testDB), data.NewAccessTokenService(suite.testDB),
+		suite.newLockoutService(userData), notifier.NewLogNotifier(""), nil, service.AuthOptions{Keys: keys, Admins: []string{"admin@gmail.com"}})
+	userService := service.NewUserService(userData, data.NewAttributeService(suite.testDB), suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
+	router := gin.Default()
+	router.GET("/users", httpservice.GetUsersHandler(userService))
+	authenticate := httpservice.AuthenticationMiddleware(authService, true)
+	router.POST("/users/:id/suspend", authenticate, httpservice.SuspendUserHandler(statusService))
+	router.POST("/users/:id/reactivate", authenticate, httpservice.ReactivateUserHandler(statusService))
+	router.POST("/users/:id/disable", authenticate, httpservice.DisableUserHandler(statusService))
+	router.POST("/login", httpservice.LoginHandler(authService))
+
+	user, err := userData.CreateUser(internal.UserRequest{
+		Name:     "test",
+		Email:    "test@gmail.com",
+		Password: "123455664546",
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), internal.StatusActive, user.Status)
+	adminUser, err := userData.CreateUser(internal.UserRequest{Name: "admin", Email: "admin@gmail.com", Password: "123455664546"})
+	assert.NoError(suite.T(), err)
+	suite.verifyEmail(adminUser.ID)
+	admin, err := authService.Login("admin@gmail.com", "123455664546", "10.0.0.2")
+	assert.NoError(suite.T(), err)
+
+	request := func(method string, path string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+admin.AccessToken)
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	login := func() int {
+		return request("POST", "/login", fmt.Sprintf(loginObj, "test@gmail.com", "123455664546")).Code
+	}
+
+	suite.T().Run("suspend and block login", func(t *testing.T) {
+		until := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		recorder := request("POST", fmt.Sprintf("/users/%d/suspend", user.ID), fmt.Sprintf(`{"reason":"abuse","until":"%s"}`, until))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, http.StatusForbidden, login())
+
+		recorder = request("GET", "/users?status=suspended", "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response internal.UsersResponse
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		assert.Equal(t, uint(1), response.Total)
+		if assert.Len(t, response.Users, 1) {
+			assert.Equal(t, "abuse", response.Users[0].StatusReason)
+			assert.NotNil(t, response.Users[0].SuspendedUntil)
+		}
+
+		recorder = request("POST", fmt.Sprintf("/users/%d/suspend", user.ID), "")
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+	})
+
+	suite.T().Run("reactivate ended suspension", func(t *testing.T) {
+		err := suite.testDB.Model(&data.User{}).Where("id = ?", user.ID).Update("suspended_until", time.Now().Add(-time.Minute)).Error
+		assert.NoError(t, err)
+		assert.NoError(t, statusService.ReactivateExpired())
+		assert.Equal(t, http.StatusOK, login())
+	})
+
+	suite.T().Run("disable and reactivate", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("POST", fmt.Sprintf("/users/%d/disable", user.ID), "").Code)
+		assert.Equal(t, http.StatusForbidden, login())
+		assert.Equal(t, http.StatusOK, request("POST", fmt.Sprintf("/users/%d/reactivate", user.ID), "").Code)
+		assert.Equal(t, http.StatusOK, login())
+	})
+
+	suite.testDB.Exec("DELETE FROM audit_events")
+	suite.cleanUsers()
+	suite.cleanSigningKeys()
+}
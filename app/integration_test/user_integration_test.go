@@ -10,6 +10,7 @@ import (
 	"usermanagement/app/internal"
 	"usermanagement/app/internal/data"
 	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
 	"usermanagement/app/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -27,7 +28,7 @@ const (
 
 func (suite *IntegrationTestSuite) TestCreateUser() {
 	dataService := data.NewUserService(suite.testDB)
-	userService := service.NewUserService(dataService)
+	userService := service.NewUserService(dataService, data.NewAttributeService(suite.testDB), suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
 	router := gin.Default()
 	router.POST("/", httpservice.CreateUserHandler(userService))
 
@@ -83,7 +84,7 @@ func (suite *IntegrationTestSuite) TestCreateUser() {
 
 func (suite *IntegrationTestSuite) TestUpdateUser() {
 	dataService := data.NewUserService(suite.testDB)
-	userService := service.NewUserService(dataService)
+	userService := service.NewUserService(dataService, data.NewAttributeService(suite.testDB), suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
 	router := gin.Default()
 	router.PUT("/users/:id", httpservice.UpdateUserHandler(userService))
 
@@ -104,7 +105,8 @@ func (suite *IntegrationTestSuite) TestUpdateUser() {
 			err = suite.testDB.Find(&updatedUser).Error
 			assert.NoError(t, err)
 			assert.Equal(t, "update", updatedUser.Name)
-			assert.Equal(t, "test.update@gmail.com", updatedUser.Email)
+			assert.Equal(t, "test@gmail.com", updatedUser.Email)
+			assert.Equal(t, "test.update@gmail.com", updatedUser.PendingEmail)
 		}
 	})
 
@@ -129,7 +131,7 @@ func (suite *IntegrationTestSuite) TestUpdateUser() {
 		}
 	})
 
-	suite.T().Run("update email alone successfully", func(t *testing.T) {
+	suite.T().Run("keep email change pending", func(t *testing.T) {
 		response, err := dataService.CreateUser(internal.UserRequest{
 			Name:     "test3",
 			Email:    "test3@gmail.com",
@@ -146,27 +148,28 @@ func (suite *IntegrationTestSuite) TestUpdateUser() {
 			err = suite.testDB.Find(&updatedUser).Error
 			assert.NoError(t, err)
 			assert.Equal(t, "test3", updatedUser.Name)
-			assert.Equal(t, "test3.update@gmail.com", updatedUser.Email)
+			assert.Equal(t, "test3@gmail.com", updatedUser.Email)
+			assert.Equal(t, "test3.update@gmail.com", updatedUser.PendingEmail)
 		}
 	})
 
 	suite.T().Run("fail updating same email", func(t *testing.T) {
 		response, err := dataService.CreateUser(internal.UserRequest{
-			Name:     "test3",
-			Email:    "test3@gmail.com",
+			Name:     "test4",
+			Email:    "test4@gmail.com",
 			Password: "dsfsfsdfdsfdsfsdfs",
 		})
 		assert.NoError(t, err)
 		recorder := httptest.NewRecorder()
 		req, _ := http.NewRequest("PUT", fmt.Sprintf("/users/%d", response.ID),
-			strings.NewReader(fmt.Sprintf(updateUserEmailObj, "test3@gmail.com")))
+			strings.NewReader(fmt.Sprintf(updateUserEmailObj, "test4@gmail.com")))
 		router.ServeHTTP(recorder, req)
 		assert.Equal(t, http.StatusBadRequest, recorder.Code)
 		updatedUser := data.User{ID: response.ID}
 		err = suite.testDB.Find(&updatedUser).Error
 		assert.NoError(t, err)
-		assert.Equal(t, "test3", updatedUser.Name)
-		assert.Equal(t, "test3@gmail.com", updatedUser.Email)
+		assert.Equal(t, "test4", updatedUser.Name)
+		assert.Equal(t, "test4@gmail.com", updatedUser.Email)
 	})
 
 	suite.cleanUsers()
@@ -174,7 +177,7 @@ func (suite *IntegrationTestSuite) TestUpdateUser() {
 
 func (suite *IntegrationTestSuite) TestDeleteUser() {
 	dataService := data.NewUserService(suite.testDB)
-	userService := service.NewUserService(dataService)
+	userService := service.NewUserService(dataService, data.NewAttributeService(suite.testDB), suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
 	router := gin.Default()
 	router.DELETE("/users/:id", httpservice.DeleteUserHandler(userService))
 
@@ -200,7 +203,7 @@ func (suite *IntegrationTestSuite) TestDeleteUser() {
 
 func (suite *IntegrationTestSuite) TestChangePassword() {
 	dataService := data.NewUserService(suite.testDB)
-	userService := service.NewUserService(dataService)
+	userService := service.NewUserService(dataService, data.NewAttributeService(suite.testDB), suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
 	router := gin.Default()
 	router.PUT("/:id/password", httpservice.ChangePasswordHandler(userService))
 
@@ -230,7 +233,7 @@ func (suite *IntegrationTestSuite) TestChangePassword() {
 
 func (suite *IntegrationTestSuite) TestGetUsers() {
 	dataService := data.NewUserService(suite.testDB)
-	userService := service.NewUserService(dataService)
+	userService := service.NewUserService(dataService, data.NewAttributeService(suite.testDB), suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
 	router := gin.Default()
 	router.GET("/", httpservice.GetUsersHandler(userService))
 
@@ -275,6 +278,27 @@ func (suite *IntegrationTestSuite) TestGetUsers() {
 	suite.cleanUsers()
 }
 
+// verifyEmail confirms the email of the user, as admins must have.
+func (suite *IntegrationTestSuite) verifyEmail(userID uint) {
+	err := suite.testDB.Model(&data.User{}).Where("id = ?", userID).Update("email_verified", true).Error
+	assert.NoError(suite.T(), err)
+}
+
+// loginAdmin creates an admin who verified its email and returns an auth
+// service knowing it with its access token. Tests using it must clean the
+// signing keys.
+func (suite *IntegrationTestSuite) loginAdmin(users internal.UserData) (internal.AuthService, string) {
+	authService := service.NewAuthService(users, data.NewMFAService(suite.testDB), data.NewAccessTokenService(suite.testDB),
+		suite.newLockoutService(users), notifier.NewLogNotifier(""), nil,
+		service.AuthOptions{Keys: suite.keyManager(), Admins: []string{"admin@gmail.com"}})
+	admin, err := users.CreateUser(internal.UserRequest{Name: "admin", Email: "admin@gmail.com", Password: "123455664546"})
+	assert.NoError(suite.T(), err)
+	suite.verifyEmail(admin.ID)
+	login, err := authService.Login("admin@gmail.com", "123455664546", "10.0.0.2")
+	assert.NoError(suite.T(), err)
+	return authService, login.AccessToken
+}
+
 func (suite *IntegrationTestSuite) cleanUsers() {
 	err := suite.testDB.Where("1 = 1").Delete(&data.User{}).Error
 	assert.NoError(suite.T(), err)
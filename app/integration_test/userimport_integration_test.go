@@ -0,0 +1,88 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/data"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *IntegrationTestSuite) TestImportExportUsers() {
+	userData := data.NewUserService(suite.testDB)
+	attributeData := data.NewAttributeService(suite.testDB)
+	groupData := data.NewGroupService(suite.testDB)
+	userService := service.NewUserService(userData, attributeData, suite.dynamicGroups(), notifier.NewLogNotifier(""), service.UserOptions{})
+	importService := service.NewUserImportService(userService, userData, attributeData, groupData, service.NewJobService(data.NewJobService(suite.testDB), service.JobOptions{}))
+	authService, adminToken := suite.loginAdmin(userData)
+	router := gin.Default()
+	router.POST("/users:method", httpservice.AuthenticationMiddleware(authService, false), httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"import": httpservice.ImportUsersHandler(importService),
+	}))
+	router.GET("/users:method", httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"export": httpservice.ExportUsersHandler(importService),
+	}))
+
+	assert.NoError(suite.T(), attributeData.SaveAttributeDefinition(internal.AttributeDefinition{Name: "level", Type: internal.AttributeNumber}))
+	_, err := groupData.CreateGroup(internal.GroupRequest{Name: "eng"})
+	assert.NoError(suite.T(), err)
+
+	importUsers := func(t *testing.T, query string, body string) internal.ImportUsersResponse {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/users:import"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", httpservice.CSVContentType)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response internal.ImportUsersResponse
+		assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		return response
+	}
+	csv := "name,email,password,group,attributes.level\n" +
+		"test1,test1@gmail.com,12345678,eng,1\n" +
+		"test2,test2@gmail.com,12345678,,2\n"
+
+	suite.T().Run("dry run creates nothing", func(t *testing.T) {
+		response := importUsers(t, "?dryRun=true", csv)
+		assert.Equal(t, 2, response.Created)
+		users, err := userService.GetUsers(1, 10, internal.UsersFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), users.Total)
+	})
+
+	suite.T().Run("import and upsert by email", func(t *testing.T) {
+		response := importUsers(t, "", csv)
+		assert.Equal(t, 2, response.Created)
+		response = importUsers(t, "", csv+"test3,test3@gmail.com,,,\n")
+		assert.Equal(t, 2, response.Unchanged)
+		assert.Equal(t, 1, response.Failed)
+		response = importUsers(t, "", "email,name\ntest2@gmail.com,renamed\n")
+		assert.Equal(t, 1, response.Updated)
+	})
+
+	suite.T().Run("export users", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users:export", nil)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+		if assert.Len(t, lines, 4) {
+			assert.Equal(t, "id,name,email,emailVerified,status,attributes.level", lines[0])
+			assert.True(t, strings.HasSuffix(lines[3], ",renamed,test2@gmail.com,false,active,2"))
+		}
+	})
+
+	assert.NoError(suite.T(), attributeData.DeleteAttributeDefinition("level"))
+	suite.cleanUserGroups()
+	suite.cleanUsers()
+	suite.cleanGroups()
+	suite.cleanSigningKeys()
+}
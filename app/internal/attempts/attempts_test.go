@@ -0,0 +1,71 @@
+package attempts_test
+
+import (
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/attempts"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, attempts.NewMemoryStore())
+}
+
+func TestRedisStore(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer server.Close()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	testStore(t, attempts.NewRedisStore(client))
+}
+
+func testStore(t *testing.T, store internal.AttemptStore) {
+	t.Run("count failures", func(t *testing.T) {
+		for i := int64(1); i <= 3; i++ {
+			response, err := store.RecordFailure("count", time.Hour)
+			assert.NoError(t, err)
+			assert.Equal(t, i, response.Failures)
+		}
+		response, err := store.GetAttempts("count")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), response.Failures)
+		assert.WithinDuration(t, time.Now(), response.LastFailure, time.Second)
+		assert.True(t, response.LockedUntil.IsZero())
+	})
+
+	t.Run("start over after window", func(t *testing.T) {
+		_, err := store.RecordFailure("window", 20*time.Millisecond)
+		assert.NoError(t, err)
+		time.Sleep(30 * time.Millisecond)
+		response, err := store.RecordFailure("window", 20*time.Millisecond)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), response.Failures)
+	})
+
+	t.Run("lock and reset", func(t *testing.T) {
+		until := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+		_, err := store.RecordFailure("lock", time.Hour)
+		assert.NoError(t, err)
+		assert.NoError(t, store.Lock("lock", until))
+		response, err := store.RecordFailure("lock", time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), response.Failures)
+		assert.True(t, until.Equal(response.LockedUntil))
+
+		assert.NoError(t, store.ResetAttempts("lock"))
+		response, err = store.GetAttempts("lock")
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Attempts{}, response)
+	})
+
+	t.Run("return nothing for unknown key", func(t *testing.T) {
+		response, err := store.GetAttempts("unknown")
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Attempts{}, response)
+	})
+}
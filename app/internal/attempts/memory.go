@@ -0,0 +1,99 @@
+// Package attempts provides the in-memory and Redis stores for failed
+// authentication attempts, the database store lives in the data package.
+package attempts
+
+import (
+	"sync"
+	"time"
+	"usermanagement/app/internal"
+)
+
+// sweepEvery is the number of recorded failures between removals of
+// forgotten entries, so the map can't grow without bound.
+const sweepEvery = 1000
+
+type entry struct {
+	attempts  internal.Attempts
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	writes  int
+}
+
+// NewMemoryStore keeps attempts in process. Counters are lost on restart and
+// not shared between instances, use the database or Redis store for that.
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{
+		entries: map[string]*entry{},
+	}
+}
+
+func (m *memoryStore) GetAttempts(key string) (response internal.Attempts, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		return response, nil
+	}
+	return e.attempts, nil
+}
+
+func (m *memoryStore) RecordFailure(key string, window time.Duration) (response internal.Attempts, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.writes++
+	if m.writes%sweepEvery == 0 {
+		m.sweep(now)
+	}
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{}
+		m.entries[key] = e
+	}
+	if now.Sub(e.attempts.LastFailure) > window {
+		e.attempts.Failures = 0
+	}
+	e.attempts.Failures++
+	e.attempts.LastFailure = now
+	e.expiresAt = later(now.Add(window), e.attempts.LockedUntil)
+	return e.attempts, nil
+}
+
+func (m *memoryStore) Lock(key string, until time.Time) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{}
+		m.entries[key] = e
+	}
+	e.attempts.LockedUntil = until
+	e.expiresAt = later(e.expiresAt, until)
+	return nil
+}
+
+func (m *memoryStore) ResetAttempts(key string) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *memoryStore) sweep(now time.Time) {
+	for key, e := range m.entries {
+		if now.After(e.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+func later(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,104 @@
+package attempts
+
+import (
+	"context"
+	"strconv"
+	"time"
+	"usermanagement/app/internal"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+const redisKeyPrefix = "usermanagement:attempts:"
+
+// recordFailureScript increments the failures of a hash, starting over when
+// the last failure is older than the window, and keeps the hash alive until
+// both the window and any lock have passed. Times are unix milliseconds.
+var recordFailureScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local last = tonumber(redis.call('HGET', KEYS[1], 'last') or '0')
+if now - last > window then
+	redis.call('HSET', KEYS[1], 'failures', 0)
+end
+local failures = redis.call('HINCRBY', KEYS[1], 'failures', 1)
+redis.call('HSET', KEYS[1], 'last', now)
+local locked = tonumber(redis.call('HGET', KEYS[1], 'locked') or '0')
+redis.call('PEXPIREAT', KEYS[1], math.max(now + window, locked))
+return {failures, locked}
+`)
+
+var lockScript = redis.NewScript(`
+local locked = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+redis.call('HSET', KEYS[1], 'locked', locked)
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 or now + ttl < locked then
+	redis.call('PEXPIREAT', KEYS[1], locked)
+end
+return 1
+`)
+
+type redisStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisStore keeps attempts in Redis or any server speaking its protocol,
+// so counters are shared between instances.
+func NewRedisStore(client redis.UniversalClient) *redisStore {
+	return &redisStore{
+		client: client,
+	}
+}
+
+func (r *redisStore) GetAttempts(key string) (response internal.Attempts, err error) {
+	values, err := r.client.HGetAll(context.Background(), redisKeyPrefix+key).Result()
+	if err != nil {
+		return response, errors.Wrap(err, "get login attempts failed")
+	}
+	response.Failures, _ = strconv.ParseInt(values["failures"], 10, 64)
+	response.LastFailure = fromMillis(values["last"])
+	response.LockedUntil = fromMillis(values["locked"])
+	return response, nil
+}
+
+func (r *redisStore) RecordFailure(key string, window time.Duration) (response internal.Attempts, err error) {
+	now := time.Now()
+	result, err := recordFailureScript.Run(context.Background(), r.client, []string{redisKeyPrefix + key},
+		now.UnixNano()/int64(time.Millisecond), window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return response, errors.Wrap(err, "record login failure failed")
+	}
+	response = internal.Attempts{
+		Failures:    result[0],
+		LastFailure: now,
+		LockedUntil: fromMillis(strconv.FormatInt(result[1], 10)),
+	}
+	return response, nil
+}
+
+func (r *redisStore) Lock(key string, until time.Time) (err error) {
+	err = lockScript.Run(context.Background(), r.client, []string{redisKeyPrefix + key},
+		until.UnixNano()/int64(time.Millisecond), time.Now().UnixNano()/int64(time.Millisecond)).Err()
+	if err != nil {
+		return errors.Wrap(err, "lock login attempts failed")
+	}
+	return nil
+}
+
+func (r *redisStore) ResetAttempts(key string) (err error) {
+	err = r.client.Del(context.Background(), redisKeyPrefix+key).Err()
+	if err != nil {
+		return errors.Wrap(err, "reset login attempts failed")
+	}
+	return nil
+}
+
+func fromMillis(value string) time.Time {
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || millis == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, millis*int64(time.Millisecond))
+}
@@ -1,40 +1,299 @@
 package internal
 
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
 //go:generate mockgen -source=data.go  -destination=mock/data.go -package=mock
 type UserData interface {
 	CreateUser(request UserRequest) (response UserResponse, err error)
 	UpdateUser(request UpdateUserRequest) (err error)
-	DeleteUser(id uint) (err error)
-	GetUsers(offset uint, limit uint) (response UsersResponse, err error)
+	DeleteUser(id uint, version uint) (err error)
+	GetUsers(offset uint, limit uint, filter UsersFilter) (response UsersResponse, err error)
+	ExportUsers(filter UsersFilter, export func(user UserResponse) error) (err error)
 	ChangePassword(userID uint, password string) (err error)
+	HashPassword(password string) (hash string, salt string)
+	GetUser(id uint) (response UserResponse, err error)
+	GetUserByEmail(email string) (response UserResponse, err error)
+	CreatePasswordReset(userID uint, tokenHash string, expiresAt time.Time) (err error)
+	ResetPassword(tokenHash string, password string) (userID uint, err error)
+	CreateEmailVerification(userID uint, email string, tokenHash string, expiresAt time.Time) (err error)
+	ConfirmEmailVerification(tokenHash string) (response UserResponse, previousEmail string, err error)
+	Authenticate(email string, password string) (response UserResponse, err error)
+	UpdateUserStatus(update UserStatusUpdate) (err error)
+	GetExpiredSuspensions(now time.Time) (response []UserResponse, err error)
+	RestoreUser(id uint) (err error)
+	PurgeUsers(before time.Time) (count int64, err error)
+	EraseUser(id uint) (response UserResponse, err error)
+	ForOrganization(organizationID uint) UserData
+}
+
+type AttributeData interface {
+	GetAttributeDefinitions() (response []AttributeDefinition, err error)
+	SaveAttributeDefinition(definition AttributeDefinition) (err error)
+	DeleteAttributeDefinition(name string) (err error)
 }
 
 type GroupData interface {
 	CreateGroup(request GroupRequest) (response GroupResponse, err error)
 	UpdateGroup(request UpdateGroupRequest) (err error)
-	DeleteGroup(id uint) (err error)
+	DeleteGroup(id uint, version uint) (err error)
 	GetUsersByGroupID(groupID uint, offset uint, limit uint) (response UsersResponse, err error)
-	GetGroups(offset uint, limit uint) (response GroupsResponse, err error)
-	AddUser(userID uint, groupID uint) (err error)
+	GetGroups(offset uint, limit uint, filter GroupsFilter) (response GroupsResponse, err error)
+	GetGroup(id uint) (response GroupResponse, err error)
+	AddUser(request AddUserRequest) (err error)
 	RemoveUser(groupID uint, userID uint) (err error)
+	RestoreGroup(id uint) (err error)
+	PurgeGroups(before time.Time) (count int64, err error)
+	GetMemberships(userID uint) (response []Membership, err error)
+	ExpireMemberships(now time.Time) (response []ExpiredMembership, err error)
+	AddUsers(groupID uint, userIDs []uint) (response BatchMembershipResponse, err error)
+	RemoveUsers(groupID uint, userIDs []uint) (response BatchMembershipResponse, err error)
+	ReplaceUsers(groupID uint, userIDs []uint) (response BatchMembershipResponse, err error)
+	GetMemberRole(groupID uint, userID uint) (role string, err error)
+	SetMemberRole(groupID uint, userID uint, role string) (err error)
+	SetGroupRule(id uint, rule string) (err error)
+	SyncMembers(groupID uint, matched []uint, checked []uint) (response BatchMembershipResponse, err error)
+	GetGroupOwners(groupID uint) (response []UserResponse, err error)
+	GetGroupMembers(groupID uint) (response []GroupMember, err error)
+	ForOrganization(organizationID uint) GroupData
+}
+
+// OrganizationData keeps the organizations users and groups belong to.
+type OrganizationData interface {
+	CreateOrganization(request OrganizationRequest) (response Organization, err error)
+	GetOrganization(id uint) (response Organization, err error)
+	GetOrganizations(offset uint, limit uint) (response OrganizationsResponse, err error)
+}
+
+type MFAData interface {
+	GetMFA(userID uint) (response MFA, err error)
+	SaveMFASecret(userID uint, secret string) (err error)
+	EnableMFA(userID uint, step int64, recoveryCodeHashes []string) (err error)
+	UseMFAStep(userID uint, step int64) (err error)
+	UseRecoveryCode(userID uint, codeHash string) (err error)
+	ResetMFA(userID uint) (err error)
+	CreateMFAChallenge(userID uint, tokenHash string, expiresAt time.Time) (err error)
+	ConsumeMFAChallenge(tokenHash string) (userID uint, err error)
+}
+
+type PasskeyData interface {
+	CreatePasskeySession(userID uint, ceremony string, challenge string, expiresAt time.Time) (err error)
+	ConsumePasskeySession(ceremony string, challenge string) (userID uint, err error)
+	CreatePasskey(passkey Passkey) (response PasskeyResponse, err error)
+	GetPasskeys(userID uint) (response []Passkey, err error)
+	GetPasskeyByCredentialID(credentialID string) (response Passkey, err error)
+	UsePasskey(id uint, signCount uint32) (err error)
+	RenamePasskey(userID uint, id uint, name string) (err error)
+	DeletePasskey(userID uint, id uint) (err error)
+}
+
+// AccessTokenData keeps the personal access tokens of users, revoked tokens
+// are removed.
+type AccessTokenData interface {
+	CreateAccessToken(token AccessToken) (response AccessTokenResponse, err error)
+	GetAccessTokens(userID uint) (response []AccessTokenResponse, err error)
+	GetAccessTokenByHash(tokenHash string) (response AccessToken, err error)
+	UseAccessToken(id uint, usedAt time.Time) (err error)
+	RevokeAccessToken(userID uint, id uint) (err error)
+	RevokeClientAccessToken(clientID string, tokenHash string) (err error)
+}
+
+// OAuthData keeps the clients registered with the authorization server and
+// their authorization codes, a code can only be consumed once.
+type OAuthData interface {
+	CreateClient(client OAuthClient, secret string) (response OAuthClient, err error)
+	GetClient(clientID string) (response OAuthClient, err error)
+	AuthenticateClient(clientID string, secret string) (response OAuthClient, err error)
+	CreateAuthorizationCode(code OAuthAuthorizationCode) (err error)
+	ConsumeAuthorizationCode(codeHash string) (response OAuthAuthorizationCode, err error)
+}
+
+// SigningKeyData keeps the signing keys, newest first. RotateSigningKey adds
+// the active key and retires the one before at once, a retired key is kept
+// until expiresAt.
+type SigningKeyData interface {
+	GetSigningKeys() (response []StoredSigningKey, err error)
+	RotateSigningKey(key StoredSigningKey, expiresAt time.Time) (err error)
+	PurgeSigningKeys(now time.Time) (count int64, err error)
+}
+
+// AttemptStore keeps failed authentication attempts per key, a key is a user
+// or a source IP. Entries are forgotten once both the failure window and any
+// lock have passed.
+type AttemptStore interface {
+	GetAttempts(key string) (response Attempts, err error)
+	RecordFailure(key string, window time.Duration) (response Attempts, err error)
+	Lock(key string, until time.Time) (err error)
+	ResetAttempts(key string) (err error)
+}
+
+// UserAttemptKey is the AttemptStore key of the user with the email in the
+// organization, emails are only unique within one.
+func UserAttemptKey(organizationID uint, email string) string {
+	return fmt.Sprintf("user:%d:%s", organizationID, strings.ToLower(strings.TrimSpace(email)))
+}
+
+// IdempotencyStore keeps the responses of requests sent with an
+// Idempotency-Key until the key expires. A reserved key has no response until
+// the request it was reserved for completes. Keys are unique within a scope,
+// the same key of two callers are different keys.
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(scope string, key string, fingerprint string, expiresAt time.Time) (response IdempotencyRecord, reserved bool, err error)
+	SaveIdempotentResponse(scope string, key string, response IdempotentResponse) (err error)
+	ReleaseIdempotencyKey(scope string, key string) (err error)
+	PurgeIdempotencyKeys(now time.Time) (err error)
+}
+
+// JobData keeps the job queue. A worker claims a job for a lease it renews
+// while the job runs, a job whose lease ran out can be claimed again. Updates
+// of a claimed job only apply while the worker still holds it.
+type JobData interface {
+	CreateJob(request JobRequest) (response Job, err error)
+	GetJob(id uint) (response Job, err error)
+	ClaimJob(worker string, leaseUntil time.Time) (response Job, claimed bool, err error)
+	RenewJob(id uint, worker string, progress JobProgress, leaseUntil time.Time) (cancel bool, err error)
+	FinishJob(id uint, worker string, update JobUpdate) (err error)
+	CancelJob(id uint) (response Job, err error)
+	PurgeJobs(before time.Time) (count int64, err error)
+}
+
+// AccessRequestData keeps the requests of users to join groups. A user has at
+// most one pending request per group and only pending requests that haven't
+// expired can be decided. Requests belong to the organization of their group.
+type AccessRequestData interface {
+	CreateAccessRequest(request AccessRequestRequest, expiresAt time.Time) (response AccessRequest, err error)
+	GetAccessRequest(id uint) (response AccessRequest, err error)
+	GetAccessRequests(offset uint, limit uint, filter AccessRequestsFilter) (response AccessRequestsResponse, err error)
+	DecideAccessRequest(decision AccessDecision) (response AccessRequest, err error)
+	ExpireAccessRequests(now time.Time) (response []AccessRequest, err error)
+	ForOrganization(organizationID uint) AccessRequestData
 }
 
+// AccessReviewData keeps review campaigns and their items, one per reviewed
+// membership, campaigns belong to an organization. Only pending items of open
+// campaigns can be decided, closing a campaign leaves its pending items
+// undecided.
+type AccessReviewData interface {
+	CreateCampaign(request ReviewCampaignRequest, items []ReviewItem) (response ReviewCampaign, err error)
+	GetCampaign(id uint) (response ReviewCampaign, err error)
+	GetCampaigns(offset uint, limit uint, status string) (response ReviewCampaignsResponse, err error)
+	GetDueCampaigns(now time.Time) (response []ReviewCampaign, err error)
+	GetReviewItem(campaignID uint, id uint) (response ReviewItem, err error)
+	GetReviewItems(campaignID uint, offset uint, limit uint, filter ReviewItemsFilter) (response ReviewItemsResponse, err error)
+	DecideReviewItem(decision ReviewDecision) (response ReviewItem, err error)
+	CloseCampaign(id uint) (err error)
+	ForOrganization(organizationID uint) AccessReviewData
+}
+
+type AuditData interface {
+	CreateAuditEvent(event AuditEvent) (err error)
+	GetAuditEvents(userID uint, email string) (response []AuditEvent, err error)
+}
+
+// User statuses. Only active users can log in, pending users haven't been
+// activated yet, suspended users are blocked until reactivated or until their
+// suspension ends and disabled users are blocked until reactivated.
+const (
+	StatusActive    = "active"
+	StatusPending   = "pending"
+	StatusSuspended = "suspended"
+	StatusDisabled  = "disabled"
+)
+
+// User types. A person logs in with a password, a service account has none
+// and authenticates with personal access tokens only.
+const (
+	UserPerson         = "person"
+	UserServiceAccount = "service"
+)
+
+// UserRequest creates a user, a person unless Type is UserServiceAccount.
+// PasswordHash and Salt, from UserData.HashPassword, stand for a Password
+// hashed before.
 type UserRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name         string                 `json:"name"`
+	Email        string                 `json:"email"`
+	Password     string                 `json:"password"`
+	PasswordHash string                 `json:"-"`
+	Salt         string                 `json:"-"`
+	Status       string                 `json:"status"`
+	Type         string                 `json:"type"`
+	Attributes   map[string]interface{} `json:"attributes"`
 }
 
+// UpdateUserRequest changes the given fields, Attributes are merged into the
+// user's attributes and a nil value removes an attribute. A non zero Version
+// must be the user's current version.
 type UpdateUserRequest struct {
-	ID    uint   `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID         uint                   `json:"id"`
+	Name       string                 `json:"name"`
+	Email      string                 `json:"email"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Version    uint                   `json:"version"`
 }
 
 type UserResponse struct {
-	ID    uint   `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID             uint                   `json:"id"`
+	OrganizationID uint                   `json:"organizationId,omitempty"`
+	Type           string                 `json:"type,omitempty"`
+	Name           string                 `json:"name"`
+	Email          string                 `json:"email"`
+	EmailVerified  bool                   `json:"emailVerified"`
+	PendingEmail   string                 `json:"pendingEmail,omitempty"`
+	Status         string                 `json:"status"`
+	StatusReason   string                 `json:"statusReason,omitempty"`
+	SuspendedUntil *time.Time             `json:"suspendedUntil,omitempty"`
+	DeletedAt      *time.Time             `json:"deletedAt,omitempty"`
+	Attributes     map[string]interface{} `json:"attributes,omitempty"`
+	Version        uint                   `json:"-"`
+}
+
+// UsersFilter narrows a user listing, Deleted lists only deleted users, Type
+// only users of that type and Attributes only users having all the given
+// attribute values.
+type UsersFilter struct {
+	Status     string
+	Type       string
+	Deleted    bool
+	Attributes map[string]interface{}
+}
+
+// Attribute types, values are JSON strings, numbers or booleans.
+const (
+	AttributeString  = "string"
+	AttributeNumber  = "number"
+	AttributeBoolean = "boolean"
+)
+
+// AttributeDefinition describes a custom user attribute. Pattern and Enum only
+// apply to strings, a Unique value can only be held by one user that isn't
+// deleted.
+type AttributeDefinition struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Unique   bool     `json:"unique"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+}
+
+type AttributeDefinitionsResponse struct {
+	Attributes []AttributeDefinition `json:"attributes"`
+}
+
+// UserStatusUpdate moves a user from status From to To, it fails when the
+// user's status is no longer From.
+type UserStatusUpdate struct {
+	ID     uint
+	From   string
+	To     string
+	Reason string
+	Until  *time.Time
 }
 
 type UsersResponse struct {
@@ -44,9 +303,91 @@ type UsersResponse struct {
 	PerPage uint           `json:"perPage"`
 }
 
+// ImportUserRow is a row of a user import, numbered from 1. Users are matched
+// by email, a new user needs a password while Password and Status are ignored
+// for existing users. Group names a group to add the user to and text
+// attribute values are converted to their attribute's type. Error tells why
+// the row couldn't be read. A queued import carries PasswordHash and Salt
+// instead of Password.
+type ImportUserRow struct {
+	Row          int
+	Name         string
+	Email        string
+	Password     string
+	PasswordHash string
+	Salt         string
+	Status       string
+	Group        string
+	Attributes   map[string]interface{}
+	Error        string
+}
+
+// ImportUsersRequest imports the rows, or with DryRun only checks them. An
+// import with an OrganizationID only sees the users and groups of that
+// organization. CreatedBy is the user following an import queued as a job.
+type ImportUsersRequest struct {
+	Rows           []ImportUserRow
+	DryRun         bool
+	OrganizationID *uint
+	CreatedBy      uint
+}
+
+// Import row actions. A failed row changed nothing, a created or updated row
+// has an Error when adding the user to its group failed.
+const (
+	ImportCreated   = "created"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+	ImportFailed    = "failed"
+)
+
+type ImportUserResult struct {
+	Row    int    `json:"row"`
+	Email  string `json:"email,omitempty"`
+	Action string `json:"action"`
+	UserID uint   `json:"userId,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportUsersResponse struct {
+	DryRun    bool               `json:"dryRun"`
+	Created   int                `json:"created"`
+	Updated   int                `json:"updated"`
+	Unchanged int                `json:"unchanged"`
+	Failed    int                `json:"failed"`
+	Results   []ImportUserResult `json:"results"`
+}
+
+// UserExporter writes the users of an export. Begin is called once with the
+// names of the custom attributes before the first user.
+type UserExporter interface {
+	Begin(attributes []string) (err error)
+	Export(user UserResponse) (err error)
+}
+
+// Group types. The members of a static group are added and removed by hand,
+// those of a dynamic group are the users matching its rule.
+const (
+	GroupStatic  = "static"
+	GroupDynamic = "dynamic"
+)
+
 type GroupResponse struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID             uint       `json:"id"`
+	OrganizationID uint       `json:"organizationId,omitempty"`
+	Name           string     `json:"name"`
+	Type           string     `json:"type,omitempty"`
+	Rule           string     `json:"rule,omitempty"`
+	DeletedAt      *time.Time `json:"deletedAt,omitempty"`
+	Version        uint       `json:"-"`
+}
+
+// GroupsFilter narrows a group listing, Deleted lists only deleted groups,
+// Name only the group with that name and Type only groups of that type.
+type GroupsFilter struct {
+	Deleted bool
+	Name    string
+	Type    string
 }
 
 type GroupsResponse struct {
@@ -56,11 +397,718 @@ type GroupsResponse struct {
 	PerPage uint            `json:"perPage"`
 }
 
+// GroupRequest creates a group, static unless Type is GroupDynamic. Rule is
+// the membership rule of a dynamic group, see package rule.
+// GroupRequest creates a group, OwnerID is the user who becomes its first
+// owner.
 type GroupRequest struct {
-	Name string `json:"name"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Rule    string `json:"rule"`
+	OwnerID uint   `json:"ownerId"`
 }
 
+// UpdateGroupRequest renames a group, a non zero Version must be the group's
+// current version.
 type UpdateGroupRequest struct {
-	ID   uint   `json:"id"`
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Version uint   `json:"version"`
+}
+
+type MFA struct {
+	UserID   uint
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"`
+	QRCode     []byte `json:"qrCode"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type LoginResponse struct {
+	AccessToken string `json:"accessToken,omitempty"`
+	TokenType   string `json:"tokenType,omitempty"`
+	ExpiresIn   int64  `json:"expiresIn,omitempty"`
+	MFARequired bool   `json:"mfaRequired,omitempty"`
+	MFAToken    string `json:"mfaToken,omitempty"`
+}
+
+// Caller is the authenticated user of a request, an Admin may manage every
+// group of every organization. Scopes limit a caller authenticated with a
+// personal access token, they are nil for a caller who logged in.
+type Caller struct {
+	UserID         uint
+	OrganizationID uint
+	Admin          bool
+	Scopes         []string
+}
+
+// Organization is a tenant, its users and groups are isolated from those of
+// other organizations. Emails and group names are unique per organization.
+// Users and groups of no organization are in the default one with id 0.
+type Organization struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type OrganizationRequest struct {
 	Name string `json:"name"`
 }
+
+type OrganizationsResponse struct {
+	Organizations []Organization `json:"organizations"`
+	Total         uint           `json:"total"`
+	Page          uint           `json:"page"`
+	PerPage       uint           `json:"perPage"`
+}
+
+type Passkey struct {
+	ID           uint
+	UserID       uint
+	CredentialID string
+	PublicKey    []byte
+	SignCount    uint32
+	Transports   []string
+	Name         string
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+type PasskeyResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+type PasskeysResponse struct {
+	Passkeys []PasskeyResponse `json:"passkeys"`
+}
+
+// AccessTokenPrefix starts every personal access token, it tells them from
+// the access tokens issued on login.
+const AccessTokenPrefix = "pat_"
+
+// AccessToken is a personal access token as stored, only the hash of the
+// token is kept. Tokens issued to an OAuth client have its ClientID.
+type AccessToken struct {
+	ID         uint
+	UserID     uint
+	ClientID   string
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+// AccessTokenRequest creates a personal access token. Scopes are
+// <resource>:read or <resource>:write of the users, groups, organizations,
+// accessRequests, reviews, attributes and jobs resources, write includes
+// read. A token without ExpiresAt doesn't expire.
+type AccessTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type AccessTokenResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// CreatedAccessToken holds the token itself, which is only shown once.
+type CreatedAccessToken struct {
+	AccessTokenResponse
+	Token string `json:"token"`
+}
+
+type AccessTokensResponse struct {
+	Tokens []AccessTokenResponse `json:"tokens"`
+}
+
+// OAuthTokenPrefix starts every access token issued to an OAuth client, they
+// authenticate like personal access tokens.
+const OAuthTokenPrefix = "oat_"
+
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+)
+
+// OAuthClient is an application registered to obtain access tokens. A
+// confidential client has a secret, a public one can only use the
+// authorization code grant. Tokens of the client credentials grant act as the
+// service account ServiceAccountID.
+type OAuthClient struct {
+	ID               uint      `json:"id"`
+	ClientID         string    `json:"clientId"`
+	Name             string    `json:"name"`
+	Confidential     bool      `json:"confidential"`
+	RedirectURIs     []string  `json:"redirectUris"`
+	GrantTypes       []string  `json:"grantTypes"`
+	Scopes           []string  `json:"scopes"`
+	ServiceAccountID uint      `json:"serviceAccountId,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// OAuthClientRequest registers a client. Scopes are those of personal access
+// tokens, the client can't be granted others.
+type OAuthClientRequest struct {
+	Name             string   `json:"name"`
+	Confidential     bool     `json:"confidential"`
+	RedirectURIs     []string `json:"redirectUris"`
+	GrantTypes       []string `json:"grantTypes"`
+	Scopes           []string `json:"scopes"`
+	ServiceAccountID uint     `json:"serviceAccountId"`
+}
+
+// RegisteredOAuthClient holds the secret of a confidential client, which is
+// only shown once.
+type RegisteredOAuthClient struct {
+	OAuthClient
+	ClientSecret string `json:"clientSecret,omitempty"`
+}
+
+// OAuthAuthorizationCode is an authorization code as stored, only the hash of
+// the code is kept. RedirectURI is the one of the authorization request, empty
+// when it named none.
+type OAuthAuthorizationCode struct {
+	ClientID      string
+	UserID        uint
+	CodeHash      string
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	Nonce         string
+	ExpiresAt     time.Time
+}
+
+// OAuthAuthorizeRequest asks for an authorization code the user UserID grants
+// the client, Scope is space separated.
+type OAuthAuthorizeRequest struct {
+	UserID              uint
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+}
+
+// OAuthTokenRequest is a request to the token endpoint, the client
+// authenticates with ClientSecret unless it is public.
+type OAuthTokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	Scope        string
+}
+
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+// OAuthIntrospection describes a token as of RFC 7662, an inactive token has
+// no other fields.
+type OAuthIntrospection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+}
+
+// OpenID Connect scopes a client may be registered with besides those of
+// personal access tokens, they select the claims about the user.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+	ScopeGroups  = "groups"
+)
+
+// IDTokenRequest asks for an ID token of the user for the client, Scopes
+// select the claims besides the standard ones.
+type IDTokenRequest struct {
+	UserID   uint
+	ClientID string
+	Nonce    string
+	Scopes   []string
+}
+
+// UserInfo are the claims about a user, profile adds the name, email the
+// email and whether it is verified and groups the names of the groups the user
+// is a member of.
+type UserInfo struct {
+	Sub           string   `json:"sub"`
+	Name          string   `json:"name,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	EmailVerified *bool    `json:"email_verified,omitempty"`
+	Groups        []string `json:"groups,omitempty"`
+}
+
+// OIDCDiscovery is the OpenID Provider metadata of OpenID Connect Discovery.
+type OIDCDiscovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// SigningKey is a private key tokens are signed with, ID is the kid naming it
+// in the header of the tokens.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.Signer
+}
+
+// JSONWebKey is a public key as of RFC 7517, N and E are set for RSA keys and
+// Crv and X for Ed25519 keys.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// Statuses of signing keys, the active key signs and retired keys only verify
+// tokens signed before they retired.
+const (
+	SigningKeyActive  = "active"
+	SigningKeyRetired = "retired"
+)
+
+// StoredSigningKey is a signing key as stored, its private key encrypted with
+// the master key. A retired key is kept until ExpiresAt.
+type StoredSigningKey struct {
+	ID           string
+	Algorithm    string
+	EncryptedKey []byte
+	CreatedAt    time.Time
+	RetiredAt    *time.Time
+	ExpiresAt    *time.Time
+}
+
+// SigningKeyInfo describes a signing key without its private key.
+type SigningKeyInfo struct {
+	ID        string     `json:"id"`
+	Algorithm string     `json:"algorithm"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RetiredAt *time.Time `json:"retiredAt,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type SigningKeysResponse struct {
+	Keys []SigningKeyInfo `json:"keys"`
+}
+
+type Attempts struct {
+	Failures    int64
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// Job statuses. A queued job waits for a worker, or for its next attempt
+// after a failure, the other statuses after running are final.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Job types.
+const (
+	JobImportUsers       = "users.import"
+	JobPurgeRetention    = "retention.purge"
+	JobSendPasswordReset = "auth.passwordReset"
+)
+
+// PasswordResetRequest is the payload of JobSendPasswordReset, a request made
+// for an organization looks the email up in it.
+type PasswordResetRequest struct {
+	Email          string `json:"email"`
+	OrganizationID *uint  `json:"organizationId,omitempty"`
+}
+
+// JobRequest queues a job, CreatedBy is the user who may follow it, none for
+// jobs of the server.
+type JobRequest struct {
+	Type        string
+	Payload     []byte
+	MaxAttempts int
+	CreatedBy   uint
+}
+
+type JobProgress struct {
+	Done  int64 `json:"done"`
+	Total int64 `json:"total"`
+}
+
+// Job is a long-running operation, its payload is dropped once it finished.
+type Job struct {
+	ID              uint            `json:"id"`
+	Type            string          `json:"type"`
+	Status          string          `json:"status"`
+	Payload         []byte          `json:"-"`
+	Progress        JobProgress     `json:"progress"`
+	Result          json.RawMessage `json:"result,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	Attempts        int             `json:"attempts"`
+	MaxAttempts     int             `json:"maxAttempts"`
+	CreatedBy       uint            `json:"createdBy,omitempty"`
+	CancelRequested bool            `json:"cancelRequested,omitempty"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	StartedAt       *time.Time      `json:"startedAt,omitempty"`
+	FinishedAt      *time.Time      `json:"finishedAt,omitempty"`
+}
+
+// JobUpdate ends an attempt of a job, a queued Status retries it at RunAt.
+type JobUpdate struct {
+	Status   string
+	Progress JobProgress
+	Result   []byte
+	Error    string
+	RunAt    time.Time
+}
+
+// IdempotencyRecord is a reserved Idempotency-Key, Fingerprint identifies the
+// request it was first sent with and Response is nil while that request runs.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	Response    *IdempotentResponse
+	ExpiresAt   time.Time
+}
+
+type IdempotentResponse struct {
+	Status int
+	Header map[string]string
+	Body   []byte
+}
+
+const (
+	EventLoginSucceeded    = "login_succeeded"
+	EventLoginFailed       = "login_failed"
+	EventLoginThrottled    = "login_throttled"
+	EventAccountLocked     = "account_locked"
+	EventIPLocked          = "ip_locked"
+	EventAccountUnlocked   = "account_unlocked"
+	EventUserSuspended     = "user_suspended"
+	EventUserReactivated   = "user_reactivated"
+	EventUserDisabled      = "user_disabled"
+	EventUserErased        = "user_erased"
+	EventMembershipExpired = "membership_expired"
+	EventAccessApproved    = "access_approved"
+	EventAccessDenied      = "access_denied"
+	EventAccessCertified   = "access_certified"
+	EventAccessRevoked     = "access_revoked"
+)
+
+type AuditEvent struct {
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"`
+	UserID    uint      `json:"userId,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Roles of a group member. Managers add and remove members, owners also
+// manage roles, a group keeps at least one owner once it has one.
+const (
+	RoleMember  = "member"
+	RoleManager = "manager"
+	RoleOwner   = "owner"
+)
+
+// AddUserRequest adds a user to a group. A membership with StartsAt grants
+// access from then on, one with ExpiresAt is removed once it passed.
+type AddUserRequest struct {
+	UserID    uint
+	GroupID   uint
+	StartsAt  *time.Time
+	ExpiresAt *time.Time
+}
+
+type Membership struct {
+	GroupID   uint       `json:"groupId"`
+	GroupName string     `json:"groupName"`
+	Role      string     `json:"role"`
+	JoinedAt  time.Time  `json:"joinedAt"`
+	StartsAt  *time.Time `json:"startsAt,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RemovedAt *time.Time `json:"removedAt,omitempty"`
+}
+
+// ExpiredMembership is a membership ExpireMemberships removed.
+type ExpiredMembership struct {
+	UserID    uint
+	GroupID   uint
+	GroupName string
+	ExpiresAt time.Time
+}
+
+// Outcomes of a user in a batch membership change.
+const (
+	MembershipAdded             = "added"
+	MembershipRemoved           = "removed"
+	MembershipAlreadyMember     = "alreadyMember"
+	MembershipNotMember         = "notMember"
+	MembershipUnknownUser       = "unknownUser"
+	MembershipOtherGroup        = "memberOfOtherGroup"
+	MembershipLastOwner         = "lastOwner"
+	MembershipOtherOrganization = "memberOfOtherOrganization"
+)
+
+type MembershipResult struct {
+	UserID  uint   `json:"userId"`
+	Outcome string `json:"outcome"`
+}
+
+// BatchMembershipResponse reports a batch membership change per user, an
+// unknown user, one in another group or organization or the last owner counts
+// as failed.
+type BatchMembershipResponse struct {
+	Added     int                `json:"added"`
+	Removed   int                `json:"removed"`
+	Unchanged int                `json:"unchanged"`
+	Failed    int                `json:"failed"`
+	Results   []MembershipResult `json:"results"`
+}
+
+// Access request statuses. A pending request waits for an owner of the group,
+// or an admin, to approve or deny it and expires when nobody does in time.
+const (
+	AccessPending  = "pending"
+	AccessApproved = "approved"
+	AccessDenied   = "denied"
+	AccessExpired  = "expired"
+)
+
+// AccessRequestRequest asks for the user to be added to the group.
+type AccessRequestRequest struct {
+	UserID        uint   `json:"userId"`
+	GroupID       uint   `json:"groupId"`
+	Justification string `json:"justification"`
+}
+
+// AccessRequest is a request to join a group, DecidedBy is the approver of a
+// decided request when it was authenticated.
+type AccessRequest struct {
+	ID            uint       `json:"id"`
+	UserID        uint       `json:"userId"`
+	GroupID       uint       `json:"groupId"`
+	Justification string     `json:"justification,omitempty"`
+	Status        string     `json:"status"`
+	DecidedBy     uint       `json:"decidedBy,omitempty"`
+	Reason        string     `json:"reason,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	DecidedAt     *time.Time `json:"decidedAt,omitempty"`
+}
+
+// AccessRequestsFilter narrows a listing to the requests of a user, for a
+// group or with a status.
+type AccessRequestsFilter struct {
+	UserID  uint
+	GroupID uint
+	Status  string
+}
+
+type AccessRequestsResponse struct {
+	AccessRequests []AccessRequest `json:"accessRequests"`
+	Total          uint            `json:"total"`
+	Page           uint            `json:"page"`
+	PerPage        uint            `json:"perPage"`
+}
+
+// AccessDecision approves or denies a pending access request.
+type AccessDecision struct {
+	ID        uint
+	Status    string
+	DecidedBy uint
+	Reason    string
+}
+
+// GroupMember is a member of a group whose membership has started and not
+// expired.
+type GroupMember struct {
+	UserID uint
+	Role   string
+}
+
+// Review campaign statuses, an open campaign closes at its deadline or when
+// closed by hand.
+const (
+	CampaignOpen   = "open"
+	CampaignClosed = "closed"
+)
+
+// Decisions of a reviewed membership. An item still pending when its campaign
+// closes is undecided, or revoked if the campaign revokes undecided items.
+const (
+	ReviewPending   = "pending"
+	ReviewCertified = "certified"
+	ReviewRevoked   = "revoked"
+	ReviewUndecided = "undecided"
+)
+
+// ReviewCampaignRequest reviews the memberships of the groups by the
+// Reviewers, or by the owners of each group when none are given.
+type ReviewCampaignRequest struct {
+	Name            string    `json:"name"`
+	GroupIDs        []uint    `json:"groupIds"`
+	Reviewers       []uint    `json:"reviewers"`
+	Deadline        time.Time `json:"deadline"`
+	RevokeUndecided bool      `json:"revokeUndecided"`
+}
+
+type ReviewSummary struct {
+	Pending   int `json:"pending"`
+	Certified int `json:"certified"`
+	Revoked   int `json:"revoked"`
+	Undecided int `json:"undecided"`
+}
+
+type ReviewCampaign struct {
+	ID              uint          `json:"id"`
+	OrganizationID  uint          `json:"organizationId,omitempty"`
+	Name            string        `json:"name"`
+	GroupIDs        []uint        `json:"groupIds"`
+	Status          string        `json:"status"`
+	Deadline        time.Time     `json:"deadline"`
+	RevokeUndecided bool          `json:"revokeUndecided"`
+	Summary         ReviewSummary `json:"summary"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	ClosedAt        *time.Time    `json:"closedAt,omitempty"`
+}
+
+type ReviewCampaignsResponse struct {
+	Campaigns []ReviewCampaign `json:"campaigns"`
+	Total     uint             `json:"total"`
+	Page      uint             `json:"page"`
+	PerPage   uint             `json:"perPage"`
+}
+
+// ReviewItem is a membership as it was when its campaign started. Items
+// without ReviewerID are reviewed by admins.
+type ReviewItem struct {
+	ID         uint       `json:"id"`
+	CampaignID uint       `json:"campaignId"`
+	UserID     uint       `json:"userId"`
+	GroupID    uint       `json:"groupId"`
+	Role       string     `json:"role"`
+	ReviewerID uint       `json:"reviewerId,omitempty"`
+	Decision   string     `json:"decision"`
+	DecidedBy  uint       `json:"decidedBy,omitempty"`
+	Comment    string     `json:"comment,omitempty"`
+	DecidedAt  *time.Time `json:"decidedAt,omitempty"`
+}
+
+// ReviewItemsFilter narrows a listing to the items of a reviewer or with a
+// decision.
+type ReviewItemsFilter struct {
+	ReviewerID uint
+	Decision   string
+}
+
+type ReviewItemsResponse struct {
+	Items   []ReviewItem `json:"items"`
+	Total   uint         `json:"total"`
+	Page    uint         `json:"page"`
+	PerPage uint         `json:"perPage"`
+}
+
+// ReviewDecision decides a pending item of an open campaign.
+type ReviewDecision struct {
+	CampaignID uint
+	ItemID     uint
+	Decision   string
+	DecidedBy  uint
+	Comment    string
+}
+
+// ReviewReport is the outcome of a campaign for auditors.
+type ReviewReport struct {
+	Campaign    ReviewCampaign `json:"campaign"`
+	Items       []ReviewItem   `json:"items"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+}
+
+// SignedReviewReport carries a report with the signature of its exact bytes
+// in base64url. KeyID names the signing key among the published JSON Web
+// Keys, Algorithm is its JWS algorithm.
+type SignedReviewReport struct {
+	Report    json.RawMessage `json:"report"`
+	KeyID     string          `json:"kid"`
+	Algorithm string          `json:"algorithm"`
+	Signature string          `json:"signature"`
+}
+
+// Session is a successful login, access tokens are stateless so logins are
+// the only sessions kept.
+type Session struct {
+	IP        string    `json:"ip,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UserExport is everything kept about a user, returned on a data subject
+// access request.
+type UserExport struct {
+	ExportedAt  time.Time         `json:"exportedAt"`
+	Profile     UserResponse      `json:"profile"`
+	Memberships []Membership      `json:"memberships"`
+	MFAEnabled  bool              `json:"mfaEnabled"`
+	Passkeys    []PasskeyResponse `json:"passkeys"`
+	Sessions    []Session         `json:"sessions"`
+	AuditEvents []AuditEvent      `json:"auditEvents"`
+}
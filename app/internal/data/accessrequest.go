@@ -0,0 +1,200 @@
+package data
+
+import (
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type AccessRequest struct {
+	ID            uint `gorm:"primary_key"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	UserID        uint   `sql:"index"`
+	GroupID       uint   `sql:"index"`
+	Justification string `sql:"type:text"`
+	Status        string `sql:"index"`
+	DecidedBy     uint
+	Reason        string
+	ExpiresAt     time.Time `sql:"index"`
+	DecidedAt     *time.Time
+}
+
+type accessRequestDataService struct {
+	db           *gorm.DB
+	organization *uint
+}
+
+func NewAccessRequestService(db *gorm.DB) *accessRequestDataService {
+	db.AutoMigrate(&AccessRequest{})
+	return &accessRequestDataService{
+		db: db,
+	}
+}
+
+// ForOrganization returns the same data limited to the requests for groups of
+// the organization.
+func (a *accessRequestDataService) ForOrganization(organizationID uint) internal.AccessRequestData {
+	return &accessRequestDataService{
+		db:           a.db,
+		organization: &organizationID,
+	}
+}
+
+// scoped limits a query on access requests to the groups of the organization,
+// if any.
+func (a *accessRequestDataService) scoped(query *gorm.DB) *gorm.DB {
+	if a.organization == nil {
+		return query
+	}
+	return query.Where("access_requests.group_id IN (SELECT id FROM groups WHERE organization_id = ?)", *a.organization)
+}
+
+func (a *accessRequestDataService) CreateAccessRequest(request internal.AccessRequestRequest, expiresAt time.Time) (response internal.AccessRequest, err error) {
+	if request.UserID == 0 || request.GroupID == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidAccessRequest, errors.New("group_id or user_id is 0 for requesting access"))
+	}
+	var count int64
+	err = a.db.Model(&AccessRequest{}).Where("user_id = ? AND group_id = ? AND status = ? AND expires_at > ?",
+		request.UserID, request.GroupID, internal.AccessPending, time.Now()).Count(&count).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get pending access request count failed")
+	}
+	if count > 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidAccessRequest,
+			fmt.Errorf("user_id %d already requested access to group %d", request.UserID, request.GroupID))
+	}
+	accessRequest := AccessRequest{
+		UserID:        request.UserID,
+		GroupID:       request.GroupID,
+		Justification: request.Justification,
+		Status:        internal.AccessPending,
+		ExpiresAt:     expiresAt,
+	}
+	err = a.db.Create(&accessRequest).Error
+	if err != nil {
+		return response, errors.Wrap(err, "create access request failed")
+	}
+	return toAccessRequest(accessRequest), nil
+}
+
+func (a *accessRequestDataService) GetAccessRequest(id uint) (response internal.AccessRequest, err error) {
+	var accessRequest AccessRequest
+	err = a.scoped(a.db).First(&accessRequest, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.AccessRequestNotFound, fmt.Errorf("access request %d not found", id))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get access request failed")
+	}
+	return toAccessRequest(accessRequest), nil
+}
+
+func (a *accessRequestDataService) GetAccessRequests(offset uint, limit uint, filter internal.AccessRequestsFilter) (response internal.AccessRequestsResponse, err error) {
+	if limit == 0 || limit > 1000 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidAccessRequest, fmt.Errorf("limit %d is not valid for getting access requests", limit))
+	}
+	query := a.scoped(a.db.Model(&AccessRequest{}))
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.GroupID != 0 {
+		query = query.Where("group_id = ?", filter.GroupID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	var accessRequests []AccessRequest
+	err = query.Order("id").Limit(limit).Offset(offset).Find(&accessRequests).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get access requests failed")
+	}
+	var count int64
+	err = query.Count(&count).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get access requests count failed")
+	}
+	response = internal.AccessRequestsResponse{
+		AccessRequests: make([]internal.AccessRequest, len(accessRequests)),
+		Total:          uint(count),
+	}
+	for i, accessRequest := range accessRequests {
+		response.AccessRequests[i] = toAccessRequest(accessRequest)
+	}
+	return response, nil
+}
+
+// DecideAccessRequest only changes a request that is still pending, so of two
+// approvers deciding at once only the first wins. Limited to an organization,
+// only requests for its groups change.
+func (a *accessRequestDataService) DecideAccessRequest(decision internal.AccessDecision) (response internal.AccessRequest, err error) {
+	if decision.Status != internal.AccessApproved && decision.Status != internal.AccessDenied {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidAccessRequest, fmt.Errorf("access request can't be decided as %s", decision.Status))
+	}
+	now := time.Now()
+	var accessRequests []AccessRequest
+	err = a.db.Raw(`UPDATE access_requests SET status = ?, decided_by = ?, reason = ?, decided_at = ?, updated_at = ?
+		WHERE id = ? AND status = ? AND expires_at > ?
+			AND (? OR group_id IN (SELECT id FROM groups WHERE organization_id = ?))
+		RETURNING *`, decision.Status, decision.DecidedBy, decision.Reason, now, now,
+		decision.ID, internal.AccessPending, now, a.organization == nil, a.organizationID()).Scan(&accessRequests).Error
+	if err != nil {
+		return response, errors.Wrap(err, "decide access request failed")
+	}
+	if len(accessRequests) > 0 {
+		return toAccessRequest(accessRequests[0]), nil
+	}
+	response, err = a.GetAccessRequest(decision.ID)
+	if err != nil {
+		return response, err
+	}
+	if response.Status == internal.AccessPending {
+		return response, serviceerror.NewServiceError(serviceerror.AccessRequestDecided, fmt.Errorf("access request %d has expired", decision.ID))
+	}
+	return response, serviceerror.NewServiceError(serviceerror.AccessRequestDecided, fmt.Errorf("access request %d is %s", decision.ID, response.Status))
+}
+
+// ExpireAccessRequests marks the pending requests whose expiry has passed as
+// expired and returns them.
+func (a *accessRequestDataService) ExpireAccessRequests(now time.Time) (response []internal.AccessRequest, err error) {
+	var accessRequests []AccessRequest
+	err = a.db.Raw(`UPDATE access_requests SET status = ?, updated_at = ?
+		WHERE status = ? AND expires_at <= ?
+		RETURNING *`, internal.AccessExpired, now, internal.AccessPending, now).Scan(&accessRequests).Error
+	if err != nil {
+		return response, errors.Wrap(err, "expire access requests failed")
+	}
+	response = make([]internal.AccessRequest, len(accessRequests))
+	for i, accessRequest := range accessRequests {
+		response[i] = toAccessRequest(accessRequest)
+	}
+	return response, nil
+}
+
+// organizationID is the organization requests are limited to, the default
+// one for data that isn't limited to an organization.
+func (a *accessRequestDataService) organizationID() uint {
+	if a.organization == nil {
+		return 0
+	}
+	return *a.organization
+}
+
+func toAccessRequest(accessRequest AccessRequest) internal.AccessRequest {
+	return internal.AccessRequest{
+		ID:            accessRequest.ID,
+		UserID:        accessRequest.UserID,
+		GroupID:       accessRequest.GroupID,
+		Justification: accessRequest.Justification,
+		Status:        accessRequest.Status,
+		DecidedBy:     accessRequest.DecidedBy,
+		Reason:        accessRequest.Reason,
+		CreatedAt:     accessRequest.CreatedAt,
+		ExpiresAt:     accessRequest.ExpiresAt,
+		DecidedAt:     accessRequest.DecidedAt,
+	}
+}
@@ -0,0 +1,139 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type PersonalAccessToken struct {
+	ID         uint `gorm:"primary_key"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uint   `sql:"index"`
+	ClientID   string `sql:"not null;default:''"`
+	Name       string
+	TokenHash  string `sql:"unique_index"`
+	Scopes     string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+type accessTokenDataService struct {
+	db *gorm.DB
+}
+
+func NewAccessTokenService(db *gorm.DB) *accessTokenDataService {
+	db.AutoMigrate(&PersonalAccessToken{})
+	return &accessTokenDataService{
+		db: db,
+	}
+}
+
+func (a *accessTokenDataService) CreateAccessToken(token internal.AccessToken) (response internal.AccessTokenResponse, err error) {
+	if token.UserID == 0 || token.Name == "" || token.TokenHash == "" || len(token.Scopes) == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidAccessToken, errors.New("missing access token fields"))
+	}
+	accessToken := PersonalAccessToken{
+		UserID:    token.UserID,
+		ClientID:  token.ClientID,
+		Name:      token.Name,
+		TokenHash: token.TokenHash,
+		Scopes:    strings.Join(token.Scopes, ","),
+		ExpiresAt: token.ExpiresAt,
+	}
+	err = a.db.Create(&accessToken).Error
+	if err != nil {
+		return response, errors.Wrap(err, "create access token failed")
+	}
+	return toAccessTokenResponse(accessToken), err
+}
+
+// GetAccessTokens returns the personal access tokens of the user, those issued
+// to OAuth clients are managed by the clients.
+func (a *accessTokenDataService) GetAccessTokens(userID uint) (response []internal.AccessTokenResponse, err error) {
+	var tokens []PersonalAccessToken
+	err = a.db.Where("user_id = ? AND client_id = ''", userID).Order("id").Find(&tokens).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get access tokens failed")
+	}
+	response = make([]internal.AccessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		response = append(response, toAccessTokenResponse(token))
+	}
+	return response, err
+}
+
+func (a *accessTokenDataService) GetAccessTokenByHash(tokenHash string) (response internal.AccessToken, err error) {
+	var token PersonalAccessToken
+	err = a.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.AccessTokenNotFound, errors.New("access token not found"))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get access token failed")
+	}
+	return toAccessToken(token), err
+}
+
+func (a *accessTokenDataService) UseAccessToken(id uint, usedAt time.Time) (err error) {
+	err = a.db.Model(&PersonalAccessToken{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+	if err != nil {
+		return errors.Wrap(err, "update access token failed")
+	}
+	return nil
+}
+
+func (a *accessTokenDataService) RevokeAccessToken(userID uint, id uint) (err error) {
+	result := a.db.Where("id = ? AND user_id = ? AND client_id = ''", id, userID).Delete(&PersonalAccessToken{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "delete access token failed")
+	}
+	if result.RowsAffected == 0 {
+		return serviceerror.NewServiceError(serviceerror.AccessTokenNotFound, fmt.Errorf("access token %d of user %d not found", id, userID))
+	}
+	return nil
+}
+
+// RevokeClientAccessToken removes a token issued to the client, tokens that
+// are unknown or of another client are left alone without error.
+func (a *accessTokenDataService) RevokeClientAccessToken(clientID string, tokenHash string) (err error) {
+	if clientID == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidAccessToken, errors.New("client_id is empty for revoke access token"))
+	}
+	err = a.db.Where("token_hash = ? AND client_id = ?", tokenHash, clientID).Delete(&PersonalAccessToken{}).Error
+	if err != nil {
+		return errors.Wrap(err, "delete access token failed")
+	}
+	return nil
+}
+
+func toAccessToken(token PersonalAccessToken) internal.AccessToken {
+	return internal.AccessToken{
+		ID:         token.ID,
+		UserID:     token.UserID,
+		ClientID:   token.ClientID,
+		Name:       token.Name,
+		TokenHash:  token.TokenHash,
+		Scopes:     strings.Split(token.Scopes, ","),
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
+
+func toAccessTokenResponse(token PersonalAccessToken) internal.AccessTokenResponse {
+	return internal.AccessTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     strings.Split(token.Scopes, ","),
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
@@ -0,0 +1,87 @@
+package data
+
+import (
+	"time"
+	"usermanagement/app/internal"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type LoginAttempt struct {
+	ID          uint `gorm:"primary_key"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	AttemptKey  string `sql:"unique_index"`
+	Failures    int64
+	LastFailure time.Time
+	LockedUntil *time.Time
+}
+
+type attemptDataService struct {
+	db *gorm.DB
+}
+
+func NewAttemptService(db *gorm.DB) *attemptDataService {
+	db.AutoMigrate(&LoginAttempt{})
+	return &attemptDataService{
+		db: db,
+	}
+}
+
+func (a *attemptDataService) GetAttempts(key string) (response internal.Attempts, err error) {
+	var attempt LoginAttempt
+	err = a.db.Where("attempt_key = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, nil
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get login attempts failed")
+	}
+	return toAttempts(attempt), err
+}
+
+// RecordFailure counts a failure in one upsert so concurrent attempts can't
+// lose increments. A failure after the window has passed starts over at 1.
+func (a *attemptDataService) RecordFailure(key string, window time.Duration) (response internal.Attempts, err error) {
+	now := time.Now()
+	var attempt LoginAttempt
+	err = a.db.Raw(`INSERT INTO login_attempts (created_at, updated_at, attempt_key, failures, last_failure)
+		VALUES (?, ?, ?, 1, ?)
+		ON CONFLICT (attempt_key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure = EXCLUDED.last_failure,
+			updated_at = EXCLUDED.updated_at
+		RETURNING *`, now, now, key, now, now.Add(-window)).Scan(&attempt).Error
+	if err != nil {
+		return response, errors.Wrap(err, "record login failure failed")
+	}
+	return toAttempts(attempt), err
+}
+
+func (a *attemptDataService) Lock(key string, until time.Time) (err error) {
+	err = a.db.Model(&LoginAttempt{}).Where("attempt_key = ?", key).Update("locked_until", until).Error
+	if err != nil {
+		return errors.Wrap(err, "lock login attempts failed")
+	}
+	return err
+}
+
+func (a *attemptDataService) ResetAttempts(key string) (err error) {
+	err = a.db.Where("attempt_key = ?", key).Delete(&LoginAttempt{}).Error
+	if err != nil {
+		return errors.Wrap(err, "reset login attempts failed")
+	}
+	return err
+}
+
+func toAttempts(attempt LoginAttempt) internal.Attempts {
+	response := internal.Attempts{
+		Failures:    attempt.Failures,
+		LastFailure: attempt.LastFailure,
+	}
+	if attempt.LockedUntil != nil {
+		response.LockedUntil = *attempt.LockedUntil
+	}
+	return response
+}
@@ -0,0 +1,120 @@
+package data
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+type AttributeDefinition struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string `sql:"unique_index"`
+	Type      string
+	Required  bool
+	Unique    bool
+	Pattern   string
+	Enum      pq.StringArray `sql:"type:text[]"`
+}
+
+// Attributes are the custom attribute values of a user kept in a jsonb
+// column, a NULL column reads as no attributes.
+type Attributes map[string]interface{}
+
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	value, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
+}
+
+func (a *Attributes) Scan(value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(value, a)
+	case string:
+		return json.Unmarshal([]byte(value), a)
+	}
+	return fmt.Errorf("unsupported attributes value %T", value)
+}
+
+type attributeDataService struct {
+	db *gorm.DB
+}
+
+func NewAttributeService(db *gorm.DB) *attributeDataService {
+	db.AutoMigrate(&AttributeDefinition{})
+	return &attributeDataService{
+		db: db,
+	}
+}
+
+func (a *attributeDataService) GetAttributeDefinitions() (response []internal.AttributeDefinition, err error) {
+	var definitions []AttributeDefinition
+	err = a.db.Order("name").Find(&definitions).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get attribute definitions failed")
+	}
+	response = make([]internal.AttributeDefinition, len(definitions))
+	for i, definition := range definitions {
+		response[i] = internal.AttributeDefinition{
+			Name:     definition.Name,
+			Type:     definition.Type,
+			Required: definition.Required,
+			Unique:   definition.Unique,
+			Pattern:  definition.Pattern,
+			Enum:     definition.Enum,
+		}
+	}
+	return response, nil
+}
+
+// SaveAttributeDefinition creates the definition or replaces the one with the
+// same name. Values users already have are only checked on their next update.
+func (a *attributeDataService) SaveAttributeDefinition(definition internal.AttributeDefinition) (err error) {
+	if definition.Name == "" || definition.Type == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidAttribute, errors.New("missing attribute definition fields"))
+	}
+	var saved AttributeDefinition
+	err = a.db.Where(AttributeDefinition{Name: definition.Name}).
+		Assign(map[string]interface{}{
+			"type":     definition.Type,
+			"required": definition.Required,
+			"unique":   definition.Unique,
+			"pattern":  definition.Pattern,
+			"enum":     pq.StringArray(definition.Enum),
+		}).
+		FirstOrCreate(&saved).Error
+	if err != nil {
+		return errors.Wrap(err, "save attribute definition failed")
+	}
+	return nil
+}
+
+// DeleteAttributeDefinition removes the definition, values users hold for it
+// are kept until they are removed in an update.
+func (a *attributeDataService) DeleteAttributeDefinition(name string) (err error) {
+	result := a.db.Where("name = ?", name).Delete(&AttributeDefinition{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "delete attribute definition failed")
+	}
+	if result.RowsAffected == 0 {
+		return serviceerror.NewServiceError(serviceerror.AttributeNotFound, fmt.Errorf("attribute %s not found", name))
+	}
+	return nil
+}
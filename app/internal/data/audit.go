@@ -0,0 +1,75 @@
+package data
+
+import (
+	"time"
+	"usermanagement/app/internal"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type AuditEvent struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	Type      string `sql:"index"`
+	UserID    uint   `sql:"index"`
+	Email     string
+	IP        string
+	Detail    string
+}
+
+type auditDataService struct {
+	db *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *auditDataService {
+	db.AutoMigrate(&AuditEvent{})
+	return &auditDataService{
+		db: db,
+	}
+}
+
+func (a *auditDataService) CreateAuditEvent(event internal.AuditEvent) (err error) {
+	record := AuditEvent{
+		Type:   event.Type,
+		UserID: event.UserID,
+		Email:  event.Email,
+		IP:     event.IP,
+		Detail: event.Detail,
+	}
+	err = a.db.Create(&record).Error
+	if err != nil {
+		return errors.Wrap(err, "create audit event failed")
+	}
+	return err
+}
+
+// GetAuditEvents lists the events of a user, with an email also those that
+// only carry the email such as failed logins.
+func (a *auditDataService) GetAuditEvents(userID uint, email string) (response []internal.AuditEvent, err error) {
+	var events []AuditEvent
+	err = a.subject(userID, email).Order("id").Find(&events).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get audit events failed")
+	}
+	response = make([]internal.AuditEvent, 0, len(events))
+	for _, event := range events {
+		response = append(response, internal.AuditEvent{
+			ID:        event.ID,
+			Type:      event.Type,
+			UserID:    event.UserID,
+			Email:     event.Email,
+			IP:        event.IP,
+			Detail:    event.Detail,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	return response, err
+}
+
+func (a *auditDataService) subject(userID uint, email string) *gorm.DB {
+	if email == "" {
+		return a.db.Where("user_id = ?", userID)
+	}
+	return a.db.Where("user_id = ? OR (user_id = 0 AND lower(email) = lower(?))", userID, email)
+}
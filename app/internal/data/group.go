@@ -7,15 +7,20 @@ import (
 	"usermanagement/app/internal/serviceerror"
 
 	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
 type Group struct {
-	ID        uint `gorm:"primary_key"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt *time.Time `sql:"index"`
-	Name      string     `sql:"index"`
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      *time.Time `sql:"index"`
+	OrganizationID uint       `sql:"not null;default:0;index"`
+	Name           string     `sql:"index"`
+	Type           string     `sql:"not null;default:'static'"`
+	Rule           string     `sql:"type:text"`
+	Version        uint       `sql:"not null;default:1"`
 }
 
 type UserGroup struct {
@@ -25,10 +30,22 @@ type UserGroup struct {
 	DeletedAt *time.Time `sql:"index"`
 	UserID    uint       `sql:"index"`
 	GroupID   uint       `sql:"index"`
+	Role      string     `sql:"not null;default:'member'"`
+	StartsAt  *time.Time
+	ExpiresAt *time.Time `sql:"index"`
 }
 
+// unexpired keeps memberships whose expiry hasn't passed, also those the
+// expiry service hasn't removed yet.
+const unexpired = "(user_groups.expires_at IS NULL OR user_groups.expires_at > ?)"
+
+// effective keeps memberships that have started and not expired.
+const effective = unexpired + " AND (user_groups.starts_at IS NULL OR user_groups.starts_at <= ?)"
+
 type groupDataService struct {
 	db *gorm.DB
+	// organization limits the groups to one organization, all are seen if nil
+	organization *uint
 }
 
 func NewGroupService(db *gorm.DB) *groupDataService {
@@ -39,12 +56,48 @@ func NewGroupService(db *gorm.DB) *groupDataService {
 	}
 }
 
+// ForOrganization returns the same data limited to the groups of the
+// organization and their memberships, groups it creates belong to the
+// organization.
+func (g *groupDataService) ForOrganization(organizationID uint) internal.GroupData {
+	return &groupDataService{
+		db:           g.db,
+		organization: &organizationID,
+	}
+}
+
+// scoped limits a query on groups to the organization, if any.
+func (g *groupDataService) scoped(query *gorm.DB) *gorm.DB {
+	if g.organization == nil {
+		return query
+	}
+	return query.Where("groups.organization_id = ?", *g.organization)
+}
+
+// scopedMemberships limits a query on user_groups to the groups of the
+// organization, if any.
+func (g *groupDataService) scopedMemberships(query *gorm.DB) *gorm.DB {
+	if g.organization == nil {
+		return query
+	}
+	return query.Where("user_groups.group_id IN (SELECT id FROM groups WHERE organization_id = ?)", *g.organization)
+}
+
+// organizationID is the organization new groups belong to, the default one
+// for data that isn't limited to an organization.
+func (g *groupDataService) organizationID() uint {
+	if g.organization == nil {
+		return 0
+	}
+	return *g.organization
+}
+
 func (g *groupDataService) CreateGroup(request internal.GroupRequest) (response internal.GroupResponse, err error) {
 	if request.Name == "" {
 		return response, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("missing create group fields"))
 	}
 	var count int64
-	err = g.db.Model(&Group{}).Where("name = ?", request.Name).Count(&count).Error
+	err = g.db.Model(&Group{}).Where("name = ? AND organization_id = ?", request.Name, g.organizationID()).Count(&count).Error
 	if err != nil {
 		return response, errors.Wrap(err, "get group with name count failed")
 	}
@@ -52,17 +105,54 @@ func (g *groupDataService) CreateGroup(request internal.GroupRequest) (response
 		return response, serviceerror.NewServiceError(serviceerror.DuplicateUser, fmt.Errorf("group with name %s is present", request.Name))
 	}
 	group := Group{
-		Name: request.Name,
+		OrganizationID: g.organizationID(),
+		Name:           request.Name,
+		Type:           request.Type,
+		Rule:           request.Rule,
+		Version:        1,
+	}
+	if group.Type == "" {
+		group.Type = internal.GroupStatic
+	}
+	err = g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&group).Error; err != nil {
+			return errors.Wrap(err, "create group failed")
+		}
+		if request.OwnerID == 0 {
+			return nil
+		}
+		return addOwner(tx, group, request.OwnerID)
+	})
+	if err != nil {
+		return response, err
 	}
-	err = g.db.Create(&group).Error
+	return toGroupResponse(group), err
+}
+
+// addOwner makes the user the first owner of the new group. Like any member
+// it must be of the group's organization and in no other group.
+func addOwner(tx *gorm.DB, group Group, userID uint) (err error) {
+	var users []struct{ OrganizationID uint }
+	err = tx.Raw("SELECT organization_id FROM users WHERE id = ? AND deleted_at IS NULL FOR UPDATE", userID).Scan(&users).Error
 	if err != nil {
-		return response, errors.Wrap(err, "create group failed")
+		return errors.Wrap(err, "lock owner failed")
 	}
-	response = internal.GroupResponse{
-		ID:   group.ID,
-		Name: group.Name,
+	if len(users) == 0 || users[0].OrganizationID != group.OrganizationID {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("owner %d not found", userID))
 	}
-	return response, err
+	var count int64
+	err = tx.Model(&UserGroup{}).Where("user_id = ?", userID).Where(unexpired, time.Now()).Count(&count).Error
+	if err != nil {
+		return errors.Wrap(err, "count usergroup failed")
+	}
+	if count != 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("user_id %d is already associated with a group", userID))
+	}
+	err = tx.Create(&UserGroup{UserID: userID, GroupID: group.ID, Role: internal.RoleOwner}).Error
+	if err != nil {
+		return errors.Wrap(err, "create owner failed")
+	}
+	return nil
 }
 
 func (g *groupDataService) UpdateGroup(request internal.UpdateGroupRequest) (err error) {
@@ -70,53 +160,176 @@ func (g *groupDataService) UpdateGroup(request internal.UpdateGroupRequest) (err
 		return serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("missing update group fields"))
 	}
 	var count int64
-	err = g.db.Model(&Group{}).Where("name = ?", request.Name).Count(&count).Error
+	err = g.db.Model(&Group{}).Where("name = ? AND organization_id = (SELECT organization_id FROM groups WHERE id = ?)", request.Name, request.ID).
+		Count(&count).Error
 	if err != nil {
 		return errors.Wrap(err, "get group with name count failed")
 	}
 	if count > 0 {
 		return serviceerror.NewServiceError(serviceerror.DuplicateUser, fmt.Errorf("group with name %s is present", request.Name))
 	}
-	group := Group{
-		ID: request.ID,
+	query := g.scoped(g.db.Model(&Group{})).Where("id = ?", request.ID)
+	if request.Version != 0 {
+		query = query.Where("version = ?", request.Version)
+	}
+	result := query.Updates(map[string]interface{}{"name": request.Name, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "update group failed")
+	}
+	if result.RowsAffected == 0 {
+		return g.versionMismatch(request.ID, request.Version)
 	}
-	err = g.db.Model(&group).Updates(User{Name: request.Name}).Error
+	return nil
+}
+
+// versionMismatch tells why a conditional write of group id changed nothing,
+// either the group is gone or it is no longer at the expected version.
+func (g *groupDataService) versionMismatch(id uint, version uint) (err error) {
+	var count int64
+	err = g.scoped(g.db.Model(&Group{})).Where("id = ?", id).Count(&count).Error
 	if err != nil {
-		return errors.Wrap(err, "update group failed")
+		return errors.Wrap(err, "get group count failed")
 	}
-	return err
+	if count == 0 || version == 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("group %d not found", id))
+	}
+	return serviceerror.NewServiceError(serviceerror.PreconditionFailed, fmt.Errorf("group %d is no longer at version %d", id, version))
 }
 
-func (g *groupDataService) DeleteGroup(id uint) (err error) {
+// DeleteGroup soft deletes the group and its memberships with the same
+// deletion time, RestoreGroup uses it to tell them from memberships removed
+// before. A non zero version must be the group's current one.
+func (g *groupDataService) DeleteGroup(id uint, version uint) (err error) {
 	if id == 0 {
 		return serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("group_id is 0 for delete group"))
 	}
-	err = g.db.Where("group_id = ?", id).Delete(&UserGroup{}).Error
+	now := time.Now().Truncate(time.Microsecond)
+	err = g.db.Transaction(func(tx *gorm.DB) error {
+		query := g.scoped(tx.Model(&Group{})).Where("id = ?", id)
+		if version != 0 {
+			query = query.Where("version = ?", version)
+		}
+		result := query.Updates(map[string]interface{}{"deleted_at": now, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "delete group failed")
+		}
+		if result.RowsAffected == 0 && (version != 0 || g.organization != nil) {
+			return errVersionMismatch
+		}
+		if err := tx.Model(&UserGroup{}).Where("group_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return errors.Wrap(err, "delete user group failed")
+		}
+		return nil
+	})
+	if errors.Is(err, errVersionMismatch) {
+		return g.versionMismatch(id, version)
+	}
+	return err
+}
+
+// RestoreGroup undeletes the group together with the memberships deleted with
+// it. Memberships of users that are deleted or have joined another group since
+// stay deleted.
+func (g *groupDataService) RestoreGroup(id uint) (err error) {
+	if id == 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("group_id is 0 for restore group"))
+	}
+	var group Group
+	err = g.scoped(g.db.Unscoped()).Where("id = ? AND deleted_at IS NOT NULL", id).First(&group).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("deleted group %d not found", id))
+	}
 	if err != nil {
-		return errors.Wrap(err, "delete user group failed")
+		return errors.Wrap(err, "get deleted group failed")
 	}
-	err = g.db.Delete(&Group{}, id).Error
+	var count int64
+	err = g.db.Model(&Group{}).Where("name = ? AND organization_id = ?", group.Name, group.OrganizationID).Count(&count).Error
 	if err != nil {
-		return errors.Wrap(err, "delete group failed")
+		return errors.Wrap(err, "get group with name count failed")
 	}
-	return err
+	if count > 0 {
+		return serviceerror.NewServiceError(serviceerror.DuplicateGroup, fmt.Errorf("group with name %s is present", group.Name))
+	}
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&Group{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return errors.Wrap(err, "restore group failed")
+		}
+		err = tx.Unscoped().Model(&UserGroup{}).
+			Where("group_id = ? AND deleted_at = ?", id, group.DeletedAt).
+			Where("user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)").
+			Where("user_id NOT IN (SELECT user_id FROM user_groups WHERE deleted_at IS NULL)").
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return errors.Wrap(err, "restore user group failed")
+		}
+		return nil
+	})
 }
 
-func (g *groupDataService) AddUser(userID uint, groupID uint) (err error) {
-	if userID == 0 || groupID == 0 {
+// PurgeGroups permanently removes groups deleted before the given time with
+// all their memberships, as well as any membership removed before it.
+func (g *groupDataService) PurgeGroups(before time.Time) (count int64, err error) {
+	err = g.db.Transaction(func(tx *gorm.DB) error {
+		err := g.scopedMemberships(tx.Unscoped()).
+			Where("deleted_at < ? OR group_id IN (SELECT id FROM groups WHERE deleted_at < ?)", before, before).
+			Delete(&UserGroup{}).Error
+		if err != nil {
+			return errors.Wrap(err, "purge user groups failed")
+		}
+		result := g.scoped(tx.Unscoped()).Where("deleted_at < ?", before).Delete(&Group{})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "purge groups failed")
+		}
+		count = result.RowsAffected
+		return nil
+	})
+	return count, err
+}
+
+func (g *groupDataService) GetGroup(id uint) (response internal.GroupResponse, err error) {
+	if id == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("group_id is 0 for get group"))
+	}
+	var group Group
+	err = g.scoped(g.db).First(&group, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("group %d not found", id))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get group failed")
+	}
+	return toGroupResponse(group), err
+}
+
+// AddUser adds the user to the group unless it has a membership that hasn't
+// expired, also one that has yet to start. Users only join groups of their
+// own organization.
+func (g *groupDataService) AddUser(request internal.AddUserRequest) (err error) {
+	if request.UserID == 0 || request.GroupID == 0 {
 		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, errors.New("group_id or user_id is 0 for adding user"))
 	}
+	if err := staticGroup(g.db, request.GroupID); err != nil {
+		return err
+	}
+	if err := g.sameOrganization(request.GroupID, request.UserID); err != nil {
+		return err
+	}
 	var count int64
-	err = g.db.Model(&UserGroup{}).Where("user_id = ?", userID).Count(&count).Error
+	err = g.db.Model(&UserGroup{}).Where("user_id = ?", request.UserID).Where(unexpired, time.Now()).Count(&count).Error
 	if err != nil {
 		return errors.Wrap(err, "count usergroup failed")
 	}
 	if count != 0 {
-		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("user_id %d is already associated with a group", userID))
+		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("user_id %d is already associated with a group", request.UserID))
 	}
 	userGrp := UserGroup{
-		UserID:  userID,
-		GroupID: groupID,
+		UserID:    request.UserID,
+		GroupID:   request.GroupID,
+		Role:      internal.RoleMember,
+		StartsAt:  request.StartsAt,
+		ExpiresAt: request.ExpiresAt,
 	}
 	err = g.db.Create(&userGrp).Error
 	if err != nil {
@@ -125,40 +338,396 @@ func (g *groupDataService) AddUser(userID uint, groupID uint) (err error) {
 	return err
 }
 
+// RemoveUser removes the user from the group, unless it is the last owner.
 func (g *groupDataService) RemoveUser(groupID uint, userID uint) (err error) {
 	if userID == 0 || groupID == 0 {
 		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, errors.New("group_id or user_id is 0 for removing user"))
 	}
-	err = g.db.Where("user_id = ? AND group_id = ?", userID, groupID).Delete(&UserGroup{}).Error
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := keepOwner(tx, groupID, userID); err != nil {
+			return err
+		}
+		if err := staticGroup(tx, groupID); err != nil {
+			return err
+		}
+		err := g.scopedMemberships(tx).Where("user_id = ? AND group_id = ?", userID, groupID).Delete(&UserGroup{}).Error
+		if err != nil {
+			return errors.Wrap(err, "remove usergroup failed")
+		}
+		return nil
+	})
+}
+
+// GetMemberRole returns the role of the user in the group, or an empty role
+// if its membership hasn't started or has expired.
+func (g *groupDataService) GetMemberRole(groupID uint, userID uint) (role string, err error) {
+	var memberships []UserGroup
+	now := time.Now()
+	err = g.scopedMemberships(g.db).Where("group_id = ? AND user_id = ?", groupID, userID).Where(effective, now, now).Limit(1).Find(&memberships).Error
 	if err != nil {
-		return errors.Wrap(err, "remove usergroup failed")
+		return role, errors.Wrap(err, "get member role failed")
 	}
-	return err
+	if len(memberships) == 0 {
+		return "", nil
+	}
+	return memberships[0].Role, nil
+}
+
+// SetMemberRole changes the role of a member, the last owner can't be
+// demoted. A member made owner loses its expiry.
+func (g *groupDataService) SetMemberRole(groupID uint, userID uint, role string) (err error) {
+	if userID == 0 || groupID == 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, errors.New("group_id or user_id is 0 for setting role"))
+	}
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if role != internal.RoleOwner {
+			if err := keepOwner(tx, groupID, userID); err != nil {
+				return err
+			}
+		}
+		update := map[string]interface{}{"role": role}
+		if role == internal.RoleOwner {
+			update["expires_at"] = nil
+		}
+		result := g.scopedMemberships(tx.Model(&UserGroup{})).Where("group_id = ? AND user_id = ?", groupID, userID).Where(unexpired, time.Now()).
+			Updates(update)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "set member role failed")
+		}
+		if result.RowsAffected == 0 {
+			return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("user %d is not a member of group %d", userID, groupID))
+		}
+		return nil
+	})
+}
+
+// sameOrganization refuses a membership of the user in a group of another
+// organization, or in a group outside the organization of the data.
+func (g *groupDataService) sameOrganization(groupID uint, userID uint) (err error) {
+	var groups, users []struct{ OrganizationID uint }
+	err = g.db.Raw("SELECT organization_id FROM groups WHERE id = ? AND deleted_at IS NULL", groupID).Scan(&groups).Error
+	if err != nil {
+		return errors.Wrap(err, "get group organization failed")
+	}
+	if g.organization != nil && (len(groups) == 0 || groups[0].OrganizationID != *g.organization) {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("group %d not found", groupID))
+	}
+	err = g.db.Raw("SELECT organization_id FROM users WHERE id = ? AND deleted_at IS NULL", userID).Scan(&users).Error
+	if err != nil {
+		return errors.Wrap(err, "get user organization failed")
+	}
+	if len(groups) > 0 && len(users) > 0 && groups[0].OrganizationID != users[0].OrganizationID {
+		return serviceerror.NewServiceError(serviceerror.CrossOrganizationMembership,
+			fmt.Errorf("user %d and group %d belong to different organizations", userID, groupID))
+	}
+	return nil
 }
 
+// staticGroup refuses to change the members of a dynamic group by hand.
+func staticGroup(db *gorm.DB, groupID uint) (err error) {
+	var groups []struct{ Type string }
+	err = db.Raw("SELECT type FROM groups WHERE id = ?", groupID).Scan(&groups).Error
+	if err != nil {
+		return errors.Wrap(err, "get group type failed")
+	}
+	if len(groups) > 0 && groups[0].Type == internal.GroupDynamic {
+		return serviceerror.NewServiceError(serviceerror.DynamicGroupMembership, fmt.Errorf("members of dynamic group %d follow its rule", groupID))
+	}
+	return nil
+}
+
+// SetGroupRule changes the rule of a dynamic group.
+func (g *groupDataService) SetGroupRule(id uint, rule string) (err error) {
+	if id == 0 || rule == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("missing set group rule fields"))
+	}
+	result := g.scoped(g.db.Model(&Group{})).Where("id = ? AND type = ?", id, internal.GroupDynamic).
+		Updates(map[string]interface{}{"rule": rule, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "set group rule failed")
+	}
+	if result.RowsAffected == 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("dynamic group %d not found", id))
+	}
+	return nil
+}
+
+// keepOwnerships refuses to remove the user from the groups it is the last
+// owner of, for removals of the user rather than of a membership.
+func keepOwnerships(tx *gorm.DB, userID uint) (err error) {
+	var owned []struct{ GroupID uint }
+	err = tx.Model(&UserGroup{}).Select("group_id").Where("user_id = ? AND role = ?", userID, internal.RoleOwner).
+		Where(unexpired, time.Now()).Order("group_id").Scan(&owned).Error
+	if err != nil {
+		return errors.Wrap(err, "get owned groups failed")
+	}
+	for _, group := range owned {
+		if err := keepOwner(tx, group.GroupID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepOwner locks the group and refuses to let the user stop owning it if no
+// other owner is left. Locking the group serialises owner changes.
+func keepOwner(tx *gorm.DB, groupID uint, userID uint) (err error) {
+	var groups []struct{ ID uint }
+	err = tx.Raw("SELECT id FROM groups WHERE id = ? FOR UPDATE", groupID).Scan(&groups).Error
+	if err != nil {
+		return errors.Wrap(err, "lock group failed")
+	}
+	var owners []struct{ UserID uint }
+	err = tx.Model(&UserGroup{}).Select("user_id").Where("group_id = ? AND role = ?", groupID, internal.RoleOwner).
+		Where(unexpired, time.Now()).Scan(&owners).Error
+	if err != nil {
+		return errors.Wrap(err, "get group owners failed")
+	}
+	if len(owners) == 1 && owners[0].UserID == userID {
+		return serviceerror.NewServiceError(serviceerror.LastGroupOwner, fmt.Errorf("user %d is the last owner of group %d", userID, groupID))
+	}
+	return nil
+}
+
+// AddUsers adds the users to the group in one transaction. Users that are
+// unknown, deleted, of another organization or already in a group are
+// reported and left out.
+func (g *groupDataService) AddUsers(groupID uint, userIDs []uint) (response internal.BatchMembershipResponse, err error) {
+	return g.changeMembers(groupID, userIDs, false, func(batch *memberBatch) {
+		for _, id := range batch.ids {
+			batch.add(id)
+		}
+	})
+}
+
+// RemoveUsers removes the users from the group in one transaction.
+func (g *groupDataService) RemoveUsers(groupID uint, userIDs []uint) (response internal.BatchMembershipResponse, err error) {
+	return g.changeMembers(groupID, userIDs, false, func(batch *memberBatch) {
+		for _, id := range batch.ids {
+			batch.remove(id)
+		}
+	})
+}
+
+// ReplaceUsers makes the users the only members of the group in one
+// transaction, members left out are removed.
+func (g *groupDataService) ReplaceUsers(groupID uint, userIDs []uint) (response internal.BatchMembershipResponse, err error) {
+	return g.changeMembers(groupID, userIDs, false, func(batch *memberBatch) {
+		keep := make(map[uint]bool, len(batch.ids))
+		for _, id := range batch.ids {
+			keep[id] = true
+			batch.add(id)
+		}
+		for _, id := range batch.members {
+			if !keep[id] {
+				batch.remove(id)
+			}
+		}
+	})
+}
+
+// SyncMembers adds the matched users to a dynamic group and removes the
+// checked members that didn't match, all members if checked is nil. Owners are
+// removed like other members, the rule decides, except the last one.
+func (g *groupDataService) SyncMembers(groupID uint, matched []uint, checked []uint) (response internal.BatchMembershipResponse, err error) {
+	return g.changeMembers(groupID, matched, true, func(batch *memberBatch) {
+		match := make(map[uint]bool, len(batch.ids))
+		for _, id := range batch.ids {
+			match[id] = true
+			batch.add(id)
+		}
+		if checked == nil {
+			checked = batch.members
+		}
+		for _, id := range checked {
+			if group, member := batch.groups[id]; !match[id] && member && group == groupID {
+				batch.remove(id)
+			}
+		}
+	})
+}
+
+// memberBatch is a batch membership change of a group, plan decides the
+// outcome of every user before anything is written.
+type memberBatch struct {
+	groupID  uint
+	ids      []uint
+	known    map[uint]bool
+	foreign  map[uint]bool
+	groups   map[uint]uint
+	members  []uint
+	owners   map[uint]bool
+	added    pq.Int64Array
+	removed  pq.Int64Array
+	response *internal.BatchMembershipResponse
+}
+
+func (b *memberBatch) result(id uint, outcome string) {
+	b.response.Results = append(b.response.Results, internal.MembershipResult{UserID: id, Outcome: outcome})
+	switch outcome {
+	case internal.MembershipAdded:
+		b.response.Added++
+		b.added = append(b.added, int64(id))
+	case internal.MembershipRemoved:
+		b.response.Removed++
+		b.removed = append(b.removed, int64(id))
+	case internal.MembershipAlreadyMember, internal.MembershipNotMember:
+		b.response.Unchanged++
+	default:
+		b.response.Failed++
+	}
+}
+
+func (b *memberBatch) add(id uint) {
+	group, member := b.groups[id]
+	switch {
+	case b.foreign[id]:
+		b.result(id, internal.MembershipOtherOrganization)
+	case !b.known[id]:
+		b.result(id, internal.MembershipUnknownUser)
+	case member && group == b.groupID:
+		b.result(id, internal.MembershipAlreadyMember)
+	case member:
+		b.result(id, internal.MembershipOtherGroup)
+	default:
+		b.result(id, internal.MembershipAdded)
+	}
+}
+
+// remove keeps the last owner, like RemoveUser.
+func (b *memberBatch) remove(id uint) {
+	group, member := b.groups[id]
+	switch {
+	case !member || group != b.groupID:
+		b.result(id, internal.MembershipNotMember)
+	case b.owners[id] && len(b.owners) == 1:
+		b.result(id, internal.MembershipLastOwner)
+	default:
+		delete(b.owners, id)
+		b.result(id, internal.MembershipRemoved)
+	}
+}
+
+// changeMembers locks the group and the users, so concurrent changes of the
+// same users wait for each other, and writes the outcome of plan. Repeated
+// user ids are reported once. Only dynamic groups are synced, only static ones
+// changed otherwise.
+func (g *groupDataService) changeMembers(groupID uint, userIDs []uint, sync bool, plan func(batch *memberBatch)) (response internal.BatchMembershipResponse, err error) {
+	if groupID == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, errors.New("group_id is 0 for changing members"))
+	}
+	batch := &memberBatch{
+		groupID:  groupID,
+		known:    map[uint]bool{},
+		foreign:  map[uint]bool{},
+		groups:   map[uint]uint{},
+		owners:   map[uint]bool{},
+		response: &internal.BatchMembershipResponse{Results: make([]internal.MembershipResult, 0, len(userIDs))},
+	}
+	seen := make(map[uint]bool, len(userIDs))
+	var ids pq.Int64Array
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			batch.ids = append(batch.ids, id)
+			ids = append(ids, int64(id))
+		}
+	}
+	err = g.db.Transaction(func(tx *gorm.DB) error {
+		var groups []Group
+		err := tx.Raw("SELECT id, type, organization_id FROM groups WHERE id = ? AND deleted_at IS NULL FOR UPDATE", groupID).Scan(&groups).Error
+		if err != nil {
+			return errors.Wrap(err, "lock group failed")
+		}
+		if len(groups) == 0 || (g.organization != nil && groups[0].OrganizationID != *g.organization) {
+			return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("group %d not found", groupID))
+		}
+		switch dynamic := groups[0].Type == internal.GroupDynamic; {
+		case dynamic && !sync:
+			return serviceerror.NewServiceError(serviceerror.DynamicGroupMembership, fmt.Errorf("members of dynamic group %d follow its rule", groupID))
+		case !dynamic && sync:
+			return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("group %d is not dynamic", groupID))
+		}
+		var users []struct {
+			ID             uint
+			OrganizationID uint
+		}
+		err = tx.Raw("SELECT id, organization_id FROM users WHERE id = ANY(?) AND deleted_at IS NULL ORDER BY id FOR UPDATE", ids).Scan(&users).Error
+		if err != nil {
+			return errors.Wrap(err, "lock users failed")
+		}
+		for _, user := range users {
+			if user.OrganizationID != groups[0].OrganizationID {
+				batch.foreign[user.ID] = true
+				continue
+			}
+			batch.known[user.ID] = true
+		}
+		var memberships []struct {
+			UserID  uint
+			GroupID uint
+			Role    string
+		}
+		err = tx.Raw("SELECT user_id, group_id, role FROM user_groups WHERE deleted_at IS NULL AND (user_id = ANY(?) OR group_id = ?) AND "+unexpired+" ORDER BY id",
+			ids, groupID, time.Now()).Scan(&memberships).Error
+		if err != nil {
+			return errors.Wrap(err, "get memberships failed")
+		}
+		for _, membership := range memberships {
+			batch.groups[membership.UserID] = membership.GroupID
+			if membership.GroupID == groupID {
+				batch.members = append(batch.members, membership.UserID)
+			}
+			if membership.GroupID == groupID && membership.Role == internal.RoleOwner {
+				batch.owners[membership.UserID] = true
+			}
+		}
+		plan(batch)
+
+		now := time.Now()
+		if len(batch.added) > 0 {
+			err = tx.Exec("INSERT INTO user_groups (created_at, updated_at, user_id, group_id, role) SELECT ?, ?, unnest(?::bigint[]), ?, ?",
+				now, now, batch.added, groupID, internal.RoleMember).Error
+			if err != nil {
+				return errors.Wrap(err, "add users failed")
+			}
+		}
+		if len(batch.removed) > 0 {
+			err = tx.Model(&UserGroup{}).Where("group_id = ? AND user_id = ANY(?)", groupID, batch.removed).Update("deleted_at", now).Error
+			if err != nil {
+				return errors.Wrap(err, "remove users failed")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return response, err
+	}
+	return *batch.response, nil
+}
+
+// GetUsersByGroupID lists the users whose membership has started and not
+// expired.
 func (g *groupDataService) GetUsersByGroupID(groupID uint, offset uint, limit uint) (response internal.UsersResponse, err error) {
 	if groupID == 0 || limit == 0 || limit > 1000 {
 		return response, serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("group_id or limit %d is not valid for getting users", limit))
 	}
+	now := time.Now()
 	var users []User
-	err = g.db.Joins("JOIN user_groups ON user_groups.group_id = ? AND user_groups.deleted_at IS NULL", groupID).
-		Where("users.id = user_groups.user_id").Offset(offset).Limit(limit).Find(&users).Error
+	err = g.scopedMemberships(g.db).Joins("JOIN user_groups ON user_groups.group_id = ? AND user_groups.deleted_at IS NULL", groupID).
+		Where("users.id = user_groups.user_id").Where(effective, now, now).Offset(offset).Limit(limit).Find(&users).Error
 	if err != nil {
 		return response, errors.Wrap(err, "get users failed")
 	}
 	var count int64
-	err = g.db.Model(&User{}).Joins("JOIN user_groups ON user_groups.group_id = ? AND user_groups.deleted_at IS NULL", groupID).
-		Where("users.id = user_groups.user_id").Count(&count).Error
+	err = g.scopedMemberships(g.db.Model(&User{})).Joins("JOIN user_groups ON user_groups.group_id = ? AND user_groups.deleted_at IS NULL", groupID).
+		Where("users.id = user_groups.user_id").Where(effective, now, now).Count(&count).Error
 	if err != nil {
 		return response, errors.Wrap(err, "get users count failed")
 	}
 	usersResponse := make([]internal.UserResponse, len(users))
 	for i, u := range users {
-		usersResponse[i] = internal.UserResponse{
-			ID:    u.ID,
-			Name:  u.Name,
-			Email: u.Email,
-		}
+		usersResponse[i] = toUserResponse(u)
 	}
 	response = internal.UsersResponse{
 		Users: usersResponse,
@@ -167,26 +736,141 @@ func (g *groupDataService) GetUsersByGroupID(groupID uint, offset uint, limit ui
 	return response, err
 }
 
-func (g *groupDataService) GetGroups(offset uint, limit uint) (response internal.GroupsResponse, err error) {
+// GetGroupOwners lists the owners of the group whose membership has started
+// and not expired.
+func (g *groupDataService) GetGroupOwners(groupID uint) (response []internal.UserResponse, err error) {
+	now := time.Now()
+	var users []User
+	err = g.scopedMemberships(g.db).Joins("JOIN user_groups ON user_groups.group_id = ? AND user_groups.deleted_at IS NULL", groupID).
+		Where("users.id = user_groups.user_id AND user_groups.role = ?", internal.RoleOwner).Where(effective, now, now).
+		Order("users.id").Find(&users).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get group owners failed")
+	}
+	response = make([]internal.UserResponse, len(users))
+	for i, u := range users {
+		response[i] = toUserResponse(u)
+	}
+	return response, nil
+}
+
+// GetGroupMembers lists the members of the group whose membership has started
+// and not expired, by user id.
+func (g *groupDataService) GetGroupMembers(groupID uint) (response []internal.GroupMember, err error) {
+	now := time.Now()
+	var memberships []UserGroup
+	err = g.scopedMemberships(g.db).Where("group_id = ?", groupID).Where(effective, now, now).Order("user_id").Find(&memberships).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get group members failed")
+	}
+	response = make([]internal.GroupMember, len(memberships))
+	for i, membership := range memberships {
+		response[i] = internal.GroupMember{UserID: membership.UserID, Role: membership.Role}
+	}
+	return response, nil
+}
+
+// ExpireMemberships removes the memberships whose expiry has passed, like
+// RemoveUser would, and returns them. Owners have no expiry, SetMemberRole
+// clears it, so no group loses its last owner here.
+func (g *groupDataService) ExpireMemberships(now time.Time) (response []internal.ExpiredMembership, err error) {
+	var rows []struct {
+		UserID    uint
+		GroupID   uint
+		Name      string
+		ExpiresAt time.Time
+	}
+	scope, args := "", []interface{}{now, now, now}
+	if g.organization != nil {
+		scope = " AND group_id IN (SELECT id FROM groups WHERE organization_id = ?)"
+		args = append(args, *g.organization)
+	}
+	err = g.db.Raw(`WITH expired AS (
+			UPDATE user_groups SET deleted_at = ?, updated_at = ?
+			WHERE deleted_at IS NULL AND expires_at <= ?`+scope+`
+			RETURNING id, user_id, group_id, expires_at)
+		SELECT expired.user_id, expired.group_id, groups.name, expired.expires_at
+		FROM expired LEFT JOIN groups ON groups.id = expired.group_id
+		ORDER BY expired.id`, args...).Scan(&rows).Error
+	if err != nil {
+		return response, errors.Wrap(err, "expire memberships failed")
+	}
+	response = make([]internal.ExpiredMembership, len(rows))
+	for i, row := range rows {
+		response[i] = internal.ExpiredMembership{
+			UserID:    row.UserID,
+			GroupID:   row.GroupID,
+			GroupName: row.Name,
+			ExpiresAt: row.ExpiresAt,
+		}
+	}
+	return response, nil
+}
+
+// GetMemberships lists the current and removed memberships of a user, also
+// those in deleted groups.
+func (g *groupDataService) GetMemberships(userID uint) (response []internal.Membership, err error) {
+	if userID == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, errors.New("user_id is 0 for getting memberships"))
+	}
+	var rows []struct {
+		GroupID   uint
+		Name      string
+		Role      string
+		CreatedAt time.Time
+		StartsAt  *time.Time
+		ExpiresAt *time.Time
+		DeletedAt *time.Time
+	}
+	err = g.scoped(g.db.Table("user_groups")).
+		Select("user_groups.group_id, groups.name, user_groups.role, user_groups.created_at, user_groups.starts_at, user_groups.expires_at, user_groups.deleted_at").
+		Joins("JOIN groups ON groups.id = user_groups.group_id").
+		Where("user_groups.user_id = ?", userID).Order("user_groups.id").Scan(&rows).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get memberships failed")
+	}
+	response = make([]internal.Membership, len(rows))
+	for i, row := range rows {
+		response[i] = internal.Membership{
+			GroupID:   row.GroupID,
+			GroupName: row.Name,
+			Role:      row.Role,
+			JoinedAt:  row.CreatedAt,
+			StartsAt:  row.StartsAt,
+			ExpiresAt: row.ExpiresAt,
+			RemovedAt: row.DeletedAt,
+		}
+	}
+	return response, err
+}
+
+func (g *groupDataService) GetGroups(offset uint, limit uint, filter internal.GroupsFilter) (response internal.GroupsResponse, err error) {
 	if limit == 0 || limit > 1000 {
 		return response, serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("limit %d is not valid for getting groups", limit))
 	}
+	query := g.scoped(g.db.Model(&Group{}))
+	if filter.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if filter.Name != "" {
+		query = query.Where("name = ?", filter.Name)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
 	var groups []Group
-	err = g.db.Limit(limit).Offset(offset).Find(&groups).Error
+	err = query.Order("id").Limit(limit).Offset(offset).Find(&groups).Error
 	if err != nil {
 		return response, errors.Wrap(err, "get groups failed")
 	}
 	var count int64
-	err = g.db.Model(&Group{}).Count(&count).Error
+	err = query.Count(&count).Error
 	if err != nil {
 		return response, errors.Wrap(err, "get groups count failed")
 	}
 	groupsResponse := make([]internal.GroupResponse, len(groups))
 	for i, g := range groups {
-		groupsResponse[i] = internal.GroupResponse{
-			ID:   g.ID,
-			Name: g.Name,
-		}
+		groupsResponse[i] = toGroupResponse(g)
 	}
 	response = internal.GroupsResponse{
 		Groups: groupsResponse,
@@ -194,3 +878,15 @@ func (g *groupDataService) GetGroups(offset uint, limit uint) (response internal
 	}
 	return response, err
 }
+
+func toGroupResponse(group Group) internal.GroupResponse {
+	return internal.GroupResponse{
+		ID:             group.ID,
+		OrganizationID: group.OrganizationID,
+		Name:           group.Name,
+		Type:           group.Type,
+		Rule:           group.Rule,
+		DeletedAt:      group.DeletedAt,
+		Version:        group.Version,
+	}
+}
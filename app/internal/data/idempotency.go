@@ -0,0 +1,115 @@
+package data
+
+import (
+	"encoding/json"
+	"time"
+	"usermanagement/app/internal"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type IdempotencyKey struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      time.Time
+	Scope          string `sql:"unique_index:idx_idempotency_keys_scope_key"`
+	IdempotencyKey string `sql:"unique_index:idx_idempotency_keys_scope_key"`
+	Fingerprint    string
+	Status         int
+	Header         string `sql:"type:text"`
+	Body           []byte
+	ExpiresAt      time.Time `sql:"index"`
+}
+
+type idempotencyDataService struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyService(db *gorm.DB) *idempotencyDataService {
+	// keys were unique across callers before they were scoped
+	if db.Dialect().HasIndex("idempotency_keys", "uix_idempotency_keys_idempotency_key") {
+		db.Model(&IdempotencyKey{}).RemoveIndex("uix_idempotency_keys_idempotency_key")
+	}
+	db.AutoMigrate(&IdempotencyKey{})
+	return &idempotencyDataService{
+		db: db,
+	}
+}
+
+// ReserveIdempotencyKey inserts the key of the scope unless it is already
+// there, so of two concurrent requests with the same key only one gets to run.
+// An expired key is reserved again.
+func (i *idempotencyDataService) ReserveIdempotencyKey(scope string, key string, fingerprint string, expiresAt time.Time) (response internal.IdempotencyRecord, reserved bool, err error) {
+	now := time.Now()
+	err = i.db.Where("scope = ? AND idempotency_key = ? AND expires_at <= ?", scope, key, now).Delete(&IdempotencyKey{}).Error
+	if err != nil {
+		return response, false, errors.Wrap(err, "delete expired idempotency key failed")
+	}
+	result := i.db.Exec(`INSERT INTO idempotency_keys (created_at, scope, idempotency_key, fingerprint, status, expires_at)
+		VALUES (?, ?, ?, ?, 0, ?)
+		ON CONFLICT (scope, idempotency_key) DO NOTHING`, now, scope, key, fingerprint, expiresAt)
+	if result.Error != nil {
+		return response, false, errors.Wrap(result.Error, "reserve idempotency key failed")
+	}
+	if result.RowsAffected == 1 {
+		return internal.IdempotencyRecord{Key: key, Fingerprint: fingerprint, ExpiresAt: expiresAt}, true, nil
+	}
+	var record IdempotencyKey
+	err = i.db.Where("scope = ? AND idempotency_key = ?", scope, key).First(&record).Error
+	if err != nil {
+		return response, false, errors.Wrap(err, "get idempotency key failed")
+	}
+	response, err = toIdempotencyRecord(record)
+	return response, false, err
+}
+
+func (i *idempotencyDataService) SaveIdempotentResponse(scope string, key string, response internal.IdempotentResponse) (err error) {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return errors.Wrap(err, "encode idempotent response header failed")
+	}
+	err = i.db.Model(&IdempotencyKey{}).Where("scope = ? AND idempotency_key = ?", scope, key).Updates(map[string]interface{}{
+		"status": response.Status,
+		"header": string(header),
+		"body":   response.Body,
+	}).Error
+	if err != nil {
+		return errors.Wrap(err, "save idempotent response failed")
+	}
+	return err
+}
+
+func (i *idempotencyDataService) ReleaseIdempotencyKey(scope string, key string) (err error) {
+	err = i.db.Where("scope = ? AND idempotency_key = ?", scope, key).Delete(&IdempotencyKey{}).Error
+	if err != nil {
+		return errors.Wrap(err, "release idempotency key failed")
+	}
+	return err
+}
+
+func (i *idempotencyDataService) PurgeIdempotencyKeys(now time.Time) (err error) {
+	err = i.db.Where("expires_at <= ?", now).Delete(&IdempotencyKey{}).Error
+	if err != nil {
+		return errors.Wrap(err, "purge idempotency keys failed")
+	}
+	return err
+}
+
+func toIdempotencyRecord(record IdempotencyKey) (response internal.IdempotencyRecord, err error) {
+	response = internal.IdempotencyRecord{
+		Key:         record.IdempotencyKey,
+		Fingerprint: record.Fingerprint,
+		ExpiresAt:   record.ExpiresAt,
+	}
+	if record.Status == 0 {
+		return response, nil
+	}
+	stored := internal.IdempotentResponse{Status: record.Status, Body: record.Body}
+	if record.Header != "" {
+		if err = json.Unmarshal([]byte(record.Header), &stored.Header); err != nil {
+			return response, errors.Wrap(err, "decode idempotent response header failed")
+		}
+	}
+	response.Response = &stored
+	return response, nil
+}
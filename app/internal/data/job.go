@@ -0,0 +1,199 @@
+package data
+
+import (
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type Job struct {
+	ID              uint `gorm:"primary_key"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Type            string `sql:"index"`
+	Status          string `sql:"index"`
+	Payload         []byte
+	Done            int64
+	Total           int64
+	Result          string `sql:"type:text"`
+	Error           string `sql:"type:text"`
+	Attempts        int
+	MaxAttempts     int
+	CreatedBy       uint `sql:"index"`
+	CancelRequested bool
+	RunAt           time.Time `sql:"index"`
+	LockedBy        string
+	LockedUntil     *time.Time
+	StartedAt       *time.Time
+	FinishedAt      *time.Time `sql:"index"`
+}
+
+type jobDataService struct {
+	db *gorm.DB
+}
+
+func NewJobService(db *gorm.DB) *jobDataService {
+	db.AutoMigrate(&Job{})
+	return &jobDataService{
+		db: db,
+	}
+}
+
+func (j *jobDataService) CreateJob(request internal.JobRequest) (response internal.Job, err error) {
+	if request.Type == "" || request.MaxAttempts <= 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidJobRequest, errors.New("missing create job fields"))
+	}
+	job := Job{
+		Type:        request.Type,
+		Status:      internal.JobQueued,
+		Payload:     request.Payload,
+		MaxAttempts: request.MaxAttempts,
+		CreatedBy:   request.CreatedBy,
+		RunAt:       time.Now(),
+	}
+	err = j.db.Create(&job).Error
+	if err != nil {
+		return response, errors.Wrap(err, "create job failed")
+	}
+	return toJob(job), err
+}
+
+func (j *jobDataService) GetJob(id uint) (response internal.Job, err error) {
+	var job Job
+	err = j.db.First(&job, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.JobNotFound, fmt.Errorf("job %d not found", id))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get job failed")
+	}
+	return toJob(job), err
+}
+
+// ClaimJob takes the queued job that is due first, or a running job whose
+// worker let its lease run out. SKIP LOCKED keeps concurrent workers, also of
+// other instances, from claiming the same job.
+func (j *jobDataService) ClaimJob(worker string, leaseUntil time.Time) (response internal.Job, claimed bool, err error) {
+	now := time.Now()
+	var jobs []Job
+	err = j.db.Raw(`UPDATE jobs SET status = ?, attempts = attempts + 1, locked_by = ?, locked_until = ?,
+			started_at = COALESCE(started_at, ?), updated_at = ?
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING *`, internal.JobRunning, worker, leaseUntil, now, now,
+		internal.JobQueued, now, internal.JobRunning, now).Scan(&jobs).Error
+	if err != nil {
+		return response, false, errors.Wrap(err, "claim job failed")
+	}
+	if len(jobs) == 0 {
+		return response, false, nil
+	}
+	return toJob(jobs[0]), true, nil
+}
+
+// RenewJob extends the lease of a running job and saves its progress. cancel
+// tells the worker to stop, because the job was cancelled or because the
+// worker no longer holds it.
+func (j *jobDataService) RenewJob(id uint, worker string, progress internal.JobProgress, leaseUntil time.Time) (cancel bool, err error) {
+	var jobs []Job
+	err = j.db.Raw(`UPDATE jobs SET done = ?, total = ?, locked_until = ?, updated_at = ?
+		WHERE id = ? AND locked_by = ? AND status = ?
+		RETURNING *`, progress.Done, progress.Total, leaseUntil, time.Now(), id, worker, internal.JobRunning).Scan(&jobs).Error
+	if err != nil {
+		return false, errors.Wrap(err, "renew job failed")
+	}
+	return len(jobs) == 0 || jobs[0].CancelRequested, nil
+}
+
+// FinishJob ends the attempt of the worker, either queueing the job again or
+// finishing it, when its payload isn't needed anymore.
+func (j *jobDataService) FinishJob(id uint, worker string, update internal.JobUpdate) (err error) {
+	now := time.Now()
+	values := map[string]interface{}{
+		"status":       update.Status,
+		"done":         update.Progress.Done,
+		"total":        update.Progress.Total,
+		"result":       string(update.Result),
+		"error":        update.Error,
+		"locked_by":    "",
+		"locked_until": nil,
+	}
+	if update.Status == internal.JobQueued {
+		values["run_at"] = update.RunAt
+	} else {
+		values["payload"] = nil
+		values["finished_at"] = now
+	}
+	err = j.db.Model(&Job{}).Where("id = ? AND locked_by = ?", id, worker).Updates(values).Error
+	if err != nil {
+		return errors.Wrap(err, "finish job failed")
+	}
+	return err
+}
+
+// CancelJob cancels a queued job at once and asks the worker of a running job
+// to stop, the job is cancelled once it did.
+func (j *jobDataService) CancelJob(id uint) (response internal.Job, err error) {
+	now := time.Now()
+	result := j.db.Model(&Job{}).Where("id = ? AND status = ?", id, internal.JobQueued).Updates(map[string]interface{}{
+		"status":      internal.JobCancelled,
+		"payload":     nil,
+		"finished_at": now,
+	})
+	if result.Error != nil {
+		return response, errors.Wrap(result.Error, "cancel job failed")
+	}
+	if result.RowsAffected == 0 {
+		result = j.db.Model(&Job{}).Where("id = ? AND status = ?", id, internal.JobRunning).Update("cancel_requested", true)
+		if result.Error != nil {
+			return response, errors.Wrap(result.Error, "cancel job failed")
+		}
+	}
+	response, err = j.GetJob(id)
+	if err != nil {
+		return response, err
+	}
+	if result.RowsAffected == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.JobFinished, fmt.Errorf("job %d is already %s", id, response.Status))
+	}
+	return response, nil
+}
+
+// PurgeJobs removes jobs that finished before the given time.
+func (j *jobDataService) PurgeJobs(before time.Time) (count int64, err error) {
+	result := j.db.Where("finished_at < ?", before).Delete(&Job{})
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "purge jobs failed")
+	}
+	return result.RowsAffected, nil
+}
+
+func toJob(job Job) internal.Job {
+	response := internal.Job{
+		ID:              job.ID,
+		Type:            job.Type,
+		Status:          job.Status,
+		Payload:         job.Payload,
+		Progress:        internal.JobProgress{Done: job.Done, Total: job.Total},
+		Error:           job.Error,
+		Attempts:        job.Attempts,
+		MaxAttempts:     job.MaxAttempts,
+		CreatedBy:       job.CreatedBy,
+		CancelRequested: job.CancelRequested,
+		CreatedAt:       job.CreatedAt,
+		StartedAt:       job.StartedAt,
+		FinishedAt:      job.FinishedAt,
+	}
+	if job.Result != "" {
+		response.Result = []byte(job.Result)
+	}
+	return response
+}
@@ -0,0 +1,216 @@
+package data
+
+import (
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type UserMFA struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint `sql:"unique_index"`
+	Secret    string
+	Enabled   bool
+	EnabledAt *time.Time
+	LastStep  int64
+}
+
+type RecoveryCode struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint   `sql:"index"`
+	CodeHash  string `sql:"index"`
+	UsedAt    *time.Time
+}
+
+type MFAChallenge struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint   `sql:"index"`
+	TokenHash string `sql:"index"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type mfaDataService struct {
+	db *gorm.DB
+}
+
+func NewMFAService(db *gorm.DB) *mfaDataService {
+	db.AutoMigrate(&UserMFA{})
+	db.AutoMigrate(&RecoveryCode{})
+	db.AutoMigrate(&MFAChallenge{})
+	return &mfaDataService{
+		db: db,
+	}
+}
+
+func (m *mfaDataService) GetMFA(userID uint) (response internal.MFA, err error) {
+	if userID == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("user_id is 0 for get mfa"))
+	}
+	var mfa UserMFA
+	err = m.db.Where("user_id = ?", userID).First(&mfa).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.MFANotEnrolled, fmt.Errorf("user %d has no mfa enrollment", userID))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get mfa failed")
+	}
+	response = internal.MFA{
+		UserID:   mfa.UserID,
+		Secret:   mfa.Secret,
+		Enabled:  mfa.Enabled,
+		LastStep: mfa.LastStep,
+	}
+	return response, err
+}
+
+// SaveMFASecret starts a new enrollment. It is refused while MFA is enabled so
+// an active second factor can only be replaced through ResetMFA.
+func (m *mfaDataService) SaveMFASecret(userID uint, secret string) (err error) {
+	if userID == 0 || secret == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("missing mfa enrollment fields"))
+	}
+	var mfa UserMFA
+	err = m.db.Where("user_id = ?", userID).First(&mfa).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		mfa = UserMFA{
+			UserID: userID,
+			Secret: secret,
+		}
+		err = m.db.Create(&mfa).Error
+		if err != nil {
+			return errors.Wrap(err, "create mfa failed")
+		}
+		return err
+	}
+	if err != nil {
+		return errors.Wrap(err, "get mfa failed")
+	}
+	if mfa.Enabled {
+		return serviceerror.NewServiceError(serviceerror.MFAAlreadyEnabled, fmt.Errorf("mfa of user %d is already enabled", userID))
+	}
+	err = m.db.Model(&mfa).Updates(map[string]interface{}{"secret": secret, "last_step": 0}).Error
+	if err != nil {
+		return errors.Wrap(err, "update mfa failed")
+	}
+	return err
+}
+
+func (m *mfaDataService) EnableMFA(userID uint, step int64, recoveryCodeHashes []string) (err error) {
+	if userID == 0 || len(recoveryCodeHashes) == 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("missing enable mfa fields"))
+	}
+	now := time.Now()
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&UserMFA{}).Where("user_id = ? AND enabled = ?", userID, false).
+			Updates(map[string]interface{}{"enabled": true, "enabled_at": now, "last_step": step})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "enable mfa failed")
+		}
+		if result.RowsAffected == 0 {
+			return serviceerror.NewServiceError(serviceerror.MFAAlreadyEnabled, fmt.Errorf("mfa of user %d is already enabled", userID))
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return errors.Wrap(err, "delete recovery codes failed")
+		}
+		for _, hash := range recoveryCodeHashes {
+			code := RecoveryCode{
+				UserID:   userID,
+				CodeHash: hash,
+			}
+			if err := tx.Create(&code).Error; err != nil {
+				return errors.Wrap(err, "create recovery code failed")
+			}
+		}
+		return nil
+	})
+}
+
+// UseMFAStep records the time step of an accepted code, a step at or before the
+// last accepted one is rejected so a code can't be replayed.
+func (m *mfaDataService) UseMFAStep(userID uint, step int64) (err error) {
+	result := m.db.Model(&UserMFA{}).Where("user_id = ? AND last_step < ?", userID, step).Update("last_step", step)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "update mfa step failed")
+	}
+	if result.RowsAffected == 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidMFACode, errors.New("mfa code is already used"))
+	}
+	return nil
+}
+
+func (m *mfaDataService) UseRecoveryCode(userID uint, codeHash string) (err error) {
+	result := m.db.Model(&RecoveryCode{}).Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "use recovery code failed")
+	}
+	if result.RowsAffected == 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidMFACode, errors.New("recovery code is invalid or used"))
+	}
+	return nil
+}
+
+func (m *mfaDataService) ResetMFA(userID uint) (err error) {
+	if userID == 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("user_id is 0 for reset mfa"))
+	}
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return errors.Wrap(err, "delete recovery codes failed")
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&MFAChallenge{}).Error; err != nil {
+			return errors.Wrap(err, "delete mfa challenges failed")
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&UserMFA{}).Error; err != nil {
+			return errors.Wrap(err, "delete mfa failed")
+		}
+		return nil
+	})
+}
+
+func (m *mfaDataService) CreateMFAChallenge(userID uint, tokenHash string, expiresAt time.Time) (err error) {
+	if userID == 0 || tokenHash == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("missing mfa challenge fields"))
+	}
+	challenge := MFAChallenge{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+	err = m.db.Create(&challenge).Error
+	if err != nil {
+		return errors.Wrap(err, "create mfa challenge failed")
+	}
+	return err
+}
+
+func (m *mfaDataService) ConsumeMFAChallenge(tokenHash string) (userID uint, err error) {
+	now := time.Now()
+	var challenge MFAChallenge
+	err = m.db.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).First(&challenge).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return userID, serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("mfa challenge is invalid or expired"))
+	}
+	if err != nil {
+		return userID, errors.Wrap(err, "get mfa challenge failed")
+	}
+	result := m.db.Model(&MFAChallenge{}).Where("id = ? AND used_at IS NULL", challenge.ID).Update("used_at", now)
+	if result.Error != nil {
+		return userID, errors.Wrap(result.Error, "consume mfa challenge failed")
+	}
+	if result.RowsAffected == 0 {
+		return userID, serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("mfa challenge is already used"))
+	}
+	return challenge.UserID, err
+}
@@ -0,0 +1,183 @@
+package data
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// OAuthClient keeps the secret of a confidential client hashed like the
+// passwords of users, public clients have none. Redirect URIs are space
+// separated as they can't hold spaces.
+type OAuthClient struct {
+	ID               uint `gorm:"primary_key"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	ClientID         string `sql:"unique_index"`
+	Name             string
+	SecretHash       string
+	Salt             string
+	RedirectURIs     string
+	GrantTypes       string
+	Scopes           string
+	ServiceAccountID uint
+}
+
+type OAuthAuthorizationCode struct {
+	ID            uint `gorm:"primary_key"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	CodeHash      string `sql:"unique_index"`
+	ClientID      string `sql:"index"`
+	UserID        uint   `sql:"index"`
+	RedirectURI   string
+	Scopes        string
+	CodeChallenge string
+	Nonce         string
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+}
+
+type oauthDataService struct {
+	db *gorm.DB
+}
+
+func NewOAuthService(db *gorm.DB) *oauthDataService {
+	db.AutoMigrate(&OAuthClient{})
+	db.AutoMigrate(&OAuthAuthorizationCode{})
+	return &oauthDataService{
+		db: db,
+	}
+}
+
+func (o *oauthDataService) CreateClient(client internal.OAuthClient, secret string) (response internal.OAuthClient, err error) {
+	if client.ClientID == "" || client.Name == "" || len(client.GrantTypes) == 0 || len(client.Scopes) == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidOAuthClientRequest, errors.New("missing oauth client fields"))
+	}
+	if client.Confidential != (secret != "") {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidOAuthClientRequest, errors.New("only confidential clients have a secret"))
+	}
+	oauthClient := OAuthClient{
+		ClientID:         client.ClientID,
+		Name:             client.Name,
+		RedirectURIs:     strings.Join(client.RedirectURIs, " "),
+		GrantTypes:       strings.Join(client.GrantTypes, ","),
+		Scopes:           strings.Join(client.Scopes, ","),
+		ServiceAccountID: client.ServiceAccountID,
+	}
+	if secret != "" {
+		oauthClient.Salt = randomString()
+		oauthClient.SecretHash = encodePassword(secret, oauthClient.Salt)
+	}
+	err = o.db.Create(&oauthClient).Error
+	if err != nil {
+		return response, errors.Wrap(err, "create oauth client failed")
+	}
+	return toOAuthClient(oauthClient), err
+}
+
+func (o *oauthDataService) GetClient(clientID string) (response internal.OAuthClient, err error) {
+	var client OAuthClient
+	err = o.db.Where("client_id = ?", clientID).First(&client).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthClientNotFound, fmt.Errorf("oauth client %s not found", clientID))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get oauth client failed")
+	}
+	return toOAuthClient(client), err
+}
+
+func (o *oauthDataService) AuthenticateClient(clientID string, secret string) (response internal.OAuthClient, err error) {
+	if clientID == "" || secret == "" {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthInvalidClient, errors.New("missing client credentials"))
+	}
+	var client OAuthClient
+	err = o.db.Where("client_id = ?", clientID).First(&client).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, errors.Wrap(err, "get oauth client failed")
+	}
+	// hash even for unknown clients so both cases take the same time
+	encoded := encodePassword(secret, client.Salt)
+	if client.ID == 0 || client.SecretHash == "" || subtle.ConstantTimeCompare([]byte(encoded), []byte(client.SecretHash)) != 1 {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthInvalidClient, errors.New("client id or secret is wrong"))
+	}
+	return toOAuthClient(client), nil
+}
+
+func (o *oauthDataService) CreateAuthorizationCode(code internal.OAuthAuthorizationCode) (err error) {
+	if code.ClientID == "" || code.UserID == 0 || code.CodeHash == "" || code.CodeChallenge == "" {
+		return serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("missing authorization code fields"))
+	}
+	err = o.db.Create(&OAuthAuthorizationCode{
+		CodeHash:      code.CodeHash,
+		ClientID:      code.ClientID,
+		UserID:        code.UserID,
+		RedirectURI:   code.RedirectURI,
+		Scopes:        strings.Join(code.Scopes, ","),
+		CodeChallenge: code.CodeChallenge,
+		Nonce:         code.Nonce,
+		ExpiresAt:     code.ExpiresAt,
+	}).Error
+	if err != nil {
+		return errors.Wrap(err, "create authorization code failed")
+	}
+	return nil
+}
+
+func (o *oauthDataService) ConsumeAuthorizationCode(codeHash string) (response internal.OAuthAuthorizationCode, err error) {
+	now := time.Now()
+	var code OAuthAuthorizationCode
+	err = o.db.Where("code_hash = ? AND used_at IS NULL AND expires_at > ?", codeHash, now).First(&code).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthInvalidGrant, errors.New("authorization code is invalid or expired"))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get authorization code failed")
+	}
+	result := o.db.Model(&OAuthAuthorizationCode{}).Where("id = ? AND used_at IS NULL", code.ID).Update("used_at", now)
+	if result.Error != nil {
+		return response, errors.Wrap(result.Error, "consume authorization code failed")
+	}
+	if result.RowsAffected == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthInvalidGrant, errors.New("authorization code is already used"))
+	}
+	return internal.OAuthAuthorizationCode{
+		ClientID:      code.ClientID,
+		UserID:        code.UserID,
+		CodeHash:      code.CodeHash,
+		RedirectURI:   code.RedirectURI,
+		Scopes:        splitList(code.Scopes, ","),
+		CodeChallenge: code.CodeChallenge,
+		Nonce:         code.Nonce,
+		ExpiresAt:     code.ExpiresAt,
+	}, err
+}
+
+func toOAuthClient(client OAuthClient) internal.OAuthClient {
+	return internal.OAuthClient{
+		ID:               client.ID,
+		ClientID:         client.ClientID,
+		Name:             client.Name,
+		Confidential:     client.SecretHash != "",
+		RedirectURIs:     splitList(client.RedirectURIs, " "),
+		GrantTypes:       splitList(client.GrantTypes, ","),
+		Scopes:           splitList(client.Scopes, ","),
+		ServiceAccountID: client.ServiceAccountID,
+		CreatedAt:        client.CreatedAt,
+	}
+}
+
+// splitList splits a joined column, an empty one is an empty list.
+func splitList(joined string, separator string) []string {
+	if joined == "" {
+		return []string{}
+	}
+	return strings.Split(joined, separator)
+}
@@ -0,0 +1,95 @@
+package data
+
+import (
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type Organization struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string `sql:"index"`
+}
+
+type organizationDataService struct {
+	db *gorm.DB
+}
+
+func NewOrganizationService(db *gorm.DB) *organizationDataService {
+	db.AutoMigrate(&Organization{})
+	return &organizationDataService{
+		db: db,
+	}
+}
+
+func (o *organizationDataService) CreateOrganization(request internal.OrganizationRequest) (response internal.Organization, err error) {
+	if request.Name == "" {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidOrganizationRequest, errors.New("missing create organization fields"))
+	}
+	var count int64
+	err = o.db.Model(&Organization{}).Where("name = ?", request.Name).Count(&count).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get organization with name count failed")
+	}
+	if count > 0 {
+		return response, serviceerror.NewServiceError(serviceerror.DuplicateOrganization, fmt.Errorf("organization with name %s is present", request.Name))
+	}
+	organization := Organization{
+		Name: request.Name,
+	}
+	err = o.db.Create(&organization).Error
+	if err != nil {
+		return response, errors.Wrap(err, "create organization failed")
+	}
+	return toOrganization(organization), nil
+}
+
+func (o *organizationDataService) GetOrganization(id uint) (response internal.Organization, err error) {
+	var organization Organization
+	err = o.db.First(&organization, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.OrganizationNotFound, fmt.Errorf("organization %d not found", id))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get organization failed")
+	}
+	return toOrganization(organization), nil
+}
+
+func (o *organizationDataService) GetOrganizations(offset uint, limit uint) (response internal.OrganizationsResponse, err error) {
+	if limit == 0 || limit > 1000 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidOrganizationRequest, fmt.Errorf("limit %d is not valid for getting organizations", limit))
+	}
+	var organizations []Organization
+	err = o.db.Order("id").Limit(limit).Offset(offset).Find(&organizations).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get organizations failed")
+	}
+	var count int64
+	err = o.db.Model(&Organization{}).Count(&count).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get organizations count failed")
+	}
+	response = internal.OrganizationsResponse{
+		Organizations: make([]internal.Organization, len(organizations)),
+		Total:         uint(count),
+	}
+	for i, organization := range organizations {
+		response.Organizations[i] = toOrganization(organization)
+	}
+	return response, nil
+}
+
+func toOrganization(organization Organization) internal.Organization {
+	return internal.Organization{
+		ID:        organization.ID,
+		Name:      organization.Name,
+		CreatedAt: organization.CreatedAt,
+	}
+}
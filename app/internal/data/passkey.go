@@ -0,0 +1,210 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type WebAuthnCredential struct {
+	ID           uint `gorm:"primary_key"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uint   `sql:"index"`
+	CredentialID string `sql:"unique_index"`
+	PublicKey    []byte
+	SignCount    int64
+	Transports   string
+	Name         string
+	LastUsedAt   *time.Time
+}
+
+type WebAuthnSession struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint `sql:"index"`
+	Ceremony  string
+	Challenge string `sql:"index"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type passkeyDataService struct {
+	db *gorm.DB
+}
+
+func NewPasskeyService(db *gorm.DB) *passkeyDataService {
+	db.AutoMigrate(&WebAuthnCredential{})
+	db.AutoMigrate(&WebAuthnSession{})
+	return &passkeyDataService{
+		db: db,
+	}
+}
+
+// CreatePasskeySession stores the challenge of a started ceremony. userID is 0
+// for a login that was started without naming the user.
+func (p *passkeyDataService) CreatePasskeySession(userID uint, ceremony string, challenge string, expiresAt time.Time) (err error) {
+	if ceremony == "" || challenge == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidPasskey, errors.New("missing passkey session fields"))
+	}
+	session := WebAuthnSession{
+		UserID:    userID,
+		Ceremony:  ceremony,
+		Challenge: challenge,
+		ExpiresAt: expiresAt,
+	}
+	err = p.db.Create(&session).Error
+	if err != nil {
+		return errors.Wrap(err, "create passkey session failed")
+	}
+	return err
+}
+
+func (p *passkeyDataService) ConsumePasskeySession(ceremony string, challenge string) (userID uint, err error) {
+	now := time.Now()
+	var session WebAuthnSession
+	err = p.db.Where("ceremony = ? AND challenge = ? AND used_at IS NULL AND expires_at > ?", ceremony, challenge, now).
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return userID, serviceerror.NewServiceError(serviceerror.InvalidPasskey, errors.New("passkey challenge is invalid or expired"))
+	}
+	if err != nil {
+		return userID, errors.Wrap(err, "get passkey session failed")
+	}
+	result := p.db.Model(&WebAuthnSession{}).Where("id = ? AND used_at IS NULL", session.ID).Update("used_at", now)
+	if result.Error != nil {
+		return userID, errors.Wrap(result.Error, "consume passkey session failed")
+	}
+	if result.RowsAffected == 0 {
+		return userID, serviceerror.NewServiceError(serviceerror.InvalidPasskey, errors.New("passkey challenge is already used"))
+	}
+	return session.UserID, err
+}
+
+func (p *passkeyDataService) CreatePasskey(passkey internal.Passkey) (response internal.PasskeyResponse, err error) {
+	if passkey.UserID == 0 || passkey.CredentialID == "" || len(passkey.PublicKey) == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidPasskey, errors.New("missing passkey fields"))
+	}
+	var count int
+	err = p.db.Model(&WebAuthnCredential{}).Where("credential_id = ?", passkey.CredentialID).Count(&count).Error
+	if err != nil {
+		return response, errors.Wrap(err, "count passkeys failed")
+	}
+	if count > 0 {
+		return response, serviceerror.NewServiceError(serviceerror.DuplicatePasskey, errors.New("passkey is already registered"))
+	}
+	credential := WebAuthnCredential{
+		UserID:       passkey.UserID,
+		CredentialID: passkey.CredentialID,
+		PublicKey:    passkey.PublicKey,
+		SignCount:    int64(passkey.SignCount),
+		Transports:   strings.Join(passkey.Transports, ","),
+		Name:         passkey.Name,
+	}
+	err = p.db.Create(&credential).Error
+	if err != nil {
+		return response, errors.Wrap(err, "create passkey failed")
+	}
+	return toPasskeyResponse(credential), err
+}
+
+func (p *passkeyDataService) GetPasskeys(userID uint) (response []internal.Passkey, err error) {
+	var credentials []WebAuthnCredential
+	err = p.db.Where("user_id = ?", userID).Order("id").Find(&credentials).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get passkeys failed")
+	}
+	response = make([]internal.Passkey, 0, len(credentials))
+	for _, credential := range credentials {
+		response = append(response, toPasskey(credential))
+	}
+	return response, err
+}
+
+func (p *passkeyDataService) GetPasskeyByCredentialID(credentialID string) (response internal.Passkey, err error) {
+	var credential WebAuthnCredential
+	err = p.db.Where("credential_id = ?", credentialID).First(&credential).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.PasskeyNotFound, errors.New("passkey is not registered"))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get passkey failed")
+	}
+	return toPasskey(credential), err
+}
+
+// UsePasskey stores the sign counter of a verified assertion. The update only
+// applies while the stored counter is lower, so two assertions racing with the
+// same counter can't both succeed.
+func (p *passkeyDataService) UsePasskey(id uint, signCount uint32) (err error) {
+	query := p.db.Model(&WebAuthnCredential{}).Where("id = ?", id)
+	if signCount > 0 {
+		query = query.Where("sign_count < ?", signCount)
+	}
+	result := query.Updates(map[string]interface{}{"sign_count": signCount, "last_used_at": time.Now()})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "update passkey failed")
+	}
+	if result.RowsAffected == 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("passkey sign count is not increasing"))
+	}
+	return nil
+}
+
+func (p *passkeyDataService) RenamePasskey(userID uint, id uint, name string) (err error) {
+	if name == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidPasskey, errors.New("missing passkey name"))
+	}
+	result := p.db.Model(&WebAuthnCredential{}).Where("id = ? AND user_id = ?", id, userID).Update("name", name)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "rename passkey failed")
+	}
+	if result.RowsAffected == 0 {
+		return serviceerror.NewServiceError(serviceerror.PasskeyNotFound, fmt.Errorf("passkey %d of user %d not found", id, userID))
+	}
+	return nil
+}
+
+func (p *passkeyDataService) DeletePasskey(userID uint, id uint) (err error) {
+	result := p.db.Where("id = ? AND user_id = ?", id, userID).Delete(&WebAuthnCredential{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "delete passkey failed")
+	}
+	if result.RowsAffected == 0 {
+		return serviceerror.NewServiceError(serviceerror.PasskeyNotFound, fmt.Errorf("passkey %d of user %d not found", id, userID))
+	}
+	return nil
+}
+
+func toPasskey(credential WebAuthnCredential) internal.Passkey {
+	var transports []string
+	if credential.Transports != "" {
+		transports = strings.Split(credential.Transports, ",")
+	}
+	return internal.Passkey{
+		ID:           credential.ID,
+		UserID:       credential.UserID,
+		CredentialID: credential.CredentialID,
+		PublicKey:    credential.PublicKey,
+		SignCount:    uint32(credential.SignCount),
+		Transports:   transports,
+		Name:         credential.Name,
+		CreatedAt:    credential.CreatedAt,
+		LastUsedAt:   credential.LastUsedAt,
+	}
+}
+
+func toPasskeyResponse(credential WebAuthnCredential) internal.PasskeyResponse {
+	return internal.PasskeyResponse{
+		ID:         credential.ID,
+		Name:       credential.Name,
+		CreatedAt:  credential.CreatedAt,
+		LastUsedAt: credential.LastUsedAt,
+	}
+}
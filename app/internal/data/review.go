@@ -0,0 +1,366 @@
+package data
+
+import (
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+type ReviewCampaign struct {
+	ID              uint `gorm:"primary_key"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	OrganizationID  uint `sql:"not null;default:0;index"`
+	Name            string
+	GroupIDs        pq.Int64Array `sql:"type:bigint[]"`
+	Status          string        `sql:"index"`
+	Deadline        time.Time     `sql:"index"`
+	RevokeUndecided bool
+	ClosedAt        *time.Time
+}
+
+type ReviewItem struct {
+	ID         uint `gorm:"primary_key"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	CampaignID uint `sql:"index"`
+	UserID     uint
+	GroupID    uint
+	Role       string
+	ReviewerID uint   `sql:"index"`
+	Decision   string `sql:"index"`
+	DecidedBy  uint
+	Comment    string
+	DecidedAt  *time.Time
+}
+
+type reviewDataService struct {
+	db           *gorm.DB
+	organization *uint
+}
+
+func NewReviewService(db *gorm.DB) *reviewDataService {
+	db.AutoMigrate(&ReviewCampaign{})
+	db.AutoMigrate(&ReviewItem{})
+	return &reviewDataService{
+		db: db,
+	}
+}
+
+// ForOrganization returns the same data limited to the campaigns of the
+// organization and their items, campaigns it creates belong to the
+// organization.
+func (r *reviewDataService) ForOrganization(organizationID uint) internal.AccessReviewData {
+	return &reviewDataService{
+		db:           r.db,
+		organization: &organizationID,
+	}
+}
+
+// scoped limits a query on review campaigns to the organization, if any.
+func (r *reviewDataService) scoped(query *gorm.DB) *gorm.DB {
+	if r.organization == nil {
+		return query
+	}
+	return query.Where("review_campaigns.organization_id = ?", *r.organization)
+}
+
+// scopedItems limits a query on review items to the campaigns of the
+// organization, if any.
+func (r *reviewDataService) scopedItems(query *gorm.DB) *gorm.DB {
+	if r.organization == nil {
+		return query
+	}
+	return query.Where("review_items.campaign_id IN (SELECT id FROM review_campaigns WHERE organization_id = ?)", *r.organization)
+}
+
+// organizationID is the organization new campaigns belong to, the default one
+// for data that isn't limited to an organization.
+func (r *reviewDataService) organizationID() uint {
+	if r.organization == nil {
+		return 0
+	}
+	return *r.organization
+}
+
+// CreateCampaign stores the campaign with its items, all pending, in one
+// transaction.
+func (r *reviewDataService) CreateCampaign(request internal.ReviewCampaignRequest, items []internal.ReviewItem) (response internal.ReviewCampaign, err error) {
+	if request.Name == "" || len(request.GroupIDs) == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidReviewRequest, errors.New("missing create review campaign fields"))
+	}
+	campaign := ReviewCampaign{
+		OrganizationID:  r.organizationID(),
+		Name:            request.Name,
+		Status:          internal.CampaignOpen,
+		Deadline:        request.Deadline,
+		RevokeUndecided: request.RevokeUndecided,
+	}
+	for _, id := range request.GroupIDs {
+		campaign.GroupIDs = append(campaign.GroupIDs, int64(id))
+	}
+	var userIDs, groupIDs, reviewerIDs pq.Int64Array
+	var roles pq.StringArray
+	for _, item := range items {
+		userIDs = append(userIDs, int64(item.UserID))
+		groupIDs = append(groupIDs, int64(item.GroupID))
+		reviewerIDs = append(reviewerIDs, int64(item.ReviewerID))
+		roles = append(roles, item.Role)
+	}
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&campaign).Error
+		if err != nil {
+			return errors.Wrap(err, "create review campaign failed")
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		now := time.Now()
+		err = tx.Exec(`INSERT INTO review_items (created_at, updated_at, campaign_id, user_id, group_id, role, reviewer_id, decision)
+			SELECT ?, ?, ?, user_id, group_id, role, reviewer_id, ?
+			FROM unnest(?::bigint[], ?::bigint[], ?::text[], ?::bigint[]) AS items (user_id, group_id, role, reviewer_id)`,
+			now, now, campaign.ID, internal.ReviewPending, userIDs, groupIDs, roles, reviewerIDs).Error
+		if err != nil {
+			return errors.Wrap(err, "create review items failed")
+		}
+		return nil
+	})
+	if err != nil {
+		return response, err
+	}
+	response = toReviewCampaign(campaign)
+	response.Summary.Pending = len(items)
+	return response, nil
+}
+
+func (r *reviewDataService) GetCampaign(id uint) (response internal.ReviewCampaign, err error) {
+	var campaign ReviewCampaign
+	err = r.scoped(r.db).First(&campaign, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.ReviewNotFound, fmt.Errorf("review campaign %d not found", id))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get review campaign failed")
+	}
+	campaigns, err := r.withSummaries([]ReviewCampaign{campaign})
+	if err != nil {
+		return response, err
+	}
+	return campaigns[0], nil
+}
+
+func (r *reviewDataService) GetCampaigns(offset uint, limit uint, status string) (response internal.ReviewCampaignsResponse, err error) {
+	if limit == 0 || limit > 1000 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidReviewRequest, fmt.Errorf("limit %d is not valid for getting review campaigns", limit))
+	}
+	query := r.scoped(r.db.Model(&ReviewCampaign{}))
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var campaigns []ReviewCampaign
+	err = query.Order("id").Limit(limit).Offset(offset).Find(&campaigns).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get review campaigns failed")
+	}
+	var count int64
+	err = query.Count(&count).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get review campaigns count failed")
+	}
+	response.Campaigns, err = r.withSummaries(campaigns)
+	response.Total = uint(count)
+	return response, err
+}
+
+// GetDueCampaigns lists the open campaigns whose deadline has passed.
+func (r *reviewDataService) GetDueCampaigns(now time.Time) (response []internal.ReviewCampaign, err error) {
+	var campaigns []ReviewCampaign
+	err = r.db.Where("status = ? AND deadline <= ?", internal.CampaignOpen, now).Order("id").Find(&campaigns).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get due review campaigns failed")
+	}
+	return r.withSummaries(campaigns)
+}
+
+// withSummaries counts the decisions of the campaigns in one query.
+func (r *reviewDataService) withSummaries(campaigns []ReviewCampaign) (response []internal.ReviewCampaign, err error) {
+	response = make([]internal.ReviewCampaign, len(campaigns))
+	if len(campaigns) == 0 {
+		return response, nil
+	}
+	var ids pq.Int64Array
+	for _, campaign := range campaigns {
+		ids = append(ids, int64(campaign.ID))
+	}
+	var counts []struct {
+		CampaignID uint
+		Decision   string
+		Count      int
+	}
+	err = r.db.Raw("SELECT campaign_id, decision, count(*) AS count FROM review_items WHERE campaign_id = ANY(?) GROUP BY campaign_id, decision", ids).
+		Scan(&counts).Error
+	if err != nil {
+		return response, errors.Wrap(err, "count review decisions failed")
+	}
+	summaries := map[uint]*internal.ReviewSummary{}
+	for i, campaign := range campaigns {
+		response[i] = toReviewCampaign(campaign)
+		summaries[campaign.ID] = &response[i].Summary
+	}
+	for _, count := range counts {
+		summary := summaries[count.CampaignID]
+		switch count.Decision {
+		case internal.ReviewPending:
+			summary.Pending = count.Count
+		case internal.ReviewCertified:
+			summary.Certified = count.Count
+		case internal.ReviewRevoked:
+			summary.Revoked = count.Count
+		case internal.ReviewUndecided:
+			summary.Undecided = count.Count
+		}
+	}
+	return response, nil
+}
+
+func (r *reviewDataService) GetReviewItem(campaignID uint, id uint) (response internal.ReviewItem, err error) {
+	var items []ReviewItem
+	err = r.scopedItems(r.db.Where("campaign_id = ? AND id = ?", campaignID, id)).Limit(1).Find(&items).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get review item failed")
+	}
+	if len(items) == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.ReviewNotFound, fmt.Errorf("review item %d of campaign %d not found", id, campaignID))
+	}
+	return toReviewItem(items[0]), nil
+}
+
+func (r *reviewDataService) GetReviewItems(campaignID uint, offset uint, limit uint, filter internal.ReviewItemsFilter) (response internal.ReviewItemsResponse, err error) {
+	if limit == 0 || limit > 1000 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidReviewRequest, fmt.Errorf("limit %d is not valid for getting review items", limit))
+	}
+	query := r.scopedItems(r.db.Model(&ReviewItem{}).Where("campaign_id = ?", campaignID))
+	if filter.ReviewerID != 0 {
+		query = query.Where("reviewer_id = ?", filter.ReviewerID)
+	}
+	if filter.Decision != "" {
+		query = query.Where("decision = ?", filter.Decision)
+	}
+	var items []ReviewItem
+	err = query.Order("id").Limit(limit).Offset(offset).Find(&items).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get review items failed")
+	}
+	var count int64
+	err = query.Count(&count).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get review items count failed")
+	}
+	response = internal.ReviewItemsResponse{
+		Items: make([]internal.ReviewItem, len(items)),
+		Total: uint(count),
+	}
+	for i, item := range items {
+		response.Items[i] = toReviewItem(item)
+	}
+	return response, nil
+}
+
+// DecideReviewItem only changes a pending item while its campaign is open, so
+// an item is decided once and not after the campaign closed. Limited to an
+// organization, only items of its campaigns change.
+func (r *reviewDataService) DecideReviewItem(decision internal.ReviewDecision) (response internal.ReviewItem, err error) {
+	if decision.Decision != internal.ReviewCertified && decision.Decision != internal.ReviewRevoked {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidReviewRequest, fmt.Errorf("review item can't be decided as %s", decision.Decision))
+	}
+	now := time.Now()
+	var items []ReviewItem
+	err = r.db.Raw(`UPDATE review_items SET decision = ?, decided_by = ?, comment = ?, decided_at = ?, updated_at = ?
+		WHERE id = ? AND campaign_id = ? AND decision = ?
+			AND EXISTS (SELECT 1 FROM review_campaigns WHERE id = ? AND status = ? AND (? OR organization_id = ?))
+		RETURNING *`, decision.Decision, decision.DecidedBy, decision.Comment, now, now,
+		decision.ItemID, decision.CampaignID, internal.ReviewPending, decision.CampaignID, internal.CampaignOpen,
+		r.organization == nil, r.organizationID()).Scan(&items).Error
+	if err != nil {
+		return response, errors.Wrap(err, "decide review item failed")
+	}
+	if len(items) > 0 {
+		return toReviewItem(items[0]), nil
+	}
+	response, err = r.GetReviewItem(decision.CampaignID, decision.ItemID)
+	if err != nil {
+		return response, err
+	}
+	if response.Decision == internal.ReviewPending {
+		return response, serviceerror.NewServiceError(serviceerror.ReviewDecided, fmt.Errorf("review campaign %d is closed", decision.CampaignID))
+	}
+	return response, serviceerror.NewServiceError(serviceerror.ReviewDecided, fmt.Errorf("review item %d is %s", decision.ItemID, response.Decision))
+}
+
+// CloseCampaign closes an open campaign and marks its pending items
+// undecided.
+func (r *reviewDataService) CloseCampaign(id uint) (err error) {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := r.scoped(tx.Model(&ReviewCampaign{})).Where("id = ? AND status = ?", id, internal.CampaignOpen).
+			Updates(map[string]interface{}{"status": internal.CampaignClosed, "closed_at": now})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "close review campaign failed")
+		}
+		if result.RowsAffected == 0 {
+			var count int64
+			err := r.scoped(tx.Model(&ReviewCampaign{})).Where("id = ?", id).Count(&count).Error
+			if err != nil {
+				return errors.Wrap(err, "get review campaign count failed")
+			}
+			if count == 0 {
+				return serviceerror.NewServiceError(serviceerror.ReviewNotFound, fmt.Errorf("review campaign %d not found", id))
+			}
+			return serviceerror.NewServiceError(serviceerror.ReviewDecided, fmt.Errorf("review campaign %d is already closed", id))
+		}
+		err := tx.Model(&ReviewItem{}).Where("campaign_id = ? AND decision = ?", id, internal.ReviewPending).
+			Updates(map[string]interface{}{"decision": internal.ReviewUndecided, "updated_at": now}).Error
+		if err != nil {
+			return errors.Wrap(err, "mark review items undecided failed")
+		}
+		return nil
+	})
+}
+
+func toReviewCampaign(campaign ReviewCampaign) internal.ReviewCampaign {
+	groupIDs := make([]uint, len(campaign.GroupIDs))
+	for i, id := range campaign.GroupIDs {
+		groupIDs[i] = uint(id)
+	}
+	return internal.ReviewCampaign{
+		ID:              campaign.ID,
+		OrganizationID:  campaign.OrganizationID,
+		Name:            campaign.Name,
+		GroupIDs:        groupIDs,
+		Status:          campaign.Status,
+		Deadline:        campaign.Deadline,
+		RevokeUndecided: campaign.RevokeUndecided,
+		CreatedAt:       campaign.CreatedAt,
+		ClosedAt:        campaign.ClosedAt,
+	}
+}
+
+func toReviewItem(item ReviewItem) internal.ReviewItem {
+	return internal.ReviewItem{
+		ID:         item.ID,
+		CampaignID: item.CampaignID,
+		UserID:     item.UserID,
+		GroupID:    item.GroupID,
+		Role:       item.Role,
+		ReviewerID: item.ReviewerID,
+		Decision:   item.Decision,
+		DecidedBy:  item.DecidedBy,
+		Comment:    item.Comment,
+		DecidedAt:  item.DecidedAt,
+	}
+}
@@ -0,0 +1,88 @@
+package data
+
+import (
+	"time"
+	"usermanagement/app/internal"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// SigningKey keeps the private key encrypted, the data service never sees it
+// in the clear. The active key is the one that isn't retired.
+type SigningKey struct {
+	ID           uint `gorm:"primary_key"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	KeyID        string `sql:"unique_index"`
+	Algorithm    string
+	EncryptedKey []byte
+	RetiredAt    *time.Time `sql:"index"`
+	ExpiresAt    *time.Time `sql:"index"`
+}
+
+type signingKeyDataService struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyService(db *gorm.DB) *signingKeyDataService {
+	db.AutoMigrate(&SigningKey{})
+	return &signingKeyDataService{
+		db: db,
+	}
+}
+
+func (s *signingKeyDataService) GetSigningKeys() (response []internal.StoredSigningKey, err error) {
+	var keys []SigningKey
+	err = s.db.Order("created_at desc, id desc").Find(&keys).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get signing keys failed")
+	}
+	response = make([]internal.StoredSigningKey, len(keys))
+	for i, key := range keys {
+		response[i] = toStoredSigningKey(key)
+	}
+	return response, nil
+}
+
+func (s *signingKeyDataService) RotateSigningKey(key internal.StoredSigningKey, expiresAt time.Time) (err error) {
+	if key.ID == "" || key.Algorithm == "" || len(key.EncryptedKey) == 0 {
+		return errors.New("missing signing key fields")
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&SigningKey{}).Where("retired_at IS NULL").
+			Updates(map[string]interface{}{"retired_at": now, "expires_at": expiresAt}).Error
+		if err != nil {
+			return errors.Wrap(err, "retire signing key failed")
+		}
+		err = tx.Create(&SigningKey{
+			KeyID:        key.ID,
+			Algorithm:    key.Algorithm,
+			EncryptedKey: key.EncryptedKey,
+		}).Error
+		if err != nil {
+			return errors.Wrap(err, "create signing key failed")
+		}
+		return nil
+	})
+}
+
+func (s *signingKeyDataService) PurgeSigningKeys(now time.Time) (count int64, err error) {
+	result := s.db.Where("expires_at < ?", now).Delete(&SigningKey{})
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "purge signing keys failed")
+	}
+	return result.RowsAffected, nil
+}
+
+func toStoredSigningKey(key SigningKey) internal.StoredSigningKey {
+	return internal.StoredSigningKey{
+		ID:           key.KeyID,
+		Algorithm:    key.Algorithm,
+		EncryptedKey: key.EncryptedKey,
+		CreatedAt:    key.CreatedAt,
+		RetiredAt:    key.RetiredAt,
+		ExpiresAt:    key.ExpiresAt,
+	}
+}
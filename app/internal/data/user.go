@@ -2,9 +2,11 @@ package data
 
 import (
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 	"usermanagement/app/internal"
 	"usermanagement/app/internal/serviceerror"
@@ -14,34 +16,118 @@ import (
 	"golang.org/x/crypto/pbkdf2"
 )
 
+// errVersionMismatch rolls back a conditional write that found no row at the
+// expected version.
+var errVersionMismatch = errors.New("version mismatch")
+
 type userDataService struct {
 	db *gorm.DB
+	// organization limits the users to one organization, all are seen if nil
+	organization *uint
 }
 
 type User struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      *time.Time `sql:"index"`
+	OrganizationID uint       `sql:"not null;default:0;index"`
+	Type           string     `sql:"not null;default:'person'"`
+	Name           string
+	Password       string
+	Salt           string
+	Email          string `sql:"index"`
+	EmailVerified  bool
+	PendingEmail   string
+	Status         string `sql:"index;default:'active'"`
+	StatusReason   string
+	SuspendedUntil *time.Time
+	ErasedAt       *time.Time
+	Attributes     Attributes `sql:"type:jsonb"`
+	Version        uint       `sql:"not null;default:1"`
+}
+
+type EmailVerification struct {
 	ID        uint `gorm:"primary_key"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	DeletedAt *time.Time `sql:"index"`
-	Name      string
-	Password  string
-	Salt      string
-	Email     string `sql:"index"`
+	UserID    uint `sql:"index"`
+	Email     string
+	TokenHash string `sql:"index"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type PasswordReset struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint   `sql:"index"`
+	TokenHash string `sql:"index"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
 }
 
 func NewUserService(db *gorm.DB) *userDataService {
 	db.AutoMigrate(&User{})
+	db.AutoMigrate(&PasswordReset{})
+	db.AutoMigrate(&EmailVerification{})
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_users_attributes ON users USING gin (attributes)")
 	return &userDataService{
 		db: db,
 	}
 }
 
+// ForOrganization returns the same data limited to the users of the
+// organization, users it creates belong to the organization.
+func (u *userDataService) ForOrganization(organizationID uint) internal.UserData {
+	return &userDataService{
+		db:           u.db,
+		organization: &organizationID,
+	}
+}
+
+// scoped limits a query on users to the organization, if any.
+func (u *userDataService) scoped(query *gorm.DB) *gorm.DB {
+	if u.organization == nil {
+		return query
+	}
+	return query.Where("users.organization_id = ?", *u.organization)
+}
+
+// inOrganization limits a query on users to the organization, the default one
+// for data that isn't limited to an organization. Lookups by email use it, as
+// the same email may belong to users of several organizations.
+func (u *userDataService) inOrganization(query *gorm.DB) *gorm.DB {
+	return query.Where("users.organization_id = ?", u.organizationID())
+}
+
+// organizationID is the organization new users belong to, the default one
+// for data that isn't limited to an organization.
+func (u *userDataService) organizationID() uint {
+	if u.organization == nil {
+		return 0
+	}
+	return *u.organization
+}
+
 func (u *userDataService) CreateUser(request internal.UserRequest) (response internal.UserResponse, err error) {
-	if request.Email == "" || request.Name == "" || request.Password == "" {
+	userType := request.Type
+	if userType == "" {
+		userType = internal.UserPerson
+	}
+	if userType != internal.UserPerson && userType != internal.UserServiceAccount {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, fmt.Errorf("user type %s is not valid", userType))
+	}
+	hasPassword := request.Password != "" || request.PasswordHash != ""
+	if request.Email == "" || request.Name == "" || (!hasPassword && userType == internal.UserPerson) {
 		return response, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("missing create user fields"))
 	}
+	if hasPassword && userType == internal.UserServiceAccount {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("service accounts have no password"))
+	}
 	var count int64
-	err = u.db.Model(&User{}).Where("email = ?", request.Email).Count(&count).Error
+	err = u.db.Model(&User{}).Where("email = ? AND organization_id = ?", request.Email, u.organizationID()).Count(&count).Error
 	if err != nil {
 		return response, errors.Wrap(err, "get user with email count failed")
 	}
@@ -49,111 +135,354 @@ func (u *userDataService) CreateUser(request internal.UserRequest) (response int
 		return response, serviceerror.NewServiceError(serviceerror.DuplicateUser, fmt.Errorf("user with email %s is present", request.Email))
 	}
 
-	salt := u.randomString()
+	status := request.Status
+	if status == "" {
+		status = internal.StatusActive
+	}
+	salt := randomString()
+	var password string
+	switch {
+	case request.PasswordHash != "":
+		password, salt = request.PasswordHash, request.Salt
+	case request.Password != "":
+		password = encodePassword(request.Password, salt)
+	}
 	user := User{
-		Name:     request.Name,
-		Email:    request.Email,
-		Password: u.encodePassword(request.Password, salt),
-		Salt:     salt,
+		OrganizationID: u.organizationID(),
+		Type:           userType,
+		Name:           request.Name,
+		Email:          request.Email,
+		Password:       password,
+		Salt:           salt,
+		Status:         status,
+		Attributes:     request.Attributes,
+		Version:        1,
 	}
 	err = u.db.Create(&user).Error
 	if err != nil {
 		return response, errors.Wrap(err, "create user failed")
 	}
-	response = internal.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-	}
-	return response, err
+	return toUserResponse(user), err
 }
 
 func (u *userDataService) UpdateUser(request internal.UpdateUserRequest) (err error) {
-	if request.ID == 0 || (request.Email == "" && request.Name == "") {
+	if request.ID == 0 || (request.Email == "" && request.Name == "" && len(request.Attributes) == 0) {
 		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("missing update user fields"))
 	}
-	user := User{
-		ID: request.ID,
-	}
-	update := make(map[string]interface{})
+	update := map[string]interface{}{"version": gorm.Expr("version + 1")}
 	if request.Email != "" {
 		var count int64
-		err = u.db.Model(&User{}).Where("email = ?", request.Email).Count(&count).Error
+		err = u.db.Model(&User{}).Where("email = ? AND organization_id = (SELECT organization_id FROM users WHERE id = ?)", request.Email, request.ID).
+			Count(&count).Error
 		if err != nil {
 			return errors.Wrap(err, "get user with email count failed")
 		}
 		if count > 0 {
 			return serviceerror.NewServiceError(serviceerror.DuplicateUser, fmt.Errorf("user with email %s is present", request.Email))
 		}
-		update["email"] = request.Email
+		update["pending_email"] = request.Email
 	}
 	if request.Name != "" {
 		update["name"] = request.Name
 	}
-	err = u.db.Model(&user).Updates(update).Error
+	if len(request.Attributes) > 0 {
+		update["attributes"] = mergeAttributes(request.Attributes)
+	}
+	query := u.scoped(u.db.Model(&User{})).Where("id = ?", request.ID)
+	if request.Version != 0 {
+		query = query.Where("version = ?", request.Version)
+	}
+	result := query.Updates(update)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "update user failed")
+	}
+	if result.RowsAffected == 0 {
+		return u.versionMismatch(request.ID, request.Version)
+	}
+	return nil
+}
+
+// versionMismatch tells why a conditional write of user id changed nothing,
+// either the user is gone or it is no longer at the expected version.
+func (u *userDataService) versionMismatch(id uint, version uint) (err error) {
+	var count int64
+	err = u.scoped(u.db.Model(&User{})).Where("id = ?", id).Count(&count).Error
 	if err != nil {
-		return errors.Wrap(err, "update user failed")
+		return errors.Wrap(err, "get user count failed")
 	}
-	return err
+	if count == 0 || version == 0 {
+		return serviceerror.NewServiceError(serviceerror.UserNotFound, fmt.Errorf("user %d not found", id))
+	}
+	return serviceerror.NewServiceError(serviceerror.PreconditionFailed, fmt.Errorf("user %d is no longer at version %d", id, version))
 }
 
 func (u *userDataService) ChangePassword(userID uint, password string) (err error) {
+	return u.changePassword(u.db, userID, password)
+}
+
+func (u *userDataService) changePassword(tx *gorm.DB, userID uint, password string) (err error) {
 	if userID == 0 {
 		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("user_id=0 for change password"))
 	}
 	user := User{
 		ID: userID,
 	}
-	err = u.db.First(&user).Error
+	err = u.scoped(tx).First(&user).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return serviceerror.NewServiceError(serviceerror.UserNotFound, fmt.Errorf("user %d not found", userID))
 	}
 	if err != nil {
 		return errors.Wrap(err, "get user failed")
 	}
-	err = u.db.Model(&user).Updates(User{Password: u.encodePassword(password, user.Salt)}).Error
+	if user.Type == internal.UserServiceAccount {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, fmt.Errorf("service account %d has no password", userID))
+	}
+	err = tx.Model(&user).Updates(User{Password: encodePassword(password, user.Salt)}).Error
 	if err != nil {
 		return errors.Wrap(err, "update user failed")
 	}
 	return err
 }
 
-func (u *userDataService) DeleteUser(id uint) (err error) {
+// DeleteUser soft deletes the user and its memberships with the same deletion
+// time, RestoreUser uses it to tell them from memberships removed before. A
+// non zero version must be the user's current one. The last owner of a group
+// can't be deleted.
+func (u *userDataService) DeleteUser(id uint, version uint) (err error) {
 	if id == 0 {
 		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("user_id is 0 for delete user"))
 	}
-	err = u.db.Where("user_id = ?", id).Delete(&UserGroup{}).Error
+	now := time.Now().Truncate(time.Microsecond)
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		query := u.scoped(tx.Model(&User{})).Where("id = ?", id)
+		if version != 0 {
+			query = query.Where("version = ?", version)
+		}
+		result := query.Updates(map[string]interface{}{"deleted_at": now, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "delete user failed")
+		}
+		if result.RowsAffected == 0 && (version != 0 || u.organization != nil) {
+			return errVersionMismatch
+		}
+		if err := keepOwnerships(tx, id); err != nil {
+			return err
+		}
+		if err := tx.Model(&UserGroup{}).Where("user_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return errors.Wrap(err, "delete user group failed")
+		}
+		return nil
+	})
+	if errors.Is(err, errVersionMismatch) {
+		return u.versionMismatch(id, version)
+	}
+	return err
+}
+
+// RestoreUser undeletes the user together with the memberships deleted with
+// it, as long as their group still exists. It fails when a user with the same
+// email was created in the meantime.
+func (u *userDataService) RestoreUser(id uint) (err error) {
+	if id == 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("user_id is 0 for restore user"))
+	}
+	var user User
+	err = u.scoped(u.db.Unscoped()).Where("id = ? AND deleted_at IS NOT NULL AND erased_at IS NULL", id).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return serviceerror.NewServiceError(serviceerror.UserNotFound, fmt.Errorf("deleted user %d not found", id))
+	}
 	if err != nil {
-		return errors.Wrap(err, "delete user group failed")
+		return errors.Wrap(err, "get deleted user failed")
 	}
-	err = u.db.Delete(&User{}, id).Error
+	var count int64
+	err = u.db.Model(&User{}).Where("email = ? AND organization_id = ?", user.Email, user.OrganizationID).Count(&count).Error
 	if err != nil {
-		return errors.Wrap(err, "delete user failed")
+		return errors.Wrap(err, "get user with email count failed")
 	}
-	return err
+	if count > 0 {
+		return serviceerror.NewServiceError(serviceerror.DuplicateUser, fmt.Errorf("user with email %s is present", user.Email))
+	}
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&User{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return errors.Wrap(err, "restore user failed")
+		}
+		err = tx.Unscoped().Model(&UserGroup{}).
+			Where("user_id = ? AND deleted_at = ? AND group_id IN (SELECT id FROM groups WHERE deleted_at IS NULL)", id, user.DeletedAt).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return errors.Wrap(err, "restore user group failed")
+		}
+		return nil
+	})
+}
+
+// erasedUserRecords hold personal data of a user and are removed when it is
+// erased, memberships are kept as they only link ids. Access review items are
+// kept too, they are the evidence of certifications, only their comments are
+// cleared.
+var erasedUserRecords = []interface{}{
+	&PasswordReset{}, &EmailVerification{}, &UserMFA{}, &RecoveryCode{}, &MFAChallenge{},
+	&WebAuthnCredential{}, &WebAuthnSession{}, &PersonalAccessToken{}, &OAuthAuthorizationCode{},
+	&AccessRequest{},
 }
 
-func (u *userDataService) GetUsers(offset uint, limit uint) (response internal.UsersResponse, err error) {
+// EraseUser irreversibly replaces the personal data of a user, deleted or not,
+// and removes its credentials. The row and its id stay so records referring
+// to it remain valid, the user is left deleted and can't be restored. Its
+// audit events lose the email and IP in the same transaction, their type and
+// time are kept. The last owner of a group can't be erased. The user as it
+// was before is returned.
+func (u *userDataService) EraseUser(id uint) (response internal.UserResponse, err error) {
+	if id == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("user_id is 0 for erase user"))
+	}
+	var user User
+	err = u.scoped(u.db.Unscoped()).Where("id = ? AND erased_at IS NULL", id).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.UserNotFound, fmt.Errorf("user %d not found or already erased", id))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get user failed")
+	}
+	now := time.Now().Truncate(time.Microsecond)
+	deletedAt := now
+	if user.DeletedAt != nil {
+		deletedAt = *user.DeletedAt
+	}
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		if err := keepOwnerships(tx, id); err != nil {
+			return err
+		}
+		for _, model := range erasedUserRecords {
+			if !tx.HasTable(model) {
+				continue
+			}
+			if err := tx.Unscoped().Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return errors.Wrap(err, "erase user records failed")
+			}
+		}
+		if tx.HasTable(&LoginAttempt{}) {
+			err := tx.Where("attempt_key = ?", internal.UserAttemptKey(user.OrganizationID, user.Email)).Delete(&LoginAttempt{}).Error
+			if err != nil {
+				return errors.Wrap(err, "erase login attempts failed")
+			}
+		}
+		if tx.HasTable(&ReviewItem{}) {
+			if err := tx.Model(&ReviewItem{}).Where("user_id = ?", id).Update("comment", "").Error; err != nil {
+				return errors.Wrap(err, "erase review comments failed")
+			}
+		}
+		if tx.HasTable(&AuditEvent{}) {
+			err := tx.Model(&AuditEvent{}).Where("user_id = ? OR (user_id = 0 AND lower(email) = lower(?))", id, user.Email).
+				Updates(map[string]interface{}{"email": "", "ip": ""}).Error
+			if err != nil {
+				return errors.Wrap(err, "anonymize audit events failed")
+			}
+		}
+		if err := tx.Model(&UserGroup{}).Where("user_id = ?", id).Update("deleted_at", deletedAt).Error; err != nil {
+			return errors.Wrap(err, "delete user group failed")
+		}
+		err := tx.Unscoped().Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"name":            "erased user",
+			"email":           fmt.Sprintf("erased-%d@erased.invalid", id),
+			"email_verified":  false,
+			"pending_email":   "",
+			"password":        "",
+			"salt":            "",
+			"status":          internal.StatusDisabled,
+			"status_reason":   "",
+			"suspended_until": nil,
+			"attributes":      nil,
+			"erased_at":       now,
+			"deleted_at":      deletedAt,
+			"version":         gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return errors.Wrap(err, "erase user failed")
+		}
+		return nil
+	})
+	if err != nil {
+		return response, err
+	}
+	return toUserResponse(user), err
+}
+
+// userRecords are the models holding a user_id that go with a purged user,
+// review items included as the membership they certified is gone. Items the
+// user reviewed stay, they only refer to the reviewer by id.
+var userRecords = []interface{}{
+	&UserGroup{}, &PasswordReset{}, &EmailVerification{}, &UserMFA{}, &RecoveryCode{}, &MFAChallenge{},
+	&WebAuthnCredential{}, &WebAuthnSession{}, &PersonalAccessToken{}, &OAuthAuthorizationCode{},
+	&AccessRequest{}, &ReviewItem{},
+}
+
+// PurgeUsers permanently removes users deleted before the given time along
+// with their records. Their audit events are kept for the trail but lose the
+// email and IP, as on erase. Their memberships went with the deletion, which
+// kept the last owners, so no group loses an owner here.
+func (u *userDataService) PurgeUsers(before time.Time) (count int64, err error) {
+	var users []User
+	err = u.scoped(u.db.Unscoped()).Select("id, email").Where("deleted_at < ?", before).Find(&users).Error
+	if err != nil {
+		return count, errors.Wrap(err, "get users to purge failed")
+	}
+	if len(users) == 0 {
+		return count, nil
+	}
+	ids := make([]uint, len(users))
+	emails := make([]string, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+		emails[i] = strings.ToLower(user.Email)
+	}
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range userRecords {
+			if !tx.HasTable(model) {
+				continue
+			}
+			if err := tx.Unscoped().Where("user_id IN (?)", ids).Delete(model).Error; err != nil {
+				return errors.Wrap(err, "purge user records failed")
+			}
+		}
+		if tx.HasTable(&AuditEvent{}) {
+			err := tx.Model(&AuditEvent{}).Where("user_id IN (?) OR (user_id = 0 AND lower(email) IN (?))", ids, emails).
+				Updates(map[string]interface{}{"email": "", "ip": ""}).Error
+			if err != nil {
+				return errors.Wrap(err, "anonymize audit events failed")
+			}
+		}
+		if err := tx.Unscoped().Where("id IN (?)", ids).Delete(&User{}).Error; err != nil {
+			return errors.Wrap(err, "purge users failed")
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return int64(len(ids)), nil
+}
+
+func (u *userDataService) GetUsers(offset uint, limit uint, filter internal.UsersFilter) (response internal.UsersResponse, err error) {
 	if limit == 0 || limit > 1000 {
 		return response, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, fmt.Errorf("limit %d is not valid for get users", limit))
 	}
+	query := u.usersQuery(filter)
 	var users []User
-	err = u.db.Limit(limit).Offset(offset).Find(&users).Error
+	err = query.Limit(limit).Offset(offset).Find(&users).Error
 	if err != nil {
 		return response, errors.Wrap(err, "get users failed")
 	}
 	var count int64
-	err = u.db.Model(&User{}).Count(&count).Error
+	err = query.Count(&count).Error
 	if err != nil {
 		return response, errors.Wrap(err, "get users count failed")
 	}
 	userResponse := make([]internal.UserResponse, len(users))
 	for i, u := range users {
-		userResponse[i] = internal.UserResponse{
-			ID:    u.ID,
-			Name:  u.Name,
-			Email: u.Email,
-		}
+		userResponse[i] = toUserResponse(u)
 	}
 	response = internal.UsersResponse{
 		Total: uint(count),
@@ -162,7 +491,297 @@ func (u *userDataService) GetUsers(offset uint, limit uint) (response internal.U
 	return response, err
 }
 
-func (u *userDataService) randomString() string {
+// ExportUsers passes every user matching filter to export in id order. Users
+// are read one row at a time, so unlike GetUsers there is no limit.
+func (u *userDataService) ExportUsers(filter internal.UsersFilter, export func(user internal.UserResponse) error) (err error) {
+	rows, err := u.usersQuery(filter).Order("id").Rows()
+	if err != nil {
+		return errors.Wrap(err, "export users failed")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var user User
+		if err := u.db.ScanRows(rows, &user); err != nil {
+			return errors.Wrap(err, "scan exported user failed")
+		}
+		if err := export(toUserResponse(user)); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(rows.Err(), "export users failed")
+}
+
+func (u *userDataService) usersQuery(filter internal.UsersFilter) *gorm.DB {
+	query := u.scoped(u.db.Model(&User{}))
+	if filter.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if len(filter.Attributes) > 0 {
+		query = query.Where("attributes @> ?", Attributes(filter.Attributes))
+	}
+	return query
+}
+
+func (u *userDataService) GetUser(id uint) (response internal.UserResponse, err error) {
+	if id == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("user_id is 0 for get user"))
+	}
+	var user User
+	err = u.scoped(u.db).First(&user, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.UserNotFound, fmt.Errorf("user %d not found", id))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get user failed")
+	}
+	return toUserResponse(user), err
+}
+
+func (u *userDataService) GetUserByEmail(email string) (response internal.UserResponse, err error) {
+	if email == "" {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("missing email for get user"))
+	}
+	var user User
+	err = u.inOrganization(u.db).Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.UserNotFound, fmt.Errorf("user with email %s not found", email))
+	}
+	if err != nil {
+		return response, errors.Wrap(err, "get user failed")
+	}
+	return toUserResponse(user), err
+}
+
+func (u *userDataService) CreatePasswordReset(userID uint, tokenHash string, expiresAt time.Time) (err error) {
+	if userID == 0 || tokenHash == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("missing password reset fields"))
+	}
+	reset := PasswordReset{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+	err = u.db.Create(&reset).Error
+	if err != nil {
+		return errors.Wrap(err, "create password reset failed")
+	}
+	return err
+}
+
+// ResetPassword consumes the reset token and changes the password at once, a
+// token is only used up by a reset that succeeds. All other pending tokens of
+// the user are invalidated with it.
+func (u *userDataService) ResetPassword(tokenHash string, password string) (userID uint, err error) {
+	if tokenHash == "" {
+		return userID, serviceerror.NewServiceError(serviceerror.InvalidResetToken, errors.New("missing reset token"))
+	}
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var reset PasswordReset
+		err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).First(&reset).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return serviceerror.NewServiceError(serviceerror.InvalidResetToken, errors.New("reset token is invalid or expired"))
+		}
+		if err != nil {
+			return errors.Wrap(err, "get password reset failed")
+		}
+		result := tx.Model(&PasswordReset{}).Where("id = ? AND used_at IS NULL", reset.ID).Update("used_at", now)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "consume password reset failed")
+		}
+		if result.RowsAffected == 0 {
+			return serviceerror.NewServiceError(serviceerror.InvalidResetToken, errors.New("reset token is already used"))
+		}
+		err = tx.Model(&PasswordReset{}).Where("user_id = ? AND used_at IS NULL", reset.UserID).Update("used_at", now).Error
+		if err != nil {
+			return errors.Wrap(err, "invalidate password resets failed")
+		}
+		userID = reset.UserID
+		return u.changePassword(tx, reset.UserID, password)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (u *userDataService) CreateEmailVerification(userID uint, email string, tokenHash string, expiresAt time.Time) (err error) {
+	if userID == 0 || email == "" || tokenHash == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("missing email verification fields"))
+	}
+	verification := EmailVerification{
+		UserID:    userID,
+		Email:     email,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+	err = u.db.Create(&verification).Error
+	if err != nil {
+		return errors.Wrap(err, "create email verification failed")
+	}
+	return err
+}
+
+// ConfirmEmailVerification consumes the token and either marks the current email
+// as verified or promotes the pending email to be the login email. The email the
+// user had before confirmation is returned so it can be notified of a change.
+func (u *userDataService) ConfirmEmailVerification(tokenHash string) (response internal.UserResponse, previousEmail string, err error) {
+	if tokenHash == "" {
+		return response, previousEmail, serviceerror.NewServiceError(serviceerror.InvalidVerificationToken, errors.New("missing verification token"))
+	}
+	now := time.Now()
+	var verification EmailVerification
+	err = u.db.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).First(&verification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, previousEmail, serviceerror.NewServiceError(serviceerror.InvalidVerificationToken, errors.New("verification token is invalid or expired"))
+	}
+	if err != nil {
+		return response, previousEmail, errors.Wrap(err, "get email verification failed")
+	}
+	var user User
+	err = u.db.First(&user, verification.UserID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, previousEmail, serviceerror.NewServiceError(serviceerror.UserNotFound, fmt.Errorf("user %d not found", verification.UserID))
+	}
+	if err != nil {
+		return response, previousEmail, errors.Wrap(err, "get user failed")
+	}
+	previousEmail = user.Email
+	update := map[string]interface{}{"email_verified": true, "version": gorm.Expr("version + 1")}
+	switch verification.Email {
+	case user.Email:
+	case user.PendingEmail:
+		var count int64
+		err = u.db.Model(&User{}).Where("email = ? AND organization_id = ? AND id <> ?", user.PendingEmail, user.OrganizationID, user.ID).Count(&count).Error
+		if err != nil {
+			return response, previousEmail, errors.Wrap(err, "get user with email count failed")
+		}
+		if count > 0 {
+			return response, previousEmail, serviceerror.NewServiceError(serviceerror.DuplicateUser, fmt.Errorf("user with email %s is present", user.PendingEmail))
+		}
+		update["email"] = user.PendingEmail
+		update["pending_email"] = ""
+	default:
+		return response, previousEmail, serviceerror.NewServiceError(serviceerror.InvalidVerificationToken, errors.New("verification token is for an outdated email"))
+	}
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&EmailVerification{}).Where("id = ? AND used_at IS NULL", verification.ID).Update("used_at", now)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "consume email verification failed")
+		}
+		if result.RowsAffected == 0 {
+			return serviceerror.NewServiceError(serviceerror.InvalidVerificationToken, errors.New("verification token is already used"))
+		}
+		if err := tx.Model(&user).Updates(update).Error; err != nil {
+			return errors.Wrap(err, "update user failed")
+		}
+		return nil
+	})
+	if err != nil {
+		return response, previousEmail, err
+	}
+	user.EmailVerified = true
+	user.Version++
+	if email, ok := update["email"].(string); ok {
+		user.Email = email
+		user.PendingEmail = ""
+	}
+	return toUserResponse(user), previousEmail, err
+}
+
+func (u *userDataService) Authenticate(email string, password string) (response internal.UserResponse, err error) {
+	if email == "" || password == "" {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("missing credentials"))
+	}
+	var user User
+	err = u.inOrganization(u.db).Where("email = ?", email).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, errors.Wrap(err, "get user failed")
+	}
+	// hash even for unknown emails so both cases take the same time
+	encoded := encodePassword(password, user.Salt)
+	if user.ID == 0 || user.Type == internal.UserServiceAccount || subtle.ConstantTimeCompare([]byte(encoded), []byte(user.Password)) != 1 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("email or password is wrong"))
+	}
+	return toUserResponse(user), nil
+}
+
+// UpdateUserStatus changes the status only while the user is still in the
+// expected one, so concurrent changes can't skip a transition check.
+func (u *userDataService) UpdateUserStatus(update internal.UserStatusUpdate) (err error) {
+	if update.ID == 0 || update.From == "" || update.To == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("missing user status fields"))
+	}
+	result := u.scoped(u.db.Model(&User{})).Where("id = ? AND status = ?", update.ID, update.From).Updates(map[string]interface{}{
+		"status":          update.To,
+		"status_reason":   update.Reason,
+		"suspended_until": update.Until,
+		"version":         gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "update user status failed")
+	}
+	if result.RowsAffected == 0 {
+		return serviceerror.NewServiceError(serviceerror.InvalidStatusTransition, fmt.Errorf("user %d is no longer %s", update.ID, update.From))
+	}
+	return err
+}
+
+func (u *userDataService) GetExpiredSuspensions(now time.Time) (response []internal.UserResponse, err error) {
+	var users []User
+	err = u.scoped(u.db).Where("status = ? AND suspended_until <= ?", internal.StatusSuspended, now).Find(&users).Error
+	if err != nil {
+		return response, errors.Wrap(err, "get expired suspensions failed")
+	}
+	response = make([]internal.UserResponse, len(users))
+	for i, user := range users {
+		response[i] = toUserResponse(user)
+	}
+	return response, err
+}
+
+func toUserResponse(user User) internal.UserResponse {
+	return internal.UserResponse{
+		ID:             user.ID,
+		OrganizationID: user.OrganizationID,
+		Type:           user.Type,
+		Name:           user.Name,
+		Email:          user.Email,
+		EmailVerified:  user.EmailVerified,
+		PendingEmail:   user.PendingEmail,
+		Status:         user.Status,
+		StatusReason:   user.StatusReason,
+		SuspendedUntil: user.SuspendedUntil,
+		DeletedAt:      user.DeletedAt,
+		Attributes:     user.Attributes,
+		Version:        user.Version,
+	}
+}
+
+// mergeAttributes sets the non nil attributes on top of the stored ones and
+// removes the nil ones in a single statement.
+func mergeAttributes(attributes map[string]interface{}) interface{} {
+	set := Attributes{}
+	expr := "COALESCE(attributes, '{}'::jsonb) || ?::jsonb"
+	args := []interface{}{set}
+	for name, value := range attributes {
+		if value == nil {
+			expr += " - ?::text"
+			args = append(args, name)
+			continue
+		}
+		set[name] = value
+	}
+	return gorm.Expr(expr, args...)
+}
+
+func randomString() string {
 	letterBytes := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	b := make([]byte, 10)
 	for i := range b {
@@ -171,7 +790,14 @@ func (u *userDataService) randomString() string {
 	return string(b)
 }
 
-func (u *userDataService) encodePassword(password string, salt string) string {
+// HashPassword hashes the password with a new salt the way CreateUser does,
+// for a user created later without keeping the password around.
+func (u *userDataService) HashPassword(password string) (hash string, salt string) {
+	salt = randomString()
+	return encodePassword(password, salt), salt
+}
+
+func encodePassword(password string, salt string) string {
 	newPasswd := pbkdf2.Key([]byte(password), []byte(salt), 10000, 50, sha256.New)
 	return hex.EncodeToString(newPasswd)
 }
@@ -0,0 +1,159 @@
+package httpservice
+
+import (
+	"net/http"
+	"strconv"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type RequestAccess struct {
+	UserID        uint   `json:"userId" validate:"required"`
+	GroupID       uint   `json:"groupId" validate:"required"`
+	Justification string `json:"justification" validate:"max=1000"`
+}
+
+type DecideAccess struct {
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+// RequestAccessHandler asks for a user to join a group. An authenticated
+// caller requests for itself unless it is an admin.
+func RequestAccessHandler(accessService internal.AccessRequestService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request RequestAccess
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if caller, ok := callerOf(c); ok && !caller.Admin {
+			request.UserID = caller.UserID
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		response, err := accessRequestsIn(c, accessService).RequestAccess(internal.AccessRequestRequest{
+			UserID:        request.UserID,
+			GroupID:       request.GroupID,
+			Justification: request.Justification,
+		})
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// GetAccessRequestHandler gets an access request, the caller must be its
+// requester, an owner of its group or an admin.
+func GetAccessRequestHandler(accessService internal.AccessRequestService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		requests := accessRequestsIn(c, accessService)
+		if !authorized(c, func(caller internal.Caller) error {
+			return requests.AuthorizeView(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := requests.GetAccessRequest(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetAccessRequestsHandler lists access requests, of a user, for a group or
+// with a status when the query names one. Callers who aren't admins list
+// their own requests or those for a group they own.
+func GetAccessRequestsHandler(accessService internal.AccessRequestService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		perPage, err := strconv.ParseUint(c.DefaultQuery("perPage", "10"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		userID, err := strconv.ParseUint(c.DefaultQuery("userId", "0"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		groupID, err := strconv.ParseUint(c.DefaultQuery("groupId", "0"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		filter := internal.AccessRequestsFilter{
+			UserID:  uint(userID),
+			GroupID: uint(groupID),
+			Status:  c.Query("status"),
+		}
+		requests := accessRequestsIn(c, accessService)
+		if !authorized(c, func(caller internal.Caller) error {
+			return requests.AuthorizeList(caller, filter)
+		}) {
+			return
+		}
+		response, err := requests.GetAccessRequests(uint(page), uint(perPage), filter)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ApproveAccessHandler adds the user of a pending request to its group, the
+// caller must own the group.
+func ApproveAccessHandler(accessService internal.AccessRequestService) gin.HandlerFunc {
+	return decideAccessHandler(accessService, internal.AccessRequestService.Approve)
+}
+
+// DenyAccessHandler denies a pending request, the caller must own the group.
+func DenyAccessHandler(accessService internal.AccessRequestService) gin.HandlerFunc {
+	return decideAccessHandler(accessService, internal.AccessRequestService.Deny)
+}
+
+func decideAccessHandler(accessService internal.AccessRequestService,
+	decide func(requests internal.AccessRequestService, id uint, approver uint, reason string) (internal.AccessRequest, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request DecideAccess
+		if !bindOptionalJSON(c, &request) {
+			return
+		}
+		requests := accessRequestsIn(c, accessService)
+		if !authorized(c, func(caller internal.Caller) error {
+			return requests.AuthorizeDecision(caller, uint(id))
+		}) {
+			return
+		}
+		caller, _ := callerOf(c)
+		response, err := decide(requests, uint(id), caller.UserID, request.Reason)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,273 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestAccessHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	accessService := mock.NewMockAccessRequestService(mockCtrl)
+	router := gin.Default()
+	router.POST("/accessRequests", httpservice.AuthenticationMiddleware(authService, false), httpservice.RequestAccessHandler(accessService))
+
+	tests := []struct {
+		name          string
+		request       string
+		authorization string
+		status        int
+		setup         func()
+	}{
+		{
+			name:    "request access successfully",
+			request: `{"userId":2,"groupId":1,"justification":"on call"}`,
+			status:  http.StatusCreated,
+			setup: func() {
+				accessService.EXPECT().RequestAccess(internal.AccessRequestRequest{UserID: 2, GroupID: 1, Justification: "on call"}).
+					Return(internal.AccessRequest{ID: 5}, nil).Times(1)
+			},
+		},
+		{
+			name:          "request access for caller",
+			request:       `{"userId":3,"groupId":1}`,
+			authorization: "Bearer token",
+			status:        http.StatusCreated,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 2}, nil).Times(1)
+				accessService.EXPECT().RequestAccess(internal.AccessRequestRequest{UserID: 2, GroupID: 1}).Return(internal.AccessRequest{ID: 5}, nil).Times(1)
+			},
+		},
+		{
+			name:          "request access for user as admin",
+			request:       `{"userId":3,"groupId":1}`,
+			authorization: "Bearer token",
+			status:        http.StatusCreated,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 2, Admin: true}, nil).Times(1)
+				accessService.EXPECT().RequestAccess(internal.AccessRequestRequest{UserID: 3, GroupID: 1}).Return(internal.AccessRequest{ID: 5}, nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on missing user",
+			request: `{"groupId":1}`,
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on dynamic group",
+			request: `{"userId":2,"groupId":1}`,
+			status:  http.StatusConflict,
+			setup: func() {
+				accessService.EXPECT().RequestAccess(internal.AccessRequestRequest{UserID: 2, GroupID: 1}).
+					Return(internal.AccessRequest{}, serviceerror.NewServiceError(serviceerror.DynamicGroupMembership, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/accessRequests", strings.NewReader(test.request))
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestGetAccessRequestsHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	accessService := mock.NewMockAccessRequestService(mockCtrl)
+	router := gin.Default()
+	caller := internal.Caller{UserID: 7}
+	router.GET("/accessRequests", httpservice.CallerMiddleware(caller), httpservice.GetAccessRequestsHandler(accessService))
+	router.GET("/accessRequests/:id", httpservice.CallerMiddleware(caller), httpservice.GetAccessRequestHandler(accessService))
+	router.GET("/anonymous/accessRequests", httpservice.GetAccessRequestsHandler(accessService))
+	router.GET("/anonymous/accessRequests/:id", httpservice.GetAccessRequestHandler(accessService))
+
+	tests := []struct {
+		name   string
+		path   string
+		query  string
+		status int
+		setup  func()
+	}{
+		{
+			name:   "list pending requests of group",
+			query:  "?groupId=1&status=pending&perPage=20",
+			status: http.StatusOK,
+			setup: func() {
+				filter := internal.AccessRequestsFilter{GroupID: 1, Status: internal.AccessPending}
+				accessService.EXPECT().AuthorizeList(caller, filter).Return(nil).Times(1)
+				accessService.EXPECT().GetAccessRequests(uint(1), uint(20), filter).Return(internal.AccessRequestsResponse{}, nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on requests of other users",
+			query:  "?userId=8",
+			status: http.StatusForbidden,
+			setup: func() {
+				accessService.EXPECT().AuthorizeList(caller, internal.AccessRequestsFilter{UserID: 8}).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:   "fail on listing without token",
+			query:  "?userId=7",
+			path:   "/anonymous",
+			status: http.StatusUnauthorized,
+			setup:  func() {},
+		},
+		{
+			name:   "fail on invalid user",
+			query:  "?userId=x",
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+		{
+			name:   "get request",
+			query:  "/5",
+			status: http.StatusOK,
+			setup: func() {
+				accessService.EXPECT().AuthorizeView(caller, uint(5)).Return(nil).Times(1)
+				accessService.EXPECT().GetAccessRequest(uint(5)).Return(internal.AccessRequest{ID: 5}, nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on request of other user",
+			query:  "/5",
+			status: http.StatusForbidden,
+			setup: func() {
+				accessService.EXPECT().AuthorizeView(caller, uint(5)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:   "fail on request without token",
+			query:  "/5",
+			path:   "/anonymous",
+			status: http.StatusUnauthorized,
+			setup:  func() {},
+		},
+		{
+			name:   "fail on unknown request",
+			query:  "/6",
+			status: http.StatusBadRequest,
+			setup: func() {
+				accessService.EXPECT().AuthorizeView(caller, uint(6)).
+					Return(serviceerror.NewServiceError(serviceerror.AccessRequestNotFound, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", test.path+"/accessRequests"+test.query, nil)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestDecideAccessHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	accessService := mock.NewMockAccessRequestService(mockCtrl)
+	router := gin.Default()
+	router.POST("/:id/approve", httpservice.AuthenticationMiddleware(authService, false), httpservice.ApproveAccessHandler(accessService))
+	router.POST("/:id/deny", httpservice.AuthenticationMiddleware(authService, false), httpservice.DenyAccessHandler(accessService))
+	caller := internal.Caller{UserID: 7}
+
+	tests := []struct {
+		name          string
+		path          string
+		request       string
+		authorization string
+		status        int
+		setup         func()
+	}{
+		{
+			name:          "approve without body",
+			path:          "/5/approve",
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				accessService.EXPECT().AuthorizeDecision(caller, uint(5)).Return(nil).Times(1)
+				accessService.EXPECT().Approve(uint(5), uint(7), "").Return(internal.AccessRequest{ID: 5, Status: internal.AccessApproved}, nil).Times(1)
+			},
+		},
+		{
+			name:          "approve as owner",
+			path:          "/5/approve",
+			request:       `{"reason":"ok"}`,
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				accessService.EXPECT().AuthorizeDecision(caller, uint(5)).Return(nil).Times(1)
+				accessService.EXPECT().Approve(uint(5), uint(7), "ok").Return(internal.AccessRequest{ID: 5, Status: internal.AccessApproved}, nil).Times(1)
+			},
+		},
+		{
+			name:          "fail on caller not owning group",
+			path:          "/5/deny",
+			authorization: "Bearer token",
+			status:        http.StatusForbidden,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				accessService.EXPECT().AuthorizeDecision(caller, uint(5)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:   "fail without token",
+			path:   "/5/approve",
+			status: http.StatusUnauthorized,
+			setup:  func() {},
+		},
+		{
+			name:          "fail on decided request",
+			path:          "/5/deny",
+			request:       `{"reason":"not needed"}`,
+			authorization: "Bearer token",
+			status:        http.StatusConflict,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				accessService.EXPECT().AuthorizeDecision(caller, uint(5)).Return(nil).Times(1)
+				accessService.EXPECT().Deny(uint(5), uint(7), "not needed").
+					Return(internal.AccessRequest{}, serviceerror.NewServiceError(serviceerror.AccessRequestDecided, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.path, strings.NewReader(test.request))
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package httpservice
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CreateAccessToken struct {
+	Name      string     `json:"name" validate:"required,max=64"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// CreateAccessTokenHandler issues a personal access token for the user, the
+// caller must be the user or an admin. The token is only
+// returned in this response.
+func CreateAccessTokenHandler(accessTokenService internal.AccessTokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request CreateAccessToken
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return accessTokenService.AuthorizeAccessTokens(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := accessTokenService.CreateAccessToken(uint(id), internal.AccessTokenRequest{
+			Name:      request.Name,
+			Scopes:    request.Scopes,
+			ExpiresAt: request.ExpiresAt,
+		})
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+func GetAccessTokensHandler(accessTokenService internal.AccessTokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return accessTokenService.AuthorizeAccessTokens(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := accessTokenService.GetAccessTokens(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func RevokeAccessTokenHandler(accessTokenService internal.AccessTokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		tokenID, err := strconv.ParseUint(c.Param("tokenid"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return accessTokenService.AuthorizeAccessTokens(caller, uint(id))
+		}) {
+			return
+		}
+		err = accessTokenService.RevokeAccessToken(uint(id), uint(tokenID))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
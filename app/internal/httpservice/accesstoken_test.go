@@ -0,0 +1,156 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAccessTokenHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	accessTokenService := mock.NewMockAccessTokenService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users/:id/tokens", httpservice.AuthenticationMiddleware(authService, false), httpservice.CreateAccessTokenHandler(accessTokenService))
+	createdAt := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		request       string
+		authorization string
+		status        int
+		response      string
+		setup         func()
+	}{
+		{
+			name:          "create token successfully",
+			request:       `{"name":"ci","scopes":["users:read"]}`,
+			authorization: "Bearer token",
+			status:        http.StatusCreated,
+			response:      `{"id":3,"name":"ci","scopes":["users:read"],"createdAt":"2021-09-01T00:00:00Z","token":"pat_token"}`,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1}, nil).Times(1)
+				accessTokenService.EXPECT().AuthorizeAccessTokens(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				accessTokenService.EXPECT().CreateAccessToken(uint(1), internal.AccessTokenRequest{Name: "ci", Scopes: []string{"users:read"}}).
+					Return(internal.CreatedAccessToken{
+						AccessTokenResponse: internal.AccessTokenResponse{ID: 3, Name: "ci", Scopes: []string{"users:read"}, CreatedAt: createdAt},
+						Token:               "pat_token",
+					}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on missing scopes",
+			request:  `{"name":"ci","scopes":[]}`,
+			status:   http.StatusBadRequest,
+			response: `{"message":"Key: 'CreateAccessToken.Scopes' Error:Field validation for 'Scopes' failed on the 'min' tag"}`,
+			setup:    func() {},
+		},
+		{
+			name:          "fail on caller of other user",
+			request:       `{"name":"ci","scopes":["users:read"]}`,
+			authorization: "Bearer token",
+			status:        http.StatusForbidden,
+			response:      `{"message":"Forbidden : test"}`,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 7}, nil).Times(1)
+				accessTokenService.EXPECT().AuthorizeAccessTokens(internal.Caller{UserID: 7}, uint(1)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:     "fail without token",
+			request:  `{"name":"ci","scopes":["users:read"]}`,
+			status:   http.StatusUnauthorized,
+			response: `{"message":"bearer access token is missing"}`,
+			setup:    func() {},
+		},
+		{
+			name:          "fail on invalid scope",
+			request:       `{"name":"ci","scopes":["secrets:read"]}`,
+			authorization: "Bearer token",
+			status:        http.StatusBadRequest,
+			response:      `{"message":"Invalid Access Token : test"}`,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1}, nil).Times(1)
+				accessTokenService.EXPECT().AuthorizeAccessTokens(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				accessTokenService.EXPECT().CreateAccessToken(uint(1), gomock.Any()).
+					Return(internal.CreatedAccessToken{}, serviceerror.NewServiceError(serviceerror.InvalidAccessToken, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/users/1/tokens", strings.NewReader(test.request))
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, recorder.Body.String())
+		})
+	}
+}
+
+func TestRevokeAccessTokenHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	accessTokenService := mock.NewMockAccessTokenService(mockCtrl)
+	router := gin.Default()
+	caller := internal.Caller{UserID: 1}
+	router.DELETE("/users/:id/tokens/:tokenid", httpservice.CallerMiddleware(caller), httpservice.RevokeAccessTokenHandler(accessTokenService))
+	accessTokenService.EXPECT().AuthorizeAccessTokens(caller, uint(1)).Return(nil).AnyTimes()
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		setup  func()
+	}{
+		{
+			name:   "revoke token successfully",
+			path:   "/users/1/tokens/3",
+			status: http.StatusOK,
+			setup: func() {
+				accessTokenService.EXPECT().RevokeAccessToken(uint(1), uint(3)).Return(nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on token of other user",
+			path:   "/users/1/tokens/4",
+			status: http.StatusBadRequest,
+			setup: func() {
+				accessTokenService.EXPECT().RevokeAccessToken(uint(1), uint(4)).
+					Return(serviceerror.NewServiceError(serviceerror.AccessTokenNotFound, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:   "fail on invalid token id",
+			path:   "/users/1/tokens/ci",
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", test.path, strings.NewReader(""))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
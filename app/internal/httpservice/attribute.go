@@ -0,0 +1,71 @@
+package httpservice
+
+import (
+	"net/http"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type SaveAttribute struct {
+	Type     string   `json:"type" validate:"required,oneof=string number boolean"`
+	Required bool     `json:"required"`
+	Unique   bool     `json:"unique"`
+	Pattern  string   `json:"pattern" validate:"max=255"`
+	Enum     []string `json:"enum" validate:"omitempty,dive,required"`
+}
+
+func GetAttributesHandler(attributeService internal.AttributeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response, err := attributeService.GetAttributeDefinitions()
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func SaveAttributeHandler(attributeService internal.AttributeService) gin.HandlerFunc {
+	mapSaveAttributeRequest := func(name string, request SaveAttribute) internal.AttributeDefinition {
+		return internal.AttributeDefinition{
+			Name:     name,
+			Type:     request.Type,
+			Required: request.Required,
+			Unique:   request.Unique,
+			Pattern:  request.Pattern,
+			Enum:     request.Enum,
+		}
+	}
+	return func(c *gin.Context) {
+		var request SaveAttribute
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		err := attributeService.SaveAttributeDefinition(mapSaveAttributeRequest(c.Param("name"), request))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+func DeleteAttributeHandler(attributeService internal.AttributeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := attributeService.DeleteAttributeDefinition(c.Param("name"))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,104 @@
+package httpservice_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttributeHandlers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	attributeService := mock.NewMockAttributeService(mockCtrl)
+	router := gin.Default()
+	router.GET("/attributes", httpservice.GetAttributesHandler(attributeService))
+	router.PUT("/attributes/:name", httpservice.SaveAttributeHandler(attributeService))
+	router.DELETE("/attributes/:name", httpservice.DeleteAttributeHandler(attributeService))
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		request  string
+		status   int
+		response string
+		setup    func()
+	}{
+		{
+			name:     "get attributes",
+			method:   "GET",
+			path:     "/attributes",
+			status:   http.StatusOK,
+			response: `{"attributes":[{"name":"department","type":"string","required":true,"unique":false,"enum":["eng","ops"]}]}`,
+			setup: func() {
+				attributeService.EXPECT().GetAttributeDefinitions().Return(internal.AttributeDefinitionsResponse{
+					Attributes: []internal.AttributeDefinition{{Name: "department", Type: internal.AttributeString, Required: true, Enum: []string{"eng", "ops"}}},
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:    "save attribute",
+			method:  "PUT",
+			path:    "/attributes/employeeId",
+			request: `{"type":"string","required":true,"unique":true,"pattern":"^E[0-9]+$"}`,
+			status:  http.StatusOK,
+			setup: func() {
+				attributeService.EXPECT().SaveAttributeDefinition(internal.AttributeDefinition{
+					Name: "employeeId", Type: internal.AttributeString, Required: true, Unique: true, Pattern: "^E[0-9]+$",
+				}).Return(nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on unknown type",
+			method:   "PUT",
+			path:     "/attributes/birthday",
+			request:  `{"type":"date"}`,
+			status:   http.StatusBadRequest,
+			response: `{"message":"Key: 'SaveAttribute.Type' Error:Field validation for 'Type' failed on the 'oneof' tag"}`,
+			setup:    func() {},
+		},
+		{
+			name:     "fail on empty enum value",
+			method:   "PUT",
+			path:     "/attributes/department",
+			request:  `{"type":"string","enum":["eng",""]}`,
+			status:   http.StatusBadRequest,
+			response: `{"message":"Key: 'SaveAttribute.Enum[1]' Error:Field validation for 'Enum[1]' failed on the 'required' tag"}`,
+			setup:    func() {},
+		},
+		{
+			name:     "fail on deleting unknown attribute",
+			method:   "DELETE",
+			path:     "/attributes/phone",
+			status:   http.StatusBadRequest,
+			response: `{"message":"Attribute Not Found : test"}`,
+			setup: func() {
+				attributeService.EXPECT().DeleteAttributeDefinition("phone").
+					Return(serviceerror.NewServiceError(serviceerror.AttributeNotFound, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(test.method, test.path, strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			response, err := ioutil.ReadAll(recorder.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, string(response))
+		})
+	}
+}
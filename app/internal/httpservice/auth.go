@@ -0,0 +1,138 @@
+package httpservice
+
+import (
+	"net/http"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type ForgotPassword struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPassword struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+type VerifyEmail struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type Login struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginMFA struct {
+	MFAToken string `json:"mfaToken" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+func LoginHandler(authService internal.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request Login
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		response, err := authIn(c, authService).Login(request.Email, request.Password, clientIPOf(c))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func LoginMFAHandler(authService internal.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request LoginMFA
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		response, err := authIn(c, authService).LoginMFA(request.MFAToken, request.Code, clientIPOf(c))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func ForgotPasswordHandler(authService internal.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request ForgotPassword
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		err := authIn(c, authService).ForgotPassword(request.Email)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusAccepted)
+	}
+}
+
+func ResetPasswordHandler(authService internal.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request ResetPassword
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		err := authService.ResetPassword(request.Token, request.Password)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+func VerifyEmailHandler(authService internal.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request VerifyEmail
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		err := authService.VerifyEmail(request.Token)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,296 @@
+package httpservice_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	forgotPasswordObj = `{"email":"%s"}`
+	resetPasswordObj  = `{"token":"%s","password":"%s"}`
+	verifyEmailObj    = `{"token":"%s"}`
+	loginObj          = `{"email":"%s","password":"%s"}`
+	loginMFAObj       = `{"mfaToken":"%s","code":"%s"}`
+)
+
+func TestForgotPasswordHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	router := gin.Default()
+	router.POST("/", httpservice.ForgotPasswordHandler(authService))
+
+	tests := []struct {
+		name    string
+		request string
+		status  int
+		setup   func()
+	}{
+		{
+			name:    "request reset successfully",
+			request: fmt.Sprintf(forgotPasswordObj, "test@gmail.com"),
+			status:  http.StatusAccepted,
+			setup: func() {
+				authService.EXPECT().ForgotPassword("test@gmail.com").Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on wrong email",
+			request: fmt.Sprintf(forgotPasswordObj, "test.com"),
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on unknown error",
+			request: fmt.Sprintf(forgotPasswordObj, "test@gmail.com"),
+			status:  http.StatusInternalServerError,
+			setup: func() {
+				authService.EXPECT().ForgotPassword("test@gmail.com").Return(errors.New("test")).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/", strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestResetPasswordHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	router := gin.Default()
+	router.POST("/", httpservice.ResetPasswordHandler(authService))
+
+	tests := []struct {
+		name    string
+		request string
+		status  int
+		setup   func()
+	}{
+		{
+			name:    "reset password successfully",
+			request: fmt.Sprintf(resetPasswordObj, "token", "12345678"),
+			status:  http.StatusOK,
+			setup: func() {
+				authService.EXPECT().ResetPassword("token", "12345678").Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on missing token",
+			request: fmt.Sprintf(resetPasswordObj, "", "12345678"),
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on small password",
+			request: fmt.Sprintf(resetPasswordObj, "token", "123"),
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on invalid token",
+			request: fmt.Sprintf(resetPasswordObj, "token", "12345678"),
+			status:  http.StatusBadRequest,
+			setup: func() {
+				authService.EXPECT().ResetPassword("token", "12345678").
+					Return(serviceerror.NewServiceError(serviceerror.InvalidResetToken, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/", strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestVerifyEmailHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	router := gin.Default()
+	router.POST("/", httpservice.VerifyEmailHandler(authService))
+
+	tests := []struct {
+		name    string
+		request string
+		status  int
+		setup   func()
+	}{
+		{
+			name:    "verify email successfully",
+			request: fmt.Sprintf(verifyEmailObj, "token"),
+			status:  http.StatusOK,
+			setup: func() {
+				authService.EXPECT().VerifyEmail("token").Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on missing token",
+			request: fmt.Sprintf(verifyEmailObj, ""),
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on invalid token",
+			request: fmt.Sprintf(verifyEmailObj, "token"),
+			status:  http.StatusBadRequest,
+			setup: func() {
+				authService.EXPECT().VerifyEmail("token").
+					Return(serviceerror.NewServiceError(serviceerror.InvalidVerificationToken, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/", strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestLoginHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	router := gin.Default()
+	router.POST("/", httpservice.LoginHandler(authService))
+
+	tests := []struct {
+		name     string
+		request  string
+		status   int
+		response string
+		setup    func()
+	}{
+		{
+			name:     "login successfully",
+			request:  fmt.Sprintf(loginObj, "test@gmail.com", "12345678"),
+			status:   http.StatusOK,
+			response: `{"accessToken":"token","tokenType":"Bearer","expiresIn":3600}`,
+			setup: func() {
+				authService.EXPECT().Login("test@gmail.com", "12345678", gomock.Any()).Return(internal.LoginResponse{
+					AccessToken: "token",
+					TokenType:   "Bearer",
+					ExpiresIn:   3600,
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:     "require mfa",
+			request:  fmt.Sprintf(loginObj, "test@gmail.com", "12345678"),
+			status:   http.StatusOK,
+			response: `{"mfaRequired":true,"mfaToken":"token"}`,
+			setup: func() {
+				authService.EXPECT().Login("test@gmail.com", "12345678", gomock.Any()).Return(internal.LoginResponse{
+					MFARequired: true,
+					MFAToken:    "token",
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on wrong credentials",
+			request:  fmt.Sprintf(loginObj, "test@gmail.com", "12345678"),
+			status:   http.StatusUnauthorized,
+			response: `{"message":"Invalid Credentials : test"}`,
+			setup: func() {
+				authService.EXPECT().Login("test@gmail.com", "12345678", gomock.Any()).Return(internal.LoginResponse{},
+					serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:     "fail when throttled",
+			request:  fmt.Sprintf(loginObj, "test@gmail.com", "12345678"),
+			status:   http.StatusTooManyRequests,
+			response: `{"message":"Too Many Attempts : retry after 4 seconds"}`,
+			setup: func() {
+				authService.EXPECT().Login("test@gmail.com", "12345678", "192.0.2.1").Return(internal.LoginResponse{},
+					serviceerror.NewServiceError(serviceerror.TooManyAttempts, errors.New("retry after 4 seconds"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/", strings.NewReader(test.request))
+			req.RemoteAddr = "192.0.2.1:1234"
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, recorder.Body.String())
+		})
+	}
+}
+
+func TestLoginMFAHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	router := gin.Default()
+	router.POST("/", httpservice.LoginMFAHandler(authService))
+
+	tests := []struct {
+		name    string
+		request string
+		status  int
+		setup   func()
+	}{
+		{
+			name:    "login with code successfully",
+			request: fmt.Sprintf(loginMFAObj, "token", "123456"),
+			status:  http.StatusOK,
+			setup: func() {
+				authService.EXPECT().LoginMFA("token", "123456", gomock.Any()).Return(internal.LoginResponse{AccessToken: "token"}, nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on missing code",
+			request: fmt.Sprintf(loginMFAObj, "token", ""),
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on wrong code",
+			request: fmt.Sprintf(loginMFAObj, "token", "123456"),
+			status:  http.StatusBadRequest,
+			setup: func() {
+				authService.EXPECT().LoginMFA("token", "123456", gomock.Any()).Return(internal.LoginResponse{},
+					serviceerror.NewServiceError(serviceerror.InvalidMFACode, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/", strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
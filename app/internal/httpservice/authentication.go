@@ -0,0 +1,111 @@
+package httpservice
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const callerKey = "caller"
+
+// AuthenticationMiddleware identifies the caller by the Bearer access token.
+// Without requireToken a request without token passes on without caller, to
+// endpoints that don't check the caller; those that do refuse it.
+func AuthenticationMiddleware(authService internal.AuthService, requireToken bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := callerOf(c); ok {
+			c.Next()
+			return
+		}
+		header := c.GetHeader("Authorization")
+		if header == "" && !requireToken {
+			c.Next()
+			return
+		}
+		accessToken := strings.TrimPrefix(header, "Bearer ")
+		if accessToken == header || accessToken == "" {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "bearer access token is missing"})
+			return
+		}
+		caller, err := authService.Authenticate(accessToken)
+		if err != nil {
+			c.Header("WWW-Authenticate", "Bearer")
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Set(callerKey, caller)
+		c.Next()
+	}
+}
+
+// AdminMiddleware stops callers who aren't admins who logged in, for
+// endpoints that have no service to authorize them.
+func AdminMiddleware(authService internal.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authorized(c, authService.AuthorizeAdmin) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// ScopeMiddleware stops callers whose personal access token isn't scoped to
+// the resource. Reading takes the read or write scope, anything else the
+// write scope. Callers who logged in aren't limited.
+func ScopeMiddleware(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller, ok := callerOf(c)
+		if !ok || caller.Scopes == nil {
+			c.Next()
+			return
+		}
+		write := resource + ":write"
+		allowed := []string{write}
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			allowed = append(allowed, resource+":read")
+		}
+		for _, scope := range caller.Scopes {
+			for _, required := range allowed {
+				if scope == required {
+					c.Next()
+					return
+				}
+			}
+		}
+		serviceerror.AbortOnError(c, serviceerror.NewServiceError(serviceerror.Forbidden,
+			fmt.Errorf("access token isn't scoped to %s", allowed[len(allowed)-1])))
+	}
+}
+
+// callerOf returns the authenticated caller of the request, ok is false for
+// a request without token.
+func callerOf(c *gin.Context) (caller internal.Caller, ok bool) {
+	value, ok := c.Get(callerKey)
+	if !ok {
+		return caller, false
+	}
+	caller, ok = value.(internal.Caller)
+	return caller, ok
+}
+
+// authorized runs check for the authenticated caller and aborts the request
+// if it fails. A request without caller is refused, checks never pass for
+// anonymous callers.
+func authorized(c *gin.Context, check func(caller internal.Caller) error) bool {
+	caller, ok := callerOf(c)
+	if !ok {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "bearer access token is missing"})
+		return false
+	}
+	if err := check(caller); err != nil {
+		serviceerror.AbortOnError(c, err)
+		return false
+	}
+	return true
+}
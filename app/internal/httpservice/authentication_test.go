@@ -0,0 +1,190 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticationMiddleware(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	groupService := mock.NewMockGroupService(mockCtrl)
+	router := gin.Default()
+	router.DELETE("/optional/:id/users/:userid", httpservice.AuthenticationMiddleware(authService, false), httpservice.RemoveUserHandler(groupService))
+	router.DELETE("/required/:id/users/:userid", httpservice.AuthenticationMiddleware(authService, true), httpservice.RemoveUserHandler(groupService))
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		status        int
+		setup         func()
+	}{
+		{
+			name:   "fail on authorization without token",
+			path:   "/optional/1/users/2",
+			status: http.StatusUnauthorized,
+			setup:  func() {},
+		},
+		{
+			name:          "serve authorized caller",
+			path:          "/optional/1/users/2",
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 5}, nil).Times(1)
+				groupService.EXPECT().AuthorizeMemberChange(internal.Caller{UserID: 5}, uint(1), uint(2)).Return(nil).Times(1)
+				groupService.EXPECT().RemoveUser(uint(1), uint(2)).Return(nil).Times(1)
+			},
+		},
+		{
+			name:          "fail on forbidden caller",
+			path:          "/optional/1/users/2",
+			authorization: "Bearer token",
+			status:        http.StatusForbidden,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 5}, nil).Times(1)
+				groupService.EXPECT().AuthorizeMemberChange(internal.Caller{UserID: 5}, uint(1), uint(2)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:          "fail on invalid token",
+			path:          "/optional/1/users/2",
+			authorization: "Bearer token",
+			status:        http.StatusUnauthorized,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").
+					Return(internal.Caller{}, serviceerror.NewServiceError(serviceerror.Unauthenticated, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:          "fail on other scheme",
+			path:          "/optional/1/users/2",
+			authorization: "Basic dGVzdA==",
+			status:        http.StatusUnauthorized,
+			setup:         func() {},
+		},
+		{
+			name:   "fail on missing required token",
+			path:   "/required/1/users/2",
+			status: http.StatusUnauthorized,
+			setup:  func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", test.path, strings.NewReader(""))
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			if test.status == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", recorder.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestScopeMiddleware(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	router := gin.Default()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/groups", httpservice.AuthenticationMiddleware(authService, false), httpservice.ScopeMiddleware("groups"), ok)
+	router.POST("/groups", httpservice.AuthenticationMiddleware(authService, false), httpservice.ScopeMiddleware("groups"), ok)
+
+	tests := []struct {
+		name   string
+		method string
+		caller internal.Caller
+		status int
+	}{
+		{"serve logged in caller", "POST", internal.Caller{UserID: 5}, http.StatusOK},
+		{"serve read with read scope", "GET", internal.Caller{UserID: 5, Scopes: []string{"groups:read"}}, http.StatusOK},
+		{"serve read with write scope", "GET", internal.Caller{UserID: 5, Scopes: []string{"users:read", "groups:write"}}, http.StatusOK},
+		{"serve write with write scope", "POST", internal.Caller{UserID: 5, Scopes: []string{"groups:write"}}, http.StatusOK},
+		{"fail on write with read scope", "POST", internal.Caller{UserID: 5, Scopes: []string{"groups:read"}}, http.StatusForbidden},
+		{"fail on scope of other resource", "GET", internal.Caller{UserID: 5, Scopes: []string{"users:write"}}, http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(test.method, "/groups", strings.NewReader(""))
+			req.Header.Set("Authorization", "Bearer token")
+			authService.EXPECT().Authenticate("token").Return(test.caller, nil).Times(1)
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	router := gin.Default()
+	router.GET("/debug/vars", httpservice.AuthenticationMiddleware(authService, true), httpservice.AdminMiddleware(authService), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		setup         func()
+	}{
+		{
+			name:          "serve admin",
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				authService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+			},
+		},
+		{
+			name:          "fail on caller who isn't admin",
+			authorization: "Bearer token",
+			status:        http.StatusForbidden,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 2}, nil).Times(1)
+				authService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 2}).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:   "fail without token",
+			status: http.StatusUnauthorized,
+			setup:  func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/debug/vars", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package httpservice
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const clientIPKey = "clientIP"
+
+// ClientIPMiddleware resolves the IP logins are throttled by. X-Forwarded-For
+// is only believed for requests from trustedProxies, and only from the right
+// up to the first address that isn't a trusted proxy: anything left of it the
+// client wrote itself. Without trusted proxies the remote address is used.
+func ClientIPMiddleware(trustedProxies []*net.IPNet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(clientIPKey, resolveClientIP(c, trustedProxies))
+		c.Next()
+	}
+}
+
+// ParseTrustedProxies parses the CIDRs or IPs of trusted proxies.
+func ParseTrustedProxies(proxies []string) (response []*net.IPNet, err error) {
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: proxy}
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			proxy = proxy + "/" + strconv.Itoa(bits)
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, network)
+	}
+	return response, nil
+}
+
+// clientIPOf returns the IP the client middleware resolved, or the remote
+// address of a request it didn't see.
+func clientIPOf(c *gin.Context) string {
+	if ip, ok := c.Get(clientIPKey); ok {
+		return ip.(string)
+	}
+	return resolveClientIP(c, nil)
+}
+
+func resolveClientIP(c *gin.Context, trustedProxies []*net.IPNet) string {
+	remote, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		remote = strings.TrimSpace(c.Request.RemoteAddr)
+	}
+	if !trusted(net.ParseIP(remote), trustedProxies) {
+		return remote
+	}
+	forwarded := strings.Split(c.GetHeader("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+		if !trusted(ip, trustedProxies) {
+			return ip.String()
+		}
+	}
+	return remote
+}
+
+func trusted(ip net.IP, trustedProxies []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
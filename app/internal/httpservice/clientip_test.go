@@ -0,0 +1,87 @@
+package httpservice_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIPMiddleware(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	proxies, err := httpservice.ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	assert.NoError(t, err)
+	router := gin.Default()
+	router.Use(httpservice.ClientIPMiddleware(proxies))
+	router.POST("/login", httpservice.LoginHandler(authService))
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		ip        string
+	}{
+		{
+			name:   "use remote address without proxy",
+			remote: "203.0.113.7:4000",
+			ip:     "203.0.113.7",
+		},
+		{
+			name:      "ignore forwarded header of untrusted client",
+			remote:    "203.0.113.7:4000",
+			forwarded: "198.51.100.1",
+			ip:        "203.0.113.7",
+		},
+		{
+			name:      "use forwarded address of trusted proxy",
+			remote:    "10.1.2.3:4000",
+			forwarded: "198.51.100.1",
+			ip:        "198.51.100.1",
+		},
+		{
+			name:      "skip trusted proxies but not spoofed addresses",
+			remote:    "192.168.1.1:4000",
+			forwarded: "1.2.3.4, 198.51.100.1, 10.0.0.5",
+			ip:        "198.51.100.1",
+		},
+		{
+			name:      "use remote address on malformed header",
+			remote:    "10.1.2.3:4000",
+			forwarded: "unknown",
+			ip:        "10.1.2.3",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authService.EXPECT().Login("test@gmail.com", "12345678", test.ip).Return(internal.LoginResponse{}, nil).Times(1)
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/login", strings.NewReader(`{"email":"test@gmail.com","password":"12345678"}`))
+			req.RemoteAddr = test.remote
+			if test.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", test.forwarded)
+			}
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := httpservice.ParseTrustedProxies([]string{"10.0.0.1", "fd00::/8"})
+	assert.NoError(t, err)
+	if assert.Len(t, proxies, 2) {
+		assert.Equal(t, "10.0.0.1/32", proxies[0].String())
+		assert.Equal(t, "fd00::/8", proxies[1].String())
+	}
+	_, err = httpservice.ParseTrustedProxies([]string{"proxy"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package httpservice
+
+import (
+	"net/http"
+	"strconv"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// GroupRule is the membership rule of a dynamic group, see package rule.
+type GroupRule struct {
+	Rule string `json:"rule" validate:"required,max=1000"`
+}
+
+// SetGroupRuleHandler changes the rule of a dynamic group and reports the
+// members it added and removed, the caller must own the group.
+func SetGroupRuleHandler(dynamicGroups internal.DynamicGroupService, grpService internal.GroupService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request GroupRule
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		groups := groupsIn(c, grpService)
+		if !authorized(c, func(caller internal.Caller) error {
+			return groups.AuthorizeGroupOwner(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := dynamicGroupsIn(c, dynamicGroups).SetRule(uint(id), request.Rule)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// PreviewRuleHandler lists a page of the users a rule matches, to try a rule
+// before a dynamic group uses it. The caller must be an admin or own a group,
+// only the users of its organization are listed.
+func PreviewRuleHandler(dynamicGroups internal.DynamicGroupService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		perPage, err := strconv.ParseUint(c.DefaultQuery("perPage", "10"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request GroupRule
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		scoped := dynamicGroupsIn(c, dynamicGroups)
+		if !authorized(c, scoped.AuthorizePreview) {
+			return
+		}
+		response, err := scoped.PreviewRule(request.Rule, uint(page), uint(perPage))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,179 @@
+package httpservice_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetGroupRuleHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dynamicGroups := mock.NewMockDynamicGroupService(mockCtrl)
+	grpService := mock.NewMockGroupService(mockCtrl)
+	router := gin.Default()
+	router.PUT("/:id/rule", httpservice.CallerMiddleware(internal.Caller{UserID: 7}), httpservice.SetGroupRuleHandler(dynamicGroups, grpService))
+	router.PUT("/anonymous/:id/rule", httpservice.SetGroupRuleHandler(dynamicGroups, grpService))
+
+	tests := []struct {
+		name     string
+		path     string
+		request  string
+		status   int
+		response string
+		setup    func()
+	}{
+		{
+			name:     "set rule successfully",
+			request:  `{"rule":"level > 2"}`,
+			status:   http.StatusOK,
+			response: `{"added":1,"removed":2,"unchanged":0,"failed":0,"results":null}`,
+			setup: func() {
+				grpService.EXPECT().AuthorizeGroupOwner(internal.Caller{UserID: 7}, uint(1)).Return(nil).Times(1)
+				dynamicGroups.EXPECT().SetRule(uint(1), "level > 2").Return(internal.BatchMembershipResponse{Added: 1, Removed: 2}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on missing rule",
+			request:  `{}`,
+			status:   http.StatusBadRequest,
+			response: `{"message":"Key: 'GroupRule.Rule' Error:Field validation for 'Rule' failed on the 'required' tag"}`,
+			setup:    func() {},
+		},
+		{
+			name:     "fail on static group",
+			request:  `{"rule":"level > 2"}`,
+			status:   http.StatusBadRequest,
+			response: `{"message":"Invalid Group Request : test"}`,
+			setup: func() {
+				grpService.EXPECT().AuthorizeGroupOwner(internal.Caller{UserID: 7}, uint(1)).Return(nil).Times(1)
+				dynamicGroups.EXPECT().SetRule(uint(1), "level > 2").
+					Return(internal.BatchMembershipResponse{}, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:     "fail on caller not owner",
+			request:  `{"rule":"level > 2"}`,
+			status:   http.StatusForbidden,
+			response: `{"message":"Forbidden : test"}`,
+			setup: func() {
+				grpService.EXPECT().AuthorizeGroupOwner(internal.Caller{UserID: 7}, uint(1)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:     "fail without token",
+			path:     "/anonymous/1/rule",
+			request:  `{"rule":"level > 2"}`,
+			status:   http.StatusUnauthorized,
+			response: `{"message":"bearer access token is missing"}`,
+			setup:    func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			path := test.path
+			if path == "" {
+				path = "/1/rule"
+			}
+			req, _ := http.NewRequest("PUT", path, strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			body, _ := ioutil.ReadAll(recorder.Body)
+			assert.Equal(t, test.response, string(body))
+		})
+	}
+}
+
+func TestPreviewRuleHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dynamicGroups := mock.NewMockDynamicGroupService(mockCtrl)
+	router := gin.Default()
+	router.POST("/groups:method", httpservice.CallerMiddleware(internal.Caller{UserID: 7}), httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"previewRule": httpservice.PreviewRuleHandler(dynamicGroups),
+	}))
+	router.POST("/anonymous:method", httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"previewRule": httpservice.PreviewRuleHandler(dynamicGroups),
+	}))
+
+	tests := []struct {
+		name    string
+		path    string
+		query   string
+		request string
+		status  int
+		setup   func()
+	}{
+		{
+			name:    "preview matching users",
+			query:   "?page=2&perPage=5",
+			request: `{"rule":"status = \"active\""}`,
+			status:  http.StatusOK,
+			setup: func() {
+				dynamicGroups.EXPECT().AuthorizePreview(internal.Caller{UserID: 7}).Return(nil).Times(1)
+				dynamicGroups.EXPECT().PreviewRule(`status = "active"`, uint(2), uint(5)).
+					Return(internal.UsersResponse{Users: []internal.UserResponse{{ID: 6}}, Total: 6, Page: 2, PerPage: 5}, nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on invalid page",
+			query:   "?page=x",
+			request: `{"rule":"status = \"active\""}`,
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on invalid rule",
+			request: `{"rule":"status ="}`,
+			status:  http.StatusBadRequest,
+			setup: func() {
+				dynamicGroups.EXPECT().AuthorizePreview(internal.Caller{UserID: 7}).Return(nil).Times(1)
+				dynamicGroups.EXPECT().PreviewRule("status =", uint(1), uint(10)).
+					Return(internal.UsersResponse{}, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:    "fail on caller owning no group",
+			request: `{"rule":"status = \"active\""}`,
+			status:  http.StatusForbidden,
+			setup: func() {
+				dynamicGroups.EXPECT().AuthorizePreview(internal.Caller{UserID: 7}).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:    "fail without token",
+			path:    "/anonymous:previewRule",
+			request: `{"rule":"status = \"active\""}`,
+			status:  http.StatusUnauthorized,
+			setup:   func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			path := test.path
+			if path == "" {
+				path = "/groups:previewRule"
+			}
+			req, _ := http.NewRequest("POST", path+test.query, strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
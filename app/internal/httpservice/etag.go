@@ -0,0 +1,68 @@
+package httpservice
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag is the entity tag of a user or group at a version.
+func etag(version uint) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ifMatch returns the version required by the If-Match header, 0 when there is
+// no header or it is "*". Only a single entity tag is supported, anything else
+// can't match and aborts with 412.
+func ifMatch(c *gin.Context) (version uint, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	if len(header) > 2 && strings.HasPrefix(header, `"`) && strings.HasSuffix(header, `"`) {
+		parsed, err := strconv.ParseUint(header[1:len(header)-1], 10, 64)
+		if err == nil && parsed != 0 {
+			return uint(parsed), true
+		}
+	}
+	c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"message": fmt.Sprintf("If-Match %s matches no version", header)})
+	return 0, false
+}
+
+// notModified sets the entity tag and answers 304 when If-None-Match holds it,
+// entity tags are compared weakly.
+func notModified(c *gin.Context, tag string) bool {
+	c.Header("ETag", tag)
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// jsonWithETag writes a listing with a weak entity tag of its content, as
+// listings have no version of their own.
+func jsonWithETag(c *gin.Context, response interface{}) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	sum := sha256.Sum256(body)
+	if notModified(c, fmt.Sprintf(`W/"%x"`, sum[:16])) {
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
@@ -0,0 +1,150 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserETags(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	userService := mock.NewMockUserService(mockCtrl)
+	router := gin.Default()
+	router.GET("/users", httpservice.GetUsersHandler(userService))
+	router.GET("/users/:id", httpservice.GetUserHandler(userService))
+	router.PUT("/users/:id", httpservice.UpdateUserHandler(userService))
+	router.PATCH("/users/:id", httpservice.PatchUserHandler(userService))
+	router.DELETE("/users/:id", httpservice.DeleteUserHandler(userService))
+	user := internal.UserResponse{ID: 1, Name: "test", Email: "test@gmail.com", Version: 2}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		header  map[string]string
+		request string
+		status  int
+		etag    string
+		setup   func()
+	}{
+		{
+			name:   "get user with etag",
+			method: "GET",
+			path:   "/users/1",
+			status: http.StatusOK,
+			etag:   `"2"`,
+			setup: func() {
+				userService.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+			},
+		},
+		{
+			name:   "not modified on matching If-None-Match",
+			method: "GET",
+			path:   "/users/1",
+			header: map[string]string{"If-None-Match": `"1", W/"2"`},
+			status: http.StatusNotModified,
+			etag:   `"2"`,
+			setup: func() {
+				userService.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+			},
+		},
+		{
+			name:    "update on matching If-Match",
+			method:  "PUT",
+			path:    "/users/1",
+			header:  map[string]string{"If-Match": `"2"`},
+			request: `{"name":"test2"}`,
+			status:  http.StatusOK,
+			etag:    `"3"`,
+			setup: func() {
+				userService.EXPECT().UpdateUser(internal.UpdateUserRequest{ID: 1, Name: "test2", Version: 2}).Return(nil).Times(1)
+				userService.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Version: 3}, nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on stale If-Match",
+			method:  "PUT",
+			path:    "/users/1",
+			header:  map[string]string{"If-Match": `"1"`},
+			request: `{"name":"test2"}`,
+			status:  http.StatusPreconditionFailed,
+			setup: func() {
+				userService.EXPECT().UpdateUser(internal.UpdateUserRequest{ID: 1, Name: "test2", Version: 1}).
+					Return(serviceerror.NewServiceError(serviceerror.PreconditionFailed, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:    "fail on malformed If-Match",
+			method:  "PUT",
+			path:    "/users/1",
+			header:  map[string]string{"If-Match": `W/"2"`},
+			request: `{"name":"test2"}`,
+			status:  http.StatusPreconditionFailed,
+			setup:   func() {},
+		},
+		{
+			name:    "fail patch on stale If-Match",
+			method:  "PATCH",
+			path:    "/users/1",
+			header:  map[string]string{"If-Match": `"1"`, "Content-Type": httpservice.MergePatchContentType},
+			request: `{"name":"test2"}`,
+			status:  http.StatusPreconditionFailed,
+			setup: func() {
+				userService.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+			},
+		},
+		{
+			name:   "delete on If-Match",
+			method: "DELETE",
+			path:   "/users/1",
+			header: map[string]string{"If-Match": `"2"`},
+			status: http.StatusOK,
+			setup: func() {
+				userService.EXPECT().DeleteUser(uint(1), uint(2)).Return(nil).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(test.method, test.path, strings.NewReader(test.request))
+			for key, value := range test.header {
+				req.Header.Set(key, value)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			if test.etag != "" {
+				assert.Equal(t, test.etag, recorder.Header().Get("ETag"))
+			}
+		})
+	}
+
+	t.Run("not modified listing", func(t *testing.T) {
+		users := internal.UsersResponse{Users: []internal.UserResponse{user}, Total: 1}
+		userService.EXPECT().GetUsers(uint(1), uint(10), internal.UsersFilter{}).Return(users, nil).Times(2)
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users", nil)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		tag := recorder.Header().Get("ETag")
+		assert.True(t, strings.HasPrefix(tag, `W/"`))
+
+		recorder = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/users", nil)
+		req.Header.Set("If-None-Match", tag)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusNotModified, recorder.Code)
+	})
+}
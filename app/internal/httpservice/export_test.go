@@ -0,0 +1,16 @@
+package httpservice
+
+import (
+	"usermanagement/app/internal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CallerMiddleware serves every request as caller, for tests of handlers the
+// authentication middleware is in front of.
+func CallerMiddleware(caller internal.Caller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(callerKey, caller)
+		c.Next()
+	}
+}
@@ -1,8 +1,10 @@
 package httpservice
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 	"usermanagement/app/internal"
 	"usermanagement/app/internal/serviceerror"
 
@@ -10,22 +12,58 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+// CreateGroup creates a static group unless Type is dynamic, the members of a
+// dynamic group are the users matching Rule. OwnerID is its first owner, the
+// caller unless an admin names another user.
 type CreateGroup struct {
-	Name string `json:"name" validate:"required"`
+	Name    string `json:"name" validate:"required"`
+	Type    string `json:"type" validate:"omitempty,oneof=static dynamic"`
+	Rule    string `json:"rule" validate:"max=1000"`
+	OwnerID uint   `json:"ownerId"`
 }
 
 type UpdateGroup struct {
 	Name string `json:"name" validate:"required"`
 }
 
+// PatchGroup is the part of a group PATCH applies to.
+type PatchGroup struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// AddUser adds a user to a group, with expiresAt the membership ends on its
+// own and with startsAt it grants access only from then on.
 type AddUser struct {
-	UserID uint `json:"user_id" validate:"required"`
+	UserID    uint       `json:"user_id" validate:"required"`
+	StartsAt  *time.Time `json:"startsAt"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// MemberRole is the role of a group member, see internal.RoleMember.
+type MemberRole struct {
+	Role string `json:"role" validate:"required,oneof=member manager owner"`
 }
 
+// BatchUsers adds or removes many users of a group at once.
+type BatchUsers struct {
+	UserIDs []uint `json:"user_ids" validate:"required,min=1,max=10000"`
+}
+
+// ReplaceUsers are all the members a group should have, an empty list
+// removes every member.
+type ReplaceUsers struct {
+	UserIDs []uint `json:"user_ids" validate:"required,max=10000"`
+}
+
+// CreateGroupHandler creates a group owned by the caller, or by the user an
+// admin names.
 func CreateGroupHandler(grpService internal.GroupService) gin.HandlerFunc {
 	mapCreateGroupRequest := func(request CreateGroup) internal.GroupRequest {
 		return internal.GroupRequest{
-			Name: request.Name,
+			Name:    request.Name,
+			Type:    request.Type,
+			Rule:    request.Rule,
+			OwnerID: request.OwnerID,
 		}
 	}
 	return func(c *gin.Context) {
@@ -39,20 +77,52 @@ func CreateGroupHandler(grpService internal.GroupService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		response, err := grpService.CreateGroup(mapCreateGroupRequest(request))
+		if !authorized(c, func(caller internal.Caller) error {
+			return grpService.AuthorizeCreate(caller, request.OwnerID)
+		}) {
+			return
+		}
+		if request.OwnerID == 0 {
+			caller, _ := callerOf(c)
+			request.OwnerID = caller.UserID
+		}
+		response, err := groupsIn(c, grpService).CreateGroup(mapCreateGroupRequest(request))
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
 		}
+		c.Header("ETag", etag(response.Version))
 		c.JSON(http.StatusCreated, response)
 	}
 }
 
+func GetGroupHandler(grpService internal.GroupService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		response, err := groupsIn(c, grpService).GetGroup(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		if notModified(c, etag(response.Version)) {
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// UpdateGroupHandler renames the group, only while it is at the version given
+// in If-Match if there is one, and returns the new entity tag.
 func UpdateGroupHandler(grpService internal.GroupService) gin.HandlerFunc {
-	mapUpdateGroupRequest := func(id uint, request UpdateGroup) internal.UpdateGroupRequest {
+	mapUpdateGroupRequest := func(id uint, version uint, request UpdateGroup) internal.UpdateGroupRequest {
 		return internal.UpdateGroupRequest{
-			ID:   id,
-			Name: request.Name,
+			ID:      id,
+			Name:    request.Name,
+			Version: version,
 		}
 	}
 	return func(c *gin.Context) {
@@ -61,6 +131,10 @@ func UpdateGroupHandler(grpService internal.GroupService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
+		version, ok := ifMatch(c)
+		if !ok {
+			return
+		}
 		var request UpdateGroup
 		if err := c.ShouldBindJSON(&request); err != nil {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
@@ -71,15 +145,70 @@ func UpdateGroupHandler(grpService internal.GroupService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		err = grpService.UpdateGroup(mapUpdateGroupRequest(uint(id), request))
+		groups := groupsIn(c, grpService)
+		err = groups.UpdateGroup(mapUpdateGroupRequest(uint(id), version, request))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		group, err := groups.GetGroup(uint(id))
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
 		}
+		c.Header("ETag", etag(group.Version))
 		c.Status(http.StatusOK)
 	}
 }
 
+// PatchGroupHandler applies a JSON merge patch or JSON patch to the group and
+// returns the group after the update. The update only applies to the version
+// the patch was applied to, which must match If-Match if given.
+func PatchGroupHandler(grpService internal.GroupService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		version, ok := ifMatch(c)
+		if !ok {
+			return
+		}
+		groups := groupsIn(c, grpService)
+		group, err := groups.GetGroup(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		if version != 0 && version != group.Version {
+			c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"message": fmt.Sprintf("group %d is no longer at version %d", id, version)})
+			return
+		}
+		var patched PatchGroup
+		if !bindPatch(c, PatchGroup{Name: group.Name}, &patched) {
+			return
+		}
+		if patched.Name == group.Name {
+			c.Header("ETag", etag(group.Version))
+			c.JSON(http.StatusOK, group)
+			return
+		}
+		err = groups.UpdateGroup(internal.UpdateGroupRequest{ID: group.ID, Name: patched.Name, Version: group.Version})
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		group, err = groups.GetGroup(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Header("ETag", etag(group.Version))
+		c.JSON(http.StatusOK, group)
+	}
+}
+
 func DeleteGroupHandler(grpService internal.GroupService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -87,7 +216,27 @@ func DeleteGroupHandler(grpService internal.GroupService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		err = grpService.DeleteGroup(uint(id))
+		version, ok := ifMatch(c)
+		if !ok {
+			return
+		}
+		err = groupsIn(c, grpService).DeleteGroup(uint(id), version)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+func RestoreGroupHandler(grpService internal.GroupService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		err = groupsIn(c, grpService).RestoreGroup(uint(id))
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
@@ -113,12 +262,12 @@ func GetGroupUsersHandler(grpService internal.GroupService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		response, err := grpService.GetUsersByGroupID(uint(id), uint(page), uint(perPage))
+		response, err := groupsIn(c, grpService).GetUsersByGroupID(uint(id), uint(page), uint(perPage))
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, response)
+		jsonWithETag(c, response)
 	}
 }
 
@@ -134,15 +283,22 @@ func GetGroupsHandler(grpService internal.GroupService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		response, err := grpService.GetGroups(uint(page), uint(perPage))
+		deleted, err := strconv.ParseBool(c.DefaultQuery("deleted", "false"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		response, err := groupsIn(c, grpService).GetGroups(uint(page), uint(perPage), internal.GroupsFilter{Deleted: deleted, Type: c.Query("type")})
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, response)
+		jsonWithETag(c, response)
 	}
 }
 
+// AddUserHandler adds a user to the group, the caller must own or
+// manage the group.
 func AddUserHandler(grpService internal.GroupService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -160,7 +316,18 @@ func AddUserHandler(grpService internal.GroupService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		err = grpService.AddUser(request.UserID, uint(id))
+		groups := groupsIn(c, grpService)
+		if !authorized(c, func(caller internal.Caller) error {
+			return groups.AuthorizeMemberChange(caller, uint(id), request.UserID)
+		}) {
+			return
+		}
+		err = groups.AddUser(internal.AddUserRequest{
+			UserID:    request.UserID,
+			GroupID:   uint(id),
+			StartsAt:  request.StartsAt,
+			ExpiresAt: request.ExpiresAt,
+		})
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
@@ -169,6 +336,8 @@ func AddUserHandler(grpService internal.GroupService) gin.HandlerFunc {
 	}
 }
 
+// RemoveUserHandler removes a user from the group, the caller
+// must own the group or manage it and the user be a plain member.
 func RemoveUserHandler(grpService internal.GroupService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -181,7 +350,132 @@ func RemoveUserHandler(grpService internal.GroupService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		err = grpService.RemoveUser(uint(id), uint(userid))
+		groups := groupsIn(c, grpService)
+		if !authorized(c, func(caller internal.Caller) error {
+			return groups.AuthorizeMemberChange(caller, uint(id), uint(userid))
+		}) {
+			return
+		}
+		err = groups.RemoveUser(uint(id), uint(userid))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+// BatchAddUsersHandler adds the users to the group and reports the outcome
+// of each, a user unknown or in another group doesn't fail the batch.
+func BatchAddUsersHandler(grpService internal.GroupService) gin.HandlerFunc {
+	return batchUsersHandler(grpService, internal.GroupService.AddUsers)
+}
+
+// BatchRemoveUsersHandler removes the users from the group and reports the
+// outcome of each.
+func BatchRemoveUsersHandler(grpService internal.GroupService) gin.HandlerFunc {
+	return batchUsersHandler(grpService, internal.GroupService.RemoveUsers)
+}
+
+// batchUsersHandler serves a batch change, which only owners of the group may
+// make.
+func batchUsersHandler(grpService internal.GroupService, change func(grpService internal.GroupService, groupID uint, userIDs []uint) (internal.BatchMembershipResponse, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request BatchUsers
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		groups := groupsIn(c, grpService)
+		if !authorized(c, func(caller internal.Caller) error {
+			return groups.AuthorizeGroupOwner(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := change(groups, uint(id), request.UserIDs)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ReplaceUsersHandler sets the full membership of the group, members not in
+// the list are removed. Only owners of the group may replace its members.
+func ReplaceUsersHandler(grpService internal.GroupService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request ReplaceUsers
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		groups := groupsIn(c, grpService)
+		if !authorized(c, func(caller internal.Caller) error {
+			return groups.AuthorizeGroupOwner(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := groups.ReplaceUsers(uint(id), request.UserIDs)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// SetMemberRoleHandler promotes or demotes a member, only owners of the group
+// may do so and the last owner can't step down.
+func SetMemberRoleHandler(grpService internal.GroupService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		userid, err := strconv.ParseUint(c.Param("userid"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request MemberRole
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		groups := groupsIn(c, grpService)
+		if !authorized(c, func(caller internal.Caller) error {
+			return groups.AuthorizeGroupOwner(caller, uint(id))
+		}) {
+			return
+		}
+		err = groups.SetMemberRole(uint(id), uint(userid), request.Role)
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
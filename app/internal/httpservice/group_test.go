@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 	"usermanagement/app/internal"
 	"usermanagement/app/internal/httpservice"
 	"usermanagement/app/internal/mock"
@@ -31,10 +32,14 @@ func TestCreateGroupHandler(t *testing.T) {
 	defer mockCtrl.Finish()
 	groupService := mock.NewMockGroupService(mockCtrl)
 	router := gin.Default()
-	router.POST("/", httpservice.CreateGroupHandler(groupService))
+	caller := internal.Caller{UserID: 5}
+	router.POST("/", httpservice.CallerMiddleware(caller), httpservice.CreateGroupHandler(groupService))
+	router.POST("/anonymous", httpservice.CreateGroupHandler(groupService))
+	groupService.EXPECT().AuthorizeCreate(caller, uint(0)).Return(nil).AnyTimes()
 
 	tests := []struct {
 		name     string
+		path     string
 		request  string
 		status   int
 		response string
@@ -47,7 +52,8 @@ func TestCreateGroupHandler(t *testing.T) {
 			response: fmt.Sprintf(responseGroupObj, 1, "test"),
 			setup: func() {
 				request := internal.GroupRequest{
-					Name: "test",
+					Name:    "test",
+					OwnerID: 5,
 				}
 				groupService.EXPECT().CreateGroup(request).Return(internal.GroupResponse{
 					ID:   1,
@@ -55,6 +61,56 @@ func TestCreateGroupHandler(t *testing.T) {
 				}, nil).Times(1)
 			},
 		},
+		{
+			name:     "create dynamic group",
+			request:  `{"name":"sales","type":"dynamic","rule":"department = \"Sales\""}`,
+			status:   http.StatusCreated,
+			response: `{"id":1,"name":"sales","type":"dynamic","rule":"department = \"Sales\""}`,
+			setup: func() {
+				request := internal.GroupRequest{Name: "sales", Type: internal.GroupDynamic, Rule: `department = "Sales"`, OwnerID: 5}
+				groupService.EXPECT().CreateGroup(request).Return(internal.GroupResponse{
+					ID: 1, Name: "sales", Type: internal.GroupDynamic, Rule: `department = "Sales"`,
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:     "create group owned by other user",
+			request:  `{"name":"test","ownerId":7}`,
+			status:   http.StatusCreated,
+			response: fmt.Sprintf(responseGroupObj, 1, "test"),
+			setup: func() {
+				groupService.EXPECT().AuthorizeCreate(caller, uint(7)).Return(nil).Times(1)
+				groupService.EXPECT().CreateGroup(internal.GroupRequest{Name: "test", OwnerID: 7}).Return(internal.GroupResponse{
+					ID:   1,
+					Name: "test",
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on other owner for non admin",
+			request:  `{"name":"test","ownerId":7}`,
+			status:   http.StatusForbidden,
+			response: `{"message":"Forbidden : test"}`,
+			setup: func() {
+				groupService.EXPECT().AuthorizeCreate(caller, uint(7)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:     "fail without caller",
+			path:     "/anonymous",
+			request:  fmt.Sprintf(createGroupObj, "test"),
+			status:   http.StatusUnauthorized,
+			response: `{"message":"bearer access token is missing"}`,
+			setup:    func() {},
+		},
+		{
+			name:     "fail on unknown type",
+			request:  `{"name":"test","type":"smart"}`,
+			status:   http.StatusBadRequest,
+			response: `{"message":"Key: 'CreateGroup.Type' Error:Field validation for 'Type' failed on the 'oneof' tag"}`,
+			setup:    func() {},
+		},
 		{
 			name:     "fail on body unmarshal",
 			request:  `{"name":"test",`,
@@ -76,7 +132,8 @@ func TestCreateGroupHandler(t *testing.T) {
 			response: `{"message":"Invalid Group Request : test"}`,
 			setup: func() {
 				request := internal.GroupRequest{
-					Name: "test",
+					Name:    "test",
+					OwnerID: 5,
 				}
 				groupService.EXPECT().CreateGroup(request).Return(internal.GroupResponse{},
 					serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("test"))).Times(1)
@@ -89,7 +146,8 @@ func TestCreateGroupHandler(t *testing.T) {
 			response: `{"message":"test"}`,
 			setup: func() {
 				request := internal.GroupRequest{
-					Name: "test",
+					Name:    "test",
+					OwnerID: 5,
 				}
 				groupService.EXPECT().CreateGroup(request).Return(internal.GroupResponse{}, errors.New("test")).Times(1)
 			},
@@ -98,7 +156,11 @@ func TestCreateGroupHandler(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			recorder := httptest.NewRecorder()
-			req, _ := http.NewRequest("POST", "/", strings.NewReader(test.request))
+			path := test.path
+			if path == "" {
+				path = "/"
+			}
+			req, _ := http.NewRequest("POST", path, strings.NewReader(test.request))
 			test.setup()
 			router.ServeHTTP(recorder, req)
 			response, err := ioutil.ReadAll(recorder.Body)
@@ -132,6 +194,7 @@ func TestUpdateGroupHandler(t *testing.T) {
 					Name: "test",
 				}
 				groupService.EXPECT().UpdateGroup(request).Return(nil).Times(1)
+				groupService.EXPECT().GetGroup(uint(1)).Return(internal.GroupResponse{ID: 1, Name: "test", Version: 2}, nil).Times(1)
 			},
 		},
 		{
@@ -185,6 +248,94 @@ func TestUpdateGroupHandler(t *testing.T) {
 	}
 }
 
+func TestPatchGroupHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	grpService := mock.NewMockGroupService(mockCtrl)
+	router := gin.Default()
+	router.PATCH("/:id", httpservice.PatchGroupHandler(grpService))
+	group := internal.GroupResponse{ID: 1, Name: "test"}
+
+	tests := []struct {
+		name        string
+		contentType string
+		request     string
+		status      int
+		response    string
+		setup       func()
+	}{
+		{
+			name:        "merge patch name",
+			contentType: httpservice.MergePatchContentType,
+			request:     `{"name":"new"}`,
+			status:      http.StatusOK,
+			response:    fmt.Sprintf(responseGroupObj, 1, "new"),
+			setup: func() {
+				grpService.EXPECT().GetGroup(uint(1)).Return(group, nil).Times(1)
+				grpService.EXPECT().UpdateGroup(internal.UpdateGroupRequest{ID: 1, Name: "new"}).Return(nil).Times(1)
+				grpService.EXPECT().GetGroup(uint(1)).Return(internal.GroupResponse{ID: 1, Name: "new"}, nil).Times(1)
+			},
+		},
+		{
+			name:        "json patch name",
+			contentType: httpservice.JSONPatchContentType,
+			request:     `[{"op":"replace","path":"/name","value":"new"}]`,
+			status:      http.StatusOK,
+			response:    fmt.Sprintf(responseGroupObj, 1, "new"),
+			setup: func() {
+				grpService.EXPECT().GetGroup(uint(1)).Return(group, nil).Times(1)
+				grpService.EXPECT().UpdateGroup(internal.UpdateGroupRequest{ID: 1, Name: "new"}).Return(nil).Times(1)
+				grpService.EXPECT().GetGroup(uint(1)).Return(internal.GroupResponse{ID: 1, Name: "new"}, nil).Times(1)
+			},
+		},
+		{
+			name:        "fail on removing name",
+			contentType: httpservice.JSONPatchContentType,
+			request:     `[{"op":"remove","path":"/name"}]`,
+			status:      http.StatusBadRequest,
+			response:    `{"message":"Key: 'PatchGroup.Name' Error:Field validation for 'Name' failed on the 'required' tag"}`,
+			setup: func() {
+				grpService.EXPECT().GetGroup(uint(1)).Return(group, nil).Times(1)
+			},
+		},
+		{
+			name:        "fail on invalid patch",
+			contentType: httpservice.JSONPatchContentType,
+			request:     `{"name":"new"}`,
+			status:      http.StatusBadRequest,
+			response:    `{"message":"json: cannot unmarshal object into Go value of type jsonpatch.Patch"}`,
+			setup: func() {
+				grpService.EXPECT().GetGroup(uint(1)).Return(group, nil).Times(1)
+			},
+		},
+		{
+			name:        "fail on duplicate name",
+			contentType: httpservice.MergePatchContentType,
+			request:     `{"name":"new"}`,
+			status:      http.StatusBadRequest,
+			response:    `{"message":"Duplicate Group : test"}`,
+			setup: func() {
+				grpService.EXPECT().GetGroup(uint(1)).Return(group, nil).Times(1)
+				grpService.EXPECT().UpdateGroup(internal.UpdateGroupRequest{ID: 1, Name: "new"}).
+					Return(serviceerror.NewServiceError(serviceerror.DuplicateGroup, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", "/1", strings.NewReader(test.request))
+			req.Header.Set("Content-Type", test.contentType)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			response, err := ioutil.ReadAll(recorder.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, string(response))
+		})
+	}
+}
+
 func TestDeleteGroupHandler(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -201,21 +352,21 @@ func TestDeleteGroupHandler(t *testing.T) {
 			name:   "Delete group successfully",
 			status: http.StatusOK,
 			setup: func() {
-				groupService.EXPECT().DeleteGroup(uint(1)).Return(nil).Times(1)
+				groupService.EXPECT().DeleteGroup(uint(1), uint(0)).Return(nil).Times(1)
 			},
 		},
 		{
 			name:   "fail on service error",
 			status: http.StatusBadRequest,
 			setup: func() {
-				groupService.EXPECT().DeleteGroup(uint(1)).Return(serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("test"))).Times(1)
+				groupService.EXPECT().DeleteGroup(uint(1), uint(0)).Return(serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("test"))).Times(1)
 			},
 		},
 		{
 			name:   "fail on unknown error",
 			status: http.StatusInternalServerError,
 			setup: func() {
-				groupService.EXPECT().DeleteGroup(uint(1)).Return(errors.New("test")).Times(1)
+				groupService.EXPECT().DeleteGroup(uint(1), uint(0)).Return(errors.New("test")).Times(1)
 			},
 		},
 	}
@@ -250,7 +401,7 @@ func TestGetGroupsHandler(t *testing.T) {
 			query:    "/?page=1&perPage=100",
 			response: fmt.Sprintf(responseGroupsObj, 1, "test", 1, 1, 100),
 			setup: func() {
-				groupService.EXPECT().GetGroups(uint(1), uint(100)).Return(internal.GroupsResponse{
+				groupService.EXPECT().GetGroups(uint(1), uint(100), internal.GroupsFilter{}).Return(internal.GroupsResponse{
 					Groups: []internal.GroupResponse{{
 						ID:   1,
 						Name: "test",
@@ -261,6 +412,19 @@ func TestGetGroupsHandler(t *testing.T) {
 				}, nil).Times(1)
 			},
 		},
+		{
+			name:     "Get dynamic groups",
+			status:   http.StatusOK,
+			query:    "/?page=1&perPage=100&type=dynamic",
+			response: `{"groups":[],"total":0,"page":1,"perPage":100}`,
+			setup: func() {
+				groupService.EXPECT().GetGroups(uint(1), uint(100), internal.GroupsFilter{Type: internal.GroupDynamic}).Return(internal.GroupsResponse{
+					Groups:  []internal.GroupResponse{},
+					Page:    1,
+					PerPage: 100,
+				}, nil).Times(1)
+			},
+		},
 		{
 			name:     "missing page",
 			status:   http.StatusBadRequest,
@@ -281,7 +445,7 @@ func TestGetGroupsHandler(t *testing.T) {
 			query:    "/",
 			response: fmt.Sprintf(responseGroupsObj, 1, "test", 1, 1, 10),
 			setup: func() {
-				groupService.EXPECT().GetGroups(uint(1), uint(10)).Return(internal.GroupsResponse{
+				groupService.EXPECT().GetGroups(uint(1), uint(10), internal.GroupsFilter{}).Return(internal.GroupsResponse{
 					Groups: []internal.GroupResponse{{
 						ID:   1,
 						Name: "test",
@@ -292,13 +456,35 @@ func TestGetGroupsHandler(t *testing.T) {
 				}, nil).Times(1)
 			},
 		},
+		{
+			name:     "list deleted groups",
+			status:   http.StatusOK,
+			query:    "/?page=1&perPage=100&deleted=true",
+			response: `{"groups":[{"id":1,"name":"test","deletedAt":"2021-09-01T00:00:00Z"}],"total":1,"page":1,"perPage":100}`,
+			setup: func() {
+				deletedAt := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+				groupService.EXPECT().GetGroups(uint(1), uint(100), internal.GroupsFilter{Deleted: true}).Return(internal.GroupsResponse{
+					Groups:  []internal.GroupResponse{{ID: 1, Name: "test", DeletedAt: &deletedAt}},
+					Total:   1,
+					Page:    1,
+					PerPage: 100,
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on invalid deleted flag",
+			status:   http.StatusBadRequest,
+			query:    "/?deleted=maybe",
+			response: `{"message":"strconv.ParseBool: parsing \"maybe\": invalid syntax"}`,
+			setup:    func() {},
+		},
 		{
 			name:     "fail on service error",
 			status:   http.StatusBadRequest,
 			query:    "/?page=1&perPage=100",
 			response: `{"message":"Invalid Group Request : test"}`,
 			setup: func() {
-				groupService.EXPECT().GetGroups(uint(1), uint(100)).Return(internal.GroupsResponse{},
+				groupService.EXPECT().GetGroups(uint(1), uint(100), internal.GroupsFilter{}).Return(internal.GroupsResponse{},
 					serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("test"))).Times(1)
 			},
 		},
@@ -308,7 +494,7 @@ func TestGetGroupsHandler(t *testing.T) {
 			query:    "/?page=1&perPage=100",
 			response: `{"message":"test"}`,
 			setup: func() {
-				groupService.EXPECT().GetGroups(uint(1), uint(100)).Return(internal.GroupsResponse{}, errors.New("test")).Times(1)
+				groupService.EXPECT().GetGroups(uint(1), uint(100), internal.GroupsFilter{}).Return(internal.GroupsResponse{}, errors.New("test")).Times(1)
 			},
 		},
 	}
@@ -326,6 +512,54 @@ func TestGetGroupsHandler(t *testing.T) {
 	}
 }
 
+func TestRestoreGroupHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	groupService := mock.NewMockGroupService(mockCtrl)
+	router := gin.Default()
+	router.POST("/groups/:id/restore", httpservice.RestoreGroupHandler(groupService))
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		setup  func()
+	}{
+		{
+			name:   "restore successfully",
+			path:   "/groups/1/restore",
+			status: http.StatusOK,
+			setup: func() {
+				groupService.EXPECT().RestoreGroup(uint(1)).Return(nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on invalid id",
+			path:   "/groups/abc/restore",
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+		{
+			name:   "fail on name taken",
+			path:   "/groups/1/restore",
+			status: http.StatusBadRequest,
+			setup: func() {
+				groupService.EXPECT().RestoreGroup(uint(1)).
+					Return(serviceerror.NewServiceError(serviceerror.DuplicateGroup, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.path, nil)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
 func TestGetGroupUsersHandler(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -348,9 +582,10 @@ func TestGetGroupUsersHandler(t *testing.T) {
 			setup: func() {
 				groupService.EXPECT().GetUsersByGroupID(uint(1), uint(1), uint(100)).Return(internal.UsersResponse{
 					Users: []internal.UserResponse{{
-						ID:    1,
-						Name:  "test",
-						Email: "test@gmail.com",
+						ID:     1,
+						Name:   "test",
+						Email:  "test@gmail.com",
+						Status: internal.StatusActive,
 					}},
 					Total:   1,
 					Page:    1,
@@ -380,9 +615,10 @@ func TestGetGroupUsersHandler(t *testing.T) {
 			setup: func() {
 				groupService.EXPECT().GetUsersByGroupID(uint(1), uint(1), uint(10)).Return(internal.UsersResponse{
 					Users: []internal.UserResponse{{
-						ID:    1,
-						Name:  "test",
-						Email: "test@gmail.com",
+						ID:     1,
+						Name:   "test",
+						Email:  "test@gmail.com",
+						Status: internal.StatusActive,
 					}},
 					Total:   1,
 					Page:    1,
@@ -430,7 +666,9 @@ func TestAddUserHandler(t *testing.T) {
 	defer mockCtrl.Finish()
 	groupService := mock.NewMockGroupService(mockCtrl)
 	router := gin.Default()
-	router.POST("/:id/users", httpservice.AddUserHandler(groupService))
+	caller := internal.Caller{UserID: 5}
+	router.POST("/:id/users", httpservice.CallerMiddleware(caller), httpservice.AddUserHandler(groupService))
+	groupService.EXPECT().AuthorizeMemberChange(caller, uint(1), uint(3)).Return(nil).AnyTimes()
 
 	tests := []struct {
 		name    string
@@ -443,7 +681,17 @@ func TestAddUserHandler(t *testing.T) {
 			request: fmt.Sprintf(addUserObj, 3),
 			status:  http.StatusOK,
 			setup: func() {
-				groupService.EXPECT().AddUser(uint(3), uint(1)).Return(nil).Times(1)
+				groupService.EXPECT().AddUser(internal.AddUserRequest{UserID: 3, GroupID: 1}).Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "add user until expiry",
+			request: `{"user_id":3,"startsAt":"2030-01-01T00:00:00Z","expiresAt":"2030-02-01T00:00:00Z"}`,
+			status:  http.StatusOK,
+			setup: func() {
+				startsAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+				expiresAt := time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)
+				groupService.EXPECT().AddUser(internal.AddUserRequest{UserID: 3, GroupID: 1, StartsAt: &startsAt, ExpiresAt: &expiresAt}).Return(nil).Times(1)
 			},
 		},
 		{
@@ -463,7 +711,7 @@ func TestAddUserHandler(t *testing.T) {
 			request: fmt.Sprintf(addUserObj, 3),
 			status:  http.StatusBadRequest,
 			setup: func() {
-				groupService.EXPECT().AddUser(uint(3), uint(1)).
+				groupService.EXPECT().AddUser(internal.AddUserRequest{UserID: 3, GroupID: 1}).
 					Return(serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("test"))).Times(1)
 			},
 		},
@@ -472,7 +720,7 @@ func TestAddUserHandler(t *testing.T) {
 			request: fmt.Sprintf(addUserObj, 3),
 			status:  http.StatusInternalServerError,
 			setup: func() {
-				groupService.EXPECT().AddUser(uint(3), uint(1)).
+				groupService.EXPECT().AddUser(internal.AddUserRequest{UserID: 3, GroupID: 1}).
 					Return(errors.New("test")).Times(1)
 			},
 		},
@@ -493,7 +741,9 @@ func TestRemoveUserHandler(t *testing.T) {
 	defer mockCtrl.Finish()
 	groupService := mock.NewMockGroupService(mockCtrl)
 	router := gin.Default()
-	router.DELETE("/groups/:id/users/:userid", httpservice.RemoveUserHandler(groupService))
+	caller := internal.Caller{UserID: 5}
+	router.DELETE("/groups/:id/users/:userid", httpservice.CallerMiddleware(caller), httpservice.RemoveUserHandler(groupService))
+	groupService.EXPECT().AuthorizeMemberChange(caller, uint(1), uint(2)).Return(nil).AnyTimes()
 
 	tests := []struct {
 		name   string
@@ -532,3 +782,218 @@ func TestRemoveUserHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestBatchUsersHandlers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	groupService := mock.NewMockGroupService(mockCtrl)
+	router := gin.Default()
+	caller := internal.Caller{UserID: 5}
+	router.Use(httpservice.CallerMiddleware(caller))
+	router.POST("/:id/users", httpservice.AddUserHandler(groupService))
+	router.PUT("/:id/users", httpservice.ReplaceUsersHandler(groupService))
+	router.POST("/:id/users:method", httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"batchAdd":    httpservice.BatchAddUsersHandler(groupService),
+		"batchRemove": httpservice.BatchRemoveUsersHandler(groupService),
+	}))
+	router.DELETE("/:id/users/:userid", httpservice.RemoveUserHandler(groupService))
+	groupService.EXPECT().AuthorizeGroupOwner(caller, uint(1)).Return(nil).AnyTimes()
+	response := internal.BatchMembershipResponse{Added: 1, Failed: 1, Results: []internal.MembershipResult{
+		{UserID: 2, Outcome: internal.MembershipAdded},
+		{UserID: 3, Outcome: internal.MembershipUnknownUser},
+	}}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		request string
+		status  int
+		body    string
+		setup   func()
+	}{
+		{
+			name:    "batch add users",
+			method:  "POST",
+			path:    "/1/users:batchAdd",
+			request: `{"user_ids":[2,3]}`,
+			status:  http.StatusOK,
+			body:    `{"added":1,"removed":0,"unchanged":0,"failed":1,"results":[{"userId":2,"outcome":"added"},{"userId":3,"outcome":"unknownUser"}]}`,
+			setup: func() {
+				groupService.EXPECT().AddUsers(uint(1), []uint{2, 3}).Return(response, nil).Times(1)
+			},
+		},
+		{
+			name:    "batch remove users",
+			method:  "POST",
+			path:    "/1/users:batchRemove",
+			request: `{"user_ids":[2]}`,
+			status:  http.StatusOK,
+			setup: func() {
+				groupService.EXPECT().RemoveUsers(uint(1), []uint{2}).Return(internal.BatchMembershipResponse{Removed: 1}, nil).Times(1)
+			},
+		},
+		{
+			name:    "replace users",
+			method:  "PUT",
+			path:    "/1/users",
+			request: `{"user_ids":[2,3]}`,
+			status:  http.StatusOK,
+			setup: func() {
+				groupService.EXPECT().ReplaceUsers(uint(1), []uint{2, 3}).Return(response, nil).Times(1)
+			},
+		},
+		{
+			name:    "replace with no users",
+			method:  "PUT",
+			path:    "/1/users",
+			request: `{"user_ids":[]}`,
+			status:  http.StatusOK,
+			setup: func() {
+				groupService.EXPECT().ReplaceUsers(uint(1), []uint{}).Return(internal.BatchMembershipResponse{}, nil).Times(1)
+			},
+		},
+		{
+			name:    "fail replace on missing user_ids",
+			method:  "PUT",
+			path:    "/1/users",
+			request: `{}`,
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail batch add on empty user_ids",
+			method:  "POST",
+			path:    "/1/users:batchAdd",
+			request: `{"user_ids":[]}`,
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on invalid group id",
+			method:  "POST",
+			path:    "/test/users:batchRemove",
+			request: `{"user_ids":[2]}`,
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on unknown method",
+			method:  "POST",
+			path:    "/1/users:batchMove",
+			request: `{"user_ids":[2]}`,
+			status:  http.StatusNotFound,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on unknown error",
+			method:  "POST",
+			path:    "/1/users:batchAdd",
+			request: `{"user_ids":[2]}`,
+			status:  http.StatusInternalServerError,
+			setup: func() {
+				groupService.EXPECT().AddUsers(uint(1), []uint{2}).Return(internal.BatchMembershipResponse{}, errors.New("test")).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(test.method, test.path, strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			if test.body != "" {
+				assert.JSONEq(t, test.body, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestSetMemberRoleHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	groupService := mock.NewMockGroupService(mockCtrl)
+	router := gin.Default()
+	router.PUT("/:id/users/:userid/role", httpservice.AuthenticationMiddleware(authService, false), httpservice.SetMemberRoleHandler(groupService))
+	caller := internal.Caller{UserID: 5}
+
+	tests := []struct {
+		name          string
+		request       string
+		authorization string
+		status        int
+		setup         func()
+	}{
+		{
+			name:          "set role successfully",
+			request:       `{"role":"manager"}`,
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				groupService.EXPECT().AuthorizeGroupOwner(caller, uint(1)).Return(nil).Times(1)
+				groupService.EXPECT().SetMemberRole(uint(1), uint(2), internal.RoleManager).Return(nil).Times(1)
+			},
+		},
+		{
+			name:          "set role as owner",
+			request:       `{"role":"owner"}`,
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				groupService.EXPECT().AuthorizeGroupOwner(caller, uint(1)).Return(nil).Times(1)
+				groupService.EXPECT().SetMemberRole(uint(1), uint(2), internal.RoleOwner).Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on unknown role",
+			request: `{"role":"admin"}`,
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail without token",
+			request: `{"role":"manager"}`,
+			status:  http.StatusUnauthorized,
+			setup:   func() {},
+		},
+		{
+			name:          "fail on caller not owning group",
+			request:       `{"role":"manager"}`,
+			authorization: "Bearer token",
+			status:        http.StatusForbidden,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				groupService.EXPECT().AuthorizeGroupOwner(caller, uint(1)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:          "fail on demoting last owner",
+			request:       `{"role":"member"}`,
+			authorization: "Bearer token",
+			status:        http.StatusConflict,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				groupService.EXPECT().AuthorizeGroupOwner(caller, uint(1)).Return(nil).Times(1)
+				groupService.EXPECT().SetMemberRole(uint(1), uint(2), internal.RoleMember).
+					Return(serviceerror.NewServiceError(serviceerror.LastGroupOwner, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/1/users/2/role", strings.NewReader(test.request))
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package httpservice
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	maxIdempotentBodySize    = 1 << 20
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+)
+
+// replayedHeaders are the response headers kept with a response to replay it.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// recordingWriter keeps a copy of the body written through it.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// IdempotencyMiddleware makes requests sent with an Idempotency-Key safe to
+// retry. The first request with a key runs and its response is kept for ttl,
+// repeats of it get that response replayed. Keys are scoped to the caller, a
+// response is never replayed to anyone else; anonymous requests are scoped
+// to their organization and client IP. Bodies larger than 1 MiB are refused
+// with 413. A key sent with a different
+// method, path or body is refused with 422, and while the first request still
+// runs repeats get 409. Responses with a 5xx status aren't kept, so a retry
+// runs the request again.
+func IdempotencyMiddleware(store internal.IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	if ttl == 0 {
+		ttl = defaultIdempotencyKeyTTL
+	}
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprintf("%s is longer than %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength),
+			})
+			return
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		if err != nil {
+			status := http.StatusBadRequest
+			if len(body) >= maxIdempotentBodySize {
+				status = http.StatusRequestEntityTooLarge
+			}
+			c.AbortWithStatusJSON(status, gin.H{"message": err.Error()})
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		scope := idempotencyScope(c)
+		fingerprint := requestFingerprint(scope, c.Request.Method, c.Request.URL.Path, body)
+		record, reserved, err := store.ReserveIdempotencyKey(scope, key, fingerprint, time.Now().Add(ttl))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		if !reserved {
+			replay(c, record, fingerprint)
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		logger := log.WithField("idempotencyKey", key)
+		if writer.Status() >= http.StatusInternalServerError {
+			if err := store.ReleaseIdempotencyKey(scope, key); err != nil {
+				logger.WithError(err).Error("release idempotency key failed")
+			}
+			return
+		}
+		response := internal.IdempotentResponse{
+			Status: writer.Status(),
+			Header: map[string]string{},
+			Body:   writer.body.Bytes(),
+		}
+		for _, name := range replayedHeaders {
+			if value := writer.Header().Get(name); value != "" {
+				response.Header[name] = value
+			}
+		}
+		if err := store.SaveIdempotentResponse(scope, key, response); err != nil {
+			logger.WithError(err).Error("save idempotent response failed")
+		}
+	}
+}
+
+// replay answers a repeated request with the response kept for its key.
+func replay(c *gin.Context, record internal.IdempotencyRecord, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"message": fmt.Sprintf("%s %s was sent with a different request", IdempotencyKeyHeader, record.Key),
+		})
+		return
+	}
+	if record.Response == nil {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("request with %s %s is still in progress", IdempotencyKeyHeader, record.Key),
+		})
+		return
+	}
+	for name, value := range record.Response.Header {
+		c.Header(name, value)
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(record.Response.Status)
+	c.Writer.Write(record.Response.Body)
+	c.Abort()
+}
+
+// idempotencyScope names whose keys a request's key is among.
+func idempotencyScope(c *gin.Context) string {
+	if caller, ok := callerOf(c); ok {
+		return fmt.Sprintf("user:%d", caller.UserID)
+	}
+	organizationID, _ := organizationOf(c)
+	return fmt.Sprintf("anonymous:%d:%s", organizationID, clientIPOf(c))
+}
+
+func requestFingerprint(scope string, method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(scope))
+	hash.Write([]byte{0})
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
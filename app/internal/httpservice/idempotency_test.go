@@ -0,0 +1,172 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	store := mock.NewMockIdempotencyStore(mockCtrl)
+	groupService := mock.NewMockGroupService(mockCtrl)
+	router := gin.Default()
+	// createGroup stands for any handler, anonymous callers can't create
+	// groups with CreateGroupHandler.
+	createGroup := func(c *gin.Context) {
+		response, err := groupService.CreateGroup(internal.GroupRequest{Name: "test"})
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Header("ETag", `"1"`)
+		c.JSON(http.StatusCreated, response)
+	}
+	router.POST("/groups", httpservice.IdempotencyMiddleware(store, time.Hour), createGroup)
+	router.POST("/caller/groups", httpservice.CallerMiddleware(internal.Caller{UserID: 5}), httpservice.IdempotencyMiddleware(store, time.Hour),
+		createGroup)
+	anonymous := "anonymous:0:192.0.2.1"
+	stored := &internal.IdempotentResponse{
+		Status: http.StatusCreated,
+		Header: map[string]string{"Content-Type": "application/json; charset=utf-8", "ETag": `"1"`},
+		Body:   []byte(`{"id":1,"name":"test"}`),
+	}
+	reserve := func(response *internal.IdempotentResponse, fingerprint string, reserved bool) {
+		store.EXPECT().ReserveIdempotencyKey(anonymous, "key", gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ string, key string, requestFingerprint string, expiresAt time.Time) (internal.IdempotencyRecord, bool, error) {
+				if fingerprint == "" {
+					fingerprint = requestFingerprint
+				}
+				return internal.IdempotencyRecord{Key: key, Fingerprint: fingerprint, Response: response, ExpiresAt: expiresAt}, reserved, nil
+			}).Times(1)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		key     string
+		request string
+		status  int
+		body    string
+		setup   func()
+		replied bool
+	}{
+		{
+			name:   "run request without key",
+			status: http.StatusCreated,
+			setup: func() {
+				groupService.EXPECT().CreateGroup(internal.GroupRequest{Name: "test"}).Return(internal.GroupResponse{ID: 1, Name: "test", Version: 1}, nil).Times(1)
+			},
+		},
+		{
+			name:   "run and keep response of first request",
+			key:    "key",
+			status: http.StatusCreated,
+			setup: func() {
+				reserve(nil, "", true)
+				groupService.EXPECT().CreateGroup(internal.GroupRequest{Name: "test"}).Return(internal.GroupResponse{ID: 1, Name: "test", Version: 1}, nil).Times(1)
+				store.EXPECT().SaveIdempotentResponse(anonymous, "key", *stored).Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "replay response of repeated request",
+			key:     "key",
+			status:  http.StatusCreated,
+			body:    `{"id":1,"name":"test"}`,
+			replied: true,
+			setup: func() {
+				reserve(stored, "", false)
+			},
+		},
+		{
+			name:   "fail on key sent with different request",
+			key:    "key",
+			status: http.StatusUnprocessableEntity,
+			setup: func() {
+				reserve(stored, "other", false)
+			},
+		},
+		{
+			name:   "fail on request still in progress",
+			key:    "key",
+			status: http.StatusConflict,
+			setup: func() {
+				reserve(nil, "", false)
+			},
+		},
+		{
+			name:   "release key on unknown error",
+			key:    "key",
+			status: http.StatusInternalServerError,
+			setup: func() {
+				reserve(nil, "", true)
+				groupService.EXPECT().CreateGroup(internal.GroupRequest{Name: "test"}).Return(internal.GroupResponse{}, errors.New("test")).Times(1)
+				store.EXPECT().ReleaseIdempotencyKey(anonymous, "key").Return(nil).Times(1)
+			},
+		},
+		{
+			name:   "scope key to caller",
+			path:   "/caller/groups",
+			key:    "key",
+			status: http.StatusCreated,
+			setup: func() {
+				store.EXPECT().ReserveIdempotencyKey("user:5", "key", gomock.Any(), gomock.Any()).
+					Return(internal.IdempotencyRecord{Key: "key"}, true, nil).Times(1)
+				groupService.EXPECT().CreateGroup(internal.GroupRequest{Name: "test"}).Return(internal.GroupResponse{ID: 1, Name: "test", Version: 1}, nil).Times(1)
+				store.EXPECT().SaveIdempotentResponse("user:5", "key", *stored).Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on too large body",
+			key:     "key",
+			request: `{"name":"` + strings.Repeat("a", 1<<20) + `"}`,
+			status:  http.StatusRequestEntityTooLarge,
+			setup:   func() {},
+		},
+		{
+			name:   "fail on too long key",
+			key:    strings.Repeat("k", 256),
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, request := test.path, test.request
+			if path == "" {
+				path = "/groups"
+			}
+			if request == "" {
+				request = `{"name":"test"}`
+			}
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", path, strings.NewReader(request))
+			req.RemoteAddr = "192.0.2.1:1234"
+			if test.key != "" {
+				req.Header.Set(httpservice.IdempotencyKeyHeader, test.key)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			if test.body != "" {
+				assert.Equal(t, test.body, recorder.Body.String())
+			}
+			if test.replied {
+				assert.Equal(t, "true", recorder.Header().Get(httpservice.IdempotentReplayedHeader))
+				assert.Equal(t, `"1"`, recorder.Header().Get("ETag"))
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package httpservice
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const jobsPath = "/api/v1/jobs"
+
+// accepted answers a request whose work was queued as a job, the client
+// follows Location to see its progress and result.
+func accepted(c *gin.Context, job internal.Job) {
+	c.Header("Location", fmt.Sprintf("%s/%d", jobsPath, job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetJobHandler reports on a job, to the user who created it or an admin.
+func GetJobHandler(jobService internal.JobService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return jobService.AuthorizeJob(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := jobService.GetJob(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CancelJobHandler cancels a queued job, a running job stops at its next
+// heartbeat and keeps what it did so far. Like GetJobHandler it is for the
+// user who created the job or an admin.
+func CancelJobHandler(jobService internal.JobService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return jobService.AuthorizeJob(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := jobService.CancelJob(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
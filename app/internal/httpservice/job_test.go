@@ -0,0 +1,136 @@
+package httpservice_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobHandlers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	jobService := mock.NewMockJobService(mockCtrl)
+	router := gin.Default()
+	caller := internal.Caller{UserID: 5}
+	router.Use(httpservice.CallerMiddleware(caller))
+	router.GET("/jobs/:id", httpservice.GetJobHandler(jobService))
+	router.POST("/jobs/:id/cancel", httpservice.CancelJobHandler(jobService))
+	anonymous := gin.Default()
+	anonymous.GET("/jobs/:id", httpservice.GetJobHandler(jobService))
+	jobService.EXPECT().AuthorizeJob(caller, uint(1)).Return(nil).AnyTimes()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+		setup  func()
+	}{
+		{
+			name:   "get job",
+			method: "GET",
+			path:   "/jobs/1",
+			status: http.StatusOK,
+			body:   `"progress":{"done":1,"total":2}`,
+			setup: func() {
+				jobService.EXPECT().GetJob(uint(1)).Return(internal.Job{
+					ID: 1, Type: internal.JobImportUsers, Status: internal.JobRunning, Progress: internal.JobProgress{Done: 1, Total: 2},
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on invalid id",
+			method: "GET",
+			path:   "/jobs/test",
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+		{
+			name:   "fail on unknown job",
+			method: "GET",
+			path:   "/jobs/2",
+			status: http.StatusBadRequest,
+			setup: func() {
+				jobService.EXPECT().AuthorizeJob(caller, uint(2)).Return(
+					serviceerror.NewServiceError(serviceerror.JobNotFound, fmt.Errorf("job 2 not found"))).Times(1)
+			},
+		},
+		{
+			name:   "fail on job of other user",
+			method: "GET",
+			path:   "/jobs/3",
+			status: http.StatusForbidden,
+			setup: func() {
+				jobService.EXPECT().AuthorizeJob(caller, uint(3)).Return(
+					serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user 5 didn't create job 3"))).Times(1)
+			},
+		},
+		{
+			name:   "fail on cancel of job of other user",
+			method: "POST",
+			path:   "/jobs/3/cancel",
+			status: http.StatusForbidden,
+			setup: func() {
+				jobService.EXPECT().AuthorizeJob(caller, uint(3)).Return(
+					serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user 5 didn't create job 3"))).Times(1)
+			},
+		},
+		{
+			name:   "cancel job",
+			method: "POST",
+			path:   "/jobs/1/cancel",
+			status: http.StatusOK,
+			body:   `"cancelRequested":true`,
+			setup: func() {
+				jobService.EXPECT().CancelJob(uint(1)).Return(internal.Job{ID: 1, Status: internal.JobRunning, CancelRequested: true}, nil).Times(1)
+			},
+		},
+		{
+			name:   "fail to cancel finished job",
+			method: "POST",
+			path:   "/jobs/1/cancel",
+			status: http.StatusConflict,
+			setup: func() {
+				jobService.EXPECT().CancelJob(uint(1)).Return(internal.Job{},
+					serviceerror.NewServiceError(serviceerror.JobFinished, fmt.Errorf("job 1 is already succeeded"))).Times(1)
+			},
+		},
+		{
+			name:   "fail on unknown error",
+			method: "POST",
+			path:   "/jobs/1/cancel",
+			status: http.StatusInternalServerError,
+			setup: func() {
+				jobService.EXPECT().CancelJob(uint(1)).Return(internal.Job{}, errors.New("test")).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(test.method, test.path, nil)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), test.body)
+		})
+	}
+
+	t.Run("fail without caller", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/jobs/1", nil)
+		anonymous.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+}
@@ -0,0 +1,31 @@
+package httpservice
+
+import (
+	"net/http"
+	"strconv"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnlockUserHandler clears the lockout of the user, the caller must be an
+// admin.
+func UnlockUserHandler(lockoutService internal.LockoutService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, lockoutService.AuthorizeAdmin) {
+			return
+		}
+		err = lockoutService.Unlock(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,75 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnlockUserHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	lockoutService := mock.NewMockLockoutService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users/:id/unlock", httpservice.CallerMiddleware(internal.Caller{UserID: 2, Admin: true}), httpservice.UnlockUserHandler(lockoutService))
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		setup  func()
+	}{
+		{
+			name:   "unlock successfully",
+			path:   "/users/1/unlock",
+			status: http.StatusOK,
+			setup: func() {
+				lockoutService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 2, Admin: true}).Return(nil).Times(1)
+				lockoutService.EXPECT().Unlock(uint(1)).Return(nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on invalid id",
+			path:   "/users/abc/unlock",
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+		{
+			name:   "fail on caller who isn't admin",
+			path:   "/users/1/unlock",
+			status: http.StatusForbidden,
+			setup: func() {
+				lockoutService.EXPECT().AuthorizeAdmin(gomock.Any()).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:   "fail on unknown user",
+			path:   "/users/2/unlock",
+			status: http.StatusBadRequest,
+			setup: func() {
+				lockoutService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 2, Admin: true}).Return(nil).Times(1)
+				lockoutService.EXPECT().Unlock(uint(2)).
+					Return(serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.path, nil)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
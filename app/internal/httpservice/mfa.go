@@ -0,0 +1,95 @@
+package httpservice
+
+import (
+	"net/http"
+	"strconv"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type ActivateMFA struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// EnrollMFAHandler starts the mfa enrollment of the user, the caller must be
+// the user.
+func EnrollMFAHandler(mfaService internal.MFAService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return mfaService.AuthorizeEnrollment(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := mfaService.Enroll(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		if c.GetHeader("Accept") == "image/png" {
+			c.Data(http.StatusCreated, "image/png", response.QRCode)
+			return
+		}
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// ActivateMFAHandler confirms the enrollment with a code, the caller must be
+// the user.
+func ActivateMFAHandler(mfaService internal.MFAService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request ActivateMFA
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return mfaService.AuthorizeEnrollment(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := mfaService.Activate(uint(id), request.Code)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ResetMFAHandler disables the mfa of the user, the caller must be an admin.
+func ResetMFAHandler(mfaService internal.MFAService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, mfaService.AuthorizeAdmin) {
+			return
+		}
+		err = mfaService.Reset(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
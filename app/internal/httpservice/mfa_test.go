@@ -0,0 +1,188 @@
+package httpservice_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const activateMFAObj = `{"code":"%s"}`
+
+func TestEnrollMFAHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mfaService := mock.NewMockMFAService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users/:id/mfa", httpservice.CallerMiddleware(internal.Caller{UserID: 1}), httpservice.EnrollMFAHandler(mfaService))
+	enrollment := internal.MFAEnrollment{Secret: "SECRET", OtpauthURI: "otpauth://totp/test", QRCode: []byte("png")}
+
+	tests := []struct {
+		name        string
+		accept      string
+		status      int
+		contentType string
+		setup       func()
+	}{
+		{
+			name:        "enroll successfully",
+			status:      http.StatusCreated,
+			contentType: "application/json; charset=utf-8",
+			setup: func() {
+				mfaService.EXPECT().AuthorizeEnrollment(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				mfaService.EXPECT().Enroll(uint(1)).Return(enrollment, nil).Times(1)
+			},
+		},
+		{
+			name:        "enroll with qr png",
+			accept:      "image/png",
+			status:      http.StatusCreated,
+			contentType: "image/png",
+			setup: func() {
+				mfaService.EXPECT().AuthorizeEnrollment(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				mfaService.EXPECT().Enroll(uint(1)).Return(enrollment, nil).Times(1)
+			},
+		},
+		{
+			name:        "fail on other user",
+			status:      http.StatusForbidden,
+			contentType: "application/json; charset=utf-8",
+			setup: func() {
+				mfaService.EXPECT().AuthorizeEnrollment(internal.Caller{UserID: 1}, uint(1)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:        "fail on service error",
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			setup: func() {
+				mfaService.EXPECT().AuthorizeEnrollment(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				mfaService.EXPECT().Enroll(uint(1)).Return(internal.MFAEnrollment{},
+					serviceerror.NewServiceError(serviceerror.MFAAlreadyEnabled, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/users/1/mfa", nil)
+			req.Header.Set("Accept", test.accept)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.contentType, recorder.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestActivateMFAHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mfaService := mock.NewMockMFAService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users/:id/mfa/activate", httpservice.CallerMiddleware(internal.Caller{UserID: 1}), httpservice.ActivateMFAHandler(mfaService))
+
+	tests := []struct {
+		name     string
+		request  string
+		status   int
+		response string
+		setup    func()
+	}{
+		{
+			name:     "activate successfully",
+			request:  fmt.Sprintf(activateMFAObj, "123456"),
+			status:   http.StatusOK,
+			response: `{"recoveryCodes":["abcd-efgh"]}`,
+			setup: func() {
+				mfaService.EXPECT().AuthorizeEnrollment(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				mfaService.EXPECT().Activate(uint(1), "123456").Return(internal.RecoveryCodesResponse{
+					RecoveryCodes: []string{"abcd-efgh"},
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on malformed code",
+			request:  fmt.Sprintf(activateMFAObj, "12345a"),
+			status:   http.StatusBadRequest,
+			response: `{"message":"Key: 'ActivateMFA.Code' Error:Field validation for 'Code' failed on the 'numeric' tag"}`,
+			setup:    func() {},
+		},
+		{
+			name:     "fail on other user",
+			request:  fmt.Sprintf(activateMFAObj, "123456"),
+			status:   http.StatusForbidden,
+			response: `{"message":"Forbidden : test"}`,
+			setup: func() {
+				mfaService.EXPECT().AuthorizeEnrollment(internal.Caller{UserID: 1}, uint(1)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:     "fail on wrong code",
+			request:  fmt.Sprintf(activateMFAObj, "123456"),
+			status:   http.StatusBadRequest,
+			response: `{"message":"Invalid MFA Code : test"}`,
+			setup: func() {
+				mfaService.EXPECT().AuthorizeEnrollment(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				mfaService.EXPECT().Activate(uint(1), "123456").Return(internal.RecoveryCodesResponse{},
+					serviceerror.NewServiceError(serviceerror.InvalidMFACode, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/users/1/mfa/activate", strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, recorder.Body.String())
+		})
+	}
+}
+
+func TestResetMFAHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mfaService := mock.NewMockMFAService(mockCtrl)
+	router := gin.Default()
+	router.DELETE("/users/:id/mfa", httpservice.CallerMiddleware(internal.Caller{UserID: 2, Admin: true}), httpservice.ResetMFAHandler(mfaService))
+	router.DELETE("/anonymous/:id/mfa", httpservice.ResetMFAHandler(mfaService))
+
+	t.Run("reset successfully", func(t *testing.T) {
+		mfaService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 2, Admin: true}).Return(nil).Times(1)
+		mfaService.EXPECT().Reset(uint(1)).Return(nil).Times(1)
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/users/1/mfa", nil)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("fail without caller", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/anonymous/1/mfa", nil)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("fail on unknown error", func(t *testing.T) {
+		mfaService.EXPECT().AuthorizeAdmin(gomock.Any()).Return(nil).Times(1)
+		mfaService.EXPECT().Reset(uint(1)).Return(errors.New("test")).Times(1)
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/users/1/mfa", nil)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
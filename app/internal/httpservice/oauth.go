@@ -0,0 +1,304 @@
+package httpservice
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// oauthErrors are the RFC 6749 error codes of the service errors the OAuth
+// endpoints report in their own format.
+var oauthErrors = map[serviceerror.ErrorCode]string{
+	serviceerror.OAuthInvalidRequest:       "invalid_request",
+	serviceerror.OAuthInvalidClient:        "invalid_client",
+	serviceerror.OAuthInvalidGrant:         "invalid_grant",
+	serviceerror.OAuthUnauthorizedClient:   "unauthorized_client",
+	serviceerror.OAuthUnsupportedGrantType: "unsupported_grant_type",
+	serviceerror.OAuthUnsupportedResponse:  "unsupported_response_type",
+	serviceerror.OAuthInvalidScope:         "invalid_scope",
+}
+
+type RegisterOAuthClient struct {
+	Name             string   `json:"name" validate:"required,max=255"`
+	Confidential     bool     `json:"confidential"`
+	RedirectURIs     []string `json:"redirectUris" validate:"dive,required,url"`
+	GrantTypes       []string `json:"grantTypes" validate:"required,min=1,dive,oneof=authorization_code client_credentials"`
+	Scopes           []string `json:"scopes" validate:"required,min=1,dive,required"`
+	ServiceAccountID uint     `json:"serviceAccountId"`
+}
+
+type OAuthAuthorize struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+	Nonce               string `form:"nonce"`
+}
+
+type OAuthToken struct {
+	GrantType    string `form:"grant_type"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	Scope        string `form:"scope"`
+}
+
+// OAuthTokenAction is a request to the introspection or revocation endpoint,
+// the hint is accepted but not needed as only access tokens are issued.
+type OAuthTokenAction struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// RegisterOAuthClientHandler registers an OAuth client, the caller must be
+// an admin. The secret of a confidential client is only returned in this
+// response.
+func RegisterOAuthClientHandler(oauthService internal.OAuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request RegisterOAuthClient
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, oauthService.AuthorizeAdmin) {
+			return
+		}
+		response, err := oauthService.RegisterClient(internal.OAuthClientRequest{
+			Name:             request.Name,
+			Confidential:     request.Confidential,
+			RedirectURIs:     request.RedirectURIs,
+			GrantTypes:       request.GrantTypes,
+			Scopes:           request.Scopes,
+			ServiceAccountID: request.ServiceAccountID,
+		})
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+func GetOAuthClientHandler(oauthService internal.OAuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response, err := oauthService.GetClient(c.Param("clientid"))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// OAuthAuthorizeHandler is the authorization endpoint. A user calling it with
+// the access token of their login grants the client an authorization code
+// and is redirected back to the client with the code. A browser, which has no
+// token to send, is shown a page to log in and consent, see
+// OAuthLoginHandler. Errors about the client or its redirect uri are
+// answered, the others redirected.
+func OAuthAuthorizeHandler(oauthService internal.OAuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request OAuthAuthorize
+		if err := c.ShouldBindQuery(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		redirectURI, err := oauthService.RedirectURI(request.ClientID, request.RedirectURI)
+		if err != nil {
+			abortOAuth(c, err)
+			return
+		}
+		caller, ok := callerOf(c)
+		if !ok || caller.Scopes != nil {
+			renderOAuthLogin(c, oauthService, http.StatusOK, oauthLoginPage{Request: request})
+			return
+		}
+		grantAuthorization(c, oauthService, request, redirectURI, caller.UserID)
+	}
+}
+
+// grantAuthorization redirects the user back to the client with a code or,
+// for errors the client should know about, with the error.
+func grantAuthorization(c *gin.Context, oauthService internal.OAuthService, request OAuthAuthorize, redirectURI string, userID uint) {
+	code, err := oauthService.Authorize(internal.OAuthAuthorizeRequest{
+		UserID:              userID,
+		ResponseType:        request.ResponseType,
+		ClientID:            request.ClientID,
+		RedirectURI:         request.RedirectURI,
+		Scope:               request.Scope,
+		CodeChallenge:       request.CodeChallenge,
+		CodeChallengeMethod: request.CodeChallengeMethod,
+		Nonce:               request.Nonce,
+	})
+	params := url.Values{}
+	if err != nil {
+		var srvError *serviceerror.ServiceError
+		if !errors.As(err, &srvError) || oauthErrors[srvError.Code] == "" {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		params.Set("error", oauthErrors[srvError.Code])
+		params.Set("error_description", srvError.Err.Error())
+	} else {
+		params.Set("code", code)
+	}
+	redirectWithState(c, redirectURI, params, request.State)
+}
+
+// redirectWithState redirects back to the client, with the state it sent.
+func redirectWithState(c *gin.Context, redirectURI string, params url.Values, state string) {
+	if state != "" {
+		params.Set("state", state)
+	}
+	c.Redirect(http.StatusFound, withQuery(redirectURI, params))
+}
+
+// OAuthTokenHandler is the token endpoint, clients authenticate with HTTP
+// basic authentication or with client_id and client_secret in the form.
+func OAuthTokenHandler(oauthService internal.OAuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request OAuthToken
+		if err := c.ShouldBindWith(&request, binding.Form); err != nil {
+			abortOAuth(c, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, err))
+			return
+		}
+		clientID, clientSecret, err := clientCredentials(c, request.ClientID, request.ClientSecret)
+		if err != nil {
+			abortOAuth(c, err)
+			return
+		}
+		response, err := oauthService.Token(internal.OAuthTokenRequest{
+			GrantType:    request.GrantType,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Code:         request.Code,
+			RedirectURI:  request.RedirectURI,
+			CodeVerifier: request.CodeVerifier,
+			Scope:        request.Scope,
+		})
+		if err != nil {
+			abortOAuth(c, err)
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.Header("Pragma", "no-cache")
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// OAuthIntrospectHandler is the RFC 7662 introspection endpoint.
+func OAuthIntrospectHandler(oauthService internal.OAuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request OAuthTokenAction
+		if err := c.ShouldBindWith(&request, binding.Form); err != nil || request.Token == "" {
+			abortOAuth(c, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("token is required")))
+			return
+		}
+		clientID, clientSecret, err := clientCredentials(c, request.ClientID, request.ClientSecret)
+		if err != nil {
+			abortOAuth(c, err)
+			return
+		}
+		response, err := oauthService.Introspect(clientID, clientSecret, request.Token)
+		if err != nil {
+			abortOAuth(c, err)
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// OAuthRevokeHandler is the RFC 7009 revocation endpoint, it answers 200 for
+// unknown tokens too.
+func OAuthRevokeHandler(oauthService internal.OAuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request OAuthTokenAction
+		if err := c.ShouldBindWith(&request, binding.Form); err != nil {
+			abortOAuth(c, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, err))
+			return
+		}
+		clientID, clientSecret, err := clientCredentials(c, request.ClientID, request.ClientSecret)
+		if err != nil {
+			abortOAuth(c, err)
+			return
+		}
+		if err := oauthService.Revoke(clientID, clientSecret, request.Token); err != nil {
+			abortOAuth(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+// clientCredentials returns the client credentials of HTTP basic
+// authentication, form encoded as RFC 6749 asks, or else those of the form.
+// Using both is an error.
+func clientCredentials(c *gin.Context, formID string, formSecret string) (clientID string, clientSecret string, err error) {
+	id, secret, ok := c.Request.BasicAuth()
+	if !ok {
+		return formID, formSecret, nil
+	}
+	if formSecret != "" {
+		return "", "", serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("client authenticated more than once"))
+	}
+	if clientID, err = url.QueryUnescape(id); err != nil {
+		return "", "", serviceerror.NewServiceError(serviceerror.OAuthInvalidClient, err)
+	}
+	if clientSecret, err = url.QueryUnescape(secret); err != nil {
+		return "", "", serviceerror.NewServiceError(serviceerror.OAuthInvalidClient, err)
+	}
+	return clientID, clientSecret, nil
+}
+
+// abortOAuth answers OAuth errors as RFC 6749 asks, an invalid client with 401
+// and the others with 400. Other errors are answered as usual.
+func abortOAuth(c *gin.Context, err error) {
+	var srvError *serviceerror.ServiceError
+	if !errors.As(err, &srvError) || oauthErrors[srvError.Code] == "" {
+		serviceerror.AbortOnError(c, err)
+		return
+	}
+	status := http.StatusBadRequest
+	if srvError.Code == serviceerror.OAuthInvalidClient {
+		status = http.StatusUnauthorized
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.AbortWithStatusJSON(status, gin.H{
+		"error":             oauthErrors[srvError.Code],
+		"error_description": srvError.Err.Error(),
+	})
+}
+
+// withQuery adds params to the query the uri already has.
+func withQuery(uri string, params url.Values) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	query := parsed.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
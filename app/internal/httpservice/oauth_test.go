@@ -0,0 +1,467 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterOAuthClientHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	oauthService := mock.NewMockOAuthService(mockCtrl)
+	router := gin.Default()
+	router.POST("/oauth/clients", httpservice.AuthenticationMiddleware(authService, false), httpservice.RegisterOAuthClientHandler(oauthService))
+
+	tests := []struct {
+		name          string
+		request       string
+		authorization string
+		status        int
+		response      string
+		setup         func()
+	}{
+		{
+			name:          "register client successfully",
+			authorization: "Bearer token",
+			request:       `{"name":"ci","confidential":true,"grantTypes":["client_credentials"],"scopes":["users:read"],"serviceAccountId":4}`,
+			status:        http.StatusCreated,
+			response:      `{"id":1,"clientId":"client","name":"ci","confidential":true,"redirectUris":[],"grantTypes":["client_credentials"],"scopes":["users:read"],"serviceAccountId":4,"createdAt":"0001-01-01T00:00:00Z","clientSecret":"secret"}`,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				oauthService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+				oauthService.EXPECT().RegisterClient(internal.OAuthClientRequest{
+					Name: "ci", Confidential: true, GrantTypes: []string{"client_credentials"}, Scopes: []string{"users:read"}, ServiceAccountID: 4,
+				}).Return(internal.RegisteredOAuthClient{
+					OAuthClient: internal.OAuthClient{
+						ID: 1, ClientID: "client", Name: "ci", Confidential: true, RedirectURIs: []string{},
+						GrantTypes: []string{"client_credentials"}, Scopes: []string{"users:read"}, ServiceAccountID: 4,
+					},
+					ClientSecret: "secret",
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on unknown grant type",
+			request:  `{"name":"ci","grantTypes":["password"],"scopes":["users:read"]}`,
+			status:   http.StatusBadRequest,
+			response: `{"message":"Key: 'RegisterOAuthClient.GrantTypes[0]' Error:Field validation for 'GrantTypes[0]' failed on the 'oneof' tag"}`,
+			setup:    func() {},
+		},
+		{
+			name:          "fail on caller not admin",
+			request:       `{"name":"ci","grantTypes":["authorization_code"],"redirectUris":["https://app.example.com/callback"],"scopes":["users:read"]}`,
+			authorization: "Bearer token",
+			status:        http.StatusForbidden,
+			response:      `{"message":"Forbidden : test"}`,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 7}, nil).Times(1)
+				oauthService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 7}).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:     "fail without token",
+			request:  `{"name":"ci","grantTypes":["authorization_code"],"redirectUris":["https://app.example.com/callback"],"scopes":["users:read"]}`,
+			status:   http.StatusUnauthorized,
+			response: `{"message":"bearer access token is missing"}`,
+			setup:    func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/oauth/clients", strings.NewReader(test.request))
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, recorder.Body.String())
+		})
+	}
+}
+
+func TestOAuthAuthorizeHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	oauthService := mock.NewMockOAuthService(mockCtrl)
+	router := gin.Default()
+	router.GET("/oauth/authorize", httpservice.AuthenticationMiddleware(authService, false), httpservice.OAuthAuthorizeHandler(oauthService))
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	query := "?response_type=code&client_id=client&state=xyz&code_challenge=" + challenge + "&code_challenge_method=S256"
+
+	tests := []struct {
+		name          string
+		query         string
+		authorization string
+		status        int
+		location      string
+		response      string
+		setup         func()
+	}{
+		{
+			name:          "redirect with code",
+			query:         query,
+			authorization: "Bearer token",
+			status:        http.StatusFound,
+			location:      "https://app.example.com/callback?code=code&state=xyz",
+			setup: func() {
+				oauthService.EXPECT().RedirectURI("client", "").Return("https://app.example.com/callback", nil).Times(1)
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1}, nil).Times(1)
+				oauthService.EXPECT().Authorize(internal.OAuthAuthorizeRequest{
+					UserID: 1, ResponseType: "code", ClientID: "client", CodeChallenge: challenge, CodeChallengeMethod: "S256",
+				}).Return("code", nil).Times(1)
+			},
+		},
+		{
+			name:          "redirect with error",
+			query:         query + "&scope=secrets:read",
+			authorization: "Bearer token",
+			status:        http.StatusFound,
+			location:      "https://app.example.com/callback?error=invalid_scope&error_description=test&state=xyz",
+			setup: func() {
+				oauthService.EXPECT().RedirectURI("client", "").Return("https://app.example.com/callback", nil).Times(1)
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1}, nil).Times(1)
+				oauthService.EXPECT().Authorize(gomock.Any()).
+					Return("", serviceerror.NewServiceError(serviceerror.OAuthInvalidScope, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:     "fail on unregistered redirect uri",
+			query:    query + "&redirect_uri=https://evil.example.com",
+			status:   http.StatusBadRequest,
+			response: `{"error":"invalid_request","error_description":"test"}`,
+			setup: func() {
+				oauthService.EXPECT().RedirectURI("client", "https://evil.example.com").
+					Return("", serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:   "show login page without login",
+			query:  query,
+			status: http.StatusOK,
+			setup: func() {
+				oauthService.EXPECT().RedirectURI("client", "").Return("https://app.example.com/callback", nil).Times(1)
+				oauthService.EXPECT().GetClient("client").Return(internal.OAuthClient{ClientID: "client", Name: "web"}, nil).Times(1)
+			},
+		},
+		{
+			name:          "show login page with personal access token",
+			query:         query,
+			authorization: "Bearer pat_token",
+			status:        http.StatusOK,
+			setup: func() {
+				oauthService.EXPECT().RedirectURI("client", "").Return("https://app.example.com/callback", nil).Times(1)
+				authService.EXPECT().Authenticate("pat_token").Return(internal.Caller{UserID: 1, Scopes: []string{"users:read"}}, nil).Times(1)
+				oauthService.EXPECT().GetClient("client").Return(internal.OAuthClient{ClientID: "client", Name: "web"}, nil).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/oauth/authorize"+test.query, nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.location, recorder.Header().Get("Location"))
+			if test.response != "" {
+				assert.Equal(t, test.response, recorder.Body.String())
+			}
+			if test.status == http.StatusOK {
+				assert.Contains(t, recorder.Body.String(), "Sign in to web")
+				assert.Contains(t, recorder.Body.String(), `name="code_challenge" value="`+challenge+`"`)
+				assert.Equal(t, "DENY", recorder.Header().Get("X-Frame-Options"))
+			}
+		})
+	}
+}
+
+func TestOAuthLoginHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	oauthService := mock.NewMockOAuthService(mockCtrl)
+	router := gin.Default()
+	router.POST("/oauth/authorize", httpservice.OAuthLoginHandler(oauthService, authService))
+	form := "response_type=code&client_id=client&state=xyz&scope=openid&action=allow"
+
+	tests := []struct {
+		name     string
+		request  string
+		status   int
+		location string
+		response string
+		setup    func()
+	}{
+		{
+			name:     "redirect with code after login",
+			request:  form + "&email=test@gmail.com&password=secret",
+			status:   http.StatusFound,
+			location: "https://app.example.com/callback?code=code&state=xyz",
+			setup: func() {
+				oauthService.EXPECT().RedirectURI("client", "").Return("https://app.example.com/callback", nil).Times(1)
+				authService.EXPECT().Login("test@gmail.com", "secret", "192.0.2.1").Return(internal.LoginResponse{AccessToken: "token"}, nil).Times(1)
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1}, nil).Times(1)
+				oauthService.EXPECT().Authorize(internal.OAuthAuthorizeRequest{
+					UserID: 1, ResponseType: "code", ClientID: "client", Scope: "openid",
+				}).Return("code", nil).Times(1)
+			},
+		},
+		{
+			name:     "ask for mfa code",
+			request:  form + "&email=test@gmail.com&password=secret",
+			status:   http.StatusOK,
+			response: `name="mfa_token" value="mfa"`,
+			setup: func() {
+				oauthService.EXPECT().RedirectURI("client", "").Return("https://app.example.com/callback", nil).Times(1)
+				authService.EXPECT().Login("test@gmail.com", "secret", "192.0.2.1").
+					Return(internal.LoginResponse{MFARequired: true, MFAToken: "mfa"}, nil).Times(1)
+				oauthService.EXPECT().GetClient("client").Return(internal.OAuthClient{ClientID: "client", Name: "web"}, nil).Times(1)
+			},
+		},
+		{
+			name:     "redirect with code after mfa",
+			request:  form + "&mfa_token=mfa&code=123456",
+			status:   http.StatusFound,
+			location: "https://app.example.com/callback?code=code&state=xyz",
+			setup: func() {
+				oauthService.EXPECT().RedirectURI("client", "").Return("https://app.example.com/callback", nil).Times(1)
+				authService.EXPECT().LoginMFA("mfa", "123456", "192.0.2.1").Return(internal.LoginResponse{AccessToken: "token"}, nil).Times(1)
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1}, nil).Times(1)
+				oauthService.EXPECT().Authorize(gomock.Any()).Return("code", nil).Times(1)
+			},
+		},
+		{
+			name:     "show login page again on wrong password",
+			request:  form + "&email=test@gmail.com&password=wrong",
+			status:   http.StatusUnauthorized,
+			response: "The email or password is wrong.",
+			setup: func() {
+				oauthService.EXPECT().RedirectURI("client", "").Return("https://app.example.com/callback", nil).Times(1)
+				authService.EXPECT().Login("test@gmail.com", "wrong", "192.0.2.1").
+					Return(internal.LoginResponse{}, serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("test"))).Times(1)
+				oauthService.EXPECT().GetClient("client").Return(internal.OAuthClient{ClientID: "client", Name: "web"}, nil).Times(1)
+			},
+		},
+		{
+			name:     "redirect with access denied",
+			request:  "response_type=code&client_id=client&state=xyz&action=deny",
+			status:   http.StatusFound,
+			location: "https://app.example.com/callback?error=access_denied&error_description=the+user+denied+the+authorization&state=xyz",
+			setup: func() {
+				oauthService.EXPECT().RedirectURI("client", "").Return("https://app.example.com/callback", nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on unregistered redirect uri",
+			request:  form + "&redirect_uri=https://evil.example.com&email=test@gmail.com&password=secret",
+			status:   http.StatusBadRequest,
+			response: `{"error":"invalid_request","error_description":"test"}`,
+			setup: func() {
+				oauthService.EXPECT().RedirectURI("client", "https://evil.example.com").
+					Return("", serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/oauth/authorize", strings.NewReader(test.request))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.RemoteAddr = "192.0.2.1:1234"
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.location, recorder.Header().Get("Location"))
+			assert.Contains(t, recorder.Body.String(), test.response)
+		})
+	}
+}
+
+func TestOAuthTokenHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	oauthService := mock.NewMockOAuthService(mockCtrl)
+	router := gin.Default()
+	router.POST("/oauth/token", httpservice.OAuthTokenHandler(oauthService))
+
+	tests := []struct {
+		name     string
+		request  string
+		username string
+		password string
+		status   int
+		response string
+		setup    func()
+	}{
+		{
+			name:     "exchange code successfully",
+			request:  "grant_type=authorization_code&client_id=client&code=code&code_verifier=verifier&redirect_uri=https%3A%2F%2Fapp.example.com%2Fcallback",
+			status:   http.StatusOK,
+			response: `{"access_token":"oat_token","token_type":"Bearer","expires_in":3600,"scope":"users:read"}`,
+			setup: func() {
+				oauthService.EXPECT().Token(internal.OAuthTokenRequest{
+					GrantType: "authorization_code", ClientID: "client", Code: "code", CodeVerifier: "verifier", RedirectURI: "https://app.example.com/callback",
+				}).Return(internal.OAuthTokenResponse{AccessToken: "oat_token", TokenType: "Bearer", ExpiresIn: 3600, Scope: "users:read"}, nil).Times(1)
+			},
+		},
+		{
+			name:     "client credentials with basic authentication",
+			request:  "grant_type=client_credentials",
+			username: "client",
+			password: "secret",
+			status:   http.StatusOK,
+			response: `{"access_token":"oat_token","token_type":"Bearer","expires_in":3600}`,
+			setup: func() {
+				oauthService.EXPECT().Token(internal.OAuthTokenRequest{GrantType: "client_credentials", ClientID: "client", ClientSecret: "secret"}).
+					Return(internal.OAuthTokenResponse{AccessToken: "oat_token", TokenType: "Bearer", ExpiresIn: 3600}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on client authenticated twice",
+			request:  "grant_type=client_credentials&client_secret=secret",
+			username: "client",
+			password: "secret",
+			status:   http.StatusBadRequest,
+			response: `{"error":"invalid_request","error_description":"client authenticated more than once"}`,
+			setup:    func() {},
+		},
+		{
+			name:     "fail on invalid client",
+			request:  "grant_type=client_credentials&client_id=client&client_secret=wrong",
+			status:   http.StatusUnauthorized,
+			response: `{"error":"invalid_client","error_description":"test"}`,
+			setup: func() {
+				oauthService.EXPECT().Token(gomock.Any()).
+					Return(internal.OAuthTokenResponse{}, serviceerror.NewServiceError(serviceerror.OAuthInvalidClient, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:     "fail on used code",
+			request:  "grant_type=authorization_code&client_id=client&code=code&code_verifier=verifier",
+			status:   http.StatusBadRequest,
+			response: `{"error":"invalid_grant","error_description":"test"}`,
+			setup: func() {
+				oauthService.EXPECT().Token(gomock.Any()).
+					Return(internal.OAuthTokenResponse{}, serviceerror.NewServiceError(serviceerror.OAuthInvalidGrant, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/oauth/token", strings.NewReader(test.request))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if test.username != "" {
+				req.SetBasicAuth(test.username, test.password)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, recorder.Body.String())
+			assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestOAuthIntrospectHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	oauthService := mock.NewMockOAuthService(mockCtrl)
+	router := gin.Default()
+	router.POST("/oauth/introspect", httpservice.OAuthIntrospectHandler(oauthService))
+
+	tests := []struct {
+		name     string
+		request  string
+		status   int
+		response string
+		setup    func()
+	}{
+		{
+			name:     "introspect active token",
+			request:  "token=oat_token",
+			status:   http.StatusOK,
+			response: `{"active":true,"scope":"users:read","client_id":"client","sub":"1","token_type":"Bearer","exp":1630458000,"iat":1630454400}`,
+			setup: func() {
+				oauthService.EXPECT().Introspect("client", "secret", "oat_token").Return(internal.OAuthIntrospection{
+					Active: true, Scope: "users:read", ClientID: "client", Sub: "1", TokenType: "Bearer", Exp: 1630458000, Iat: 1630454400,
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:     "introspect inactive token",
+			request:  "token=unknown",
+			status:   http.StatusOK,
+			response: `{"active":false}`,
+			setup: func() {
+				oauthService.EXPECT().Introspect("client", "secret", "unknown").Return(internal.OAuthIntrospection{}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on missing token",
+			request:  "",
+			status:   http.StatusBadRequest,
+			response: `{"error":"invalid_request","error_description":"token is required"}`,
+			setup:    func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/oauth/introspect", strings.NewReader(test.request))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.SetBasicAuth("client", "secret")
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, recorder.Body.String())
+		})
+	}
+}
+
+func TestOAuthRevokeHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	oauthService := mock.NewMockOAuthService(mockCtrl)
+	router := gin.Default()
+	router.POST("/oauth/revoke", httpservice.OAuthRevokeHandler(oauthService))
+
+	t.Run("revoke token successfully", func(t *testing.T) {
+		oauthService.EXPECT().Revoke("client", "", "oat_token").Return(nil).Times(1)
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/oauth/revoke", strings.NewReader("token=oat_token&token_type_hint=access_token&client_id=client"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("fail on invalid client", func(t *testing.T) {
+		oauthService.EXPECT().Revoke("client", "wrong", "oat_token").
+			Return(serviceerror.NewServiceError(serviceerror.OAuthInvalidClient, errors.New("test"))).Times(1)
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/oauth/revoke", strings.NewReader("token=oat_token&client_id=client&client_secret=wrong"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.Equal(t, `Basic realm="oauth"`, recorder.Header().Get("WWW-Authenticate"))
+	})
+}
@@ -0,0 +1,156 @@
+package httpservice
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// OAuthLogin is the form of the login and consent page, it carries the
+// parameters of the authorization request along.
+type OAuthLogin struct {
+	OAuthAuthorize
+	Email    string `form:"email"`
+	Password string `form:"password"`
+	MFAToken string `form:"mfa_token"`
+	Code     string `form:"code"`
+	Action   string `form:"action"`
+}
+
+// oauthLoginPage is what the login and consent page shows. With an MFAToken
+// the password was right and the page asks for the code.
+type oauthLoginPage struct {
+	Request    OAuthAuthorize
+	ClientName string
+	Scopes     []string
+	Email      string
+	MFAToken   string
+	Error      string
+}
+
+// loginMessages tell the user why logging in failed, other errors aren't
+// explained.
+var loginMessages = map[serviceerror.ErrorCode]string{
+	serviceerror.InvalidCredentials: "The email or password is wrong.",
+	serviceerror.InvalidMFACode:     "The code is wrong, log in again.",
+	serviceerror.TooManyAttempts:    "Too many failed attempts, try again later.",
+	serviceerror.UserInactive:       "The account isn't active.",
+}
+
+var oauthLoginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Sign in to {{.ClientName}}</title></head>
+<body>
+<h1>Sign in to {{.ClientName}}</h1>
+{{if .Scopes}}<p>{{.ClientName}} asks for:</p>
+<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post">
+<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Request.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+<input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+{{if .MFAToken}}<input type="hidden" name="mfa_token" value="{{.MFAToken}}">
+<label>Code <input name="code" autocomplete="one-time-code" required autofocus></label>
+{{else}}<label>Email <input type="email" name="email" value="{{.Email}}" autocomplete="username" required autofocus></label>
+<label>Password <input type="password" name="password" autocomplete="current-password" required></label>
+{{end}}<button type="submit" name="action" value="allow">Allow</button>
+<button type="submit" name="action" value="deny" formnovalidate>Deny</button>
+</form>
+</body>
+</html>
+`))
+
+// OAuthLoginHandler takes the login and consent page of the authorization
+// endpoint. The user logs in as at /auth/login, with the MFA code if enabled,
+// and is redirected back to the client with a code. Denying redirects with
+// access_denied.
+func OAuthLoginHandler(oauthService internal.OAuthService, authService internal.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request OAuthLogin
+		if err := c.ShouldBindWith(&request, binding.Form); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		redirectURI, err := oauthService.RedirectURI(request.ClientID, request.RedirectURI)
+		if err != nil {
+			abortOAuth(c, err)
+			return
+		}
+		if request.Action != "allow" {
+			params := url.Values{}
+			params.Set("error", "access_denied")
+			params.Set("error_description", "the user denied the authorization")
+			redirectWithState(c, redirectURI, params, request.State)
+			return
+		}
+		page := oauthLoginPage{Request: request.OAuthAuthorize, Email: request.Email}
+		auth := authIn(c, authService)
+		var response internal.LoginResponse
+		if request.MFAToken != "" {
+			response, err = auth.LoginMFA(request.MFAToken, request.Code, clientIPOf(c))
+		} else {
+			response, err = auth.Login(request.Email, request.Password, clientIPOf(c))
+		}
+		if err != nil {
+			var srvError *serviceerror.ServiceError
+			if !errors.As(err, &srvError) {
+				serviceerror.AbortOnError(c, err)
+				return
+			}
+			page.Error = loginMessages[srvError.Code]
+			if request.MFAToken != "" && srvError.Code == serviceerror.InvalidCredentials {
+				page.Error = "The login expired, log in again."
+			}
+			if page.Error == "" {
+				page.Error = "Logging in failed."
+			}
+			renderOAuthLogin(c, oauthService, serviceerror.StatusOf(err), page)
+			return
+		}
+		if response.MFARequired {
+			page.MFAToken = response.MFAToken
+			renderOAuthLogin(c, oauthService, http.StatusOK, page)
+			return
+		}
+		caller, err := authService.Authenticate(response.AccessToken)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		grantAuthorization(c, oauthService, request.OAuthAuthorize, redirectURI, caller.UserID)
+	}
+}
+
+// renderOAuthLogin shows the login and consent page for the client. The page
+// may not be framed, so other sites can't trick users into submitting it.
+func renderOAuthLogin(c *gin.Context, oauthService internal.OAuthService, status int, page oauthLoginPage) {
+	client, err := oauthService.GetClient(page.Request.ClientID)
+	if err != nil {
+		abortOAuth(c, err)
+		return
+	}
+	page.ClientName = client.Name
+	page.Scopes = strings.Fields(page.Request.Scope)
+	var body bytes.Buffer
+	if err := oauthLoginTemplate.Execute(&body, page); err != nil {
+		serviceerror.AbortOnError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "frame-ancestors 'none'")
+	c.Data(status, "text/html; charset=utf-8", body.Bytes())
+}
@@ -0,0 +1,58 @@
+package httpservice
+
+import (
+	"errors"
+	"net/http"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiscoveryHandler serves the OpenID Provider metadata.
+func DiscoveryHandler(oidcService internal.OIDCService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response, err := oidcService.Discovery()
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// JWKSHandler serves the keys ID tokens are verified with.
+func JWKSHandler(oidcService internal.OIDCService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response, err := oidcService.JWKS()
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// UserInfoHandler answers the claims about the user of the access token, as
+// far as the scopes granted to it allow.
+func UserInfoHandler(oidcService internal.OIDCService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller, ok := callerOf(c)
+		if !ok {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "bearer access token is missing"})
+			return
+		}
+		response, err := oidcService.UserInfo(caller)
+		if err != nil {
+			var srvError *serviceerror.ServiceError
+			if errors.As(err, &srvError) && srvError.Code == serviceerror.Forbidden {
+				c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+			}
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,95 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKSHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	oidcService := mock.NewMockOIDCService(mockCtrl)
+	router := gin.Default()
+	router.GET("/jwks.json", httpservice.JWKSHandler(oidcService))
+
+	oidcService.EXPECT().JWKS().Return(internal.JSONWebKeySet{Keys: []internal.JSONWebKey{
+		{Kty: "OKP", Kid: "kid", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "x"},
+	}}, nil).Times(1)
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/jwks.json", nil)
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"keys":[{"kty":"OKP","kid":"kid","use":"sig","alg":"EdDSA","crv":"Ed25519","x":"x"}]}`, recorder.Body.String())
+}
+
+func TestUserInfoHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	oidcService := mock.NewMockOIDCService(mockCtrl)
+	router := gin.Default()
+	router.GET("/userinfo", httpservice.AuthenticationMiddleware(authService, true), httpservice.UserInfoHandler(oidcService))
+	caller := internal.Caller{UserID: 1, Scopes: []string{"openid", "profile"}}
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		response      string
+		challenge     string
+		setup         func()
+	}{
+		{
+			name:          "answer claims",
+			authorization: "Bearer oat_token",
+			status:        http.StatusOK,
+			response:      `{"sub":"1","name":"test"}`,
+			setup: func() {
+				authService.EXPECT().Authenticate("oat_token").Return(caller, nil).Times(1)
+				oidcService.EXPECT().UserInfo(caller).Return(internal.UserInfo{Sub: "1", Name: "test"}, nil).Times(1)
+			},
+		},
+		{
+			name:          "fail without openid scope",
+			authorization: "Bearer pat_token",
+			status:        http.StatusForbidden,
+			response:      `{"message":"Forbidden : test"}`,
+			challenge:     `Bearer error="insufficient_scope", scope="openid"`,
+			setup: func() {
+				authService.EXPECT().Authenticate("pat_token").Return(internal.Caller{UserID: 1, Scopes: []string{"users:read"}}, nil).Times(1)
+				oidcService.EXPECT().UserInfo(gomock.Any()).Return(internal.UserInfo{}, serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:      "fail without token",
+			status:    http.StatusUnauthorized,
+			response:  `{"message":"bearer access token is missing"}`,
+			challenge: "Bearer",
+			setup:     func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/userinfo", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, recorder.Body.String())
+			assert.Equal(t, test.challenge, recorder.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package httpservice
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	organizationKey    = "organization"
+	organizationHeader = "X-Org-ID"
+)
+
+type CreateOrganization struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// OrganizationMiddleware resolves the organization a request is for. A caller
+// with an access token is in its own organization, only admins may name
+// another in the X-Org-ID header. With anonymous a request without token may
+// name one too, for endpoints like login that come before any token; others
+// refuse it. A request without token naming none is in the default
+// organization, only admins are served across organizations.
+func OrganizationMiddleware(organizationService internal.OrganizationService, anonymous bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller, authenticated := callerOf(c)
+		header := c.GetHeader(organizationHeader)
+		if header == "" {
+			if !caller.Admin {
+				c.Set(organizationKey, caller.OrganizationID)
+			}
+			c.Next()
+			return
+		}
+		if !authenticated && !anonymous {
+			c.Header("WWW-Authenticate", "Bearer")
+			serviceerror.AbortOnError(c, serviceerror.NewServiceError(serviceerror.Unauthenticated,
+				fmt.Errorf("%s needs a bearer access token", organizationHeader)))
+			return
+		}
+		id, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if authenticated && !caller.Admin && uint(id) != caller.OrganizationID {
+			serviceerror.AbortOnError(c, serviceerror.NewServiceError(serviceerror.Forbidden,
+				fmt.Errorf("user %d doesn't belong to organization %d", caller.UserID, id)))
+			return
+		}
+		if id != 0 {
+			if _, err := organizationService.GetOrganization(uint(id)); err != nil {
+				serviceerror.AbortOnError(c, err)
+				return
+			}
+		}
+		c.Set(organizationKey, uint(id))
+		c.Next()
+	}
+}
+
+// organizationOf returns the organization the request is for, ok is false for
+// a request served across organizations.
+func organizationOf(c *gin.Context) (organizationID uint, ok bool) {
+	value, ok := c.Get(organizationKey)
+	if !ok {
+		return organizationID, false
+	}
+	organizationID, ok = value.(uint)
+	return organizationID, ok
+}
+
+// usersIn returns the user service for the organization of the request.
+func usersIn(c *gin.Context, userService internal.UserService) internal.UserService {
+	if organizationID, ok := organizationOf(c); ok {
+		return userService.ForOrganization(organizationID)
+	}
+	return userService
+}
+
+// groupsIn returns the group service for the organization of the request.
+func groupsIn(c *gin.Context, grpService internal.GroupService) internal.GroupService {
+	if organizationID, ok := organizationOf(c); ok {
+		return grpService.ForOrganization(organizationID)
+	}
+	return grpService
+}
+
+// dynamicGroupsIn returns the dynamic group service for the organization of
+// the request.
+func dynamicGroupsIn(c *gin.Context, dynamicGroups internal.DynamicGroupService) internal.DynamicGroupService {
+	if organizationID, ok := organizationOf(c); ok {
+		return dynamicGroups.ForOrganization(organizationID)
+	}
+	return dynamicGroups
+}
+
+// authIn returns the auth service logging in users of the organization of the
+// request.
+func authIn(c *gin.Context, authService internal.AuthService) internal.AuthService {
+	if organizationID, ok := organizationOf(c); ok {
+		return authService.ForOrganization(organizationID)
+	}
+	return authService
+}
+
+// importIn returns the import service for the organization of the request.
+func importIn(c *gin.Context, importService internal.UserImportService) internal.UserImportService {
+	if organizationID, ok := organizationOf(c); ok {
+		return importService.ForOrganization(organizationID)
+	}
+	return importService
+}
+
+// accessRequestsIn returns the access request service for the organization of
+// the request.
+func accessRequestsIn(c *gin.Context, accessService internal.AccessRequestService) internal.AccessRequestService {
+	if organizationID, ok := organizationOf(c); ok {
+		return accessService.ForOrganization(organizationID)
+	}
+	return accessService
+}
+
+// reviewsIn returns the access review service for the organization of the
+// request.
+func reviewsIn(c *gin.Context, reviewService internal.AccessReviewService) internal.AccessReviewService {
+	if organizationID, ok := organizationOf(c); ok {
+		return reviewService.ForOrganization(organizationID)
+	}
+	return reviewService
+}
+
+// UserInOrganization stops requests for a user of another organization before
+// they reach services that don't know about organizations.
+func UserInOrganization(userService internal.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationID, ok := organizationOf(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if _, err := userService.ForOrganization(organizationID).GetUser(uint(id)); err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// CreateOrganizationHandler creates an organization, the caller
+// must be an admin.
+func CreateOrganizationHandler(organizationService internal.OrganizationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request CreateOrganization
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, organizationService.AuthorizeAdmin) {
+			return
+		}
+		response, err := organizationService.CreateOrganization(internal.OrganizationRequest{Name: request.Name})
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// GetOrganizationHandler returns an organization, callers limited to one
+// organization only find their own.
+func GetOrganizationHandler(organizationService internal.OrganizationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if organizationID, ok := organizationOf(c); ok && organizationID != uint(id) {
+			serviceerror.AbortOnError(c, serviceerror.NewServiceError(serviceerror.OrganizationNotFound,
+				fmt.Errorf("organization %d not found", id)))
+			return
+		}
+		response, err := organizationService.GetOrganization(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetOrganizationsHandler lists the organizations, the caller must be an
+// admin.
+func GetOrganizationsHandler(organizationService internal.OrganizationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authorized(c, organizationService.AuthorizeAdmin) {
+			return
+		}
+		page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		perPage, err := strconv.ParseUint(c.DefaultQuery("perPage", "10"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		response, err := organizationService.GetOrganizations(uint(page), uint(perPage))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
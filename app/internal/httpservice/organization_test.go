@@ -0,0 +1,357 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationMiddleware(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	organizationService := mock.NewMockOrganizationService(mockCtrl)
+	userService := mock.NewMockUserService(mockCtrl)
+	scoped := mock.NewMockUserService(mockCtrl)
+	router := gin.Default()
+	router.GET("/users/:id", httpservice.AuthenticationMiddleware(authService, false), httpservice.OrganizationMiddleware(organizationService, false),
+		httpservice.GetUserHandler(userService))
+	router.GET("/login", httpservice.AuthenticationMiddleware(authService, false), httpservice.OrganizationMiddleware(organizationService, true),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name          string
+		path          string
+		organization  string
+		authorization string
+		status        int
+		setup         func()
+	}{
+		{
+			name:   "serve request without token in default organization",
+			status: http.StatusOK,
+			setup: func() {
+				userService.EXPECT().ForOrganization(uint(0)).Return(scoped).Times(1)
+				scoped.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1}, nil).Times(1)
+			},
+		},
+		{
+			name:          "serve admin across organizations",
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 5, Admin: true}, nil).Times(1)
+				userService.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, OrganizationID: 3}, nil).Times(1)
+			},
+		},
+		{
+			name:         "fail on request without token naming organization",
+			organization: "2",
+			status:       http.StatusUnauthorized,
+			setup:        func() {},
+		},
+		{
+			name:         "serve login for named organization",
+			path:         "/login",
+			organization: "2",
+			status:       http.StatusOK,
+			setup: func() {
+				organizationService.EXPECT().GetOrganization(uint(2)).Return(internal.Organization{ID: 2}, nil).Times(1)
+			},
+		},
+		{
+			name:          "serve request for default organization",
+			organization:  "0",
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 5}, nil).Times(1)
+				userService.EXPECT().ForOrganization(uint(0)).Return(scoped).Times(1)
+				scoped.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1}, nil).Times(1)
+			},
+		},
+		{
+			name:          "serve caller in its organization",
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 5, OrganizationID: 2}, nil).Times(1)
+				userService.EXPECT().ForOrganization(uint(2)).Return(scoped).Times(1)
+				scoped.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, OrganizationID: 2}, nil).Times(1)
+			},
+		},
+		{
+			name:          "serve admin in named organization",
+			organization:  "3",
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 5, OrganizationID: 2, Admin: true}, nil).Times(1)
+				organizationService.EXPECT().GetOrganization(uint(3)).Return(internal.Organization{ID: 3}, nil).Times(1)
+				userService.EXPECT().ForOrganization(uint(3)).Return(scoped).Times(1)
+				scoped.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, OrganizationID: 3}, nil).Times(1)
+			},
+		},
+		{
+			name:          "fail on caller naming other organization",
+			organization:  "3",
+			authorization: "Bearer token",
+			status:        http.StatusForbidden,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 5, OrganizationID: 2}, nil).Times(1)
+			},
+		},
+		{
+			name:         "fail on unknown organization",
+			path:         "/login",
+			organization: "4",
+			status:       http.StatusBadRequest,
+			setup: func() {
+				organizationService.EXPECT().GetOrganization(uint(4)).
+					Return(internal.Organization{}, serviceerror.NewServiceError(serviceerror.OrganizationNotFound, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:         "fail on invalid organization",
+			path:         "/login",
+			organization: "acme",
+			status:       http.StatusBadRequest,
+			setup:        func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			path := test.path
+			if path == "" {
+				path = "/users/1"
+			}
+			req, _ := http.NewRequest("GET", path, strings.NewReader(""))
+			if test.organization != "" {
+				req.Header.Set("X-Org-ID", test.organization)
+			}
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestUserInOrganization(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	organizationService := mock.NewMockOrganizationService(mockCtrl)
+	userService := mock.NewMockUserService(mockCtrl)
+	scoped := mock.NewMockUserService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users/:id/unlock", httpservice.OrganizationMiddleware(organizationService, true), httpservice.UserInOrganization(userService),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name         string
+		organization string
+		status       int
+		setup        func()
+	}{
+		{
+			name:   "serve user of default organization",
+			status: http.StatusOK,
+			setup: func() {
+				userService.EXPECT().ForOrganization(uint(0)).Return(scoped).Times(1)
+				scoped.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1}, nil).Times(1)
+			},
+		},
+		{
+			name:         "serve user of organization",
+			organization: "2",
+			status:       http.StatusOK,
+			setup: func() {
+				organizationService.EXPECT().GetOrganization(uint(2)).Return(internal.Organization{ID: 2}, nil).Times(1)
+				userService.EXPECT().ForOrganization(uint(2)).Return(scoped).Times(1)
+				scoped.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, OrganizationID: 2}, nil).Times(1)
+			},
+		},
+		{
+			name:         "fail on user of other organization",
+			organization: "2",
+			status:       http.StatusBadRequest,
+			setup: func() {
+				organizationService.EXPECT().GetOrganization(uint(2)).Return(internal.Organization{ID: 2}, nil).Times(1)
+				userService.EXPECT().ForOrganization(uint(2)).Return(scoped).Times(1)
+				scoped.EXPECT().GetUser(uint(1)).
+					Return(internal.UserResponse{}, serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/users/1/unlock", strings.NewReader(""))
+			if test.organization != "" {
+				req.Header.Set("X-Org-ID", test.organization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestCreateOrganizationHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	organizationService := mock.NewMockOrganizationService(mockCtrl)
+	router := gin.Default()
+	router.POST("/organizations", httpservice.AuthenticationMiddleware(authService, false), httpservice.CreateOrganizationHandler(organizationService))
+
+	tests := []struct {
+		name          string
+		request       string
+		authorization string
+		status        int
+		setup         func()
+	}{
+		{
+			name:          "create organization successfully",
+			authorization: "Bearer token",
+			request:       `{"name":"acme"}`,
+			status:        http.StatusCreated,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				organizationService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+				organizationService.EXPECT().CreateOrganization(internal.OrganizationRequest{Name: "acme"}).Return(internal.Organization{ID: 2, Name: "acme"}, nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on missing name",
+			request: `{}`,
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:          "fail on caller not admin",
+			request:       `{"name":"acme"}`,
+			authorization: "Bearer token",
+			status:        http.StatusForbidden,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 7}, nil).Times(1)
+				organizationService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 7}).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:          "fail on duplicate name",
+			authorization: "Bearer token",
+			request:       `{"name":"acme"}`,
+			status:        http.StatusBadRequest,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				organizationService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+				organizationService.EXPECT().CreateOrganization(internal.OrganizationRequest{Name: "acme"}).
+					Return(internal.Organization{}, serviceerror.NewServiceError(serviceerror.DuplicateOrganization, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/organizations", strings.NewReader(test.request))
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestGetOrganizationsHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	organizationService := mock.NewMockOrganizationService(mockCtrl)
+	router := gin.Default()
+	organization := httpservice.OrganizationMiddleware(organizationService, false)
+	router.GET("/organizations", httpservice.AuthenticationMiddleware(authService, false), organization,
+		httpservice.GetOrganizationsHandler(organizationService))
+	router.GET("/organizations/:id", httpservice.AuthenticationMiddleware(authService, false), organization,
+		httpservice.GetOrganizationHandler(organizationService))
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		status        int
+		setup         func()
+	}{
+		{
+			name:          "get organizations successfully",
+			path:          "/organizations",
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				organizationService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+				organizationService.EXPECT().GetOrganizations(uint(1), uint(10)).Return(internal.OrganizationsResponse{}, nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on organizations without token",
+			path:   "/organizations",
+			status: http.StatusUnauthorized,
+			setup:  func() {},
+		},
+		{
+			name:          "get own organization",
+			path:          "/organizations/2",
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 7, OrganizationID: 2}, nil).Times(1)
+				organizationService.EXPECT().GetOrganization(uint(2)).Return(internal.Organization{ID: 2, Name: "acme"}, nil).Times(1)
+			},
+		},
+		{
+			name:          "fail on other organization",
+			path:          "/organizations/3",
+			authorization: "Bearer token",
+			status:        http.StatusBadRequest,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 7, OrganizationID: 2}, nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on organization without token",
+			path:   "/organizations/3",
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", test.path, strings.NewReader(""))
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
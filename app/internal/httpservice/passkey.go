@@ -0,0 +1,207 @@
+package httpservice
+
+import (
+	"net/http"
+	"strconv"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/webauthn"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type FinishPasskeyRegistration struct {
+	Name       string                `json:"name" validate:"required,max=64"`
+	Credential webauthn.Registration `json:"credential"`
+}
+
+type RenamePasskey struct {
+	Name string `json:"name" validate:"required,max=64"`
+}
+
+type BeginPasskeyLogin struct {
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+// BeginPasskeyRegistrationHandler starts registering a passkey of the user,
+// the caller must be the user or an admin.
+func BeginPasskeyRegistrationHandler(passkeyService internal.PasskeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return passkeyService.AuthorizePasskeys(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := passkeyService.BeginRegistration(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// FinishPasskeyRegistrationHandler stores the passkey of the user, the caller
+// must be the user or an admin.
+func FinishPasskeyRegistrationHandler(passkeyService internal.PasskeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request FinishPasskeyRegistration
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return passkeyService.AuthorizePasskeys(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := passkeyService.FinishRegistration(uint(id), request.Name, request.Credential)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// GetPasskeysHandler lists the passkeys of the user, the caller must be the
+// user or an admin.
+func GetPasskeysHandler(passkeyService internal.PasskeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return passkeyService.AuthorizePasskeys(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := passkeyService.GetPasskeys(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// RenamePasskeyHandler renames a passkey of the user, the caller must be the
+// user or an admin.
+func RenamePasskeyHandler(passkeyService internal.PasskeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		passkeyID, err := strconv.ParseUint(c.Param("passkeyid"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request RenamePasskey
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return passkeyService.AuthorizePasskeys(caller, uint(id))
+		}) {
+			return
+		}
+		err = passkeyService.RenamePasskey(uint(id), uint(passkeyID), request.Name)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+// DeletePasskeyHandler removes a passkey of the user, the caller must be the
+// user or an admin.
+func DeletePasskeyHandler(passkeyService internal.PasskeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		passkeyID, err := strconv.ParseUint(c.Param("passkeyid"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return passkeyService.AuthorizePasskeys(caller, uint(id))
+		}) {
+			return
+		}
+		err = passkeyService.DeletePasskey(uint(id), uint(passkeyID))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+func BeginPasskeyLoginHandler(passkeyService internal.PasskeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request BeginPasskeyLogin
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		response, err := passkeyService.BeginLogin(request.Email)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func FinishPasskeyLoginHandler(passkeyService internal.PasskeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request webauthn.Assertion
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		response, err := passkeyService.FinishLogin(request)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
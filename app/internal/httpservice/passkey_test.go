@@ -0,0 +1,210 @@
+package httpservice_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/webauthn"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	finishPasskeyRegistrationObj = `{"name":"%s","credential":{"id":"abc","rawId":"abc","type":"public-key","response":{"clientDataJSON":"e30","attestationObject":"oA"}}}`
+	passkeyAssertionObj          = `{"id":"abc","rawId":"abc","type":"public-key","response":{"clientDataJSON":"e30","authenticatorData":"AA","signature":"AA"}}`
+)
+
+func TestFinishPasskeyRegistrationHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	passkeyService := mock.NewMockPasskeyService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users/:id/passkeys/register/finish", httpservice.CallerMiddleware(internal.Caller{UserID: 1}), httpservice.FinishPasskeyRegistrationHandler(passkeyService))
+	createdAt := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		request  string
+		status   int
+		response string
+		setup    func()
+	}{
+		{
+			name:     "register successfully",
+			request:  fmt.Sprintf(finishPasskeyRegistrationObj, "laptop"),
+			status:   http.StatusCreated,
+			response: `{"id":1,"name":"laptop","createdAt":"2021-09-01T00:00:00Z"}`,
+			setup: func() {
+				passkeyService.EXPECT().AuthorizePasskeys(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				passkeyService.EXPECT().FinishRegistration(uint(1), "laptop", gomock.Any()).
+					Do(func(_ uint, _ string, registration webauthn.Registration) {
+						assert.Equal(t, "e30", registration.Response.ClientDataJSON)
+					}).Return(internal.PasskeyResponse{ID: 1, Name: "laptop", CreatedAt: createdAt}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on missing name",
+			request:  fmt.Sprintf(finishPasskeyRegistrationObj, ""),
+			status:   http.StatusBadRequest,
+			response: `{"message":"Key: 'FinishPasskeyRegistration.Name' Error:Field validation for 'Name' failed on the 'required' tag"}`,
+			setup:    func() {},
+		},
+		{
+			name:     "fail on rejected credential",
+			request:  fmt.Sprintf(finishPasskeyRegistrationObj, "laptop"),
+			status:   http.StatusBadRequest,
+			response: `{"message":"Invalid Passkey : test"}`,
+			setup: func() {
+				passkeyService.EXPECT().AuthorizePasskeys(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				passkeyService.EXPECT().FinishRegistration(uint(1), "laptop", gomock.Any()).Return(internal.PasskeyResponse{},
+					serviceerror.NewServiceError(serviceerror.InvalidPasskey, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/users/1/passkeys/register/finish", strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, recorder.Body.String())
+		})
+	}
+}
+
+func TestGetPasskeysHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	passkeyService := mock.NewMockPasskeyService(mockCtrl)
+	router := gin.Default()
+	router.GET("/users/:id/passkeys", httpservice.CallerMiddleware(internal.Caller{UserID: 1}), httpservice.GetPasskeysHandler(passkeyService))
+
+	t.Run("list successfully", func(t *testing.T) {
+		passkeyService.EXPECT().AuthorizePasskeys(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+		passkeyService.EXPECT().GetPasskeys(uint(1)).Return(internal.PasskeysResponse{
+			Passkeys: []internal.PasskeyResponse{{ID: 1, Name: "laptop", CreatedAt: time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)}},
+		}, nil).Times(1)
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users/1/passkeys", nil)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `{"passkeys":[{"id":1,"name":"laptop","createdAt":"2021-09-01T00:00:00Z"}]}`, recorder.Body.String())
+	})
+}
+
+func TestDeletePasskeyHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	passkeyService := mock.NewMockPasskeyService(mockCtrl)
+	router := gin.Default()
+	router.DELETE("/users/:id/passkeys/:passkeyid", httpservice.CallerMiddleware(internal.Caller{UserID: 1}), httpservice.DeletePasskeyHandler(passkeyService))
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		setup  func()
+	}{
+		{
+			name:   "revoke successfully",
+			path:   "/users/1/passkeys/2",
+			status: http.StatusOK,
+			setup: func() {
+				passkeyService.EXPECT().AuthorizePasskeys(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				passkeyService.EXPECT().DeletePasskey(uint(1), uint(2)).Return(nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on other user",
+			path:   "/users/1/passkeys/2",
+			status: http.StatusForbidden,
+			setup: func() {
+				passkeyService.EXPECT().AuthorizePasskeys(internal.Caller{UserID: 1}, uint(1)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:   "fail on invalid passkey id",
+			path:   "/users/1/passkeys/abc",
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+		{
+			name:   "fail on unknown passkey",
+			path:   "/users/1/passkeys/3",
+			status: http.StatusBadRequest,
+			setup: func() {
+				passkeyService.EXPECT().AuthorizePasskeys(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				passkeyService.EXPECT().DeletePasskey(uint(1), uint(3)).
+					Return(serviceerror.NewServiceError(serviceerror.PasskeyNotFound, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", test.path, nil)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestFinishPasskeyLoginHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	passkeyService := mock.NewMockPasskeyService(mockCtrl)
+	router := gin.Default()
+	router.POST("/auth/login/passkey/finish", httpservice.FinishPasskeyLoginHandler(passkeyService))
+
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		setup    func()
+	}{
+		{
+			name:     "login successfully",
+			status:   http.StatusOK,
+			response: `{"accessToken":"token","tokenType":"Bearer","expiresIn":3600}`,
+			setup: func() {
+				passkeyService.EXPECT().FinishLogin(gomock.Any()).Return(internal.LoginResponse{
+					AccessToken: "token",
+					TokenType:   "Bearer",
+					ExpiresIn:   3600,
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on rejected assertion",
+			status:   http.StatusUnauthorized,
+			response: `{"message":"Invalid Credentials : test"}`,
+			setup: func() {
+				passkeyService.EXPECT().FinishLogin(gomock.Any()).Return(internal.LoginResponse{},
+					serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/auth/login/passkey/finish", strings.NewReader(passkeyAssertionObj))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, recorder.Body.String())
+		})
+	}
+}
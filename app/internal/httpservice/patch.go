@@ -0,0 +1,71 @@
+package httpservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// bindPatch applies the body to document as a JSON merge patch or a JSON
+// patch, depending on the content type, then decodes and validates the result
+// into patched. A patch touching fields that patched doesn't have is refused.
+func bindPatch(c *gin.Context, document interface{}, patched interface{}) bool {
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return false
+	}
+	original, err := json.Marshal(document)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return false
+	}
+	var result []byte
+	switch c.ContentType() {
+	case MergePatchContentType:
+		result, err = jsonpatch.MergePatch(original, body)
+	case JSONPatchContentType:
+		var patch jsonpatch.Patch
+		patch, err = jsonpatch.DecodePatch(body)
+		if err == nil {
+			result, err = patch.Apply(original)
+		}
+	default:
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+			"message": fmt.Sprintf("content type must be %s or %s", MergePatchContentType, JSONPatchContentType),
+		})
+		return false
+	}
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return false
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(result))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(patched); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return false
+	}
+	v := validator.New()
+	if err := v.Struct(patched); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return false
+	}
+	return true
+}
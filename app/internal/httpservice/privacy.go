@@ -0,0 +1,66 @@
+package httpservice
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EraseUser struct {
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+// ExportUserHandler returns the personal data of a user, the caller must be
+// the user or an admin.
+func ExportUserHandler(privacyService internal.PrivacyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return privacyService.AuthorizeSubject(caller, uint(id))
+		}) {
+			return
+		}
+		response, err := privacyService.Export(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-export.json"`, id))
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// EraseUserHandler irreversibly erases a user, the caller must be the user or
+// an admin.
+func EraseUserHandler(privacyService internal.PrivacyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request EraseUser
+		if !bindOptionalJSON(c, &request) {
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return privacyService.AuthorizeSubject(caller, uint(id))
+		}) {
+			return
+		}
+		err = privacyService.Erase(uint(id), request.Reason)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,144 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportUserHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	privacyService := mock.NewMockPrivacyService(mockCtrl)
+	router := gin.Default()
+	router.GET("/users/:id/export", httpservice.CallerMiddleware(internal.Caller{UserID: 1}), httpservice.ExportUserHandler(privacyService))
+	router.GET("/anonymous/:id/export", httpservice.ExportUserHandler(privacyService))
+
+	t.Run("export successfully", func(t *testing.T) {
+		privacyService.EXPECT().AuthorizeSubject(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+		privacyService.EXPECT().Export(uint(1)).Return(internal.UserExport{
+			ExportedAt:  time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC),
+			Profile:     internal.UserResponse{ID: 1, Name: "test", Email: "test@gmail.com", Status: internal.StatusActive},
+			Memberships: []internal.Membership{},
+			Passkeys:    []internal.PasskeyResponse{},
+			Sessions:    []internal.Session{},
+			AuditEvents: []internal.AuditEvent{},
+		}, nil).Times(1)
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users/1/export", nil)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `attachment; filename="user-1-export.json"`, recorder.Header().Get("Content-Disposition"))
+		assert.Equal(t, `{"exportedAt":"2021-09-01T00:00:00Z","profile":{"id":1,"name":"test","email":"test@gmail.com","emailVerified":false,"status":"active"},`+
+			`"memberships":[],"mfaEnabled":false,"passkeys":[],"sessions":[],"auditEvents":[]}`, recorder.Body.String())
+	})
+
+	t.Run("fail on unknown user", func(t *testing.T) {
+		privacyService.EXPECT().AuthorizeSubject(internal.Caller{UserID: 1}, uint(2)).Return(nil).Times(1)
+		privacyService.EXPECT().Export(uint(2)).Return(internal.UserExport{},
+			serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))).Times(1)
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users/2/export", nil)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("fail on other user", func(t *testing.T) {
+		privacyService.EXPECT().AuthorizeSubject(internal.Caller{UserID: 1}, uint(2)).
+			Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users/2/export", nil)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("fail without token", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/anonymous/1/export", nil)
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+}
+
+func TestEraseUserHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	privacyService := mock.NewMockPrivacyService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users/:id/erase", httpservice.CallerMiddleware(internal.Caller{UserID: 1}), httpservice.EraseUserHandler(privacyService))
+	router.POST("/anonymous/:id/erase", httpservice.EraseUserHandler(privacyService))
+
+	tests := []struct {
+		name    string
+		path    string
+		request string
+		status  int
+		setup   func()
+	}{
+		{
+			name:    "erase with reason",
+			path:    "/users/1/erase",
+			request: `{"reason":"request 42"}`,
+			status:  http.StatusOK,
+			setup: func() {
+				privacyService.EXPECT().AuthorizeSubject(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				privacyService.EXPECT().Erase(uint(1), "request 42").Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "erase without body",
+			path:    "/users/1/erase",
+			request: "",
+			status:  http.StatusOK,
+			setup: func() {
+				privacyService.EXPECT().AuthorizeSubject(internal.Caller{UserID: 1}, uint(1)).Return(nil).Times(1)
+				privacyService.EXPECT().Erase(uint(1), "").Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on other user",
+			path:    "/users/2/erase",
+			request: "",
+			status:  http.StatusForbidden,
+			setup: func() {
+				privacyService.EXPECT().AuthorizeSubject(internal.Caller{UserID: 1}, uint(2)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:    "fail without token",
+			path:    "/anonymous/1/erase",
+			request: "",
+			status:  http.StatusUnauthorized,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on invalid id",
+			path:    "/users/abc/erase",
+			request: "",
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.path, strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
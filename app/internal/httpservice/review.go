@@ -0,0 +1,224 @@
+package httpservice
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CreateReviewCampaign struct {
+	Name            string    `json:"name" validate:"required,max=255"`
+	GroupIDs        []uint    `json:"groupIds" validate:"required,min=1,max=100"`
+	Reviewers       []uint    `json:"reviewers" validate:"max=100"`
+	Deadline        time.Time `json:"deadline" validate:"required"`
+	RevokeUndecided bool      `json:"revokeUndecided"`
+}
+
+type ReviewComment struct {
+	Comment string `json:"comment" validate:"max=255"`
+}
+
+// CreateReviewCampaignHandler starts a campaign, the caller must
+// be an admin.
+func CreateReviewCampaignHandler(reviewService internal.AccessReviewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request CreateReviewCampaign
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		v := validator.New()
+		if err := v.Struct(request); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, reviewService.AuthorizeAdmin) {
+			return
+		}
+		response, err := reviewsIn(c, reviewService).CreateCampaign(internal.ReviewCampaignRequest{
+			Name:            request.Name,
+			GroupIDs:        request.GroupIDs,
+			Reviewers:       request.Reviewers,
+			Deadline:        request.Deadline,
+			RevokeUndecided: request.RevokeUndecided,
+		})
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+func GetReviewCampaignHandler(reviewService internal.AccessReviewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		response, err := reviewsIn(c, reviewService).GetCampaign(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func GetReviewCampaignsHandler(reviewService internal.AccessReviewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		perPage, err := strconv.ParseUint(c.DefaultQuery("perPage", "10"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		response, err := reviewsIn(c, reviewService).GetCampaigns(uint(page), uint(perPage), c.Query("status"))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetReviewItemsHandler lists the items of a campaign, of a reviewer or with a
+// decision when the query names one. Admins list all items, reviewers only
+// their own.
+func GetReviewItemsHandler(reviewService internal.AccessReviewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		perPage, err := strconv.ParseUint(c.DefaultQuery("perPage", "10"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		reviewerID, err := strconv.ParseUint(c.DefaultQuery("reviewerId", "0"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, func(caller internal.Caller) error {
+			return reviewService.AuthorizeItems(caller, uint(reviewerID))
+		}) {
+			return
+		}
+		response, err := reviewsIn(c, reviewService).GetReviewItems(uint(id), uint(page), uint(perPage), internal.ReviewItemsFilter{
+			ReviewerID: uint(reviewerID),
+			Decision:   c.Query("decision"),
+		})
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CertifyReviewItemHandler keeps a membership, the caller must
+// be its reviewer or an admin.
+func CertifyReviewItemHandler(reviewService internal.AccessReviewService) gin.HandlerFunc {
+	return decideReviewItemHandler(reviewService, internal.AccessReviewService.Certify)
+}
+
+// RevokeReviewItemHandler removes a membership, the caller must
+// be its reviewer or an admin.
+func RevokeReviewItemHandler(reviewService internal.AccessReviewService) gin.HandlerFunc {
+	return decideReviewItemHandler(reviewService, internal.AccessReviewService.Revoke)
+}
+
+func decideReviewItemHandler(reviewService internal.AccessReviewService,
+	decide func(reviews internal.AccessReviewService, campaignID uint, itemID uint, reviewer uint, comment string) (internal.ReviewItem, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		itemID, err := strconv.ParseUint(c.Param("itemid"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request ReviewComment
+		if !bindOptionalJSON(c, &request) {
+			return
+		}
+		reviews := reviewsIn(c, reviewService)
+		if !authorized(c, func(caller internal.Caller) error {
+			return reviews.AuthorizeReview(caller, uint(id), uint(itemID))
+		}) {
+			return
+		}
+		caller, _ := callerOf(c)
+		response, err := decide(reviews, uint(id), uint(itemID), caller.UserID, request.Comment)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CloseReviewCampaignHandler closes a campaign before its deadline, the
+// caller must be an admin.
+func CloseReviewCampaignHandler(reviewService internal.AccessReviewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, reviewService.AuthorizeAdmin) {
+			return
+		}
+		err = reviewsIn(c, reviewService).CloseCampaign(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+// ReviewReportHandler downloads the signed report of a campaign, the caller
+// must be an admin.
+func ReviewReportHandler(reviewService internal.AccessReviewService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !authorized(c, reviewService.AuthorizeAdmin) {
+			return
+		}
+		response, err := reviewsIn(c, reviewService).Report(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="review-%d.json"`, id))
+		c.JSON(http.StatusOK, response)
+	}
+}
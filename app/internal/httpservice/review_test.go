@@ -0,0 +1,310 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateReviewCampaignHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	reviewService := mock.NewMockAccessReviewService(mockCtrl)
+	router := gin.Default()
+	router.POST("/reviews", httpservice.AuthenticationMiddleware(authService, false), httpservice.CreateReviewCampaignHandler(reviewService))
+	deadline := time.Date(2030, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		request       string
+		authorization string
+		status        int
+		setup         func()
+	}{
+		{
+			name:          "create campaign successfully",
+			authorization: "Bearer token",
+			request:       `{"name":"q1","groupIds":[1,2],"reviewers":[7],"deadline":"2030-03-31T00:00:00Z","revokeUndecided":true}`,
+			status:        http.StatusCreated,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				reviewService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+				reviewService.EXPECT().CreateCampaign(internal.ReviewCampaignRequest{Name: "q1", GroupIDs: []uint{1, 2}, Reviewers: []uint{7}, Deadline: deadline, RevokeUndecided: true}).
+					Return(internal.ReviewCampaign{ID: 4}, nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on missing groups",
+			request: `{"name":"q1","groupIds":[],"deadline":"2030-03-31T00:00:00Z"}`,
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:          "fail on caller not admin",
+			request:       `{"name":"q1","groupIds":[1],"deadline":"2030-03-31T00:00:00Z"}`,
+			authorization: "Bearer token",
+			status:        http.StatusForbidden,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 7}, nil).Times(1)
+				reviewService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 7}).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:          "fail on dynamic group",
+			authorization: "Bearer token",
+			request:       `{"name":"q1","groupIds":[1],"deadline":"2030-03-31T00:00:00Z"}`,
+			status:        http.StatusBadRequest,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				reviewService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+				reviewService.EXPECT().CreateCampaign(gomock.Any()).
+					Return(internal.ReviewCampaign{}, serviceerror.NewServiceError(serviceerror.InvalidReviewRequest, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/reviews", strings.NewReader(test.request))
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestGetReviewCampaignsHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	reviewService := mock.NewMockAccessReviewService(mockCtrl)
+	router := gin.Default()
+	router.GET("/reviews", httpservice.GetReviewCampaignsHandler(reviewService))
+	router.GET("/reviews/:id", httpservice.GetReviewCampaignHandler(reviewService))
+	caller := internal.Caller{UserID: 7}
+	router.GET("/reviews/:id/items", httpservice.CallerMiddleware(caller), httpservice.GetReviewItemsHandler(reviewService))
+	router.GET("/reviews/:id/report", httpservice.CallerMiddleware(caller), httpservice.ReviewReportHandler(reviewService))
+	router.GET("/reviews/anonymous/:id/items", httpservice.GetReviewItemsHandler(reviewService))
+	router.GET("/reviews/anonymous/:id/report", httpservice.ReviewReportHandler(reviewService))
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		setup  func()
+	}{
+		{
+			name:   "list open campaigns",
+			query:  "?status=open",
+			status: http.StatusOK,
+			setup: func() {
+				reviewService.EXPECT().GetCampaigns(uint(1), uint(10), internal.CampaignOpen).Return(internal.ReviewCampaignsResponse{}, nil).Times(1)
+			},
+		},
+		{
+			name:   "get campaign",
+			query:  "/4",
+			status: http.StatusOK,
+			setup: func() {
+				reviewService.EXPECT().GetCampaign(uint(4)).Return(internal.ReviewCampaign{ID: 4}, nil).Times(1)
+			},
+		},
+		{
+			name:   "list pending items of reviewer",
+			query:  "/4/items?reviewerId=7&decision=pending",
+			status: http.StatusOK,
+			setup: func() {
+				reviewService.EXPECT().AuthorizeItems(caller, uint(7)).Return(nil).Times(1)
+				reviewService.EXPECT().GetReviewItems(uint(4), uint(1), uint(10), internal.ReviewItemsFilter{ReviewerID: 7, Decision: internal.ReviewPending}).
+					Return(internal.ReviewItemsResponse{}, nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on items of other reviewers",
+			query:  "/4/items",
+			status: http.StatusForbidden,
+			setup: func() {
+				reviewService.EXPECT().AuthorizeItems(caller, uint(0)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:   "fail on items without token",
+			query:  "/anonymous/4/items?reviewerId=7",
+			status: http.StatusUnauthorized,
+			setup:  func() {},
+		},
+		{
+			name:   "fail on invalid reviewer",
+			query:  "/4/items?reviewerId=x",
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+		{
+			name:   "download report",
+			query:  "/4/report",
+			status: http.StatusOK,
+			setup: func() {
+				reviewService.EXPECT().AuthorizeAdmin(caller).Return(nil).Times(1)
+				reviewService.EXPECT().Report(uint(4)).Return(internal.SignedReviewReport{Report: []byte(`{}`), KeyID: "kid", Algorithm: "EdDSA"}, nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on report for non admin",
+			query:  "/4/report",
+			status: http.StatusForbidden,
+			setup: func() {
+				reviewService.EXPECT().AuthorizeAdmin(caller).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:   "fail on report without token",
+			query:  "/anonymous/4/report",
+			status: http.StatusUnauthorized,
+			setup:  func() {},
+		},
+		{
+			name:   "fail on unknown campaign",
+			query:  "/5/report",
+			status: http.StatusBadRequest,
+			setup: func() {
+				reviewService.EXPECT().AuthorizeAdmin(caller).Return(nil).Times(1)
+				reviewService.EXPECT().Report(uint(5)).
+					Return(internal.SignedReviewReport{}, serviceerror.NewServiceError(serviceerror.ReviewNotFound, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/reviews"+test.query, nil)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestDecideReviewItemHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	reviewService := mock.NewMockAccessReviewService(mockCtrl)
+	router := gin.Default()
+	router.POST("/:id/items/:itemid/certify", httpservice.AuthenticationMiddleware(authService, false), httpservice.CertifyReviewItemHandler(reviewService))
+	router.POST("/:id/items/:itemid/revoke", httpservice.AuthenticationMiddleware(authService, false), httpservice.RevokeReviewItemHandler(reviewService))
+	router.POST("/:id/close", httpservice.AuthenticationMiddleware(authService, false), httpservice.CloseReviewCampaignHandler(reviewService))
+	caller := internal.Caller{UserID: 7}
+
+	tests := []struct {
+		name          string
+		path          string
+		request       string
+		authorization string
+		status        int
+		setup         func()
+	}{
+		{
+			name:          "certify without body",
+			authorization: "Bearer token",
+			path:          "/4/items/5/certify",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				reviewService.EXPECT().AuthorizeReview(caller, uint(4), uint(5)).Return(nil).Times(1)
+				reviewService.EXPECT().Certify(uint(4), uint(5), uint(7), "").Return(internal.ReviewItem{ID: 5, Decision: internal.ReviewCertified}, nil).Times(1)
+			},
+		},
+		{
+			name:          "revoke as reviewer",
+			path:          "/4/items/5/revoke",
+			request:       `{"comment":"left team"}`,
+			authorization: "Bearer token",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				reviewService.EXPECT().AuthorizeReview(caller, uint(4), uint(5)).Return(nil).Times(1)
+				reviewService.EXPECT().Revoke(uint(4), uint(5), uint(7), "left team").Return(internal.ReviewItem{ID: 5, Decision: internal.ReviewRevoked}, nil).Times(1)
+			},
+		},
+		{
+			name:          "fail on caller not reviewer",
+			path:          "/4/items/5/certify",
+			authorization: "Bearer token",
+			status:        http.StatusForbidden,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				reviewService.EXPECT().AuthorizeReview(caller, uint(4), uint(5)).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:          "fail on decided item",
+			authorization: "Bearer token",
+			path:          "/4/items/5/revoke",
+			status:        http.StatusConflict,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(caller, nil).Times(1)
+				reviewService.EXPECT().AuthorizeReview(caller, uint(4), uint(5)).Return(nil).Times(1)
+				reviewService.EXPECT().Revoke(uint(4), uint(5), uint(7), "").
+					Return(internal.ReviewItem{}, serviceerror.NewServiceError(serviceerror.ReviewDecided, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:          "close campaign",
+			authorization: "Bearer token",
+			path:          "/4/close",
+			status:        http.StatusOK,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				reviewService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+				reviewService.EXPECT().CloseCampaign(uint(4)).Return(nil).Times(1)
+			},
+		},
+		{
+			name:   "fail without token",
+			path:   "/4/close",
+			status: http.StatusUnauthorized,
+			setup:  func() {},
+		},
+		{
+			name:          "fail on closed campaign",
+			authorization: "Bearer token",
+			path:          "/4/close",
+			status:        http.StatusConflict,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				reviewService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+				reviewService.EXPECT().CloseCampaign(uint(4)).Return(serviceerror.NewServiceError(serviceerror.ReviewDecided, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.path, strings.NewReader(test.request))
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package httpservice
+
+import (
+	"net/http"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSigningKeysHandler lists the signing keys without their private keys, the
+// caller must be an admin.
+func GetSigningKeysHandler(keyManager internal.KeyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authorized(c, keyManager.AuthorizeAdmin) {
+			return
+		}
+		response, err := keyManager.GetKeys()
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// RotateSigningKeyHandler makes a new key sign ahead of schedule, the
+// caller must be an admin.
+func RotateSigningKeyHandler(keyManager internal.KeyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authorized(c, keyManager.AuthorizeAdmin) {
+			return
+		}
+		response, err := keyManager.Rotate()
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, response)
+	}
+}
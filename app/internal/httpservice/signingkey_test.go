@@ -0,0 +1,107 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigningKeyHandlers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	authService := mock.NewMockAuthService(mockCtrl)
+	keyManager := mock.NewMockKeyManager(mockCtrl)
+	router := gin.Default()
+	authenticate := httpservice.AuthenticationMiddleware(authService, false)
+	router.GET("/signingKeys", authenticate, httpservice.GetSigningKeysHandler(keyManager))
+	router.POST("/signingKeys/rotate", authenticate, httpservice.RotateSigningKeyHandler(keyManager))
+	createdAt := time.Date(2030, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		status        int
+		response      string
+		setup         func()
+	}{
+		{
+			name:          "list keys",
+			authorization: "Bearer token",
+			method:        "GET",
+			path:          "/signingKeys",
+			status:        http.StatusOK,
+			response:      `{"keys":[{"id":"kid","algorithm":"RS256","status":"active","createdAt":"2030-03-31T00:00:00Z"}]}`,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				keyManager.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+				keyManager.EXPECT().GetKeys().Return(internal.SigningKeysResponse{Keys: []internal.SigningKeyInfo{
+					{ID: "kid", Algorithm: "RS256", Status: internal.SigningKeyActive, CreatedAt: createdAt},
+				}}, nil).Times(1)
+			},
+		},
+		{
+			name:          "rotate key",
+			method:        "POST",
+			path:          "/signingKeys/rotate",
+			authorization: "Bearer token",
+			status:        http.StatusCreated,
+			response:      `{"id":"new","algorithm":"EdDSA","status":"active","createdAt":"2030-03-31T00:00:00Z"}`,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				keyManager.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+				keyManager.EXPECT().Rotate().Return(internal.SigningKeyInfo{ID: "new", Algorithm: "EdDSA", Status: internal.SigningKeyActive, CreatedAt: createdAt}, nil).Times(1)
+			},
+		},
+		{
+			name:          "fail on caller not admin",
+			method:        "POST",
+			path:          "/signingKeys/rotate",
+			authorization: "Bearer token",
+			status:        http.StatusForbidden,
+			response:      `{"message":"Forbidden : test"}`,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 7}, nil).Times(1)
+				keyManager.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 7}).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:          "fail on rotation error",
+			authorization: "Bearer token",
+			method:        "POST",
+			path:          "/signingKeys/rotate",
+			status:        http.StatusInternalServerError,
+			response:      `{"message":"test"}`,
+			setup: func() {
+				authService.EXPECT().Authenticate("token").Return(internal.Caller{UserID: 1, Admin: true}, nil).Times(1)
+				keyManager.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}).Return(nil).Times(1)
+				keyManager.EXPECT().Rotate().Return(internal.SigningKeyInfo{}, errors.New("test")).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(test.method, test.path, nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package httpservice
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type SuspendUser struct {
+	Reason string     `json:"reason" validate:"max=255"`
+	Until  *time.Time `json:"until"`
+}
+
+type ChangeUserStatus struct {
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+// SuspendUserHandler suspends the user, the caller must be an admin.
+func SuspendUserHandler(statusService internal.UserStatusService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request SuspendUser
+		if !bindOptionalJSON(c, &request) {
+			return
+		}
+		if !authorized(c, statusService.AuthorizeAdmin) {
+			return
+		}
+		err = statusService.Suspend(uint(id), request.Reason, request.Until)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+// ReactivateUserHandler reactivates the user, the caller must be an admin.
+func ReactivateUserHandler(statusService internal.UserStatusService) gin.HandlerFunc {
+	return changeUserStatusHandler(statusService, statusService.Reactivate)
+}
+
+// DisableUserHandler disables the user, the caller must be an admin.
+func DisableUserHandler(statusService internal.UserStatusService) gin.HandlerFunc {
+	return changeUserStatusHandler(statusService, statusService.Disable)
+}
+
+func changeUserStatusHandler(statusService internal.UserStatusService, change func(id uint, reason string) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var request ChangeUserStatus
+		if !bindOptionalJSON(c, &request) {
+			return
+		}
+		if !authorized(c, statusService.AuthorizeAdmin) {
+			return
+		}
+		err = change(uint(id), request.Reason)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+// bindOptionalJSON binds and validates the body when there is one, an empty
+// body leaves request at its zero value.
+func bindOptionalJSON(c *gin.Context, request interface{}) bool {
+	if c.Request.ContentLength == 0 {
+		return true
+	}
+	if err := c.ShouldBindJSON(request); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return false
+	}
+	v := validator.New()
+	if err := v.Struct(request); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return false
+	}
+	return true
+}
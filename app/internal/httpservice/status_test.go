@@ -0,0 +1,154 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuspendUserHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	statusService := mock.NewMockUserStatusService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users/:id/suspend", httpservice.CallerMiddleware(internal.Caller{UserID: 2, Admin: true}), httpservice.SuspendUserHandler(statusService))
+	until := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		path    string
+		request string
+		status  int
+		setup   func()
+	}{
+		{
+			name:    "suspend with reason and end",
+			path:    "/users/1/suspend",
+			request: `{"reason":"abuse","until":"2030-01-01T00:00:00Z"}`,
+			status:  http.StatusOK,
+			setup: func() {
+				statusService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 2, Admin: true}).Return(nil).Times(1)
+				statusService.EXPECT().Suspend(uint(1), "abuse", &until).Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "suspend without body",
+			path:    "/users/1/suspend",
+			request: "",
+			status:  http.StatusOK,
+			setup: func() {
+				statusService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 2, Admin: true}).Return(nil).Times(1)
+				statusService.EXPECT().Suspend(uint(1), "", nil).Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on invalid end",
+			path:    "/users/1/suspend",
+			request: `{"until":"tomorrow"}`,
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on invalid id",
+			path:    "/users/abc/suspend",
+			request: "",
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+		{
+			name:    "fail on disallowed transition",
+			path:    "/users/1/suspend",
+			request: "",
+			status:  http.StatusConflict,
+			setup: func() {
+				statusService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 2, Admin: true}).Return(nil).Times(1)
+				statusService.EXPECT().Suspend(uint(1), "", nil).
+					Return(serviceerror.NewServiceError(serviceerror.InvalidStatusTransition, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.path, strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
+func TestChangeUserStatusHandlers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	statusService := mock.NewMockUserStatusService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users/:id/reactivate", httpservice.CallerMiddleware(internal.Caller{UserID: 2, Admin: true}), httpservice.ReactivateUserHandler(statusService))
+	router.POST("/users/:id/disable", httpservice.CallerMiddleware(internal.Caller{UserID: 2, Admin: true}), httpservice.DisableUserHandler(statusService))
+
+	tests := []struct {
+		name    string
+		path    string
+		request string
+		status  int
+		setup   func()
+	}{
+		{
+			name:    "reactivate successfully",
+			path:    "/users/1/reactivate",
+			request: `{"reason":"appeal accepted"}`,
+			status:  http.StatusOK,
+			setup: func() {
+				statusService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 2, Admin: true}).Return(nil).Times(1)
+				statusService.EXPECT().Reactivate(uint(1), "appeal accepted").Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "disable successfully",
+			path:    "/users/1/disable",
+			request: "",
+			status:  http.StatusOK,
+			setup: func() {
+				statusService.EXPECT().AuthorizeAdmin(internal.Caller{UserID: 2, Admin: true}).Return(nil).Times(1)
+				statusService.EXPECT().Disable(uint(1), "").Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "fail on caller who isn't admin",
+			path:    "/users/1/disable",
+			request: "",
+			status:  http.StatusForbidden,
+			setup: func() {
+				statusService.EXPECT().AuthorizeAdmin(gomock.Any()).
+					Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+			},
+		},
+		{
+			name:    "fail on long reason",
+			path:    "/users/1/disable",
+			request: `{"reason":"` + strings.Repeat("a", 256) + `"}`,
+			status:  http.StatusBadRequest,
+			setup:   func() {},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.path, strings.NewReader(test.request))
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package httpservice
 
 import (
+	"fmt"
 	"net/http"
+	"reflect"
 	"strconv"
 	"usermanagement/app/internal"
 	"usermanagement/app/internal/serviceerror"
@@ -10,15 +12,31 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+// CreateUser creates a person or, with type service, a service account
+// without password.
 type CreateUser struct {
-	Name     string `json:"name" validate:"required"`
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Name       string                 `json:"name" validate:"required"`
+	Email      string                 `json:"email" validate:"required,email"`
+	Password   string                 `json:"password" validate:"required_unless=Type service,omitempty,min=6"`
+	Status     string                 `json:"status" validate:"omitempty,oneof=active pending"`
+	Type       string                 `json:"type" validate:"omitempty,oneof=person service"`
+	Attributes map[string]interface{} `json:"attributes"`
 }
 
+// UpdateUser changes the given fields, attributes are merged into the user's
+// attributes and a null attribute is removed.
 type UpdateUser struct {
-	Name  string `json:"name" validate:"omitempty"`
-	Email string `json:"email" validate:"omitempty,email"`
+	Name       string                 `json:"name" validate:"omitempty"`
+	Email      string                 `json:"email" validate:"omitempty,email"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// PatchUser is the part of a user PATCH applies to, name and email can't be
+// cleared while null removes attributes.
+type PatchUser struct {
+	Name       string                 `json:"name" validate:"required"`
+	Email      string                 `json:"email" validate:"required,email"`
+	Attributes map[string]interface{} `json:"attributes"`
 }
 
 type CreatePassword struct {
@@ -28,9 +46,12 @@ type CreatePassword struct {
 func CreateUserHandler(userService internal.UserService) gin.HandlerFunc {
 	mapCreateUserRequest := func(request CreateUser) internal.UserRequest {
 		return internal.UserRequest{
-			Name:     request.Name,
-			Email:    request.Email,
-			Password: request.Password,
+			Name:       request.Name,
+			Email:      request.Email,
+			Password:   request.Password,
+			Status:     request.Status,
+			Type:       request.Type,
+			Attributes: request.Attributes,
 		}
 	}
 	return func(c *gin.Context) {
@@ -44,21 +65,45 @@ func CreateUserHandler(userService internal.UserService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		response, err := userService.CreateUser(mapCreateUserRequest(request))
+		response, err := usersIn(c, userService).CreateUser(mapCreateUserRequest(request))
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
 		}
+		c.Header("ETag", etag(response.Version))
 		c.JSON(http.StatusCreated, response)
 	}
 }
 
+func GetUserHandler(userService internal.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		response, err := usersIn(c, userService).GetUser(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		if notModified(c, etag(response.Version)) {
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// UpdateUserHandler updates the user, only while it is at the version given
+// in If-Match if there is one, and returns the new entity tag.
 func UpdateUserHandler(userService internal.UserService) gin.HandlerFunc {
-	mapUpdateUserRequest := func(id uint, request UpdateUser) internal.UpdateUserRequest {
+	mapUpdateUserRequest := func(id uint, version uint, request UpdateUser) internal.UpdateUserRequest {
 		return internal.UpdateUserRequest{
-			ID:    id,
-			Name:  request.Name,
-			Email: request.Email,
+			ID:         id,
+			Name:       request.Name,
+			Email:      request.Email,
+			Attributes: request.Attributes,
+			Version:    version,
 		}
 	}
 	return func(c *gin.Context) {
@@ -67,6 +112,10 @@ func UpdateUserHandler(userService internal.UserService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
+		version, ok := ifMatch(c)
+		if !ok {
+			return
+		}
 		var request UpdateUser
 		if err := c.ShouldBindJSON(&request); err != nil {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
@@ -77,15 +126,100 @@ func UpdateUserHandler(userService internal.UserService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		err = userService.UpdateUser(mapUpdateUserRequest(uint(id), request))
+		users := usersIn(c, userService)
+		err = users.UpdateUser(mapUpdateUserRequest(uint(id), version, request))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		user, err := users.GetUser(uint(id))
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
 		}
+		c.Header("ETag", etag(user.Version))
 		c.Status(http.StatusOK)
 	}
 }
 
+// PatchUserHandler applies a JSON merge patch or JSON patch to the user and
+// returns the user after the update. A changed email stays pending until it
+// is confirmed, as with UpdateUserHandler. The update only applies to the
+// version the patch was applied to, which must match If-Match if given.
+func PatchUserHandler(userService internal.UserService) gin.HandlerFunc {
+	mapPatchUserRequest := func(user internal.UserResponse, patched PatchUser) internal.UpdateUserRequest {
+		request := internal.UpdateUserRequest{ID: user.ID, Version: user.Version}
+		if patched.Name != user.Name {
+			request.Name = patched.Name
+		}
+		if patched.Email != user.Email {
+			request.Email = patched.Email
+		}
+		attributes := make(map[string]interface{})
+		for name, value := range patched.Attributes {
+			if current, ok := user.Attributes[name]; !ok || !reflect.DeepEqual(current, value) {
+				attributes[name] = value
+			}
+		}
+		for name := range user.Attributes {
+			if _, ok := patched.Attributes[name]; !ok {
+				attributes[name] = nil
+			}
+		}
+		if len(attributes) > 0 {
+			request.Attributes = attributes
+		}
+		return request
+	}
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		version, ok := ifMatch(c)
+		if !ok {
+			return
+		}
+		users := usersIn(c, userService)
+		user, err := users.GetUser(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		if version != 0 && version != user.Version {
+			c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"message": fmt.Sprintf("user %d is no longer at version %d", id, version)})
+			return
+		}
+		document := PatchUser{Name: user.Name, Email: user.Email, Attributes: user.Attributes}
+		if document.Attributes == nil {
+			document.Attributes = map[string]interface{}{}
+		}
+		var patched PatchUser
+		if !bindPatch(c, document, &patched) {
+			return
+		}
+		request := mapPatchUserRequest(user, patched)
+		if request.Name == "" && request.Email == "" && len(request.Attributes) == 0 {
+			c.Header("ETag", etag(user.Version))
+			c.JSON(http.StatusOK, user)
+			return
+		}
+		err = users.UpdateUser(request)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		user, err = users.GetUser(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Header("ETag", etag(user.Version))
+		c.JSON(http.StatusOK, user)
+	}
+}
+
 func DeleteUserHandler(userService internal.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -93,7 +227,27 @@ func DeleteUserHandler(userService internal.UserService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		err = userService.DeleteUser(uint(id))
+		version, ok := ifMatch(c)
+		if !ok {
+			return
+		}
+		err = usersIn(c, userService).DeleteUser(uint(id), version)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+func RestoreUserHandler(userService internal.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		err = usersIn(c, userService).RestoreUser(uint(id))
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
@@ -114,12 +268,36 @@ func GetUsersHandler(userService internal.UserService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		response, err := userService.GetUsers(uint(page), uint(perPage))
+		deleted, err := strconv.ParseBool(c.DefaultQuery("deleted", "false"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		filter := internal.UsersFilter{
+			Status:  c.Query("status"),
+			Type:    c.Query("type"),
+			Deleted: deleted,
+		}
+		if err := validator.New().Var(filter.Status, "omitempty,oneof=active pending suspended disabled"); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if err := validator.New().Var(filter.Type, "omitempty,oneof=person service"); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if attributes := c.QueryMap("attributes"); len(attributes) > 0 {
+			filter.Attributes = make(map[string]interface{}, len(attributes))
+			for name, value := range attributes {
+				filter.Attributes[name] = value
+			}
+		}
+		response, err := usersIn(c, userService).GetUsers(uint(page), uint(perPage), filter)
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, response)
+		jsonWithETag(c, response)
 	}
 }
 
@@ -140,7 +318,7 @@ func ChangePasswordHandler(userService internal.UserService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
-		err = userService.ChangePassword(uint(id), request.Password)
+		err = usersIn(c, userService).ChangePassword(uint(id), request.Password)
 		if err != nil {
 			serviceerror.AbortOnError(c, err)
 			return
@@ -148,3 +326,19 @@ func ChangePasswordHandler(userService internal.UserService) gin.HandlerFunc {
 		c.Status(http.StatusOK)
 	}
 }
+
+func ResendVerificationHandler(userService internal.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		err = usersIn(c, userService).ResendVerification(uint(id))
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.Status(http.StatusAccepted)
+	}
+}
@@ -20,9 +20,9 @@ import (
 
 const (
 	createUserObj     = `{"name":"%s","email":"%s","password":"%s"}`
-	responseUserObj   = `{"id":%d,"name":"%s","email":"%s"}`
+	responseUserObj   = `{"id":%d,"name":"%s","email":"%s","emailVerified":false,"status":"active"}`
 	updateUserObj     = `{"name":"%s","email":"%s"}`
-	responseUsersObj  = `{"users":[{"id":%d,"name":"%s","email":"%s"}],"total":%d,"page":%d,"perPage":%d}`
+	responseUsersObj  = `{"users":[{"id":%d,"name":"%s","email":"%s","emailVerified":false,"status":"active"}],"total":%d,"page":%d,"perPage":%d}`
 	changePasswordObj = `{"password":"%s"}`
 )
 
@@ -52,9 +52,10 @@ func TestCreateUserHandler(t *testing.T) {
 					Password: "12345678",
 				}
 				userService.EXPECT().CreateUser(request).Return(internal.UserResponse{
-					ID:    1,
-					Name:  "test",
-					Email: "test@gmail.com",
+					ID:     1,
+					Name:   "test",
+					Email:  "test@gmail.com",
+					Status: internal.StatusActive,
 				}, nil).Times(1)
 			},
 		},
@@ -86,6 +87,29 @@ func TestCreateUserHandler(t *testing.T) {
 			response: `{"message":"Key: 'CreateUser.Password' Error:Field validation for 'Password' failed on the 'min' tag"}`,
 			setup:    func() {},
 		},
+		{
+			name:     "create service account without password",
+			request:  `{"name":"ci","email":"ci@example.com","type":"service"}`,
+			status:   http.StatusCreated,
+			response: `{"id":2,"type":"service","name":"ci","email":"ci@example.com","emailVerified":false,"status":"active"}`,
+			setup: func() {
+				request := internal.UserRequest{Name: "ci", Email: "ci@example.com", Type: internal.UserServiceAccount}
+				userService.EXPECT().CreateUser(request).Return(internal.UserResponse{
+					ID:     2,
+					Type:   internal.UserServiceAccount,
+					Name:   "ci",
+					Email:  "ci@example.com",
+					Status: internal.StatusActive,
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on missing password of person",
+			request:  `{"name":"test","email":"test@gmail.com"}`,
+			status:   http.StatusBadRequest,
+			response: `{"message":"Key: 'CreateUser.Password' Error:Field validation for 'Password' failed on the 'required_unless' tag"}`,
+			setup:    func() {},
+		},
 		{
 			name:     "fail on service error",
 			request:  fmt.Sprintf(createUserObj, "test", "test@gmail.com", "12345678"),
@@ -154,6 +178,7 @@ func TestUpdateUserHandler(t *testing.T) {
 					Email: "test@gmail.com",
 				}
 				userService.EXPECT().UpdateUser(request).Return(nil).Times(1)
+				userService.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Version: 2}, nil).Times(1)
 			},
 		},
 		{
@@ -178,6 +203,7 @@ func TestUpdateUserHandler(t *testing.T) {
 					Name: "test",
 				}
 				userService.EXPECT().UpdateUser(request).Return(nil).Times(1)
+				userService.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Version: 2}, nil).Times(1)
 			},
 		},
 		{
@@ -190,6 +216,7 @@ func TestUpdateUserHandler(t *testing.T) {
 					Email: "test@gmail.com",
 				}
 				userService.EXPECT().UpdateUser(request).Return(nil).Times(1)
+				userService.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Version: 2}, nil).Times(1)
 			},
 		},
 		{
@@ -229,6 +256,134 @@ func TestUpdateUserHandler(t *testing.T) {
 	}
 }
 
+func TestPatchUserHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	userService := mock.NewMockUserService(mockCtrl)
+	router := gin.Default()
+	router.PATCH("/:id", httpservice.PatchUserHandler(userService))
+	user := internal.UserResponse{
+		ID: 1, Name: "test", Email: "test@gmail.com", Status: internal.StatusActive,
+		Attributes: map[string]interface{}{"department": "eng", "level": float64(3)},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		request     string
+		status      int
+		response    string
+		setup       func()
+	}{
+		{
+			name:        "merge patch name and clear attribute",
+			contentType: httpservice.MergePatchContentType,
+			request:     `{"name":"new","attributes":{"level":null}}`,
+			status:      http.StatusOK,
+			response:    `{"id":1,"name":"new","email":"test@gmail.com","emailVerified":false,"status":"active","attributes":{"department":"eng"}}`,
+			setup: func() {
+				userService.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+				userService.EXPECT().UpdateUser(internal.UpdateUserRequest{
+					ID: 1, Name: "new", Attributes: map[string]interface{}{"level": nil},
+				}).Return(nil).Times(1)
+				userService.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{
+					ID: 1, Name: "new", Email: "test@gmail.com", Status: internal.StatusActive,
+					Attributes: map[string]interface{}{"department": "eng"},
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:        "json patch email and attribute",
+			contentType: httpservice.JSONPatchContentType,
+			request:     `[{"op":"test","path":"/email","value":"test@gmail.com"},{"op":"replace","path":"/email","value":"new@gmail.com"},{"op":"add","path":"/attributes/department","value":"ops"}]`,
+			status:      http.StatusOK,
+			response:    fmt.Sprintf(responseUserObj, 1, "test", "test@gmail.com"),
+			setup: func() {
+				userService.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+				userService.EXPECT().UpdateUser(internal.UpdateUserRequest{
+					ID: 1, Email: "new@gmail.com", Attributes: map[string]interface{}{"department": "ops"},
+				}).Return(nil).Times(1)
+				userService.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{
+					ID: 1, Name: "test", Email: "test@gmail.com", Status: internal.StatusActive,
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:        "unchanged user without update",
+			contentType: httpservice.MergePatchContentType,
+			request:     `{"name":"test"}`,
+			status:      http.StatusOK,
+			response:    `{"id":1,"name":"test","email":"test@gmail.com","emailVerified":false,"status":"active","attributes":{"department":"eng","level":3}}`,
+			setup: func() {
+				userService.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+			},
+		},
+		{
+			name:        "fail on clearing name",
+			contentType: httpservice.MergePatchContentType,
+			request:     `{"name":null}`,
+			status:      http.StatusBadRequest,
+			response:    `{"message":"Key: 'PatchUser.Name' Error:Field validation for 'Name' failed on the 'required' tag"}`,
+			setup: func() {
+				userService.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+			},
+		},
+		{
+			name:        "fail on unknown field",
+			contentType: httpservice.MergePatchContentType,
+			request:     `{"status":"disabled"}`,
+			status:      http.StatusBadRequest,
+			response:    `{"message":"json: unknown field \"status\""}`,
+			setup: func() {
+				userService.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+			},
+		},
+		{
+			name:        "fail on failed test",
+			contentType: httpservice.JSONPatchContentType,
+			request:     `[{"op":"test","path":"/name","value":"other"},{"op":"replace","path":"/name","value":"new"}]`,
+			status:      http.StatusConflict,
+			response:    `{"message":"testing value /name failed: test failed"}`,
+			setup: func() {
+				userService.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+			},
+		},
+		{
+			name:        "fail on plain json",
+			contentType: "application/json",
+			request:     `{"name":"new"}`,
+			status:      http.StatusUnsupportedMediaType,
+			response:    `{"message":"content type must be application/merge-patch+json or application/json-patch+json"}`,
+			setup: func() {
+				userService.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+			},
+		},
+		{
+			name:        "fail on unknown user",
+			contentType: httpservice.MergePatchContentType,
+			request:     `{"name":"new"}`,
+			status:      http.StatusBadRequest,
+			response:    `{"message":"UserNotFound : test"}`,
+			setup: func() {
+				userService.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{}, serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", "/1", strings.NewReader(test.request))
+			req.Header.Set("Content-Type", test.contentType)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			response, err := ioutil.ReadAll(recorder.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.response, string(response))
+		})
+	}
+}
+
 func TestDeleteUserHandler(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -245,21 +400,21 @@ func TestDeleteUserHandler(t *testing.T) {
 			name:   "Delete user successfully",
 			status: http.StatusOK,
 			setup: func() {
-				userService.EXPECT().DeleteUser(uint(1)).Return(nil).Times(1)
+				userService.EXPECT().DeleteUser(uint(1), uint(0)).Return(nil).Times(1)
 			},
 		},
 		{
 			name:   "fail on service error",
 			status: http.StatusBadRequest,
 			setup: func() {
-				userService.EXPECT().DeleteUser(uint(1)).Return(serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("test"))).Times(1)
+				userService.EXPECT().DeleteUser(uint(1), uint(0)).Return(serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("test"))).Times(1)
 			},
 		},
 		{
 			name:   "fail on unknown error",
 			status: http.StatusInternalServerError,
 			setup: func() {
-				userService.EXPECT().DeleteUser(uint(1)).Return(errors.New("test")).Times(1)
+				userService.EXPECT().DeleteUser(uint(1), uint(0)).Return(errors.New("test")).Times(1)
 			},
 		},
 	}
@@ -274,6 +429,54 @@ func TestDeleteUserHandler(t *testing.T) {
 	}
 }
 
+func TestRestoreUserHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	userService := mock.NewMockUserService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users/:id/restore", httpservice.RestoreUserHandler(userService))
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		setup  func()
+	}{
+		{
+			name:   "restore successfully",
+			path:   "/users/1/restore",
+			status: http.StatusOK,
+			setup: func() {
+				userService.EXPECT().RestoreUser(uint(1)).Return(nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on invalid id",
+			path:   "/users/abc/restore",
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+		{
+			name:   "fail on email taken",
+			path:   "/users/1/restore",
+			status: http.StatusBadRequest,
+			setup: func() {
+				userService.EXPECT().RestoreUser(uint(1)).
+					Return(serviceerror.NewServiceError(serviceerror.DuplicateUser, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.path, nil)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
+
 func TestGetUsersHandler(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -294,11 +497,12 @@ func TestGetUsersHandler(t *testing.T) {
 			query:    "/?page=1&perPage=100",
 			response: fmt.Sprintf(responseUsersObj, 1, "test", "test@gmail.com", 1, 1, 100),
 			setup: func() {
-				userService.EXPECT().GetUsers(uint(1), uint(100)).Return(internal.UsersResponse{
+				userService.EXPECT().GetUsers(uint(1), uint(100), internal.UsersFilter{}).Return(internal.UsersResponse{
 					Users: []internal.UserResponse{{
-						ID:    1,
-						Name:  "test",
-						Email: "test@gmail.com",
+						ID:     1,
+						Name:   "test",
+						Email:  "test@gmail.com",
+						Status: internal.StatusActive,
 					}},
 					Total:   1,
 					Page:    1,
@@ -326,11 +530,12 @@ func TestGetUsersHandler(t *testing.T) {
 			query:    "/",
 			response: fmt.Sprintf(responseUsersObj, 1, "test", "test@gmail.com", 1, 1, 10),
 			setup: func() {
-				userService.EXPECT().GetUsers(uint(1), uint(10)).Return(internal.UsersResponse{
+				userService.EXPECT().GetUsers(uint(1), uint(10), internal.UsersFilter{}).Return(internal.UsersResponse{
 					Users: []internal.UserResponse{{
-						ID:    1,
-						Name:  "test",
-						Email: "test@gmail.com",
+						ID:     1,
+						Name:   "test",
+						Email:  "test@gmail.com",
+						Status: internal.StatusActive,
 					}},
 					Total:   1,
 					Page:    1,
@@ -338,13 +543,50 @@ func TestGetUsersHandler(t *testing.T) {
 				}, nil).Times(1)
 			},
 		},
+		{
+			name:     "filter by status",
+			status:   http.StatusOK,
+			query:    "/?page=1&perPage=100&status=suspended",
+			response: `{"users":[],"total":0,"page":1,"perPage":100}`,
+			setup: func() {
+				userService.EXPECT().GetUsers(uint(1), uint(100), internal.UsersFilter{Status: internal.StatusSuspended}).
+					Return(internal.UsersResponse{Users: []internal.UserResponse{}, Page: 1, PerPage: 100}, nil).Times(1)
+			},
+		},
+		{
+			name:     "list deleted users",
+			status:   http.StatusOK,
+			query:    "/?deleted=true",
+			response: `{"users":[],"total":0,"page":1,"perPage":10}`,
+			setup: func() {
+				userService.EXPECT().GetUsers(uint(1), uint(10), internal.UsersFilter{Deleted: true}).
+					Return(internal.UsersResponse{Users: []internal.UserResponse{}, Page: 1, PerPage: 10}, nil).Times(1)
+			},
+		},
+		{
+			name:     "filter by attributes",
+			status:   http.StatusOK,
+			query:    "/?attributes[department]=eng&attributes[level]=3",
+			response: `{"users":[],"total":0,"page":1,"perPage":10}`,
+			setup: func() {
+				userService.EXPECT().GetUsers(uint(1), uint(10), internal.UsersFilter{Attributes: map[string]interface{}{"department": "eng", "level": "3"}}).
+					Return(internal.UsersResponse{Users: []internal.UserResponse{}, Page: 1, PerPage: 10}, nil).Times(1)
+			},
+		},
+		{
+			name:     "fail on unknown status",
+			status:   http.StatusBadRequest,
+			query:    "/?status=deleted",
+			response: `{"message":"Key: '' Error:Field validation for '' failed on the 'oneof' tag"}`,
+			setup:    func() {},
+		},
 		{
 			name:     "fail on service error",
 			status:   http.StatusBadRequest,
 			query:    "/?page=1&perPage=100",
 			response: `{"message":"Invalid User Request : test"}`,
 			setup: func() {
-				userService.EXPECT().GetUsers(uint(1), uint(100)).Return(internal.UsersResponse{}, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("test"))).Times(1)
+				userService.EXPECT().GetUsers(uint(1), uint(100), internal.UsersFilter{}).Return(internal.UsersResponse{}, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("test"))).Times(1)
 			},
 		},
 		{
@@ -353,7 +595,7 @@ func TestGetUsersHandler(t *testing.T) {
 			query:    "/?page=1&perPage=100",
 			response: `{"message":"test"}`,
 			setup: func() {
-				userService.EXPECT().GetUsers(uint(1), uint(100)).Return(internal.UsersResponse{}, errors.New("test")).Times(1)
+				userService.EXPECT().GetUsers(uint(1), uint(100), internal.UsersFilter{}).Return(internal.UsersResponse{}, errors.New("test")).Times(1)
 			},
 		},
 	}
@@ -439,3 +681,51 @@ func TestChangePasswordHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestResendVerificationHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	userService := mock.NewMockUserService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users/:id/verification", httpservice.ResendVerificationHandler(userService))
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		setup  func()
+	}{
+		{
+			name:   "resend verification successfully",
+			path:   "/users/1/verification",
+			status: http.StatusAccepted,
+			setup: func() {
+				userService.EXPECT().ResendVerification(uint(1)).Return(nil).Times(1)
+			},
+		},
+		{
+			name:   "fail on wrong id",
+			path:   "/users/test/verification",
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+		{
+			name:   "fail on service error",
+			path:   "/users/1/verification",
+			status: http.StatusBadRequest,
+			setup: func() {
+				userService.EXPECT().ResendVerification(uint(1)).
+					Return(serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("test"))).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.path, nil)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+		})
+	}
+}
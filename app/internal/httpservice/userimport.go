@@ -0,0 +1,360 @@
+package httpservice
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	CSVContentType    = "text/csv"
+	NDJSONContentType = "application/x-ndjson"
+	maxImportRows     = 10000
+	maxNDJSONLine     = 1 << 20
+	exportFlushEvery  = 100
+	attributeColumn   = "attributes."
+)
+
+// ImportUser is a row of a user import, an NDJSON line or a CSV record with
+// the columns name, email, password, status, group and attributes.<name>.
+// The id and emailVerified columns of an export are ignored.
+type ImportUser struct {
+	Name       string                 `json:"name" validate:"required"`
+	Email      string                 `json:"email" validate:"required,email"`
+	Password   string                 `json:"password" validate:"omitempty,min=6"`
+	Status     string                 `json:"status" validate:"omitempty,oneof=active pending"`
+	Group      string                 `json:"group"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// CustomMethodHandler serves the custom methods of a collection, such as
+// POST /users:import. gin can't match a literal colon, so the route is
+// registered as "/users:method" and the handler is picked by method name.
+func CustomMethodHandler(handlers map[string]gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Param("method")
+		handler, ok := handlers[strings.TrimPrefix(method, ":")]
+		if !ok || !strings.HasPrefix(method, ":") {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": fmt.Sprintf("unknown method %s", method)})
+			return
+		}
+		handler(c)
+	}
+}
+
+// ImportUsersHandler creates or updates the users of a CSV or NDJSON body,
+// or only checks them with dryRun, and reports the outcome of every row. With
+// async the import runs as a job and the report is its result, only the
+// caller who started it can follow it. Only admins may import rows with a
+// group.
+func ImportUsersHandler(importService internal.UserImportService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		async, err := strconv.ParseBool(c.DefaultQuery("async", "false"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var rows []internal.ImportUserRow
+		switch c.ContentType() {
+		case CSVContentType:
+			rows, err = readCSVUsers(c.Request.Body)
+		case NDJSONContentType:
+			rows, err = readNDJSONUsers(c.Request.Body)
+		default:
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+				"message": fmt.Sprintf("content type must be %s or %s", CSVContentType, NDJSONContentType),
+			})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if hasGroup(rows) && !authorized(c, importService.AuthorizeGroups) {
+			return
+		}
+		request := internal.ImportUsersRequest{Rows: rows, DryRun: dryRun}
+		if async {
+			if !authorized(c, func(internal.Caller) error { return nil }) {
+				return
+			}
+			caller, _ := callerOf(c)
+			request.CreatedBy = caller.UserID
+			job, err := importIn(c, importService).StartImport(request)
+			if err != nil {
+				serviceerror.AbortOnError(c, err)
+				return
+			}
+			accepted(c, job)
+			return
+		}
+		response, err := importIn(c, importService).ImportUsers(request)
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func hasGroup(rows []internal.ImportUserRow) bool {
+	for _, row := range rows {
+		if row.Group != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func readCSVUsers(body io.Reader) (rows []internal.ImportUserRow, err error) {
+	reader := csv.NewReader(body)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return rows, nil
+	}
+	if err != nil {
+		return rows, err
+	}
+	hasEmail := false
+	for _, column := range header {
+		switch {
+		case column == "email":
+			hasEmail = true
+		case column == "name", column == "password", column == "status", column == "group":
+		case column == "id", column == "emailVerified":
+		case strings.HasPrefix(column, attributeColumn) && len(column) > len(attributeColumn):
+		default:
+			return rows, fmt.Errorf("unknown column %s", column)
+		}
+	}
+	if !hasEmail {
+		return rows, errors.New("email column is missing")
+	}
+	v := validator.New()
+	for number := 1; ; number++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if number > maxImportRows {
+			return rows, fmt.Errorf("more than %d rows", maxImportRows)
+		}
+		if errors.Is(err, csv.ErrFieldCount) {
+			rows = append(rows, internal.ImportUserRow{Row: number, Error: err.Error()})
+			continue
+		}
+		if err != nil {
+			return rows, err
+		}
+		var user ImportUser
+		for i, column := range header {
+			value := record[i]
+			switch {
+			case column == "name":
+				user.Name = value
+			case column == "email":
+				user.Email = value
+			case column == "password":
+				user.Password = value
+			case column == "status":
+				user.Status = value
+			case column == "group":
+				user.Group = value
+			case strings.HasPrefix(column, attributeColumn) && value != "":
+				if user.Attributes == nil {
+					user.Attributes = map[string]interface{}{}
+				}
+				user.Attributes[strings.TrimPrefix(column, attributeColumn)] = value
+			}
+		}
+		rows = append(rows, toImportUserRow(v, number, user))
+	}
+}
+
+func readNDJSONUsers(body io.Reader) (rows []internal.ImportUserRow, err error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+	v := validator.New()
+	number := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		number++
+		if number > maxImportRows {
+			return rows, fmt.Errorf("more than %d rows", maxImportRows)
+		}
+		var user ImportUser
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&user); err != nil {
+			rows = append(rows, internal.ImportUserRow{Row: number, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, toImportUserRow(v, number, user))
+	}
+	return rows, scanner.Err()
+}
+
+func toImportUserRow(v *validator.Validate, number int, user ImportUser) internal.ImportUserRow {
+	row := internal.ImportUserRow{
+		Row:        number,
+		Name:       user.Name,
+		Email:      user.Email,
+		Password:   user.Password,
+		Status:     user.Status,
+		Group:      user.Group,
+		Attributes: user.Attributes,
+	}
+	if err := v.Struct(user); err != nil {
+		row.Error = err.Error()
+	}
+	return row
+}
+
+// ExportUsersHandler streams the users as CSV, with the columns an import
+// reads, or as NDJSON. An error after the first user was sent can only end
+// the response early.
+func ExportUsersHandler(importService internal.UserImportService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deleted, err := strconv.ParseBool(c.DefaultQuery("deleted", "false"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		filter := internal.UsersFilter{
+			Status:  c.Query("status"),
+			Deleted: deleted,
+		}
+		if err := validator.New().Var(filter.Status, "omitempty,oneof=active pending suspended disabled"); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		var exporter streamingExporter
+		switch format := c.DefaultQuery("format", "csv"); format {
+		case "csv":
+			exporter = &csvUserExporter{c: c, writer: csv.NewWriter(c.Writer)}
+		case "ndjson":
+			exporter = &ndjsonUserExporter{c: c, encoder: json.NewEncoder(c.Writer)}
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("format %s is not csv or ndjson", format)})
+			return
+		}
+		err = importIn(c, importService).ExportUsers(filter, exporter)
+		if err == nil {
+			err = exporter.finish()
+		}
+		if err != nil && c.Writer.Written() {
+			log.WithError(err).Error("export users failed")
+			c.Abort()
+			return
+		}
+		if err != nil {
+			serviceerror.AbortOnError(c, err)
+		}
+	}
+}
+
+// streamingExporter writes exported users to the response, finish writes
+// what is still buffered.
+type streamingExporter interface {
+	internal.UserExporter
+	finish() (err error)
+}
+
+func beginExport(c *gin.Context, contentType string, filename string) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+}
+
+type csvUserExporter struct {
+	c          *gin.Context
+	writer     *csv.Writer
+	attributes []string
+	count      int
+}
+
+func (e *csvUserExporter) Begin(attributes []string) (err error) {
+	e.attributes = attributes
+	beginExport(e.c, CSVContentType+"; charset=utf-8", "users.csv")
+	header := []string{"id", "name", "email", "emailVerified", "status"}
+	for _, name := range attributes {
+		header = append(header, attributeColumn+name)
+	}
+	e.writer.Write(header)
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvUserExporter) Export(user internal.UserResponse) (err error) {
+	record := []string{strconv.FormatUint(uint64(user.ID), 10), user.Name, user.Email, strconv.FormatBool(user.EmailVerified), user.Status}
+	for _, name := range e.attributes {
+		value, ok := user.Attributes[name]
+		if !ok || value == nil {
+			record = append(record, "")
+			continue
+		}
+		record = append(record, fmt.Sprint(value))
+	}
+	if err := e.writer.Write(record); err != nil {
+		return err
+	}
+	e.count++
+	if e.count%exportFlushEvery == 0 {
+		e.writer.Flush()
+		e.c.Writer.Flush()
+	}
+	return e.writer.Error()
+}
+
+func (e *csvUserExporter) finish() (err error) {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonUserExporter struct {
+	c       *gin.Context
+	encoder *json.Encoder
+	count   int
+}
+
+func (e *ndjsonUserExporter) Begin(attributes []string) (err error) {
+	beginExport(e.c, NDJSONContentType, "users.ndjson")
+	e.c.Writer.WriteHeaderNow()
+	return nil
+}
+
+func (e *ndjsonUserExporter) Export(user internal.UserResponse) (err error) {
+	if err := e.encoder.Encode(user); err != nil {
+		return err
+	}
+	e.count++
+	if e.count%exportFlushEvery == 0 {
+		e.c.Writer.Flush()
+	}
+	return nil
+}
+
+func (e *ndjsonUserExporter) finish() (err error) {
+	return nil
+}
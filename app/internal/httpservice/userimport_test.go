@@ -0,0 +1,258 @@
+package httpservice_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/httpservice"
+	"usermanagement/app/internal/mock"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportUsersHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	importService := mock.NewMockUserImportService(mockCtrl)
+	router := gin.Default()
+	router.POST("/users:method", httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"import": httpservice.ImportUsersHandler(importService),
+	}))
+	admin := internal.Caller{UserID: 1, Admin: true}
+	router.POST("/admin/users:method", httpservice.CallerMiddleware(admin), httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"import": httpservice.ImportUsersHandler(importService),
+	}))
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		request     string
+		status      int
+		location    string
+		setup       func()
+	}{
+		{
+			name:        "import csv with attributes and group",
+			path:        "/admin/users:import",
+			contentType: httpservice.CSVContentType,
+			request: "name,email,password,group,attributes.level,id\n" +
+				"test,test@gmail.com,12345678,eng,3,7\n" +
+				"test2,test2@gmail.com,,,,\n" +
+				"test3,test.com,,,,\n" +
+				"test4,test4@gmail.com\n",
+			status: http.StatusOK,
+			setup: func() {
+				importService.EXPECT().AuthorizeGroups(admin).Return(nil).Times(1)
+				importService.EXPECT().ImportUsers(gomock.Any()).DoAndReturn(func(request internal.ImportUsersRequest) (internal.ImportUsersResponse, error) {
+					assert.False(t, request.DryRun)
+					if assert.Len(t, request.Rows, 4) {
+						assert.Equal(t, internal.ImportUserRow{
+							Row: 1, Name: "test", Email: "test@gmail.com", Password: "12345678", Group: "eng",
+							Attributes: map[string]interface{}{"level": "3"},
+						}, request.Rows[0])
+						assert.Equal(t, internal.ImportUserRow{Row: 2, Name: "test2", Email: "test2@gmail.com"}, request.Rows[1])
+						assert.NotEmpty(t, request.Rows[2].Error)
+						assert.NotEmpty(t, request.Rows[3].Error)
+					}
+					return internal.ImportUsersResponse{}, nil
+				}).Times(1)
+			},
+		},
+		{
+			name:        "dry run ndjson",
+			path:        "/users:import?dryRun=true",
+			contentType: httpservice.NDJSONContentType,
+			request: `{"name":"test","email":"test@gmail.com","attributes":{"level":3}}` + "\n\n" +
+				`{"name":"test2","email":"test2@gmail.com","phone":"123"}` + "\n",
+			status: http.StatusOK,
+			setup: func() {
+				importService.EXPECT().ImportUsers(gomock.Any()).DoAndReturn(func(request internal.ImportUsersRequest) (internal.ImportUsersResponse, error) {
+					assert.True(t, request.DryRun)
+					if assert.Len(t, request.Rows, 2) {
+						assert.Equal(t, internal.ImportUserRow{
+							Row: 1, Name: "test", Email: "test@gmail.com", Attributes: map[string]interface{}{"level": float64(3)},
+						}, request.Rows[0])
+						assert.Equal(t, 2, request.Rows[1].Row)
+						assert.NotEmpty(t, request.Rows[1].Error)
+					}
+					return internal.ImportUsersResponse{DryRun: true}, nil
+				}).Times(1)
+			},
+		},
+		{
+			name:        "start import as job",
+			path:        "/admin/users:import?async=true",
+			contentType: httpservice.CSVContentType,
+			request:     "name,email\ntest,test@gmail.com\n",
+			status:      http.StatusAccepted,
+			location:    "/api/v1/jobs/7",
+			setup: func() {
+				importService.EXPECT().StartImport(gomock.Any()).DoAndReturn(func(request internal.ImportUsersRequest) (internal.Job, error) {
+					assert.Len(t, request.Rows, 1)
+					assert.Equal(t, admin.UserID, request.CreatedBy)
+					return internal.Job{ID: 7, Type: internal.JobImportUsers, Status: internal.JobQueued}, nil
+				}).Times(1)
+			},
+		},
+		{
+			name:        "fail on job without token",
+			path:        "/users:import?async=true",
+			contentType: httpservice.CSVContentType,
+			request:     "name,email\ntest,test@gmail.com\n",
+			status:      http.StatusUnauthorized,
+			setup:       func() {},
+		},
+		{
+			name:        "fail on group without token",
+			path:        "/users:import",
+			contentType: httpservice.NDJSONContentType,
+			request:     `{"name":"test","email":"test@gmail.com","group":"eng"}` + "\n",
+			status:      http.StatusUnauthorized,
+			setup:       func() {},
+		},
+		{
+			name:        "fail on unknown csv column",
+			path:        "/users:import",
+			contentType: httpservice.CSVContentType,
+			request:     "name,email,phone\ntest,test@gmail.com,123\n",
+			status:      http.StatusBadRequest,
+			setup:       func() {},
+		},
+		{
+			name:        "fail on missing email column",
+			path:        "/users:import",
+			contentType: httpservice.CSVContentType,
+			request:     "name\ntest\n",
+			status:      http.StatusBadRequest,
+			setup:       func() {},
+		},
+		{
+			name:        "fail on unsupported content type",
+			path:        "/users:import",
+			contentType: "application/json",
+			request:     `[]`,
+			status:      http.StatusUnsupportedMediaType,
+			setup:       func() {},
+		},
+		{
+			name:        "fail on unknown method",
+			path:        "/users:purge",
+			contentType: httpservice.CSVContentType,
+			status:      http.StatusNotFound,
+			setup:       func() {},
+		},
+		{
+			name:        "fail on unknown error",
+			path:        "/users:import",
+			contentType: httpservice.CSVContentType,
+			request:     "name,email\ntest,test@gmail.com\n",
+			status:      http.StatusInternalServerError,
+			setup: func() {
+				importService.EXPECT().ImportUsers(gomock.Any()).Return(internal.ImportUsersResponse{}, errors.New("test")).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", test.path, strings.NewReader(test.request))
+			req.Header.Set("Content-Type", test.contentType)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			assert.Equal(t, test.location, recorder.Header().Get("Location"))
+		})
+	}
+}
+
+func TestExportUsersHandler(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	importService := mock.NewMockUserImportService(mockCtrl)
+	router := gin.Default()
+	router.GET("/users:method", httpservice.CustomMethodHandler(map[string]gin.HandlerFunc{
+		"export": httpservice.ExportUsersHandler(importService),
+	}))
+	export := func(filter internal.UsersFilter) *gomock.Call {
+		return importService.EXPECT().ExportUsers(filter, gomock.Any()).DoAndReturn(func(filter internal.UsersFilter, exporter internal.UserExporter) error {
+			if err := exporter.Begin([]string{"department", "level"}); err != nil {
+				return err
+			}
+			for _, user := range []internal.UserResponse{
+				{ID: 1, Name: "test", Email: "test@gmail.com", Status: internal.StatusActive, Attributes: map[string]interface{}{"level": float64(3)}},
+				{ID: 2, Name: "test, 2", Email: "test2@gmail.com", EmailVerified: true, Status: internal.StatusActive},
+			} {
+				if err := exporter.Export(user); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+		body        string
+		setup       func()
+	}{
+		{
+			name:        "export csv",
+			path:        "/users:export",
+			status:      http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			body: "id,name,email,emailVerified,status,attributes.department,attributes.level\n" +
+				"1,test,test@gmail.com,false,active,,3\n" +
+				"2,\"test, 2\",test2@gmail.com,true,active,,\n",
+			setup: func() {
+				export(internal.UsersFilter{}).Times(1)
+			},
+		},
+		{
+			name:        "export ndjson of active users",
+			path:        "/users:export?format=ndjson&status=active",
+			status:      http.StatusOK,
+			contentType: httpservice.NDJSONContentType,
+			body: `{"id":1,"name":"test","email":"test@gmail.com","emailVerified":false,"status":"active","attributes":{"level":3}}` + "\n" +
+				`{"id":2,"name":"test, 2","email":"test2@gmail.com","emailVerified":true,"status":"active"}` + "\n",
+			setup: func() {
+				export(internal.UsersFilter{Status: internal.StatusActive}).Times(1)
+			},
+		},
+		{
+			name:   "fail on unknown format",
+			path:   "/users:export?format=xml",
+			status: http.StatusBadRequest,
+			setup:  func() {},
+		},
+		{
+			name:   "fail on unknown error",
+			path:   "/users:export",
+			status: http.StatusInternalServerError,
+			setup: func() {
+				importService.EXPECT().ExportUsers(internal.UsersFilter{}, gomock.Any()).Return(errors.New("test")).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", test.path, nil)
+			test.setup()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, test.status, recorder.Code)
+			if test.body != "" {
+				assert.Equal(t, test.contentType, recorder.Header().Get("Content-Type"))
+				assert.Equal(t, test.body, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package mock
 
 import (
 	reflect "reflect"
+	time "time"
 	internal "usermanagement/app/internal"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,6 +35,21 @@ func (m *MockUserData) EXPECT() *MockUserDataMockRecorder {
 	return m.recorder
 }
 
+// Authenticate mocks base method.
+func (m *MockUserData) Authenticate(email, password string) (internal.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", email, password)
+	ret0, _ := ret[0].(internal.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockUserDataMockRecorder) Authenticate(email, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockUserData)(nil).Authenticate), email, password)
+}
+
 // ChangePassword mocks base method.
 func (m *MockUserData) ChangePassword(userID uint, password string) error {
 	m.ctrl.T.Helper()
@@ -48,6 +64,50 @@ func (mr *MockUserDataMockRecorder) ChangePassword(userID, password interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserData)(nil).ChangePassword), userID, password)
 }
 
+// ConfirmEmailVerification mocks base method.
+func (m *MockUserData) ConfirmEmailVerification(tokenHash string) (internal.UserResponse, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailVerification", tokenHash)
+	ret0, _ := ret[0].(internal.UserResponse)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ConfirmEmailVerification indicates an expected call of ConfirmEmailVerification.
+func (mr *MockUserDataMockRecorder) ConfirmEmailVerification(tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailVerification", reflect.TypeOf((*MockUserData)(nil).ConfirmEmailVerification), tokenHash)
+}
+
+// CreateEmailVerification mocks base method.
+func (m *MockUserData) CreateEmailVerification(userID uint, email, tokenHash string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailVerification", userID, email, tokenHash, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEmailVerification indicates an expected call of CreateEmailVerification.
+func (mr *MockUserDataMockRecorder) CreateEmailVerification(userID, email, tokenHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailVerification", reflect.TypeOf((*MockUserData)(nil).CreateEmailVerification), userID, email, tokenHash, expiresAt)
+}
+
+// CreatePasswordReset mocks base method.
+func (m *MockUserData) CreatePasswordReset(userID uint, tokenHash string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordReset", userID, tokenHash, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePasswordReset indicates an expected call of CreatePasswordReset.
+func (mr *MockUserDataMockRecorder) CreatePasswordReset(userID, tokenHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordReset", reflect.TypeOf((*MockUserData)(nil).CreatePasswordReset), userID, tokenHash, expiresAt)
+}
+
 // CreateUser mocks base method.
 func (m *MockUserData) CreateUser(request internal.UserRequest) (internal.UserResponse, error) {
 	m.ctrl.T.Helper()
@@ -64,32 +124,179 @@ func (mr *MockUserDataMockRecorder) CreateUser(request interface{}) *gomock.Call
 }
 
 // DeleteUser mocks base method.
-func (m *MockUserData) DeleteUser(id uint) error {
+func (m *MockUserData) DeleteUser(id, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", id)
+	ret := m.ctrl.Call(m, "DeleteUser", id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockUserDataMockRecorder) DeleteUser(id interface{}) *gomock.Call {
+func (mr *MockUserDataMockRecorder) DeleteUser(id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserData)(nil).DeleteUser), id, version)
+}
+
+// EraseUser mocks base method.
+func (m *MockUserData) EraseUser(id uint) (internal.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUser", id)
+	ret0, _ := ret[0].(internal.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EraseUser indicates an expected call of EraseUser.
+func (mr *MockUserDataMockRecorder) EraseUser(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUser", reflect.TypeOf((*MockUserData)(nil).EraseUser), id)
+}
+
+// ExportUsers mocks base method.
+func (m *MockUserData) ExportUsers(filter internal.UsersFilter, export func(internal.UserResponse) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUsers", filter, export)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportUsers indicates an expected call of ExportUsers.
+func (mr *MockUserDataMockRecorder) ExportUsers(filter, export interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUsers", reflect.TypeOf((*MockUserData)(nil).ExportUsers), filter, export)
+}
+
+// ForOrganization mocks base method.
+func (m *MockUserData) ForOrganization(organizationID uint) internal.UserData {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.UserData)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockUserDataMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockUserData)(nil).ForOrganization), organizationID)
+}
+
+// GetExpiredSuspensions mocks base method.
+func (m *MockUserData) GetExpiredSuspensions(now time.Time) ([]internal.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredSuspensions", now)
+	ret0, _ := ret[0].([]internal.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredSuspensions indicates an expected call of GetExpiredSuspensions.
+func (mr *MockUserDataMockRecorder) GetExpiredSuspensions(now interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserData)(nil).DeleteUser), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredSuspensions", reflect.TypeOf((*MockUserData)(nil).GetExpiredSuspensions), now)
+}
+
+// GetUser mocks base method.
+func (m *MockUserData) GetUser(id uint) (internal.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", id)
+	ret0, _ := ret[0].(internal.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUserDataMockRecorder) GetUser(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUserData)(nil).GetUser), id)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockUserData) GetUserByEmail(email string) (internal.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", email)
+	ret0, _ := ret[0].(internal.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockUserDataMockRecorder) GetUserByEmail(email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserData)(nil).GetUserByEmail), email)
 }
 
 // GetUsers mocks base method.
-func (m *MockUserData) GetUsers(offset, limit uint) (internal.UsersResponse, error) {
+func (m *MockUserData) GetUsers(offset, limit uint, filter internal.UsersFilter) (internal.UsersResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", offset, limit)
+	ret := m.ctrl.Call(m, "GetUsers", offset, limit, filter)
 	ret0, _ := ret[0].(internal.UsersResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUsers indicates an expected call of GetUsers.
-func (mr *MockUserDataMockRecorder) GetUsers(offset, limit interface{}) *gomock.Call {
+func (mr *MockUserDataMockRecorder) GetUsers(offset, limit, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserData)(nil).GetUsers), offset, limit, filter)
+}
+
+// HashPassword mocks base method.
+func (m *MockUserData) HashPassword(password string) (string, string) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HashPassword", password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	return ret0, ret1
+}
+
+// HashPassword indicates an expected call of HashPassword.
+func (mr *MockUserDataMockRecorder) HashPassword(password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashPassword", reflect.TypeOf((*MockUserData)(nil).HashPassword), password)
+}
+
+// PurgeUsers mocks base method.
+func (m *MockUserData) PurgeUsers(before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUsers", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeUsers indicates an expected call of PurgeUsers.
+func (mr *MockUserDataMockRecorder) PurgeUsers(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUsers", reflect.TypeOf((*MockUserData)(nil).PurgeUsers), before)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUserData) ResetPassword(tokenHash, password string) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", tokenHash, password)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserDataMockRecorder) ResetPassword(tokenHash, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUserData)(nil).ResetPassword), tokenHash, password)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUserData) RestoreUser(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserDataMockRecorder) RestoreUser(id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserData)(nil).GetUsers), offset, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserData)(nil).RestoreUser), id)
 }
 
 // UpdateUser mocks base method.
@@ -106,6 +313,86 @@ func (mr *MockUserDataMockRecorder) UpdateUser(request interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserData)(nil).UpdateUser), request)
 }
 
+// UpdateUserStatus mocks base method.
+func (m *MockUserData) UpdateUserStatus(update internal.UserStatusUpdate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserStatus", update)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserStatus indicates an expected call of UpdateUserStatus.
+func (mr *MockUserDataMockRecorder) UpdateUserStatus(update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserStatus", reflect.TypeOf((*MockUserData)(nil).UpdateUserStatus), update)
+}
+
+// MockAttributeData is a mock of AttributeData interface.
+type MockAttributeData struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttributeDataMockRecorder
+}
+
+// MockAttributeDataMockRecorder is the mock recorder for MockAttributeData.
+type MockAttributeDataMockRecorder struct {
+	mock *MockAttributeData
+}
+
+// NewMockAttributeData creates a new mock instance.
+func NewMockAttributeData(ctrl *gomock.Controller) *MockAttributeData {
+	mock := &MockAttributeData{ctrl: ctrl}
+	mock.recorder = &MockAttributeDataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttributeData) EXPECT() *MockAttributeDataMockRecorder {
+	return m.recorder
+}
+
+// DeleteAttributeDefinition mocks base method.
+func (m *MockAttributeData) DeleteAttributeDefinition(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttributeDefinition", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAttributeDefinition indicates an expected call of DeleteAttributeDefinition.
+func (mr *MockAttributeDataMockRecorder) DeleteAttributeDefinition(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttributeDefinition", reflect.TypeOf((*MockAttributeData)(nil).DeleteAttributeDefinition), name)
+}
+
+// GetAttributeDefinitions mocks base method.
+func (m *MockAttributeData) GetAttributeDefinitions() ([]internal.AttributeDefinition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttributeDefinitions")
+	ret0, _ := ret[0].([]internal.AttributeDefinition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttributeDefinitions indicates an expected call of GetAttributeDefinitions.
+func (mr *MockAttributeDataMockRecorder) GetAttributeDefinitions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttributeDefinitions", reflect.TypeOf((*MockAttributeData)(nil).GetAttributeDefinitions))
+}
+
+// SaveAttributeDefinition mocks base method.
+func (m *MockAttributeData) SaveAttributeDefinition(definition internal.AttributeDefinition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAttributeDefinition", definition)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAttributeDefinition indicates an expected call of SaveAttributeDefinition.
+func (mr *MockAttributeDataMockRecorder) SaveAttributeDefinition(definition interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAttributeDefinition", reflect.TypeOf((*MockAttributeData)(nil).SaveAttributeDefinition), definition)
+}
+
 // MockGroupData is a mock of GroupData interface.
 type MockGroupData struct {
 	ctrl     *gomock.Controller
@@ -130,17 +417,32 @@ func (m *MockGroupData) EXPECT() *MockGroupDataMockRecorder {
 }
 
 // AddUser mocks base method.
-func (m *MockGroupData) AddUser(userID, groupID uint) error {
+func (m *MockGroupData) AddUser(request internal.AddUserRequest) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddUser", userID, groupID)
+	ret := m.ctrl.Call(m, "AddUser", request)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddUser indicates an expected call of AddUser.
-func (mr *MockGroupDataMockRecorder) AddUser(userID, groupID interface{}) *gomock.Call {
+func (mr *MockGroupDataMockRecorder) AddUser(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockGroupData)(nil).AddUser), request)
+}
+
+// AddUsers mocks base method.
+func (m *MockGroupData) AddUsers(groupID uint, userIDs []uint) (internal.BatchMembershipResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUsers", groupID, userIDs)
+	ret0, _ := ret[0].(internal.BatchMembershipResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddUsers indicates an expected call of AddUsers.
+func (mr *MockGroupDataMockRecorder) AddUsers(groupID, userIDs interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockGroupData)(nil).AddUser), userID, groupID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUsers", reflect.TypeOf((*MockGroupData)(nil).AddUsers), groupID, userIDs)
 }
 
 // CreateGroup mocks base method.
@@ -159,32 +461,136 @@ func (mr *MockGroupDataMockRecorder) CreateGroup(request interface{}) *gomock.Ca
 }
 
 // DeleteGroup mocks base method.
-func (m *MockGroupData) DeleteGroup(id uint) error {
+func (m *MockGroupData) DeleteGroup(id, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteGroup", id)
+	ret := m.ctrl.Call(m, "DeleteGroup", id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteGroup indicates an expected call of DeleteGroup.
-func (mr *MockGroupDataMockRecorder) DeleteGroup(id interface{}) *gomock.Call {
+func (mr *MockGroupDataMockRecorder) DeleteGroup(id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockGroupData)(nil).DeleteGroup), id, version)
+}
+
+// ExpireMemberships mocks base method.
+func (m *MockGroupData) ExpireMemberships(now time.Time) ([]internal.ExpiredMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireMemberships", now)
+	ret0, _ := ret[0].([]internal.ExpiredMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireMemberships indicates an expected call of ExpireMemberships.
+func (mr *MockGroupDataMockRecorder) ExpireMemberships(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireMemberships", reflect.TypeOf((*MockGroupData)(nil).ExpireMemberships), now)
+}
+
+// ForOrganization mocks base method.
+func (m *MockGroupData) ForOrganization(organizationID uint) internal.GroupData {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.GroupData)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockGroupDataMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockGroupData)(nil).ForOrganization), organizationID)
+}
+
+// GetGroup mocks base method.
+func (m *MockGroupData) GetGroup(id uint) (internal.GroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", id)
+	ret0, _ := ret[0].(internal.GroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockGroupDataMockRecorder) GetGroup(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockGroupData)(nil).GetGroup), id)
+}
+
+// GetGroupMembers mocks base method.
+func (m *MockGroupData) GetGroupMembers(groupID uint) ([]internal.GroupMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupMembers", groupID)
+	ret0, _ := ret[0].([]internal.GroupMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroupMembers indicates an expected call of GetGroupMembers.
+func (mr *MockGroupDataMockRecorder) GetGroupMembers(groupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupMembers", reflect.TypeOf((*MockGroupData)(nil).GetGroupMembers), groupID)
+}
+
+// GetGroupOwners mocks base method.
+func (m *MockGroupData) GetGroupOwners(groupID uint) ([]internal.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupOwners", groupID)
+	ret0, _ := ret[0].([]internal.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroupOwners indicates an expected call of GetGroupOwners.
+func (mr *MockGroupDataMockRecorder) GetGroupOwners(groupID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockGroupData)(nil).DeleteGroup), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupOwners", reflect.TypeOf((*MockGroupData)(nil).GetGroupOwners), groupID)
 }
 
 // GetGroups mocks base method.
-func (m *MockGroupData) GetGroups(offset, limit uint) (internal.GroupsResponse, error) {
+func (m *MockGroupData) GetGroups(offset, limit uint, filter internal.GroupsFilter) (internal.GroupsResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetGroups", offset, limit)
+	ret := m.ctrl.Call(m, "GetGroups", offset, limit, filter)
 	ret0, _ := ret[0].(internal.GroupsResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetGroups indicates an expected call of GetGroups.
-func (mr *MockGroupDataMockRecorder) GetGroups(offset, limit interface{}) *gomock.Call {
+func (mr *MockGroupDataMockRecorder) GetGroups(offset, limit, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*MockGroupData)(nil).GetGroups), offset, limit, filter)
+}
+
+// GetMemberRole mocks base method.
+func (m *MockGroupData) GetMemberRole(groupID, userID uint) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberRole", groupID, userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemberRole indicates an expected call of GetMemberRole.
+func (mr *MockGroupDataMockRecorder) GetMemberRole(groupID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberRole", reflect.TypeOf((*MockGroupData)(nil).GetMemberRole), groupID, userID)
+}
+
+// GetMemberships mocks base method.
+func (m *MockGroupData) GetMemberships(userID uint) ([]internal.Membership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberships", userID)
+	ret0, _ := ret[0].([]internal.Membership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemberships indicates an expected call of GetMemberships.
+func (mr *MockGroupDataMockRecorder) GetMemberships(userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*MockGroupData)(nil).GetGroups), offset, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberships", reflect.TypeOf((*MockGroupData)(nil).GetMemberships), userID)
 }
 
 // GetUsersByGroupID mocks base method.
@@ -202,6 +608,21 @@ func (mr *MockGroupDataMockRecorder) GetUsersByGroupID(groupID, offset, limit in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByGroupID", reflect.TypeOf((*MockGroupData)(nil).GetUsersByGroupID), groupID, offset, limit)
 }
 
+// PurgeGroups mocks base method.
+func (m *MockGroupData) PurgeGroups(before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeGroups", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeGroups indicates an expected call of PurgeGroups.
+func (mr *MockGroupDataMockRecorder) PurgeGroups(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeGroups", reflect.TypeOf((*MockGroupData)(nil).PurgeGroups), before)
+}
+
 // RemoveUser mocks base method.
 func (m *MockGroupData) RemoveUser(groupID, userID uint) error {
 	m.ctrl.T.Helper()
@@ -216,16 +637,1382 @@ func (mr *MockGroupDataMockRecorder) RemoveUser(groupID, userID interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUser", reflect.TypeOf((*MockGroupData)(nil).RemoveUser), groupID, userID)
 }
 
-// UpdateGroup mocks base method.
-func (m *MockGroupData) UpdateGroup(request internal.UpdateGroupRequest) error {
+// RemoveUsers mocks base method.
+func (m *MockGroupData) RemoveUsers(groupID uint, userIDs []uint) (internal.BatchMembershipResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateGroup", request)
+	ret := m.ctrl.Call(m, "RemoveUsers", groupID, userIDs)
+	ret0, _ := ret[0].(internal.BatchMembershipResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveUsers indicates an expected call of RemoveUsers.
+func (mr *MockGroupDataMockRecorder) RemoveUsers(groupID, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUsers", reflect.TypeOf((*MockGroupData)(nil).RemoveUsers), groupID, userIDs)
+}
+
+// ReplaceUsers mocks base method.
+func (m *MockGroupData) ReplaceUsers(groupID uint, userIDs []uint) (internal.BatchMembershipResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceUsers", groupID, userIDs)
+	ret0, _ := ret[0].(internal.BatchMembershipResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceUsers indicates an expected call of ReplaceUsers.
+func (mr *MockGroupDataMockRecorder) ReplaceUsers(groupID, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceUsers", reflect.TypeOf((*MockGroupData)(nil).ReplaceUsers), groupID, userIDs)
+}
+
+// RestoreGroup mocks base method.
+func (m *MockGroupData) RestoreGroup(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreGroup", id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateGroup indicates an expected call of UpdateGroup.
-func (mr *MockGroupDataMockRecorder) UpdateGroup(request interface{}) *gomock.Call {
+// RestoreGroup indicates an expected call of RestoreGroup.
+func (mr *MockGroupDataMockRecorder) RestoreGroup(id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGroup", reflect.TypeOf((*MockGroupData)(nil).UpdateGroup), request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreGroup", reflect.TypeOf((*MockGroupData)(nil).RestoreGroup), id)
+}
+
+// SetGroupRule mocks base method.
+func (m *MockGroupData) SetGroupRule(id uint, rule string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetGroupRule", id, rule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetGroupRule indicates an expected call of SetGroupRule.
+func (mr *MockGroupDataMockRecorder) SetGroupRule(id, rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGroupRule", reflect.TypeOf((*MockGroupData)(nil).SetGroupRule), id, rule)
+}
+
+// SetMemberRole mocks base method.
+func (m *MockGroupData) SetMemberRole(groupID, userID uint, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMemberRole", groupID, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMemberRole indicates an expected call of SetMemberRole.
+func (mr *MockGroupDataMockRecorder) SetMemberRole(groupID, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMemberRole", reflect.TypeOf((*MockGroupData)(nil).SetMemberRole), groupID, userID, role)
+}
+
+// SyncMembers mocks base method.
+func (m *MockGroupData) SyncMembers(groupID uint, matched, checked []uint) (internal.BatchMembershipResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncMembers", groupID, matched, checked)
+	ret0, _ := ret[0].(internal.BatchMembershipResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncMembers indicates an expected call of SyncMembers.
+func (mr *MockGroupDataMockRecorder) SyncMembers(groupID, matched, checked interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncMembers", reflect.TypeOf((*MockGroupData)(nil).SyncMembers), groupID, matched, checked)
+}
+
+// UpdateGroup mocks base method.
+func (m *MockGroupData) UpdateGroup(request internal.UpdateGroupRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGroup", request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateGroup indicates an expected call of UpdateGroup.
+func (mr *MockGroupDataMockRecorder) UpdateGroup(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGroup", reflect.TypeOf((*MockGroupData)(nil).UpdateGroup), request)
+}
+
+// MockOrganizationData is a mock of OrganizationData interface.
+type MockOrganizationData struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrganizationDataMockRecorder
+}
+
+// MockOrganizationDataMockRecorder is the mock recorder for MockOrganizationData.
+type MockOrganizationDataMockRecorder struct {
+	mock *MockOrganizationData
+}
+
+// NewMockOrganizationData creates a new mock instance.
+func NewMockOrganizationData(ctrl *gomock.Controller) *MockOrganizationData {
+	mock := &MockOrganizationData{ctrl: ctrl}
+	mock.recorder = &MockOrganizationDataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrganizationData) EXPECT() *MockOrganizationDataMockRecorder {
+	return m.recorder
+}
+
+// CreateOrganization mocks base method.
+func (m *MockOrganizationData) CreateOrganization(request internal.OrganizationRequest) (internal.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", request)
+	ret0, _ := ret[0].(internal.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockOrganizationDataMockRecorder) CreateOrganization(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockOrganizationData)(nil).CreateOrganization), request)
+}
+
+// GetOrganization mocks base method.
+func (m *MockOrganizationData) GetOrganization(id uint) (internal.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganization", id)
+	ret0, _ := ret[0].(internal.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganization indicates an expected call of GetOrganization.
+func (mr *MockOrganizationDataMockRecorder) GetOrganization(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganization", reflect.TypeOf((*MockOrganizationData)(nil).GetOrganization), id)
+}
+
+// GetOrganizations mocks base method.
+func (m *MockOrganizationData) GetOrganizations(offset, limit uint) (internal.OrganizationsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizations", offset, limit)
+	ret0, _ := ret[0].(internal.OrganizationsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizations indicates an expected call of GetOrganizations.
+func (mr *MockOrganizationDataMockRecorder) GetOrganizations(offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizations", reflect.TypeOf((*MockOrganizationData)(nil).GetOrganizations), offset, limit)
+}
+
+// MockMFAData is a mock of MFAData interface.
+type MockMFAData struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFADataMockRecorder
+}
+
+// MockMFADataMockRecorder is the mock recorder for MockMFAData.
+type MockMFADataMockRecorder struct {
+	mock *MockMFAData
+}
+
+// NewMockMFAData creates a new mock instance.
+func NewMockMFAData(ctrl *gomock.Controller) *MockMFAData {
+	mock := &MockMFAData{ctrl: ctrl}
+	mock.recorder = &MockMFADataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFAData) EXPECT() *MockMFADataMockRecorder {
+	return m.recorder
+}
+
+// ConsumeMFAChallenge mocks base method.
+func (m *MockMFAData) ConsumeMFAChallenge(tokenHash string) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeMFAChallenge", tokenHash)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeMFAChallenge indicates an expected call of ConsumeMFAChallenge.
+func (mr *MockMFADataMockRecorder) ConsumeMFAChallenge(tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeMFAChallenge", reflect.TypeOf((*MockMFAData)(nil).ConsumeMFAChallenge), tokenHash)
+}
+
+// CreateMFAChallenge mocks base method.
+func (m *MockMFAData) CreateMFAChallenge(userID uint, tokenHash string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFAChallenge", userID, tokenHash, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMFAChallenge indicates an expected call of CreateMFAChallenge.
+func (mr *MockMFADataMockRecorder) CreateMFAChallenge(userID, tokenHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFAChallenge", reflect.TypeOf((*MockMFAData)(nil).CreateMFAChallenge), userID, tokenHash, expiresAt)
+}
+
+// EnableMFA mocks base method.
+func (m *MockMFAData) EnableMFA(userID uint, step int64, recoveryCodeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableMFA", userID, step, recoveryCodeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableMFA indicates an expected call of EnableMFA.
+func (mr *MockMFADataMockRecorder) EnableMFA(userID, step, recoveryCodeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableMFA", reflect.TypeOf((*MockMFAData)(nil).EnableMFA), userID, step, recoveryCodeHashes)
+}
+
+// GetMFA mocks base method.
+func (m *MockMFAData) GetMFA(userID uint) (internal.MFA, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMFA", userID)
+	ret0, _ := ret[0].(internal.MFA)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMFA indicates an expected call of GetMFA.
+func (mr *MockMFADataMockRecorder) GetMFA(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMFA", reflect.TypeOf((*MockMFAData)(nil).GetMFA), userID)
+}
+
+// ResetMFA mocks base method.
+func (m *MockMFAData) ResetMFA(userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetMFA", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetMFA indicates an expected call of ResetMFA.
+func (mr *MockMFADataMockRecorder) ResetMFA(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetMFA", reflect.TypeOf((*MockMFAData)(nil).ResetMFA), userID)
+}
+
+// SaveMFASecret mocks base method.
+func (m *MockMFAData) SaveMFASecret(userID uint, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMFASecret", userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveMFASecret indicates an expected call of SaveMFASecret.
+func (mr *MockMFADataMockRecorder) SaveMFASecret(userID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMFASecret", reflect.TypeOf((*MockMFAData)(nil).SaveMFASecret), userID, secret)
+}
+
+// UseMFAStep mocks base method.
+func (m *MockMFAData) UseMFAStep(userID uint, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseMFAStep", userID, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseMFAStep indicates an expected call of UseMFAStep.
+func (mr *MockMFADataMockRecorder) UseMFAStep(userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseMFAStep", reflect.TypeOf((*MockMFAData)(nil).UseMFAStep), userID, step)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockMFAData) UseRecoveryCode(userID uint, codeHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", userID, codeHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockMFADataMockRecorder) UseRecoveryCode(userID, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockMFAData)(nil).UseRecoveryCode), userID, codeHash)
+}
+
+// MockPasskeyData is a mock of PasskeyData interface.
+type MockPasskeyData struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasskeyDataMockRecorder
+}
+
+// MockPasskeyDataMockRecorder is the mock recorder for MockPasskeyData.
+type MockPasskeyDataMockRecorder struct {
+	mock *MockPasskeyData
+}
+
+// NewMockPasskeyData creates a new mock instance.
+func NewMockPasskeyData(ctrl *gomock.Controller) *MockPasskeyData {
+	mock := &MockPasskeyData{ctrl: ctrl}
+	mock.recorder = &MockPasskeyDataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasskeyData) EXPECT() *MockPasskeyDataMockRecorder {
+	return m.recorder
+}
+
+// ConsumePasskeySession mocks base method.
+func (m *MockPasskeyData) ConsumePasskeySession(ceremony, challenge string) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumePasskeySession", ceremony, challenge)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumePasskeySession indicates an expected call of ConsumePasskeySession.
+func (mr *MockPasskeyDataMockRecorder) ConsumePasskeySession(ceremony, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumePasskeySession", reflect.TypeOf((*MockPasskeyData)(nil).ConsumePasskeySession), ceremony, challenge)
+}
+
+// CreatePasskey mocks base method.
+func (m *MockPasskeyData) CreatePasskey(passkey internal.Passkey) (internal.PasskeyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasskey", passkey)
+	ret0, _ := ret[0].(internal.PasskeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePasskey indicates an expected call of CreatePasskey.
+func (mr *MockPasskeyDataMockRecorder) CreatePasskey(passkey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasskey", reflect.TypeOf((*MockPasskeyData)(nil).CreatePasskey), passkey)
+}
+
+// CreatePasskeySession mocks base method.
+func (m *MockPasskeyData) CreatePasskeySession(userID uint, ceremony, challenge string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasskeySession", userID, ceremony, challenge, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePasskeySession indicates an expected call of CreatePasskeySession.
+func (mr *MockPasskeyDataMockRecorder) CreatePasskeySession(userID, ceremony, challenge, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasskeySession", reflect.TypeOf((*MockPasskeyData)(nil).CreatePasskeySession), userID, ceremony, challenge, expiresAt)
+}
+
+// DeletePasskey mocks base method.
+func (m *MockPasskeyData) DeletePasskey(userID, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePasskey", userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePasskey indicates an expected call of DeletePasskey.
+func (mr *MockPasskeyDataMockRecorder) DeletePasskey(userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePasskey", reflect.TypeOf((*MockPasskeyData)(nil).DeletePasskey), userID, id)
+}
+
+// GetPasskeyByCredentialID mocks base method.
+func (m *MockPasskeyData) GetPasskeyByCredentialID(credentialID string) (internal.Passkey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasskeyByCredentialID", credentialID)
+	ret0, _ := ret[0].(internal.Passkey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasskeyByCredentialID indicates an expected call of GetPasskeyByCredentialID.
+func (mr *MockPasskeyDataMockRecorder) GetPasskeyByCredentialID(credentialID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasskeyByCredentialID", reflect.TypeOf((*MockPasskeyData)(nil).GetPasskeyByCredentialID), credentialID)
+}
+
+// GetPasskeys mocks base method.
+func (m *MockPasskeyData) GetPasskeys(userID uint) ([]internal.Passkey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasskeys", userID)
+	ret0, _ := ret[0].([]internal.Passkey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasskeys indicates an expected call of GetPasskeys.
+func (mr *MockPasskeyDataMockRecorder) GetPasskeys(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasskeys", reflect.TypeOf((*MockPasskeyData)(nil).GetPasskeys), userID)
+}
+
+// RenamePasskey mocks base method.
+func (m *MockPasskeyData) RenamePasskey(userID, id uint, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenamePasskey", userID, id, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenamePasskey indicates an expected call of RenamePasskey.
+func (mr *MockPasskeyDataMockRecorder) RenamePasskey(userID, id, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenamePasskey", reflect.TypeOf((*MockPasskeyData)(nil).RenamePasskey), userID, id, name)
+}
+
+// UsePasskey mocks base method.
+func (m *MockPasskeyData) UsePasskey(id uint, signCount uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UsePasskey", id, signCount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UsePasskey indicates an expected call of UsePasskey.
+func (mr *MockPasskeyDataMockRecorder) UsePasskey(id, signCount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsePasskey", reflect.TypeOf((*MockPasskeyData)(nil).UsePasskey), id, signCount)
+}
+
+// MockAccessTokenData is a mock of AccessTokenData interface.
+type MockAccessTokenData struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessTokenDataMockRecorder
+}
+
+// MockAccessTokenDataMockRecorder is the mock recorder for MockAccessTokenData.
+type MockAccessTokenDataMockRecorder struct {
+	mock *MockAccessTokenData
+}
+
+// NewMockAccessTokenData creates a new mock instance.
+func NewMockAccessTokenData(ctrl *gomock.Controller) *MockAccessTokenData {
+	mock := &MockAccessTokenData{ctrl: ctrl}
+	mock.recorder = &MockAccessTokenDataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessTokenData) EXPECT() *MockAccessTokenDataMockRecorder {
+	return m.recorder
+}
+
+// CreateAccessToken mocks base method.
+func (m *MockAccessTokenData) CreateAccessToken(token internal.AccessToken) (internal.AccessTokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccessToken", token)
+	ret0, _ := ret[0].(internal.AccessTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccessToken indicates an expected call of CreateAccessToken.
+func (mr *MockAccessTokenDataMockRecorder) CreateAccessToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccessToken", reflect.TypeOf((*MockAccessTokenData)(nil).CreateAccessToken), token)
+}
+
+// GetAccessTokenByHash mocks base method.
+func (m *MockAccessTokenData) GetAccessTokenByHash(tokenHash string) (internal.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessTokenByHash", tokenHash)
+	ret0, _ := ret[0].(internal.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessTokenByHash indicates an expected call of GetAccessTokenByHash.
+func (mr *MockAccessTokenDataMockRecorder) GetAccessTokenByHash(tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessTokenByHash", reflect.TypeOf((*MockAccessTokenData)(nil).GetAccessTokenByHash), tokenHash)
+}
+
+// GetAccessTokens mocks base method.
+func (m *MockAccessTokenData) GetAccessTokens(userID uint) ([]internal.AccessTokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessTokens", userID)
+	ret0, _ := ret[0].([]internal.AccessTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessTokens indicates an expected call of GetAccessTokens.
+func (mr *MockAccessTokenDataMockRecorder) GetAccessTokens(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessTokens", reflect.TypeOf((*MockAccessTokenData)(nil).GetAccessTokens), userID)
+}
+
+// RevokeAccessToken mocks base method.
+func (m *MockAccessTokenData) RevokeAccessToken(userID, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAccessToken", userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAccessToken indicates an expected call of RevokeAccessToken.
+func (mr *MockAccessTokenDataMockRecorder) RevokeAccessToken(userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccessToken", reflect.TypeOf((*MockAccessTokenData)(nil).RevokeAccessToken), userID, id)
+}
+
+// RevokeClientAccessToken mocks base method.
+func (m *MockAccessTokenData) RevokeClientAccessToken(clientID, tokenHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeClientAccessToken", clientID, tokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeClientAccessToken indicates an expected call of RevokeClientAccessToken.
+func (mr *MockAccessTokenDataMockRecorder) RevokeClientAccessToken(clientID, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeClientAccessToken", reflect.TypeOf((*MockAccessTokenData)(nil).RevokeClientAccessToken), clientID, tokenHash)
+}
+
+// UseAccessToken mocks base method.
+func (m *MockAccessTokenData) UseAccessToken(id uint, usedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseAccessToken", id, usedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseAccessToken indicates an expected call of UseAccessToken.
+func (mr *MockAccessTokenDataMockRecorder) UseAccessToken(id, usedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseAccessToken", reflect.TypeOf((*MockAccessTokenData)(nil).UseAccessToken), id, usedAt)
+}
+
+// MockOAuthData is a mock of OAuthData interface.
+type MockOAuthData struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthDataMockRecorder
+}
+
+// MockOAuthDataMockRecorder is the mock recorder for MockOAuthData.
+type MockOAuthDataMockRecorder struct {
+	mock *MockOAuthData
+}
+
+// NewMockOAuthData creates a new mock instance.
+func NewMockOAuthData(ctrl *gomock.Controller) *MockOAuthData {
+	mock := &MockOAuthData{ctrl: ctrl}
+	mock.recorder = &MockOAuthDataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthData) EXPECT() *MockOAuthDataMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateClient mocks base method.
+func (m *MockOAuthData) AuthenticateClient(clientID, secret string) (internal.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateClient", clientID, secret)
+	ret0, _ := ret[0].(internal.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateClient indicates an expected call of AuthenticateClient.
+func (mr *MockOAuthDataMockRecorder) AuthenticateClient(clientID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateClient", reflect.TypeOf((*MockOAuthData)(nil).AuthenticateClient), clientID, secret)
+}
+
+// ConsumeAuthorizationCode mocks base method.
+func (m *MockOAuthData) ConsumeAuthorizationCode(codeHash string) (internal.OAuthAuthorizationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeAuthorizationCode", codeHash)
+	ret0, _ := ret[0].(internal.OAuthAuthorizationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeAuthorizationCode indicates an expected call of ConsumeAuthorizationCode.
+func (mr *MockOAuthDataMockRecorder) ConsumeAuthorizationCode(codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeAuthorizationCode", reflect.TypeOf((*MockOAuthData)(nil).ConsumeAuthorizationCode), codeHash)
+}
+
+// CreateAuthorizationCode mocks base method.
+func (m *MockOAuthData) CreateAuthorizationCode(code internal.OAuthAuthorizationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuthorizationCode", code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuthorizationCode indicates an expected call of CreateAuthorizationCode.
+func (mr *MockOAuthDataMockRecorder) CreateAuthorizationCode(code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuthorizationCode", reflect.TypeOf((*MockOAuthData)(nil).CreateAuthorizationCode), code)
+}
+
+// CreateClient mocks base method.
+func (m *MockOAuthData) CreateClient(client internal.OAuthClient, secret string) (internal.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClient", client, secret)
+	ret0, _ := ret[0].(internal.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateClient indicates an expected call of CreateClient.
+func (mr *MockOAuthDataMockRecorder) CreateClient(client, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClient", reflect.TypeOf((*MockOAuthData)(nil).CreateClient), client, secret)
+}
+
+// GetClient mocks base method.
+func (m *MockOAuthData) GetClient(clientID string) (internal.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClient", clientID)
+	ret0, _ := ret[0].(internal.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClient indicates an expected call of GetClient.
+func (mr *MockOAuthDataMockRecorder) GetClient(clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClient", reflect.TypeOf((*MockOAuthData)(nil).GetClient), clientID)
+}
+
+// MockSigningKeyData is a mock of SigningKeyData interface.
+type MockSigningKeyData struct {
+	ctrl     *gomock.Controller
+	recorder *MockSigningKeyDataMockRecorder
+}
+
+// MockSigningKeyDataMockRecorder is the mock recorder for MockSigningKeyData.
+type MockSigningKeyDataMockRecorder struct {
+	mock *MockSigningKeyData
+}
+
+// NewMockSigningKeyData creates a new mock instance.
+func NewMockSigningKeyData(ctrl *gomock.Controller) *MockSigningKeyData {
+	mock := &MockSigningKeyData{ctrl: ctrl}
+	mock.recorder = &MockSigningKeyDataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSigningKeyData) EXPECT() *MockSigningKeyDataMockRecorder {
+	return m.recorder
+}
+
+// GetSigningKeys mocks base method.
+func (m *MockSigningKeyData) GetSigningKeys() ([]internal.StoredSigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSigningKeys")
+	ret0, _ := ret[0].([]internal.StoredSigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSigningKeys indicates an expected call of GetSigningKeys.
+func (mr *MockSigningKeyDataMockRecorder) GetSigningKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSigningKeys", reflect.TypeOf((*MockSigningKeyData)(nil).GetSigningKeys))
+}
+
+// PurgeSigningKeys mocks base method.
+func (m *MockSigningKeyData) PurgeSigningKeys(now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeSigningKeys", now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeSigningKeys indicates an expected call of PurgeSigningKeys.
+func (mr *MockSigningKeyDataMockRecorder) PurgeSigningKeys(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeSigningKeys", reflect.TypeOf((*MockSigningKeyData)(nil).PurgeSigningKeys), now)
+}
+
+// RotateSigningKey mocks base method.
+func (m *MockSigningKeyData) RotateSigningKey(key internal.StoredSigningKey, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSigningKey", key, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateSigningKey indicates an expected call of RotateSigningKey.
+func (mr *MockSigningKeyDataMockRecorder) RotateSigningKey(key, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSigningKey", reflect.TypeOf((*MockSigningKeyData)(nil).RotateSigningKey), key, expiresAt)
+}
+
+// MockAttemptStore is a mock of AttemptStore interface.
+type MockAttemptStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttemptStoreMockRecorder
+}
+
+// MockAttemptStoreMockRecorder is the mock recorder for MockAttemptStore.
+type MockAttemptStoreMockRecorder struct {
+	mock *MockAttemptStore
+}
+
+// NewMockAttemptStore creates a new mock instance.
+func NewMockAttemptStore(ctrl *gomock.Controller) *MockAttemptStore {
+	mock := &MockAttemptStore{ctrl: ctrl}
+	mock.recorder = &MockAttemptStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttemptStore) EXPECT() *MockAttemptStoreMockRecorder {
+	return m.recorder
+}
+
+// GetAttempts mocks base method.
+func (m *MockAttemptStore) GetAttempts(key string) (internal.Attempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttempts", key)
+	ret0, _ := ret[0].(internal.Attempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttempts indicates an expected call of GetAttempts.
+func (mr *MockAttemptStoreMockRecorder) GetAttempts(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttempts", reflect.TypeOf((*MockAttemptStore)(nil).GetAttempts), key)
+}
+
+// Lock mocks base method.
+func (m *MockAttemptStore) Lock(key string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockAttemptStoreMockRecorder) Lock(key, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockAttemptStore)(nil).Lock), key, until)
+}
+
+// RecordFailure mocks base method.
+func (m *MockAttemptStore) RecordFailure(key string, window time.Duration) (internal.Attempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", key, window)
+	ret0, _ := ret[0].(internal.Attempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockAttemptStoreMockRecorder) RecordFailure(key, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockAttemptStore)(nil).RecordFailure), key, window)
+}
+
+// ResetAttempts mocks base method.
+func (m *MockAttemptStore) ResetAttempts(key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetAttempts", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetAttempts indicates an expected call of ResetAttempts.
+func (mr *MockAttemptStoreMockRecorder) ResetAttempts(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetAttempts", reflect.TypeOf((*MockAttemptStore)(nil).ResetAttempts), key)
+}
+
+// MockIdempotencyStore is a mock of IdempotencyStore interface.
+type MockIdempotencyStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyStoreMockRecorder
+}
+
+// MockIdempotencyStoreMockRecorder is the mock recorder for MockIdempotencyStore.
+type MockIdempotencyStoreMockRecorder struct {
+	mock *MockIdempotencyStore
+}
+
+// NewMockIdempotencyStore creates a new mock instance.
+func NewMockIdempotencyStore(ctrl *gomock.Controller) *MockIdempotencyStore {
+	mock := &MockIdempotencyStore{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyStore) EXPECT() *MockIdempotencyStoreMockRecorder {
+	return m.recorder
+}
+
+// PurgeIdempotencyKeys mocks base method.
+func (m *MockIdempotencyStore) PurgeIdempotencyKeys(now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeIdempotencyKeys", now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeIdempotencyKeys indicates an expected call of PurgeIdempotencyKeys.
+func (mr *MockIdempotencyStoreMockRecorder) PurgeIdempotencyKeys(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeIdempotencyKeys", reflect.TypeOf((*MockIdempotencyStore)(nil).PurgeIdempotencyKeys), now)
+}
+
+// ReleaseIdempotencyKey mocks base method.
+func (m *MockIdempotencyStore) ReleaseIdempotencyKey(scope, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseIdempotencyKey", scope, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseIdempotencyKey indicates an expected call of ReleaseIdempotencyKey.
+func (mr *MockIdempotencyStoreMockRecorder) ReleaseIdempotencyKey(scope, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotencyKey", reflect.TypeOf((*MockIdempotencyStore)(nil).ReleaseIdempotencyKey), scope, key)
+}
+
+// ReserveIdempotencyKey mocks base method.
+func (m *MockIdempotencyStore) ReserveIdempotencyKey(scope, key, fingerprint string, expiresAt time.Time) (internal.IdempotencyRecord, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveIdempotencyKey", scope, key, fingerprint, expiresAt)
+	ret0, _ := ret[0].(internal.IdempotencyRecord)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReserveIdempotencyKey indicates an expected call of ReserveIdempotencyKey.
+func (mr *MockIdempotencyStoreMockRecorder) ReserveIdempotencyKey(scope, key, fingerprint, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveIdempotencyKey", reflect.TypeOf((*MockIdempotencyStore)(nil).ReserveIdempotencyKey), scope, key, fingerprint, expiresAt)
+}
+
+// SaveIdempotentResponse mocks base method.
+func (m *MockIdempotencyStore) SaveIdempotentResponse(scope, key string, response internal.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIdempotentResponse", scope, key, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIdempotentResponse indicates an expected call of SaveIdempotentResponse.
+func (mr *MockIdempotencyStoreMockRecorder) SaveIdempotentResponse(scope, key, response interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotentResponse", reflect.TypeOf((*MockIdempotencyStore)(nil).SaveIdempotentResponse), scope, key, response)
+}
+
+// MockJobData is a mock of JobData interface.
+type MockJobData struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobDataMockRecorder
+}
+
+// MockJobDataMockRecorder is the mock recorder for MockJobData.
+type MockJobDataMockRecorder struct {
+	mock *MockJobData
+}
+
+// NewMockJobData creates a new mock instance.
+func NewMockJobData(ctrl *gomock.Controller) *MockJobData {
+	mock := &MockJobData{ctrl: ctrl}
+	mock.recorder = &MockJobDataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobData) EXPECT() *MockJobDataMockRecorder {
+	return m.recorder
+}
+
+// CancelJob mocks base method.
+func (m *MockJobData) CancelJob(id uint) (internal.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelJob", id)
+	ret0, _ := ret[0].(internal.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelJob indicates an expected call of CancelJob.
+func (mr *MockJobDataMockRecorder) CancelJob(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelJob", reflect.TypeOf((*MockJobData)(nil).CancelJob), id)
+}
+
+// ClaimJob mocks base method.
+func (m *MockJobData) ClaimJob(worker string, leaseUntil time.Time) (internal.Job, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimJob", worker, leaseUntil)
+	ret0, _ := ret[0].(internal.Job)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ClaimJob indicates an expected call of ClaimJob.
+func (mr *MockJobDataMockRecorder) ClaimJob(worker, leaseUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimJob", reflect.TypeOf((*MockJobData)(nil).ClaimJob), worker, leaseUntil)
+}
+
+// CreateJob mocks base method.
+func (m *MockJobData) CreateJob(request internal.JobRequest) (internal.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJob", request)
+	ret0, _ := ret[0].(internal.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateJob indicates an expected call of CreateJob.
+func (mr *MockJobDataMockRecorder) CreateJob(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJob", reflect.TypeOf((*MockJobData)(nil).CreateJob), request)
+}
+
+// FinishJob mocks base method.
+func (m *MockJobData) FinishJob(id uint, worker string, update internal.JobUpdate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishJob", id, worker, update)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishJob indicates an expected call of FinishJob.
+func (mr *MockJobDataMockRecorder) FinishJob(id, worker, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishJob", reflect.TypeOf((*MockJobData)(nil).FinishJob), id, worker, update)
+}
+
+// GetJob mocks base method.
+func (m *MockJobData) GetJob(id uint) (internal.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", id)
+	ret0, _ := ret[0].(internal.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockJobDataMockRecorder) GetJob(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockJobData)(nil).GetJob), id)
+}
+
+// PurgeJobs mocks base method.
+func (m *MockJobData) PurgeJobs(before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeJobs", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeJobs indicates an expected call of PurgeJobs.
+func (mr *MockJobDataMockRecorder) PurgeJobs(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeJobs", reflect.TypeOf((*MockJobData)(nil).PurgeJobs), before)
+}
+
+// RenewJob mocks base method.
+func (m *MockJobData) RenewJob(id uint, worker string, progress internal.JobProgress, leaseUntil time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewJob", id, worker, progress, leaseUntil)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenewJob indicates an expected call of RenewJob.
+func (mr *MockJobDataMockRecorder) RenewJob(id, worker, progress, leaseUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewJob", reflect.TypeOf((*MockJobData)(nil).RenewJob), id, worker, progress, leaseUntil)
+}
+
+// MockAccessRequestData is a mock of AccessRequestData interface.
+type MockAccessRequestData struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessRequestDataMockRecorder
+}
+
+// MockAccessRequestDataMockRecorder is the mock recorder for MockAccessRequestData.
+type MockAccessRequestDataMockRecorder struct {
+	mock *MockAccessRequestData
+}
+
+// NewMockAccessRequestData creates a new mock instance.
+func NewMockAccessRequestData(ctrl *gomock.Controller) *MockAccessRequestData {
+	mock := &MockAccessRequestData{ctrl: ctrl}
+	mock.recorder = &MockAccessRequestDataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessRequestData) EXPECT() *MockAccessRequestDataMockRecorder {
+	return m.recorder
+}
+
+// CreateAccessRequest mocks base method.
+func (m *MockAccessRequestData) CreateAccessRequest(request internal.AccessRequestRequest, expiresAt time.Time) (internal.AccessRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccessRequest", request, expiresAt)
+	ret0, _ := ret[0].(internal.AccessRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccessRequest indicates an expected call of CreateAccessRequest.
+func (mr *MockAccessRequestDataMockRecorder) CreateAccessRequest(request, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccessRequest", reflect.TypeOf((*MockAccessRequestData)(nil).CreateAccessRequest), request, expiresAt)
+}
+
+// DecideAccessRequest mocks base method.
+func (m *MockAccessRequestData) DecideAccessRequest(decision internal.AccessDecision) (internal.AccessRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecideAccessRequest", decision)
+	ret0, _ := ret[0].(internal.AccessRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DecideAccessRequest indicates an expected call of DecideAccessRequest.
+func (mr *MockAccessRequestDataMockRecorder) DecideAccessRequest(decision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecideAccessRequest", reflect.TypeOf((*MockAccessRequestData)(nil).DecideAccessRequest), decision)
+}
+
+// ExpireAccessRequests mocks base method.
+func (m *MockAccessRequestData) ExpireAccessRequests(now time.Time) ([]internal.AccessRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireAccessRequests", now)
+	ret0, _ := ret[0].([]internal.AccessRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireAccessRequests indicates an expected call of ExpireAccessRequests.
+func (mr *MockAccessRequestDataMockRecorder) ExpireAccessRequests(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireAccessRequests", reflect.TypeOf((*MockAccessRequestData)(nil).ExpireAccessRequests), now)
+}
+
+// ForOrganization mocks base method.
+func (m *MockAccessRequestData) ForOrganization(organizationID uint) internal.AccessRequestData {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.AccessRequestData)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockAccessRequestDataMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockAccessRequestData)(nil).ForOrganization), organizationID)
+}
+
+// GetAccessRequest mocks base method.
+func (m *MockAccessRequestData) GetAccessRequest(id uint) (internal.AccessRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessRequest", id)
+	ret0, _ := ret[0].(internal.AccessRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessRequest indicates an expected call of GetAccessRequest.
+func (mr *MockAccessRequestDataMockRecorder) GetAccessRequest(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessRequest", reflect.TypeOf((*MockAccessRequestData)(nil).GetAccessRequest), id)
+}
+
+// GetAccessRequests mocks base method.
+func (m *MockAccessRequestData) GetAccessRequests(offset, limit uint, filter internal.AccessRequestsFilter) (internal.AccessRequestsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessRequests", offset, limit, filter)
+	ret0, _ := ret[0].(internal.AccessRequestsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessRequests indicates an expected call of GetAccessRequests.
+func (mr *MockAccessRequestDataMockRecorder) GetAccessRequests(offset, limit, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessRequests", reflect.TypeOf((*MockAccessRequestData)(nil).GetAccessRequests), offset, limit, filter)
+}
+
+// MockAccessReviewData is a mock of AccessReviewData interface.
+type MockAccessReviewData struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessReviewDataMockRecorder
+}
+
+// MockAccessReviewDataMockRecorder is the mock recorder for MockAccessReviewData.
+type MockAccessReviewDataMockRecorder struct {
+	mock *MockAccessReviewData
+}
+
+// NewMockAccessReviewData creates a new mock instance.
+func NewMockAccessReviewData(ctrl *gomock.Controller) *MockAccessReviewData {
+	mock := &MockAccessReviewData{ctrl: ctrl}
+	mock.recorder = &MockAccessReviewDataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessReviewData) EXPECT() *MockAccessReviewDataMockRecorder {
+	return m.recorder
+}
+
+// CloseCampaign mocks base method.
+func (m *MockAccessReviewData) CloseCampaign(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseCampaign", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseCampaign indicates an expected call of CloseCampaign.
+func (mr *MockAccessReviewDataMockRecorder) CloseCampaign(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseCampaign", reflect.TypeOf((*MockAccessReviewData)(nil).CloseCampaign), id)
+}
+
+// CreateCampaign mocks base method.
+func (m *MockAccessReviewData) CreateCampaign(request internal.ReviewCampaignRequest, items []internal.ReviewItem) (internal.ReviewCampaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCampaign", request, items)
+	ret0, _ := ret[0].(internal.ReviewCampaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCampaign indicates an expected call of CreateCampaign.
+func (mr *MockAccessReviewDataMockRecorder) CreateCampaign(request, items interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCampaign", reflect.TypeOf((*MockAccessReviewData)(nil).CreateCampaign), request, items)
+}
+
+// DecideReviewItem mocks base method.
+func (m *MockAccessReviewData) DecideReviewItem(decision internal.ReviewDecision) (internal.ReviewItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecideReviewItem", decision)
+	ret0, _ := ret[0].(internal.ReviewItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DecideReviewItem indicates an expected call of DecideReviewItem.
+func (mr *MockAccessReviewDataMockRecorder) DecideReviewItem(decision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecideReviewItem", reflect.TypeOf((*MockAccessReviewData)(nil).DecideReviewItem), decision)
+}
+
+// ForOrganization mocks base method.
+func (m *MockAccessReviewData) ForOrganization(organizationID uint) internal.AccessReviewData {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.AccessReviewData)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockAccessReviewDataMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockAccessReviewData)(nil).ForOrganization), organizationID)
+}
+
+// GetCampaign mocks base method.
+func (m *MockAccessReviewData) GetCampaign(id uint) (internal.ReviewCampaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaign", id)
+	ret0, _ := ret[0].(internal.ReviewCampaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaign indicates an expected call of GetCampaign.
+func (mr *MockAccessReviewDataMockRecorder) GetCampaign(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaign", reflect.TypeOf((*MockAccessReviewData)(nil).GetCampaign), id)
+}
+
+// GetCampaigns mocks base method.
+func (m *MockAccessReviewData) GetCampaigns(offset, limit uint, status string) (internal.ReviewCampaignsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaigns", offset, limit, status)
+	ret0, _ := ret[0].(internal.ReviewCampaignsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaigns indicates an expected call of GetCampaigns.
+func (mr *MockAccessReviewDataMockRecorder) GetCampaigns(offset, limit, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaigns", reflect.TypeOf((*MockAccessReviewData)(nil).GetCampaigns), offset, limit, status)
+}
+
+// GetDueCampaigns mocks base method.
+func (m *MockAccessReviewData) GetDueCampaigns(now time.Time) ([]internal.ReviewCampaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueCampaigns", now)
+	ret0, _ := ret[0].([]internal.ReviewCampaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueCampaigns indicates an expected call of GetDueCampaigns.
+func (mr *MockAccessReviewDataMockRecorder) GetDueCampaigns(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueCampaigns", reflect.TypeOf((*MockAccessReviewData)(nil).GetDueCampaigns), now)
+}
+
+// GetReviewItem mocks base method.
+func (m *MockAccessReviewData) GetReviewItem(campaignID, id uint) (internal.ReviewItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReviewItem", campaignID, id)
+	ret0, _ := ret[0].(internal.ReviewItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReviewItem indicates an expected call of GetReviewItem.
+func (mr *MockAccessReviewDataMockRecorder) GetReviewItem(campaignID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReviewItem", reflect.TypeOf((*MockAccessReviewData)(nil).GetReviewItem), campaignID, id)
+}
+
+// GetReviewItems mocks base method.
+func (m *MockAccessReviewData) GetReviewItems(campaignID, offset, limit uint, filter internal.ReviewItemsFilter) (internal.ReviewItemsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReviewItems", campaignID, offset, limit, filter)
+	ret0, _ := ret[0].(internal.ReviewItemsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReviewItems indicates an expected call of GetReviewItems.
+func (mr *MockAccessReviewDataMockRecorder) GetReviewItems(campaignID, offset, limit, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReviewItems", reflect.TypeOf((*MockAccessReviewData)(nil).GetReviewItems), campaignID, offset, limit, filter)
+}
+
+// MockAuditData is a mock of AuditData interface.
+type MockAuditData struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditDataMockRecorder
+}
+
+// MockAuditDataMockRecorder is the mock recorder for MockAuditData.
+type MockAuditDataMockRecorder struct {
+	mock *MockAuditData
+}
+
+// NewMockAuditData creates a new mock instance.
+func NewMockAuditData(ctrl *gomock.Controller) *MockAuditData {
+	mock := &MockAuditData{ctrl: ctrl}
+	mock.recorder = &MockAuditDataMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditData) EXPECT() *MockAuditDataMockRecorder {
+	return m.recorder
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockAuditData) CreateAuditEvent(event internal.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockAuditDataMockRecorder) CreateAuditEvent(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockAuditData)(nil).CreateAuditEvent), event)
+}
+
+// GetAuditEvents mocks base method.
+func (m *MockAuditData) GetAuditEvents(userID uint, email string) ([]internal.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEvents", userID, email)
+	ret0, _ := ret[0].([]internal.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEvents indicates an expected call of GetAuditEvents.
+func (mr *MockAuditDataMockRecorder) GetAuditEvents(userID, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEvents", reflect.TypeOf((*MockAuditData)(nil).GetAuditEvents), userID, email)
+}
+
+// MockUserExporter is a mock of UserExporter interface.
+type MockUserExporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserExporterMockRecorder
+}
+
+// MockUserExporterMockRecorder is the mock recorder for MockUserExporter.
+type MockUserExporterMockRecorder struct {
+	mock *MockUserExporter
+}
+
+// NewMockUserExporter creates a new mock instance.
+func NewMockUserExporter(ctrl *gomock.Controller) *MockUserExporter {
+	mock := &MockUserExporter{ctrl: ctrl}
+	mock.recorder = &MockUserExporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserExporter) EXPECT() *MockUserExporterMockRecorder {
+	return m.recorder
+}
+
+// Begin mocks base method.
+func (m *MockUserExporter) Begin(attributes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin", attributes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Begin indicates an expected call of Begin.
+func (mr *MockUserExporterMockRecorder) Begin(attributes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockUserExporter)(nil).Begin), attributes)
+}
+
+// Export mocks base method.
+func (m *MockUserExporter) Export(user internal.UserResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockUserExporterMockRecorder) Export(user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockUserExporter)(nil).Export), user)
 }
@@ -5,8 +5,11 @@
 package mock
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 	internal "usermanagement/app/internal"
+	webauthn "usermanagement/app/internal/webauthn"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -64,32 +67,89 @@ func (mr *MockUserServiceMockRecorder) CreateUser(request interface{}) *gomock.C
 }
 
 // DeleteUser mocks base method.
-func (m *MockUserService) DeleteUser(id uint) error {
+func (m *MockUserService) DeleteUser(id, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", id)
+	ret := m.ctrl.Call(m, "DeleteUser", id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockUserServiceMockRecorder) DeleteUser(id interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) DeleteUser(id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserService)(nil).DeleteUser), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserService)(nil).DeleteUser), id, version)
+}
+
+// ForOrganization mocks base method.
+func (m *MockUserService) ForOrganization(organizationID uint) internal.UserService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.UserService)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockUserServiceMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockUserService)(nil).ForOrganization), organizationID)
+}
+
+// GetUser mocks base method.
+func (m *MockUserService) GetUser(id uint) (internal.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", id)
+	ret0, _ := ret[0].(internal.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUserServiceMockRecorder) GetUser(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUserService)(nil).GetUser), id)
 }
 
 // GetUsers mocks base method.
-func (m *MockUserService) GetUsers(page, perPage uint) (internal.UsersResponse, error) {
+func (m *MockUserService) GetUsers(page, perPage uint, filter internal.UsersFilter) (internal.UsersResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", page, perPage)
+	ret := m.ctrl.Call(m, "GetUsers", page, perPage, filter)
 	ret0, _ := ret[0].(internal.UsersResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUsers indicates an expected call of GetUsers.
-func (mr *MockUserServiceMockRecorder) GetUsers(page, perPage interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) GetUsers(page, perPage, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserService)(nil).GetUsers), page, perPage, filter)
+}
+
+// ResendVerification mocks base method.
+func (m *MockUserService) ResendVerification(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerification", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendVerification indicates an expected call of ResendVerification.
+func (mr *MockUserServiceMockRecorder) ResendVerification(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerification", reflect.TypeOf((*MockUserService)(nil).ResendVerification), id)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUserService) RestoreUser(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserServiceMockRecorder) RestoreUser(id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserService)(nil).GetUsers), page, perPage)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserService)(nil).RestoreUser), id)
 }
 
 // UpdateUser mocks base method.
@@ -106,6 +166,167 @@ func (mr *MockUserServiceMockRecorder) UpdateUser(request interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserService)(nil).UpdateUser), request)
 }
 
+// MockAttributeService is a mock of AttributeService interface.
+type MockAttributeService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttributeServiceMockRecorder
+}
+
+// MockAttributeServiceMockRecorder is the mock recorder for MockAttributeService.
+type MockAttributeServiceMockRecorder struct {
+	mock *MockAttributeService
+}
+
+// NewMockAttributeService creates a new mock instance.
+func NewMockAttributeService(ctrl *gomock.Controller) *MockAttributeService {
+	mock := &MockAttributeService{ctrl: ctrl}
+	mock.recorder = &MockAttributeServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttributeService) EXPECT() *MockAttributeServiceMockRecorder {
+	return m.recorder
+}
+
+// DeleteAttributeDefinition mocks base method.
+func (m *MockAttributeService) DeleteAttributeDefinition(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttributeDefinition", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAttributeDefinition indicates an expected call of DeleteAttributeDefinition.
+func (mr *MockAttributeServiceMockRecorder) DeleteAttributeDefinition(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttributeDefinition", reflect.TypeOf((*MockAttributeService)(nil).DeleteAttributeDefinition), name)
+}
+
+// GetAttributeDefinitions mocks base method.
+func (m *MockAttributeService) GetAttributeDefinitions() (internal.AttributeDefinitionsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttributeDefinitions")
+	ret0, _ := ret[0].(internal.AttributeDefinitionsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttributeDefinitions indicates an expected call of GetAttributeDefinitions.
+func (mr *MockAttributeServiceMockRecorder) GetAttributeDefinitions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttributeDefinitions", reflect.TypeOf((*MockAttributeService)(nil).GetAttributeDefinitions))
+}
+
+// SaveAttributeDefinition mocks base method.
+func (m *MockAttributeService) SaveAttributeDefinition(definition internal.AttributeDefinition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAttributeDefinition", definition)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAttributeDefinition indicates an expected call of SaveAttributeDefinition.
+func (mr *MockAttributeServiceMockRecorder) SaveAttributeDefinition(definition interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAttributeDefinition", reflect.TypeOf((*MockAttributeService)(nil).SaveAttributeDefinition), definition)
+}
+
+// MockUserImportService is a mock of UserImportService interface.
+type MockUserImportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserImportServiceMockRecorder
+}
+
+// MockUserImportServiceMockRecorder is the mock recorder for MockUserImportService.
+type MockUserImportServiceMockRecorder struct {
+	mock *MockUserImportService
+}
+
+// NewMockUserImportService creates a new mock instance.
+func NewMockUserImportService(ctrl *gomock.Controller) *MockUserImportService {
+	mock := &MockUserImportService{ctrl: ctrl}
+	mock.recorder = &MockUserImportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserImportService) EXPECT() *MockUserImportServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthorizeGroups mocks base method.
+func (m *MockUserImportService) AuthorizeGroups(caller internal.Caller) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeGroups", caller)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeGroups indicates an expected call of AuthorizeGroups.
+func (mr *MockUserImportServiceMockRecorder) AuthorizeGroups(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeGroups", reflect.TypeOf((*MockUserImportService)(nil).AuthorizeGroups), caller)
+}
+
+// ExportUsers mocks base method.
+func (m *MockUserImportService) ExportUsers(filter internal.UsersFilter, exporter internal.UserExporter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUsers", filter, exporter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportUsers indicates an expected call of ExportUsers.
+func (mr *MockUserImportServiceMockRecorder) ExportUsers(filter, exporter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUsers", reflect.TypeOf((*MockUserImportService)(nil).ExportUsers), filter, exporter)
+}
+
+// ForOrganization mocks base method.
+func (m *MockUserImportService) ForOrganization(organizationID uint) internal.UserImportService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.UserImportService)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockUserImportServiceMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockUserImportService)(nil).ForOrganization), organizationID)
+}
+
+// ImportUsers mocks base method.
+func (m *MockUserImportService) ImportUsers(request internal.ImportUsersRequest) (internal.ImportUsersResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportUsers", request)
+	ret0, _ := ret[0].(internal.ImportUsersResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportUsers indicates an expected call of ImportUsers.
+func (mr *MockUserImportServiceMockRecorder) ImportUsers(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportUsers", reflect.TypeOf((*MockUserImportService)(nil).ImportUsers), request)
+}
+
+// StartImport mocks base method.
+func (m *MockUserImportService) StartImport(request internal.ImportUsersRequest) (internal.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartImport", request)
+	ret0, _ := ret[0].(internal.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartImport indicates an expected call of StartImport.
+func (mr *MockUserImportServiceMockRecorder) StartImport(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartImport", reflect.TypeOf((*MockUserImportService)(nil).StartImport), request)
+}
+
 // MockGroupService is a mock of GroupService interface.
 type MockGroupService struct {
 	ctrl     *gomock.Controller
@@ -130,17 +351,74 @@ func (m *MockGroupService) EXPECT() *MockGroupServiceMockRecorder {
 }
 
 // AddUser mocks base method.
-func (m *MockGroupService) AddUser(userID, groupID uint) error {
+func (m *MockGroupService) AddUser(request internal.AddUserRequest) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddUser", userID, groupID)
+	ret := m.ctrl.Call(m, "AddUser", request)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddUser indicates an expected call of AddUser.
-func (mr *MockGroupServiceMockRecorder) AddUser(userID, groupID interface{}) *gomock.Call {
+func (mr *MockGroupServiceMockRecorder) AddUser(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockGroupService)(nil).AddUser), request)
+}
+
+// AddUsers mocks base method.
+func (m *MockGroupService) AddUsers(groupID uint, userIDs []uint) (internal.BatchMembershipResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUsers", groupID, userIDs)
+	ret0, _ := ret[0].(internal.BatchMembershipResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddUsers indicates an expected call of AddUsers.
+func (mr *MockGroupServiceMockRecorder) AddUsers(groupID, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUsers", reflect.TypeOf((*MockGroupService)(nil).AddUsers), groupID, userIDs)
+}
+
+// AuthorizeCreate mocks base method.
+func (m *MockGroupService) AuthorizeCreate(caller internal.Caller, ownerID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeCreate", caller, ownerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeCreate indicates an expected call of AuthorizeCreate.
+func (mr *MockGroupServiceMockRecorder) AuthorizeCreate(caller, ownerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeCreate", reflect.TypeOf((*MockGroupService)(nil).AuthorizeCreate), caller, ownerID)
+}
+
+// AuthorizeGroupOwner mocks base method.
+func (m *MockGroupService) AuthorizeGroupOwner(caller internal.Caller, groupID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeGroupOwner", caller, groupID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeGroupOwner indicates an expected call of AuthorizeGroupOwner.
+func (mr *MockGroupServiceMockRecorder) AuthorizeGroupOwner(caller, groupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeGroupOwner", reflect.TypeOf((*MockGroupService)(nil).AuthorizeGroupOwner), caller, groupID)
+}
+
+// AuthorizeMemberChange mocks base method.
+func (m *MockGroupService) AuthorizeMemberChange(caller internal.Caller, groupID, userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeMemberChange", caller, groupID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeMemberChange indicates an expected call of AuthorizeMemberChange.
+func (mr *MockGroupServiceMockRecorder) AuthorizeMemberChange(caller, groupID, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockGroupService)(nil).AddUser), userID, groupID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeMemberChange", reflect.TypeOf((*MockGroupService)(nil).AuthorizeMemberChange), caller, groupID, userID)
 }
 
 // CreateGroup mocks base method.
@@ -159,32 +437,61 @@ func (mr *MockGroupServiceMockRecorder) CreateGroup(request interface{}) *gomock
 }
 
 // DeleteGroup mocks base method.
-func (m *MockGroupService) DeleteGroup(id uint) error {
+func (m *MockGroupService) DeleteGroup(id, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteGroup", id)
+	ret := m.ctrl.Call(m, "DeleteGroup", id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteGroup indicates an expected call of DeleteGroup.
-func (mr *MockGroupServiceMockRecorder) DeleteGroup(id interface{}) *gomock.Call {
+func (mr *MockGroupServiceMockRecorder) DeleteGroup(id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockGroupService)(nil).DeleteGroup), id, version)
+}
+
+// ForOrganization mocks base method.
+func (m *MockGroupService) ForOrganization(organizationID uint) internal.GroupService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.GroupService)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockGroupServiceMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockGroupService)(nil).ForOrganization), organizationID)
+}
+
+// GetGroup mocks base method.
+func (m *MockGroupService) GetGroup(id uint) (internal.GroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", id)
+	ret0, _ := ret[0].(internal.GroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockGroupServiceMockRecorder) GetGroup(id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockGroupService)(nil).DeleteGroup), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockGroupService)(nil).GetGroup), id)
 }
 
 // GetGroups mocks base method.
-func (m *MockGroupService) GetGroups(page, perPage uint) (internal.GroupsResponse, error) {
+func (m *MockGroupService) GetGroups(page, perPage uint, filter internal.GroupsFilter) (internal.GroupsResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetGroups", page, perPage)
+	ret := m.ctrl.Call(m, "GetGroups", page, perPage, filter)
 	ret0, _ := ret[0].(internal.GroupsResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetGroups indicates an expected call of GetGroups.
-func (mr *MockGroupServiceMockRecorder) GetGroups(page, perPage interface{}) *gomock.Call {
+func (mr *MockGroupServiceMockRecorder) GetGroups(page, perPage, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*MockGroupService)(nil).GetGroups), page, perPage)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*MockGroupService)(nil).GetGroups), page, perPage, filter)
 }
 
 // GetUsersByGroupID mocks base method.
@@ -216,6 +523,64 @@ func (mr *MockGroupServiceMockRecorder) RemoveUser(groupID, userID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUser", reflect.TypeOf((*MockGroupService)(nil).RemoveUser), groupID, userID)
 }
 
+// RemoveUsers mocks base method.
+func (m *MockGroupService) RemoveUsers(groupID uint, userIDs []uint) (internal.BatchMembershipResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveUsers", groupID, userIDs)
+	ret0, _ := ret[0].(internal.BatchMembershipResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveUsers indicates an expected call of RemoveUsers.
+func (mr *MockGroupServiceMockRecorder) RemoveUsers(groupID, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUsers", reflect.TypeOf((*MockGroupService)(nil).RemoveUsers), groupID, userIDs)
+}
+
+// ReplaceUsers mocks base method.
+func (m *MockGroupService) ReplaceUsers(groupID uint, userIDs []uint) (internal.BatchMembershipResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceUsers", groupID, userIDs)
+	ret0, _ := ret[0].(internal.BatchMembershipResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceUsers indicates an expected call of ReplaceUsers.
+func (mr *MockGroupServiceMockRecorder) ReplaceUsers(groupID, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceUsers", reflect.TypeOf((*MockGroupService)(nil).ReplaceUsers), groupID, userIDs)
+}
+
+// RestoreGroup mocks base method.
+func (m *MockGroupService) RestoreGroup(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreGroup", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreGroup indicates an expected call of RestoreGroup.
+func (mr *MockGroupServiceMockRecorder) RestoreGroup(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreGroup", reflect.TypeOf((*MockGroupService)(nil).RestoreGroup), id)
+}
+
+// SetMemberRole mocks base method.
+func (m *MockGroupService) SetMemberRole(groupID, userID uint, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMemberRole", groupID, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMemberRole indicates an expected call of SetMemberRole.
+func (mr *MockGroupServiceMockRecorder) SetMemberRole(groupID, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMemberRole", reflect.TypeOf((*MockGroupService)(nil).SetMemberRole), groupID, userID, role)
+}
+
 // UpdateGroup mocks base method.
 func (m *MockGroupService) UpdateGroup(request internal.UpdateGroupRequest) error {
 	m.ctrl.T.Helper()
@@ -229,3 +594,2180 @@ func (mr *MockGroupServiceMockRecorder) UpdateGroup(request interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGroup", reflect.TypeOf((*MockGroupService)(nil).UpdateGroup), request)
 }
+
+// MockOrganizationService is a mock of OrganizationService interface.
+type MockOrganizationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrganizationServiceMockRecorder
+}
+
+// MockOrganizationServiceMockRecorder is the mock recorder for MockOrganizationService.
+type MockOrganizationServiceMockRecorder struct {
+	mock *MockOrganizationService
+}
+
+// NewMockOrganizationService creates a new mock instance.
+func NewMockOrganizationService(ctrl *gomock.Controller) *MockOrganizationService {
+	mock := &MockOrganizationService{ctrl: ctrl}
+	mock.recorder = &MockOrganizationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrganizationService) EXPECT() *MockOrganizationServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthorizeAdmin mocks base method.
+func (m *MockOrganizationService) AuthorizeAdmin(caller internal.Caller) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeAdmin", caller)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeAdmin indicates an expected call of AuthorizeAdmin.
+func (mr *MockOrganizationServiceMockRecorder) AuthorizeAdmin(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeAdmin", reflect.TypeOf((*MockOrganizationService)(nil).AuthorizeAdmin), caller)
+}
+
+// CreateOrganization mocks base method.
+func (m *MockOrganizationService) CreateOrganization(request internal.OrganizationRequest) (internal.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", request)
+	ret0, _ := ret[0].(internal.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockOrganizationServiceMockRecorder) CreateOrganization(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockOrganizationService)(nil).CreateOrganization), request)
+}
+
+// GetOrganization mocks base method.
+func (m *MockOrganizationService) GetOrganization(id uint) (internal.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganization", id)
+	ret0, _ := ret[0].(internal.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganization indicates an expected call of GetOrganization.
+func (mr *MockOrganizationServiceMockRecorder) GetOrganization(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganization", reflect.TypeOf((*MockOrganizationService)(nil).GetOrganization), id)
+}
+
+// GetOrganizations mocks base method.
+func (m *MockOrganizationService) GetOrganizations(page, perPage uint) (internal.OrganizationsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizations", page, perPage)
+	ret0, _ := ret[0].(internal.OrganizationsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizations indicates an expected call of GetOrganizations.
+func (mr *MockOrganizationServiceMockRecorder) GetOrganizations(page, perPage interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizations", reflect.TypeOf((*MockOrganizationService)(nil).GetOrganizations), page, perPage)
+}
+
+// MockOIDCService is a mock of OIDCService interface.
+type MockOIDCService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCServiceMockRecorder
+}
+
+// MockOIDCServiceMockRecorder is the mock recorder for MockOIDCService.
+type MockOIDCServiceMockRecorder struct {
+	mock *MockOIDCService
+}
+
+// NewMockOIDCService creates a new mock instance.
+func NewMockOIDCService(ctrl *gomock.Controller) *MockOIDCService {
+	mock := &MockOIDCService{ctrl: ctrl}
+	mock.recorder = &MockOIDCServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDCService) EXPECT() *MockOIDCServiceMockRecorder {
+	return m.recorder
+}
+
+// Discovery mocks base method.
+func (m *MockOIDCService) Discovery() (internal.OIDCDiscovery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Discovery")
+	ret0, _ := ret[0].(internal.OIDCDiscovery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Discovery indicates an expected call of Discovery.
+func (mr *MockOIDCServiceMockRecorder) Discovery() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Discovery", reflect.TypeOf((*MockOIDCService)(nil).Discovery))
+}
+
+// IDToken mocks base method.
+func (m *MockOIDCService) IDToken(request internal.IDTokenRequest) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IDToken", request)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IDToken indicates an expected call of IDToken.
+func (mr *MockOIDCServiceMockRecorder) IDToken(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IDToken", reflect.TypeOf((*MockOIDCService)(nil).IDToken), request)
+}
+
+// JWKS mocks base method.
+func (m *MockOIDCService) JWKS() (internal.JSONWebKeySet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(internal.JSONWebKeySet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockOIDCServiceMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockOIDCService)(nil).JWKS))
+}
+
+// UserInfo mocks base method.
+func (m *MockOIDCService) UserInfo(caller internal.Caller) (internal.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserInfo", caller)
+	ret0, _ := ret[0].(internal.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserInfo indicates an expected call of UserInfo.
+func (mr *MockOIDCServiceMockRecorder) UserInfo(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserInfo", reflect.TypeOf((*MockOIDCService)(nil).UserInfo), caller)
+}
+
+// MockSigningKeys is a mock of SigningKeys interface.
+type MockSigningKeys struct {
+	ctrl     *gomock.Controller
+	recorder *MockSigningKeysMockRecorder
+}
+
+// MockSigningKeysMockRecorder is the mock recorder for MockSigningKeys.
+type MockSigningKeysMockRecorder struct {
+	mock *MockSigningKeys
+}
+
+// NewMockSigningKeys creates a new mock instance.
+func NewMockSigningKeys(ctrl *gomock.Controller) *MockSigningKeys {
+	mock := &MockSigningKeys{ctrl: ctrl}
+	mock.recorder = &MockSigningKeysMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSigningKeys) EXPECT() *MockSigningKeysMockRecorder {
+	return m.recorder
+}
+
+// Signing mocks base method.
+func (m *MockSigningKeys) Signing() (internal.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Signing")
+	ret0, _ := ret[0].(internal.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Signing indicates an expected call of Signing.
+func (mr *MockSigningKeysMockRecorder) Signing() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Signing", reflect.TypeOf((*MockSigningKeys)(nil).Signing))
+}
+
+// Verification mocks base method.
+func (m *MockSigningKeys) Verification() ([]internal.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verification")
+	ret0, _ := ret[0].([]internal.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verification indicates an expected call of Verification.
+func (mr *MockSigningKeysMockRecorder) Verification() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verification", reflect.TypeOf((*MockSigningKeys)(nil).Verification))
+}
+
+// VerificationKey mocks base method.
+func (m *MockSigningKeys) VerificationKey(kid string) (internal.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerificationKey", kid)
+	ret0, _ := ret[0].(internal.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerificationKey indicates an expected call of VerificationKey.
+func (mr *MockSigningKeysMockRecorder) VerificationKey(kid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerificationKey", reflect.TypeOf((*MockSigningKeys)(nil).VerificationKey), kid)
+}
+
+// MockKeyManager is a mock of KeyManager interface.
+type MockKeyManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyManagerMockRecorder
+}
+
+// MockKeyManagerMockRecorder is the mock recorder for MockKeyManager.
+type MockKeyManagerMockRecorder struct {
+	mock *MockKeyManager
+}
+
+// NewMockKeyManager creates a new mock instance.
+func NewMockKeyManager(ctrl *gomock.Controller) *MockKeyManager {
+	mock := &MockKeyManager{ctrl: ctrl}
+	mock.recorder = &MockKeyManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyManager) EXPECT() *MockKeyManagerMockRecorder {
+	return m.recorder
+}
+
+// AuthorizeAdmin mocks base method.
+func (m *MockKeyManager) AuthorizeAdmin(caller internal.Caller) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeAdmin", caller)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeAdmin indicates an expected call of AuthorizeAdmin.
+func (mr *MockKeyManagerMockRecorder) AuthorizeAdmin(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeAdmin", reflect.TypeOf((*MockKeyManager)(nil).AuthorizeAdmin), caller)
+}
+
+// GetKeys mocks base method.
+func (m *MockKeyManager) GetKeys() (internal.SigningKeysResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeys")
+	ret0, _ := ret[0].(internal.SigningKeysResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeys indicates an expected call of GetKeys.
+func (mr *MockKeyManagerMockRecorder) GetKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeys", reflect.TypeOf((*MockKeyManager)(nil).GetKeys))
+}
+
+// Init mocks base method.
+func (m *MockKeyManager) Init() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Init")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockKeyManagerMockRecorder) Init() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockKeyManager)(nil).Init))
+}
+
+// Rotate mocks base method.
+func (m *MockKeyManager) Rotate() (internal.SigningKeyInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate")
+	ret0, _ := ret[0].(internal.SigningKeyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockKeyManagerMockRecorder) Rotate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockKeyManager)(nil).Rotate))
+}
+
+// RotateDue mocks base method.
+func (m *MockKeyManager) RotateDue() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateDue")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateDue indicates an expected call of RotateDue.
+func (mr *MockKeyManagerMockRecorder) RotateDue() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateDue", reflect.TypeOf((*MockKeyManager)(nil).RotateDue))
+}
+
+// Run mocks base method.
+func (m *MockKeyManager) Run(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockKeyManagerMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockKeyManager)(nil).Run), ctx)
+}
+
+// Signing mocks base method.
+func (m *MockKeyManager) Signing() (internal.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Signing")
+	ret0, _ := ret[0].(internal.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Signing indicates an expected call of Signing.
+func (mr *MockKeyManagerMockRecorder) Signing() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Signing", reflect.TypeOf((*MockKeyManager)(nil).Signing))
+}
+
+// Verification mocks base method.
+func (m *MockKeyManager) Verification() ([]internal.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verification")
+	ret0, _ := ret[0].([]internal.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verification indicates an expected call of Verification.
+func (mr *MockKeyManagerMockRecorder) Verification() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verification", reflect.TypeOf((*MockKeyManager)(nil).Verification))
+}
+
+// VerificationKey mocks base method.
+func (m *MockKeyManager) VerificationKey(kid string) (internal.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerificationKey", kid)
+	ret0, _ := ret[0].(internal.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerificationKey indicates an expected call of VerificationKey.
+func (mr *MockKeyManagerMockRecorder) VerificationKey(kid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerificationKey", reflect.TypeOf((*MockKeyManager)(nil).VerificationKey), kid)
+}
+
+// MockAuthService is a mock of AuthService interface.
+type MockAuthService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthServiceMockRecorder
+}
+
+// MockAuthServiceMockRecorder is the mock recorder for MockAuthService.
+type MockAuthServiceMockRecorder struct {
+	mock *MockAuthService
+}
+
+// NewMockAuthService creates a new mock instance.
+func NewMockAuthService(ctrl *gomock.Controller) *MockAuthService {
+	mock := &MockAuthService{ctrl: ctrl}
+	mock.recorder = &MockAuthServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthService) EXPECT() *MockAuthServiceMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAuthService) Authenticate(accessToken string) (internal.Caller, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", accessToken)
+	ret0, _ := ret[0].(internal.Caller)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAuthServiceMockRecorder) Authenticate(accessToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAuthService)(nil).Authenticate), accessToken)
+}
+
+// AuthorizeAdmin mocks base method.
+func (m *MockAuthService) AuthorizeAdmin(caller internal.Caller) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeAdmin", caller)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeAdmin indicates an expected call of AuthorizeAdmin.
+func (mr *MockAuthServiceMockRecorder) AuthorizeAdmin(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeAdmin", reflect.TypeOf((*MockAuthService)(nil).AuthorizeAdmin), caller)
+}
+
+// ForOrganization mocks base method.
+func (m *MockAuthService) ForOrganization(organizationID uint) internal.AuthService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.AuthService)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockAuthServiceMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockAuthService)(nil).ForOrganization), organizationID)
+}
+
+// ForgotPassword mocks base method.
+func (m *MockAuthService) ForgotPassword(email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgotPassword", email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockAuthServiceMockRecorder) ForgotPassword(email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockAuthService)(nil).ForgotPassword), email)
+}
+
+// Login mocks base method.
+func (m *MockAuthService) Login(email, password, ip string) (internal.LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", email, password, ip)
+	ret0, _ := ret[0].(internal.LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockAuthServiceMockRecorder) Login(email, password, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthService)(nil).Login), email, password, ip)
+}
+
+// LoginMFA mocks base method.
+func (m *MockAuthService) LoginMFA(mfaToken, code, ip string) (internal.LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginMFA", mfaToken, code, ip)
+	ret0, _ := ret[0].(internal.LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginMFA indicates an expected call of LoginMFA.
+func (mr *MockAuthServiceMockRecorder) LoginMFA(mfaToken, code, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginMFA", reflect.TypeOf((*MockAuthService)(nil).LoginMFA), mfaToken, code, ip)
+}
+
+// ResetPassword mocks base method.
+func (m *MockAuthService) ResetPassword(token, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", token, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAuthServiceMockRecorder) ResetPassword(token, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAuthService)(nil).ResetPassword), token, password)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockAuthService) VerifyEmail(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAuthServiceMockRecorder) VerifyEmail(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAuthService)(nil).VerifyEmail), token)
+}
+
+// MockMFAService is a mock of MFAService interface.
+type MockMFAService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFAServiceMockRecorder
+}
+
+// MockMFAServiceMockRecorder is the mock recorder for MockMFAService.
+type MockMFAServiceMockRecorder struct {
+	mock *MockMFAService
+}
+
+// NewMockMFAService creates a new mock instance.
+func NewMockMFAService(ctrl *gomock.Controller) *MockMFAService {
+	mock := &MockMFAService{ctrl: ctrl}
+	mock.recorder = &MockMFAServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFAService) EXPECT() *MockMFAServiceMockRecorder {
+	return m.recorder
+}
+
+// Activate mocks base method.
+func (m *MockMFAService) Activate(userID uint, code string) (internal.RecoveryCodesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Activate", userID, code)
+	ret0, _ := ret[0].(internal.RecoveryCodesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Activate indicates an expected call of Activate.
+func (mr *MockMFAServiceMockRecorder) Activate(userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Activate", reflect.TypeOf((*MockMFAService)(nil).Activate), userID, code)
+}
+
+// AuthorizeAdmin mocks base method.
+func (m *MockMFAService) AuthorizeAdmin(caller internal.Caller) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeAdmin", caller)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeAdmin indicates an expected call of AuthorizeAdmin.
+func (mr *MockMFAServiceMockRecorder) AuthorizeAdmin(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeAdmin", reflect.TypeOf((*MockMFAService)(nil).AuthorizeAdmin), caller)
+}
+
+// AuthorizeEnrollment mocks base method.
+func (m *MockMFAService) AuthorizeEnrollment(caller internal.Caller, userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeEnrollment", caller, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeEnrollment indicates an expected call of AuthorizeEnrollment.
+func (mr *MockMFAServiceMockRecorder) AuthorizeEnrollment(caller, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeEnrollment", reflect.TypeOf((*MockMFAService)(nil).AuthorizeEnrollment), caller, userID)
+}
+
+// Enroll mocks base method.
+func (m *MockMFAService) Enroll(userID uint) (internal.MFAEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enroll", userID)
+	ret0, _ := ret[0].(internal.MFAEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enroll indicates an expected call of Enroll.
+func (mr *MockMFAServiceMockRecorder) Enroll(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enroll", reflect.TypeOf((*MockMFAService)(nil).Enroll), userID)
+}
+
+// Reset mocks base method.
+func (m *MockMFAService) Reset(userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockMFAServiceMockRecorder) Reset(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockMFAService)(nil).Reset), userID)
+}
+
+// MockPasskeyService is a mock of PasskeyService interface.
+type MockPasskeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasskeyServiceMockRecorder
+}
+
+// MockPasskeyServiceMockRecorder is the mock recorder for MockPasskeyService.
+type MockPasskeyServiceMockRecorder struct {
+	mock *MockPasskeyService
+}
+
+// NewMockPasskeyService creates a new mock instance.
+func NewMockPasskeyService(ctrl *gomock.Controller) *MockPasskeyService {
+	mock := &MockPasskeyService{ctrl: ctrl}
+	mock.recorder = &MockPasskeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasskeyService) EXPECT() *MockPasskeyServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthorizePasskeys mocks base method.
+func (m *MockPasskeyService) AuthorizePasskeys(caller internal.Caller, userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizePasskeys", caller, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizePasskeys indicates an expected call of AuthorizePasskeys.
+func (mr *MockPasskeyServiceMockRecorder) AuthorizePasskeys(caller, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizePasskeys", reflect.TypeOf((*MockPasskeyService)(nil).AuthorizePasskeys), caller, userID)
+}
+
+// BeginLogin mocks base method.
+func (m *MockPasskeyService) BeginLogin(email string) (webauthn.RequestOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginLogin", email)
+	ret0, _ := ret[0].(webauthn.RequestOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginLogin indicates an expected call of BeginLogin.
+func (mr *MockPasskeyServiceMockRecorder) BeginLogin(email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginLogin", reflect.TypeOf((*MockPasskeyService)(nil).BeginLogin), email)
+}
+
+// BeginRegistration mocks base method.
+func (m *MockPasskeyService) BeginRegistration(userID uint) (webauthn.CreationOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginRegistration", userID)
+	ret0, _ := ret[0].(webauthn.CreationOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginRegistration indicates an expected call of BeginRegistration.
+func (mr *MockPasskeyServiceMockRecorder) BeginRegistration(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginRegistration", reflect.TypeOf((*MockPasskeyService)(nil).BeginRegistration), userID)
+}
+
+// DeletePasskey mocks base method.
+func (m *MockPasskeyService) DeletePasskey(userID, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePasskey", userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePasskey indicates an expected call of DeletePasskey.
+func (mr *MockPasskeyServiceMockRecorder) DeletePasskey(userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePasskey", reflect.TypeOf((*MockPasskeyService)(nil).DeletePasskey), userID, id)
+}
+
+// FinishLogin mocks base method.
+func (m *MockPasskeyService) FinishLogin(assertion webauthn.Assertion) (internal.LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishLogin", assertion)
+	ret0, _ := ret[0].(internal.LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishLogin indicates an expected call of FinishLogin.
+func (mr *MockPasskeyServiceMockRecorder) FinishLogin(assertion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishLogin", reflect.TypeOf((*MockPasskeyService)(nil).FinishLogin), assertion)
+}
+
+// FinishRegistration mocks base method.
+func (m *MockPasskeyService) FinishRegistration(userID uint, name string, registration webauthn.Registration) (internal.PasskeyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishRegistration", userID, name, registration)
+	ret0, _ := ret[0].(internal.PasskeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishRegistration indicates an expected call of FinishRegistration.
+func (mr *MockPasskeyServiceMockRecorder) FinishRegistration(userID, name, registration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishRegistration", reflect.TypeOf((*MockPasskeyService)(nil).FinishRegistration), userID, name, registration)
+}
+
+// GetPasskeys mocks base method.
+func (m *MockPasskeyService) GetPasskeys(userID uint) (internal.PasskeysResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasskeys", userID)
+	ret0, _ := ret[0].(internal.PasskeysResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasskeys indicates an expected call of GetPasskeys.
+func (mr *MockPasskeyServiceMockRecorder) GetPasskeys(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasskeys", reflect.TypeOf((*MockPasskeyService)(nil).GetPasskeys), userID)
+}
+
+// RenamePasskey mocks base method.
+func (m *MockPasskeyService) RenamePasskey(userID, id uint, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenamePasskey", userID, id, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenamePasskey indicates an expected call of RenamePasskey.
+func (mr *MockPasskeyServiceMockRecorder) RenamePasskey(userID, id, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenamePasskey", reflect.TypeOf((*MockPasskeyService)(nil).RenamePasskey), userID, id, name)
+}
+
+// MockAccessTokenService is a mock of AccessTokenService interface.
+type MockAccessTokenService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessTokenServiceMockRecorder
+}
+
+// MockAccessTokenServiceMockRecorder is the mock recorder for MockAccessTokenService.
+type MockAccessTokenServiceMockRecorder struct {
+	mock *MockAccessTokenService
+}
+
+// NewMockAccessTokenService creates a new mock instance.
+func NewMockAccessTokenService(ctrl *gomock.Controller) *MockAccessTokenService {
+	mock := &MockAccessTokenService{ctrl: ctrl}
+	mock.recorder = &MockAccessTokenServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessTokenService) EXPECT() *MockAccessTokenServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthorizeAccessTokens mocks base method.
+func (m *MockAccessTokenService) AuthorizeAccessTokens(caller internal.Caller, userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeAccessTokens", caller, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeAccessTokens indicates an expected call of AuthorizeAccessTokens.
+func (mr *MockAccessTokenServiceMockRecorder) AuthorizeAccessTokens(caller, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeAccessTokens", reflect.TypeOf((*MockAccessTokenService)(nil).AuthorizeAccessTokens), caller, userID)
+}
+
+// CreateAccessToken mocks base method.
+func (m *MockAccessTokenService) CreateAccessToken(userID uint, request internal.AccessTokenRequest) (internal.CreatedAccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccessToken", userID, request)
+	ret0, _ := ret[0].(internal.CreatedAccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccessToken indicates an expected call of CreateAccessToken.
+func (mr *MockAccessTokenServiceMockRecorder) CreateAccessToken(userID, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccessToken", reflect.TypeOf((*MockAccessTokenService)(nil).CreateAccessToken), userID, request)
+}
+
+// GetAccessTokens mocks base method.
+func (m *MockAccessTokenService) GetAccessTokens(userID uint) (internal.AccessTokensResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessTokens", userID)
+	ret0, _ := ret[0].(internal.AccessTokensResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessTokens indicates an expected call of GetAccessTokens.
+func (mr *MockAccessTokenServiceMockRecorder) GetAccessTokens(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessTokens", reflect.TypeOf((*MockAccessTokenService)(nil).GetAccessTokens), userID)
+}
+
+// RevokeAccessToken mocks base method.
+func (m *MockAccessTokenService) RevokeAccessToken(userID, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAccessToken", userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAccessToken indicates an expected call of RevokeAccessToken.
+func (mr *MockAccessTokenServiceMockRecorder) RevokeAccessToken(userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccessToken", reflect.TypeOf((*MockAccessTokenService)(nil).RevokeAccessToken), userID, id)
+}
+
+// MockOAuthService is a mock of OAuthService interface.
+type MockOAuthService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthServiceMockRecorder
+}
+
+// MockOAuthServiceMockRecorder is the mock recorder for MockOAuthService.
+type MockOAuthServiceMockRecorder struct {
+	mock *MockOAuthService
+}
+
+// NewMockOAuthService creates a new mock instance.
+func NewMockOAuthService(ctrl *gomock.Controller) *MockOAuthService {
+	mock := &MockOAuthService{ctrl: ctrl}
+	mock.recorder = &MockOAuthServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthService) EXPECT() *MockOAuthServiceMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *MockOAuthService) Authorize(request internal.OAuthAuthorizeRequest) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", request)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockOAuthServiceMockRecorder) Authorize(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockOAuthService)(nil).Authorize), request)
+}
+
+// AuthorizeAdmin mocks base method.
+func (m *MockOAuthService) AuthorizeAdmin(caller internal.Caller) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeAdmin", caller)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeAdmin indicates an expected call of AuthorizeAdmin.
+func (mr *MockOAuthServiceMockRecorder) AuthorizeAdmin(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeAdmin", reflect.TypeOf((*MockOAuthService)(nil).AuthorizeAdmin), caller)
+}
+
+// GetClient mocks base method.
+func (m *MockOAuthService) GetClient(clientID string) (internal.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClient", clientID)
+	ret0, _ := ret[0].(internal.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClient indicates an expected call of GetClient.
+func (mr *MockOAuthServiceMockRecorder) GetClient(clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClient", reflect.TypeOf((*MockOAuthService)(nil).GetClient), clientID)
+}
+
+// Introspect mocks base method.
+func (m *MockOAuthService) Introspect(clientID, clientSecret, token string) (internal.OAuthIntrospection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Introspect", clientID, clientSecret, token)
+	ret0, _ := ret[0].(internal.OAuthIntrospection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Introspect indicates an expected call of Introspect.
+func (mr *MockOAuthServiceMockRecorder) Introspect(clientID, clientSecret, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Introspect", reflect.TypeOf((*MockOAuthService)(nil).Introspect), clientID, clientSecret, token)
+}
+
+// RedirectURI mocks base method.
+func (m *MockOAuthService) RedirectURI(clientID, redirectURI string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedirectURI", clientID, redirectURI)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedirectURI indicates an expected call of RedirectURI.
+func (mr *MockOAuthServiceMockRecorder) RedirectURI(clientID, redirectURI interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedirectURI", reflect.TypeOf((*MockOAuthService)(nil).RedirectURI), clientID, redirectURI)
+}
+
+// RegisterClient mocks base method.
+func (m *MockOAuthService) RegisterClient(request internal.OAuthClientRequest) (internal.RegisteredOAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterClient", request)
+	ret0, _ := ret[0].(internal.RegisteredOAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterClient indicates an expected call of RegisterClient.
+func (mr *MockOAuthServiceMockRecorder) RegisterClient(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterClient", reflect.TypeOf((*MockOAuthService)(nil).RegisterClient), request)
+}
+
+// Revoke mocks base method.
+func (m *MockOAuthService) Revoke(clientID, clientSecret, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", clientID, clientSecret, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockOAuthServiceMockRecorder) Revoke(clientID, clientSecret, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockOAuthService)(nil).Revoke), clientID, clientSecret, token)
+}
+
+// Token mocks base method.
+func (m *MockOAuthService) Token(request internal.OAuthTokenRequest) (internal.OAuthTokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Token", request)
+	ret0, _ := ret[0].(internal.OAuthTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Token indicates an expected call of Token.
+func (mr *MockOAuthServiceMockRecorder) Token(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MockOAuthService)(nil).Token), request)
+}
+
+// MockLockoutService is a mock of LockoutService interface.
+type MockLockoutService struct {
+	ctrl     *gomock.Controller
+	recorder *MockLockoutServiceMockRecorder
+}
+
+// MockLockoutServiceMockRecorder is the mock recorder for MockLockoutService.
+type MockLockoutServiceMockRecorder struct {
+	mock *MockLockoutService
+}
+
+// NewMockLockoutService creates a new mock instance.
+func NewMockLockoutService(ctrl *gomock.Controller) *MockLockoutService {
+	mock := &MockLockoutService{ctrl: ctrl}
+	mock.recorder = &MockLockoutServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLockoutService) EXPECT() *MockLockoutServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthorizeAdmin mocks base method.
+func (m *MockLockoutService) AuthorizeAdmin(caller internal.Caller) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeAdmin", caller)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeAdmin indicates an expected call of AuthorizeAdmin.
+func (mr *MockLockoutServiceMockRecorder) AuthorizeAdmin(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeAdmin", reflect.TypeOf((*MockLockoutService)(nil).AuthorizeAdmin), caller)
+}
+
+// Check mocks base method.
+func (m *MockLockoutService) Check(email, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", email, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockLockoutServiceMockRecorder) Check(email, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockLockoutService)(nil).Check), email, ip)
+}
+
+// Fail mocks base method.
+func (m *MockLockoutService) Fail(email, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", email, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MockLockoutServiceMockRecorder) Fail(email, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockLockoutService)(nil).Fail), email, ip)
+}
+
+// ForOrganization mocks base method.
+func (m *MockLockoutService) ForOrganization(organizationID uint) internal.LockoutService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.LockoutService)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockLockoutServiceMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockLockoutService)(nil).ForOrganization), organizationID)
+}
+
+// Succeed mocks base method.
+func (m *MockLockoutService) Succeed(userID uint, email, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Succeed", userID, email, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Succeed indicates an expected call of Succeed.
+func (mr *MockLockoutServiceMockRecorder) Succeed(userID, email, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Succeed", reflect.TypeOf((*MockLockoutService)(nil).Succeed), userID, email, ip)
+}
+
+// Unlock mocks base method.
+func (m *MockLockoutService) Unlock(userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockLockoutServiceMockRecorder) Unlock(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockLockoutService)(nil).Unlock), userID)
+}
+
+// MockUserStatusService is a mock of UserStatusService interface.
+type MockUserStatusService struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserStatusServiceMockRecorder
+}
+
+// MockUserStatusServiceMockRecorder is the mock recorder for MockUserStatusService.
+type MockUserStatusServiceMockRecorder struct {
+	mock *MockUserStatusService
+}
+
+// NewMockUserStatusService creates a new mock instance.
+func NewMockUserStatusService(ctrl *gomock.Controller) *MockUserStatusService {
+	mock := &MockUserStatusService{ctrl: ctrl}
+	mock.recorder = &MockUserStatusServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserStatusService) EXPECT() *MockUserStatusServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthorizeAdmin mocks base method.
+func (m *MockUserStatusService) AuthorizeAdmin(caller internal.Caller) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeAdmin", caller)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeAdmin indicates an expected call of AuthorizeAdmin.
+func (mr *MockUserStatusServiceMockRecorder) AuthorizeAdmin(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeAdmin", reflect.TypeOf((*MockUserStatusService)(nil).AuthorizeAdmin), caller)
+}
+
+// Disable mocks base method.
+func (m *MockUserStatusService) Disable(id uint, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Disable", id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Disable indicates an expected call of Disable.
+func (mr *MockUserStatusServiceMockRecorder) Disable(id, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disable", reflect.TypeOf((*MockUserStatusService)(nil).Disable), id, reason)
+}
+
+// Reactivate mocks base method.
+func (m *MockUserStatusService) Reactivate(id uint, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reactivate", id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reactivate indicates an expected call of Reactivate.
+func (mr *MockUserStatusServiceMockRecorder) Reactivate(id, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reactivate", reflect.TypeOf((*MockUserStatusService)(nil).Reactivate), id, reason)
+}
+
+// ReactivateExpired mocks base method.
+func (m *MockUserStatusService) ReactivateExpired() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReactivateExpired")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReactivateExpired indicates an expected call of ReactivateExpired.
+func (mr *MockUserStatusServiceMockRecorder) ReactivateExpired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReactivateExpired", reflect.TypeOf((*MockUserStatusService)(nil).ReactivateExpired))
+}
+
+// Suspend mocks base method.
+func (m *MockUserStatusService) Suspend(id uint, reason string, until *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Suspend", id, reason, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Suspend indicates an expected call of Suspend.
+func (mr *MockUserStatusServiceMockRecorder) Suspend(id, reason, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Suspend", reflect.TypeOf((*MockUserStatusService)(nil).Suspend), id, reason, until)
+}
+
+// MockJobService is a mock of JobService interface.
+type MockJobService struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobServiceMockRecorder
+}
+
+// MockJobServiceMockRecorder is the mock recorder for MockJobService.
+type MockJobServiceMockRecorder struct {
+	mock *MockJobService
+}
+
+// NewMockJobService creates a new mock instance.
+func NewMockJobService(ctrl *gomock.Controller) *MockJobService {
+	mock := &MockJobService{ctrl: ctrl}
+	mock.recorder = &MockJobServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobService) EXPECT() *MockJobServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthorizeJob mocks base method.
+func (m *MockJobService) AuthorizeJob(caller internal.Caller, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeJob", caller, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeJob indicates an expected call of AuthorizeJob.
+func (mr *MockJobServiceMockRecorder) AuthorizeJob(caller, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeJob", reflect.TypeOf((*MockJobService)(nil).AuthorizeJob), caller, id)
+}
+
+// CancelJob mocks base method.
+func (m *MockJobService) CancelJob(id uint) (internal.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelJob", id)
+	ret0, _ := ret[0].(internal.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelJob indicates an expected call of CancelJob.
+func (mr *MockJobServiceMockRecorder) CancelJob(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelJob", reflect.TypeOf((*MockJobService)(nil).CancelJob), id)
+}
+
+// Enqueue mocks base method.
+func (m *MockJobService) Enqueue(jobType string, createdBy uint, payload interface{}) (internal.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", jobType, createdBy, payload)
+	ret0, _ := ret[0].(internal.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockJobServiceMockRecorder) Enqueue(jobType, createdBy, payload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockJobService)(nil).Enqueue), jobType, createdBy, payload)
+}
+
+// GetJob mocks base method.
+func (m *MockJobService) GetJob(id uint) (internal.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", id)
+	ret0, _ := ret[0].(internal.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockJobServiceMockRecorder) GetJob(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockJobService)(nil).GetJob), id)
+}
+
+// MockJobRunner is a mock of JobRunner interface.
+type MockJobRunner struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobRunnerMockRecorder
+}
+
+// MockJobRunnerMockRecorder is the mock recorder for MockJobRunner.
+type MockJobRunnerMockRecorder struct {
+	mock *MockJobRunner
+}
+
+// NewMockJobRunner creates a new mock instance.
+func NewMockJobRunner(ctrl *gomock.Controller) *MockJobRunner {
+	mock := &MockJobRunner{ctrl: ctrl}
+	mock.recorder = &MockJobRunnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobRunner) EXPECT() *MockJobRunnerMockRecorder {
+	return m.recorder
+}
+
+// AuthorizeJob mocks base method.
+func (m *MockJobRunner) AuthorizeJob(caller internal.Caller, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeJob", caller, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeJob indicates an expected call of AuthorizeJob.
+func (mr *MockJobRunnerMockRecorder) AuthorizeJob(caller, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeJob", reflect.TypeOf((*MockJobRunner)(nil).AuthorizeJob), caller, id)
+}
+
+// CancelJob mocks base method.
+func (m *MockJobRunner) CancelJob(id uint) (internal.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelJob", id)
+	ret0, _ := ret[0].(internal.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelJob indicates an expected call of CancelJob.
+func (mr *MockJobRunnerMockRecorder) CancelJob(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelJob", reflect.TypeOf((*MockJobRunner)(nil).CancelJob), id)
+}
+
+// Enqueue mocks base method.
+func (m *MockJobRunner) Enqueue(jobType string, createdBy uint, payload interface{}) (internal.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", jobType, createdBy, payload)
+	ret0, _ := ret[0].(internal.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockJobRunnerMockRecorder) Enqueue(jobType, createdBy, payload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockJobRunner)(nil).Enqueue), jobType, createdBy, payload)
+}
+
+// GetJob mocks base method.
+func (m *MockJobRunner) GetJob(id uint) (internal.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", id)
+	ret0, _ := ret[0].(internal.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockJobRunnerMockRecorder) GetJob(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockJobRunner)(nil).GetJob), id)
+}
+
+// Init mocks base method.
+func (m *MockJobRunner) Init() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Init")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockJobRunnerMockRecorder) Init() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockJobRunner)(nil).Init))
+}
+
+// Register mocks base method.
+func (m *MockJobRunner) Register(jobType string, handler internal.JobHandler) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Register", jobType, handler)
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockJobRunnerMockRecorder) Register(jobType, handler interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockJobRunner)(nil).Register), jobType, handler)
+}
+
+// Run mocks base method.
+func (m *MockJobRunner) Run(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockJobRunnerMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockJobRunner)(nil).Run), ctx)
+}
+
+// MockDynamicGroupService is a mock of DynamicGroupService interface.
+type MockDynamicGroupService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDynamicGroupServiceMockRecorder
+}
+
+// MockDynamicGroupServiceMockRecorder is the mock recorder for MockDynamicGroupService.
+type MockDynamicGroupServiceMockRecorder struct {
+	mock *MockDynamicGroupService
+}
+
+// NewMockDynamicGroupService creates a new mock instance.
+func NewMockDynamicGroupService(ctrl *gomock.Controller) *MockDynamicGroupService {
+	mock := &MockDynamicGroupService{ctrl: ctrl}
+	mock.recorder = &MockDynamicGroupServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDynamicGroupService) EXPECT() *MockDynamicGroupServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthorizePreview mocks base method.
+func (m *MockDynamicGroupService) AuthorizePreview(caller internal.Caller) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizePreview", caller)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizePreview indicates an expected call of AuthorizePreview.
+func (mr *MockDynamicGroupServiceMockRecorder) AuthorizePreview(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizePreview", reflect.TypeOf((*MockDynamicGroupService)(nil).AuthorizePreview), caller)
+}
+
+// CreateGroup mocks base method.
+func (m *MockDynamicGroupService) CreateGroup(request internal.GroupRequest) (internal.GroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroup", request)
+	ret0, _ := ret[0].(internal.GroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGroup indicates an expected call of CreateGroup.
+func (mr *MockDynamicGroupServiceMockRecorder) CreateGroup(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockDynamicGroupService)(nil).CreateGroup), request)
+}
+
+// ForOrganization mocks base method.
+func (m *MockDynamicGroupService) ForOrganization(organizationID uint) internal.DynamicGroupService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.DynamicGroupService)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockDynamicGroupServiceMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockDynamicGroupService)(nil).ForOrganization), organizationID)
+}
+
+// Init mocks base method.
+func (m *MockDynamicGroupService) Init() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Init")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockDynamicGroupServiceMockRecorder) Init() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockDynamicGroupService)(nil).Init))
+}
+
+// PreviewRule mocks base method.
+func (m *MockDynamicGroupService) PreviewRule(rule string, page, perPage uint) (internal.UsersResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreviewRule", rule, page, perPage)
+	ret0, _ := ret[0].(internal.UsersResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreviewRule indicates an expected call of PreviewRule.
+func (mr *MockDynamicGroupServiceMockRecorder) PreviewRule(rule, page, perPage interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewRule", reflect.TypeOf((*MockDynamicGroupService)(nil).PreviewRule), rule, page, perPage)
+}
+
+// Reconcile mocks base method.
+func (m *MockDynamicGroupService) Reconcile() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconcile")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reconcile indicates an expected call of Reconcile.
+func (mr *MockDynamicGroupServiceMockRecorder) Reconcile() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockDynamicGroupService)(nil).Reconcile))
+}
+
+// Run mocks base method.
+func (m *MockDynamicGroupService) Run(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockDynamicGroupServiceMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockDynamicGroupService)(nil).Run), ctx)
+}
+
+// SetRule mocks base method.
+func (m *MockDynamicGroupService) SetRule(groupID uint, rule string) (internal.BatchMembershipResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRule", groupID, rule)
+	ret0, _ := ret[0].(internal.BatchMembershipResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRule indicates an expected call of SetRule.
+func (mr *MockDynamicGroupServiceMockRecorder) SetRule(groupID, rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRule", reflect.TypeOf((*MockDynamicGroupService)(nil).SetRule), groupID, rule)
+}
+
+// SyncUser mocks base method.
+func (m *MockDynamicGroupService) SyncUser(userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncUser", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncUser indicates an expected call of SyncUser.
+func (mr *MockDynamicGroupServiceMockRecorder) SyncUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncUser", reflect.TypeOf((*MockDynamicGroupService)(nil).SyncUser), userID)
+}
+
+// MockAccessRequestService is a mock of AccessRequestService interface.
+type MockAccessRequestService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessRequestServiceMockRecorder
+}
+
+// MockAccessRequestServiceMockRecorder is the mock recorder for MockAccessRequestService.
+type MockAccessRequestServiceMockRecorder struct {
+	mock *MockAccessRequestService
+}
+
+// NewMockAccessRequestService creates a new mock instance.
+func NewMockAccessRequestService(ctrl *gomock.Controller) *MockAccessRequestService {
+	mock := &MockAccessRequestService{ctrl: ctrl}
+	mock.recorder = &MockAccessRequestServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessRequestService) EXPECT() *MockAccessRequestServiceMockRecorder {
+	return m.recorder
+}
+
+// Approve mocks base method.
+func (m *MockAccessRequestService) Approve(id, approver uint, reason string) (internal.AccessRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Approve", id, approver, reason)
+	ret0, _ := ret[0].(internal.AccessRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Approve indicates an expected call of Approve.
+func (mr *MockAccessRequestServiceMockRecorder) Approve(id, approver, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Approve", reflect.TypeOf((*MockAccessRequestService)(nil).Approve), id, approver, reason)
+}
+
+// AuthorizeDecision mocks base method.
+func (m *MockAccessRequestService) AuthorizeDecision(caller internal.Caller, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeDecision", caller, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeDecision indicates an expected call of AuthorizeDecision.
+func (mr *MockAccessRequestServiceMockRecorder) AuthorizeDecision(caller, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeDecision", reflect.TypeOf((*MockAccessRequestService)(nil).AuthorizeDecision), caller, id)
+}
+
+// AuthorizeList mocks base method.
+func (m *MockAccessRequestService) AuthorizeList(caller internal.Caller, filter internal.AccessRequestsFilter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeList", caller, filter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeList indicates an expected call of AuthorizeList.
+func (mr *MockAccessRequestServiceMockRecorder) AuthorizeList(caller, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeList", reflect.TypeOf((*MockAccessRequestService)(nil).AuthorizeList), caller, filter)
+}
+
+// AuthorizeView mocks base method.
+func (m *MockAccessRequestService) AuthorizeView(caller internal.Caller, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeView", caller, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeView indicates an expected call of AuthorizeView.
+func (mr *MockAccessRequestServiceMockRecorder) AuthorizeView(caller, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeView", reflect.TypeOf((*MockAccessRequestService)(nil).AuthorizeView), caller, id)
+}
+
+// Deny mocks base method.
+func (m *MockAccessRequestService) Deny(id, approver uint, reason string) (internal.AccessRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deny", id, approver, reason)
+	ret0, _ := ret[0].(internal.AccessRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Deny indicates an expected call of Deny.
+func (mr *MockAccessRequestServiceMockRecorder) Deny(id, approver, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deny", reflect.TypeOf((*MockAccessRequestService)(nil).Deny), id, approver, reason)
+}
+
+// ExpireRequests mocks base method.
+func (m *MockAccessRequestService) ExpireRequests() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireRequests")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExpireRequests indicates an expected call of ExpireRequests.
+func (mr *MockAccessRequestServiceMockRecorder) ExpireRequests() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireRequests", reflect.TypeOf((*MockAccessRequestService)(nil).ExpireRequests))
+}
+
+// ForOrganization mocks base method.
+func (m *MockAccessRequestService) ForOrganization(organizationID uint) internal.AccessRequestService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.AccessRequestService)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockAccessRequestServiceMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockAccessRequestService)(nil).ForOrganization), organizationID)
+}
+
+// GetAccessRequest mocks base method.
+func (m *MockAccessRequestService) GetAccessRequest(id uint) (internal.AccessRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessRequest", id)
+	ret0, _ := ret[0].(internal.AccessRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessRequest indicates an expected call of GetAccessRequest.
+func (mr *MockAccessRequestServiceMockRecorder) GetAccessRequest(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessRequest", reflect.TypeOf((*MockAccessRequestService)(nil).GetAccessRequest), id)
+}
+
+// GetAccessRequests mocks base method.
+func (m *MockAccessRequestService) GetAccessRequests(page, perPage uint, filter internal.AccessRequestsFilter) (internal.AccessRequestsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessRequests", page, perPage, filter)
+	ret0, _ := ret[0].(internal.AccessRequestsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessRequests indicates an expected call of GetAccessRequests.
+func (mr *MockAccessRequestServiceMockRecorder) GetAccessRequests(page, perPage, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessRequests", reflect.TypeOf((*MockAccessRequestService)(nil).GetAccessRequests), page, perPage, filter)
+}
+
+// Init mocks base method.
+func (m *MockAccessRequestService) Init() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Init")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockAccessRequestServiceMockRecorder) Init() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockAccessRequestService)(nil).Init))
+}
+
+// RequestAccess mocks base method.
+func (m *MockAccessRequestService) RequestAccess(request internal.AccessRequestRequest) (internal.AccessRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestAccess", request)
+	ret0, _ := ret[0].(internal.AccessRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestAccess indicates an expected call of RequestAccess.
+func (mr *MockAccessRequestServiceMockRecorder) RequestAccess(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestAccess", reflect.TypeOf((*MockAccessRequestService)(nil).RequestAccess), request)
+}
+
+// Run mocks base method.
+func (m *MockAccessRequestService) Run(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockAccessRequestServiceMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockAccessRequestService)(nil).Run), ctx)
+}
+
+// MockAccessReviewService is a mock of AccessReviewService interface.
+type MockAccessReviewService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessReviewServiceMockRecorder
+}
+
+// MockAccessReviewServiceMockRecorder is the mock recorder for MockAccessReviewService.
+type MockAccessReviewServiceMockRecorder struct {
+	mock *MockAccessReviewService
+}
+
+// NewMockAccessReviewService creates a new mock instance.
+func NewMockAccessReviewService(ctrl *gomock.Controller) *MockAccessReviewService {
+	mock := &MockAccessReviewService{ctrl: ctrl}
+	mock.recorder = &MockAccessReviewServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessReviewService) EXPECT() *MockAccessReviewServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthorizeAdmin mocks base method.
+func (m *MockAccessReviewService) AuthorizeAdmin(caller internal.Caller) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeAdmin", caller)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeAdmin indicates an expected call of AuthorizeAdmin.
+func (mr *MockAccessReviewServiceMockRecorder) AuthorizeAdmin(caller interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeAdmin", reflect.TypeOf((*MockAccessReviewService)(nil).AuthorizeAdmin), caller)
+}
+
+// AuthorizeItems mocks base method.
+func (m *MockAccessReviewService) AuthorizeItems(caller internal.Caller, reviewerID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeItems", caller, reviewerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeItems indicates an expected call of AuthorizeItems.
+func (mr *MockAccessReviewServiceMockRecorder) AuthorizeItems(caller, reviewerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeItems", reflect.TypeOf((*MockAccessReviewService)(nil).AuthorizeItems), caller, reviewerID)
+}
+
+// AuthorizeReview mocks base method.
+func (m *MockAccessReviewService) AuthorizeReview(caller internal.Caller, campaignID, itemID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeReview", caller, campaignID, itemID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeReview indicates an expected call of AuthorizeReview.
+func (mr *MockAccessReviewServiceMockRecorder) AuthorizeReview(caller, campaignID, itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeReview", reflect.TypeOf((*MockAccessReviewService)(nil).AuthorizeReview), caller, campaignID, itemID)
+}
+
+// Certify mocks base method.
+func (m *MockAccessReviewService) Certify(campaignID, itemID, reviewer uint, comment string) (internal.ReviewItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Certify", campaignID, itemID, reviewer, comment)
+	ret0, _ := ret[0].(internal.ReviewItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Certify indicates an expected call of Certify.
+func (mr *MockAccessReviewServiceMockRecorder) Certify(campaignID, itemID, reviewer, comment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Certify", reflect.TypeOf((*MockAccessReviewService)(nil).Certify), campaignID, itemID, reviewer, comment)
+}
+
+// CloseCampaign mocks base method.
+func (m *MockAccessReviewService) CloseCampaign(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseCampaign", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseCampaign indicates an expected call of CloseCampaign.
+func (mr *MockAccessReviewServiceMockRecorder) CloseCampaign(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseCampaign", reflect.TypeOf((*MockAccessReviewService)(nil).CloseCampaign), id)
+}
+
+// CloseDueCampaigns mocks base method.
+func (m *MockAccessReviewService) CloseDueCampaigns() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseDueCampaigns")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseDueCampaigns indicates an expected call of CloseDueCampaigns.
+func (mr *MockAccessReviewServiceMockRecorder) CloseDueCampaigns() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseDueCampaigns", reflect.TypeOf((*MockAccessReviewService)(nil).CloseDueCampaigns))
+}
+
+// CreateCampaign mocks base method.
+func (m *MockAccessReviewService) CreateCampaign(request internal.ReviewCampaignRequest) (internal.ReviewCampaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCampaign", request)
+	ret0, _ := ret[0].(internal.ReviewCampaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCampaign indicates an expected call of CreateCampaign.
+func (mr *MockAccessReviewServiceMockRecorder) CreateCampaign(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCampaign", reflect.TypeOf((*MockAccessReviewService)(nil).CreateCampaign), request)
+}
+
+// ForOrganization mocks base method.
+func (m *MockAccessReviewService) ForOrganization(organizationID uint) internal.AccessReviewService {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForOrganization", organizationID)
+	ret0, _ := ret[0].(internal.AccessReviewService)
+	return ret0
+}
+
+// ForOrganization indicates an expected call of ForOrganization.
+func (mr *MockAccessReviewServiceMockRecorder) ForOrganization(organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForOrganization", reflect.TypeOf((*MockAccessReviewService)(nil).ForOrganization), organizationID)
+}
+
+// GetCampaign mocks base method.
+func (m *MockAccessReviewService) GetCampaign(id uint) (internal.ReviewCampaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaign", id)
+	ret0, _ := ret[0].(internal.ReviewCampaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaign indicates an expected call of GetCampaign.
+func (mr *MockAccessReviewServiceMockRecorder) GetCampaign(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaign", reflect.TypeOf((*MockAccessReviewService)(nil).GetCampaign), id)
+}
+
+// GetCampaigns mocks base method.
+func (m *MockAccessReviewService) GetCampaigns(page, perPage uint, status string) (internal.ReviewCampaignsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaigns", page, perPage, status)
+	ret0, _ := ret[0].(internal.ReviewCampaignsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaigns indicates an expected call of GetCampaigns.
+func (mr *MockAccessReviewServiceMockRecorder) GetCampaigns(page, perPage, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaigns", reflect.TypeOf((*MockAccessReviewService)(nil).GetCampaigns), page, perPage, status)
+}
+
+// GetReviewItems mocks base method.
+func (m *MockAccessReviewService) GetReviewItems(campaignID, page, perPage uint, filter internal.ReviewItemsFilter) (internal.ReviewItemsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReviewItems", campaignID, page, perPage, filter)
+	ret0, _ := ret[0].(internal.ReviewItemsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReviewItems indicates an expected call of GetReviewItems.
+func (mr *MockAccessReviewServiceMockRecorder) GetReviewItems(campaignID, page, perPage, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReviewItems", reflect.TypeOf((*MockAccessReviewService)(nil).GetReviewItems), campaignID, page, perPage, filter)
+}
+
+// Init mocks base method.
+func (m *MockAccessReviewService) Init() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Init")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockAccessReviewServiceMockRecorder) Init() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockAccessReviewService)(nil).Init))
+}
+
+// Report mocks base method.
+func (m *MockAccessReviewService) Report(id uint) (internal.SignedReviewReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Report", id)
+	ret0, _ := ret[0].(internal.SignedReviewReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Report indicates an expected call of Report.
+func (mr *MockAccessReviewServiceMockRecorder) Report(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockAccessReviewService)(nil).Report), id)
+}
+
+// Revoke mocks base method.
+func (m *MockAccessReviewService) Revoke(campaignID, itemID, reviewer uint, comment string) (internal.ReviewItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", campaignID, itemID, reviewer, comment)
+	ret0, _ := ret[0].(internal.ReviewItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockAccessReviewServiceMockRecorder) Revoke(campaignID, itemID, reviewer, comment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockAccessReviewService)(nil).Revoke), campaignID, itemID, reviewer, comment)
+}
+
+// Run mocks base method.
+func (m *MockAccessReviewService) Run(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockAccessReviewServiceMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockAccessReviewService)(nil).Run), ctx)
+}
+
+// MockMembershipExpiryService is a mock of MembershipExpiryService interface.
+type MockMembershipExpiryService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMembershipExpiryServiceMockRecorder
+}
+
+// MockMembershipExpiryServiceMockRecorder is the mock recorder for MockMembershipExpiryService.
+type MockMembershipExpiryServiceMockRecorder struct {
+	mock *MockMembershipExpiryService
+}
+
+// NewMockMembershipExpiryService creates a new mock instance.
+func NewMockMembershipExpiryService(ctrl *gomock.Controller) *MockMembershipExpiryService {
+	mock := &MockMembershipExpiryService{ctrl: ctrl}
+	mock.recorder = &MockMembershipExpiryServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMembershipExpiryService) EXPECT() *MockMembershipExpiryServiceMockRecorder {
+	return m.recorder
+}
+
+// ExpireMemberships mocks base method.
+func (m *MockMembershipExpiryService) ExpireMemberships() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireMemberships")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExpireMemberships indicates an expected call of ExpireMemberships.
+func (mr *MockMembershipExpiryServiceMockRecorder) ExpireMemberships() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireMemberships", reflect.TypeOf((*MockMembershipExpiryService)(nil).ExpireMemberships))
+}
+
+// Init mocks base method.
+func (m *MockMembershipExpiryService) Init() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Init")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockMembershipExpiryServiceMockRecorder) Init() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockMembershipExpiryService)(nil).Init))
+}
+
+// Run mocks base method.
+func (m *MockMembershipExpiryService) Run(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockMembershipExpiryServiceMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockMembershipExpiryService)(nil).Run), ctx)
+}
+
+// MockRetentionService is a mock of RetentionService interface.
+type MockRetentionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockRetentionServiceMockRecorder
+}
+
+// MockRetentionServiceMockRecorder is the mock recorder for MockRetentionService.
+type MockRetentionServiceMockRecorder struct {
+	mock *MockRetentionService
+}
+
+// NewMockRetentionService creates a new mock instance.
+func NewMockRetentionService(ctrl *gomock.Controller) *MockRetentionService {
+	mock := &MockRetentionService{ctrl: ctrl}
+	mock.recorder = &MockRetentionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRetentionService) EXPECT() *MockRetentionServiceMockRecorder {
+	return m.recorder
+}
+
+// Purge mocks base method.
+func (m *MockRetentionService) Purge() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockRetentionServiceMockRecorder) Purge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockRetentionService)(nil).Purge))
+}
+
+// MockPrivacyService is a mock of PrivacyService interface.
+type MockPrivacyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrivacyServiceMockRecorder
+}
+
+// MockPrivacyServiceMockRecorder is the mock recorder for MockPrivacyService.
+type MockPrivacyServiceMockRecorder struct {
+	mock *MockPrivacyService
+}
+
+// NewMockPrivacyService creates a new mock instance.
+func NewMockPrivacyService(ctrl *gomock.Controller) *MockPrivacyService {
+	mock := &MockPrivacyService{ctrl: ctrl}
+	mock.recorder = &MockPrivacyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrivacyService) EXPECT() *MockPrivacyServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthorizeSubject mocks base method.
+func (m *MockPrivacyService) AuthorizeSubject(caller internal.Caller, userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeSubject", caller, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeSubject indicates an expected call of AuthorizeSubject.
+func (mr *MockPrivacyServiceMockRecorder) AuthorizeSubject(caller, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeSubject", reflect.TypeOf((*MockPrivacyService)(nil).AuthorizeSubject), caller, userID)
+}
+
+// Erase mocks base method.
+func (m *MockPrivacyService) Erase(userID uint, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Erase", userID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Erase indicates an expected call of Erase.
+func (mr *MockPrivacyServiceMockRecorder) Erase(userID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Erase", reflect.TypeOf((*MockPrivacyService)(nil).Erase), userID, reason)
+}
+
+// Export mocks base method.
+func (m *MockPrivacyService) Export(userID uint) (internal.UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", userID)
+	ret0, _ := ret[0].(internal.UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockPrivacyServiceMockRecorder) Export(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockPrivacyService)(nil).Export), userID)
+}
+
+// MockAuditor is a mock of Auditor interface.
+type MockAuditor struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditorMockRecorder
+}
+
+// MockAuditorMockRecorder is the mock recorder for MockAuditor.
+type MockAuditorMockRecorder struct {
+	mock *MockAuditor
+}
+
+// NewMockAuditor creates a new mock instance.
+func NewMockAuditor(ctrl *gomock.Controller) *MockAuditor {
+	mock := &MockAuditor{ctrl: ctrl}
+	mock.recorder = &MockAuditorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditor) EXPECT() *MockAuditorMockRecorder {
+	return m.recorder
+}
+
+// Record mocks base method.
+func (m *MockAuditor) Record(event internal.AuditEvent) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Record", event)
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditorMockRecorder) Record(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditor)(nil).Record), event)
+}
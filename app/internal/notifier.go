@@ -0,0 +1,12 @@
+package internal
+
+//go:generate mockgen -source=notifier.go  -destination=mock/notifier.go -package=mock
+type Notifier interface {
+	Notify(message Message) (err error)
+}
+
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
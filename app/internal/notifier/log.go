@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"usermanagement/app/internal"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// logNotifier is meant for local development and tests. Messages are appended
+// as JSON lines to the configured file or written to the log when no file is set.
+type logNotifier struct {
+	mu   sync.Mutex
+	file string
+}
+
+func NewLogNotifier(file string) *logNotifier {
+	return &logNotifier{
+		file: file,
+	}
+}
+
+func (l *logNotifier) Notify(message internal.Message) (err error) {
+	if l.file == "" {
+		log.WithFields(log.Fields{
+			"to":      message.To,
+			"subject": message.Subject,
+			"body":    message.Body,
+		}).Info("notification")
+		return nil
+	}
+	line, err := json.Marshal(message)
+	if err != nil {
+		return errors.Wrap(err, "marshal notification failed")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "open notification file failed")
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		return errors.Wrap(err, "write notification failed")
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/notifier"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogNotifier(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "notifications.log")
+	logNotifier := notifier.NewLogNotifier(file)
+
+	t.Run("append messages to file", func(t *testing.T) {
+		message := internal.Message{To: "test@gmail.com", Subject: "subject", Body: "body"}
+		assert.NoError(t, logNotifier.Notify(message))
+		assert.NoError(t, logNotifier.Notify(message))
+		content, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		assert.Len(t, lines, 2)
+		var written internal.Message
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &written))
+		assert.Equal(t, message, written)
+	})
+}
@@ -0,0 +1,47 @@
+package notifier
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"usermanagement/app/internal"
+
+	"github.com/pkg/errors"
+)
+
+type smtpNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPNotifier(host string, port int, username string, password string, from string) *smtpNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpNotifier{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (s *smtpNotifier) Notify(message internal.Message) (err error) {
+	if message.To == "" {
+		return errors.New("missing recipient for mail")
+	}
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", s.from)
+	fmt.Fprintf(&body, "To: %s\r\n", message.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", message.Subject)
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	body.WriteString(message.Body)
+	body.WriteString("\r\n")
+	err = smtp.SendMail(s.addr, s.auth, s.from, []string{message.To}, []byte(body.String()))
+	if err != nil {
+		return errors.Wrap(err, "send mail failed")
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+package notifier_test
+
+import (
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/notifier"
+	"usermanagement/app/internal/notifier/smtptest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMTPNotifier(t *testing.T) {
+	server, err := smtptest.NewServer()
+	assert.NoError(t, err)
+	defer server.Close()
+	smtpNotifier := notifier.NewSMTPNotifier(server.Host, server.Port, "", "", "no-reply@test.com")
+
+	t.Run("send mail successfully", func(t *testing.T) {
+		err := smtpNotifier.Notify(internal.Message{To: "test@gmail.com", Subject: "subject", Body: "body"})
+		assert.NoError(t, err)
+		messages := server.Messages()
+		assert.Len(t, messages, 1)
+		assert.Equal(t, "no-reply@test.com", messages[0].From)
+		assert.Equal(t, []string{"test@gmail.com"}, messages[0].To)
+		assert.Contains(t, messages[0].Data, "Subject: subject\n")
+		assert.Contains(t, messages[0].Data, "\n\nbody\n")
+	})
+
+	t.Run("fail on missing recipient", func(t *testing.T) {
+		err := smtpNotifier.Notify(internal.Message{Subject: "subject", Body: "body"})
+		assert.EqualError(t, err, "missing recipient for mail")
+	})
+}
@@ -0,0 +1,124 @@
+// Package smtptest provides a local SMTP stand-in so mail notifications can be
+// asserted in tests without a real mail server.
+package smtptest
+
+import (
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Message struct {
+	From string
+	To   []string
+	Data string
+}
+
+type Server struct {
+	Host     string
+	Port     int
+	listener net.Listener
+	mu       sync.Mutex
+	messages []Message
+	wg       sync.WaitGroup
+}
+
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	server := &Server{
+		Host:     addr.IP.String(),
+		Port:     addr.Port,
+		listener: listener,
+	}
+	server.wg.Add(1)
+	go server.serve()
+	return server, nil
+}
+
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := make([]Message, len(s.messages))
+	copy(messages, s.messages)
+	return messages
+}
+
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(code int, message string) {
+		text.PrintfLine("%s %s", strconv.Itoa(code), message)
+	}
+	reply(220, "smtptest ready")
+	var message Message
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply(250, "smtptest")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			message = Message{From: address(line[len("MAIL FROM:"):])}
+			reply(250, "OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			message.To = append(message.To, address(line[len("RCPT TO:"):]))
+			reply(250, "OK")
+		case command == "DATA":
+			reply(354, "end data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			message.Data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, message)
+			s.mu.Unlock()
+			reply(250, "OK")
+		case command == "RSET", command == "NOOP":
+			reply(250, "OK")
+		case command == "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "command not implemented")
+		}
+	}
+}
+
+func address(value string) string {
+	value = strings.TrimSpace(value)
+	if i := strings.Index(value, " "); i >= 0 {
+		value = value[:i]
+	}
+	return strings.Trim(value, "<>")
+}
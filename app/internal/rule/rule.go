@@ -0,0 +1,422 @@
+// Package rule implements the membership rules of dynamic groups, boolean
+// expressions over the fields of a user such as
+//
+//	email endsWith "@eng.example.com" or (department = "Sales" and level >= 3)
+//
+// Comparisons are =, !=, <, <=, >, >=, contains, startsWith, endsWith and
+// in [...], combined with and, or, not and parentheses. Values are double
+// quoted strings, numbers, true or false. A comparison with a field the user
+// doesn't have or of another type only matches with !=.
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Rule is a parsed membership rule.
+type Rule struct {
+	source string
+	root   node
+	fields []string
+}
+
+// Parse parses the expression, the error tells where it went wrong.
+func Parse(expression string) (*Rule, error) {
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, fields: map[string]bool{}}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEnd {
+		return nil, fmt.Errorf("unexpected %s at position %d", t, t.pos)
+	}
+	r := &Rule{source: expression, root: root}
+	for field := range p.fields {
+		r.fields = append(r.fields, field)
+	}
+	return r, nil
+}
+
+// Match tells whether the user with the given field values matches.
+func (r *Rule) Match(values map[string]interface{}) bool {
+	return r.root.match(values)
+}
+
+// Fields lists the fields the rule compares, in no particular order.
+func (r *Rule) Fields() []string {
+	return r.fields
+}
+
+func (r *Rule) String() string {
+	return r.source
+}
+
+type node interface {
+	match(values map[string]interface{}) bool
+}
+
+type and struct{ left, right node }
+
+func (n and) match(values map[string]interface{}) bool {
+	return n.left.match(values) && n.right.match(values)
+}
+
+type or struct{ left, right node }
+
+func (n or) match(values map[string]interface{}) bool {
+	return n.left.match(values) || n.right.match(values)
+}
+
+type not struct{ operand node }
+
+func (n not) match(values map[string]interface{}) bool {
+	return !n.operand.match(values)
+}
+
+type comparison struct {
+	field    string
+	operator string
+	values   []interface{}
+}
+
+func (n comparison) match(values map[string]interface{}) bool {
+	actual, ok := values[n.field]
+	if !ok || actual == nil {
+		return n.operator == "!="
+	}
+	switch n.operator {
+	case "=":
+		return equal(actual, n.values[0])
+	case "!=":
+		return !equal(actual, n.values[0])
+	case "in":
+		for _, value := range n.values {
+			if equal(actual, value) {
+				return true
+			}
+		}
+		return false
+	case "contains", "startsWith", "endsWith":
+		a, ok1 := actual.(string)
+		v, ok2 := n.values[0].(string)
+		if !ok1 || !ok2 {
+			return false
+		}
+		switch n.operator {
+		case "contains":
+			return strings.Contains(a, v)
+		case "startsWith":
+			return strings.HasPrefix(a, v)
+		}
+		return strings.HasSuffix(a, v)
+	}
+	c, ok := compare(actual, n.values[0])
+	if !ok {
+		return false
+	}
+	switch n.operator {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+func equal(actual interface{}, value interface{}) bool {
+	if c, ok := compare(actual, value); ok {
+		return c == 0
+	}
+	a, ok1 := actual.(bool)
+	v, ok2 := value.(bool)
+	return ok1 && ok2 && a == v
+}
+
+// compare orders numbers and strings, ok is false for other or mixed types.
+func compare(actual interface{}, value interface{}) (c int, ok bool) {
+	switch v := value.(type) {
+	case float64:
+		a, ok := number(actual)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a < v:
+			return -1, true
+		case a > v:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		a, ok := actual.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(a, v), true
+	}
+	return 0, false
+}
+
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+type parser struct {
+	tokens []token
+	next   int
+	fields map[string]bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEnd {
+		p.next++
+	}
+	return t
+}
+
+// keyword takes the next token if it is the word, case insensitively.
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == tokenIdent && strings.EqualFold(t.text, word) {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) symbol(text string) bool {
+	t := p.peek()
+	if t.kind == tokenSymbol && t.text == text {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = and{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.keyword("not") {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{operand}, nil
+	}
+	if p.symbol("(") {
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.take(); t.kind != tokenSymbol || t.text != ")" {
+			return nil, fmt.Errorf("expected ) at position %d, got %s", t.pos, t)
+		}
+		return inner, nil
+	}
+	return p.comparison()
+}
+
+var operators = map[string]bool{
+	"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"contains": true, "startsWith": true, "endsWith": true, "in": true,
+}
+
+func (p *parser) comparison() (node, error) {
+	field := p.take()
+	if field.kind != tokenIdent || isKeyword(field.text) {
+		return nil, fmt.Errorf("expected field at position %d, got %s", field.pos, field)
+	}
+	operator := p.take()
+	if (operator.kind != tokenSymbol && operator.kind != tokenIdent) || !operators[operator.text] {
+		return nil, fmt.Errorf("expected comparison at position %d, got %s", operator.pos, operator)
+	}
+	p.fields[field.text] = true
+	n := comparison{field: field.text, operator: operator.text}
+	if operator.text != "in" {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		n.values = []interface{}{value}
+		return n, nil
+	}
+	if t := p.take(); t.kind != tokenSymbol || t.text != "[" {
+		return nil, fmt.Errorf("expected [ at position %d, got %s", t.pos, t)
+	}
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, value)
+		if p.symbol(",") {
+			continue
+		}
+		if t := p.take(); t.kind != tokenSymbol || t.text != "]" {
+			return nil, fmt.Errorf("expected , or ] at position %d, got %s", t.pos, t)
+		}
+		return n, nil
+	}
+}
+
+func (p *parser) value() (interface{}, error) {
+	t := p.take()
+	switch {
+	case t.kind == tokenString:
+		return t.value, nil
+	case t.kind == tokenNumber:
+		return t.value, nil
+	case t.kind == tokenIdent && t.text == "true":
+		return true, nil
+	case t.kind == tokenIdent && t.text == "false":
+		return false, nil
+	}
+	return nil, fmt.Errorf("expected value at position %d, got %s", t.pos, t)
+}
+
+func isKeyword(text string) bool {
+	switch strings.ToLower(text) {
+	case "and", "or", "not":
+		return true
+	}
+	return false
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEnd {
+		return "end of rule"
+	}
+	return strconv.Quote(t.text)
+}
+
+func lex(expression string) (tokens []token, err error) {
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			value, err := strconv.ParseFloat(string(runes[start:i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), value: value, pos: start})
+		case r == '"':
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			value, err := strconv.Unquote(string(runes[start:i]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[start:i]), value: value, pos: start})
+		case strings.ContainsRune("()[],", r):
+			i++
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(r), pos: start})
+		case strings.ContainsRune("=!<>", r):
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			text := string(runes[start:i])
+			if text == "!" || text == "==" {
+				return nil, fmt.Errorf("unknown operator %q at position %d", text, start)
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: text, pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", r, start)
+		}
+	}
+	return append(tokens, token{kind: tokenEnd, pos: len(runes)}), nil
+}
@@ -0,0 +1,82 @@
+package rule_test
+
+import (
+	"sort"
+	"testing"
+	"usermanagement/app/internal/rule"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	user := map[string]interface{}{
+		"email":      "jane@eng.example.com",
+		"status":     "active",
+		"department": "Sales",
+		"level":      float64(3),
+		"remote":     true,
+	}
+	tests := []struct {
+		rule  string
+		match bool
+	}{
+		{rule: `email endsWith "@eng.example.com"`, match: true},
+		{rule: `email endsWith "@example.org"`, match: false},
+		{rule: `email startsWith "jane" and email contains "eng"`, match: true},
+		{rule: `department = "Sales"`, match: true},
+		{rule: `department != "Sales"`, match: false},
+		{rule: `level >= 3 and level < 4`, match: true},
+		{rule: `level > 3`, match: false},
+		{rule: `level <= -1`, match: false},
+		{rule: `remote = true`, match: true},
+		{rule: `department in ["Marketing", "Sales"]`, match: true},
+		{rule: `department = "Marketing" or (level = 3 and not remote = false)`, match: true},
+		{rule: `NOT department = "Sales" OR status = "disabled"`, match: false},
+		{rule: `manager = "bob"`, match: false},
+		{rule: `manager != "bob"`, match: true},
+		{rule: `level = "3"`, match: false},
+		{rule: `department > 1`, match: false},
+		{rule: `name = "Jane \"J\" Doe"`, match: false},
+	}
+	for _, test := range tests {
+		t.Run(test.rule, func(t *testing.T) {
+			r, err := rule.Parse(test.rule)
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.match, r.Match(user))
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Run("list compared fields", func(t *testing.T) {
+		r, err := rule.Parse(`department = "Sales" and (level > 2 or department = "Marketing")`)
+		assert.NoError(t, err)
+		fields := r.Fields()
+		sort.Strings(fields)
+		assert.Equal(t, []string{"department", "level"}, fields)
+		assert.Equal(t, `department = "Sales" and (level > 2 or department = "Marketing")`, r.String())
+	})
+
+	tests := []struct {
+		rule string
+		err  string
+	}{
+		{rule: ``, err: `expected field at position 0, got end of rule`},
+		{rule: `department`, err: `expected comparison at position 10, got end of rule`},
+		{rule: `department == "Sales"`, err: `unknown operator "==" at position 11`},
+		{rule: `department = Sales`, err: `expected value at position 13, got "Sales"`},
+		{rule: `department = "Sales`, err: `unterminated string at position 13`},
+		{rule: `(level > 2`, err: `expected ) at position 10, got end of rule`},
+		{rule: `level in [1, 2`, err: `expected , or ] at position 14, got end of rule`},
+		{rule: `level > 2 level < 4`, err: `unexpected "level" at position 10`},
+		{rule: `and = 1`, err: `expected field at position 0, got "and"`},
+		{rule: `level ~ 1`, err: `unexpected '~' at position 6`},
+	}
+	for _, test := range tests {
+		t.Run(test.rule, func(t *testing.T) {
+			_, err := rule.Parse(test.rule)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
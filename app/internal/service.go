@@ -1,20 +1,278 @@
 package internal
 
+import (
+	"context"
+	"time"
+	"usermanagement/app/internal/webauthn"
+)
+
 //go:generate mockgen -source=service.go  -destination=mock/service.go -package=mock
 type UserService interface {
 	CreateUser(request UserRequest) (response UserResponse, err error)
 	UpdateUser(request UpdateUserRequest) (err error)
-	DeleteUser(id uint) (err error)
-	GetUsers(page uint, perPage uint) (response UsersResponse, err error)
+	DeleteUser(id uint, version uint) (err error)
+	GetUsers(page uint, perPage uint, filter UsersFilter) (response UsersResponse, err error)
+	GetUser(id uint) (response UserResponse, err error)
 	ChangePassword(userID uint, password string) (err error)
+	ResendVerification(id uint) (err error)
+	RestoreUser(id uint) (err error)
+	ForOrganization(organizationID uint) UserService
+}
+
+// AttributeService manages the schema of custom user attributes, values are
+// validated against it when users are created or updated.
+type AttributeService interface {
+	GetAttributeDefinitions() (response AttributeDefinitionsResponse, err error)
+	SaveAttributeDefinition(definition AttributeDefinition) (err error)
+	DeleteAttributeDefinition(name string) (err error)
+}
+
+// UserImportService creates or updates users in bulk, matching them by email,
+// and exports all users. StartImport runs an import as a job.
+type UserImportService interface {
+	ImportUsers(request ImportUsersRequest) (response ImportUsersResponse, err error)
+	StartImport(request ImportUsersRequest) (response Job, err error)
+	ExportUsers(filter UsersFilter, exporter UserExporter) (err error)
+	AuthorizeGroups(caller Caller) (err error)
+	ForOrganization(organizationID uint) UserImportService
 }
 
 type GroupService interface {
 	CreateGroup(request GroupRequest) (response GroupResponse, err error)
 	UpdateGroup(request UpdateGroupRequest) (err error)
-	DeleteGroup(id uint) (err error)
+	DeleteGroup(id uint, version uint) (err error)
 	GetUsersByGroupID(groupID uint, page uint, perPage uint) (response UsersResponse, err error)
-	GetGroups(page uint, perPage uint) (response GroupsResponse, err error)
-	AddUser(userID uint, groupID uint) (err error)
+	GetGroups(page uint, perPage uint, filter GroupsFilter) (response GroupsResponse, err error)
+	GetGroup(id uint) (response GroupResponse, err error)
+	AddUser(request AddUserRequest) (err error)
 	RemoveUser(groupID uint, userID uint) (err error)
+	RestoreGroup(id uint) (err error)
+	AddUsers(groupID uint, userIDs []uint) (response BatchMembershipResponse, err error)
+	RemoveUsers(groupID uint, userIDs []uint) (response BatchMembershipResponse, err error)
+	ReplaceUsers(groupID uint, userIDs []uint) (response BatchMembershipResponse, err error)
+	SetMemberRole(groupID uint, userID uint, role string) (err error)
+	AuthorizeMemberChange(caller Caller, groupID uint, userID uint) (err error)
+	AuthorizeGroupOwner(caller Caller, groupID uint) (err error)
+	AuthorizeCreate(caller Caller, ownerID uint) (err error)
+	ForOrganization(organizationID uint) GroupService
+}
+
+// OrganizationService manages the organizations users and groups belong to,
+// only admins create them.
+type OrganizationService interface {
+	CreateOrganization(request OrganizationRequest) (response Organization, err error)
+	GetOrganization(id uint) (response Organization, err error)
+	GetOrganizations(page uint, perPage uint) (response OrganizationsResponse, err error)
+	AuthorizeAdmin(caller Caller) (err error)
+}
+
+// OIDCService is the OpenID Connect provider on top of the OAuth service, it
+// signs ID tokens and answers the claims about the user of an access token
+// granted the openid scope.
+type OIDCService interface {
+	Discovery() (response OIDCDiscovery, err error)
+	JWKS() (response JSONWebKeySet, err error)
+	IDToken(request IDTokenRequest) (token string, err error)
+	UserInfo(caller Caller) (response UserInfo, err error)
+}
+
+// SigningKeys are the keys tokens are signed with. New tokens are signed with
+// the Signing key, Verification holds it and every key tokens that haven't
+// expired yet may be signed with. VerificationKey finds one of them by ID.
+type SigningKeys interface {
+	Signing() (key SigningKey, err error)
+	Verification() (keys []SigningKey, err error)
+	VerificationKey(kid string) (key SigningKey, err error)
+}
+
+// KeyManager are the SigningKeys kept encrypted in the database. Rotate makes
+// a new key sign, the key before keeps verifying until the tokens it signed
+// expired. Run rotates at the rotation interval and removes expired keys.
+type KeyManager interface {
+	SigningKeys
+	GetKeys() (response SigningKeysResponse, err error)
+	Rotate() (response SigningKeyInfo, err error)
+	RotateDue() (err error)
+	AuthorizeAdmin(caller Caller) (err error)
+	Init() (err error)
+	Run(ctx context.Context) (err error)
+}
+
+type AuthService interface {
+	ForgotPassword(email string) (err error)
+	ResetPassword(token string, password string) (err error)
+	VerifyEmail(token string) (err error)
+	Login(email string, password string, ip string) (response LoginResponse, err error)
+	LoginMFA(mfaToken string, code string, ip string) (response LoginResponse, err error)
+	Authenticate(accessToken string) (response Caller, err error)
+	AuthorizeAdmin(caller Caller) (err error)
+	ForOrganization(organizationID uint) AuthService
+}
+
+type MFAService interface {
+	Enroll(userID uint) (response MFAEnrollment, err error)
+	Activate(userID uint, code string) (response RecoveryCodesResponse, err error)
+	Reset(userID uint) (err error)
+	AuthorizeEnrollment(caller Caller, userID uint) (err error)
+	AuthorizeAdmin(caller Caller) (err error)
+}
+
+type PasskeyService interface {
+	BeginRegistration(userID uint) (response webauthn.CreationOptions, err error)
+	FinishRegistration(userID uint, name string, registration webauthn.Registration) (response PasskeyResponse, err error)
+	GetPasskeys(userID uint) (response PasskeysResponse, err error)
+	RenamePasskey(userID uint, id uint, name string) (err error)
+	DeletePasskey(userID uint, id uint) (err error)
+	AuthorizePasskeys(caller Caller, userID uint) (err error)
+	BeginLogin(email string) (response webauthn.RequestOptions, err error)
+	FinishLogin(assertion webauthn.Assertion) (response LoginResponse, err error)
+}
+
+// AccessTokenService manages the personal access tokens users and service
+// accounts authenticate with instead of logging in.
+type AccessTokenService interface {
+	CreateAccessToken(userID uint, request AccessTokenRequest) (response CreatedAccessToken, err error)
+	GetAccessTokens(userID uint) (response AccessTokensResponse, err error)
+	RevokeAccessToken(userID uint, id uint) (err error)
+	AuthorizeAccessTokens(caller Caller, userID uint) (err error)
+}
+
+// OAuthService is the OAuth 2.0 authorization server. Registered clients
+// obtain access tokens with the authorization code grant with PKCE or the
+// client credentials grant, and introspect and revoke them. RedirectURI
+// resolves where the authorization endpoint may redirect to before anything
+// else is checked.
+type OAuthService interface {
+	RegisterClient(request OAuthClientRequest) (response RegisteredOAuthClient, err error)
+	GetClient(clientID string) (response OAuthClient, err error)
+	RedirectURI(clientID string, redirectURI string) (response string, err error)
+	Authorize(request OAuthAuthorizeRequest) (code string, err error)
+	Token(request OAuthTokenRequest) (response OAuthTokenResponse, err error)
+	Introspect(clientID string, clientSecret string, token string) (response OAuthIntrospection, err error)
+	Revoke(clientID string, clientSecret string, token string) (err error)
+	AuthorizeAdmin(caller Caller) (err error)
+}
+
+// LockoutService throttles password and MFA guessing per user and per source
+// IP. Users are told apart by organization and email.
+type LockoutService interface {
+	Check(email string, ip string) (err error)
+	Fail(email string, ip string) (err error)
+	Succeed(userID uint, email string, ip string) (err error)
+	Unlock(userID uint) (err error)
+	AuthorizeAdmin(caller Caller) (err error)
+	ForOrganization(organizationID uint) LockoutService
+}
+
+// UserStatusService moves users between statuses, see StatusActive.
+type UserStatusService interface {
+	Suspend(id uint, reason string, until *time.Time) (err error)
+	Reactivate(id uint, reason string) (err error)
+	Disable(id uint, reason string) (err error)
+	ReactivateExpired() (err error)
+	AuthorizeAdmin(caller Caller) (err error)
+}
+
+// JobHandler runs a job from its payload and returns its result. ctx ends when
+// the job is cancelled or the server stops, progress reports how much of the
+// job is done. A service error fails the job, other errors are retried.
+type JobHandler func(ctx context.Context, payload []byte, progress func(progress JobProgress)) (result interface{}, err error)
+
+// JobService queues long-running operations as jobs and reports on them.
+// Only the user who created a job and admins follow it.
+type JobService interface {
+	Enqueue(jobType string, createdBy uint, payload interface{}) (response Job, err error)
+	GetJob(id uint) (response Job, err error)
+	CancelJob(id uint) (response Job, err error)
+	AuthorizeJob(caller Caller, id uint) (err error)
+}
+
+// JobRunner is the JobService that also runs the queued jobs, with the
+// handler registered for their type, until the server stops.
+type JobRunner interface {
+	JobService
+	Register(jobType string, handler JobHandler)
+	Init() (err error)
+	Run(ctx context.Context) (err error)
+}
+
+// DynamicGroupService keeps the members of dynamic groups, the users matching
+// their rule. SyncUser updates the groups of a created or changed user and
+// Reconcile, which Run does periodically, catches up with all other changes.
+type DynamicGroupService interface {
+	CreateGroup(request GroupRequest) (response GroupResponse, err error)
+	SetRule(groupID uint, rule string) (response BatchMembershipResponse, err error)
+	PreviewRule(rule string, page uint, perPage uint) (response UsersResponse, err error)
+	AuthorizePreview(caller Caller) (err error)
+	SyncUser(userID uint) (err error)
+	Reconcile() (err error)
+	Init() (err error)
+	Run(ctx context.Context) (err error)
+	ForOrganization(organizationID uint) DynamicGroupService
+}
+
+// AccessRequestService lets users request to join a group. The owners of the
+// group, or the admins for a group without owners, are notified and approve
+// or deny the request. Only the requester, the owners of the group and the
+// admins see a request. Pending requests expire, Run expires them periodically.
+type AccessRequestService interface {
+	RequestAccess(request AccessRequestRequest) (response AccessRequest, err error)
+	GetAccessRequest(id uint) (response AccessRequest, err error)
+	GetAccessRequests(page uint, perPage uint, filter AccessRequestsFilter) (response AccessRequestsResponse, err error)
+	Approve(id uint, approver uint, reason string) (response AccessRequest, err error)
+	Deny(id uint, approver uint, reason string) (response AccessRequest, err error)
+	AuthorizeDecision(caller Caller, id uint) (err error)
+	AuthorizeView(caller Caller, id uint) (err error)
+	AuthorizeList(caller Caller, filter AccessRequestsFilter) (err error)
+	ExpireRequests() (err error)
+	Init() (err error)
+	Run(ctx context.Context) (err error)
+	ForOrganization(organizationID uint) AccessRequestService
+}
+
+// AccessReviewService runs access review campaigns. A campaign snapshots the
+// memberships of its groups for reviewers to certify or revoke. At its
+// deadline Run closes it, revoking the memberships nobody reviewed if the
+// campaign says so. Reports are signed with the signing keys.
+type AccessReviewService interface {
+	CreateCampaign(request ReviewCampaignRequest) (response ReviewCampaign, err error)
+	GetCampaign(id uint) (response ReviewCampaign, err error)
+	GetCampaigns(page uint, perPage uint, status string) (response ReviewCampaignsResponse, err error)
+	GetReviewItems(campaignID uint, page uint, perPage uint, filter ReviewItemsFilter) (response ReviewItemsResponse, err error)
+	Certify(campaignID uint, itemID uint, reviewer uint, comment string) (response ReviewItem, err error)
+	Revoke(campaignID uint, itemID uint, reviewer uint, comment string) (response ReviewItem, err error)
+	CloseCampaign(id uint) (err error)
+	Report(id uint) (response SignedReviewReport, err error)
+	AuthorizeReview(caller Caller, campaignID uint, itemID uint) (err error)
+	AuthorizeItems(caller Caller, reviewerID uint) (err error)
+	AuthorizeAdmin(caller Caller) (err error)
+	CloseDueCampaigns() (err error)
+	Init() (err error)
+	Run(ctx context.Context) (err error)
+	ForOrganization(organizationID uint) AccessReviewService
+}
+
+// MembershipExpiryService removes group memberships once they expire, Run
+// does so periodically.
+type MembershipExpiryService interface {
+	ExpireMemberships() (err error)
+	Init() (err error)
+	Run(ctx context.Context) (err error)
+}
+
+// RetentionService permanently removes records deleted longer ago than the
+// retention period.
+type RetentionService interface {
+	Purge() (err error)
+}
+
+// PrivacyService answers data subject requests.
+type PrivacyService interface {
+	Export(userID uint) (response UserExport, err error)
+	Erase(userID uint, reason string) (err error)
+	AuthorizeSubject(caller Caller, userID uint) (err error)
+}
+
+type Auditor interface {
+	Record(event AuditEvent)
 }
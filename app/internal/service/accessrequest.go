@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAccessRequestTTL      = 7 * 24 * time.Hour
+	defaultAccessRequestInterval = time.Hour
+)
+
+// AccessRequestOptions configures how long a request waits for a decision,
+// how often expired requests are swept and the emails of the admins who are
+// asked when a group has no owners.
+type AccessRequestOptions struct {
+	TTL            time.Duration
+	ExpiryInterval time.Duration
+	Admins         []string
+}
+
+type accessRequestService struct {
+	data      internal.AccessRequestData
+	groups    internal.GroupService
+	groupData internal.GroupData
+	users     internal.UserData
+	notifier  internal.Notifier
+	auditor   internal.Auditor
+	ttl       time.Duration
+	interval  time.Duration
+	admins    []string
+}
+
+func NewAccessRequestService(data internal.AccessRequestData, groups internal.GroupService, groupData internal.GroupData, users internal.UserData,
+	notifier internal.Notifier, auditor internal.Auditor, options AccessRequestOptions) *accessRequestService {
+	if options.TTL == 0 {
+		options.TTL = defaultAccessRequestTTL
+	}
+	if options.ExpiryInterval == 0 {
+		options.ExpiryInterval = defaultAccessRequestInterval
+	}
+	return &accessRequestService{
+		data:      data,
+		groups:    groups,
+		groupData: groupData,
+		users:     users,
+		notifier:  notifier,
+		auditor:   auditor,
+		ttl:       options.TTL,
+		interval:  options.ExpiryInterval,
+		admins:    options.Admins,
+	}
+}
+
+// RequestAccess records the request of a user to join a static group it isn't
+// in yet and asks the approvers of the group to decide it.
+func (a *accessRequestService) RequestAccess(request internal.AccessRequestRequest) (response internal.AccessRequest, err error) {
+	user, err := a.users.GetUser(request.UserID)
+	if err != nil {
+		return response, err
+	}
+	group, err := a.groups.GetGroup(request.GroupID)
+	if err != nil {
+		return response, err
+	}
+	if group.Type == internal.GroupDynamic {
+		return response, serviceerror.NewServiceError(serviceerror.DynamicGroupMembership,
+			fmt.Errorf("members of dynamic group %d follow its rule and can't be requested", group.ID))
+	}
+	memberships, err := a.groupData.GetMemberships(user.ID)
+	if err != nil {
+		return response, err
+	}
+	now := time.Now()
+	for _, membership := range memberships {
+		if membership.RemovedAt == nil && (membership.ExpiresAt == nil || membership.ExpiresAt.After(now)) {
+			return response, serviceerror.NewServiceError(serviceerror.InvalidAccessRequest,
+				fmt.Errorf("user %d is already in group %d", user.ID, membership.GroupID))
+		}
+	}
+	response, err = a.data.CreateAccessRequest(request, now.Add(a.ttl))
+	if err != nil {
+		return response, err
+	}
+	a.notifyApprovers(response, user, group)
+	return response, nil
+}
+
+// approvers returns the emails of the owners of the group, or of the admins
+// if it has none.
+func (a *accessRequestService) approvers(groupID uint) (emails []string, err error) {
+	owners, err := a.groupData.GetGroupOwners(groupID)
+	if err != nil {
+		return nil, err
+	}
+	for _, owner := range owners {
+		emails = append(emails, owner.Email)
+	}
+	if len(emails) == 0 {
+		return a.admins, nil
+	}
+	return emails, nil
+}
+
+// notifyApprovers never fails the request, the approvers also find it by
+// listing the pending requests of their group.
+func (a *accessRequestService) notifyApprovers(request internal.AccessRequest, user internal.UserResponse, group internal.GroupResponse) {
+	emails, err := a.approvers(group.ID)
+	if err != nil {
+		log.WithError(err).WithField("accessRequest", request.ID).Error("getting approvers failed")
+		return
+	}
+	if len(emails) == 0 {
+		log.WithField("accessRequest", request.ID).Warn("access request has no approvers to notify")
+	}
+	for _, email := range emails {
+		err := a.notifier.Notify(internal.Message{
+			To:      email,
+			Subject: fmt.Sprintf("Access requested to group %s", group.Name),
+			Body: fmt.Sprintf("Hello,\n\n%s (%s) requests to join group %s:\n\n%s\n\nPlease approve or deny access request %d before %s.\n",
+				user.Name, user.Email, group.Name, request.Justification, request.ID, request.ExpiresAt.Format(time.RFC1123)),
+		})
+		if err != nil {
+			log.WithError(err).WithField("accessRequest", request.ID).Error("sending access request notice failed")
+		}
+	}
+}
+
+// notifyRequester tells the user its request was approved, denied or expired.
+func (a *accessRequestService) notifyRequester(request internal.AccessRequest) {
+	user, err := a.users.GetUser(request.UserID)
+	if err != nil {
+		log.WithError(err).WithField("accessRequest", request.ID).Error("getting requester failed")
+		return
+	}
+	body := fmt.Sprintf("Hello %s,\n\nYour request to join group %d was %s.\n", user.Name, request.GroupID, request.Status)
+	if request.Reason != "" {
+		body += fmt.Sprintf("\nReason: %s\n", request.Reason)
+	}
+	err = a.notifier.Notify(internal.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("Access request %s", request.Status),
+		Body:    body,
+	})
+	if err != nil {
+		log.WithError(err).WithField("accessRequest", request.ID).Error("sending access decision failed")
+	}
+}
+
+func (a *accessRequestService) GetAccessRequest(id uint) (response internal.AccessRequest, err error) {
+	return a.data.GetAccessRequest(id)
+}
+
+func (a *accessRequestService) GetAccessRequests(page uint, perPage uint, filter internal.AccessRequestsFilter) (response internal.AccessRequestsResponse, err error) {
+	if page <= 0 || perPage == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidAccessRequest, fmt.Errorf("page %d  or per page %d is not valid", page, perPage))
+	}
+	offset := perPage * (page - 1)
+	response, err = a.data.GetAccessRequests(offset, perPage, filter)
+	response.Page = page
+	response.PerPage = perPage
+	return response, err
+}
+
+// AuthorizeDecision lets admins and owners of the requested group decide a
+// request.
+func (a *accessRequestService) AuthorizeDecision(caller internal.Caller, id uint) (err error) {
+	request, err := a.data.GetAccessRequest(id)
+	if err != nil {
+		return err
+	}
+	return a.groups.AuthorizeGroupOwner(caller, request.GroupID)
+}
+
+// AuthorizeView lets the requester, the owners of the requested group and
+// admins see a request.
+func (a *accessRequestService) AuthorizeView(caller internal.Caller, id uint) (err error) {
+	if caller.Admin {
+		return nil
+	}
+	request, err := a.data.GetAccessRequest(id)
+	if err != nil {
+		return err
+	}
+	if request.UserID == caller.UserID {
+		return nil
+	}
+	return a.groups.AuthorizeGroupOwner(caller, request.GroupID)
+}
+
+// AuthorizeList lets admins list any requests, users their own and owners
+// those for their group.
+func (a *accessRequestService) AuthorizeList(caller internal.Caller, filter internal.AccessRequestsFilter) (err error) {
+	if caller.Admin {
+		return nil
+	}
+	if filter.UserID != 0 && filter.UserID == caller.UserID {
+		return nil
+	}
+	if filter.GroupID != 0 {
+		return a.groups.AuthorizeGroupOwner(caller, filter.GroupID)
+	}
+	return serviceerror.NewServiceError(serviceerror.Forbidden,
+		fmt.Errorf("user %d can only list its own requests or those for groups it owns", caller.UserID))
+}
+
+// Approve adds the user to the group and then marks the request approved. A
+// request whose user can't be added stays pending.
+func (a *accessRequestService) Approve(id uint, approver uint, reason string) (response internal.AccessRequest, err error) {
+	request, err := a.data.GetAccessRequest(id)
+	if err != nil {
+		return response, err
+	}
+	if request.Status != internal.AccessPending {
+		return response, serviceerror.NewServiceError(serviceerror.AccessRequestDecided, fmt.Errorf("access request %d is %s", id, request.Status))
+	}
+	if !request.ExpiresAt.After(time.Now()) {
+		return response, serviceerror.NewServiceError(serviceerror.AccessRequestDecided, fmt.Errorf("access request %d has expired", id))
+	}
+	err = a.groups.AddUser(internal.AddUserRequest{UserID: request.UserID, GroupID: request.GroupID})
+	if err != nil {
+		return response, err
+	}
+	return a.decide(internal.AccessDecision{ID: id, Status: internal.AccessApproved, DecidedBy: approver, Reason: reason}, internal.EventAccessApproved)
+}
+
+func (a *accessRequestService) Deny(id uint, approver uint, reason string) (response internal.AccessRequest, err error) {
+	return a.decide(internal.AccessDecision{ID: id, Status: internal.AccessDenied, DecidedBy: approver, Reason: reason}, internal.EventAccessDenied)
+}
+
+func (a *accessRequestService) decide(decision internal.AccessDecision, event string) (response internal.AccessRequest, err error) {
+	response, err = a.data.DecideAccessRequest(decision)
+	if err != nil {
+		return response, err
+	}
+	a.auditor.Record(internal.AuditEvent{
+		Type:   event,
+		UserID: response.UserID,
+		Detail: fmt.Sprintf("access request %d to group %d %s by user %d", response.ID, response.GroupID, response.Status, response.DecidedBy),
+	})
+	a.notifyRequester(response)
+	return response, nil
+}
+
+// ExpireRequests expires the pending requests nobody decided in time and
+// tells their users.
+func (a *accessRequestService) ExpireRequests() (err error) {
+	expired, err := a.data.ExpireAccessRequests(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, request := range expired {
+		log.WithFields(log.Fields{"accessRequest": request.ID, "user": request.UserID, "group": request.GroupID}).Info("access request expired")
+		a.notifyRequester(request)
+	}
+	return nil
+}
+
+// ForOrganization returns the service for the requests, groups and users of
+// the organization.
+func (a *accessRequestService) ForOrganization(organizationID uint) internal.AccessRequestService {
+	scoped := *a
+	scoped.data = a.data.ForOrganization(organizationID)
+	scoped.groups = a.groups.ForOrganization(organizationID)
+	scoped.groupData = a.groupData.ForOrganization(organizationID)
+	scoped.users = a.users.ForOrganization(organizationID)
+	return &scoped
+}
+
+func (a *accessRequestService) Init() (err error) {
+	return nil
+}
+
+func (a *accessRequestService) Run(ctx context.Context) (err error) {
+	RunPeriodically(ctx, "expire access requests", a.interval, a.ExpireRequests)
+	return nil
+}
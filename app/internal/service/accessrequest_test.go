@@ -0,0 +1,230 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type accessRequestMocks struct {
+	data      *mock.MockAccessRequestData
+	groups    *mock.MockGroupService
+	groupData *mock.MockGroupData
+	users     *mock.MockUserData
+	notifier  *mock.MockNotifier
+	auditor   *mock.MockAuditor
+}
+
+func newAccessRequestService(mockCtrl *gomock.Controller) (internal.AccessRequestService, accessRequestMocks) {
+	mocks := accessRequestMocks{
+		data:      mock.NewMockAccessRequestData(mockCtrl),
+		groups:    mock.NewMockGroupService(mockCtrl),
+		groupData: mock.NewMockGroupData(mockCtrl),
+		users:     mock.NewMockUserData(mockCtrl),
+		notifier:  mock.NewMockNotifier(mockCtrl),
+		auditor:   mock.NewMockAuditor(mockCtrl),
+	}
+	handler := service.NewAccessRequestService(mocks.data, mocks.groups, mocks.groupData, mocks.users, mocks.notifier, mocks.auditor,
+		service.AccessRequestOptions{Admins: []string{"admin@gmail.com"}})
+	return handler, mocks
+}
+
+func TestRequestAccess(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler, mocks := newAccessRequestService(mockCtrl)
+	request := internal.AccessRequestRequest{UserID: 2, GroupID: 1, Justification: "on call"}
+	user := internal.UserResponse{ID: 2, Name: "test", Email: "test@gmail.com"}
+
+	t.Run("notify owners of the group", func(t *testing.T) {
+		mocks.users.EXPECT().GetUser(uint(2)).Return(user, nil).Times(1)
+		mocks.groups.EXPECT().GetGroup(uint(1)).Return(internal.GroupResponse{ID: 1, Name: "ops", Type: internal.GroupStatic}, nil).Times(1)
+		mocks.groupData.EXPECT().GetMemberships(uint(2)).Return([]internal.Membership{{GroupID: 3, RemovedAt: &time.Time{}}}, nil).Times(1)
+		mocks.data.EXPECT().CreateAccessRequest(request, gomock.Any()).DoAndReturn(func(request internal.AccessRequestRequest, expiresAt time.Time) (internal.AccessRequest, error) {
+			assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), expiresAt, time.Minute)
+			return internal.AccessRequest{ID: 5, UserID: 2, GroupID: 1, Justification: "on call", Status: internal.AccessPending, ExpiresAt: expiresAt}, nil
+		}).Times(1)
+		mocks.groupData.EXPECT().GetGroupOwners(uint(1)).Return([]internal.UserResponse{{ID: 7, Email: "owner1@gmail.com"}, {ID: 8, Email: "owner2@gmail.com"}}, nil).Times(1)
+		var to []string
+		mocks.notifier.EXPECT().Notify(gomock.Any()).Do(func(message internal.Message) {
+			to = append(to, message.To)
+			assert.Contains(t, message.Body, "on call")
+		}).Return(nil).Times(2)
+		response, err := handler.RequestAccess(request)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(5), response.ID)
+		assert.Equal(t, []string{"owner1@gmail.com", "owner2@gmail.com"}, to)
+	})
+
+	t.Run("notify admins without owners", func(t *testing.T) {
+		mocks.users.EXPECT().GetUser(uint(2)).Return(user, nil).Times(1)
+		mocks.groups.EXPECT().GetGroup(uint(1)).Return(internal.GroupResponse{ID: 1, Name: "ops", Type: internal.GroupStatic}, nil).Times(1)
+		mocks.groupData.EXPECT().GetMemberships(uint(2)).Return(nil, nil).Times(1)
+		mocks.data.EXPECT().CreateAccessRequest(request, gomock.Any()).Return(internal.AccessRequest{ID: 5}, nil).Times(1)
+		mocks.groupData.EXPECT().GetGroupOwners(uint(1)).Return(nil, nil).Times(1)
+		mocks.notifier.EXPECT().Notify(gomock.Any()).Do(func(message internal.Message) {
+			assert.Equal(t, "admin@gmail.com", message.To)
+		}).Return(errors.New("test")).Times(1)
+		_, err := handler.RequestAccess(request)
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail on member of a group", func(t *testing.T) {
+		mocks.users.EXPECT().GetUser(uint(2)).Return(user, nil).Times(1)
+		mocks.groups.EXPECT().GetGroup(uint(1)).Return(internal.GroupResponse{ID: 1, Type: internal.GroupStatic}, nil).Times(1)
+		mocks.groupData.EXPECT().GetMemberships(uint(2)).Return([]internal.Membership{{GroupID: 3}}, nil).Times(1)
+		_, err := handler.RequestAccess(request)
+		assert.True(t, hasCode(err, serviceerror.InvalidAccessRequest))
+	})
+
+	t.Run("fail on dynamic group", func(t *testing.T) {
+		mocks.users.EXPECT().GetUser(uint(2)).Return(user, nil).Times(1)
+		mocks.groups.EXPECT().GetGroup(uint(1)).Return(internal.GroupResponse{ID: 1, Type: internal.GroupDynamic}, nil).Times(1)
+		_, err := handler.RequestAccess(request)
+		assert.True(t, hasCode(err, serviceerror.DynamicGroupMembership))
+	})
+}
+
+func TestDecideAccess(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler, mocks := newAccessRequestService(mockCtrl)
+	pending := internal.AccessRequest{ID: 5, UserID: 2, GroupID: 1, Status: internal.AccessPending, ExpiresAt: time.Now().Add(time.Hour)}
+	notifyRequester := func(status string) {
+		mocks.users.EXPECT().GetUser(uint(2)).Return(internal.UserResponse{ID: 2, Email: "test@gmail.com"}, nil).Times(1)
+		mocks.notifier.EXPECT().Notify(gomock.Any()).Do(func(message internal.Message) {
+			assert.Equal(t, "test@gmail.com", message.To)
+			assert.Contains(t, message.Body, status)
+		}).Return(nil).Times(1)
+	}
+
+	t.Run("approve adds user to group", func(t *testing.T) {
+		mocks.data.EXPECT().GetAccessRequest(uint(5)).Return(pending, nil).Times(1)
+		gomock.InOrder(
+			mocks.groups.EXPECT().AddUser(internal.AddUserRequest{UserID: 2, GroupID: 1}).Return(nil).Times(1),
+			mocks.data.EXPECT().DecideAccessRequest(internal.AccessDecision{ID: 5, Status: internal.AccessApproved, DecidedBy: 7, Reason: "ok"}).
+				Return(internal.AccessRequest{ID: 5, UserID: 2, GroupID: 1, Status: internal.AccessApproved, DecidedBy: 7}, nil).Times(1),
+		)
+		mocks.auditor.EXPECT().Record(internal.AuditEvent{
+			Type:   internal.EventAccessApproved,
+			UserID: 2,
+			Detail: "access request 5 to group 1 approved by user 7",
+		}).Times(1)
+		notifyRequester(internal.AccessApproved)
+		response, err := handler.Approve(5, 7, "ok")
+		assert.NoError(t, err)
+		assert.Equal(t, internal.AccessApproved, response.Status)
+	})
+
+	t.Run("keep request pending when adding fails", func(t *testing.T) {
+		mocks.data.EXPECT().GetAccessRequest(uint(5)).Return(pending, nil).Times(1)
+		mocks.groups.EXPECT().AddUser(internal.AddUserRequest{UserID: 2, GroupID: 1}).
+			Return(serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, errors.New("test"))).Times(1)
+		_, err := handler.Approve(5, 7, "")
+		assert.True(t, hasCode(err, serviceerror.InvalidUserGroupRequest))
+	})
+
+	t.Run("fail to approve decided request", func(t *testing.T) {
+		mocks.data.EXPECT().GetAccessRequest(uint(5)).Return(internal.AccessRequest{ID: 5, Status: internal.AccessDenied}, nil).Times(1)
+		_, err := handler.Approve(5, 7, "")
+		assert.True(t, hasCode(err, serviceerror.AccessRequestDecided))
+	})
+
+	t.Run("fail to approve expired request", func(t *testing.T) {
+		mocks.data.EXPECT().GetAccessRequest(uint(5)).Return(internal.AccessRequest{ID: 5, Status: internal.AccessPending, ExpiresAt: time.Now().Add(-time.Minute)}, nil).Times(1)
+		_, err := handler.Approve(5, 7, "")
+		assert.True(t, hasCode(err, serviceerror.AccessRequestDecided))
+	})
+
+	t.Run("deny request", func(t *testing.T) {
+		mocks.data.EXPECT().DecideAccessRequest(internal.AccessDecision{ID: 5, Status: internal.AccessDenied, Reason: "not needed"}).
+			Return(internal.AccessRequest{ID: 5, UserID: 2, GroupID: 1, Status: internal.AccessDenied, Reason: "not needed"}, nil).Times(1)
+		mocks.auditor.EXPECT().Record(gomock.Any()).Times(1)
+		notifyRequester("not needed")
+		_, err := handler.Deny(5, 0, "not needed")
+		assert.NoError(t, err)
+	})
+}
+
+func TestAuthorizeDecision(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler, mocks := newAccessRequestService(mockCtrl)
+	caller := internal.Caller{UserID: 7}
+
+	mocks.data.EXPECT().GetAccessRequest(uint(5)).Return(internal.AccessRequest{ID: 5, GroupID: 1}, nil).Times(1)
+	mocks.groups.EXPECT().AuthorizeGroupOwner(caller, uint(1)).Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+	assert.True(t, hasCode(handler.AuthorizeDecision(caller, 5), serviceerror.Forbidden))
+}
+
+func TestAuthorizeAccessRequestView(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler, mocks := newAccessRequestService(mockCtrl)
+	caller := internal.Caller{UserID: 7}
+
+	t.Run("let admin see", func(t *testing.T) {
+		assert.NoError(t, handler.AuthorizeView(internal.Caller{UserID: 9, Admin: true}, 5))
+	})
+
+	t.Run("let requester see", func(t *testing.T) {
+		mocks.data.EXPECT().GetAccessRequest(uint(5)).Return(internal.AccessRequest{ID: 5, UserID: 7, GroupID: 1}, nil).Times(1)
+		assert.NoError(t, handler.AuthorizeView(caller, 5))
+	})
+
+	t.Run("let only group owners see requests of others", func(t *testing.T) {
+		mocks.data.EXPECT().GetAccessRequest(uint(5)).Return(internal.AccessRequest{ID: 5, UserID: 2, GroupID: 1}, nil).Times(2)
+		mocks.groups.EXPECT().AuthorizeGroupOwner(caller, uint(1)).Return(nil).Times(1)
+		assert.NoError(t, handler.AuthorizeView(caller, 5))
+		mocks.groups.EXPECT().AuthorizeGroupOwner(caller, uint(1)).Return(serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("test"))).Times(1)
+		assert.True(t, hasCode(handler.AuthorizeView(caller, 5), serviceerror.Forbidden))
+	})
+
+	t.Run("list own requests and those for owned groups", func(t *testing.T) {
+		assert.NoError(t, handler.AuthorizeList(internal.Caller{UserID: 9, Admin: true}, internal.AccessRequestsFilter{}))
+		assert.NoError(t, handler.AuthorizeList(caller, internal.AccessRequestsFilter{UserID: 7}))
+		mocks.groups.EXPECT().AuthorizeGroupOwner(caller, uint(1)).Return(nil).Times(1)
+		assert.NoError(t, handler.AuthorizeList(caller, internal.AccessRequestsFilter{GroupID: 1}))
+		assert.True(t, hasCode(handler.AuthorizeList(caller, internal.AccessRequestsFilter{}), serviceerror.Forbidden))
+		assert.True(t, hasCode(handler.AuthorizeList(caller, internal.AccessRequestsFilter{UserID: 8}), serviceerror.Forbidden))
+	})
+}
+
+func TestGetAccessRequests(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler, mocks := newAccessRequestService(mockCtrl)
+	filter := internal.AccessRequestsFilter{GroupID: 1, Status: internal.AccessPending}
+
+	t.Run("list page of requests", func(t *testing.T) {
+		mocks.data.EXPECT().GetAccessRequests(uint(10), uint(10), filter).Return(internal.AccessRequestsResponse{Total: 11}, nil).Times(1)
+		response, err := handler.GetAccessRequests(2, 10, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), response.Page)
+	})
+
+	t.Run("fail on invalid page", func(t *testing.T) {
+		_, err := handler.GetAccessRequests(0, 10, filter)
+		assert.True(t, hasCode(err, serviceerror.InvalidAccessRequest))
+	})
+}
+
+func TestExpireRequests(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler, mocks := newAccessRequestService(mockCtrl)
+
+	mocks.data.EXPECT().ExpireAccessRequests(gomock.Any()).Return([]internal.AccessRequest{{ID: 5, UserID: 2, GroupID: 1, Status: internal.AccessExpired}}, nil).Times(1)
+	mocks.users.EXPECT().GetUser(uint(2)).Return(internal.UserResponse{ID: 2, Email: "test@gmail.com"}, nil).Times(1)
+	mocks.notifier.EXPECT().Notify(gomock.Any()).Do(func(message internal.Message) {
+		assert.Equal(t, "Access request expired", message.Subject)
+	}).Return(nil).Times(1)
+	assert.NoError(t, handler.ExpireRequests())
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+)
+
+// scopeResources are the resources a personal access token can be scoped to.
+var scopeResources = map[string]bool{
+	"users":          true,
+	"groups":         true,
+	"organizations":  true,
+	"accessRequests": true,
+	"reviews":        true,
+	"attributes":     true,
+	"jobs":           true,
+}
+
+type accessTokenService struct {
+	users internal.UserData
+	data  internal.AccessTokenData
+}
+
+func NewAccessTokenService(users internal.UserData, data internal.AccessTokenData) *accessTokenService {
+	return &accessTokenService{
+		users: users,
+		data:  data,
+	}
+}
+
+// CreateAccessToken issues a personal access token for the user, the token is
+// only returned here and stored as a hash.
+func (a *accessTokenService) CreateAccessToken(userID uint, request internal.AccessTokenRequest) (response internal.CreatedAccessToken, err error) {
+	if request.Name == "" || len(request.Scopes) == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidAccessToken, errors.New("missing access token fields"))
+	}
+	for _, scope := range request.Scopes {
+		if !validScope(scope) {
+			return response, serviceerror.NewServiceError(serviceerror.InvalidAccessToken, fmt.Errorf("scope %s is not valid", scope))
+		}
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidAccessToken, errors.New("access token expires in the past"))
+	}
+	_, err = a.users.GetUser(userID)
+	if err != nil {
+		return response, err
+	}
+	token, err := randomToken()
+	if err != nil {
+		return response, err
+	}
+	token = internal.AccessTokenPrefix + token
+	created, err := a.data.CreateAccessToken(internal.AccessToken{
+		UserID:    userID,
+		Name:      request.Name,
+		TokenHash: hashToken(token),
+		Scopes:    request.Scopes,
+		ExpiresAt: request.ExpiresAt,
+	})
+	if err != nil {
+		return response, err
+	}
+	return internal.CreatedAccessToken{AccessTokenResponse: created, Token: token}, err
+}
+
+func (a *accessTokenService) GetAccessTokens(userID uint) (response internal.AccessTokensResponse, err error) {
+	response.Tokens, err = a.data.GetAccessTokens(userID)
+	return response, err
+}
+
+func (a *accessTokenService) RevokeAccessToken(userID uint, id uint) (err error) {
+	return a.data.RevokeAccessToken(userID, id)
+}
+
+// AuthorizeAccessTokens lets users manage their own tokens and admins those
+// of everyone. A caller using an access token can't, so a token never grants
+// more than its scopes.
+func (a *accessTokenService) AuthorizeAccessTokens(caller internal.Caller, userID uint) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't manage access tokens"))
+	}
+	if caller.UserID != userID && !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d can't manage the access tokens of user %d", caller.UserID, userID))
+	}
+	return nil
+}
+
+// validScope tells if scope is <resource>:read or <resource>:write.
+func validScope(scope string) bool {
+	parts := strings.Split(scope, ":")
+	return len(parts) == 2 && scopeResources[parts[0]] && (parts[1] == "read" || parts[1] == "write")
+}
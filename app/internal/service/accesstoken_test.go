@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAccessToken(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserData(mockCtrl)
+	data := mock.NewMockAccessTokenData(mockCtrl)
+	handler := service.NewAccessTokenService(users, data)
+	expiresAt := time.Now().Add(time.Hour)
+
+	t.Run("create token shown once", func(t *testing.T) {
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1}, nil).Times(1)
+		var tokenHash string
+		data.EXPECT().CreateAccessToken(gomock.Any()).DoAndReturn(func(token internal.AccessToken) (internal.AccessTokenResponse, error) {
+			assert.Equal(t, uint(1), token.UserID)
+			assert.Equal(t, []string{"users:read", "groups:write"}, token.Scopes)
+			tokenHash = token.TokenHash
+			return internal.AccessTokenResponse{ID: 3, Name: token.Name, Scopes: token.Scopes, ExpiresAt: token.ExpiresAt}, nil
+		}).Times(1)
+		response, err := handler.CreateAccessToken(1, internal.AccessTokenRequest{Name: "ci", Scopes: []string{"users:read", "groups:write"}, ExpiresAt: &expiresAt})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), response.ID)
+		assert.True(t, strings.HasPrefix(response.Token, internal.AccessTokenPrefix))
+		assert.NotContains(t, tokenHash, response.Token)
+	})
+
+	tests := []struct {
+		name    string
+		request internal.AccessTokenRequest
+	}{
+		{"error on missing scopes", internal.AccessTokenRequest{Name: "ci"}},
+		{"error on unknown resource", internal.AccessTokenRequest{Name: "ci", Scopes: []string{"secrets:read"}}},
+		{"error on unknown access", internal.AccessTokenRequest{Name: "ci", Scopes: []string{"users:delete"}}},
+		{"error on past expiry", internal.AccessTokenRequest{Name: "ci", Scopes: []string{"users:read"}, ExpiresAt: &time.Time{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler.CreateAccessToken(1, tt.request)
+			assert.True(t, hasCode(err, serviceerror.InvalidAccessToken))
+		})
+	}
+
+	t.Run("fail on unknown user", func(t *testing.T) {
+		users.EXPECT().GetUser(uint(2)).Return(internal.UserResponse{}, serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))).Times(1)
+		_, err := handler.CreateAccessToken(2, internal.AccessTokenRequest{Name: "ci", Scopes: []string{"users:read"}})
+		assert.True(t, hasCode(err, serviceerror.UserNotFound))
+	})
+}
+
+func TestAuthorizeAccessTokens(t *testing.T) {
+	handler := service.NewAccessTokenService(nil, nil)
+	assert.NoError(t, handler.AuthorizeAccessTokens(internal.Caller{UserID: 1}, 1))
+	assert.NoError(t, handler.AuthorizeAccessTokens(internal.Caller{UserID: 2, Admin: true}, 1))
+	assert.True(t, hasCode(handler.AuthorizeAccessTokens(internal.Caller{UserID: 2}, 1), serviceerror.Forbidden))
+	assert.True(t, hasCode(handler.AuthorizeAccessTokens(internal.Caller{UserID: 1, Scopes: []string{"users:write"}}, 1), serviceerror.Forbidden))
+}
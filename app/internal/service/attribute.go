@@ -0,0 +1,162 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+)
+
+var attributeNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,63}$`)
+
+type attributeService struct {
+	data internal.AttributeData
+}
+
+func NewAttributeService(data internal.AttributeData) *attributeService {
+	return &attributeService{
+		data: data,
+	}
+}
+
+func (a *attributeService) GetAttributeDefinitions() (response internal.AttributeDefinitionsResponse, err error) {
+	response.Attributes, err = a.data.GetAttributeDefinitions()
+	return response, err
+}
+
+func (a *attributeService) SaveAttributeDefinition(definition internal.AttributeDefinition) (err error) {
+	if !attributeNamePattern.MatchString(definition.Name) {
+		return serviceerror.NewServiceError(serviceerror.InvalidAttribute, fmt.Errorf("attribute name %q is not valid", definition.Name))
+	}
+	switch definition.Type {
+	case internal.AttributeString:
+		if _, err := regexp.Compile(definition.Pattern); err != nil {
+			return serviceerror.NewServiceError(serviceerror.InvalidAttribute, errors.Wrap(err, "attribute pattern is not valid"))
+		}
+	case internal.AttributeNumber, internal.AttributeBoolean:
+		if definition.Pattern != "" || len(definition.Enum) > 0 {
+			return serviceerror.NewServiceError(serviceerror.InvalidAttribute,
+				fmt.Errorf("pattern and enum only apply to string attributes, %s is a %s", definition.Name, definition.Type))
+		}
+	default:
+		return serviceerror.NewServiceError(serviceerror.InvalidAttribute, fmt.Errorf("attribute type %q is not valid", definition.Type))
+	}
+	return a.data.SaveAttributeDefinition(definition)
+}
+
+func (a *attributeService) DeleteAttributeDefinition(name string) (err error) {
+	return a.data.DeleteAttributeDefinition(name)
+}
+
+// attributeSchema holds the attribute definitions by name.
+type attributeSchema map[string]internal.AttributeDefinition
+
+func loadAttributeSchema(data internal.AttributeData) (schema attributeSchema, err error) {
+	definitions, err := data.GetAttributeDefinitions()
+	if err != nil {
+		return schema, err
+	}
+	schema = make(attributeSchema, len(definitions))
+	for _, definition := range definitions {
+		schema[definition.Name] = definition
+	}
+	return schema, nil
+}
+
+// validate checks the attributes of a new user, or the changed attributes of
+// an existing user when partial is set. A nil value removes an attribute on
+// update, which is refused for required ones.
+func (s attributeSchema) validate(attributes map[string]interface{}, partial bool) (err error) {
+	for name, value := range attributes {
+		definition, ok := s[name]
+		switch {
+		case value == nil && !partial:
+			return serviceerror.NewServiceError(serviceerror.InvalidAttribute, fmt.Errorf("attribute %s has no value", name))
+		case value == nil && ok && definition.Required:
+			return serviceerror.NewServiceError(serviceerror.InvalidAttribute, fmt.Errorf("attribute %s is required", name))
+		case value == nil:
+			continue
+		case !ok:
+			return serviceerror.NewServiceError(serviceerror.InvalidAttribute, fmt.Errorf("attribute %s is not defined", name))
+		}
+		if err := checkAttribute(definition, value); err != nil {
+			return err
+		}
+	}
+	if partial {
+		return nil
+	}
+	for name, definition := range s {
+		if _, ok := attributes[name]; definition.Required && !ok {
+			return serviceerror.NewServiceError(serviceerror.InvalidAttribute, fmt.Errorf("attribute %s is required", name))
+		}
+	}
+	return nil
+}
+
+// parse converts a filter value given as text to the type of the attribute.
+func (s attributeSchema) parse(name string, text string) (value interface{}, err error) {
+	definition, ok := s[name]
+	if !ok {
+		return value, serviceerror.NewServiceError(serviceerror.InvalidAttribute, fmt.Errorf("attribute %s is not defined", name))
+	}
+	switch definition.Type {
+	case internal.AttributeNumber:
+		value, err = strconv.ParseFloat(text, 64)
+	case internal.AttributeBoolean:
+		value, err = strconv.ParseBool(text)
+	default:
+		value = text
+	}
+	if err != nil {
+		return value, serviceerror.NewServiceError(serviceerror.InvalidAttribute, errors.Wrapf(err, "attribute %s is a %s", name, definition.Type))
+	}
+	return value, nil
+}
+
+func checkAttribute(definition internal.AttributeDefinition, value interface{}) (err error) {
+	invalid := func(format string, args ...interface{}) error {
+		return serviceerror.NewServiceError(serviceerror.InvalidAttribute,
+			fmt.Errorf("attribute %s "+format, append([]interface{}{definition.Name}, args...)...))
+	}
+	switch definition.Type {
+	case internal.AttributeNumber:
+		if _, ok := value.(float64); !ok {
+			return invalid("must be a number")
+		}
+	case internal.AttributeBoolean:
+		if _, ok := value.(bool); !ok {
+			return invalid("must be a boolean")
+		}
+	case internal.AttributeString:
+		text, ok := value.(string)
+		if !ok {
+			return invalid("must be a string")
+		}
+		if definition.Pattern != "" {
+			pattern, err := regexp.Compile(definition.Pattern)
+			if err != nil {
+				return errors.Wrapf(err, "pattern of attribute %s is not valid", definition.Name)
+			}
+			if !pattern.MatchString(text) {
+				return invalid("must match %s", definition.Pattern)
+			}
+		}
+		if len(definition.Enum) > 0 && !containsString(definition.Enum, text) {
+			return invalid("must be one of %v", definition.Enum)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
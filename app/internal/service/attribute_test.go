@@ -0,0 +1,132 @@
+package service_test
+
+import (
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveAttributeDefinition(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockAttributeData(mockCtrl)
+	handler := service.NewAttributeService(data)
+
+	t.Run("save string attribute", func(t *testing.T) {
+		definition := internal.AttributeDefinition{Name: "department", Type: internal.AttributeString, Enum: []string{"eng", "ops"}}
+		data.EXPECT().SaveAttributeDefinition(definition).Return(nil).Times(1)
+		assert.NoError(t, handler.SaveAttributeDefinition(definition))
+	})
+
+	tests := []struct {
+		name       string
+		definition internal.AttributeDefinition
+	}{
+		{"error on invalid name", internal.AttributeDefinition{Name: "1st-name", Type: internal.AttributeString}},
+		{"error on unknown type", internal.AttributeDefinition{Name: "age", Type: "date"}},
+		{"error on invalid pattern", internal.AttributeDefinition{Name: "phone", Type: internal.AttributeString, Pattern: "[0-9"}},
+		{"error on enum of number", internal.AttributeDefinition{Name: "level", Type: internal.AttributeNumber, Enum: []string{"1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := handler.SaveAttributeDefinition(tt.definition)
+			assert.True(t, hasCode(err, serviceerror.InvalidAttribute))
+		})
+	}
+}
+
+func TestUserAttributes(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	attributes := mock.NewMockAttributeData(mockCtrl)
+	groups := mock.NewMockDynamicGroupService(mockCtrl)
+	notifier := mock.NewMockNotifier(mockCtrl)
+	handler := service.NewUserService(data, attributes, groups, notifier, service.UserOptions{})
+	definitions := []internal.AttributeDefinition{
+		{Name: "employeeId", Type: internal.AttributeString, Required: true, Unique: true, Pattern: "^E[0-9]+$"},
+		{Name: "department", Type: internal.AttributeString, Enum: []string{"eng", "ops"}},
+		{Name: "level", Type: internal.AttributeNumber},
+		{Name: "contractor", Type: internal.AttributeBoolean},
+	}
+	taken := func(value string) *gomock.Call {
+		return data.EXPECT().GetUsers(uint(0), uint(2), internal.UsersFilter{Attributes: map[string]interface{}{"employeeId": value}})
+	}
+
+	t.Run("create user with valid attributes", func(t *testing.T) {
+		request := internal.UserRequest{Name: "test", Email: "test@gmail.com", Password: "12345678", Attributes: map[string]interface{}{
+			"employeeId": "E1", "department": "eng", "level": float64(3), "contractor": false,
+		}}
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		taken("E1").Return(internal.UsersResponse{}, nil).Times(1)
+		data.EXPECT().CreateUser(request).Return(internal.UserResponse{ID: 1, Email: "test@gmail.com"}, nil).Times(1)
+		groups.EXPECT().SyncUser(uint(1)).Return(nil).Times(1)
+		data.EXPECT().CreateEmailVerification(uint(1), "test@gmail.com", gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		notifier.EXPECT().Notify(gomock.Any()).Return(nil).Times(1)
+		_, err := handler.CreateUser(request)
+		assert.NoError(t, err)
+	})
+
+	tests := []struct {
+		name       string
+		attributes map[string]interface{}
+	}{
+		{"error on missing required attribute", map[string]interface{}{"department": "eng"}},
+		{"error on undefined attribute", map[string]interface{}{"employeeId": "E1", "phone": "123"}},
+		{"error on wrong type", map[string]interface{}{"employeeId": "E1", "level": "3"}},
+		{"error on pattern mismatch", map[string]interface{}{"employeeId": "X1"}},
+		{"error on value outside enum", map[string]interface{}{"employeeId": "E1", "department": "sales"}},
+		{"error on null value", map[string]interface{}{"employeeId": "E1", "level": nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+			_, err := handler.CreateUser(internal.UserRequest{Name: "test", Email: "test@gmail.com", Password: "12345678", Attributes: tt.attributes})
+			assert.True(t, hasCode(err, serviceerror.InvalidAttribute))
+		})
+	}
+
+	t.Run("error on unique value of another user", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		taken("E1").Return(internal.UsersResponse{Users: []internal.UserResponse{{ID: 2}}}, nil).Times(1)
+		err := handler.UpdateUser(internal.UpdateUserRequest{ID: 1, Attributes: map[string]interface{}{"employeeId": "E1"}})
+		assert.True(t, hasCode(err, serviceerror.DuplicateAttribute))
+	})
+
+	t.Run("update own unique value and remove optional attribute", func(t *testing.T) {
+		request := internal.UpdateUserRequest{ID: 1, Attributes: map[string]interface{}{"employeeId": "E1", "level": nil}}
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		taken("E1").Return(internal.UsersResponse{Users: []internal.UserResponse{{ID: 1}}}, nil).Times(1)
+		data.EXPECT().UpdateUser(request).Return(nil).Times(1)
+		groups.EXPECT().SyncUser(uint(1)).Return(nil).Times(1)
+		assert.NoError(t, handler.UpdateUser(request))
+	})
+
+	t.Run("error on removing required attribute", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		err := handler.UpdateUser(internal.UpdateUserRequest{ID: 1, Attributes: map[string]interface{}{"employeeId": nil}})
+		assert.True(t, hasCode(err, serviceerror.InvalidAttribute))
+	})
+
+	t.Run("filter by typed attribute values", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		data.EXPECT().GetUsers(uint(0), uint(10), internal.UsersFilter{Attributes: map[string]interface{}{
+			"department": "eng", "level": float64(3), "contractor": true,
+		}}).Return(internal.UsersResponse{}, nil).Times(1)
+		_, err := handler.GetUsers(1, 10, internal.UsersFilter{Attributes: map[string]interface{}{
+			"department": "eng", "level": "3", "contractor": "true",
+		}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("error on filter by undefined attribute", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		_, err := handler.GetUsers(1, 10, internal.UsersFilter{Attributes: map[string]interface{}{"phone": "123"}})
+		assert.True(t, hasCode(err, serviceerror.InvalidAttribute))
+	})
+}
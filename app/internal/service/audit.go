@@ -0,0 +1,34 @@
+package service
+
+import (
+	"expvar"
+	"usermanagement/app/internal"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// auditEvents counts recorded events by type, published on /debug/vars.
+var auditEvents = expvar.NewMap("audit_events")
+
+type auditor struct {
+	data internal.AuditData
+}
+
+func NewAuditor(data internal.AuditData) *auditor {
+	return &auditor{
+		data: data,
+	}
+}
+
+// Record never fails the caller, an event that can't be stored is logged.
+func (a *auditor) Record(event internal.AuditEvent) {
+	auditEvents.Add(event.Type, 1)
+	log.WithFields(log.Fields{
+		"event": event.Type,
+		"user":  event.UserID,
+		"ip":    event.IP,
+	}).Info("audit event")
+	if err := a.data.CreateAuditEvent(event); err != nil {
+		log.WithError(err).WithField("event", event.Type).Error("storing audit event failed")
+	}
+}
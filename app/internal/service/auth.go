@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultResetTokenTTL = 30 * time.Minute
+	mfaChallengeTTL      = 5 * time.Minute
+)
+
+// AuthOptions configures logins, Keys sign the access tokens and Admins are
+// the emails of the users allowed to manage every group. Only users of the
+// default organization who verified their email are admins, emails are only
+// unique within an organization.
+type AuthOptions struct {
+	ResetTokenTTL time.Duration
+	Keys          internal.SigningKeys
+	TokenTTL      time.Duration
+	Admins        []string
+}
+
+type authService struct {
+	data         internal.UserData
+	mfa          internal.MFAData
+	accessTokens internal.AccessTokenData
+	lockout      internal.LockoutService
+	notifier     internal.Notifier
+	jobs         internal.JobService
+	tokens       tokenIssuer
+	options      AuthOptions
+	organization *uint
+}
+
+func NewAuthService(data internal.UserData, mfa internal.MFAData, accessTokens internal.AccessTokenData, lockout internal.LockoutService,
+	notifier internal.Notifier, jobs internal.JobService, options AuthOptions) *authService {
+	if options.ResetTokenTTL == 0 {
+		options.ResetTokenTTL = defaultResetTokenTTL
+	}
+	return &authService{
+		data:         data,
+		mfa:          mfa,
+		accessTokens: accessTokens,
+		lockout:      lockout,
+		notifier:     notifier,
+		jobs:         jobs,
+		tokens:       newTokenIssuer(options.Keys, options.TokenTTL),
+		options:      options,
+	}
+}
+
+// Login checks the password and either issues an access token or, when MFA is
+// enabled, a short lived mfa token to be completed through LoginMFA. Attempts
+// are throttled per email and source IP and only active users can log in.
+func (a *authService) Login(email string, password string, ip string) (response internal.LoginResponse, err error) {
+	err = a.lockout.Check(email, ip)
+	if err != nil {
+		return response, err
+	}
+	user, err := a.data.Authenticate(email, password)
+	if hasErrorCode(err, serviceerror.InvalidCredentials) {
+		a.fail(email, ip)
+		return response, err
+	}
+	if err != nil {
+		return response, err
+	}
+	err = checkActive(user)
+	if err != nil {
+		return response, err
+	}
+	mfa, err := a.mfa.GetMFA(user.ID)
+	if err != nil && !hasErrorCode(err, serviceerror.MFANotEnrolled) {
+		return response, err
+	}
+	if err != nil || !mfa.Enabled {
+		return a.succeed(user, ip)
+	}
+	token, err := randomToken()
+	if err != nil {
+		return response, err
+	}
+	err = a.mfa.CreateMFAChallenge(user.ID, hashToken(token), time.Now().Add(mfaChallengeTTL))
+	if err != nil {
+		return response, err
+	}
+	response = internal.LoginResponse{
+		MFARequired: true,
+		MFAToken:    token,
+	}
+	return response, err
+}
+
+func (a *authService) LoginMFA(mfaToken string, code string, ip string) (response internal.LoginResponse, err error) {
+	userID, err := a.mfa.ConsumeMFAChallenge(hashToken(mfaToken))
+	if err != nil {
+		return response, err
+	}
+	user, err := a.data.GetUser(userID)
+	if err != nil {
+		return response, err
+	}
+	err = checkActive(user)
+	if err != nil {
+		return response, err
+	}
+	err = a.lockout.Check(user.Email, ip)
+	if err != nil {
+		return response, err
+	}
+	mfa, err := a.mfa.GetMFA(userID)
+	if err != nil {
+		return response, err
+	}
+	if !mfa.Enabled {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("mfa is not enabled"))
+	}
+	err = verifyMFACode(a.mfa, mfa, code)
+	if hasErrorCode(err, serviceerror.InvalidMFACode) {
+		a.fail(user.Email, ip)
+		return response, err
+	}
+	if err != nil {
+		return response, err
+	}
+	return a.succeed(user, ip)
+}
+
+// fail counts a failed attempt, the caller still reports the original error
+// when the attempt store is unavailable.
+func (a *authService) fail(email string, ip string) {
+	if err := a.lockout.Fail(email, ip); err != nil {
+		log.WithError(err).Error("recording failed login failed")
+	}
+}
+
+func (a *authService) succeed(user internal.UserResponse, ip string) (response internal.LoginResponse, err error) {
+	if err := a.lockout.Succeed(user.ID, user.Email, ip); err != nil {
+		log.WithError(err).WithField("user", user.ID).Error("resetting login attempts failed")
+	}
+	return a.tokens.issue(user)
+}
+
+// Authenticate returns the caller an access token was issued to, as long as
+// the user is still active. A personal access token, or one issued to an OAuth
+// client, limits the caller to its scopes until it expires or is revoked.
+func (a *authService) Authenticate(accessToken string) (response internal.Caller, err error) {
+	if strings.HasPrefix(accessToken, internal.AccessTokenPrefix) || strings.HasPrefix(accessToken, internal.OAuthTokenPrefix) {
+		return a.authenticatePersonal(accessToken)
+	}
+	userID, err := a.tokens.verify(accessToken)
+	if err != nil {
+		return response, err
+	}
+	return a.caller(userID, nil)
+}
+
+func (a *authService) authenticatePersonal(accessToken string) (response internal.Caller, err error) {
+	token, err := a.accessTokens.GetAccessTokenByHash(hashToken(accessToken))
+	if hasErrorCode(err, serviceerror.AccessTokenNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.Unauthenticated, errors.New("access token is invalid"))
+	}
+	if err != nil {
+		return response, err
+	}
+	now := time.Now()
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(now) {
+		return response, serviceerror.NewServiceError(serviceerror.Unauthenticated, fmt.Errorf("access token %d is expired", token.ID))
+	}
+	response, err = a.caller(token.UserID, token.Scopes)
+	if err != nil {
+		return response, err
+	}
+	if err := a.accessTokens.UseAccessToken(token.ID, now); err != nil {
+		log.WithError(err).WithField("token", token.ID).Error("recording access token use failed")
+	}
+	return response, nil
+}
+
+func (a *authService) caller(userID uint, scopes []string) (response internal.Caller, err error) {
+	user, err := a.data.GetUser(userID)
+	if hasErrorCode(err, serviceerror.UserNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.Unauthenticated, fmt.Errorf("user %d of access token not found", userID))
+	}
+	if err != nil {
+		return response, err
+	}
+	if err := checkActive(user); err != nil {
+		return response, err
+	}
+	response = internal.Caller{UserID: user.ID, OrganizationID: user.OrganizationID, Scopes: scopes}
+	response.Admin = a.admin(user)
+	return response, nil
+}
+
+// admin tells whether the user is one of the admins. Anyone can sign up with
+// an admin's email in another organization, or before confirming it.
+func (a *authService) admin(user internal.UserResponse) bool {
+	if user.OrganizationID != 0 || !user.EmailVerified {
+		return false
+	}
+	for _, email := range a.options.Admins {
+		if strings.EqualFold(email, user.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizeAdmin lets only admins who logged in through.
+func (a *authService) AuthorizeAdmin(caller internal.Caller) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't act as admin"))
+	}
+	if !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d is not an admin", caller.UserID))
+	}
+	return nil
+}
+
+// ForgotPassword never reports whether the email belongs to a user, it only
+// queues the reset for known and unknown addresses alike. The job looks the
+// user up and mails the token, the response takes the same time either way.
+func (a *authService) ForgotPassword(email string) (err error) {
+	_, err = a.jobs.Enqueue(internal.JobSendPasswordReset, 0, internal.PasswordResetRequest{Email: email, OrganizationID: a.organization})
+	return err
+}
+
+// RunPasswordResetJob is the internal.JobHandler of
+// internal.JobSendPasswordReset. A failed notification fails the job, it is
+// retried with a new token.
+func (a *authService) RunPasswordResetJob(ctx context.Context, payload []byte, progress func(internal.JobProgress)) (result interface{}, err error) {
+	var request internal.PasswordResetRequest
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, serviceerror.NewServiceError(serviceerror.InvalidJobRequest, errors.Wrap(err, "decode password reset failed"))
+	}
+	run := a
+	if request.OrganizationID != nil {
+		run = a.forOrganization(*request.OrganizationID)
+	}
+	return nil, run.sendPasswordReset(request.Email)
+}
+
+func (a *authService) sendPasswordReset(email string) (err error) {
+	user, err := a.data.GetUserByEmail(email)
+	if hasErrorCode(err, serviceerror.UserNotFound) {
+		log.Debug("password reset requested for unknown email")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Type == internal.UserServiceAccount {
+		log.WithField("user", user.ID).Debug("password reset requested for service account")
+		return nil
+	}
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(a.options.ResetTokenTTL)
+	err = a.data.CreatePasswordReset(user.ID, hashToken(token), expiresAt)
+	if err != nil {
+		return err
+	}
+	return a.notifier.Notify(internal.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello %s,\n\nUse the token below to reset your password. It expires at %s.\n\n%s\n",
+			user.Name, expiresAt.UTC().Format(time.RFC1123), token),
+	})
+}
+
+func (a *authService) ResetPassword(token string, password string) (err error) {
+	if token == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidResetToken, errors.New("missing reset token"))
+	}
+	_, err = a.data.ResetPassword(hashToken(token), password)
+	return err
+}
+
+func (a *authService) VerifyEmail(token string) (err error) {
+	if token == "" {
+		return serviceerror.NewServiceError(serviceerror.InvalidVerificationToken, errors.New("missing verification token"))
+	}
+	user, previousEmail, err := a.data.ConfirmEmailVerification(hashToken(token))
+	if err != nil {
+		return err
+	}
+	if previousEmail == user.Email {
+		return nil
+	}
+	err = a.notifier.Notify(internal.Message{
+		To:      previousEmail,
+		Subject: "Email changed",
+		Body:    fmt.Sprintf("Hello %s,\n\nThe email of your account was changed to %s.\n", user.Name, user.Email),
+	})
+	if err != nil {
+		log.WithError(err).WithField("user", user.ID).Error("sending email changed notice failed")
+	}
+	return nil
+}
+
+func hasErrorCode(err error, code serviceerror.ErrorCode) bool {
+	var srvError *serviceerror.ServiceError
+	return errors.As(err, &srvError) && srvError.Code == code
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generate token failed")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ForOrganization returns the service that logs in the users of the
+// organization, emails are only unique within one.
+func (a *authService) ForOrganization(organizationID uint) internal.AuthService {
+	return a.forOrganization(organizationID)
+}
+
+func (a *authService) forOrganization(organizationID uint) *authService {
+	scoped := *a
+	scoped.data = a.data.ForOrganization(organizationID)
+	scoped.lockout = a.lockout.ForOrganization(organizationID)
+	scoped.organization = &organizationID
+	return &scoped
+}
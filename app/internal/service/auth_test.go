@@ -0,0 +1,464 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/signing"
+	"usermanagement/app/internal/totp"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForgotPassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	jobs := mock.NewMockJobService(mockCtrl)
+	lockout := mock.NewMockLockoutService(mockCtrl)
+	handler := service.NewAuthService(data, mock.NewMockMFAData(mockCtrl), mock.NewMockAccessTokenData(mockCtrl),
+		lockout, mock.NewMockNotifier(mockCtrl), jobs, service.AuthOptions{})
+
+	t.Run("queue reset of any email", func(t *testing.T) {
+		jobs.EXPECT().Enqueue(internal.JobSendPasswordReset, uint(0), internal.PasswordResetRequest{Email: "unknown@gmail.com"}).
+			Return(internal.Job{ID: 1}, nil).Times(1)
+		err := handler.ForgotPassword("unknown@gmail.com")
+		assert.NoError(t, err)
+	})
+
+	t.Run("queue reset in organization", func(t *testing.T) {
+		organization := uint(2)
+		data.EXPECT().ForOrganization(organization).Return(data).Times(1)
+		lockout.EXPECT().ForOrganization(organization).Return(lockout).Times(1)
+		jobs.EXPECT().Enqueue(internal.JobSendPasswordReset, uint(0), internal.PasswordResetRequest{Email: "test@gmail.com", OrganizationID: &organization}).
+			Return(internal.Job{ID: 2}, nil).Times(1)
+		err := handler.ForOrganization(organization).ForgotPassword("test@gmail.com")
+		assert.NoError(t, err)
+	})
+
+	t.Run("error on queue failure", func(t *testing.T) {
+		jobs.EXPECT().Enqueue(gomock.Any(), gomock.Any(), gomock.Any()).Return(internal.Job{}, errors.New("test")).Times(1)
+		err := handler.ForgotPassword("test@gmail.com")
+		assert.EqualError(t, err, "test")
+	})
+}
+
+func TestRunPasswordResetJob(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	notifier := mock.NewMockNotifier(mockCtrl)
+	handler := service.NewAuthService(data, mock.NewMockMFAData(mockCtrl), mock.NewMockAccessTokenData(mockCtrl),
+		mock.NewMockLockoutService(mockCtrl), notifier, mock.NewMockJobService(mockCtrl), service.AuthOptions{})
+	run := func(email string) error {
+		_, err := handler.RunPasswordResetJob(context.Background(), []byte(`{"email":"`+email+`"}`), func(internal.JobProgress) {})
+		return err
+	}
+
+	t.Run("send reset token successfully", func(t *testing.T) {
+		data.EXPECT().GetUserByEmail("test@gmail.com").Return(internal.UserResponse{
+			ID:    1,
+			Name:  "test",
+			Email: "test@gmail.com",
+		}, nil).Times(1)
+		var tokenHash string
+		data.EXPECT().CreatePasswordReset(uint(1), gomock.Any(), gomock.Any()).
+			Do(func(userID uint, hash string, _ interface{}) { tokenHash = hash }).Return(nil).Times(1)
+		notifier.EXPECT().Notify(gomock.Any()).Do(func(message internal.Message) {
+			assert.Equal(t, "test@gmail.com", message.To)
+			assert.NotContains(t, message.Body, tokenHash)
+		}).Return(nil).Times(1)
+		assert.NoError(t, run("test@gmail.com"))
+	})
+
+	t.Run("skip unknown email", func(t *testing.T) {
+		data.EXPECT().GetUserByEmail("unknown@gmail.com").Return(internal.UserResponse{},
+			serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))).Times(1)
+		assert.NoError(t, run("unknown@gmail.com"))
+	})
+
+	t.Run("fail on notifier failure", func(t *testing.T) {
+		data.EXPECT().GetUserByEmail("test@gmail.com").Return(internal.UserResponse{
+			ID:    1,
+			Email: "test@gmail.com",
+		}, nil).Times(1)
+		data.EXPECT().CreatePasswordReset(uint(1), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		notifier.EXPECT().Notify(gomock.Any()).Return(errors.New("test")).Times(1)
+		assert.EqualError(t, run("test@gmail.com"), "test")
+	})
+
+	t.Run("fail on invalid payload", func(t *testing.T) {
+		_, err := handler.RunPasswordResetJob(context.Background(), []byte("{"), func(internal.JobProgress) {})
+		assert.True(t, hasCode(err, serviceerror.InvalidJobRequest))
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	notifier := mock.NewMockNotifier(mockCtrl)
+	handler := service.NewAuthService(data, mock.NewMockMFAData(mockCtrl), mock.NewMockAccessTokenData(mockCtrl),
+		mock.NewMockLockoutService(mockCtrl), notifier, nil, service.AuthOptions{})
+
+	t.Run("reset password successfully", func(t *testing.T) {
+		data.EXPECT().ResetPassword(gomock.Not("token"), "12345678").Return(uint(1), nil).Times(1)
+		err := handler.ResetPassword("token", "12345678")
+		assert.NoError(t, err)
+	})
+
+	t.Run("error on invalid token", func(t *testing.T) {
+		tokenErr := serviceerror.NewServiceError(serviceerror.InvalidResetToken, errors.New("test"))
+		data.EXPECT().ResetPassword(gomock.Any(), "12345678").Return(uint(0), tokenErr).Times(1)
+		err := handler.ResetPassword("token", "12345678")
+		assert.Equal(t, tokenErr, err)
+	})
+
+	t.Run("error on missing token", func(t *testing.T) {
+		err := handler.ResetPassword("", "12345678")
+		assert.EqualError(t, err, "Invalid Reset Token : missing reset token")
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	notifier := mock.NewMockNotifier(mockCtrl)
+	handler := service.NewAuthService(data, mock.NewMockMFAData(mockCtrl), mock.NewMockAccessTokenData(mockCtrl),
+		mock.NewMockLockoutService(mockCtrl), notifier, nil, service.AuthOptions{})
+
+	t.Run("verify signup email", func(t *testing.T) {
+		data.EXPECT().ConfirmEmailVerification(gomock.Any()).Return(internal.UserResponse{
+			ID: 1, Email: "test@gmail.com", EmailVerified: true,
+		}, "test@gmail.com", nil).Times(1)
+		err := handler.VerifyEmail("token")
+		assert.NoError(t, err)
+	})
+
+	t.Run("confirm email change and notify old email", func(t *testing.T) {
+		data.EXPECT().ConfirmEmailVerification(gomock.Any()).Return(internal.UserResponse{
+			ID: 1, Email: "new@gmail.com", EmailVerified: true,
+		}, "old@gmail.com", nil).Times(1)
+		notifier.EXPECT().Notify(gomock.Any()).Do(func(message internal.Message) {
+			assert.Equal(t, "old@gmail.com", message.To)
+			assert.Contains(t, message.Body, "new@gmail.com")
+		}).Return(nil).Times(1)
+		err := handler.VerifyEmail("token")
+		assert.NoError(t, err)
+	})
+
+	t.Run("error on invalid token", func(t *testing.T) {
+		tokenErr := serviceerror.NewServiceError(serviceerror.InvalidVerificationToken, errors.New("test"))
+		data.EXPECT().ConfirmEmailVerification(gomock.Any()).Return(internal.UserResponse{}, "", tokenErr).Times(1)
+		err := handler.VerifyEmail("token")
+		assert.Equal(t, tokenErr, err)
+	})
+}
+
+func TestLogin(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	mfaData := mock.NewMockMFAData(mockCtrl)
+	lockout := mock.NewMockLockoutService(mockCtrl)
+	handler := service.NewAuthService(data, mfaData, mock.NewMockAccessTokenData(mockCtrl), lockout,
+		mock.NewMockNotifier(mockCtrl), nil, service.AuthOptions{Keys: newTestKeys(t, signing.RS256)})
+	user := internal.UserResponse{ID: 1, Name: "test", Email: "test@gmail.com", Status: internal.StatusActive}
+
+	t.Run("issue token without mfa", func(t *testing.T) {
+		lockout.EXPECT().Check("test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		data.EXPECT().Authenticate("test@gmail.com", "12345678").Return(user, nil).Times(1)
+		mfaData.EXPECT().GetMFA(uint(1)).Return(internal.MFA{},
+			serviceerror.NewServiceError(serviceerror.MFANotEnrolled, errors.New("test"))).Times(1)
+		lockout.EXPECT().Succeed(uint(1), "test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		response, err := handler.Login("test@gmail.com", "12345678", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+		assert.Equal(t, "Bearer", response.TokenType)
+		assert.False(t, response.MFARequired)
+	})
+
+	t.Run("issue token with pending mfa enrollment", func(t *testing.T) {
+		lockout.EXPECT().Check("test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		data.EXPECT().Authenticate("test@gmail.com", "12345678").Return(user, nil).Times(1)
+		mfaData.EXPECT().GetMFA(uint(1)).Return(internal.MFA{UserID: 1, Secret: "secret"}, nil).Times(1)
+		lockout.EXPECT().Succeed(uint(1), "test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		response, err := handler.Login("test@gmail.com", "12345678", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+	})
+
+	t.Run("challenge when mfa is enabled", func(t *testing.T) {
+		lockout.EXPECT().Check("test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		data.EXPECT().Authenticate("test@gmail.com", "12345678").Return(user, nil).Times(1)
+		mfaData.EXPECT().GetMFA(uint(1)).Return(internal.MFA{UserID: 1, Secret: "secret", Enabled: true}, nil).Times(1)
+		mfaData.EXPECT().CreateMFAChallenge(uint(1), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		response, err := handler.Login("test@gmail.com", "12345678", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.Empty(t, response.AccessToken)
+		assert.True(t, response.MFARequired)
+		assert.NotEmpty(t, response.MFAToken)
+	})
+
+	t.Run("error on wrong credentials", func(t *testing.T) {
+		credentialErr := serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("test"))
+		lockout.EXPECT().Check("test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		data.EXPECT().Authenticate("test@gmail.com", "wrong").Return(internal.UserResponse{}, credentialErr).Times(1)
+		lockout.EXPECT().Fail("test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		_, err := handler.Login("test@gmail.com", "wrong", "10.0.0.1")
+		assert.Equal(t, credentialErr, err)
+	})
+
+	t.Run("error when throttled", func(t *testing.T) {
+		throttleErr := serviceerror.NewServiceError(serviceerror.TooManyAttempts, errors.New("test"))
+		lockout.EXPECT().Check("test@gmail.com", "10.0.0.1").Return(throttleErr).Times(1)
+		_, err := handler.Login("test@gmail.com", "12345678", "10.0.0.1")
+		assert.Equal(t, throttleErr, err)
+	})
+
+	t.Run("error on suspended user", func(t *testing.T) {
+		lockout.EXPECT().Check("test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		data.EXPECT().Authenticate("test@gmail.com", "12345678").
+			Return(internal.UserResponse{ID: 1, Email: "test@gmail.com", Status: internal.StatusSuspended}, nil).Times(1)
+		response, err := handler.Login("test@gmail.com", "12345678", "10.0.0.1")
+		assert.EqualError(t, err, "User Inactive : user is suspended")
+		assert.Empty(t, response.AccessToken)
+	})
+}
+
+func TestLoginMFA(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	mfaData := mock.NewMockMFAData(mockCtrl)
+	lockout := mock.NewMockLockoutService(mockCtrl)
+	handler := service.NewAuthService(data, mfaData, mock.NewMockAccessTokenData(mockCtrl), lockout,
+		mock.NewMockNotifier(mockCtrl), nil, service.AuthOptions{Keys: newTestKeys(t, signing.RS256)})
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+	mfa := internal.MFA{UserID: 1, Secret: secret, Enabled: true}
+	user := internal.UserResponse{ID: 1, Email: "test@gmail.com", Status: internal.StatusActive}
+
+	t.Run("issue token with totp code", func(t *testing.T) {
+		step := totp.Step(time.Now())
+		code, err := totp.Code(secret, step)
+		assert.NoError(t, err)
+		mfaData.EXPECT().ConsumeMFAChallenge(gomock.Any()).Return(uint(1), nil).Times(1)
+		data.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+		lockout.EXPECT().Check("test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		mfaData.EXPECT().GetMFA(uint(1)).Return(mfa, nil).Times(1)
+		mfaData.EXPECT().UseMFAStep(uint(1), gomock.Any()).Return(nil).Times(1)
+		lockout.EXPECT().Succeed(uint(1), "test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		response, err := handler.LoginMFA("token", code, "10.0.0.1")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+	})
+
+	t.Run("issue token with recovery code", func(t *testing.T) {
+		mfaData.EXPECT().ConsumeMFAChallenge(gomock.Any()).Return(uint(1), nil).Times(1)
+		data.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+		lockout.EXPECT().Check("test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		mfaData.EXPECT().GetMFA(uint(1)).Return(mfa, nil).Times(1)
+		mfaData.EXPECT().UseRecoveryCode(uint(1), gomock.Any()).Return(nil).Times(1)
+		lockout.EXPECT().Succeed(uint(1), "test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		response, err := handler.LoginMFA("token", "abcd-efgh", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+	})
+
+	t.Run("error on wrong code", func(t *testing.T) {
+		mfaData.EXPECT().ConsumeMFAChallenge(gomock.Any()).Return(uint(1), nil).Times(1)
+		data.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+		lockout.EXPECT().Check("test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		mfaData.EXPECT().GetMFA(uint(1)).Return(mfa, nil).Times(1)
+		lockout.EXPECT().Fail("test@gmail.com", "10.0.0.1").Return(nil).Times(1)
+		_, err := handler.LoginMFA("token", "000", "10.0.0.1")
+		assert.EqualError(t, err, "Invalid MFA Code : mfa code is wrong")
+	})
+
+	t.Run("error on invalid challenge", func(t *testing.T) {
+		challengeErr := serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("test"))
+		mfaData.EXPECT().ConsumeMFAChallenge(gomock.Any()).Return(uint(0), challengeErr).Times(1)
+		_, err := handler.LoginMFA("token", "123456", "10.0.0.1")
+		assert.Equal(t, challengeErr, err)
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	mfaData := mock.NewMockMFAData(mockCtrl)
+	lockout := mock.NewMockLockoutService(mockCtrl)
+	keys := newTestKeys(t, signing.RS256)
+	handler := service.NewAuthService(data, mfaData, mock.NewMockAccessTokenData(mockCtrl), lockout, mock.NewMockNotifier(mockCtrl),
+		nil, service.AuthOptions{Keys: keys, Admins: []string{"Admin@gmail.com"}})
+	login := func(user internal.UserResponse) string {
+		lockout.EXPECT().Check(user.Email, "10.0.0.1").Return(nil).Times(1)
+		data.EXPECT().Authenticate(user.Email, "12345678").Return(user, nil).Times(1)
+		mfaData.EXPECT().GetMFA(user.ID).Return(internal.MFA{},
+			serviceerror.NewServiceError(serviceerror.MFANotEnrolled, errors.New("test"))).Times(1)
+		lockout.EXPECT().Succeed(user.ID, user.Email, "10.0.0.1").Return(nil).Times(1)
+		response, err := handler.Login(user.Email, "12345678", "10.0.0.1")
+		assert.NoError(t, err)
+		return response.AccessToken
+	}
+	user := internal.UserResponse{ID: 1, Email: "test@gmail.com", Status: internal.StatusActive}
+	admin := internal.UserResponse{ID: 2, Email: "admin@gmail.com", EmailVerified: true, Status: internal.StatusActive}
+
+	t.Run("identify user", func(t *testing.T) {
+		token := login(user)
+		data.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+		caller, err := handler.Authenticate(token)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Caller{UserID: 1}, caller)
+	})
+
+	t.Run("identify admin", func(t *testing.T) {
+		token := login(admin)
+		data.EXPECT().GetUser(uint(2)).Return(admin, nil).Times(1)
+		caller, err := handler.Authenticate(token)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Caller{UserID: 2, Admin: true}, caller)
+	})
+
+	t.Run("not admin with unverified email", func(t *testing.T) {
+		unverified := internal.UserResponse{ID: 3, Email: "admin@gmail.com", Status: internal.StatusActive}
+		token := login(unverified)
+		data.EXPECT().GetUser(uint(3)).Return(unverified, nil).Times(1)
+		caller, err := handler.Authenticate(token)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Caller{UserID: 3}, caller)
+	})
+
+	t.Run("not admin in other organization", func(t *testing.T) {
+		tenant := internal.UserResponse{ID: 4, OrganizationID: 7, Email: "admin@gmail.com", EmailVerified: true, Status: internal.StatusActive}
+		token := login(tenant)
+		data.EXPECT().GetUser(uint(4)).Return(tenant, nil).Times(1)
+		caller, err := handler.Authenticate(token)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Caller{UserID: 4, OrganizationID: 7}, caller)
+	})
+
+	t.Run("fail on invalid token", func(t *testing.T) {
+		_, err := handler.Authenticate("invalid")
+		assert.True(t, hasCode(err, serviceerror.Unauthenticated))
+	})
+
+	t.Run("identify user with token of retired key", func(t *testing.T) {
+		token := login(user)
+		keys.rotate(t, signing.EdDSA)
+		data.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+		caller, err := handler.Authenticate(token)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Caller{UserID: 1}, caller)
+	})
+
+	t.Run("fail on token of unknown key", func(t *testing.T) {
+		other := service.NewAuthService(data, mfaData, mock.NewMockAccessTokenData(mockCtrl), lockout, mock.NewMockNotifier(mockCtrl),
+			nil, service.AuthOptions{Keys: newTestKeys(t, signing.EdDSA)})
+		_, err := other.Authenticate(login(user))
+		assert.True(t, hasCode(err, serviceerror.Unauthenticated))
+	})
+
+	t.Run("fail on token that isn't an access token", func(t *testing.T) {
+		key, err := keys.Signing()
+		assert.NoError(t, err)
+		idToken := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), jwt.RegisteredClaims{
+			Subject: "1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+		idToken.Header["kid"] = key.ID
+		token, err := idToken.SignedString(key.Key)
+		assert.NoError(t, err)
+		_, err = handler.Authenticate(token)
+		assert.True(t, hasCode(err, serviceerror.Unauthenticated))
+
+		secretToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "1"}).SignedString([]byte("change-me"))
+		assert.NoError(t, err)
+		_, err = handler.Authenticate(secretToken)
+		assert.True(t, hasCode(err, serviceerror.Unauthenticated))
+	})
+
+	t.Run("fail on deleted user", func(t *testing.T) {
+		token := login(user)
+		data.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{},
+			serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))).Times(1)
+		_, err := handler.Authenticate(token)
+		assert.True(t, hasCode(err, serviceerror.Unauthenticated))
+	})
+
+	t.Run("fail on suspended user", func(t *testing.T) {
+		token := login(user)
+		data.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Status: internal.StatusSuspended}, nil).Times(1)
+		_, err := handler.Authenticate(token)
+		assert.True(t, hasCode(err, serviceerror.UserInactive))
+	})
+}
+
+func TestAuthenticatePersonalAccessToken(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	accessTokens := mock.NewMockAccessTokenData(mockCtrl)
+	handler := service.NewAuthService(data, mock.NewMockMFAData(mockCtrl), accessTokens, mock.NewMockLockoutService(mockCtrl),
+		mock.NewMockNotifier(mockCtrl), nil, service.AuthOptions{Keys: newTestKeys(t, signing.RS256)})
+	user := internal.UserResponse{ID: 1, Email: "ci@example.com", Type: internal.UserServiceAccount, Status: internal.StatusActive}
+
+	t.Run("identify user with scopes", func(t *testing.T) {
+		accessTokens.EXPECT().GetAccessTokenByHash(gomock.Any()).Return(internal.AccessToken{ID: 3, UserID: 1, Scopes: []string{"users:read"}}, nil).Times(1)
+		data.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+		accessTokens.EXPECT().UseAccessToken(uint(3), gomock.Any()).Return(nil).Times(1)
+		caller, err := handler.Authenticate("pat_token")
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Caller{UserID: 1, Scopes: []string{"users:read"}}, caller)
+	})
+
+	t.Run("identify user of oauth token", func(t *testing.T) {
+		accessTokens.EXPECT().GetAccessTokenByHash(gomock.Any()).Return(internal.AccessToken{ID: 4, UserID: 1, ClientID: "web", Scopes: []string{"groups:read"}}, nil).Times(1)
+		data.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+		accessTokens.EXPECT().UseAccessToken(uint(4), gomock.Any()).Return(nil).Times(1)
+		caller, err := handler.Authenticate("oat_token")
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Caller{UserID: 1, Scopes: []string{"groups:read"}}, caller)
+	})
+
+	t.Run("fail on unknown token", func(t *testing.T) {
+		accessTokens.EXPECT().GetAccessTokenByHash(gomock.Any()).
+			Return(internal.AccessToken{}, serviceerror.NewServiceError(serviceerror.AccessTokenNotFound, errors.New("test"))).Times(1)
+		_, err := handler.Authenticate("pat_token")
+		assert.True(t, hasCode(err, serviceerror.Unauthenticated))
+	})
+
+	t.Run("fail on expired token", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		accessTokens.EXPECT().GetAccessTokenByHash(gomock.Any()).
+			Return(internal.AccessToken{ID: 3, UserID: 1, Scopes: []string{"users:read"}, ExpiresAt: &expiresAt}, nil).Times(1)
+		_, err := handler.Authenticate("pat_token")
+		assert.True(t, hasCode(err, serviceerror.Unauthenticated))
+	})
+
+	t.Run("fail on disabled user", func(t *testing.T) {
+		accessTokens.EXPECT().GetAccessTokenByHash(gomock.Any()).Return(internal.AccessToken{ID: 3, UserID: 1, Scopes: []string{"users:read"}}, nil).Times(1)
+		data.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Status: internal.StatusDisabled}, nil).Times(1)
+		_, err := handler.Authenticate("pat_token")
+		assert.True(t, hasCode(err, serviceerror.UserInactive))
+	})
+}
+
+func TestAuthServiceAuthorizeAdmin(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler := service.NewAuthService(mock.NewMockUserData(mockCtrl), mock.NewMockMFAData(mockCtrl), mock.NewMockAccessTokenData(mockCtrl),
+		mock.NewMockLockoutService(mockCtrl), mock.NewMockNotifier(mockCtrl), nil, service.AuthOptions{Keys: newTestKeys(t, signing.RS256)})
+	assert.NoError(t, handler.AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}))
+	assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 1}), serviceerror.Forbidden))
+	assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true, Scopes: []string{"users:read"}}), serviceerror.Forbidden))
+}
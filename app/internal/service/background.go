@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RunPeriodically calls task every interval until ctx is done. Errors are
+// logged, the task is retried on the next tick.
+func RunPeriodically(ctx context.Context, name string, interval time.Duration, task func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := task(); err != nil {
+				log.WithError(err).WithField("task", name).Error("background task failed")
+			}
+		}
+	}
+}
+
+// PeriodicTask runs Task every Interval as a background service of the
+// server, for work that belongs to no service of its own.
+type PeriodicTask struct {
+	Name     string
+	Interval time.Duration
+	Task     func() error
+}
+
+func (p *PeriodicTask) Init() (err error) {
+	return nil
+}
+
+func (p *PeriodicTask) Run(ctx context.Context) (err error) {
+	RunPeriodically(ctx, p.Name, p.Interval, p.Task)
+	return nil
+}
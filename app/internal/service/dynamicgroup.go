@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/rule"
+	"usermanagement/app/internal/serviceerror"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultReconcileInterval = 5 * time.Minute
+	maxRuleLength            = 1000
+	dynamicGroupsPerPage     = 1000
+)
+
+type DynamicGroupOptions struct {
+	ReconcileInterval time.Duration
+}
+
+type dynamicGroupService struct {
+	groups     internal.GroupData
+	users      internal.UserData
+	attributes internal.AttributeData
+	interval   time.Duration
+}
+
+func NewDynamicGroupService(groups internal.GroupData, users internal.UserData, attributes internal.AttributeData, options DynamicGroupOptions) *dynamicGroupService {
+	if options.ReconcileInterval == 0 {
+		options.ReconcileInterval = defaultReconcileInterval
+	}
+	return &dynamicGroupService{
+		groups:     groups,
+		users:      users,
+		attributes: attributes,
+		interval:   options.ReconcileInterval,
+	}
+}
+
+// userFields are the fields of a user a rule can compare besides its custom
+// attributes, they take precedence over attributes of the same name.
+var userFields = []string{"name", "email", "status", "emailVerified"}
+
+func ruleValues(user internal.UserResponse) map[string]interface{} {
+	values := make(map[string]interface{}, len(user.Attributes)+len(userFields))
+	for name, value := range user.Attributes {
+		values[name] = value
+	}
+	values["name"] = user.Name
+	values["email"] = user.Email
+	values["status"] = user.Status
+	values["emailVerified"] = user.EmailVerified
+	return values
+}
+
+// parseRule parses a rule that only compares user fields and defined
+// attributes.
+func (d *dynamicGroupService) parseRule(expression string) (r *rule.Rule, err error) {
+	if len(expression) > maxRuleLength {
+		return nil, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("rule is longer than %d characters", maxRuleLength))
+	}
+	r, err = rule.Parse(expression)
+	if err != nil {
+		return nil, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("rule is not valid: %v", err))
+	}
+	schema, err := loadAttributeSchema(d.attributes)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, field := range userFields {
+		known[field] = true
+	}
+	for _, field := range r.Fields() {
+		if _, ok := schema[field]; !ok && !known[field] {
+			return nil, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("rule compares unknown field %s", field))
+		}
+	}
+	return r, nil
+}
+
+// CreateGroup creates a dynamic group and adds the users matching its rule.
+// The group is kept if adding them fails, the reconciler adds them later.
+func (d *dynamicGroupService) CreateGroup(request internal.GroupRequest) (response internal.GroupResponse, err error) {
+	r, err := d.parseRule(request.Rule)
+	if err != nil {
+		return response, err
+	}
+	request.Type = internal.GroupDynamic
+	response, err = d.groups.CreateGroup(request)
+	if err != nil {
+		return response, err
+	}
+	if _, err := d.syncGroup(response.ID, response.OrganizationID, r); err != nil {
+		log.WithError(err).WithField("group", response.ID).Error("adding members of dynamic group failed")
+	}
+	return response, nil
+}
+
+// SetRule changes the rule of a dynamic group and its members with it.
+func (d *dynamicGroupService) SetRule(groupID uint, expression string) (response internal.BatchMembershipResponse, err error) {
+	r, err := d.parseRule(expression)
+	if err != nil {
+		return response, err
+	}
+	err = d.groups.SetGroupRule(groupID, expression)
+	if err != nil {
+		return response, err
+	}
+	group, err := d.groups.GetGroup(groupID)
+	if err != nil {
+		return response, err
+	}
+	return d.syncGroup(groupID, group.OrganizationID, r)
+}
+
+// syncGroup makes the members of the group the users of its organization
+// matching the rule.
+func (d *dynamicGroupService) syncGroup(groupID uint, organizationID uint, r *rule.Rule) (response internal.BatchMembershipResponse, err error) {
+	var matched []uint
+	err = d.users.ExportUsers(internal.UsersFilter{}, func(user internal.UserResponse) error {
+		if user.OrganizationID == organizationID && r.Match(ruleValues(user)) {
+			matched = append(matched, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return response, err
+	}
+	return d.groups.SyncMembers(groupID, matched, nil)
+}
+
+// PreviewRule lists the users the rule matches, without changing any group.
+func (d *dynamicGroupService) PreviewRule(expression string, page uint, perPage uint) (response internal.UsersResponse, err error) {
+	if page <= 0 || perPage == 0 || perPage > 1000 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("page %d  or per page %d is not valid", page, perPage))
+	}
+	r, err := d.parseRule(expression)
+	if err != nil {
+		return response, err
+	}
+	offset := perPage * (page - 1)
+	response = internal.UsersResponse{Users: []internal.UserResponse{}, Page: page, PerPage: perPage}
+	err = d.users.ExportUsers(internal.UsersFilter{}, func(user internal.UserResponse) error {
+		if !r.Match(ruleValues(user)) {
+			return nil
+		}
+		if response.Total >= offset && uint(len(response.Users)) < perPage {
+			response.Users = append(response.Users, user)
+		}
+		response.Total++
+		return nil
+	})
+	return response, err
+}
+
+// AuthorizePreview lets admins and owners of groups preview rules, the
+// preview lists users like a group with the rule would.
+func (d *dynamicGroupService) AuthorizePreview(caller internal.Caller) (err error) {
+	if caller.Admin {
+		return nil
+	}
+	memberships, err := d.groups.GetMemberships(caller.UserID)
+	if err != nil {
+		return err
+	}
+	for _, membership := range memberships {
+		if membership.Role == internal.RoleOwner {
+			return nil
+		}
+	}
+	return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d doesn't own a group", caller.UserID))
+}
+
+type dynamicGroup struct {
+	id           uint
+	organization uint
+	rule         *rule.Rule
+}
+
+// dynamicGroups lists the dynamic groups by id. A group whose rule no longer
+// parses is left alone.
+func (d *dynamicGroupService) dynamicGroups() (groups []dynamicGroup, err error) {
+	for offset := uint(0); ; offset += dynamicGroupsPerPage {
+		page, err := d.groups.GetGroups(offset, dynamicGroupsPerPage, internal.GroupsFilter{Type: internal.GroupDynamic})
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range page.Groups {
+			r, err := rule.Parse(group.Rule)
+			if err != nil {
+				log.WithError(err).WithField("group", group.ID).Error("rule of dynamic group is not valid")
+				continue
+			}
+			groups = append(groups, dynamicGroup{id: group.ID, organization: group.OrganizationID, rule: r})
+		}
+		if len(page.Groups) < dynamicGroupsPerPage {
+			return groups, nil
+		}
+	}
+}
+
+// SyncUser removes the user from a dynamic group it no longer matches and
+// adds it to the first dynamic group it matches, unless it is in a group.
+// Only groups of the user's organization are considered.
+func (d *dynamicGroupService) SyncUser(userID uint) (err error) {
+	groups, err := d.dynamicGroups()
+	if err != nil || len(groups) == 0 {
+		return err
+	}
+	user, err := d.users.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	memberships, err := d.groups.GetMemberships(userID)
+	if err != nil {
+		return err
+	}
+	current := map[uint]bool{}
+	now := time.Now()
+	for _, membership := range memberships {
+		if membership.RemovedAt == nil && (membership.ExpiresAt == nil || membership.ExpiresAt.After(now)) {
+			current[membership.GroupID] = true
+		}
+	}
+	values := ruleValues(user)
+	var matching []uint
+	for _, group := range groups {
+		if group.organization != user.OrganizationID {
+			continue
+		}
+		switch {
+		case group.rule.Match(values):
+			matching = append(matching, group.id)
+		case current[group.id]:
+			if _, err := d.groups.SyncMembers(group.id, nil, []uint{userID}); err != nil {
+				return err
+			}
+			delete(current, group.id)
+		}
+	}
+	if len(current) > 0 {
+		return nil
+	}
+	for _, groupID := range matching {
+		response, err := d.groups.SyncMembers(groupID, []uint{userID}, []uint{userID})
+		if err != nil {
+			return err
+		}
+		if response.Added > 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Reconcile makes the members of every dynamic group the users of its
+// organization matching its rule. A user matching several groups stays in the
+// one it is in, or joins the first. Groups a user couldn't join because it was
+// still in another group are synced again once the others have been.
+func (d *dynamicGroupService) Reconcile() (err error) {
+	groups, err := d.dynamicGroups()
+	if err != nil || len(groups) == 0 {
+		return err
+	}
+	matched := make([][]uint, len(groups))
+	err = d.users.ExportUsers(internal.UsersFilter{}, func(user internal.UserResponse) error {
+		values := ruleValues(user)
+		for i, group := range groups {
+			if group.organization == user.OrganizationID && group.rule.Match(values) {
+				matched[i] = append(matched[i], user.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	var retry []int
+	removed := 0
+	for i, group := range groups {
+		response, err := d.groups.SyncMembers(group.id, matched[i], nil)
+		if err != nil {
+			return err
+		}
+		if response.Added > 0 || response.Removed > 0 {
+			log.WithFields(log.Fields{"group": group.id, "added": response.Added, "removed": response.Removed}).Info("dynamic group reconciled")
+		}
+		if response.Failed > 0 {
+			retry = append(retry, i)
+		}
+		removed += response.Removed
+	}
+	if removed == 0 {
+		return nil
+	}
+	for _, i := range retry {
+		if _, err := d.groups.SyncMembers(groups[i].id, matched[i], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *dynamicGroupService) Init() (err error) {
+	return nil
+}
+
+func (d *dynamicGroupService) Run(ctx context.Context) (err error) {
+	RunPeriodically(ctx, "reconcile dynamic groups", d.interval, d.Reconcile)
+	return nil
+}
+
+// ForOrganization returns the service for the dynamic groups and users of the
+// organization.
+func (d *dynamicGroupService) ForOrganization(organizationID uint) internal.DynamicGroupService {
+	scoped := *d
+	scoped.groups = d.groups.ForOrganization(organizationID)
+	scoped.users = d.users.ForOrganization(organizationID)
+	return &scoped
+}
@@ -0,0 +1,191 @@
+package service_test
+
+import (
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var ruleUsers = []internal.UserResponse{
+	{ID: 1, Email: "jane@eng.example.com", Status: internal.StatusActive, Attributes: map[string]interface{}{"department": "Sales"}},
+	{ID: 2, Email: "joe@example.com", Status: internal.StatusActive, Attributes: map[string]interface{}{"department": "Sales"}},
+	{ID: 3, Email: "ann@eng.example.com", Status: internal.StatusActive},
+}
+
+func exportUsers(users *mock.MockUserData) {
+	users.EXPECT().ExportUsers(internal.UsersFilter{}, gomock.Any()).DoAndReturn(
+		func(filter internal.UsersFilter, export func(user internal.UserResponse) error) error {
+			for _, user := range ruleUsers {
+				if err := export(user); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Times(1)
+}
+
+func TestCreateDynamicGroup(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	groups := mock.NewMockGroupData(mockCtrl)
+	users := mock.NewMockUserData(mockCtrl)
+	attributes := mock.NewMockAttributeData(mockCtrl)
+	handler := service.NewDynamicGroupService(groups, users, attributes, service.DynamicGroupOptions{})
+	definitions := []internal.AttributeDefinition{{Name: "department", Type: internal.AttributeString}}
+
+	t.Run("create group with matching users", func(t *testing.T) {
+		request := internal.GroupRequest{Name: "sales", Type: internal.GroupDynamic, Rule: `department = "Sales"`}
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		groups.EXPECT().CreateGroup(request).Return(internal.GroupResponse{ID: 7, Name: "sales", Type: internal.GroupDynamic}, nil).Times(1)
+		exportUsers(users)
+		groups.EXPECT().SyncMembers(uint(7), []uint{1, 2}, nil).Return(internal.BatchMembershipResponse{Added: 2}, nil).Times(1)
+		response, err := handler.CreateGroup(request)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(7), response.ID)
+	})
+
+	t.Run("fail on invalid rule", func(t *testing.T) {
+		_, err := handler.CreateGroup(internal.GroupRequest{Name: "sales", Type: internal.GroupDynamic, Rule: `department =`})
+		assert.True(t, hasCode(err, serviceerror.InvalidGroupRequest))
+	})
+
+	t.Run("fail on unknown field", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		_, err := handler.CreateGroup(internal.GroupRequest{Name: "sales", Type: internal.GroupDynamic, Rule: `region = "EU"`})
+		assert.True(t, hasCode(err, serviceerror.InvalidGroupRequest))
+	})
+}
+
+func TestSetRule(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	groups := mock.NewMockGroupData(mockCtrl)
+	users := mock.NewMockUserData(mockCtrl)
+	attributes := mock.NewMockAttributeData(mockCtrl)
+	handler := service.NewDynamicGroupService(groups, users, attributes, service.DynamicGroupOptions{})
+
+	attributes.EXPECT().GetAttributeDefinitions().Return(nil, nil).Times(1)
+	groups.EXPECT().SetGroupRule(uint(7), `email endsWith "@eng.example.com"`).Return(nil).Times(1)
+	groups.EXPECT().GetGroup(uint(7)).Return(internal.GroupResponse{ID: 7, Type: internal.GroupDynamic}, nil).Times(1)
+	exportUsers(users)
+	groups.EXPECT().SyncMembers(uint(7), []uint{1, 3}, nil).Return(internal.BatchMembershipResponse{Added: 1, Removed: 1}, nil).Times(1)
+	response, err := handler.SetRule(7, `email endsWith "@eng.example.com"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.Removed)
+}
+
+func TestPreviewRule(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserData(mockCtrl)
+	attributes := mock.NewMockAttributeData(mockCtrl)
+	handler := service.NewDynamicGroupService(mock.NewMockGroupData(mockCtrl), users, attributes, service.DynamicGroupOptions{})
+
+	t.Run("list page of matching users", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(nil, nil).Times(1)
+		exportUsers(users)
+		response, err := handler.PreviewRule(`status = "active"`, 2, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), response.Total)
+		if assert.Len(t, response.Users, 1) {
+			assert.Equal(t, uint(3), response.Users[0].ID)
+		}
+	})
+
+	t.Run("fail on invalid page", func(t *testing.T) {
+		_, err := handler.PreviewRule(`status = "active"`, 0, 10)
+		assert.True(t, hasCode(err, serviceerror.InvalidGroupRequest))
+	})
+}
+
+func TestAuthorizePreview(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	groups := mock.NewMockGroupData(mockCtrl)
+	handler := service.NewDynamicGroupService(groups, mock.NewMockUserData(mockCtrl), mock.NewMockAttributeData(mockCtrl), service.DynamicGroupOptions{})
+
+	assert.NoError(t, handler.AuthorizePreview(internal.Caller{UserID: 1, Admin: true}))
+	groups.EXPECT().GetMemberships(uint(2)).Return([]internal.Membership{{GroupID: 1, Role: internal.RoleMember}, {GroupID: 2, Role: internal.RoleOwner}}, nil).Times(1)
+	assert.NoError(t, handler.AuthorizePreview(internal.Caller{UserID: 2}))
+	groups.EXPECT().GetMemberships(uint(3)).Return([]internal.Membership{{GroupID: 1, Role: internal.RoleManager}}, nil).Times(1)
+	assert.True(t, hasCode(handler.AuthorizePreview(internal.Caller{UserID: 3}), serviceerror.Forbidden))
+}
+
+func TestSyncUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	groups := mock.NewMockGroupData(mockCtrl)
+	users := mock.NewMockUserData(mockCtrl)
+	handler := service.NewDynamicGroupService(groups, users, mock.NewMockAttributeData(mockCtrl), service.DynamicGroupOptions{})
+	dynamic := internal.GroupsResponse{Groups: []internal.GroupResponse{
+		{ID: 7, Type: internal.GroupDynamic, Rule: `department = "Sales"`},
+		{ID: 8, Type: internal.GroupDynamic, Rule: `email endsWith "@eng.example.com"`},
+	}}
+	listGroups := func() {
+		groups.EXPECT().GetGroups(uint(0), uint(1000), internal.GroupsFilter{Type: internal.GroupDynamic}).Return(dynamic, nil).Times(1)
+	}
+
+	t.Run("move user to group it matches now", func(t *testing.T) {
+		listGroups()
+		users.EXPECT().GetUser(uint(3)).Return(ruleUsers[2], nil).Times(1)
+		groups.EXPECT().GetMemberships(uint(3)).Return([]internal.Membership{{GroupID: 7}}, nil).Times(1)
+		groups.EXPECT().SyncMembers(uint(7), nil, []uint{3}).Return(internal.BatchMembershipResponse{Removed: 1}, nil).Times(1)
+		groups.EXPECT().SyncMembers(uint(8), []uint{3}, []uint{3}).Return(internal.BatchMembershipResponse{Added: 1}, nil).Times(1)
+		assert.NoError(t, handler.SyncUser(3))
+	})
+
+	t.Run("add user to first group it matches", func(t *testing.T) {
+		listGroups()
+		users.EXPECT().GetUser(uint(1)).Return(ruleUsers[0], nil).Times(1)
+		groups.EXPECT().GetMemberships(uint(1)).Return(nil, nil).Times(1)
+		groups.EXPECT().SyncMembers(uint(7), []uint{1}, []uint{1}).Return(internal.BatchMembershipResponse{Added: 1}, nil).Times(1)
+		assert.NoError(t, handler.SyncUser(1))
+	})
+
+	t.Run("keep user in its static group", func(t *testing.T) {
+		listGroups()
+		users.EXPECT().GetUser(uint(1)).Return(ruleUsers[0], nil).Times(1)
+		groups.EXPECT().GetMemberships(uint(1)).Return([]internal.Membership{{GroupID: 2}}, nil).Times(1)
+		assert.NoError(t, handler.SyncUser(1))
+	})
+
+	t.Run("skip group of other organization", func(t *testing.T) {
+		groups.EXPECT().GetGroups(uint(0), uint(1000), internal.GroupsFilter{Type: internal.GroupDynamic}).Return(internal.GroupsResponse{Groups: []internal.GroupResponse{
+			{ID: 9, OrganizationID: 2, Type: internal.GroupDynamic, Rule: `email endsWith "@eng.example.com"`},
+		}}, nil).Times(1)
+		users.EXPECT().GetUser(uint(3)).Return(ruleUsers[2], nil).Times(1)
+		groups.EXPECT().GetMemberships(uint(3)).Return(nil, nil).Times(1)
+		assert.NoError(t, handler.SyncUser(3))
+	})
+
+	t.Run("skip without dynamic groups", func(t *testing.T) {
+		groups.EXPECT().GetGroups(uint(0), uint(1000), internal.GroupsFilter{Type: internal.GroupDynamic}).Return(internal.GroupsResponse{}, nil).Times(1)
+		assert.NoError(t, handler.SyncUser(1))
+	})
+}
+
+func TestReconcile(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	groups := mock.NewMockGroupData(mockCtrl)
+	users := mock.NewMockUserData(mockCtrl)
+	handler := service.NewDynamicGroupService(groups, users, mock.NewMockAttributeData(mockCtrl), service.DynamicGroupOptions{})
+	groups.EXPECT().GetGroups(uint(0), uint(1000), internal.GroupsFilter{Type: internal.GroupDynamic}).Return(internal.GroupsResponse{Groups: []internal.GroupResponse{
+		{ID: 7, Type: internal.GroupDynamic, Rule: `email endsWith "@eng.example.com"`},
+		{ID: 8, Type: internal.GroupDynamic, Rule: `department = "Sales"`},
+		{ID: 9, Type: internal.GroupDynamic, Rule: `broken =`},
+	}}, nil).Times(1)
+	exportUsers(users)
+
+	gomock.InOrder(
+		groups.EXPECT().SyncMembers(uint(7), []uint{1, 3}, nil).Return(internal.BatchMembershipResponse{Added: 1, Failed: 1}, nil).Times(1),
+		groups.EXPECT().SyncMembers(uint(8), []uint{1, 2}, nil).Return(internal.BatchMembershipResponse{Removed: 1}, nil).Times(1),
+		groups.EXPECT().SyncMembers(uint(7), []uint{1, 3}, nil).Return(internal.BatchMembershipResponse{Added: 1}, nil).Times(1),
+	)
+	assert.NoError(t, handler.Reconcile())
+}
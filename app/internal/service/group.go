@@ -2,30 +2,46 @@ package service
 
 import (
 	"fmt"
+	"time"
 	"usermanagement/app/internal"
 	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
 )
 
 type groupService struct {
-	data internal.GroupData
+	data    internal.GroupData
+	dynamic internal.DynamicGroupService
 }
 
-func NewGroupService(data internal.GroupData) *groupService {
+func NewGroupService(data internal.GroupData, dynamic internal.DynamicGroupService) *groupService {
 	return &groupService{
-		data: data,
+		data:    data,
+		dynamic: dynamic,
 	}
 }
 
+// CreateGroup creates a static group, or a dynamic one with the users matching
+// its rule as members.
 func (g *groupService) CreateGroup(request internal.GroupRequest) (response internal.GroupResponse, err error) {
-	return g.data.CreateGroup(request)
+	switch request.Type {
+	case "", internal.GroupStatic:
+		if request.Rule != "" {
+			return response, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, errors.New("only dynamic groups have a rule"))
+		}
+		return g.data.CreateGroup(request)
+	case internal.GroupDynamic:
+		return g.dynamic.CreateGroup(request)
+	}
+	return response, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("group type %s is unknown", request.Type))
 }
 
 func (g *groupService) UpdateGroup(request internal.UpdateGroupRequest) (err error) {
 	return g.data.UpdateGroup(request)
 }
 
-func (g *groupService) DeleteGroup(id uint) (err error) {
-	return g.data.DeleteGroup(id)
+func (g *groupService) DeleteGroup(id uint, version uint) (err error) {
+	return g.data.DeleteGroup(id, version)
 }
 
 func (g *groupService) GetUsersByGroupID(groupID uint, page uint, perPage uint) (response internal.UsersResponse, err error) {
@@ -39,21 +55,141 @@ func (g *groupService) GetUsersByGroupID(groupID uint, page uint, perPage uint)
 	return response, err
 }
 
-func (g *groupService) GetGroups(page uint, perPage uint) (response internal.GroupsResponse, err error) {
+func (g *groupService) GetGroups(page uint, perPage uint, filter internal.GroupsFilter) (response internal.GroupsResponse, err error) {
 	if page <= 0 || perPage == 0 {
 		return response, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("page %d  or per page %d is not valid", page, perPage))
 	}
 	offset := perPage * (page - 1)
-	response, err = g.data.GetGroups(offset, perPage)
+	response, err = g.data.GetGroups(offset, perPage, filter)
 	response.Page = page
 	response.PerPage = perPage
 	return response, err
 }
 
-func (g *groupService) AddUser(userID uint, groupID uint) (err error) {
-	return g.data.AddUser(userID, groupID)
+func (g *groupService) GetGroup(id uint) (response internal.GroupResponse, err error) {
+	return g.data.GetGroup(id)
+}
+
+func (g *groupService) RestoreGroup(id uint) (err error) {
+	return g.data.RestoreGroup(id)
+}
+
+// AddUser adds the user to the group, for a limited time if the request has
+// an expiry.
+func (g *groupService) AddUser(request internal.AddUserRequest) (err error) {
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, errors.New("membership expiry is in the past"))
+	}
+	if request.StartsAt != nil && request.ExpiresAt != nil && !request.ExpiresAt.After(*request.StartsAt) {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, errors.New("membership expires before it starts"))
+	}
+	return g.data.AddUser(request)
 }
 
 func (g *groupService) RemoveUser(groupID uint, userID uint) (err error) {
 	return g.data.RemoveUser(groupID, userID)
 }
+
+// maxBatchMembers bounds the users of one batch membership change.
+const maxBatchMembers = 10000
+
+func checkBatchMembers(userIDs []uint, allowEmpty bool) (err error) {
+	if len(userIDs) == 0 && !allowEmpty {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("no user ids given"))
+	}
+	if len(userIDs) > maxBatchMembers {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest,
+			fmt.Errorf("%d user ids given, at most %d are allowed", len(userIDs), maxBatchMembers))
+	}
+	return nil
+}
+
+func (g *groupService) AddUsers(groupID uint, userIDs []uint) (response internal.BatchMembershipResponse, err error) {
+	if err := checkBatchMembers(userIDs, false); err != nil {
+		return response, err
+	}
+	return g.data.AddUsers(groupID, userIDs)
+}
+
+func (g *groupService) RemoveUsers(groupID uint, userIDs []uint) (response internal.BatchMembershipResponse, err error) {
+	if err := checkBatchMembers(userIDs, false); err != nil {
+		return response, err
+	}
+	return g.data.RemoveUsers(groupID, userIDs)
+}
+
+// ReplaceUsers takes an empty list to remove all members.
+func (g *groupService) ReplaceUsers(groupID uint, userIDs []uint) (response internal.BatchMembershipResponse, err error) {
+	if err := checkBatchMembers(userIDs, true); err != nil {
+		return response, err
+	}
+	return g.data.ReplaceUsers(groupID, userIDs)
+}
+
+var roles = map[string]bool{internal.RoleMember: true, internal.RoleManager: true, internal.RoleOwner: true}
+
+// SetMemberRole promotes or demotes a member of the group.
+func (g *groupService) SetMemberRole(groupID uint, userID uint, role string) (err error) {
+	if !roles[role] {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest, fmt.Errorf("role %s is unknown", role))
+	}
+	return g.data.SetMemberRole(groupID, userID, role)
+}
+
+// AuthorizeMemberChange lets admins and owners add or remove anyone, and
+// managers only users that aren't managers or owners of the group.
+func (g *groupService) AuthorizeMemberChange(caller internal.Caller, groupID uint, userID uint) (err error) {
+	if caller.Admin {
+		return nil
+	}
+	role, err := g.data.GetMemberRole(groupID, caller.UserID)
+	if err != nil {
+		return err
+	}
+	switch role {
+	case internal.RoleOwner:
+		return nil
+	case internal.RoleManager:
+		target, err := g.data.GetMemberRole(groupID, userID)
+		if err != nil {
+			return err
+		}
+		if target == internal.RoleManager || target == internal.RoleOwner {
+			return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("managers of group %d can't change its %s %d", groupID, target, userID))
+		}
+		return nil
+	}
+	return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d doesn't manage group %d", caller.UserID, groupID))
+}
+
+// AuthorizeGroupOwner lets admins and owners of the group through.
+func (g *groupService) AuthorizeGroupOwner(caller internal.Caller, groupID uint) (err error) {
+	if caller.Admin {
+		return nil
+	}
+	role, err := g.data.GetMemberRole(groupID, caller.UserID)
+	if err != nil {
+		return err
+	}
+	if role != internal.RoleOwner {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d doesn't own group %d", caller.UserID, groupID))
+	}
+	return nil
+}
+
+// AuthorizeCreate lets users create groups they own, only admins can make
+// another user the owner.
+func (g *groupService) AuthorizeCreate(caller internal.Caller, ownerID uint) (err error) {
+	if caller.Admin || ownerID == 0 || ownerID == caller.UserID {
+		return nil
+	}
+	return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d can't create groups owned by user %d", caller.UserID, ownerID))
+}
+
+// ForOrganization returns the service for the groups of the organization.
+func (g *groupService) ForOrganization(organizationID uint) internal.GroupService {
+	return &groupService{
+		data:    g.data.ForOrganization(organizationID),
+		dynamic: g.dynamic.ForOrganization(organizationID),
+	}
+}
@@ -3,6 +3,7 @@ package service_test
 import (
 	"fmt"
 	"testing"
+	"time"
 	"usermanagement/app/internal"
 	"usermanagement/app/internal/mock"
 	"usermanagement/app/internal/service"
@@ -16,13 +17,13 @@ func TestGetGroups(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	data := mock.NewMockGroupData(mockCtrl)
-	handler := service.NewGroupService(data)
+	handler := service.NewGroupService(data, mock.NewMockDynamicGroupService(mockCtrl))
 
 	t.Run("get groups successfully", func(t *testing.T) {
-		data.EXPECT().GetGroups(uint(100), uint(100)).Return(internal.GroupsResponse{
+		data.EXPECT().GetGroups(uint(100), uint(100), internal.GroupsFilter{Deleted: true}).Return(internal.GroupsResponse{
 			Total: 0,
 		}, nil).Times(1)
-		response, err := handler.GetGroups(2, 100)
+		response, err := handler.GetGroups(2, 100, internal.GroupsFilter{Deleted: true})
 		assert.NoError(t, err)
 		assert.Equal(t, internal.GroupsResponse{
 			Total:   0,
@@ -32,13 +33,13 @@ func TestGetGroups(t *testing.T) {
 	})
 
 	t.Run("error on missing page", func(t *testing.T) {
-		response, err := handler.GetGroups(0, 100)
+		response, err := handler.GetGroups(0, 100, internal.GroupsFilter{})
 		assert.Equal(t, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("page %d  or per page %d is not valid", 0, 100)), err)
 		assert.Equal(t, internal.GroupsResponse{}, response)
 	})
 
 	t.Run("error on missing perPage", func(t *testing.T) {
-		response, err := handler.GetGroups(1, 0)
+		response, err := handler.GetGroups(1, 0, internal.GroupsFilter{})
 		assert.Equal(t, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("page %d  or per page %d is not valid", 1, 0)), err)
 		assert.Equal(t, internal.GroupsResponse{}, response)
 	})
@@ -48,7 +49,7 @@ func TestGetGroupUsers(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	data := mock.NewMockGroupData(mockCtrl)
-	handler := service.NewGroupService(data)
+	handler := service.NewGroupService(data, mock.NewMockDynamicGroupService(mockCtrl))
 
 	t.Run("get group users successfully", func(t *testing.T) {
 		data.EXPECT().GetUsersByGroupID(uint(1), uint(0), uint(100)).Return(internal.UsersResponse{
@@ -75,3 +76,187 @@ func TestGetGroupUsers(t *testing.T) {
 		assert.Equal(t, internal.UsersResponse{}, response)
 	})
 }
+
+func TestBatchUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockGroupData(mockCtrl)
+	handler := service.NewGroupService(data, mock.NewMockDynamicGroupService(mockCtrl))
+
+	t.Run("add users", func(t *testing.T) {
+		data.EXPECT().AddUsers(uint(1), []uint{2, 3}).Return(internal.BatchMembershipResponse{Added: 2}, nil).Times(1)
+		response, err := handler.AddUsers(1, []uint{2, 3})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, response.Added)
+	})
+
+	t.Run("replace with no users", func(t *testing.T) {
+		data.EXPECT().ReplaceUsers(uint(1), []uint{}).Return(internal.BatchMembershipResponse{Removed: 2}, nil).Times(1)
+		_, err := handler.ReplaceUsers(1, []uint{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail on empty batch", func(t *testing.T) {
+		_, err := handler.RemoveUsers(1, nil)
+		assert.True(t, hasCode(err, serviceerror.InvalidUserGroupRequest))
+	})
+
+	t.Run("fail on too many users", func(t *testing.T) {
+		_, err := handler.AddUsers(1, make([]uint, 10001))
+		assert.True(t, hasCode(err, serviceerror.InvalidUserGroupRequest))
+	})
+}
+
+func TestAddUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockGroupData(mockCtrl)
+	handler := service.NewGroupService(data, mock.NewMockDynamicGroupService(mockCtrl))
+	past := time.Now().Add(-time.Hour)
+	tomorrow := time.Now().Add(24 * time.Hour)
+	nextWeek := time.Now().Add(7 * 24 * time.Hour)
+
+	t.Run("add user until expiry", func(t *testing.T) {
+		request := internal.AddUserRequest{UserID: 2, GroupID: 1, StartsAt: &tomorrow, ExpiresAt: &nextWeek}
+		data.EXPECT().AddUser(request).Return(nil).Times(1)
+		assert.NoError(t, handler.AddUser(request))
+	})
+
+	t.Run("fail on past expiry", func(t *testing.T) {
+		err := handler.AddUser(internal.AddUserRequest{UserID: 2, GroupID: 1, ExpiresAt: &past})
+		assert.True(t, hasCode(err, serviceerror.InvalidUserGroupRequest))
+	})
+
+	t.Run("fail on expiry before start", func(t *testing.T) {
+		err := handler.AddUser(internal.AddUserRequest{UserID: 2, GroupID: 1, StartsAt: &nextWeek, ExpiresAt: &tomorrow})
+		assert.True(t, hasCode(err, serviceerror.InvalidUserGroupRequest))
+	})
+}
+
+func TestSetMemberRole(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockGroupData(mockCtrl)
+	handler := service.NewGroupService(data, mock.NewMockDynamicGroupService(mockCtrl))
+
+	t.Run("promote member", func(t *testing.T) {
+		data.EXPECT().SetMemberRole(uint(1), uint(2), internal.RoleManager).Return(nil).Times(1)
+		assert.NoError(t, handler.SetMemberRole(1, 2, internal.RoleManager))
+	})
+
+	t.Run("fail on unknown role", func(t *testing.T) {
+		err := handler.SetMemberRole(1, 2, "admin")
+		assert.True(t, hasCode(err, serviceerror.InvalidUserGroupRequest))
+	})
+}
+
+func TestAuthorizeMemberChange(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockGroupData(mockCtrl)
+	handler := service.NewGroupService(data, mock.NewMockDynamicGroupService(mockCtrl))
+	caller := internal.Caller{UserID: 5}
+
+	t.Run("allow admin", func(t *testing.T) {
+		assert.NoError(t, handler.AuthorizeMemberChange(internal.Caller{UserID: 5, Admin: true}, 1, 2))
+	})
+
+	t.Run("allow owner", func(t *testing.T) {
+		data.EXPECT().GetMemberRole(uint(1), uint(5)).Return(internal.RoleOwner, nil).Times(1)
+		assert.NoError(t, handler.AuthorizeMemberChange(caller, 1, 2))
+	})
+
+	t.Run("allow manager on member", func(t *testing.T) {
+		data.EXPECT().GetMemberRole(uint(1), uint(5)).Return(internal.RoleManager, nil).Times(1)
+		data.EXPECT().GetMemberRole(uint(1), uint(2)).Return(internal.RoleMember, nil).Times(1)
+		assert.NoError(t, handler.AuthorizeMemberChange(caller, 1, 2))
+	})
+
+	t.Run("forbid manager on owner", func(t *testing.T) {
+		data.EXPECT().GetMemberRole(uint(1), uint(5)).Return(internal.RoleManager, nil).Times(1)
+		data.EXPECT().GetMemberRole(uint(1), uint(2)).Return(internal.RoleOwner, nil).Times(1)
+		err := handler.AuthorizeMemberChange(caller, 1, 2)
+		assert.True(t, hasCode(err, serviceerror.Forbidden))
+	})
+
+	t.Run("forbid member", func(t *testing.T) {
+		data.EXPECT().GetMemberRole(uint(1), uint(5)).Return(internal.RoleMember, nil).Times(1)
+		err := handler.AuthorizeMemberChange(caller, 1, 2)
+		assert.True(t, hasCode(err, serviceerror.Forbidden))
+	})
+
+	t.Run("forbid outsider", func(t *testing.T) {
+		data.EXPECT().GetMemberRole(uint(1), uint(5)).Return("", nil).Times(1)
+		err := handler.AuthorizeMemberChange(caller, 1, 2)
+		assert.True(t, hasCode(err, serviceerror.Forbidden))
+	})
+}
+
+func TestAuthorizeGroupOwner(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockGroupData(mockCtrl)
+	handler := service.NewGroupService(data, mock.NewMockDynamicGroupService(mockCtrl))
+
+	t.Run("allow owner", func(t *testing.T) {
+		data.EXPECT().GetMemberRole(uint(1), uint(5)).Return(internal.RoleOwner, nil).Times(1)
+		assert.NoError(t, handler.AuthorizeGroupOwner(internal.Caller{UserID: 5}, 1))
+	})
+
+	t.Run("forbid manager", func(t *testing.T) {
+		data.EXPECT().GetMemberRole(uint(1), uint(5)).Return(internal.RoleManager, nil).Times(1)
+		err := handler.AuthorizeGroupOwner(internal.Caller{UserID: 5}, 1)
+		assert.True(t, hasCode(err, serviceerror.Forbidden))
+	})
+}
+
+func TestAuthorizeCreate(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler := service.NewGroupService(mock.NewMockGroupData(mockCtrl), mock.NewMockDynamicGroupService(mockCtrl))
+
+	t.Run("allow caller as owner", func(t *testing.T) {
+		assert.NoError(t, handler.AuthorizeCreate(internal.Caller{UserID: 5}, 0))
+		assert.NoError(t, handler.AuthorizeCreate(internal.Caller{UserID: 5}, 5))
+	})
+
+	t.Run("allow admin to name owner", func(t *testing.T) {
+		assert.NoError(t, handler.AuthorizeCreate(internal.Caller{UserID: 1, Admin: true}, 5))
+	})
+
+	t.Run("forbid other owner", func(t *testing.T) {
+		err := handler.AuthorizeCreate(internal.Caller{UserID: 5}, 7)
+		assert.True(t, hasCode(err, serviceerror.Forbidden))
+	})
+}
+
+func TestCreateGroup(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockGroupData(mockCtrl)
+	dynamic := mock.NewMockDynamicGroupService(mockCtrl)
+	handler := service.NewGroupService(data, dynamic)
+
+	t.Run("create static group", func(t *testing.T) {
+		data.EXPECT().CreateGroup(internal.GroupRequest{Name: "test"}).Return(internal.GroupResponse{ID: 1}, nil).Times(1)
+		_, err := handler.CreateGroup(internal.GroupRequest{Name: "test"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("create dynamic group", func(t *testing.T) {
+		request := internal.GroupRequest{Name: "test", Type: internal.GroupDynamic, Rule: `status = "active"`}
+		dynamic.EXPECT().CreateGroup(request).Return(internal.GroupResponse{ID: 1}, nil).Times(1)
+		_, err := handler.CreateGroup(request)
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail on rule of static group", func(t *testing.T) {
+		_, err := handler.CreateGroup(internal.GroupRequest{Name: "test", Rule: `status = "active"`})
+		assert.True(t, hasCode(err, serviceerror.InvalidGroupRequest))
+	})
+
+	t.Run("fail on unknown type", func(t *testing.T) {
+		_, err := handler.CreateGroup(internal.GroupRequest{Name: "test", Type: "smart"})
+		assert.True(t, hasCode(err, serviceerror.InvalidGroupRequest))
+	})
+}
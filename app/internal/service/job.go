@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultJobWorkers      = 2
+	defaultJobPollInterval = time.Second
+	defaultJobLease        = 30 * time.Second
+	defaultJobMaxAttempts  = 3
+	defaultJobRetryBackoff = 10 * time.Second
+)
+
+type JobOptions struct {
+	Workers      int
+	PollInterval time.Duration
+	Lease        time.Duration
+	MaxAttempts  int
+	RetryBackoff time.Duration
+}
+
+type jobService struct {
+	data     internal.JobData
+	options  JobOptions
+	worker   string
+	mu       sync.RWMutex
+	handlers map[string]internal.JobHandler
+}
+
+func NewJobService(data internal.JobData, options JobOptions) *jobService {
+	if options.Workers == 0 {
+		options.Workers = defaultJobWorkers
+	}
+	if options.PollInterval == 0 {
+		options.PollInterval = defaultJobPollInterval
+	}
+	if options.Lease == 0 {
+		options.Lease = defaultJobLease
+	}
+	if options.MaxAttempts == 0 {
+		options.MaxAttempts = defaultJobMaxAttempts
+	}
+	if options.RetryBackoff == 0 {
+		options.RetryBackoff = defaultJobRetryBackoff
+	}
+	hostname, _ := os.Hostname()
+	suffix, _ := randomToken()
+	return &jobService{
+		data:     data,
+		options:  options,
+		worker:   fmt.Sprintf("%s-%d-%.8s", hostname, os.Getpid(), suffix),
+		handlers: map[string]internal.JobHandler{},
+	}
+}
+
+func (j *jobService) Register(jobType string, handler internal.JobHandler) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.handlers[jobType] = handler
+}
+
+func (j *jobService) handler(jobType string) (handler internal.JobHandler, ok bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	handler, ok = j.handlers[jobType]
+	return handler, ok
+}
+
+// Enqueue queues a job for the user createdBy, none for jobs of the server.
+func (j *jobService) Enqueue(jobType string, createdBy uint, payload interface{}) (response internal.Job, err error) {
+	if _, ok := j.handler(jobType); !ok {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidJobRequest, fmt.Errorf("job type %s is unknown", jobType))
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return response, errors.Wrap(err, "encode job payload failed")
+	}
+	return j.data.CreateJob(internal.JobRequest{Type: jobType, Payload: encoded, MaxAttempts: j.options.MaxAttempts, CreatedBy: createdBy})
+}
+
+func (j *jobService) GetJob(id uint) (response internal.Job, err error) {
+	return j.data.GetJob(id)
+}
+
+func (j *jobService) CancelJob(id uint) (response internal.Job, err error) {
+	return j.data.CancelJob(id)
+}
+
+// AuthorizeJob lets users follow and cancel the jobs they created, admins
+// all jobs. Jobs of the server have no creator.
+func (j *jobService) AuthorizeJob(caller internal.Caller, id uint) (err error) {
+	if caller.Admin {
+		return nil
+	}
+	job, err := j.data.GetJob(id)
+	if err != nil {
+		return err
+	}
+	if job.CreatedBy == 0 || job.CreatedBy != caller.UserID {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d didn't create job %d", caller.UserID, id))
+	}
+	return nil
+}
+
+func (j *jobService) Init() (err error) {
+	return nil
+}
+
+// Run works off the queue with the configured number of workers until ctx is
+// done. Jobs running then are queued again for the next start.
+func (j *jobService) Run(ctx context.Context) (err error) {
+	var wg sync.WaitGroup
+	for i := 0; i < j.options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			j.work(ctx)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (j *jobService) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		ran, err := j.RunNext(ctx)
+		if err != nil {
+			log.WithError(err).Error("running job failed")
+		}
+		if ran && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(j.options.PollInterval):
+		}
+	}
+}
+
+// RunNext claims a job and runs it, ran is false when no job was due.
+func (j *jobService) RunNext(ctx context.Context) (ran bool, err error) {
+	job, claimed, err := j.data.ClaimJob(j.worker, time.Now().Add(j.options.Lease))
+	if err != nil || !claimed {
+		return false, err
+	}
+	logger := log.WithFields(log.Fields{"job": job.ID, "type": job.Type, "attempt": job.Attempts})
+	handler, ok := j.handler(job.Type)
+	switch {
+	case !ok:
+		return true, j.data.FinishJob(job.ID, j.worker, internal.JobUpdate{
+			Status: internal.JobFailed, Progress: job.Progress, Error: fmt.Sprintf("job type %s is unknown", job.Type),
+		})
+	case job.Attempts > job.MaxAttempts:
+		return true, j.data.FinishJob(job.ID, j.worker, internal.JobUpdate{
+			Status: internal.JobFailed, Progress: job.Progress, Error: fmt.Sprintf("job was abandoned after %d attempts", job.MaxAttempts),
+		})
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	progress := job.Progress
+	report := func(p internal.JobProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress = p
+	}
+	current := func() internal.JobProgress {
+		mu.Lock()
+		defer mu.Unlock()
+		return progress
+	}
+	heartbeat := make(chan struct{})
+	go func() {
+		defer close(heartbeat)
+		ticker := time.NewTicker(j.options.Lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				stop, err := j.data.RenewJob(job.ID, j.worker, current(), time.Now().Add(j.options.Lease))
+				if err != nil {
+					logger.WithError(err).Error("renewing job failed")
+					continue
+				}
+				if stop {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	logger.Info("running job")
+	result, err := runJobHandler(jobCtx, handler, job.Payload, report)
+	cancel()
+	<-heartbeat
+
+	update := internal.JobUpdate{Progress: current()}
+	if result != nil {
+		update.Result, _ = json.Marshal(result)
+	}
+	var srvError *serviceerror.ServiceError
+	switch {
+	case err == nil:
+		update.Status = internal.JobSucceeded
+	case ctx.Err() != nil:
+		update.Status = internal.JobQueued
+		update.RunAt = time.Now()
+	case errors.Is(err, context.Canceled):
+		update.Status = internal.JobCancelled
+		update.Error = "job was cancelled"
+	case !errors.As(err, &srvError) && job.Attempts < job.MaxAttempts:
+		update.Status = internal.JobQueued
+		update.Error = err.Error()
+		update.RunAt = time.Now().Add(j.options.RetryBackoff << (job.Attempts - 1))
+	default:
+		update.Status = internal.JobFailed
+		update.Error = err.Error()
+	}
+	logger.WithField("status", update.Status).WithError(err).Info("job attempt ended")
+	return true, j.data.FinishJob(job.ID, j.worker, update)
+}
+
+// runJobHandler turns a panic of the handler into an error of the job.
+func runJobHandler(ctx context.Context, handler internal.JobHandler, payload []byte, progress func(internal.JobProgress)) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, payload, progress)
+}
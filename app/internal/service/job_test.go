@@ -0,0 +1,224 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnqueueJob(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockJobData(mockCtrl)
+	handler := service.NewJobService(data, service.JobOptions{MaxAttempts: 2})
+	handler.Register("test", func(ctx context.Context, payload []byte, progress func(internal.JobProgress)) (interface{}, error) {
+		return nil, nil
+	})
+
+	t.Run("queue a registered job type", func(t *testing.T) {
+		data.EXPECT().CreateJob(internal.JobRequest{Type: "test", Payload: []byte(`{"id":1}`), MaxAttempts: 2, CreatedBy: 5}).
+			Return(internal.Job{ID: 1, Status: internal.JobQueued}, nil).Times(1)
+		job, err := handler.Enqueue("test", 5, map[string]int{"id": 1})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), job.ID)
+	})
+
+	t.Run("fail on unknown job type", func(t *testing.T) {
+		_, err := handler.Enqueue("unknown", 0, nil)
+		assert.True(t, hasCode(err, serviceerror.InvalidJobRequest))
+	})
+}
+
+func TestAuthorizeJob(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockJobData(mockCtrl)
+	handler := service.NewJobService(data, service.JobOptions{})
+
+	t.Run("allow creator", func(t *testing.T) {
+		data.EXPECT().GetJob(uint(1)).Return(internal.Job{ID: 1, CreatedBy: 5}, nil).Times(1)
+		assert.NoError(t, handler.AuthorizeJob(internal.Caller{UserID: 5}, 1))
+	})
+
+	t.Run("allow admin", func(t *testing.T) {
+		assert.NoError(t, handler.AuthorizeJob(internal.Caller{UserID: 1, Admin: true}, 1))
+	})
+
+	t.Run("forbid other user", func(t *testing.T) {
+		data.EXPECT().GetJob(uint(1)).Return(internal.Job{ID: 1, CreatedBy: 5}, nil).Times(1)
+		err := handler.AuthorizeJob(internal.Caller{UserID: 6}, 1)
+		assert.True(t, hasCode(err, serviceerror.Forbidden))
+	})
+
+	t.Run("forbid job of the server", func(t *testing.T) {
+		data.EXPECT().GetJob(uint(2)).Return(internal.Job{ID: 2}, nil).Times(1)
+		err := handler.AuthorizeJob(internal.Caller{}, 2)
+		assert.True(t, hasCode(err, serviceerror.Forbidden))
+	})
+
+	t.Run("fail on unknown job", func(t *testing.T) {
+		data.EXPECT().GetJob(uint(3)).Return(internal.Job{}, serviceerror.NewServiceError(serviceerror.JobNotFound, errors.New("test"))).Times(1)
+		err := handler.AuthorizeJob(internal.Caller{UserID: 5}, 3)
+		assert.True(t, hasCode(err, serviceerror.JobNotFound))
+	})
+}
+
+func TestRunJob(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockJobData(mockCtrl)
+	handler := service.NewJobService(data, service.JobOptions{Lease: 30 * time.Millisecond, RetryBackoff: time.Minute})
+	var run func(ctx context.Context, progress func(internal.JobProgress)) (interface{}, error)
+	handler.Register("test", func(ctx context.Context, payload []byte, progress func(internal.JobProgress)) (interface{}, error) {
+		assert.Equal(t, []byte(`{}`), payload)
+		return run(ctx, progress)
+	})
+	claim := func(job internal.Job) {
+		data.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).Return(job, true, nil).Times(1)
+	}
+	finish := func(t *testing.T, check func(update internal.JobUpdate)) {
+		data.EXPECT().FinishJob(uint(1), gomock.Any(), gomock.Any()).Do(func(id uint, worker string, update internal.JobUpdate) {
+			check(update)
+		}).Return(nil).Times(1)
+	}
+	job := internal.Job{ID: 1, Type: "test", Payload: []byte(`{}`), Attempts: 1, MaxAttempts: 3}
+
+	t.Run("nothing to run", func(t *testing.T) {
+		data.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).Return(internal.Job{}, false, nil).Times(1)
+		ran, err := handler.RunNext(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, ran)
+	})
+
+	t.Run("succeed with result and progress", func(t *testing.T) {
+		claim(job)
+		run = func(ctx context.Context, progress func(internal.JobProgress)) (interface{}, error) {
+			progress(internal.JobProgress{Done: 2, Total: 2})
+			return map[string]int{"count": 2}, nil
+		}
+		finish(t, func(update internal.JobUpdate) {
+			assert.Equal(t, internal.JobSucceeded, update.Status)
+			assert.Equal(t, internal.JobProgress{Done: 2, Total: 2}, update.Progress)
+			assert.Equal(t, `{"count":2}`, string(update.Result))
+		})
+		ran, err := handler.RunNext(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, ran)
+	})
+
+	t.Run("retry after unknown error", func(t *testing.T) {
+		claim(job)
+		run = func(ctx context.Context, progress func(internal.JobProgress)) (interface{}, error) {
+			return nil, errors.New("test")
+		}
+		finish(t, func(update internal.JobUpdate) {
+			assert.Equal(t, internal.JobQueued, update.Status)
+			assert.Equal(t, "test", update.Error)
+			assert.WithinDuration(t, time.Now().Add(time.Minute), update.RunAt, time.Second)
+		})
+		_, err := handler.RunNext(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("retry after panic", func(t *testing.T) {
+		claim(job)
+		run = func(ctx context.Context, progress func(internal.JobProgress)) (interface{}, error) {
+			panic("test")
+		}
+		finish(t, func(update internal.JobUpdate) {
+			assert.Equal(t, internal.JobQueued, update.Status)
+			assert.Equal(t, "job panicked: test", update.Error)
+		})
+		_, err := handler.RunNext(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail on service error", func(t *testing.T) {
+		claim(job)
+		run = func(ctx context.Context, progress func(internal.JobProgress)) (interface{}, error) {
+			return nil, serviceerror.NewServiceError(serviceerror.InvalidJobRequest, fmt.Errorf("bad payload"))
+		}
+		finish(t, func(update internal.JobUpdate) {
+			assert.Equal(t, internal.JobFailed, update.Status)
+		})
+		_, err := handler.RunNext(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail on last attempt", func(t *testing.T) {
+		last := job
+		last.Attempts = 3
+		claim(last)
+		run = func(ctx context.Context, progress func(internal.JobProgress)) (interface{}, error) {
+			return nil, errors.New("test")
+		}
+		finish(t, func(update internal.JobUpdate) {
+			assert.Equal(t, internal.JobFailed, update.Status)
+			assert.Equal(t, "test", update.Error)
+		})
+		_, err := handler.RunNext(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail abandoned job", func(t *testing.T) {
+		abandoned := job
+		abandoned.Attempts = 4
+		claim(abandoned)
+		finish(t, func(update internal.JobUpdate) {
+			assert.Equal(t, internal.JobFailed, update.Status)
+		})
+		_, err := handler.RunNext(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail unknown job type", func(t *testing.T) {
+		unknown := job
+		unknown.Type = "unknown"
+		claim(unknown)
+		finish(t, func(update internal.JobUpdate) {
+			assert.Equal(t, internal.JobFailed, update.Status)
+		})
+		_, err := handler.RunNext(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("stop cancelled job", func(t *testing.T) {
+		claim(job)
+		data.EXPECT().RenewJob(uint(1), gomock.Any(), internal.JobProgress{Done: 1, Total: 2}, gomock.Any()).Return(true, nil).Times(1)
+		run = func(ctx context.Context, progress func(internal.JobProgress)) (interface{}, error) {
+			progress(internal.JobProgress{Done: 1, Total: 2})
+			<-ctx.Done()
+			return map[string]int{"count": 1}, ctx.Err()
+		}
+		finish(t, func(update internal.JobUpdate) {
+			assert.Equal(t, internal.JobCancelled, update.Status)
+			assert.Equal(t, `{"count":1}`, string(update.Result))
+		})
+		_, err := handler.RunNext(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("requeue on shutdown", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		claim(job)
+		run = func(jobCtx context.Context, progress func(internal.JobProgress)) (interface{}, error) {
+			cancel()
+			<-jobCtx.Done()
+			return nil, jobCtx.Err()
+		}
+		finish(t, func(update internal.JobUpdate) {
+			assert.Equal(t, internal.JobQueued, update.Status)
+		})
+		_, err := handler.RunNext(ctx)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAttemptWindow   = time.Hour
+	defaultUserThreshold   = 10
+	defaultIPThreshold     = 100
+	defaultLockoutDuration = 15 * time.Minute
+	defaultDelayAfter      = 3
+	defaultBaseDelay       = time.Second
+	defaultMaxDelay        = time.Minute
+)
+
+// LockoutOptions configure throttling. Failures are counted within Window.
+// From DelayAfter failures on, a user has to wait BaseDelay doubled for every
+// further failure, capped at MaxDelay, before the next attempt. At
+// UserThreshold failures of a user or IPThreshold failures from an IP the
+// user or IP is locked for LockoutDuration.
+type LockoutOptions struct {
+	Window          time.Duration
+	UserThreshold   int64
+	IPThreshold     int64
+	LockoutDuration time.Duration
+	DelayAfter      int64
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+}
+
+type lockoutService struct {
+	users        internal.UserData
+	store        internal.AttemptStore
+	auditor      internal.Auditor
+	options      LockoutOptions
+	organization uint
+}
+
+func NewLockoutService(users internal.UserData, store internal.AttemptStore, auditor internal.Auditor, options LockoutOptions) *lockoutService {
+	if options.Window == 0 {
+		options.Window = defaultAttemptWindow
+	}
+	if options.UserThreshold == 0 {
+		options.UserThreshold = defaultUserThreshold
+	}
+	if options.IPThreshold == 0 {
+		options.IPThreshold = defaultIPThreshold
+	}
+	if options.LockoutDuration == 0 {
+		options.LockoutDuration = defaultLockoutDuration
+	}
+	if options.DelayAfter == 0 {
+		options.DelayAfter = defaultDelayAfter
+	}
+	if options.BaseDelay == 0 {
+		options.BaseDelay = defaultBaseDelay
+	}
+	if options.MaxDelay == 0 {
+		options.MaxDelay = defaultMaxDelay
+	}
+	return &lockoutService{
+		users:   users,
+		store:   store,
+		auditor: auditor,
+		options: options,
+	}
+}
+
+// Check refuses an attempt while the user or IP is locked or the user's delay
+// since the last failure hasn't passed.
+func (l *lockoutService) Check(email string, ip string) (err error) {
+	now := time.Now()
+	user, err := l.store.GetAttempts(l.userKey(email))
+	if err != nil {
+		return err
+	}
+	retryAt := user.LockedUntil
+	if delay := l.delay(user.Failures); delay > 0 && user.LastFailure.Add(delay).After(retryAt) {
+		retryAt = user.LastFailure.Add(delay)
+	}
+	if ip != "" {
+		source, err := l.store.GetAttempts(ipKey(ip))
+		if err != nil {
+			return err
+		}
+		if source.LockedUntil.After(retryAt) {
+			retryAt = source.LockedUntil
+		}
+	}
+	if !retryAt.After(now) {
+		return nil
+	}
+	l.auditor.Record(internal.AuditEvent{Type: internal.EventLoginThrottled, Email: email, IP: ip})
+	return tooManyAttempts(retryAt.Sub(now))
+}
+
+// Fail counts a failed attempt for the user and the IP and locks either once
+// it reaches its threshold.
+func (l *lockoutService) Fail(email string, ip string) (err error) {
+	l.auditor.Record(internal.AuditEvent{Type: internal.EventLoginFailed, Email: email, IP: ip})
+	until := time.Now().Add(l.options.LockoutDuration)
+	user, err := l.store.RecordFailure(l.userKey(email), l.options.Window)
+	if err != nil {
+		return err
+	}
+	if user.Failures >= l.options.UserThreshold && user.LockedUntil.Before(until) {
+		if err = l.store.Lock(l.userKey(email), until); err != nil {
+			return err
+		}
+		log.WithField("failures", user.Failures).Warn("account locked")
+		l.auditor.Record(internal.AuditEvent{Type: internal.EventAccountLocked, Email: email, IP: ip})
+	}
+	if ip == "" {
+		return nil
+	}
+	source, err := l.store.RecordFailure(ipKey(ip), l.options.Window)
+	if err != nil {
+		return err
+	}
+	if source.Failures >= l.options.IPThreshold && source.LockedUntil.Before(until) {
+		if err = l.store.Lock(ipKey(ip), until); err != nil {
+			return err
+		}
+		log.WithField("ip", ip).WithField("failures", source.Failures).Warn("ip locked")
+		l.auditor.Record(internal.AuditEvent{Type: internal.EventIPLocked, IP: ip})
+	}
+	return nil
+}
+
+// Succeed clears the user's failures. Those of the IP are kept so a valid
+// account can't be used to reset the counter of an attacking IP.
+func (l *lockoutService) Succeed(userID uint, email string, ip string) (err error) {
+	l.auditor.Record(internal.AuditEvent{Type: internal.EventLoginSucceeded, UserID: userID, Email: email, IP: ip})
+	return l.store.ResetAttempts(l.userKey(email))
+}
+
+func (l *lockoutService) Unlock(userID uint) (err error) {
+	user, err := l.users.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	err = l.store.ResetAttempts(internal.UserAttemptKey(user.OrganizationID, user.Email))
+	if err != nil {
+		return err
+	}
+	l.auditor.Record(internal.AuditEvent{Type: internal.EventAccountUnlocked, UserID: user.ID, Email: user.Email})
+	return nil
+}
+
+// AuthorizeAdmin lets only admins who logged in unlock users.
+func (l *lockoutService) AuthorizeAdmin(caller internal.Caller) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't unlock users"))
+	}
+	if !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d is not an admin", caller.UserID))
+	}
+	return nil
+}
+
+// ForOrganization returns the service for the users of the organization, the
+// failures of a user with the same email in another organization are apart.
+func (l *lockoutService) ForOrganization(organizationID uint) internal.LockoutService {
+	scoped := *l
+	scoped.users = l.users.ForOrganization(organizationID)
+	scoped.organization = organizationID
+	return &scoped
+}
+
+func (l *lockoutService) userKey(email string) string {
+	return internal.UserAttemptKey(l.organization, email)
+}
+
+func (l *lockoutService) delay(failures int64) time.Duration {
+	if failures < l.options.DelayAfter {
+		return 0
+	}
+	delay := float64(l.options.BaseDelay) * math.Pow(2, float64(failures-l.options.DelayAfter))
+	if delay > float64(l.options.MaxDelay) {
+		return l.options.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+func tooManyAttempts(retryAfter time.Duration) error {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	return serviceerror.NewServiceError(serviceerror.TooManyAttempts, errors.Errorf("retry after %d seconds", seconds))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
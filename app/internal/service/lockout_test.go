@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/attempts"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserData(mockCtrl)
+	auditor := mock.NewMockAuditor(mockCtrl)
+	var events []string
+	auditor.EXPECT().Record(gomock.Any()).Do(func(event internal.AuditEvent) {
+		events = append(events, event.Type)
+	}).AnyTimes()
+	options := service.LockoutOptions{
+		UserThreshold:   3,
+		IPThreshold:     5,
+		LockoutDuration: time.Minute,
+		DelayAfter:      10,
+	}
+
+	t.Run("lock user at threshold", func(t *testing.T) {
+		handler := service.NewLockoutService(users, attempts.NewMemoryStore(), auditor, options)
+		events = nil
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, handler.Check("test@gmail.com", "10.0.0.1"))
+			assert.NoError(t, handler.Fail("test@gmail.com", "10.0.0.1"))
+		}
+		err := handler.Check("Test@gmail.com", "10.0.0.2")
+		assert.True(t, hasCode(err, serviceerror.TooManyAttempts))
+		assert.EqualError(t, err, "Too Many Attempts : retry after 60 seconds")
+		assert.NoError(t, handler.Check("other@gmail.com", "10.0.0.1"))
+		assert.Equal(t, []string{internal.EventLoginFailed, internal.EventLoginFailed, internal.EventLoginFailed,
+			internal.EventAccountLocked, internal.EventLoginThrottled}, events)
+	})
+
+	t.Run("lock ip at threshold", func(t *testing.T) {
+		handler := service.NewLockoutService(users, attempts.NewMemoryStore(), auditor, options)
+		events = nil
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, handler.Fail(string(rune('a'+i))+"@gmail.com", "10.0.0.1"))
+		}
+		assert.Contains(t, events, internal.EventIPLocked)
+		assert.True(t, hasCode(handler.Check("other@gmail.com", "10.0.0.1"), serviceerror.TooManyAttempts))
+		assert.NoError(t, handler.Check("other@gmail.com", "10.0.0.2"))
+	})
+
+	t.Run("delay exponentially", func(t *testing.T) {
+		handler := service.NewLockoutService(users, attempts.NewMemoryStore(), auditor, service.LockoutOptions{
+			UserThreshold: 100,
+			DelayAfter:    2,
+			BaseDelay:     time.Second,
+			MaxDelay:      3 * time.Second,
+		})
+		assert.NoError(t, handler.Fail("test@gmail.com", ""))
+		assert.NoError(t, handler.Check("test@gmail.com", ""))
+		assert.NoError(t, handler.Fail("test@gmail.com", ""))
+		assert.EqualError(t, handler.Check("test@gmail.com", ""), "Too Many Attempts : retry after 1 seconds")
+		assert.NoError(t, handler.Fail("test@gmail.com", ""))
+		assert.EqualError(t, handler.Check("test@gmail.com", ""), "Too Many Attempts : retry after 2 seconds")
+		assert.NoError(t, handler.Fail("test@gmail.com", ""))
+		assert.EqualError(t, handler.Check("test@gmail.com", ""), "Too Many Attempts : retry after 3 seconds")
+	})
+
+	t.Run("reset user on success and unlock", func(t *testing.T) {
+		handler := service.NewLockoutService(users, attempts.NewMemoryStore(), auditor, options)
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, handler.Fail("test@gmail.com", "10.0.0.1"))
+		}
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Email: "test@gmail.com"}, nil).Times(1)
+		events = nil
+		assert.NoError(t, handler.Unlock(1))
+		assert.Equal(t, []string{internal.EventAccountUnlocked}, events)
+		assert.NoError(t, handler.Check("test@gmail.com", "10.0.0.1"))
+
+		assert.NoError(t, handler.Fail("test@gmail.com", "10.0.0.2"))
+		assert.NoError(t, handler.Fail("test@gmail.com", "10.0.0.2"))
+		assert.NoError(t, handler.Succeed(1, "test@gmail.com", "10.0.0.2"))
+		assert.NoError(t, handler.Fail("test@gmail.com", "10.0.0.2"))
+		assert.NoError(t, handler.Check("test@gmail.com", "10.0.0.2"))
+	})
+
+	t.Run("keep users of other organizations apart", func(t *testing.T) {
+		handler := service.NewLockoutService(users, attempts.NewMemoryStore(), auditor, options)
+		users.EXPECT().ForOrganization(uint(2)).Return(users).Times(1)
+		scoped := handler.ForOrganization(2)
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, scoped.Fail("test@gmail.com", ""))
+		}
+		assert.True(t, hasCode(scoped.Check("test@gmail.com", ""), serviceerror.TooManyAttempts))
+		assert.NoError(t, handler.Check("test@gmail.com", ""))
+
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Email: "test@gmail.com", OrganizationID: 2}, nil).Times(1)
+		assert.NoError(t, handler.Unlock(1))
+		assert.NoError(t, scoped.Check("test@gmail.com", ""))
+	})
+}
+
+func TestLockoutAuthorizeAdmin(t *testing.T) {
+	handler := service.NewLockoutService(nil, attempts.NewMemoryStore(), nil, service.LockoutOptions{})
+	assert.NoError(t, handler.AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}))
+	assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 1}), serviceerror.Forbidden))
+	assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true, Scopes: []string{"users:write"}}), serviceerror.Forbidden))
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultExpiryInterval = time.Minute
+
+type MembershipExpiryOptions struct {
+	Interval time.Duration
+}
+
+type membershipExpiryService struct {
+	data     internal.GroupData
+	auditor  internal.Auditor
+	interval time.Duration
+}
+
+func NewMembershipExpiryService(data internal.GroupData, auditor internal.Auditor, options MembershipExpiryOptions) *membershipExpiryService {
+	if options.Interval == 0 {
+		options.Interval = defaultExpiryInterval
+	}
+	return &membershipExpiryService{
+		data:     data,
+		auditor:  auditor,
+		interval: options.Interval,
+	}
+}
+
+// ExpireMemberships removes the memberships whose expiry has passed and
+// records an audit event for each.
+func (m *membershipExpiryService) ExpireMemberships() (err error) {
+	expired, err := m.data.ExpireMemberships(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, membership := range expired {
+		log.WithFields(log.Fields{"user": membership.UserID, "group": membership.GroupID}).Info("membership expired")
+		m.auditor.Record(internal.AuditEvent{
+			Type:   internal.EventMembershipExpired,
+			UserID: membership.UserID,
+			Detail: fmt.Sprintf("membership of group %d %s expired at %s", membership.GroupID, membership.GroupName, membership.ExpiresAt.Format(time.RFC3339)),
+		})
+	}
+	return nil
+}
+
+func (m *membershipExpiryService) Init() (err error) {
+	return nil
+}
+
+func (m *membershipExpiryService) Run(ctx context.Context) (err error) {
+	RunPeriodically(ctx, "expire memberships", m.interval, m.ExpireMemberships)
+	return nil
+}
@@ -0,0 +1,39 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpireMemberships(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockGroupData(mockCtrl)
+	auditor := mock.NewMockAuditor(mockCtrl)
+	handler := service.NewMembershipExpiryService(data, auditor, service.MembershipExpiryOptions{})
+
+	t.Run("audit expired memberships", func(t *testing.T) {
+		expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+		data.EXPECT().ExpireMemberships(gomock.Any()).Return([]internal.ExpiredMembership{
+			{UserID: 2, GroupID: 1, GroupName: "contractors", ExpiresAt: expiresAt},
+		}, nil).Times(1)
+		auditor.EXPECT().Record(internal.AuditEvent{
+			Type:   internal.EventMembershipExpired,
+			UserID: 2,
+			Detail: "membership of group 1 contractors expired at 2030-01-01T00:00:00Z",
+		}).Times(1)
+		assert.NoError(t, handler.ExpireMemberships())
+	})
+
+	t.Run("fail on unknown error", func(t *testing.T) {
+		data.EXPECT().ExpireMemberships(gomock.Any()).Return(nil, errors.New("test")).Times(1)
+		assert.EqualError(t, handler.ExpireMemberships(), "test")
+	})
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/totp"
+
+	"github.com/pkg/errors"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	defaultMFAIssuer  = "usermanagement"
+	recoveryCodeCount = 10
+	qrCodeSize        = 256
+)
+
+type MFAOptions struct {
+	Issuer string
+}
+
+type mfaService struct {
+	users   internal.UserData
+	data    internal.MFAData
+	options MFAOptions
+}
+
+func NewMFAService(users internal.UserData, data internal.MFAData, options MFAOptions) *mfaService {
+	if options.Issuer == "" {
+		options.Issuer = defaultMFAIssuer
+	}
+	return &mfaService{
+		users:   users,
+		data:    data,
+		options: options,
+	}
+}
+
+func (m *mfaService) Enroll(userID uint) (response internal.MFAEnrollment, err error) {
+	user, err := m.users.GetUser(userID)
+	if err != nil {
+		return response, err
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return response, errors.Wrap(err, "generate mfa secret failed")
+	}
+	err = m.data.SaveMFASecret(userID, secret)
+	if err != nil {
+		return response, err
+	}
+	uri := totp.URI(m.options.Issuer, user.Email, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		return response, errors.Wrap(err, "encode mfa qr code failed")
+	}
+	response = internal.MFAEnrollment{
+		Secret:     secret,
+		OtpauthURI: uri,
+		QRCode:     png,
+	}
+	return response, err
+}
+
+// Activate enables MFA once the user proves the authenticator is set up and
+// returns recovery codes. Only their hashes are kept, so they are shown once.
+func (m *mfaService) Activate(userID uint, code string) (response internal.RecoveryCodesResponse, err error) {
+	mfa, err := m.data.GetMFA(userID)
+	if err != nil {
+		return response, err
+	}
+	if mfa.Enabled {
+		return response, serviceerror.NewServiceError(serviceerror.MFAAlreadyEnabled, errors.Errorf("mfa of user %d is already enabled", userID))
+	}
+	step, ok := totp.Validate(mfa.Secret, code, time.Now())
+	if !ok {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidMFACode, errors.New("mfa code is wrong"))
+	}
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = recoveryCode()
+		if err != nil {
+			return response, err
+		}
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	err = m.data.EnableMFA(userID, step, hashes)
+	if err != nil {
+		return response, err
+	}
+	response = internal.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}
+	return response, err
+}
+
+func (m *mfaService) Reset(userID uint) (err error) {
+	return m.data.ResetMFA(userID)
+}
+
+// AuthorizeEnrollment lets only users who logged in enroll themselves, the
+// secret is in the response. Not even admins enroll others.
+func (m *mfaService) AuthorizeEnrollment(caller internal.Caller, userID uint) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't enroll mfa"))
+	}
+	if caller.UserID != userID {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d can't enroll mfa of user %d", caller.UserID, userID))
+	}
+	return nil
+}
+
+// AuthorizeAdmin lets only admins who logged in reset the mfa of users who
+// lost their authenticator.
+func (m *mfaService) AuthorizeAdmin(caller internal.Caller) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't reset mfa"))
+	}
+	if !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d is not an admin", caller.UserID))
+	}
+	return nil
+}
+
+// verifyMFACode accepts either a current TOTP code or an unused recovery code.
+func verifyMFACode(data internal.MFAData, mfa internal.MFA, code string) (err error) {
+	if step, ok := totp.Validate(mfa.Secret, code, time.Now()); ok {
+		return data.UseMFAStep(mfa.UserID, step)
+	}
+	code = normalizeRecoveryCode(code)
+	if len(code) <= totp.Digits {
+		return serviceerror.NewServiceError(serviceerror.InvalidMFACode, errors.New("mfa code is wrong"))
+	}
+	return data.UseRecoveryCode(mfa.UserID, hashToken(code))
+}
+
+func recoveryCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generate recovery code failed")
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+	return code[:4] + "-" + code[4:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
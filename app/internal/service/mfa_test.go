@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/totp"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrollMFA(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserData(mockCtrl)
+	data := mock.NewMockMFAData(mockCtrl)
+	handler := service.NewMFAService(users, data, service.MFAOptions{})
+
+	t.Run("enroll successfully", func(t *testing.T) {
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Email: "test@gmail.com"}, nil).Times(1)
+		data.EXPECT().SaveMFASecret(uint(1), gomock.Any()).Return(nil).Times(1)
+		response, err := handler.Enroll(1)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, response.Secret)
+		assert.True(t, strings.HasPrefix(response.OtpauthURI, "otpauth://totp/usermanagement:test@gmail.com?"))
+		assert.Equal(t, []byte("\x89PNG"), response.QRCode[:4])
+	})
+
+	t.Run("error on enabled mfa", func(t *testing.T) {
+		enabledErr := serviceerror.NewServiceError(serviceerror.MFAAlreadyEnabled, errors.New("test"))
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Email: "test@gmail.com"}, nil).Times(1)
+		data.EXPECT().SaveMFASecret(uint(1), gomock.Any()).Return(enabledErr).Times(1)
+		_, err := handler.Enroll(1)
+		assert.Equal(t, enabledErr, err)
+	})
+}
+
+func TestActivateMFA(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockMFAData(mockCtrl)
+	handler := service.NewMFAService(mock.NewMockUserData(mockCtrl), data, service.MFAOptions{})
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+
+	t.Run("activate and return recovery codes", func(t *testing.T) {
+		step := totp.Step(time.Now())
+		code, err := totp.Code(secret, step)
+		assert.NoError(t, err)
+		data.EXPECT().GetMFA(uint(1)).Return(internal.MFA{UserID: 1, Secret: secret}, nil).Times(1)
+		data.EXPECT().EnableMFA(uint(1), gomock.Any(), gomock.Any()).Do(func(userID uint, step int64, hashes []string) {
+			assert.Len(t, hashes, 10)
+		}).Return(nil).Times(1)
+		response, err := handler.Activate(1, code)
+		assert.NoError(t, err)
+		assert.Len(t, response.RecoveryCodes, 10)
+		assert.Len(t, response.RecoveryCodes[0], 9)
+	})
+
+	t.Run("error on wrong code", func(t *testing.T) {
+		data.EXPECT().GetMFA(uint(1)).Return(internal.MFA{UserID: 1, Secret: secret}, nil).Times(1)
+		_, err := handler.Activate(1, "abcdef")
+		assert.EqualError(t, err, "Invalid MFA Code : mfa code is wrong")
+	})
+
+	t.Run("error on enabled mfa", func(t *testing.T) {
+		data.EXPECT().GetMFA(uint(1)).Return(internal.MFA{UserID: 1, Secret: secret, Enabled: true}, nil).Times(1)
+		_, err := handler.Activate(1, "123456")
+		assert.EqualError(t, err, "MFA Already Enabled : mfa of user 1 is already enabled")
+	})
+}
+
+func TestAuthorizeMFA(t *testing.T) {
+	handler := service.NewMFAService(nil, nil, service.MFAOptions{})
+	assert.NoError(t, handler.AuthorizeEnrollment(internal.Caller{UserID: 1}, 1))
+	assert.True(t, hasCode(handler.AuthorizeEnrollment(internal.Caller{UserID: 2, Admin: true}, 1), serviceerror.Forbidden))
+	assert.True(t, hasCode(handler.AuthorizeEnrollment(internal.Caller{UserID: 1, Scopes: []string{"users:write"}}, 1), serviceerror.Forbidden))
+	assert.NoError(t, handler.AuthorizeAdmin(internal.Caller{UserID: 2, Admin: true}))
+	assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 1}), serviceerror.Forbidden))
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+)
+
+// OAuthOptions configure the authorization server, TokenTTL is how long an
+// access token is valid and CodeTTL how long an authorization code waits to
+// be exchanged.
+type OAuthOptions struct {
+	TokenTTL time.Duration
+	CodeTTL  time.Duration
+}
+
+type oauthService struct {
+	data         internal.OAuthData
+	users        internal.UserData
+	accessTokens internal.AccessTokenData
+	oidc         internal.OIDCService
+	options      OAuthOptions
+}
+
+func NewOAuthService(data internal.OAuthData, users internal.UserData, accessTokens internal.AccessTokenData, oidc internal.OIDCService, options OAuthOptions) *oauthService {
+	if options.TokenTTL <= 0 {
+		options.TokenTTL = time.Hour
+	}
+	if options.CodeTTL <= 0 {
+		options.CodeTTL = 10 * time.Minute
+	}
+	return &oauthService{
+		data:         data,
+		users:        users,
+		accessTokens: accessTokens,
+		oidc:         oidc,
+		options:      options,
+	}
+}
+
+// RegisterClient registers a client, a confidential one gets a secret which
+// is only returned here. Only confidential clients bound to a service account
+// may use the client credentials grant.
+func (o *oauthService) RegisterClient(request internal.OAuthClientRequest) (response internal.RegisteredOAuthClient, err error) {
+	if request.Name == "" || len(request.GrantTypes) == 0 || len(request.Scopes) == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidOAuthClientRequest, errors.New("missing oauth client fields"))
+	}
+	for _, scope := range request.Scopes {
+		if !validScope(scope) && !oidcScopes[scope] {
+			return response, serviceerror.NewServiceError(serviceerror.InvalidOAuthClientRequest, fmt.Errorf("scope %s is not valid", scope))
+		}
+	}
+	for _, grantType := range request.GrantTypes {
+		if grantType != internal.GrantAuthorizationCode && grantType != internal.GrantClientCredentials {
+			return response, serviceerror.NewServiceError(serviceerror.InvalidOAuthClientRequest, fmt.Errorf("grant type %s is not supported", grantType))
+		}
+	}
+	for _, redirectURI := range request.RedirectURIs {
+		parsed, err := url.Parse(redirectURI)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" || strings.ContainsAny(redirectURI, " ") {
+			return response, serviceerror.NewServiceError(serviceerror.InvalidOAuthClientRequest, fmt.Errorf("redirect uri %s is not valid", redirectURI))
+		}
+	}
+	if containsString(request.GrantTypes, internal.GrantAuthorizationCode) && len(request.RedirectURIs) == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidOAuthClientRequest, errors.New("authorization code grant needs a redirect uri"))
+	}
+	if containsString(request.GrantTypes, internal.GrantClientCredentials) {
+		if !request.Confidential || request.ServiceAccountID == 0 {
+			return response, serviceerror.NewServiceError(serviceerror.InvalidOAuthClientRequest, errors.New("client credentials grant needs a confidential client with a service account"))
+		}
+	}
+	if request.ServiceAccountID != 0 {
+		user, err := o.users.GetUser(request.ServiceAccountID)
+		if err != nil {
+			return response, err
+		}
+		if user.Type != internal.UserServiceAccount {
+			return response, serviceerror.NewServiceError(serviceerror.InvalidOAuthClientRequest, fmt.Errorf("user %d is not a service account", user.ID))
+		}
+	}
+	clientID, err := randomToken()
+	if err != nil {
+		return response, err
+	}
+	var secret string
+	if request.Confidential {
+		secret, err = randomToken()
+		if err != nil {
+			return response, err
+		}
+	}
+	client, err := o.data.CreateClient(internal.OAuthClient{
+		ClientID:         clientID,
+		Name:             request.Name,
+		Confidential:     request.Confidential,
+		RedirectURIs:     request.RedirectURIs,
+		GrantTypes:       request.GrantTypes,
+		Scopes:           request.Scopes,
+		ServiceAccountID: request.ServiceAccountID,
+	}, secret)
+	if err != nil {
+		return response, err
+	}
+	return internal.RegisteredOAuthClient{OAuthClient: client, ClientSecret: secret}, err
+}
+
+func (o *oauthService) GetClient(clientID string) (response internal.OAuthClient, err error) {
+	return o.data.GetClient(clientID)
+}
+
+// RedirectURI returns the registered redirect uri the authorization endpoint
+// answers to, a client with a single one may leave it out. Errors here must
+// not be redirected.
+func (o *oauthService) RedirectURI(clientID string, redirectURI string) (response string, err error) {
+	_, response, err = o.redirectClient(clientID, redirectURI)
+	return response, err
+}
+
+func (o *oauthService) redirectClient(clientID string, redirectURI string) (client internal.OAuthClient, response string, err error) {
+	if clientID == "" {
+		return client, response, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("client_id is required"))
+	}
+	client, err = o.data.GetClient(clientID)
+	if hasErrorCode(err, serviceerror.OAuthClientNotFound) {
+		return client, response, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, err)
+	}
+	if err != nil {
+		return client, response, err
+	}
+	if redirectURI == "" {
+		if len(client.RedirectURIs) != 1 {
+			return client, response, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("redirect_uri is required"))
+		}
+		return client, client.RedirectURIs[0], nil
+	}
+	if !containsString(client.RedirectURIs, redirectURI) {
+		return client, response, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, fmt.Errorf("redirect_uri %s is not registered", redirectURI))
+	}
+	return client, redirectURI, nil
+}
+
+// Authorize issues an authorization code the user grants the client. PKCE
+// with the S256 method is required of every client.
+func (o *oauthService) Authorize(request internal.OAuthAuthorizeRequest) (code string, err error) {
+	client, _, err := o.redirectClient(request.ClientID, request.RedirectURI)
+	if err != nil {
+		return code, err
+	}
+	if request.ResponseType != "code" {
+		return code, serviceerror.NewServiceError(serviceerror.OAuthUnsupportedResponse, fmt.Errorf("response_type %s is not supported", request.ResponseType))
+	}
+	if !containsString(client.GrantTypes, internal.GrantAuthorizationCode) {
+		return code, serviceerror.NewServiceError(serviceerror.OAuthUnauthorizedClient, fmt.Errorf("client %s can't use the authorization code grant", client.ClientID))
+	}
+	if request.CodeChallengeMethod != "S256" || len(request.CodeChallenge) != 43 {
+		return code, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("code_challenge with code_challenge_method S256 is required"))
+	}
+	if request.UserID == 0 {
+		return code, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("user_id is 0 for authorize"))
+	}
+	scopes, err := grantedScopes(client, request.Scope)
+	if err != nil {
+		return code, err
+	}
+	code, err = randomToken()
+	if err != nil {
+		return code, err
+	}
+	err = o.data.CreateAuthorizationCode(internal.OAuthAuthorizationCode{
+		ClientID:      client.ClientID,
+		UserID:        request.UserID,
+		CodeHash:      hashToken(code),
+		RedirectURI:   request.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: request.CodeChallenge,
+		Nonce:         request.Nonce,
+		ExpiresAt:     time.Now().Add(o.options.CodeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// Token exchanges an authorization code, or the credentials of a client, for
+// an access token. A code granted the openid scope comes with an ID token.
+func (o *oauthService) Token(request internal.OAuthTokenRequest) (response internal.OAuthTokenResponse, err error) {
+	if request.GrantType == "" {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("grant_type is required"))
+	}
+	if request.GrantType != internal.GrantAuthorizationCode && request.GrantType != internal.GrantClientCredentials {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthUnsupportedGrantType, fmt.Errorf("grant_type %s is not supported", request.GrantType))
+	}
+	client, err := o.authenticateClient(request.ClientID, request.ClientSecret)
+	if err != nil {
+		return response, err
+	}
+	if !containsString(client.GrantTypes, request.GrantType) {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthUnauthorizedClient, fmt.Errorf("client %s can't use the %s grant", client.ClientID, request.GrantType))
+	}
+	if request.GrantType == internal.GrantClientCredentials {
+		scopes, err := grantedScopes(client, request.Scope)
+		if err != nil {
+			return response, err
+		}
+		return o.issue(client, client.ServiceAccountID, scopes)
+	}
+	if request.Code == "" || request.CodeVerifier == "" {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("code and code_verifier are required"))
+	}
+	code, err := o.data.ConsumeAuthorizationCode(hashToken(request.Code))
+	if err != nil {
+		return response, err
+	}
+	if code.ClientID != client.ClientID || code.RedirectURI != request.RedirectURI {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthInvalidGrant, errors.New("authorization code was issued to another client or redirect_uri"))
+	}
+	if !verifyCodeChallenge(request.CodeVerifier, code.CodeChallenge) {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthInvalidGrant, errors.New("code_verifier doesn't match the code_challenge"))
+	}
+	response, err = o.issue(client, code.UserID, code.Scopes)
+	if err != nil || !containsString(code.Scopes, internal.ScopeOpenID) {
+		return response, err
+	}
+	response.IDToken, err = o.oidc.IDToken(internal.IDTokenRequest{
+		UserID:   code.UserID,
+		ClientID: client.ClientID,
+		Nonce:    code.Nonce,
+		Scopes:   code.Scopes,
+	})
+	if err != nil {
+		return internal.OAuthTokenResponse{}, err
+	}
+	return response, nil
+}
+
+// issue creates an access token of the client acting as the user, the user
+// must still be active.
+func (o *oauthService) issue(client internal.OAuthClient, userID uint, scopes []string) (response internal.OAuthTokenResponse, err error) {
+	user, err := o.users.GetUser(userID)
+	if hasErrorCode(err, serviceerror.UserNotFound) {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthInvalidGrant, err)
+	}
+	if err != nil {
+		return response, err
+	}
+	if err := checkActive(user); err != nil {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthInvalidGrant, err)
+	}
+	token, err := randomToken()
+	if err != nil {
+		return response, err
+	}
+	token = internal.OAuthTokenPrefix + token
+	expiresAt := time.Now().Add(o.options.TokenTTL)
+	_, err = o.accessTokens.CreateAccessToken(internal.AccessToken{
+		UserID:    user.ID,
+		ClientID:  client.ClientID,
+		Name:      client.Name,
+		TokenHash: hashToken(token),
+		Scopes:    scopes,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		return response, err
+	}
+	return internal.OAuthTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(o.options.TokenTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// Introspect tells a confidential client whether a token issued to any client
+// is active, unknown, expired and revoked tokens and those of inactive users
+// are not.
+func (o *oauthService) Introspect(clientID string, clientSecret string, token string) (response internal.OAuthIntrospection, err error) {
+	client, err := o.authenticateClient(clientID, clientSecret)
+	if err != nil {
+		return response, err
+	}
+	if !client.Confidential {
+		return response, serviceerror.NewServiceError(serviceerror.OAuthInvalidClient, fmt.Errorf("public client %s can't introspect tokens", client.ClientID))
+	}
+	if !strings.HasPrefix(token, internal.OAuthTokenPrefix) {
+		return response, nil
+	}
+	stored, err := o.accessTokens.GetAccessTokenByHash(hashToken(token))
+	if hasErrorCode(err, serviceerror.AccessTokenNotFound) {
+		return response, nil
+	}
+	if err != nil {
+		return response, err
+	}
+	if stored.ClientID == "" || stored.ExpiresAt == nil || !stored.ExpiresAt.After(time.Now()) {
+		return response, nil
+	}
+	user, err := o.users.GetUser(stored.UserID)
+	if hasErrorCode(err, serviceerror.UserNotFound) {
+		return response, nil
+	}
+	if err != nil {
+		return response, err
+	}
+	if checkActive(user) != nil {
+		return response, nil
+	}
+	return internal.OAuthIntrospection{
+		Active:    true,
+		Scope:     strings.Join(stored.Scopes, " "),
+		ClientID:  stored.ClientID,
+		Sub:       strconv.FormatUint(uint64(stored.UserID), 10),
+		TokenType: "Bearer",
+		Exp:       stored.ExpiresAt.Unix(),
+		Iat:       stored.CreatedAt.Unix(),
+	}, nil
+}
+
+// Revoke revokes a token issued to the client. As RFC 7009 asks, unknown
+// tokens are no error.
+func (o *oauthService) Revoke(clientID string, clientSecret string, token string) (err error) {
+	client, err := o.authenticateClient(clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("token is required"))
+	}
+	return o.accessTokens.RevokeClientAccessToken(client.ClientID, hashToken(token))
+}
+
+// AuthorizeAdmin lets only admins who logged in register clients.
+func (o *oauthService) AuthorizeAdmin(caller internal.Caller) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't register oauth clients"))
+	}
+	if !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d is not an admin", caller.UserID))
+	}
+	return nil
+}
+
+// authenticateClient authenticates a confidential client with its secret, a
+// public client only names itself.
+func (o *oauthService) authenticateClient(clientID string, clientSecret string) (client internal.OAuthClient, err error) {
+	if clientSecret != "" {
+		return o.data.AuthenticateClient(clientID, clientSecret)
+	}
+	if clientID == "" {
+		return client, serviceerror.NewServiceError(serviceerror.OAuthInvalidClient, errors.New("client_id is required"))
+	}
+	client, err = o.data.GetClient(clientID)
+	if hasErrorCode(err, serviceerror.OAuthClientNotFound) {
+		return client, serviceerror.NewServiceError(serviceerror.OAuthInvalidClient, err)
+	}
+	if err != nil {
+		return client, err
+	}
+	if client.Confidential {
+		return client, serviceerror.NewServiceError(serviceerror.OAuthInvalidClient, fmt.Errorf("client %s must authenticate", clientID))
+	}
+	return client, nil
+}
+
+// grantedScopes returns the space separated scopes requested of the client,
+// all of its scopes when none are.
+func grantedScopes(client internal.OAuthClient, scope string) ([]string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return client.Scopes, nil
+	}
+	for _, s := range scopes {
+		if !containsString(client.Scopes, s) {
+			return nil, serviceerror.NewServiceError(serviceerror.OAuthInvalidScope, fmt.Errorf("scope %s is not granted to client %s", s, client.ClientID))
+		}
+	}
+	return scopes, nil
+}
+
+// verifyCodeChallenge checks a PKCE code verifier against its S256 challenge.
+func verifyCodeChallenge(verifier string, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
@@ -0,0 +1,348 @@
+package service_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// the code verifier and challenge of RFC 7636 appendix B
+const (
+	codeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	codeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+var (
+	webClient = internal.OAuthClient{
+		ClientID:     "web",
+		Name:         "web",
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		GrantTypes:   []string{internal.GrantAuthorizationCode},
+		Scopes:       []string{"users:read", "groups:read"},
+	}
+	serviceClient = internal.OAuthClient{
+		ClientID:         "ci",
+		Name:             "ci",
+		Confidential:     true,
+		GrantTypes:       []string{internal.GrantClientCredentials},
+		Scopes:           []string{"users:write"},
+		ServiceAccountID: 4,
+	}
+)
+
+func TestRegisterOAuthClient(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockOAuthData(mockCtrl)
+	users := mock.NewMockUserData(mockCtrl)
+	handler := service.NewOAuthService(data, users, nil, nil, service.OAuthOptions{})
+
+	t.Run("register confidential client", func(t *testing.T) {
+		users.EXPECT().GetUser(uint(4)).Return(internal.UserResponse{ID: 4, Type: internal.UserServiceAccount}, nil).Times(1)
+		data.EXPECT().CreateClient(gomock.Any(), gomock.Any()).DoAndReturn(func(client internal.OAuthClient, secret string) (internal.OAuthClient, error) {
+			assert.NotEmpty(t, client.ClientID)
+			assert.NotEmpty(t, secret)
+			return client, nil
+		}).Times(1)
+		response, err := handler.RegisterClient(internal.OAuthClientRequest{
+			Name: "ci", Confidential: true, GrantTypes: []string{internal.GrantClientCredentials}, Scopes: []string{"users:write"}, ServiceAccountID: 4,
+		})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, response.ClientSecret)
+	})
+
+	t.Run("register public client without secret", func(t *testing.T) {
+		data.EXPECT().CreateClient(gomock.Any(), "").DoAndReturn(func(client internal.OAuthClient, secret string) (internal.OAuthClient, error) {
+			return client, nil
+		}).Times(1)
+		response, err := handler.RegisterClient(internal.OAuthClientRequest{
+			Name: "web", RedirectURIs: webClient.RedirectURIs, GrantTypes: webClient.GrantTypes, Scopes: webClient.Scopes,
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, response.ClientSecret)
+	})
+
+	tests := []struct {
+		name    string
+		request internal.OAuthClientRequest
+	}{
+		{"error on missing scopes", internal.OAuthClientRequest{Name: "web", GrantTypes: []string{internal.GrantAuthorizationCode}, RedirectURIs: webClient.RedirectURIs}},
+		{"error on invalid scope", internal.OAuthClientRequest{Name: "web", GrantTypes: []string{internal.GrantAuthorizationCode}, RedirectURIs: webClient.RedirectURIs, Scopes: []string{"secrets:read"}}},
+		{"error on unknown grant type", internal.OAuthClientRequest{Name: "web", GrantTypes: []string{"password"}, Scopes: []string{"users:read"}}},
+		{"error on missing redirect uri", internal.OAuthClientRequest{Name: "web", GrantTypes: []string{internal.GrantAuthorizationCode}, Scopes: []string{"users:read"}}},
+		{"error on relative redirect uri", internal.OAuthClientRequest{Name: "web", GrantTypes: []string{internal.GrantAuthorizationCode}, RedirectURIs: []string{"/callback"}, Scopes: []string{"users:read"}}},
+		{"error on public client credentials", internal.OAuthClientRequest{Name: "ci", GrantTypes: []string{internal.GrantClientCredentials}, Scopes: []string{"users:read"}, ServiceAccountID: 4}},
+		{"error on client credentials without service account", internal.OAuthClientRequest{Name: "ci", Confidential: true, GrantTypes: []string{internal.GrantClientCredentials}, Scopes: []string{"users:read"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler.RegisterClient(tt.request)
+			assert.True(t, hasCode(err, serviceerror.InvalidOAuthClientRequest))
+		})
+	}
+
+	t.Run("error on person as service account", func(t *testing.T) {
+		users.EXPECT().GetUser(uint(5)).Return(internal.UserResponse{ID: 5, Type: internal.UserPerson}, nil).Times(1)
+		_, err := handler.RegisterClient(internal.OAuthClientRequest{
+			Name: "ci", Confidential: true, GrantTypes: []string{internal.GrantClientCredentials}, Scopes: []string{"users:write"}, ServiceAccountID: 5,
+		})
+		assert.True(t, hasCode(err, serviceerror.InvalidOAuthClientRequest))
+	})
+}
+
+func TestOAuthAuthorize(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockOAuthData(mockCtrl)
+	handler := service.NewOAuthService(data, nil, nil, nil, service.OAuthOptions{})
+	request := internal.OAuthAuthorizeRequest{
+		UserID: 1, ResponseType: "code", ClientID: "web", CodeChallenge: codeChallenge, CodeChallengeMethod: "S256",
+	}
+
+	t.Run("resolve redirect uri", func(t *testing.T) {
+		data.EXPECT().GetClient("web").Return(webClient, nil).Times(3)
+		redirectURI, err := handler.RedirectURI("web", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://app.example.com/callback", redirectURI)
+		_, err = handler.RedirectURI("web", "https://app.example.com/callback")
+		assert.NoError(t, err)
+		_, err = handler.RedirectURI("web", "https://evil.example.com/callback")
+		assert.True(t, hasCode(err, serviceerror.OAuthInvalidRequest))
+	})
+
+	t.Run("fail on unknown client", func(t *testing.T) {
+		data.EXPECT().GetClient("other").Return(internal.OAuthClient{}, serviceerror.NewServiceError(serviceerror.OAuthClientNotFound, errors.New("test"))).Times(1)
+		_, err := handler.RedirectURI("other", "")
+		assert.True(t, hasCode(err, serviceerror.OAuthInvalidRequest))
+	})
+
+	t.Run("issue code for the requested scopes", func(t *testing.T) {
+		data.EXPECT().GetClient("web").Return(webClient, nil).Times(1)
+		var codeHash string
+		data.EXPECT().CreateAuthorizationCode(gomock.Any()).DoAndReturn(func(code internal.OAuthAuthorizationCode) error {
+			assert.Equal(t, uint(1), code.UserID)
+			assert.Equal(t, []string{"users:read"}, code.Scopes)
+			assert.Equal(t, codeChallenge, code.CodeChallenge)
+			assert.True(t, code.ExpiresAt.After(time.Now()))
+			codeHash = code.CodeHash
+			return nil
+		}).Times(1)
+		withScope := request
+		withScope.Scope = "users:read"
+		code, err := handler.Authorize(withScope)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, code)
+		assert.NotEqual(t, code, codeHash)
+	})
+
+	tests := []struct {
+		name   string
+		change func(request *internal.OAuthAuthorizeRequest)
+		code   serviceerror.ErrorCode
+	}{
+		{"error on unsupported response type", func(r *internal.OAuthAuthorizeRequest) { r.ResponseType = "token" }, serviceerror.OAuthUnsupportedResponse},
+		{"error on missing code challenge", func(r *internal.OAuthAuthorizeRequest) { r.CodeChallenge = "" }, serviceerror.OAuthInvalidRequest},
+		{"error on plain code challenge", func(r *internal.OAuthAuthorizeRequest) { r.CodeChallengeMethod = "plain" }, serviceerror.OAuthInvalidRequest},
+		{"error on scope not granted", func(r *internal.OAuthAuthorizeRequest) { r.Scope = "users:write" }, serviceerror.OAuthInvalidScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data.EXPECT().GetClient("web").Return(webClient, nil).Times(1)
+			changed := request
+			tt.change(&changed)
+			_, err := handler.Authorize(changed)
+			assert.True(t, hasCode(err, tt.code))
+		})
+	}
+
+	t.Run("error on client without authorization code grant", func(t *testing.T) {
+		client := serviceClient
+		client.RedirectURIs = []string{"https://ci.example.com"}
+		data.EXPECT().GetClient("ci").Return(client, nil).Times(1)
+		changed := request
+		changed.ClientID = "ci"
+		_, err := handler.Authorize(changed)
+		assert.True(t, hasCode(err, serviceerror.OAuthUnauthorizedClient))
+	})
+}
+
+func TestOAuthToken(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockOAuthData(mockCtrl)
+	users := mock.NewMockUserData(mockCtrl)
+	accessTokens := mock.NewMockAccessTokenData(mockCtrl)
+	oidc := mock.NewMockOIDCService(mockCtrl)
+	handler := service.NewOAuthService(data, users, accessTokens, oidc, service.OAuthOptions{TokenTTL: time.Hour})
+	code := internal.OAuthAuthorizationCode{ClientID: "web", UserID: 1, Scopes: []string{"users:read"}, CodeChallenge: codeChallenge}
+	request := internal.OAuthTokenRequest{GrantType: internal.GrantAuthorizationCode, ClientID: "web", Code: "code", CodeVerifier: codeVerifier}
+
+	t.Run("exchange code with verifier", func(t *testing.T) {
+		data.EXPECT().GetClient("web").Return(webClient, nil).Times(1)
+		data.EXPECT().ConsumeAuthorizationCode(gomock.Any()).Return(code, nil).Times(1)
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Status: internal.StatusActive}, nil).Times(1)
+		accessTokens.EXPECT().CreateAccessToken(gomock.Any()).DoAndReturn(func(token internal.AccessToken) (internal.AccessTokenResponse, error) {
+			assert.Equal(t, uint(1), token.UserID)
+			assert.Equal(t, "web", token.ClientID)
+			assert.Equal(t, []string{"users:read"}, token.Scopes)
+			assert.NotNil(t, token.ExpiresAt)
+			return internal.AccessTokenResponse{}, nil
+		}).Times(1)
+		response, err := handler.Token(request)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(response.AccessToken, internal.OAuthTokenPrefix))
+		assert.Equal(t, "Bearer", response.TokenType)
+		assert.Equal(t, int64(3600), response.ExpiresIn)
+		assert.Equal(t, "users:read", response.Scope)
+	})
+
+	t.Run("issue id token for openid scope", func(t *testing.T) {
+		openID := code
+		openID.Scopes = []string{internal.ScopeOpenID, internal.ScopeEmail}
+		openID.Nonce = "nonce"
+		data.EXPECT().GetClient("web").Return(webClient, nil).Times(1)
+		data.EXPECT().ConsumeAuthorizationCode(gomock.Any()).Return(openID, nil).Times(1)
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Status: internal.StatusActive}, nil).Times(1)
+		accessTokens.EXPECT().CreateAccessToken(gomock.Any()).Return(internal.AccessTokenResponse{}, nil).Times(1)
+		oidc.EXPECT().IDToken(internal.IDTokenRequest{UserID: 1, ClientID: "web", Nonce: "nonce", Scopes: openID.Scopes}).Return("id_token", nil).Times(1)
+		response, err := handler.Token(request)
+		assert.NoError(t, err)
+		assert.Equal(t, "id_token", response.IDToken)
+		assert.Equal(t, "openid email", response.Scope)
+	})
+
+	t.Run("fail on wrong verifier", func(t *testing.T) {
+		data.EXPECT().GetClient("web").Return(webClient, nil).Times(1)
+		data.EXPECT().ConsumeAuthorizationCode(gomock.Any()).Return(code, nil).Times(1)
+		wrong := request
+		wrong.CodeVerifier = strings.Repeat("a", 43)
+		_, err := handler.Token(wrong)
+		assert.True(t, hasCode(err, serviceerror.OAuthInvalidGrant))
+	})
+
+	t.Run("fail on other redirect uri", func(t *testing.T) {
+		data.EXPECT().GetClient("web").Return(webClient, nil).Times(1)
+		data.EXPECT().ConsumeAuthorizationCode(gomock.Any()).Return(code, nil).Times(1)
+		other := request
+		other.RedirectURI = "https://app.example.com/callback"
+		_, err := handler.Token(other)
+		assert.True(t, hasCode(err, serviceerror.OAuthInvalidGrant))
+	})
+
+	t.Run("fail on code of inactive user", func(t *testing.T) {
+		data.EXPECT().GetClient("web").Return(webClient, nil).Times(1)
+		data.EXPECT().ConsumeAuthorizationCode(gomock.Any()).Return(code, nil).Times(1)
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Status: internal.StatusSuspended}, nil).Times(1)
+		_, err := handler.Token(request)
+		assert.True(t, hasCode(err, serviceerror.OAuthInvalidGrant))
+	})
+
+	t.Run("issue token to service account", func(t *testing.T) {
+		data.EXPECT().AuthenticateClient("ci", "secret").Return(serviceClient, nil).Times(1)
+		users.EXPECT().GetUser(uint(4)).Return(internal.UserResponse{ID: 4, Type: internal.UserServiceAccount, Status: internal.StatusActive}, nil).Times(1)
+		accessTokens.EXPECT().CreateAccessToken(gomock.Any()).DoAndReturn(func(token internal.AccessToken) (internal.AccessTokenResponse, error) {
+			assert.Equal(t, uint(4), token.UserID)
+			assert.Equal(t, []string{"users:write"}, token.Scopes)
+			return internal.AccessTokenResponse{}, nil
+		}).Times(1)
+		_, err := handler.Token(internal.OAuthTokenRequest{GrantType: internal.GrantClientCredentials, ClientID: "ci", ClientSecret: "secret"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail on confidential client without secret", func(t *testing.T) {
+		data.EXPECT().GetClient("ci").Return(serviceClient, nil).Times(1)
+		_, err := handler.Token(internal.OAuthTokenRequest{GrantType: internal.GrantClientCredentials, ClientID: "ci"})
+		assert.True(t, hasCode(err, serviceerror.OAuthInvalidClient))
+	})
+
+	t.Run("fail on grant the client can't use", func(t *testing.T) {
+		data.EXPECT().GetClient("web").Return(webClient, nil).Times(1)
+		_, err := handler.Token(internal.OAuthTokenRequest{GrantType: internal.GrantClientCredentials, ClientID: "web"})
+		assert.True(t, hasCode(err, serviceerror.OAuthUnauthorizedClient))
+	})
+
+	t.Run("fail on unsupported grant type", func(t *testing.T) {
+		_, err := handler.Token(internal.OAuthTokenRequest{GrantType: "password", ClientID: "web"})
+		assert.True(t, hasCode(err, serviceerror.OAuthUnsupportedGrantType))
+	})
+}
+
+func TestOAuthIntrospect(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockOAuthData(mockCtrl)
+	users := mock.NewMockUserData(mockCtrl)
+	accessTokens := mock.NewMockAccessTokenData(mockCtrl)
+	handler := service.NewOAuthService(data, users, accessTokens, nil, service.OAuthOptions{})
+	createdAt := time.Now().Add(-time.Minute)
+	expiresAt := createdAt.Add(time.Hour)
+	token := internal.AccessToken{UserID: 1, ClientID: "web", Scopes: []string{"users:read"}, CreatedAt: createdAt, ExpiresAt: &expiresAt}
+
+	t.Run("describe active token", func(t *testing.T) {
+		data.EXPECT().AuthenticateClient("ci", "secret").Return(serviceClient, nil).Times(1)
+		accessTokens.EXPECT().GetAccessTokenByHash(gomock.Any()).Return(token, nil).Times(1)
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Status: internal.StatusActive}, nil).Times(1)
+		response, err := handler.Introspect("ci", "secret", "oat_token")
+		assert.NoError(t, err)
+		assert.Equal(t, internal.OAuthIntrospection{
+			Active: true, Scope: "users:read", ClientID: "web", Sub: "1", TokenType: "Bearer", Exp: expiresAt.Unix(), Iat: createdAt.Unix(),
+		}, response)
+	})
+
+	t.Run("report expired token inactive", func(t *testing.T) {
+		data.EXPECT().AuthenticateClient("ci", "secret").Return(serviceClient, nil).Times(1)
+		expired := token
+		past := time.Now().Add(-time.Second)
+		expired.ExpiresAt = &past
+		accessTokens.EXPECT().GetAccessTokenByHash(gomock.Any()).Return(expired, nil).Times(1)
+		response, err := handler.Introspect("ci", "secret", "oat_token")
+		assert.NoError(t, err)
+		assert.False(t, response.Active)
+	})
+
+	t.Run("report unknown token inactive", func(t *testing.T) {
+		data.EXPECT().AuthenticateClient("ci", "secret").Return(serviceClient, nil).Times(1)
+		accessTokens.EXPECT().GetAccessTokenByHash(gomock.Any()).
+			Return(internal.AccessToken{}, serviceerror.NewServiceError(serviceerror.AccessTokenNotFound, errors.New("test"))).Times(1)
+		response, err := handler.Introspect("ci", "secret", "oat_token")
+		assert.NoError(t, err)
+		assert.False(t, response.Active)
+	})
+
+	t.Run("fail on public client", func(t *testing.T) {
+		data.EXPECT().GetClient("web").Return(webClient, nil).Times(1)
+		_, err := handler.Introspect("web", "", "oat_token")
+		assert.True(t, hasCode(err, serviceerror.OAuthInvalidClient))
+	})
+}
+
+func TestOAuthRevoke(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockOAuthData(mockCtrl)
+	accessTokens := mock.NewMockAccessTokenData(mockCtrl)
+	handler := service.NewOAuthService(data, nil, accessTokens, nil, service.OAuthOptions{})
+
+	data.EXPECT().GetClient("web").Return(webClient, nil).Times(1)
+	accessTokens.EXPECT().RevokeClientAccessToken("web", gomock.Any()).Return(nil).Times(1)
+	assert.NoError(t, handler.Revoke("web", "", "oat_token"))
+
+	data.EXPECT().AuthenticateClient("ci", "wrong").
+		Return(internal.OAuthClient{}, serviceerror.NewServiceError(serviceerror.OAuthInvalidClient, errors.New("test"))).Times(1)
+	assert.True(t, hasCode(handler.Revoke("ci", "wrong", "oat_token"), serviceerror.OAuthInvalidClient))
+}
+
+func TestAuthorizeOAuthAdmin(t *testing.T) {
+	handler := service.NewOAuthService(nil, nil, nil, nil, service.OAuthOptions{})
+	assert.NoError(t, handler.AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}))
+	assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 1}), serviceerror.Forbidden))
+	assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true, Scopes: []string{"users:write"}}), serviceerror.Forbidden))
+}
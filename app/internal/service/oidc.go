@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/signing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+// oidcScopes are the OpenID Connect scopes a client may be registered with.
+var oidcScopes = map[string]bool{
+	internal.ScopeOpenID:  true,
+	internal.ScopeProfile: true,
+	internal.ScopeEmail:   true,
+	internal.ScopeGroups:  true,
+}
+
+// OIDCOptions configure the OpenID Connect provider, Issuer is the URL it is
+// served at and IDTokenTTL how long an ID token is valid.
+type OIDCOptions struct {
+	Issuer     string
+	IDTokenTTL time.Duration
+}
+
+type oidcService struct {
+	users   internal.UserData
+	groups  internal.GroupData
+	keys    internal.SigningKeys
+	options OIDCOptions
+}
+
+func NewOIDCService(users internal.UserData, groups internal.GroupData, keys internal.SigningKeys, options OIDCOptions) *oidcService {
+	if options.IDTokenTTL <= 0 {
+		options.IDTokenTTL = time.Hour
+	}
+	options.Issuer = strings.TrimSuffix(options.Issuer, "/")
+	return &oidcService{
+		users:   users,
+		groups:  groups,
+		keys:    keys,
+		options: options,
+	}
+}
+
+// idTokenClaims are the claims of an ID token, the user info is flattened
+// into them.
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string   `json:"nonce,omitempty"`
+	Name          string   `json:"name,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	EmailVerified *bool    `json:"email_verified,omitempty"`
+	Groups        []string `json:"groups,omitempty"`
+}
+
+// Discovery describes the provider, the endpoints are those of the API below
+// the issuer.
+func (o *oidcService) Discovery() (response internal.OIDCDiscovery, err error) {
+	keys, err := o.keys.Verification()
+	if err != nil {
+		return response, err
+	}
+	algorithms := []string{}
+	for _, key := range keys {
+		if !containsString(algorithms, key.Algorithm) {
+			algorithms = append(algorithms, key.Algorithm)
+		}
+	}
+	api := o.options.Issuer + "/api/v1/oauth"
+	return internal.OIDCDiscovery{
+		Issuer:                            o.options.Issuer,
+		AuthorizationEndpoint:             api + "/authorize",
+		TokenEndpoint:                     api + "/token",
+		UserinfoEndpoint:                  api + "/userinfo",
+		JwksURI:                           o.options.Issuer + "/jwks.json",
+		IntrospectionEndpoint:             api + "/introspect",
+		RevocationEndpoint:                api + "/revoke",
+		ScopesSupported:                   []string{internal.ScopeOpenID, internal.ScopeProfile, internal.ScopeEmail, internal.ScopeGroups},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{internal.GrantAuthorizationCode, internal.GrantClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algorithms,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "nonce", "name", "email", "email_verified", "groups"},
+	}, nil
+}
+
+// JWKS publishes every key ID tokens may be verified with, so tokens signed
+// before a rotation stay valid.
+func (o *oidcService) JWKS() (response internal.JSONWebKeySet, err error) {
+	keys, err := o.keys.Verification()
+	if err != nil {
+		return response, err
+	}
+	response.Keys = make([]internal.JSONWebKey, 0, len(keys))
+	for _, key := range keys {
+		jwk, err := signing.PublicJWK(key.Key.Public())
+		if err != nil {
+			return response, errors.Wrapf(err, "publish key %s failed", key.ID)
+		}
+		response.Keys = append(response.Keys, jwk)
+	}
+	return response, nil
+}
+
+// IDToken signs an ID token for the client, the kid header names the key it
+// is signed with.
+func (o *oidcService) IDToken(request internal.IDTokenRequest) (token string, err error) {
+	if request.UserID == 0 || request.ClientID == "" {
+		return token, serviceerror.NewServiceError(serviceerror.OAuthInvalidRequest, errors.New("missing id token fields"))
+	}
+	info, err := o.userInfo(request.UserID, request.Scopes)
+	if err != nil {
+		return token, err
+	}
+	key, err := o.keys.Signing()
+	if err != nil {
+		return token, err
+	}
+	now := time.Now()
+	claims := idTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    o.options.Issuer,
+			Subject:   info.Sub,
+			Audience:  jwt.ClaimStrings{request.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(o.options.IDTokenTTL)),
+		},
+		Nonce:         request.Nonce,
+		Name:          info.Name,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		Groups:        info.Groups,
+	}
+	method := jwt.GetSigningMethod(key.Algorithm)
+	if method == nil {
+		return token, fmt.Errorf("signing algorithm %s is not supported", key.Algorithm)
+	}
+	jwtToken := jwt.NewWithClaims(method, claims)
+	jwtToken.Header["kid"] = key.ID
+	token, err = jwtToken.SignedString(key.Key)
+	if err != nil {
+		return token, errors.Wrap(err, "sign id token failed")
+	}
+	return token, nil
+}
+
+// UserInfo returns the claims the scopes of the caller's access token allow,
+// the token must be granted the openid scope.
+func (o *oidcService) UserInfo(caller internal.Caller) (response internal.UserInfo, err error) {
+	if !containsString(caller.Scopes, internal.ScopeOpenID) {
+		return response, serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access token isn't granted the openid scope"))
+	}
+	return o.userInfo(caller.UserID, caller.Scopes)
+}
+
+func (o *oidcService) userInfo(userID uint, scopes []string) (response internal.UserInfo, err error) {
+	user, err := o.users.GetUser(userID)
+	if err != nil {
+		return response, err
+	}
+	if err := checkActive(user); err != nil {
+		return response, err
+	}
+	response.Sub = strconv.FormatUint(uint64(user.ID), 10)
+	if containsString(scopes, internal.ScopeProfile) {
+		response.Name = user.Name
+	}
+	if containsString(scopes, internal.ScopeEmail) {
+		response.Email = user.Email
+		response.EmailVerified = &user.EmailVerified
+	}
+	if containsString(scopes, internal.ScopeGroups) {
+		memberships, err := o.groups.GetMemberships(user.ID)
+		if err != nil {
+			return response, err
+		}
+		now := time.Now()
+		response.Groups = []string{}
+		for _, membership := range memberships {
+			if membership.RemovedAt == nil && (membership.StartsAt == nil || !membership.StartsAt.After(now)) &&
+				(membership.ExpiresAt == nil || membership.ExpiresAt.After(now)) {
+				response.Groups = append(response.Groups, membership.GroupName)
+			}
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,147 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/signing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// testKeys are signing keys that only change when rotated, the first signs
+// and all verify.
+type testKeys struct {
+	keys []internal.SigningKey
+}
+
+func newTestKeys(t *testing.T, algorithms ...string) *testKeys {
+	keys := &testKeys{}
+	for i := len(algorithms) - 1; i >= 0; i-- {
+		keys.rotate(t, algorithms[i])
+	}
+	return keys
+}
+
+// rotate makes a new key of the algorithm sign.
+func (k *testKeys) rotate(t *testing.T, algorithm string) {
+	signer, err := signing.Generate(algorithm)
+	assert.NoError(t, err)
+	key, err := signing.NewKey(signer)
+	assert.NoError(t, err)
+	k.keys = append([]internal.SigningKey{key}, k.keys...)
+}
+
+func (k *testKeys) Signing() (key internal.SigningKey, err error) {
+	return k.keys[0], nil
+}
+
+func (k *testKeys) Verification() (keys []internal.SigningKey, err error) {
+	return k.keys, nil
+}
+
+func (k *testKeys) VerificationKey(kid string) (key internal.SigningKey, err error) {
+	for _, key := range k.keys {
+		if key.ID == kid {
+			return key, nil
+		}
+	}
+	return key, errors.New("signing key is unknown")
+}
+
+func TestIDToken(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserData(mockCtrl)
+	groups := mock.NewMockGroupData(mockCtrl)
+	keys := newTestKeys(t, signing.RS256)
+	signingKey, _ := keys.Signing()
+	handler := service.NewOIDCService(users, groups, keys, service.OIDCOptions{Issuer: "https://id.example.com/"})
+	user := internal.UserResponse{ID: 1, Name: "test", Email: "test@gmail.com", EmailVerified: true, Status: internal.StatusActive}
+	removedAt := time.Now().Add(-time.Hour)
+
+	t.Run("sign claims of the scopes", func(t *testing.T) {
+		users.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+		groups.EXPECT().GetMemberships(uint(1)).Return([]internal.Membership{
+			{GroupID: 2, GroupName: "admins"},
+			{GroupID: 3, GroupName: "former", RemovedAt: &removedAt},
+		}, nil).Times(1)
+		token, err := handler.IDToken(internal.IDTokenRequest{
+			UserID: 1, ClientID: "web", Nonce: "nonce", Scopes: []string{internal.ScopeOpenID, internal.ScopeEmail, internal.ScopeGroups},
+		})
+		assert.NoError(t, err)
+		claims := jwt.MapClaims{}
+		parsed, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+			assert.Equal(t, signingKey.ID, token.Header["kid"])
+			return signingKey.Key.Public(), nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "RS256", parsed.Method.Alg())
+		assert.Equal(t, "https://id.example.com", claims["iss"])
+		assert.Equal(t, "1", claims["sub"])
+		assert.Equal(t, []interface{}{"web"}, claims["aud"])
+		assert.Equal(t, "nonce", claims["nonce"])
+		assert.Equal(t, "test@gmail.com", claims["email"])
+		assert.Equal(t, true, claims["email_verified"])
+		assert.Equal(t, []interface{}{"admins"}, claims["groups"])
+		assert.NotContains(t, claims, "name")
+	})
+
+	t.Run("fail on inactive user", func(t *testing.T) {
+		users.EXPECT().GetUser(uint(2)).Return(internal.UserResponse{ID: 2, Status: internal.StatusDisabled}, nil).Times(1)
+		_, err := handler.IDToken(internal.IDTokenRequest{UserID: 2, ClientID: "web", Scopes: []string{internal.ScopeOpenID}})
+		assert.True(t, hasCode(err, serviceerror.UserInactive))
+	})
+}
+
+func TestUserInfo(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserData(mockCtrl)
+	handler := service.NewOIDCService(users, nil, nil, service.OIDCOptions{})
+	verified := false
+
+	t.Run("answer claims of the scopes", func(t *testing.T) {
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Name: "test", Email: "test@gmail.com", Status: internal.StatusActive}, nil).Times(1)
+		response, err := handler.UserInfo(internal.Caller{UserID: 1, Scopes: []string{internal.ScopeOpenID, internal.ScopeProfile, internal.ScopeEmail}})
+		assert.NoError(t, err)
+		assert.Equal(t, internal.UserInfo{Sub: "1", Name: "test", Email: "test@gmail.com", EmailVerified: &verified}, response)
+	})
+
+	t.Run("fail without openid scope", func(t *testing.T) {
+		_, err := handler.UserInfo(internal.Caller{UserID: 1, Scopes: []string{"users:read"}})
+		assert.True(t, hasCode(err, serviceerror.Forbidden))
+		_, err = handler.UserInfo(internal.Caller{UserID: 1})
+		assert.True(t, hasCode(err, serviceerror.Forbidden))
+	})
+
+	t.Run("fail on unknown user", func(t *testing.T) {
+		users.EXPECT().GetUser(uint(2)).Return(internal.UserResponse{}, serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))).Times(1)
+		_, err := handler.UserInfo(internal.Caller{UserID: 2, Scopes: []string{internal.ScopeOpenID}})
+		assert.True(t, hasCode(err, serviceerror.UserNotFound))
+	})
+}
+
+func TestJWKS(t *testing.T) {
+	keys := newTestKeys(t, signing.RS256, signing.EdDSA)
+	handler := service.NewOIDCService(nil, nil, keys, service.OIDCOptions{Issuer: "https://id.example.com"})
+
+	response, err := handler.JWKS()
+	assert.NoError(t, err)
+	if assert.Len(t, response.Keys, 2) {
+		assert.Equal(t, "RSA", response.Keys[0].Kty)
+		assert.Equal(t, "OKP", response.Keys[1].Kty)
+	}
+
+	discovery, err := handler.Discovery()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://id.example.com/jwks.json", discovery.JwksURI)
+	assert.Equal(t, "https://id.example.com/api/v1/oauth/token", discovery.TokenEndpoint)
+	assert.Equal(t, []string{signing.RS256, signing.EdDSA}, discovery.IDTokenSigningAlgValuesSupported)
+}
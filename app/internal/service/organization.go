@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+)
+
+type organizationService struct {
+	data internal.OrganizationData
+}
+
+func NewOrganizationService(data internal.OrganizationData) *organizationService {
+	return &organizationService{
+		data: data,
+	}
+}
+
+func (o *organizationService) CreateOrganization(request internal.OrganizationRequest) (response internal.Organization, err error) {
+	return o.data.CreateOrganization(request)
+}
+
+func (o *organizationService) GetOrganization(id uint) (response internal.Organization, err error) {
+	if id == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidOrganizationRequest, errors.New("organization_id is 0 for get organization"))
+	}
+	return o.data.GetOrganization(id)
+}
+
+func (o *organizationService) GetOrganizations(page uint, perPage uint) (response internal.OrganizationsResponse, err error) {
+	if page <= 0 || perPage == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidOrganizationRequest, fmt.Errorf("page %d  or per page %d is not valid", page, perPage))
+	}
+	offset := perPage * (page - 1)
+	response, err = o.data.GetOrganizations(offset, perPage)
+	response.Page = page
+	response.PerPage = perPage
+	return response, err
+}
+
+// AuthorizeAdmin lets only admins create organizations.
+func (o *organizationService) AuthorizeAdmin(caller internal.Caller) (err error) {
+	if !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d is not an admin", caller.UserID))
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package service_test
+
+import (
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrganizations(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockOrganizationData(mockCtrl)
+	handler := service.NewOrganizationService(data)
+
+	t.Run("get page of organizations", func(t *testing.T) {
+		data.EXPECT().GetOrganizations(uint(10), uint(10)).Return(internal.OrganizationsResponse{Total: 11}, nil).Times(1)
+		response, err := handler.GetOrganizations(2, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), response.Page)
+		assert.Equal(t, uint(10), response.PerPage)
+	})
+
+	t.Run("fail on invalid page", func(t *testing.T) {
+		_, err := handler.GetOrganizations(0, 10)
+		assert.True(t, hasCode(err, serviceerror.InvalidOrganizationRequest))
+	})
+
+	t.Run("fail on default organization", func(t *testing.T) {
+		_, err := handler.GetOrganization(0)
+		assert.True(t, hasCode(err, serviceerror.InvalidOrganizationRequest))
+	})
+}
+
+func TestAuthorizeOrganizationAdmin(t *testing.T) {
+	handler := service.NewOrganizationService(nil)
+	assert.NoError(t, handler.AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}))
+	assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 1}), serviceerror.Forbidden))
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/webauthn"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type PasskeyOptions struct {
+	RelyingParty *webauthn.RelyingParty
+	Keys         internal.SigningKeys
+	TokenTTL     time.Duration
+}
+
+type passkeyService struct {
+	users  internal.UserData
+	data   internal.PasskeyData
+	rp     *webauthn.RelyingParty
+	tokens tokenIssuer
+}
+
+func NewPasskeyService(users internal.UserData, data internal.PasskeyData, options PasskeyOptions) *passkeyService {
+	return &passkeyService{
+		users:  users,
+		data:   data,
+		rp:     options.RelyingParty,
+		tokens: newTokenIssuer(options.Keys, options.TokenTTL),
+	}
+}
+
+func (p *passkeyService) BeginRegistration(userID uint) (response webauthn.CreationOptions, err error) {
+	user, err := p.users.GetUser(userID)
+	if err != nil {
+		return response, err
+	}
+	if user.Type == internal.UserServiceAccount {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidPasskey, fmt.Errorf("service account %d can't register passkeys", userID))
+	}
+	passkeys, err := p.data.GetPasskeys(userID)
+	if err != nil {
+		return response, err
+	}
+	challenge, err := p.newSession(userID, webauthn.CeremonyCreate)
+	if err != nil {
+		return response, err
+	}
+	entity := webauthn.UserEntity{
+		ID:          userHandle(user.ID),
+		Name:        user.Email,
+		DisplayName: user.Name,
+	}
+	return p.rp.CreationOptions(challenge, entity, descriptors(passkeys)), err
+}
+
+func (p *passkeyService) FinishRegistration(userID uint, name string, registration webauthn.Registration) (response internal.PasskeyResponse, err error) {
+	clientData, err := webauthn.ParseClientData(registration.Response.ClientDataJSON)
+	if err != nil {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidPasskey, err)
+	}
+	sessionUserID, err := p.data.ConsumePasskeySession(webauthn.CeremonyCreate, clientData.Challenge)
+	if err != nil {
+		return response, err
+	}
+	if sessionUserID != userID {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidPasskey, errors.Errorf("registration was not started for user %d", userID))
+	}
+	credential, err := p.rp.VerifyRegistration(registration, clientData.Challenge)
+	if err != nil {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidPasskey, err)
+	}
+	return p.data.CreatePasskey(internal.Passkey{
+		UserID:       userID,
+		CredentialID: credential.ID,
+		PublicKey:    credential.PublicKey,
+		SignCount:    credential.SignCount,
+		Transports:   credential.Transports,
+		Name:         name,
+	})
+}
+
+func (p *passkeyService) GetPasskeys(userID uint) (response internal.PasskeysResponse, err error) {
+	passkeys, err := p.data.GetPasskeys(userID)
+	if err != nil {
+		return response, err
+	}
+	response.Passkeys = make([]internal.PasskeyResponse, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		response.Passkeys = append(response.Passkeys, internal.PasskeyResponse{
+			ID:         passkey.ID,
+			Name:       passkey.Name,
+			CreatedAt:  passkey.CreatedAt,
+			LastUsedAt: passkey.LastUsedAt,
+		})
+	}
+	return response, err
+}
+
+func (p *passkeyService) RenamePasskey(userID uint, id uint, name string) (err error) {
+	return p.data.RenamePasskey(userID, id, name)
+}
+
+func (p *passkeyService) DeletePasskey(userID uint, id uint) (err error) {
+	return p.data.DeletePasskey(userID, id)
+}
+
+// AuthorizePasskeys lets users manage their own passkeys and admins those of
+// everyone. A caller using an access token can't, so a token never adds a
+// way to log in.
+func (p *passkeyService) AuthorizePasskeys(caller internal.Caller, userID uint) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't manage passkeys"))
+	}
+	if caller.UserID != userID && !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d can't manage the passkeys of user %d", caller.UserID, userID))
+	}
+	return nil
+}
+
+// BeginLogin starts an assertion for discoverable passkeys. The options never
+// list the user's passkeys, they would tell which accounts exist; an email
+// only binds the session to its user.
+func (p *passkeyService) BeginLogin(email string) (response webauthn.RequestOptions, err error) {
+	var userID uint
+	if email != "" {
+		user, err := p.users.GetUserByEmail(email)
+		if err != nil && !hasErrorCode(err, serviceerror.UserNotFound) {
+			return response, err
+		}
+		userID = user.ID
+	}
+	challenge, err := p.newSession(userID, webauthn.CeremonyGet)
+	if err != nil {
+		return response, err
+	}
+	return p.rp.RequestOptions(challenge, nil), err
+}
+
+// FinishLogin verifies the assertion and issues an access token to an active
+// user. Every rejection of the assertion is reported as invalid credentials.
+func (p *passkeyService) FinishLogin(assertion webauthn.Assertion) (response internal.LoginResponse, err error) {
+	clientData, err := webauthn.ParseClientData(assertion.Response.ClientDataJSON)
+	if err != nil {
+		return response, loginFailed(err)
+	}
+	sessionUserID, err := p.data.ConsumePasskeySession(webauthn.CeremonyGet, clientData.Challenge)
+	if err != nil {
+		return response, loginFailed(err)
+	}
+	passkey, err := p.data.GetPasskeyByCredentialID(assertion.ID)
+	if err != nil {
+		return response, loginFailed(err)
+	}
+	if sessionUserID != 0 && sessionUserID != passkey.UserID {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("passkey belongs to another user"))
+	}
+	if assertion.Response.UserHandle != "" && assertion.Response.UserHandle != userHandle(passkey.UserID) {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidCredentials, errors.New("user handle does not match the passkey"))
+	}
+	signCount, err := p.rp.VerifyAssertion(assertion, clientData.Challenge, passkey.PublicKey, passkey.SignCount)
+	if err != nil {
+		log.WithError(err).WithField("user", passkey.UserID).Warn("passkey assertion rejected")
+		return response, loginFailed(err)
+	}
+	err = p.data.UsePasskey(passkey.ID, signCount)
+	if err != nil {
+		return response, err
+	}
+	user, err := p.users.GetUser(passkey.UserID)
+	if err != nil {
+		return response, err
+	}
+	err = checkActive(user)
+	if err != nil {
+		return response, err
+	}
+	return p.tokens.issue(user)
+}
+
+func (p *passkeyService) newSession(userID uint, ceremony string) (challenge string, err error) {
+	challenge, err = webauthn.NewChallenge()
+	if err != nil {
+		return challenge, errors.Wrap(err, "generate passkey challenge failed")
+	}
+	err = p.data.CreatePasskeySession(userID, ceremony, challenge, time.Now().Add(p.rp.Timeout()))
+	return challenge, err
+}
+
+// loginFailed turns rejections into invalid credentials so a failed login
+// doesn't tell which check failed, internal errors are passed through.
+func loginFailed(err error) error {
+	if hasErrorCode(err, serviceerror.InvalidCredentials) {
+		return err
+	}
+	var srvError *serviceerror.ServiceError
+	if errors.As(err, &srvError) || errors.Is(err, webauthn.ErrInvalidResponse) {
+		return serviceerror.NewServiceError(serviceerror.InvalidCredentials, err)
+	}
+	return err
+}
+
+// userHandle is the WebAuthn user.id, it carries the user id only so no
+// personal data ends up on the authenticator.
+func userHandle(userID uint) string {
+	return webauthn.Encode([]byte(strconv.FormatUint(uint64(userID), 10)))
+}
+
+func descriptors(passkeys []internal.Passkey) []webauthn.CredentialDescriptor {
+	descriptors := make([]webauthn.CredentialDescriptor, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		descriptors = append(descriptors, webauthn.CredentialDescriptor{
+			Type:       "public-key",
+			ID:         passkey.CredentialID,
+			Transports: passkey.Transports,
+		})
+	}
+	return descriptors
+}
@@ -0,0 +1,190 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/signing"
+	"usermanagement/app/internal/webauthn"
+	"usermanagement/app/internal/webauthn/webauthntest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const passkeyOrigin = "https://login.example.com"
+
+func newPasskeyService(t *testing.T, users internal.UserData, data internal.PasskeyData) internal.PasskeyService {
+	return service.NewPasskeyService(users, data, service.PasskeyOptions{
+		RelyingParty: webauthn.New(webauthn.Config{RPID: "example.com", Origins: []string{passkeyOrigin}}),
+		Keys:         newTestKeys(t, signing.EdDSA),
+	})
+}
+
+// registerPasskey runs a registration ceremony with the software authenticator
+// and returns the passkey the service stored.
+func registerPasskey(t *testing.T, handler internal.PasskeyService, users *mock.MockUserData, data *mock.MockPasskeyData,
+	authenticator *webauthntest.Authenticator) internal.Passkey {
+	var challenge string
+	var stored internal.Passkey
+	users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Name: "test", Email: "test@gmail.com"}, nil).Times(1)
+	data.EXPECT().GetPasskeys(uint(1)).Return(nil, nil).Times(1)
+	data.EXPECT().CreatePasskeySession(uint(1), webauthn.CeremonyCreate, gomock.Any(), gomock.Any()).
+		Do(func(_ uint, _ string, c string, _ time.Time) { challenge = c }).Return(nil).Times(1)
+	options, err := handler.BeginRegistration(1)
+	assert.NoError(t, err)
+	assert.Equal(t, challenge, options.Challenge)
+	registration, err := authenticator.Register(options)
+	assert.NoError(t, err)
+
+	data.EXPECT().ConsumePasskeySession(webauthn.CeremonyCreate, challenge).Return(uint(1), nil).Times(1)
+	data.EXPECT().CreatePasskey(gomock.Any()).Do(func(passkey internal.Passkey) {
+		stored = passkey
+		stored.ID = 7
+	}).Return(internal.PasskeyResponse{ID: 7, Name: "laptop"}, nil).Times(1)
+	response, err := handler.FinishRegistration(1, "laptop", registration)
+	assert.NoError(t, err)
+	assert.Equal(t, "laptop", response.Name)
+	return stored
+}
+
+func TestPasskeyRegistration(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserData(mockCtrl)
+	data := mock.NewMockPasskeyData(mockCtrl)
+	handler := newPasskeyService(t, users, data)
+
+	t.Run("register successfully", func(t *testing.T) {
+		passkey := registerPasskey(t, handler, users, data, webauthntest.NewAuthenticator(passkeyOrigin))
+		assert.Equal(t, uint(1), passkey.UserID)
+		assert.Equal(t, "laptop", passkey.Name)
+		assert.NotEmpty(t, passkey.CredentialID)
+		assert.NotEmpty(t, passkey.PublicKey)
+	})
+
+	t.Run("error on challenge of another user", func(t *testing.T) {
+		registration, err := webauthntest.NewAuthenticator(passkeyOrigin).Register(webauthn.CreationOptions{
+			Challenge: "challenge",
+			RP:        webauthn.RelyingPartyEntity{ID: "example.com"},
+		})
+		assert.NoError(t, err)
+		data.EXPECT().ConsumePasskeySession(webauthn.CeremonyCreate, "challenge").Return(uint(2), nil).Times(1)
+		_, err = handler.FinishRegistration(1, "laptop", registration)
+		assert.EqualError(t, err, "Invalid Passkey : registration was not started for user 1")
+	})
+
+	t.Run("error on wrong origin", func(t *testing.T) {
+		registration, err := webauthntest.NewAuthenticator("https://evil.example.com").Register(webauthn.CreationOptions{
+			Challenge: "challenge",
+			RP:        webauthn.RelyingPartyEntity{ID: "example.com"},
+		})
+		assert.NoError(t, err)
+		data.EXPECT().ConsumePasskeySession(webauthn.CeremonyCreate, "challenge").Return(uint(1), nil).Times(1)
+		_, err = handler.FinishRegistration(1, "laptop", registration)
+		assert.True(t, hasCode(err, serviceerror.InvalidPasskey))
+	})
+}
+
+func TestPasskeyLogin(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserData(mockCtrl)
+	data := mock.NewMockPasskeyData(mockCtrl)
+	handler := newPasskeyService(t, users, data)
+	authenticator := webauthntest.NewAuthenticator(passkeyOrigin)
+	passkey := registerPasskey(t, handler, users, data, authenticator)
+
+	begin := func(t *testing.T, email string, userID uint) webauthn.Assertion {
+		var challenge string
+		data.EXPECT().CreatePasskeySession(userID, webauthn.CeremonyGet, gomock.Any(), gomock.Any()).
+			Do(func(_ uint, _ string, c string, _ time.Time) { challenge = c }).Return(nil).Times(1)
+		options, err := handler.BeginLogin(email)
+		assert.NoError(t, err)
+		assert.Equal(t, challenge, options.Challenge)
+		assertion, err := authenticator.Login(options)
+		assert.NoError(t, err)
+		data.EXPECT().ConsumePasskeySession(webauthn.CeremonyGet, challenge).Return(userID, nil).Times(1)
+		return assertion
+	}
+
+	t.Run("login with email successfully", func(t *testing.T) {
+		users.EXPECT().GetUserByEmail("test@gmail.com").Return(internal.UserResponse{ID: 1}, nil).Times(1)
+		assertion := begin(t, "test@gmail.com", 1)
+		data.EXPECT().GetPasskeyByCredentialID(passkey.CredentialID).Return(passkey, nil).Times(1)
+		data.EXPECT().UsePasskey(uint(7), uint32(1)).Return(nil).Times(1)
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Status: internal.StatusActive}, nil).Times(1)
+		response, err := handler.FinishLogin(assertion)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+		passkey.SignCount = 1
+	})
+
+	t.Run("login with discoverable passkey successfully", func(t *testing.T) {
+		assertion := begin(t, "", 0)
+		data.EXPECT().GetPasskeyByCredentialID(passkey.CredentialID).Return(passkey, nil).Times(1)
+		data.EXPECT().UsePasskey(uint(7), uint32(2)).Return(nil).Times(1)
+		users.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Status: internal.StatusActive}, nil).Times(1)
+		response, err := handler.FinishLogin(assertion)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+	})
+
+	t.Run("hide unknown email", func(t *testing.T) {
+		users.EXPECT().GetUserByEmail("unknown@gmail.com").Return(internal.UserResponse{},
+			serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))).Times(1)
+		data.EXPECT().CreatePasskeySession(uint(0), webauthn.CeremonyGet, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		options, err := handler.BeginLogin("unknown@gmail.com")
+		assert.NoError(t, err)
+		assert.Empty(t, options.AllowCredentials)
+	})
+
+	t.Run("list no passkeys of known email", func(t *testing.T) {
+		users.EXPECT().GetUserByEmail("test@gmail.com").Return(internal.UserResponse{ID: 1}, nil).Times(1)
+		data.EXPECT().CreatePasskeySession(uint(1), webauthn.CeremonyGet, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		options, err := handler.BeginLogin("test@gmail.com")
+		assert.NoError(t, err)
+		assert.Empty(t, options.AllowCredentials)
+	})
+
+	t.Run("error on cloned authenticator", func(t *testing.T) {
+		passkey.SignCount = 100
+		assertion := begin(t, "", 0)
+		data.EXPECT().GetPasskeyByCredentialID(passkey.CredentialID).Return(passkey, nil).Times(1)
+		_, err := handler.FinishLogin(assertion)
+		assert.True(t, hasCode(err, serviceerror.InvalidCredentials))
+	})
+
+	t.Run("error on passkey of another user", func(t *testing.T) {
+		users.EXPECT().GetUserByEmail("other@gmail.com").Return(internal.UserResponse{ID: 2}, nil).Times(1)
+		assertion := begin(t, "other@gmail.com", 2)
+		data.EXPECT().GetPasskeyByCredentialID(passkey.CredentialID).Return(passkey, nil).Times(1)
+		_, err := handler.FinishLogin(assertion)
+		assert.EqualError(t, err, "Invalid Credentials : passkey belongs to another user")
+	})
+
+	t.Run("error on unknown passkey", func(t *testing.T) {
+		assertion := begin(t, "", 0)
+		data.EXPECT().GetPasskeyByCredentialID(passkey.CredentialID).Return(internal.Passkey{},
+			serviceerror.NewServiceError(serviceerror.PasskeyNotFound, errors.New("test"))).Times(1)
+		_, err := handler.FinishLogin(assertion)
+		assert.True(t, hasCode(err, serviceerror.InvalidCredentials))
+	})
+}
+
+func hasCode(err error, code serviceerror.ErrorCode) bool {
+	var srvError *serviceerror.ServiceError
+	return errors.As(err, &srvError) && srvError.Code == code
+}
+
+func TestAuthorizePasskeys(t *testing.T) {
+	handler := newPasskeyService(t, nil, nil)
+	assert.NoError(t, handler.AuthorizePasskeys(internal.Caller{UserID: 1}, 1))
+	assert.NoError(t, handler.AuthorizePasskeys(internal.Caller{UserID: 2, Admin: true}, 1))
+	assert.True(t, hasCode(handler.AuthorizePasskeys(internal.Caller{UserID: 2}, 1), serviceerror.Forbidden))
+	assert.True(t, hasCode(handler.AuthorizePasskeys(internal.Caller{UserID: 1, Scopes: []string{"users:write"}}, 1), serviceerror.Forbidden))
+}
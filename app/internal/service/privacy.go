@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+)
+
+type privacyService struct {
+	users    internal.UserData
+	groups   internal.GroupData
+	mfa      internal.MFAData
+	passkeys internal.PasskeyData
+	audit    internal.AuditData
+	auditor  internal.Auditor
+}
+
+func NewPrivacyService(users internal.UserData, groups internal.GroupData, mfa internal.MFAData, passkeys internal.PasskeyData,
+	audit internal.AuditData, auditor internal.Auditor) *privacyService {
+	return &privacyService{
+		users:    users,
+		groups:   groups,
+		mfa:      mfa,
+		passkeys: passkeys,
+		audit:    audit,
+		auditor:  auditor,
+	}
+}
+
+// Export collects the profile, memberships, credentials and audit trail of a
+// user. Secrets such as password hashes, MFA secrets and public keys are left
+// out.
+func (p *privacyService) Export(userID uint) (response internal.UserExport, err error) {
+	user, err := p.users.GetUser(userID)
+	if err != nil {
+		return response, err
+	}
+	memberships, err := p.groups.GetMemberships(userID)
+	if err != nil {
+		return response, err
+	}
+	mfa, err := p.mfa.GetMFA(userID)
+	if err != nil && !hasErrorCode(err, serviceerror.MFANotEnrolled) {
+		return response, err
+	}
+	passkeys, err := p.passkeys.GetPasskeys(userID)
+	if err != nil {
+		return response, err
+	}
+	events, err := p.audit.GetAuditEvents(userID, user.Email)
+	if err != nil {
+		return response, err
+	}
+	response = internal.UserExport{
+		ExportedAt:  time.Now().UTC(),
+		Profile:     user,
+		Memberships: memberships,
+		MFAEnabled:  mfa.Enabled,
+		Passkeys:    make([]internal.PasskeyResponse, 0, len(passkeys)),
+		Sessions:    []internal.Session{},
+		AuditEvents: events,
+	}
+	for _, passkey := range passkeys {
+		response.Passkeys = append(response.Passkeys, internal.PasskeyResponse{
+			ID:         passkey.ID,
+			Name:       passkey.Name,
+			CreatedAt:  passkey.CreatedAt,
+			LastUsedAt: passkey.LastUsedAt,
+		})
+	}
+	for _, event := range events {
+		if event.Type == internal.EventLoginSucceeded {
+			response.Sessions = append(response.Sessions, internal.Session{IP: event.IP, CreatedAt: event.CreatedAt})
+		}
+	}
+	return response, nil
+}
+
+// Erase anonymizes the user and its audit trail, unlike DeleteUser it can't
+// be undone. A tombstone event without personal data records the erasure.
+func (p *privacyService) Erase(userID uint, reason string) (err error) {
+	_, err = p.users.EraseUser(userID)
+	if err != nil {
+		return err
+	}
+	p.auditor.Record(internal.AuditEvent{Type: internal.EventUserErased, UserID: userID, Detail: reason})
+	return nil
+}
+
+// AuthorizeSubject lets users export and erase their own data and admins
+// those of everyone. A caller using an access token can't, erasing can't be
+// undone and the export holds the whole audit trail.
+func (p *privacyService) AuthorizeSubject(caller internal.Caller, userID uint) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't export or erase users"))
+	}
+	if caller.UserID != userID && !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d can't export or erase user %d", caller.UserID, userID))
+	}
+	return nil
+}
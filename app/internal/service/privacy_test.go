@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserData(mockCtrl)
+	groups := mock.NewMockGroupData(mockCtrl)
+	mfa := mock.NewMockMFAData(mockCtrl)
+	passkeys := mock.NewMockPasskeyData(mockCtrl)
+	audit := mock.NewMockAuditData(mockCtrl)
+	handler := service.NewPrivacyService(users, groups, mfa, passkeys, audit, mock.NewMockAuditor(mockCtrl))
+	user := internal.UserResponse{ID: 1, Name: "test", Email: "test@gmail.com", Status: internal.StatusActive}
+	loggedIn := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("export successfully", func(t *testing.T) {
+		users.EXPECT().GetUser(uint(1)).Return(user, nil).Times(1)
+		groups.EXPECT().GetMemberships(uint(1)).Return([]internal.Membership{{GroupID: 2, GroupName: "grp"}}, nil).Times(1)
+		mfa.EXPECT().GetMFA(uint(1)).Return(internal.MFA{},
+			serviceerror.NewServiceError(serviceerror.MFANotEnrolled, errors.New("test"))).Times(1)
+		passkeys.EXPECT().GetPasskeys(uint(1)).Return([]internal.Passkey{{ID: 3, Name: "laptop", PublicKey: []byte("key")}}, nil).Times(1)
+		audit.EXPECT().GetAuditEvents(uint(1), "test@gmail.com").Return([]internal.AuditEvent{
+			{Type: internal.EventLoginFailed, Email: "test@gmail.com", IP: "10.0.0.1"},
+			{Type: internal.EventLoginSucceeded, UserID: 1, IP: "10.0.0.1", CreatedAt: loggedIn},
+		}, nil).Times(1)
+		response, err := handler.Export(1)
+		assert.NoError(t, err)
+		assert.Equal(t, user, response.Profile)
+		assert.Equal(t, []internal.Membership{{GroupID: 2, GroupName: "grp"}}, response.Memberships)
+		assert.False(t, response.MFAEnabled)
+		assert.Equal(t, []internal.PasskeyResponse{{ID: 3, Name: "laptop"}}, response.Passkeys)
+		assert.Equal(t, []internal.Session{{IP: "10.0.0.1", CreatedAt: loggedIn}}, response.Sessions)
+		assert.Len(t, response.AuditEvents, 2)
+	})
+
+	t.Run("error on unknown user", func(t *testing.T) {
+		notFound := serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))
+		users.EXPECT().GetUser(uint(2)).Return(internal.UserResponse{}, notFound).Times(1)
+		_, err := handler.Export(2)
+		assert.Equal(t, notFound, err)
+	})
+}
+
+func TestErase(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserData(mockCtrl)
+	auditor := mock.NewMockAuditor(mockCtrl)
+	handler := service.NewPrivacyService(users, mock.NewMockGroupData(mockCtrl), mock.NewMockMFAData(mockCtrl),
+		mock.NewMockPasskeyData(mockCtrl), mock.NewMockAuditData(mockCtrl), auditor)
+
+	t.Run("erase and leave tombstone", func(t *testing.T) {
+		gomock.InOrder(
+			users.EXPECT().EraseUser(uint(1)).Return(internal.UserResponse{ID: 1, Email: "test@gmail.com"}, nil).Times(1),
+			auditor.EXPECT().Record(internal.AuditEvent{Type: internal.EventUserErased, UserID: 1, Detail: "request 42"}).Times(1),
+		)
+		assert.NoError(t, handler.Erase(1, "request 42"))
+	})
+
+	t.Run("error on erased user", func(t *testing.T) {
+		notFound := serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))
+		users.EXPECT().EraseUser(uint(1)).Return(internal.UserResponse{}, notFound).Times(1)
+		assert.Equal(t, notFound, handler.Erase(1, ""))
+	})
+}
+
+func TestAuthorizeSubject(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler := service.NewPrivacyService(mock.NewMockUserData(mockCtrl), mock.NewMockGroupData(mockCtrl), mock.NewMockMFAData(mockCtrl),
+		mock.NewMockPasskeyData(mockCtrl), mock.NewMockAuditData(mockCtrl), mock.NewMockAuditor(mockCtrl))
+	assert.NoError(t, handler.AuthorizeSubject(internal.Caller{UserID: 1}, 1))
+	assert.NoError(t, handler.AuthorizeSubject(internal.Caller{UserID: 2, Admin: true}, 1))
+	assert.True(t, hasCode(handler.AuthorizeSubject(internal.Caller{UserID: 2}, 1), serviceerror.Forbidden))
+	assert.True(t, hasCode(handler.AuthorizeSubject(internal.Caller{UserID: 1, Scopes: []string{"users:write"}}, 1), serviceerror.Forbidden))
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"time"
+	"usermanagement/app/internal"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type RetentionOptions struct {
+	Period time.Duration
+}
+
+type retentionService struct {
+	users   internal.UserData
+	groups  internal.GroupData
+	options RetentionOptions
+}
+
+func NewRetentionService(users internal.UserData, groups internal.GroupData, options RetentionOptions) *retentionService {
+	return &retentionService{
+		users:   users,
+		groups:  groups,
+		options: options,
+	}
+}
+
+// Purge removes users and groups deleted longer than the retention period
+// ago, after that they can't be restored anymore.
+func (r *retentionService) Purge() (err error) {
+	before := time.Now().Add(-r.options.Period)
+	users, err := r.users.PurgeUsers(before)
+	if err != nil {
+		return err
+	}
+	groups, err := r.groups.PurgeGroups(before)
+	if err != nil {
+		return err
+	}
+	if users > 0 || groups > 0 {
+		log.WithFields(log.Fields{"users": users, "groups": groups}).Info("purged deleted records")
+	}
+	return nil
+}
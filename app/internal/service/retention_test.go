@@ -0,0 +1,34 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurge(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserData(mockCtrl)
+	groups := mock.NewMockGroupData(mockCtrl)
+	handler := service.NewRetentionService(users, groups, service.RetentionOptions{Period: 24 * time.Hour})
+
+	t.Run("purge records older than the period", func(t *testing.T) {
+		expected := time.Now().Add(-24 * time.Hour)
+		users.EXPECT().PurgeUsers(gomock.Any()).Do(func(before time.Time) {
+			assert.WithinDuration(t, expected, before, time.Second)
+		}).Return(int64(2), nil).Times(1)
+		groups.EXPECT().PurgeGroups(gomock.Any()).Return(int64(1), nil).Times(1)
+		assert.NoError(t, handler.Purge())
+	})
+
+	t.Run("stop on error", func(t *testing.T) {
+		users.EXPECT().PurgeUsers(gomock.Any()).Return(int64(0), errors.New("test")).Times(1)
+		assert.EqualError(t, handler.Purge(), "test")
+	})
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/signing"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCloseInterval = 5 * time.Minute
+	maxReviewGroups      = 100
+	reviewItemsPerPage   = 1000
+)
+
+// AccessReviewOptions configures how often campaigns past their deadline are
+// closed and the keys reports are signed with.
+type AccessReviewOptions struct {
+	CloseInterval time.Duration
+	Keys          internal.SigningKeys
+}
+
+type accessReviewService struct {
+	data      internal.AccessReviewData
+	groups    internal.GroupService
+	groupData internal.GroupData
+	users     internal.UserData
+	auditor   internal.Auditor
+	interval  time.Duration
+	keys      internal.SigningKeys
+}
+
+func NewAccessReviewService(data internal.AccessReviewData, groups internal.GroupService, groupData internal.GroupData, users internal.UserData,
+	auditor internal.Auditor, options AccessReviewOptions) *accessReviewService {
+	if options.CloseInterval == 0 {
+		options.CloseInterval = defaultCloseInterval
+	}
+	return &accessReviewService{
+		data:      data,
+		groups:    groups,
+		groupData: groupData,
+		users:     users,
+		auditor:   auditor,
+		interval:  options.CloseInterval,
+		keys:      options.Keys,
+	}
+}
+
+// reviewerPool hands out reviewers in turn, never the member under review.
+type reviewerPool struct {
+	reviewers []uint
+	next      int
+}
+
+func (p *reviewerPool) assign(member uint) uint {
+	for range p.reviewers {
+		reviewer := p.reviewers[p.next%len(p.reviewers)]
+		p.next++
+		if reviewer != member {
+			return reviewer
+		}
+	}
+	return 0
+}
+
+// CreateCampaign snapshots the current members of the static groups. Each is
+// reviewed by one of the given reviewers in turn, or else by one of the owners
+// of its group. A member no one else can review is left to the admins.
+func (a *accessReviewService) CreateCampaign(request internal.ReviewCampaignRequest) (response internal.ReviewCampaign, err error) {
+	if len(request.GroupIDs) == 0 || len(request.GroupIDs) > maxReviewGroups {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidReviewRequest,
+			fmt.Errorf("%d groups given, between 1 and %d are allowed", len(request.GroupIDs), maxReviewGroups))
+	}
+	if !request.Deadline.After(time.Now()) {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidReviewRequest, errors.New("review deadline is in the past"))
+	}
+	for _, reviewer := range request.Reviewers {
+		if _, err := a.users.GetUser(reviewer); err != nil {
+			return response, err
+		}
+	}
+	shared := &reviewerPool{reviewers: request.Reviewers}
+	seen := map[uint]bool{}
+	var groupIDs []uint
+	var items []internal.ReviewItem
+	for _, groupID := range request.GroupIDs {
+		if seen[groupID] {
+			continue
+		}
+		seen[groupID] = true
+		groupIDs = append(groupIDs, groupID)
+		group, err := a.groups.GetGroup(groupID)
+		if err != nil {
+			return response, err
+		}
+		if group.Type == internal.GroupDynamic {
+			return response, serviceerror.NewServiceError(serviceerror.InvalidReviewRequest,
+				fmt.Errorf("members of dynamic group %d follow its rule and can't be reviewed", groupID))
+		}
+		members, err := a.groupData.GetGroupMembers(groupID)
+		if err != nil {
+			return response, err
+		}
+		pool := shared
+		if len(request.Reviewers) == 0 {
+			pool = &reviewerPool{}
+			for _, member := range members {
+				if member.Role == internal.RoleOwner {
+					pool.reviewers = append(pool.reviewers, member.UserID)
+				}
+			}
+		}
+		for _, member := range members {
+			items = append(items, internal.ReviewItem{
+				UserID:     member.UserID,
+				GroupID:    groupID,
+				Role:       member.Role,
+				ReviewerID: pool.assign(member.UserID),
+			})
+		}
+	}
+	request.GroupIDs = groupIDs
+	return a.data.CreateCampaign(request, items)
+}
+
+func (a *accessReviewService) GetCampaign(id uint) (response internal.ReviewCampaign, err error) {
+	return a.data.GetCampaign(id)
+}
+
+func (a *accessReviewService) GetCampaigns(page uint, perPage uint, status string) (response internal.ReviewCampaignsResponse, err error) {
+	if page <= 0 || perPage == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidReviewRequest, fmt.Errorf("page %d  or per page %d is not valid", page, perPage))
+	}
+	offset := perPage * (page - 1)
+	response, err = a.data.GetCampaigns(offset, perPage, status)
+	response.Page = page
+	response.PerPage = perPage
+	return response, err
+}
+
+func (a *accessReviewService) GetReviewItems(campaignID uint, page uint, perPage uint, filter internal.ReviewItemsFilter) (response internal.ReviewItemsResponse, err error) {
+	if page <= 0 || perPage == 0 {
+		return response, serviceerror.NewServiceError(serviceerror.InvalidReviewRequest, fmt.Errorf("page %d  or per page %d is not valid", page, perPage))
+	}
+	offset := perPage * (page - 1)
+	response, err = a.data.GetReviewItems(campaignID, offset, perPage, filter)
+	response.Page = page
+	response.PerPage = perPage
+	return response, err
+}
+
+// Certify keeps the membership as it is.
+func (a *accessReviewService) Certify(campaignID uint, itemID uint, reviewer uint, comment string) (response internal.ReviewItem, err error) {
+	return a.decide(internal.ReviewDecision{CampaignID: campaignID, ItemID: itemID, Decision: internal.ReviewCertified, DecidedBy: reviewer, Comment: comment})
+}
+
+// Revoke removes the user from the group and then records the decision. An
+// item whose membership can't be removed, like that of the last owner, stays
+// pending.
+func (a *accessReviewService) Revoke(campaignID uint, itemID uint, reviewer uint, comment string) (response internal.ReviewItem, err error) {
+	item, err := a.data.GetReviewItem(campaignID, itemID)
+	if err != nil {
+		return response, err
+	}
+	if item.Decision != internal.ReviewPending {
+		return response, serviceerror.NewServiceError(serviceerror.ReviewDecided, fmt.Errorf("review item %d is %s", itemID, item.Decision))
+	}
+	return a.revoke(item, reviewer, comment)
+}
+
+func (a *accessReviewService) revoke(item internal.ReviewItem, reviewer uint, comment string) (response internal.ReviewItem, err error) {
+	err = a.groups.RemoveUser(item.GroupID, item.UserID)
+	if err != nil {
+		return response, err
+	}
+	return a.decide(internal.ReviewDecision{CampaignID: item.CampaignID, ItemID: item.ID, Decision: internal.ReviewRevoked, DecidedBy: reviewer, Comment: comment})
+}
+
+func (a *accessReviewService) decide(decision internal.ReviewDecision) (response internal.ReviewItem, err error) {
+	response, err = a.data.DecideReviewItem(decision)
+	if err != nil {
+		return response, err
+	}
+	event := internal.EventAccessCertified
+	if response.Decision == internal.ReviewRevoked {
+		event = internal.EventAccessRevoked
+	}
+	a.auditor.Record(internal.AuditEvent{
+		Type:   event,
+		UserID: response.UserID,
+		Detail: fmt.Sprintf("membership of group %d %s in review campaign %d by user %d", response.GroupID, response.Decision, response.CampaignID, response.DecidedBy),
+	})
+	return response, nil
+}
+
+// pendingItems lists all items of the campaign still waiting for a decision.
+func (a *accessReviewService) pendingItems(campaignID uint) (items []internal.ReviewItem, err error) {
+	for offset := uint(0); ; offset += reviewItemsPerPage {
+		page, err := a.data.GetReviewItems(campaignID, offset, reviewItemsPerPage, internal.ReviewItemsFilter{Decision: internal.ReviewPending})
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+		if len(page.Items) < reviewItemsPerPage {
+			return items, nil
+		}
+	}
+}
+
+// CloseCampaign ends an open campaign. If the campaign revokes undecided
+// memberships, those nobody reviewed are removed first, the rest stays
+// undecided.
+func (a *accessReviewService) CloseCampaign(id uint) (err error) {
+	campaign, err := a.data.GetCampaign(id)
+	if err != nil {
+		return err
+	}
+	if campaign.Status != internal.CampaignOpen {
+		return serviceerror.NewServiceError(serviceerror.ReviewDecided, fmt.Errorf("review campaign %d is already closed", id))
+	}
+	if campaign.RevokeUndecided {
+		items, err := a.pendingItems(id)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if _, err := a.revoke(item, 0, "not reviewed by the deadline"); err != nil {
+				log.WithError(err).WithFields(log.Fields{"campaign": id, "item": item.ID}).Error("revoking undecided membership failed")
+			}
+		}
+	}
+	return a.data.CloseCampaign(id)
+}
+
+// Report returns the campaign with all its items, signed with the signing key
+// so auditors can tell with the published keys it came from this server
+// unchanged.
+func (a *accessReviewService) Report(id uint) (response internal.SignedReviewReport, err error) {
+	campaign, err := a.data.GetCampaign(id)
+	if err != nil {
+		return response, err
+	}
+	report := internal.ReviewReport{Campaign: campaign, Items: []internal.ReviewItem{}, GeneratedAt: time.Now().UTC()}
+	for offset := uint(0); ; offset += reviewItemsPerPage {
+		page, err := a.data.GetReviewItems(id, offset, reviewItemsPerPage, internal.ReviewItemsFilter{})
+		if err != nil {
+			return response, err
+		}
+		report.Items = append(report.Items, page.Items...)
+		if len(page.Items) < reviewItemsPerPage {
+			break
+		}
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		return response, errors.Wrap(err, "marshal review report failed")
+	}
+	key, err := a.keys.Signing()
+	if err != nil {
+		return response, err
+	}
+	signature, err := signing.Sign(key, body)
+	if err != nil {
+		return response, errors.Wrap(err, "sign review report failed")
+	}
+	return internal.SignedReviewReport{
+		Report:    body,
+		KeyID:     key.ID,
+		Algorithm: key.Algorithm,
+		Signature: base64.RawURLEncoding.EncodeToString(signature),
+	}, nil
+}
+
+// AuthorizeReview lets admins and the reviewer assigned to the item decide it,
+// no one decides on their own membership.
+func (a *accessReviewService) AuthorizeReview(caller internal.Caller, campaignID uint, itemID uint) (err error) {
+	if caller.Admin {
+		return nil
+	}
+	item, err := a.data.GetReviewItem(campaignID, itemID)
+	if err != nil {
+		return err
+	}
+	if item.UserID == caller.UserID {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d can't review its own membership", caller.UserID))
+	}
+	if item.ReviewerID != caller.UserID {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d doesn't review item %d", caller.UserID, itemID))
+	}
+	return nil
+}
+
+// AuthorizeItems lets admins list the items of any reviewer and a reviewer
+// only its own.
+func (a *accessReviewService) AuthorizeItems(caller internal.Caller, reviewerID uint) (err error) {
+	if a.AuthorizeAdmin(caller) == nil {
+		return nil
+	}
+	if reviewerID == 0 || reviewerID != caller.UserID {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d can only list the items it reviews", caller.UserID))
+	}
+	return nil
+}
+
+// AuthorizeAdmin lets only admins start and close campaigns and read their
+// reports, access tokens can't.
+func (a *accessReviewService) AuthorizeAdmin(caller internal.Caller) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't administer access reviews"))
+	}
+	if !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d is not an admin", caller.UserID))
+	}
+	return nil
+}
+
+// CloseDueCampaigns closes the campaigns whose deadline has passed, a campaign
+// that fails to close is tried again next time.
+func (a *accessReviewService) CloseDueCampaigns() (err error) {
+	campaigns, err := a.data.GetDueCampaigns(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, campaign := range campaigns {
+		if err := a.CloseCampaign(campaign.ID); err != nil {
+			log.WithError(err).WithField("campaign", campaign.ID).Error("closing review campaign failed")
+			continue
+		}
+		log.WithField("campaign", campaign.ID).Info("review campaign closed at deadline")
+	}
+	return nil
+}
+
+// ForOrganization returns the service for the campaigns, groups and users of
+// the organization.
+func (a *accessReviewService) ForOrganization(organizationID uint) internal.AccessReviewService {
+	scoped := *a
+	scoped.data = a.data.ForOrganization(organizationID)
+	scoped.groups = a.groups.ForOrganization(organizationID)
+	scoped.groupData = a.groupData.ForOrganization(organizationID)
+	scoped.users = a.users.ForOrganization(organizationID)
+	return &scoped
+}
+
+func (a *accessReviewService) Init() (err error) {
+	return nil
+}
+
+func (a *accessReviewService) Run(ctx context.Context) (err error) {
+	RunPeriodically(ctx, "close review campaigns", a.interval, a.CloseDueCampaigns)
+	return nil
+}
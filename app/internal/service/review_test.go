@@ -0,0 +1,237 @@
+package service_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/signing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type reviewMocks struct {
+	data      *mock.MockAccessReviewData
+	groups    *mock.MockGroupService
+	groupData *mock.MockGroupData
+	users     *mock.MockUserData
+	auditor   *mock.MockAuditor
+	keys      *testKeys
+}
+
+func newReviewService(t *testing.T, mockCtrl *gomock.Controller) (internal.AccessReviewService, reviewMocks) {
+	mocks := reviewMocks{
+		data:      mock.NewMockAccessReviewData(mockCtrl),
+		groups:    mock.NewMockGroupService(mockCtrl),
+		groupData: mock.NewMockGroupData(mockCtrl),
+		users:     mock.NewMockUserData(mockCtrl),
+		auditor:   mock.NewMockAuditor(mockCtrl),
+		keys:      newTestKeys(t, signing.EdDSA),
+	}
+	handler := service.NewAccessReviewService(mocks.data, mocks.groups, mocks.groupData, mocks.users, mocks.auditor,
+		service.AccessReviewOptions{Keys: mocks.keys})
+	return handler, mocks
+}
+
+func TestCreateCampaign(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler, mocks := newReviewService(t, mockCtrl)
+	deadline := time.Now().Add(24 * time.Hour)
+	members := []internal.GroupMember{{UserID: 2, Role: internal.RoleMember}, {UserID: 3, Role: internal.RoleManager}, {UserID: 7, Role: internal.RoleOwner}}
+
+	t.Run("assign owners as reviewers", func(t *testing.T) {
+		request := internal.ReviewCampaignRequest{Name: "q1", GroupIDs: []uint{1, 1}, Deadline: deadline}
+		mocks.groups.EXPECT().GetGroup(uint(1)).Return(internal.GroupResponse{ID: 1, Type: internal.GroupStatic}, nil).Times(1)
+		mocks.groupData.EXPECT().GetGroupMembers(uint(1)).Return(members, nil).Times(1)
+		mocks.data.EXPECT().CreateCampaign(internal.ReviewCampaignRequest{Name: "q1", GroupIDs: []uint{1}, Deadline: deadline}, []internal.ReviewItem{
+			{UserID: 2, GroupID: 1, Role: internal.RoleMember, ReviewerID: 7},
+			{UserID: 3, GroupID: 1, Role: internal.RoleManager, ReviewerID: 7},
+			{UserID: 7, GroupID: 1, Role: internal.RoleOwner},
+		}).Return(internal.ReviewCampaign{ID: 4}, nil).Times(1)
+		response, err := handler.CreateCampaign(request)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(4), response.ID)
+	})
+
+	t.Run("assign given reviewers in turn", func(t *testing.T) {
+		request := internal.ReviewCampaignRequest{Name: "q1", GroupIDs: []uint{1}, Reviewers: []uint{2, 9}, Deadline: deadline}
+		mocks.users.EXPECT().GetUser(uint(2)).Return(internal.UserResponse{ID: 2}, nil).Times(1)
+		mocks.users.EXPECT().GetUser(uint(9)).Return(internal.UserResponse{ID: 9}, nil).Times(1)
+		mocks.groups.EXPECT().GetGroup(uint(1)).Return(internal.GroupResponse{ID: 1, Type: internal.GroupStatic}, nil).Times(1)
+		mocks.groupData.EXPECT().GetGroupMembers(uint(1)).Return(members, nil).Times(1)
+		mocks.data.EXPECT().CreateCampaign(request, []internal.ReviewItem{
+			{UserID: 2, GroupID: 1, Role: internal.RoleMember, ReviewerID: 9},
+			{UserID: 3, GroupID: 1, Role: internal.RoleManager, ReviewerID: 2},
+			{UserID: 7, GroupID: 1, Role: internal.RoleOwner, ReviewerID: 9},
+		}).Return(internal.ReviewCampaign{ID: 4}, nil).Times(1)
+		_, err := handler.CreateCampaign(request)
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail on dynamic group", func(t *testing.T) {
+		mocks.groups.EXPECT().GetGroup(uint(1)).Return(internal.GroupResponse{ID: 1, Type: internal.GroupDynamic}, nil).Times(1)
+		_, err := handler.CreateCampaign(internal.ReviewCampaignRequest{Name: "q1", GroupIDs: []uint{1}, Deadline: deadline})
+		assert.True(t, hasCode(err, serviceerror.InvalidReviewRequest))
+	})
+
+	t.Run("fail on past deadline", func(t *testing.T) {
+		_, err := handler.CreateCampaign(internal.ReviewCampaignRequest{Name: "q1", GroupIDs: []uint{1}, Deadline: time.Now().Add(-time.Hour)})
+		assert.True(t, hasCode(err, serviceerror.InvalidReviewRequest))
+	})
+}
+
+func TestDecideReviewItem(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler, mocks := newReviewService(t, mockCtrl)
+	pending := internal.ReviewItem{ID: 5, CampaignID: 4, UserID: 2, GroupID: 1, Decision: internal.ReviewPending}
+
+	t.Run("certify membership", func(t *testing.T) {
+		mocks.data.EXPECT().DecideReviewItem(internal.ReviewDecision{CampaignID: 4, ItemID: 5, Decision: internal.ReviewCertified, DecidedBy: 7, Comment: "ok"}).
+			Return(internal.ReviewItem{ID: 5, CampaignID: 4, UserID: 2, GroupID: 1, Decision: internal.ReviewCertified, DecidedBy: 7}, nil).Times(1)
+		mocks.auditor.EXPECT().Record(internal.AuditEvent{
+			Type:   internal.EventAccessCertified,
+			UserID: 2,
+			Detail: "membership of group 1 certified in review campaign 4 by user 7",
+		}).Times(1)
+		_, err := handler.Certify(4, 5, 7, "ok")
+		assert.NoError(t, err)
+	})
+
+	t.Run("revoke removes user from group", func(t *testing.T) {
+		mocks.data.EXPECT().GetReviewItem(uint(4), uint(5)).Return(pending, nil).Times(1)
+		gomock.InOrder(
+			mocks.groups.EXPECT().RemoveUser(uint(1), uint(2)).Return(nil).Times(1),
+			mocks.data.EXPECT().DecideReviewItem(internal.ReviewDecision{CampaignID: 4, ItemID: 5, Decision: internal.ReviewRevoked, DecidedBy: 7}).
+				Return(internal.ReviewItem{ID: 5, UserID: 2, Decision: internal.ReviewRevoked}, nil).Times(1),
+		)
+		mocks.auditor.EXPECT().Record(gomock.Any()).Do(func(event internal.AuditEvent) {
+			assert.Equal(t, internal.EventAccessRevoked, event.Type)
+		}).Times(1)
+		response, err := handler.Revoke(4, 5, 7, "")
+		assert.NoError(t, err)
+		assert.Equal(t, internal.ReviewRevoked, response.Decision)
+	})
+
+	t.Run("keep item pending when removing fails", func(t *testing.T) {
+		mocks.data.EXPECT().GetReviewItem(uint(4), uint(5)).Return(pending, nil).Times(1)
+		mocks.groups.EXPECT().RemoveUser(uint(1), uint(2)).Return(serviceerror.NewServiceError(serviceerror.LastGroupOwner, errors.New("test"))).Times(1)
+		_, err := handler.Revoke(4, 5, 7, "")
+		assert.True(t, hasCode(err, serviceerror.LastGroupOwner))
+	})
+
+	t.Run("fail to revoke decided item", func(t *testing.T) {
+		mocks.data.EXPECT().GetReviewItem(uint(4), uint(5)).Return(internal.ReviewItem{ID: 5, Decision: internal.ReviewCertified}, nil).Times(1)
+		_, err := handler.Revoke(4, 5, 7, "")
+		assert.True(t, hasCode(err, serviceerror.ReviewDecided))
+	})
+}
+
+func TestCloseCampaign(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler, mocks := newReviewService(t, mockCtrl)
+
+	t.Run("revoke undecided memberships", func(t *testing.T) {
+		mocks.data.EXPECT().GetCampaign(uint(4)).Return(internal.ReviewCampaign{ID: 4, Status: internal.CampaignOpen, RevokeUndecided: true}, nil).Times(1)
+		mocks.data.EXPECT().GetReviewItems(uint(4), uint(0), uint(1000), internal.ReviewItemsFilter{Decision: internal.ReviewPending}).
+			Return(internal.ReviewItemsResponse{Items: []internal.ReviewItem{
+				{ID: 5, CampaignID: 4, UserID: 2, GroupID: 1},
+				{ID: 6, CampaignID: 4, UserID: 7, GroupID: 1},
+			}}, nil).Times(1)
+		mocks.groups.EXPECT().RemoveUser(uint(1), uint(2)).Return(nil).Times(1)
+		mocks.data.EXPECT().DecideReviewItem(internal.ReviewDecision{CampaignID: 4, ItemID: 5, Decision: internal.ReviewRevoked, Comment: "not reviewed by the deadline"}).
+			Return(internal.ReviewItem{ID: 5, Decision: internal.ReviewRevoked}, nil).Times(1)
+		mocks.auditor.EXPECT().Record(gomock.Any()).Times(1)
+		mocks.groups.EXPECT().RemoveUser(uint(1), uint(7)).Return(serviceerror.NewServiceError(serviceerror.LastGroupOwner, errors.New("test"))).Times(1)
+		mocks.data.EXPECT().CloseCampaign(uint(4)).Return(nil).Times(1)
+		assert.NoError(t, handler.CloseCampaign(4))
+	})
+
+	t.Run("leave undecided memberships", func(t *testing.T) {
+		mocks.data.EXPECT().GetCampaign(uint(4)).Return(internal.ReviewCampaign{ID: 4, Status: internal.CampaignOpen}, nil).Times(1)
+		mocks.data.EXPECT().CloseCampaign(uint(4)).Return(nil).Times(1)
+		assert.NoError(t, handler.CloseCampaign(4))
+	})
+
+	t.Run("fail on closed campaign", func(t *testing.T) {
+		mocks.data.EXPECT().GetCampaign(uint(4)).Return(internal.ReviewCampaign{ID: 4, Status: internal.CampaignClosed}, nil).Times(1)
+		assert.True(t, hasCode(handler.CloseCampaign(4), serviceerror.ReviewDecided))
+	})
+
+	t.Run("close campaigns past deadline", func(t *testing.T) {
+		mocks.data.EXPECT().GetDueCampaigns(gomock.Any()).Return([]internal.ReviewCampaign{{ID: 4}, {ID: 8}}, nil).Times(1)
+		mocks.data.EXPECT().GetCampaign(uint(4)).Return(internal.ReviewCampaign{}, errors.New("test")).Times(1)
+		mocks.data.EXPECT().GetCampaign(uint(8)).Return(internal.ReviewCampaign{ID: 8, Status: internal.CampaignOpen}, nil).Times(1)
+		mocks.data.EXPECT().CloseCampaign(uint(8)).Return(nil).Times(1)
+		assert.NoError(t, handler.CloseDueCampaigns())
+	})
+}
+
+func TestReviewReport(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler, mocks := newReviewService(t, mockCtrl)
+
+	mocks.data.EXPECT().GetCampaign(uint(4)).Return(internal.ReviewCampaign{ID: 4, Name: "q1", Status: internal.CampaignClosed}, nil).Times(1)
+	mocks.data.EXPECT().GetReviewItems(uint(4), uint(0), uint(1000), internal.ReviewItemsFilter{}).
+		Return(internal.ReviewItemsResponse{Items: []internal.ReviewItem{{ID: 5, Decision: internal.ReviewCertified}}}, nil).Times(1)
+	response, err := handler.Report(4)
+	assert.NoError(t, err)
+	key := mocks.keys.keys[0]
+	assert.Equal(t, key.ID, response.KeyID)
+	assert.Equal(t, signing.EdDSA, response.Algorithm)
+	signature, err := base64.RawURLEncoding.DecodeString(response.Signature)
+	assert.NoError(t, err)
+	assert.NoError(t, signing.Verify(key.Key.Public(), response.Report, signature))
+	var report internal.ReviewReport
+	assert.NoError(t, json.Unmarshal(response.Report, &report))
+	assert.Equal(t, "q1", report.Campaign.Name)
+	assert.Len(t, report.Items, 1)
+}
+
+func TestAuthorizeReview(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler, mocks := newReviewService(t, mockCtrl)
+	item := internal.ReviewItem{ID: 5, CampaignID: 4, UserID: 2, ReviewerID: 7}
+
+	t.Run("let assigned reviewer decide", func(t *testing.T) {
+		mocks.data.EXPECT().GetReviewItem(uint(4), uint(5)).Return(item, nil).Times(1)
+		assert.NoError(t, handler.AuthorizeReview(internal.Caller{UserID: 7}, 4, 5))
+	})
+
+	t.Run("let admin decide", func(t *testing.T) {
+		assert.NoError(t, handler.AuthorizeReview(internal.Caller{UserID: 9, Admin: true}, 4, 5))
+	})
+
+	t.Run("fail on own membership", func(t *testing.T) {
+		mocks.data.EXPECT().GetReviewItem(uint(4), uint(5)).Return(internal.ReviewItem{ID: 5, UserID: 7, ReviewerID: 7}, nil).Times(1)
+		assert.True(t, hasCode(handler.AuthorizeReview(internal.Caller{UserID: 7}, 4, 5), serviceerror.Forbidden))
+	})
+
+	t.Run("fail on other reviewer", func(t *testing.T) {
+		mocks.data.EXPECT().GetReviewItem(uint(4), uint(5)).Return(item, nil).Times(1)
+		assert.True(t, hasCode(handler.AuthorizeReview(internal.Caller{UserID: 8}, 4, 5), serviceerror.Forbidden))
+	})
+
+	t.Run("only admins manage campaigns", func(t *testing.T) {
+		assert.NoError(t, handler.AuthorizeAdmin(internal.Caller{UserID: 9, Admin: true}))
+		assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 7}), serviceerror.Forbidden))
+		assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 9, Admin: true, Scopes: []string{"reviews"}}), serviceerror.Forbidden))
+	})
+
+	t.Run("let reviewers list only their items", func(t *testing.T) {
+		assert.NoError(t, handler.AuthorizeItems(internal.Caller{UserID: 9, Admin: true}, 0))
+		assert.NoError(t, handler.AuthorizeItems(internal.Caller{UserID: 7}, 7))
+		assert.True(t, hasCode(handler.AuthorizeItems(internal.Caller{UserID: 7}, 0), serviceerror.Forbidden))
+		assert.True(t, hasCode(handler.AuthorizeItems(internal.Caller{UserID: 7}, 8), serviceerror.Forbidden))
+	})
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/signing"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRotationInterval = 30 * 24 * time.Hour
+	defaultKeyCheckInterval = time.Hour
+	defaultSignedTokenTTL   = time.Hour
+	unknownKeyReload        = 10 * time.Second
+)
+
+// KeyManagerOptions configure the key manager. Keys are encrypted with
+// MasterKey, a new key of Algorithm signs every RotationInterval and TokenTTL
+// is the longest lifetime of the tokens signed, a retired key verifies that
+// long. Every CheckInterval the keys are rotated if due and reloaded, for the
+// rotations of other instances.
+type KeyManagerOptions struct {
+	MasterKey        []byte
+	Algorithm        string
+	RotationInterval time.Duration
+	CheckInterval    time.Duration
+	TokenTTL         time.Duration
+}
+
+type keyManager struct {
+	data    internal.SigningKeyData
+	options KeyManagerOptions
+
+	// mutex guards the decrypted keys, loaded tells whether they were and
+	// reloadedAt when they were last reloaded for an unknown key ID.
+	mutex        sync.Mutex
+	loaded       bool
+	reloadedAt   time.Time
+	active       *internal.StoredSigningKey
+	signing      internal.SigningKey
+	verification []internal.SigningKey
+}
+
+func NewKeyManager(data internal.SigningKeyData, options KeyManagerOptions) *keyManager {
+	if options.Algorithm == "" {
+		options.Algorithm = signing.RS256
+	}
+	if options.RotationInterval <= 0 {
+		options.RotationInterval = defaultRotationInterval
+	}
+	if options.CheckInterval <= 0 {
+		options.CheckInterval = defaultKeyCheckInterval
+	}
+	if options.TokenTTL <= 0 {
+		options.TokenTTL = defaultSignedTokenTTL
+	}
+	return &keyManager{
+		data:    data,
+		options: options,
+	}
+}
+
+// Signing returns the active key, a key is generated if there is none.
+func (k *keyManager) Signing() (key internal.SigningKey, err error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if err := k.ensureActive(); err != nil {
+		return key, err
+	}
+	return k.signing, nil
+}
+
+// Verification returns the active key and the retired keys that haven't
+// expired.
+func (k *keyManager) Verification() (keys []internal.SigningKey, err error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if err := k.ensureActive(); err != nil {
+		return nil, err
+	}
+	return append([]internal.SigningKey{}, k.verification...), nil
+}
+
+// VerificationKey returns the key named kid. A key ID this instance doesn't
+// know may be of a key another instance rotated to since the keys were
+// loaded, they are reloaded then, at most once every unknownKeyReload so
+// tokens with made up key IDs don't reach the database.
+func (k *keyManager) VerificationKey(kid string) (key internal.SigningKey, err error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if err := k.ensureActive(); err != nil {
+		return key, err
+	}
+	if key, ok := k.verificationKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(k.reloadedAt) < unknownKeyReload {
+		return key, fmt.Errorf("signing key %s is unknown", kid)
+	}
+	k.reloadedAt = time.Now()
+	if err := k.load(); err != nil {
+		return key, err
+	}
+	if key, ok := k.verificationKey(kid); ok {
+		log.WithField("kid", kid).Info("signing keys reloaded for unknown key")
+		return key, nil
+	}
+	return key, fmt.Errorf("signing key %s is unknown", kid)
+}
+
+func (k *keyManager) verificationKey(kid string) (key internal.SigningKey, ok bool) {
+	for _, key := range k.verification {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+	return key, false
+}
+
+// GetKeys lists the stored keys, also those this instance can't decrypt.
+func (k *keyManager) GetKeys() (response internal.SigningKeysResponse, err error) {
+	stored, err := k.data.GetSigningKeys()
+	if err != nil {
+		return response, err
+	}
+	response.Keys = make([]internal.SigningKeyInfo, len(stored))
+	for i, key := range stored {
+		response.Keys[i] = toSigningKeyInfo(key)
+	}
+	return response, nil
+}
+
+// Rotate makes a new key sign, the key before verifies until the tokens it
+// signed expired.
+func (k *keyManager) Rotate() (response internal.SigningKeyInfo, err error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if err := k.rotate(); err != nil {
+		return response, err
+	}
+	return toSigningKeyInfo(*k.active), nil
+}
+
+// RotateDue removes the expired keys and rotates if the active key is older
+// than the rotation interval.
+func (k *keyManager) RotateDue() (err error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	now := time.Now()
+	count, err := k.data.PurgeSigningKeys(now)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.WithField("count", count).Info("expired signing keys removed")
+	}
+	if err := k.load(); err != nil {
+		return err
+	}
+	if k.active != nil && now.Sub(k.active.CreatedAt) < k.options.RotationInterval {
+		return nil
+	}
+	return k.rotate()
+}
+
+// AuthorizeAdmin lets only admins who logged in manage signing keys.
+func (k *keyManager) AuthorizeAdmin(caller internal.Caller) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't manage signing keys"))
+	}
+	if !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d is not an admin", caller.UserID))
+	}
+	return nil
+}
+
+// Init loads the keys and generates the first one, tokens can't be signed
+// without.
+func (k *keyManager) Init() (err error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if err := k.load(); err != nil {
+		return err
+	}
+	return k.ensureActive()
+}
+
+func (k *keyManager) Run(ctx context.Context) (err error) {
+	RunPeriodically(ctx, "rotate signing keys", k.options.CheckInterval, k.RotateDue)
+	return nil
+}
+
+// ensureActive loads the keys once and rotates if there is no active key
+// this instance can decrypt.
+func (k *keyManager) ensureActive() (err error) {
+	if !k.loaded {
+		if err := k.load(); err != nil {
+			return err
+		}
+	}
+	if k.active != nil {
+		return nil
+	}
+	return k.rotate()
+}
+
+// rotate stores a new key, encrypted with its ID, and reloads the keys. The
+// key before verifies for the lifetime of tokens, plus the check interval in
+// which other instances may still sign with it.
+func (k *keyManager) rotate() (err error) {
+	signer, err := signing.Generate(k.options.Algorithm)
+	if err != nil {
+		return err
+	}
+	key, err := signing.NewKey(signer)
+	if err != nil {
+		return err
+	}
+	encrypted, err := signing.EncryptPrivateKey(signer, key.ID, k.options.MasterKey)
+	if err != nil {
+		return errors.Wrap(err, "encrypt signing key failed")
+	}
+	expiresAt := time.Now().Add(k.options.TokenTTL + k.options.CheckInterval)
+	err = k.data.RotateSigningKey(internal.StoredSigningKey{ID: key.ID, Algorithm: key.Algorithm, EncryptedKey: encrypted}, expiresAt)
+	if err != nil {
+		return err
+	}
+	if err := k.load(); err != nil {
+		return err
+	}
+	if k.active == nil || k.active.ID != key.ID {
+		return fmt.Errorf("rotated signing key %s isn't active", key.ID)
+	}
+	log.WithFields(log.Fields{"kid": key.ID, "algorithm": key.Algorithm}).Info("signing key rotated")
+	return nil
+}
+
+// load decrypts the keys that haven't expired, keys that don't decrypt with
+// the master key are skipped.
+func (k *keyManager) load() (err error) {
+	stored, err := k.data.GetSigningKeys()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	k.active = nil
+	k.verification = nil
+	for i, key := range stored {
+		if key.ExpiresAt != nil && key.ExpiresAt.Before(now) {
+			continue
+		}
+		signer, err := signing.DecryptPrivateKey(key.EncryptedKey, key.ID, k.options.MasterKey)
+		if err != nil {
+			log.WithError(err).WithField("kid", key.ID).Warn("skipping signing key")
+			continue
+		}
+		signingKey := internal.SigningKey{ID: key.ID, Algorithm: key.Algorithm, Key: signer}
+		if key.RetiredAt == nil && k.active == nil {
+			k.active = &stored[i]
+			k.signing = signingKey
+			k.verification = append([]internal.SigningKey{signingKey}, k.verification...)
+			continue
+		}
+		k.verification = append(k.verification, signingKey)
+	}
+	k.loaded = true
+	return nil
+}
+
+func toSigningKeyInfo(key internal.StoredSigningKey) internal.SigningKeyInfo {
+	status := internal.SigningKeyActive
+	if key.RetiredAt != nil {
+		status = internal.SigningKeyRetired
+	}
+	return internal.SigningKeyInfo{
+		ID:        key.ID,
+		Algorithm: key.Algorithm,
+		Status:    status,
+		CreatedAt: key.CreatedAt,
+		RetiredAt: key.RetiredAt,
+		ExpiresAt: key.ExpiresAt,
+	}
+}
@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+	"usermanagement/app/internal/signing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// storedKeys answers the signing key data from keys, rotations update it.
+func storedKeys(data *mock.MockSigningKeyData, keys *[]internal.StoredSigningKey) {
+	data.EXPECT().GetSigningKeys().DoAndReturn(func() ([]internal.StoredSigningKey, error) {
+		return append([]internal.StoredSigningKey{}, *keys...), nil
+	}).AnyTimes()
+	data.EXPECT().RotateSigningKey(gomock.Any(), gomock.Any()).DoAndReturn(func(key internal.StoredSigningKey, expiresAt time.Time) error {
+		now := time.Now()
+		for i := range *keys {
+			if (*keys)[i].RetiredAt == nil {
+				(*keys)[i].RetiredAt, (*keys)[i].ExpiresAt = &now, &expiresAt
+			}
+		}
+		key.CreatedAt = now
+		*keys = append([]internal.StoredSigningKey{key}, *keys...)
+		return nil
+	}).AnyTimes()
+}
+
+func TestKeyManager(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockSigningKeyData(mockCtrl)
+	var keys []internal.StoredSigningKey
+	storedKeys(data, &keys)
+	master := []byte("master")
+	manager := service.NewKeyManager(data, service.KeyManagerOptions{
+		MasterKey: master, Algorithm: signing.EdDSA, TokenTTL: time.Hour, CheckInterval: time.Minute,
+	})
+	var first internal.SigningKey
+
+	t.Run("generate encrypted key on init", func(t *testing.T) {
+		assert.NoError(t, manager.Init())
+		var err error
+		first, err = manager.Signing()
+		assert.NoError(t, err)
+		assert.Equal(t, signing.EdDSA, first.Algorithm)
+		if assert.Len(t, keys, 1) {
+			assert.Equal(t, first.ID, keys[0].ID)
+			decrypted, err := signing.DecryptPrivateKey(keys[0].EncryptedKey, keys[0].ID, master)
+			assert.NoError(t, err)
+			assert.Equal(t, first.Key.Public(), decrypted.Public())
+		}
+	})
+
+	t.Run("keep retired key for verification", func(t *testing.T) {
+		response, err := manager.Rotate()
+		assert.NoError(t, err)
+		assert.Equal(t, internal.SigningKeyActive, response.Status)
+		key, err := manager.Signing()
+		assert.NoError(t, err)
+		assert.Equal(t, response.ID, key.ID)
+		assert.NotEqual(t, first.ID, key.ID)
+		verification, err := manager.Verification()
+		assert.NoError(t, err)
+		if assert.Len(t, verification, 2) {
+			assert.Equal(t, key.ID, verification[0].ID)
+			assert.Equal(t, first.ID, verification[1].ID)
+		}
+		listed, err := manager.GetKeys()
+		assert.NoError(t, err)
+		if assert.Len(t, listed.Keys, 2) {
+			assert.Equal(t, internal.SigningKeyRetired, listed.Keys[1].Status)
+			assert.WithinDuration(t, time.Now().Add(time.Hour+time.Minute), *listed.Keys[1].ExpiresAt, time.Second)
+		}
+	})
+
+	t.Run("rotate when due and drop expired keys", func(t *testing.T) {
+		data.EXPECT().PurgeSigningKeys(gomock.Any()).Return(int64(0), nil).Times(2)
+		assert.NoError(t, manager.RotateDue())
+		assert.Len(t, keys, 2)
+
+		keys[0].CreatedAt = time.Now().Add(-31 * 24 * time.Hour)
+		expired := time.Now().Add(-time.Second)
+		keys[1].ExpiresAt = &expired
+		assert.NoError(t, manager.RotateDue())
+		assert.Len(t, keys, 3)
+		verification, err := manager.Verification()
+		assert.NoError(t, err)
+		assert.Len(t, verification, 2)
+	})
+}
+
+func TestKeyManagerMasterKey(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockSigningKeyData(mockCtrl)
+	var keys []internal.StoredSigningKey
+	storedKeys(data, &keys)
+	other := service.NewKeyManager(data, service.KeyManagerOptions{MasterKey: []byte("other")})
+	_, err := other.Rotate()
+	assert.NoError(t, err)
+
+	manager := service.NewKeyManager(data, service.KeyManagerOptions{MasterKey: []byte("master")})
+	key, err := manager.Signing()
+	assert.NoError(t, err)
+	assert.Equal(t, signing.RS256, key.Algorithm)
+	assert.Len(t, keys, 2)
+	verification, err := manager.Verification()
+	assert.NoError(t, err)
+	assert.Len(t, verification, 1)
+
+	_, err = service.NewKeyManager(data, service.KeyManagerOptions{}).Rotate()
+	assert.Error(t, err)
+	assert.Len(t, keys, 2)
+}
+
+func TestKeyManagerVerificationKey(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockSigningKeyData(mockCtrl)
+	var keys []internal.StoredSigningKey
+	storedKeys(data, &keys)
+	manager := service.NewKeyManager(data, service.KeyManagerOptions{MasterKey: []byte("master"), Algorithm: signing.EdDSA})
+	other := service.NewKeyManager(data, service.KeyManagerOptions{MasterKey: []byte("master"), Algorithm: signing.EdDSA})
+	assert.NoError(t, manager.Init())
+	first, err := manager.Signing()
+	assert.NoError(t, err)
+
+	t.Run("find known key", func(t *testing.T) {
+		key, err := manager.VerificationKey(first.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, first.ID, key.ID)
+	})
+
+	t.Run("reload for key rotated by another instance", func(t *testing.T) {
+		rotated, err := other.Rotate()
+		assert.NoError(t, err)
+		key, err := manager.VerificationKey(rotated.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, rotated.ID, key.ID)
+	})
+
+	t.Run("reload at most once in a while", func(t *testing.T) {
+		rotated, err := other.Rotate()
+		assert.NoError(t, err)
+		_, err = manager.VerificationKey(rotated.ID)
+		assert.Error(t, err)
+		_, err = manager.VerificationKey("unknown")
+		assert.Error(t, err)
+	})
+}
+
+func TestKeyManagerAuthorizeAdmin(t *testing.T) {
+	manager := service.NewKeyManager(nil, service.KeyManagerOptions{})
+	assert.NoError(t, manager.AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}))
+	assert.True(t, hasCode(manager.AuthorizeAdmin(internal.Caller{UserID: 1}), serviceerror.Forbidden))
+	assert.True(t, hasCode(manager.AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true, Scopes: []string{"users:read"}}), serviceerror.Forbidden))
+}
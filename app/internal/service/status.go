@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// statusTransitions lists the statuses a user may move to from each status.
+var statusTransitions = map[string][]string{
+	internal.StatusPending:   {internal.StatusActive, internal.StatusDisabled},
+	internal.StatusActive:    {internal.StatusSuspended, internal.StatusDisabled},
+	internal.StatusSuspended: {internal.StatusActive, internal.StatusDisabled},
+	internal.StatusDisabled:  {internal.StatusActive},
+}
+
+var statusEvents = map[string]string{
+	internal.StatusActive:    internal.EventUserReactivated,
+	internal.StatusSuspended: internal.EventUserSuspended,
+	internal.StatusDisabled:  internal.EventUserDisabled,
+}
+
+type userStatusService struct {
+	data    internal.UserData
+	auditor internal.Auditor
+}
+
+func NewUserStatusService(data internal.UserData, auditor internal.Auditor) *userStatusService {
+	return &userStatusService{
+		data:    data,
+		auditor: auditor,
+	}
+}
+
+// Suspend blocks the user, with an until time the suspension ends on its own
+// through ReactivateExpired.
+func (s *userStatusService) Suspend(id uint, reason string, until *time.Time) (err error) {
+	if until != nil && !until.After(time.Now()) {
+		return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, errors.New("suspension end is in the past"))
+	}
+	return s.transition(id, internal.StatusSuspended, reason, until)
+}
+
+// Reactivate activates a suspended or disabled user as well as a pending one.
+func (s *userStatusService) Reactivate(id uint, reason string) (err error) {
+	return s.transition(id, internal.StatusActive, reason, nil)
+}
+
+func (s *userStatusService) Disable(id uint, reason string) (err error) {
+	return s.transition(id, internal.StatusDisabled, reason, nil)
+}
+
+// ReactivateExpired activates every user whose suspension has ended. A user
+// that fails is logged and retried on the next run.
+func (s *userStatusService) ReactivateExpired() (err error) {
+	users, err := s.data.GetExpiredSuspensions(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		err := s.update(user, internal.StatusActive, "suspension ended", nil)
+		if err != nil {
+			log.WithError(err).WithField("user", user.ID).Error("reactivating user failed")
+		}
+	}
+	return nil
+}
+
+func (s *userStatusService) transition(id uint, status string, reason string, until *time.Time) (err error) {
+	user, err := s.data.GetUser(id)
+	if err != nil {
+		return err
+	}
+	if !allowedTransition(user.Status, status) {
+		return serviceerror.NewServiceError(serviceerror.InvalidStatusTransition, fmt.Errorf("user %d can't change from %s to %s", id, user.Status, status))
+	}
+	return s.update(user, status, reason, until)
+}
+
+func (s *userStatusService) update(user internal.UserResponse, status string, reason string, until *time.Time) (err error) {
+	err = s.data.UpdateUserStatus(internal.UserStatusUpdate{
+		ID:     user.ID,
+		From:   user.Status,
+		To:     status,
+		Reason: reason,
+		Until:  until,
+	})
+	if err != nil {
+		return err
+	}
+	s.auditor.Record(internal.AuditEvent{Type: statusEvents[status], UserID: user.ID, Email: user.Email, Detail: reason})
+	return nil
+}
+
+func allowedTransition(from string, to string) bool {
+	for _, status := range statusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// checkActive refuses to authenticate a user that isn't active.
+func checkActive(user internal.UserResponse) error {
+	if user.Status == internal.StatusActive {
+		return nil
+	}
+	return serviceerror.NewServiceError(serviceerror.UserInactive, fmt.Errorf("user is %s", user.Status))
+}
+
+// AuthorizeAdmin lets only admins who logged in change the status of users.
+func (s *userStatusService) AuthorizeAdmin(caller internal.Caller) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't change the status of users"))
+	}
+	if !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d is not an admin", caller.UserID))
+	}
+	return nil
+}
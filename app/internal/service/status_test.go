@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserStatus(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	auditor := mock.NewMockAuditor(mockCtrl)
+	handler := service.NewUserStatusService(data, auditor)
+	user := func(status string) internal.UserResponse {
+		return internal.UserResponse{ID: 1, Email: "test@gmail.com", Status: status}
+	}
+
+	t.Run("suspend until a time", func(t *testing.T) {
+		until := time.Now().Add(time.Hour)
+		data.EXPECT().GetUser(uint(1)).Return(user(internal.StatusActive), nil).Times(1)
+		data.EXPECT().UpdateUserStatus(internal.UserStatusUpdate{
+			ID:     1,
+			From:   internal.StatusActive,
+			To:     internal.StatusSuspended,
+			Reason: "abuse",
+			Until:  &until,
+		}).Return(nil).Times(1)
+		auditor.EXPECT().Record(internal.AuditEvent{Type: internal.EventUserSuspended, UserID: 1, Email: "test@gmail.com", Detail: "abuse"}).Times(1)
+		assert.NoError(t, handler.Suspend(1, "abuse", &until))
+	})
+
+	t.Run("error on suspension end in the past", func(t *testing.T) {
+		until := time.Now().Add(-time.Hour)
+		err := handler.Suspend(1, "abuse", &until)
+		assert.True(t, hasCode(err, serviceerror.InvalidUserRequest))
+	})
+
+	t.Run("activate pending user", func(t *testing.T) {
+		data.EXPECT().GetUser(uint(1)).Return(user(internal.StatusPending), nil).Times(1)
+		data.EXPECT().UpdateUserStatus(internal.UserStatusUpdate{ID: 1, From: internal.StatusPending, To: internal.StatusActive}).Return(nil).Times(1)
+		auditor.EXPECT().Record(gomock.Any()).Times(1)
+		assert.NoError(t, handler.Reactivate(1, ""))
+	})
+
+	t.Run("disable suspended user", func(t *testing.T) {
+		data.EXPECT().GetUser(uint(1)).Return(user(internal.StatusSuspended), nil).Times(1)
+		data.EXPECT().UpdateUserStatus(internal.UserStatusUpdate{ID: 1, From: internal.StatusSuspended, To: internal.StatusDisabled, Reason: "left"}).Return(nil).Times(1)
+		auditor.EXPECT().Record(internal.AuditEvent{Type: internal.EventUserDisabled, UserID: 1, Email: "test@gmail.com", Detail: "left"}).Times(1)
+		assert.NoError(t, handler.Disable(1, "left"))
+	})
+
+	t.Run("error on disallowed transition", func(t *testing.T) {
+		tests := []struct {
+			from   string
+			change func() error
+		}{
+			{internal.StatusActive, func() error { return handler.Reactivate(1, "") }},
+			{internal.StatusDisabled, func() error { return handler.Suspend(1, "", nil) }},
+			{internal.StatusDisabled, func() error { return handler.Disable(1, "") }},
+			{internal.StatusPending, func() error { return handler.Suspend(1, "", nil) }},
+		}
+		for _, test := range tests {
+			data.EXPECT().GetUser(uint(1)).Return(user(test.from), nil).Times(1)
+			assert.True(t, hasCode(test.change(), serviceerror.InvalidStatusTransition), test.from)
+		}
+	})
+
+	t.Run("error on unknown user", func(t *testing.T) {
+		notFound := serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))
+		data.EXPECT().GetUser(uint(2)).Return(internal.UserResponse{}, notFound).Times(1)
+		assert.Equal(t, notFound, handler.Disable(2, ""))
+	})
+
+	t.Run("reactivate expired suspensions", func(t *testing.T) {
+		data.EXPECT().GetExpiredSuspensions(gomock.Any()).Return([]internal.UserResponse{
+			{ID: 1, Status: internal.StatusSuspended},
+			{ID: 2, Status: internal.StatusSuspended},
+		}, nil).Times(1)
+		data.EXPECT().UpdateUserStatus(internal.UserStatusUpdate{ID: 1, From: internal.StatusSuspended, To: internal.StatusActive, Reason: "suspension ended"}).
+			Return(serviceerror.NewServiceError(serviceerror.InvalidStatusTransition, errors.New("test"))).Times(1)
+		data.EXPECT().UpdateUserStatus(internal.UserStatusUpdate{ID: 2, From: internal.StatusSuspended, To: internal.StatusActive, Reason: "suspension ended"}).
+			Return(nil).Times(1)
+		auditor.EXPECT().Record(internal.AuditEvent{Type: internal.EventUserReactivated, UserID: 2, Detail: "suspension ended"}).Times(1)
+		assert.NoError(t, handler.ReactivateExpired())
+	})
+}
+
+func TestUserStatusAuthorizeAdmin(t *testing.T) {
+	handler := service.NewUserStatusService(nil, nil)
+	assert.NoError(t, handler.AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true}))
+	assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 1}), serviceerror.Forbidden))
+	assert.True(t, hasCode(handler.AuthorizeAdmin(internal.Caller{UserID: 1, Admin: true, Scopes: []string{"users:write"}}), serviceerror.Forbidden))
+}
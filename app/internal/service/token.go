@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultAccessTokenTTL = time.Hour
+	// accessTokenType is the typ header of access tokens (RFC 9068), ID tokens
+	// are signed with the same keys but can't be passed off as access tokens.
+	accessTokenType = "at+jwt"
+)
+
+// tokenIssuer signs the access tokens handed out by every login flow with the
+// signing key, the kid header names the key they are verified with.
+type tokenIssuer struct {
+	keys internal.SigningKeys
+	ttl  time.Duration
+}
+
+func newTokenIssuer(keys internal.SigningKeys, ttl time.Duration) tokenIssuer {
+	if ttl == 0 {
+		ttl = defaultAccessTokenTTL
+	}
+	return tokenIssuer{
+		keys: keys,
+		ttl:  ttl,
+	}
+}
+
+func (t tokenIssuer) issue(user internal.UserResponse) (response internal.LoginResponse, err error) {
+	if t.keys == nil {
+		return response, errors.New("signing keys are not configured")
+	}
+	key, err := t.keys.Signing()
+	if err != nil {
+		return response, err
+	}
+	method := jwt.GetSigningMethod(key.Algorithm)
+	if method == nil {
+		return response, fmt.Errorf("signing algorithm %s is not supported", key.Algorithm)
+	}
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(t.ttl)),
+	}
+	jwtToken := jwt.NewWithClaims(method, claims)
+	jwtToken.Header["typ"] = accessTokenType
+	jwtToken.Header["kid"] = key.ID
+	token, err := jwtToken.SignedString(key.Key)
+	if err != nil {
+		return response, errors.Wrap(err, "sign access token failed")
+	}
+	response = internal.LoginResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(t.ttl.Seconds()),
+	}
+	return response, err
+}
+
+// verify checks the signature and expiry of an access token and returns its
+// user. The token must name a key that still verifies and be signed with its
+// algorithm.
+func (t tokenIssuer) verify(accessToken string) (userID uint, err error) {
+	if t.keys == nil {
+		return 0, errors.New("signing keys are not configured")
+	}
+	var claims jwt.RegisteredClaims
+	_, err = jwt.ParseWithClaims(accessToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Header["typ"] != accessTokenType {
+			return nil, fmt.Errorf("token type %v is not accepted", token.Header["typ"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, err := t.keys.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("signing method %s is not accepted for key %s", token.Method.Alg(), kid)
+		}
+		return key.Key.Public(), nil
+	})
+	if err != nil {
+		return 0, serviceerror.NewServiceError(serviceerror.Unauthenticated, errors.Wrap(err, "invalid access token"))
+	}
+	id, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || id == 0 {
+		return 0, serviceerror.NewServiceError(serviceerror.Unauthenticated, fmt.Errorf("invalid access token subject %s", claims.Subject))
+	}
+	return uint(id), nil
+}
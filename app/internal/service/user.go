@@ -2,43 +2,230 @@ package service
 
 import (
 	"fmt"
+	"time"
 	"usermanagement/app/internal"
 	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
+const defaultVerificationTokenTTL = 24 * time.Hour
+
+type UserOptions struct {
+	VerificationTokenTTL time.Duration
+}
+
 type userService struct {
-	data internal.UserData
+	data       internal.UserData
+	attributes internal.AttributeData
+	groups     internal.DynamicGroupService
+	notifier   internal.Notifier
+	options    UserOptions
 }
 
-func NewUserService(data internal.UserData) *userService {
+func NewUserService(data internal.UserData, attributes internal.AttributeData, groups internal.DynamicGroupService, notifier internal.Notifier, options UserOptions) *userService {
+	if options.VerificationTokenTTL == 0 {
+		options.VerificationTokenTTL = defaultVerificationTokenTTL
+	}
 	return &userService{
-		data: data,
+		data:       data,
+		attributes: attributes,
+		groups:     groups,
+		notifier:   notifier,
+		options:    options,
 	}
 }
 
 func (u *userService) CreateUser(request internal.UserRequest) (response internal.UserResponse, err error) {
-	return u.data.CreateUser(request)
+	err = u.checkAttributes(request.Attributes, 0)
+	if err != nil {
+		return response, err
+	}
+	response, err = u.data.CreateUser(request)
+	if err != nil {
+		return response, err
+	}
+	u.syncGroups(response.ID)
+	if response.Type == internal.UserServiceAccount {
+		return response, nil
+	}
+	if err := u.sendVerification(response, response.Email); err != nil {
+		log.WithError(err).WithField("user", response.ID).Error("sending email verification failed")
+	}
+	return response, nil
 }
 
+// UpdateUser keeps a changed email pending until the new address is confirmed,
+// the current address stays the login email and is told about the request.
 func (u *userService) UpdateUser(request internal.UpdateUserRequest) (err error) {
-	return u.data.UpdateUser(request)
+	if len(request.Attributes) > 0 {
+		err = u.checkAttributes(request.Attributes, request.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if request.Email == "" {
+		err = u.data.UpdateUser(request)
+		if err != nil {
+			return err
+		}
+		u.syncGroups(request.ID)
+		return nil
+	}
+	user, err := u.data.GetUser(request.ID)
+	if err != nil {
+		return err
+	}
+	err = u.data.UpdateUser(request)
+	if err != nil {
+		return err
+	}
+	u.syncGroups(request.ID)
+	if err := u.sendVerification(user, request.Email); err != nil {
+		log.WithError(err).WithField("user", user.ID).Error("sending email verification failed")
+	}
+	err = u.notifier.Notify(internal.Message{
+		To:      user.Email,
+		Subject: "Email change requested",
+		Body: fmt.Sprintf("Hello %s,\n\nA change of your email to %s was requested. It takes effect once the new address is confirmed.\n",
+			user.Name, request.Email),
+	})
+	if err != nil {
+		log.WithError(err).WithField("user", user.ID).Error("sending email change notice failed")
+	}
+	return nil
+}
+
+// syncGroups moves the user into the dynamic groups it matches now. A failure
+// only delays it until the groups are reconciled.
+func (u *userService) syncGroups(userID uint) {
+	if err := u.groups.SyncUser(userID); err != nil {
+		log.WithError(err).WithField("user", userID).Error("syncing dynamic groups failed")
+	}
+}
+
+// checkAttributes validates the attributes of a new user, or the changed
+// attributes of user userID, and refuses unique values held by another user.
+func (u *userService) checkAttributes(attributes map[string]interface{}, userID uint) (err error) {
+	schema, err := loadAttributeSchema(u.attributes)
+	if err != nil {
+		return err
+	}
+	return checkUserAttributes(u.data, schema, attributes, userID)
+}
+
+func checkUserAttributes(data internal.UserData, schema attributeSchema, attributes map[string]interface{}, userID uint) (err error) {
+	err = schema.validate(attributes, userID != 0)
+	if err != nil {
+		return err
+	}
+	for name, value := range attributes {
+		if value == nil || !schema[name].Unique {
+			continue
+		}
+		users, err := data.GetUsers(0, 2, internal.UsersFilter{Attributes: map[string]interface{}{name: value}})
+		if err != nil {
+			return err
+		}
+		for _, user := range users.Users {
+			if user.ID != userID {
+				return serviceerror.NewServiceError(serviceerror.DuplicateAttribute, fmt.Errorf("attribute %s value %v is taken", name, value))
+			}
+		}
+	}
+	return nil
+}
+
+func (u *userService) ResendVerification(id uint) (err error) {
+	user, err := u.data.GetUser(id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case user.PendingEmail != "":
+		return u.sendVerification(user, user.PendingEmail)
+	case !user.EmailVerified:
+		return u.sendVerification(user, user.Email)
+	}
+	return serviceerror.NewServiceError(serviceerror.InvalidUserRequest, fmt.Errorf("email of user %d is already verified", id))
+}
+
+func (u *userService) sendVerification(user internal.UserResponse, email string) (err error) {
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(u.options.VerificationTokenTTL)
+	err = u.data.CreateEmailVerification(user.ID, email, hashToken(token), expiresAt)
+	if err != nil {
+		return err
+	}
+	err = u.notifier.Notify(internal.Message{
+		To:      email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hello %s,\n\nUse the token below to verify your email. It expires at %s.\n\n%s\n",
+			user.Name, expiresAt.UTC().Format(time.RFC1123), token),
+	})
+	return errors.Wrap(err, "notify verification failed")
 }
 
-func (u *userService) DeleteUser(id uint) (err error) {
-	return u.data.DeleteUser(id)
+func (u *userService) DeleteUser(id uint, version uint) (err error) {
+	return u.data.DeleteUser(id, version)
 }
 
-func (u *userService) GetUsers(page uint, perPage uint) (response internal.UsersResponse, err error) {
+func (u *userService) RestoreUser(id uint) (err error) {
+	return u.data.RestoreUser(id)
+}
+
+func (u *userService) GetUsers(page uint, perPage uint, filter internal.UsersFilter) (response internal.UsersResponse, err error) {
 	if page <= 0 || perPage == 0 {
 		return response, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, fmt.Errorf("page %d  or per page %d is not valid", page, perPage))
 	}
+	if len(filter.Attributes) > 0 {
+		filter.Attributes, err = u.parseAttributeFilter(filter.Attributes)
+		if err != nil {
+			return response, err
+		}
+	}
 	offset := perPage * (page - 1)
-	response, err = u.data.GetUsers(offset, perPage)
+	response, err = u.data.GetUsers(offset, perPage, filter)
 	response.Page = page
 	response.PerPage = perPage
 	return response, err
 }
 
+// parseAttributeFilter converts filter values given as text, as they are in
+// a query string, to the types of their attributes.
+func (u *userService) parseAttributeFilter(attributes map[string]interface{}) (parsed map[string]interface{}, err error) {
+	schema, err := loadAttributeSchema(u.attributes)
+	if err != nil {
+		return parsed, err
+	}
+	parsed = make(map[string]interface{}, len(attributes))
+	for name, value := range attributes {
+		if text, ok := value.(string); ok {
+			value, err = schema.parse(name, text)
+			if err != nil {
+				return parsed, err
+			}
+		}
+		parsed[name] = value
+	}
+	return parsed, nil
+}
+
+func (u *userService) GetUser(id uint) (response internal.UserResponse, err error) {
+	return u.data.GetUser(id)
+}
+
 func (u *userService) ChangePassword(userID uint, password string) (err error) {
 	return u.data.ChangePassword(userID, password)
 }
+
+// ForOrganization returns the service for the users of the organization.
+func (u *userService) ForOrganization(organizationID uint) internal.UserService {
+	scoped := *u
+	scoped.data = u.data.ForOrganization(organizationID)
+	return &scoped
+}
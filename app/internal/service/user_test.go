@@ -1,6 +1,7 @@
 package service_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"usermanagement/app/internal"
@@ -16,13 +17,13 @@ func TestGetUsers(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	data := mock.NewMockUserData(mockCtrl)
-	handler := service.NewUserService(data)
+	handler := service.NewUserService(data, mock.NewMockAttributeData(mockCtrl), mock.NewMockDynamicGroupService(mockCtrl), mock.NewMockNotifier(mockCtrl), service.UserOptions{})
 
 	t.Run("get users successfully", func(t *testing.T) {
-		data.EXPECT().GetUsers(uint(100), uint(100)).Return(internal.UsersResponse{
+		data.EXPECT().GetUsers(uint(100), uint(100), internal.UsersFilter{Status: internal.StatusActive}).Return(internal.UsersResponse{
 			Total: 0,
 		}, nil).Times(1)
-		response, err := handler.GetUsers(2, 100)
+		response, err := handler.GetUsers(2, 100, internal.UsersFilter{Status: internal.StatusActive})
 		assert.NoError(t, err)
 		assert.Equal(t, internal.UsersResponse{
 			Total:   0,
@@ -32,14 +33,133 @@ func TestGetUsers(t *testing.T) {
 	})
 
 	t.Run("error on missing page", func(t *testing.T) {
-		response, err := handler.GetUsers(0, 100)
+		response, err := handler.GetUsers(0, 100, internal.UsersFilter{})
 		assert.Equal(t, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, fmt.Errorf("page %d  or per page %d is not valid", 0, 100)), err)
 		assert.Equal(t, internal.UsersResponse{}, response)
 	})
 
 	t.Run("error on missing perPage", func(t *testing.T) {
-		response, err := handler.GetUsers(1, 0)
+		response, err := handler.GetUsers(1, 0, internal.UsersFilter{})
 		assert.Equal(t, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, fmt.Errorf("page %d  or per page %d is not valid", 1, 0)), err)
 		assert.Equal(t, internal.UsersResponse{}, response)
 	})
 }
+
+func TestCreateUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	attributes := mock.NewMockAttributeData(mockCtrl)
+	groups := mock.NewMockDynamicGroupService(mockCtrl)
+	notifier := mock.NewMockNotifier(mockCtrl)
+	handler := service.NewUserService(data, attributes, groups, notifier, service.UserOptions{})
+	request := internal.UserRequest{Name: "test", Email: "test@gmail.com", Password: "12345678"}
+	user := internal.UserResponse{ID: 1, Name: "test", Email: "test@gmail.com"}
+
+	t.Run("create user and send verification", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(nil, nil).Times(1)
+		data.EXPECT().CreateUser(request).Return(user, nil).Times(1)
+		groups.EXPECT().SyncUser(uint(1)).Return(nil).Times(1)
+		data.EXPECT().CreateEmailVerification(uint(1), "test@gmail.com", gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		notifier.EXPECT().Notify(gomock.Any()).Do(func(message internal.Message) {
+			assert.Equal(t, "test@gmail.com", message.To)
+		}).Return(nil).Times(1)
+		response, err := handler.CreateUser(request)
+		assert.NoError(t, err)
+		assert.Equal(t, user, response)
+	})
+
+	t.Run("create user even if verification or dynamic groups fail", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(nil, nil).Times(1)
+		data.EXPECT().CreateUser(request).Return(user, nil).Times(1)
+		groups.EXPECT().SyncUser(uint(1)).Return(errors.New("test")).Times(1)
+		data.EXPECT().CreateEmailVerification(uint(1), "test@gmail.com", gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		notifier.EXPECT().Notify(gomock.Any()).Return(errors.New("test")).Times(1)
+		response, err := handler.CreateUser(request)
+		assert.NoError(t, err)
+		assert.Equal(t, user, response)
+	})
+
+	t.Run("create service account without verification", func(t *testing.T) {
+		request := internal.UserRequest{Name: "ci", Email: "ci@example.com", Type: internal.UserServiceAccount}
+		account := internal.UserResponse{ID: 2, Name: "ci", Email: "ci@example.com", Type: internal.UserServiceAccount}
+		attributes.EXPECT().GetAttributeDefinitions().Return(nil, nil).Times(1)
+		data.EXPECT().CreateUser(request).Return(account, nil).Times(1)
+		groups.EXPECT().SyncUser(uint(2)).Return(nil).Times(1)
+		response, err := handler.CreateUser(request)
+		assert.NoError(t, err)
+		assert.Equal(t, account, response)
+	})
+}
+
+func TestUpdateUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	groups := mock.NewMockDynamicGroupService(mockCtrl)
+	notifier := mock.NewMockNotifier(mockCtrl)
+	handler := service.NewUserService(data, mock.NewMockAttributeData(mockCtrl), groups, notifier, service.UserOptions{})
+
+	t.Run("update name without verification", func(t *testing.T) {
+		request := internal.UpdateUserRequest{ID: 1, Name: "test"}
+		data.EXPECT().UpdateUser(request).Return(nil).Times(1)
+		groups.EXPECT().SyncUser(uint(1)).Return(nil).Times(1)
+		err := handler.UpdateUser(request)
+		assert.NoError(t, err)
+	})
+
+	t.Run("verify new email and notify old email", func(t *testing.T) {
+		request := internal.UpdateUserRequest{ID: 1, Email: "new@gmail.com"}
+		data.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Name: "test", Email: "old@gmail.com"}, nil).Times(1)
+		data.EXPECT().UpdateUser(request).Return(nil).Times(1)
+		groups.EXPECT().SyncUser(uint(1)).Return(nil).Times(1)
+		data.EXPECT().CreateEmailVerification(uint(1), "new@gmail.com", gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		var recipients []string
+		notifier.EXPECT().Notify(gomock.Any()).Do(func(message internal.Message) {
+			recipients = append(recipients, message.To)
+		}).Return(nil).Times(2)
+		err := handler.UpdateUser(request)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"new@gmail.com", "old@gmail.com"}, recipients)
+	})
+
+	t.Run("error on unknown user", func(t *testing.T) {
+		request := internal.UpdateUserRequest{ID: 1, Email: "new@gmail.com"}
+		notFound := serviceerror.NewServiceError(serviceerror.UserNotFound, errors.New("test"))
+		data.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{}, notFound).Times(1)
+		err := handler.UpdateUser(request)
+		assert.Equal(t, notFound, err)
+	})
+}
+
+func TestResendVerification(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	notifier := mock.NewMockNotifier(mockCtrl)
+	handler := service.NewUserService(data, mock.NewMockAttributeData(mockCtrl), mock.NewMockDynamicGroupService(mockCtrl), notifier, service.UserOptions{})
+
+	t.Run("resend to pending email", func(t *testing.T) {
+		data.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{
+			ID: 1, Email: "old@gmail.com", EmailVerified: true, PendingEmail: "new@gmail.com",
+		}, nil).Times(1)
+		data.EXPECT().CreateEmailVerification(uint(1), "new@gmail.com", gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		notifier.EXPECT().Notify(gomock.Any()).Return(nil).Times(1)
+		err := handler.ResendVerification(1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("resend to unverified email", func(t *testing.T) {
+		data.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Email: "test@gmail.com"}, nil).Times(1)
+		data.EXPECT().CreateEmailVerification(uint(1), "test@gmail.com", gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		notifier.EXPECT().Notify(gomock.Any()).Return(nil).Times(1)
+		err := handler.ResendVerification(1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error on verified email", func(t *testing.T) {
+		data.EXPECT().GetUser(uint(1)).Return(internal.UserResponse{ID: 1, Email: "test@gmail.com", EmailVerified: true}, nil).Times(1)
+		err := handler.ResendVerification(1)
+		assert.Equal(t, serviceerror.NewServiceError(serviceerror.InvalidUserRequest, fmt.Errorf("email of user %d is already verified", 1)), err)
+	})
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/pkg/errors"
+)
+
+type userImportService struct {
+	users      internal.UserService
+	data       internal.UserData
+	attributes internal.AttributeData
+	groups     internal.GroupData
+	jobs       internal.JobService
+	// organization is the organization imports are limited to, if any
+	organization *uint
+}
+
+// NewUserImportService writes imported users through users, so they are
+// checked and notified as users created or updated one at a time. Imports
+// started with StartImport are run by jobs.
+func NewUserImportService(users internal.UserService, data internal.UserData, attributes internal.AttributeData, groups internal.GroupData, jobs internal.JobService) *userImportService {
+	return &userImportService{
+		users:      users,
+		data:       data,
+		attributes: attributes,
+		groups:     groups,
+		jobs:       jobs,
+	}
+}
+
+// importRun is the state of an import across its rows.
+type importRun struct {
+	schema attributeSchema
+	dryRun bool
+	emails map[string]int
+	unique map[string]int
+	groups map[string]uint
+}
+
+// ImportUsers imports the rows one at a time, a failing row doesn't stop the
+// import. Only errors other than service errors abort it.
+func (i *userImportService) ImportUsers(request internal.ImportUsersRequest) (response internal.ImportUsersResponse, err error) {
+	return i.importUsers(context.Background(), request, func(internal.JobProgress) {})
+}
+
+// StartImport queues the import as a job for its creator, its result is the
+// response ImportUsers would give. The passwords are hashed first so the
+// queue never holds them.
+func (i *userImportService) StartImport(request internal.ImportUsersRequest) (response internal.Job, err error) {
+	if i.organization != nil {
+		request.OrganizationID = i.organization
+	}
+	rows := make([]internal.ImportUserRow, len(request.Rows))
+	for n, row := range request.Rows {
+		if row.Password != "" {
+			row.PasswordHash, row.Salt = i.data.HashPassword(row.Password)
+			row.Password = ""
+		}
+		rows[n] = row
+	}
+	request.Rows = rows
+	return i.jobs.Enqueue(internal.JobImportUsers, request.CreatedBy, request)
+}
+
+// RunImportJob is the internal.JobHandler of internal.JobImportUsers. A
+// cancelled import keeps the rows imported so far, an import started for an
+// organization runs in it.
+func (i *userImportService) RunImportJob(ctx context.Context, payload []byte, progress func(internal.JobProgress)) (result interface{}, err error) {
+	var request internal.ImportUsersRequest
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, serviceerror.NewServiceError(serviceerror.InvalidJobRequest, errors.Wrap(err, "decode import failed"))
+	}
+	run := i
+	if request.OrganizationID != nil {
+		run = i.forOrganization(*request.OrganizationID)
+	}
+	response, err := run.importUsers(ctx, request, progress)
+	return response, err
+}
+
+func (i *userImportService) importUsers(ctx context.Context, request internal.ImportUsersRequest, progress func(internal.JobProgress)) (response internal.ImportUsersResponse, err error) {
+	schema, err := loadAttributeSchema(i.attributes)
+	if err != nil {
+		return response, err
+	}
+	run := &importRun{
+		schema: schema,
+		dryRun: request.DryRun,
+		emails: map[string]int{},
+		unique: map[string]int{},
+		groups: map[string]uint{},
+	}
+	response = internal.ImportUsersResponse{
+		DryRun:  request.DryRun,
+		Results: make([]internal.ImportUserResult, 0, len(request.Rows)),
+	}
+	total := int64(len(request.Rows))
+	for n, row := range request.Rows {
+		if err := ctx.Err(); err != nil {
+			return response, err
+		}
+		progress(internal.JobProgress{Done: int64(n), Total: total})
+		result, err := i.importUser(run, row)
+		if err != nil {
+			return response, errors.Wrapf(err, "import of row %d failed", row.Row)
+		}
+		switch result.Action {
+		case internal.ImportCreated:
+			response.Created++
+		case internal.ImportUpdated:
+			response.Updated++
+		case internal.ImportUnchanged:
+			response.Unchanged++
+		default:
+			response.Failed++
+		}
+		response.Results = append(response.Results, result)
+	}
+	progress(internal.JobProgress{Done: total, Total: total})
+	return response, nil
+}
+
+func (i *userImportService) importUser(run *importRun, row internal.ImportUserRow) (result internal.ImportUserResult, err error) {
+	result = internal.ImportUserResult{Row: row.Row, Email: row.Email, Action: internal.ImportFailed}
+	if row.Error != "" {
+		result.Error = row.Error
+		return result, nil
+	}
+	if previous, ok := run.emails[row.Email]; ok {
+		result.Error = fmt.Sprintf("email %s is already on row %d", row.Email, previous)
+		return result, nil
+	}
+	run.emails[row.Email] = row.Row
+	attributes, err := run.parseAttributes(row)
+	if err != nil {
+		return importFailure(result, err)
+	}
+	var groupID uint
+	if row.Group != "" {
+		groupID, err = i.groupID(run, row.Group)
+		if err != nil {
+			return importFailure(result, err)
+		}
+	}
+	user, err := i.data.GetUserByEmail(row.Email)
+	switch {
+	case hasErrorCode(err, serviceerror.UserNotFound):
+		result, err = i.createUser(run, result, row, attributes)
+	case err != nil:
+		return importFailure(result, err)
+	default:
+		result, err = i.updateUser(run, result, user, row, attributes)
+	}
+	if err != nil || result.Action == internal.ImportFailed || groupID == 0 {
+		return result, err
+	}
+	err = i.addToGroup(run, result.UserID, groupID)
+	var srvError *serviceerror.ServiceError
+	if err != nil && !errors.As(err, &srvError) {
+		return result, err
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("user not added to group %s: %s", row.Group, err.Error())
+	}
+	return result, nil
+}
+
+func (i *userImportService) createUser(run *importRun, result internal.ImportUserResult, row internal.ImportUserRow, attributes map[string]interface{}) (internal.ImportUserResult, error) {
+	if row.Password == "" && row.PasswordHash == "" {
+		return importFailure(result, serviceerror.NewServiceError(serviceerror.InvalidUserRequest,
+			fmt.Errorf("password is required for new user %s", row.Email)))
+	}
+	if run.dryRun {
+		if err := checkUserAttributes(i.data, run.schema, attributes, 0); err != nil {
+			return importFailure(result, err)
+		}
+		result.Action = internal.ImportCreated
+		return result, nil
+	}
+	user, err := i.users.CreateUser(internal.UserRequest{
+		Name:         row.Name,
+		Email:        row.Email,
+		Password:     row.Password,
+		PasswordHash: row.PasswordHash,
+		Salt:         row.Salt,
+		Status:       row.Status,
+		Attributes:   attributes,
+	})
+	if err != nil {
+		return importFailure(result, err)
+	}
+	result.Action = internal.ImportCreated
+	result.UserID = user.ID
+	return result, nil
+}
+
+// updateUser changes the name and the attributes given in the row, other
+// attributes of the user are kept.
+func (i *userImportService) updateUser(run *importRun, result internal.ImportUserResult, user internal.UserResponse, row internal.ImportUserRow, attributes map[string]interface{}) (internal.ImportUserResult, error) {
+	result.UserID = user.ID
+	request := internal.UpdateUserRequest{ID: user.ID}
+	if row.Name != "" && row.Name != user.Name {
+		request.Name = row.Name
+	}
+	for name, value := range attributes {
+		if current, ok := user.Attributes[name]; !ok || !reflect.DeepEqual(current, value) {
+			if request.Attributes == nil {
+				request.Attributes = map[string]interface{}{}
+			}
+			request.Attributes[name] = value
+		}
+	}
+	if request.Name == "" && len(request.Attributes) == 0 {
+		result.Action = internal.ImportUnchanged
+		return result, nil
+	}
+	var err error
+	switch {
+	case !run.dryRun:
+		err = i.users.UpdateUser(request)
+	case len(request.Attributes) > 0:
+		err = checkUserAttributes(i.data, run.schema, request.Attributes, user.ID)
+	}
+	if err != nil {
+		return importFailure(result, err)
+	}
+	result.Action = internal.ImportUpdated
+	return result, nil
+}
+
+// parseAttributes converts text values to the type of their attribute, as
+// CSV gives every value as text, and refuses unique values already taken by
+// an earlier row.
+func (r *importRun) parseAttributes(row internal.ImportUserRow) (parsed map[string]interface{}, err error) {
+	if len(row.Attributes) == 0 {
+		return nil, nil
+	}
+	parsed = make(map[string]interface{}, len(row.Attributes))
+	for name, value := range row.Attributes {
+		if text, ok := value.(string); ok {
+			value, err = r.schema.parse(name, text)
+			if err != nil {
+				return parsed, err
+			}
+		}
+		parsed[name] = value
+		if !r.schema[name].Unique {
+			continue
+		}
+		key := fmt.Sprintf("%s=%v", name, value)
+		if previous, ok := r.unique[key]; ok {
+			return parsed, serviceerror.NewServiceError(serviceerror.DuplicateAttribute,
+				fmt.Errorf("attribute %s value %v is already on row %d", name, value, previous))
+		}
+		r.unique[key] = row.Row
+	}
+	return parsed, nil
+}
+
+func (i *userImportService) groupID(run *importRun, name string) (id uint, err error) {
+	if id, ok := run.groups[name]; ok {
+		return id, nil
+	}
+	groups, err := i.groups.GetGroups(0, 1, internal.GroupsFilter{Name: name})
+	if err != nil {
+		return id, err
+	}
+	if len(groups.Groups) == 0 {
+		return id, serviceerror.NewServiceError(serviceerror.InvalidGroupRequest, fmt.Errorf("group %s not found", name))
+	}
+	run.groups[name] = groups.Groups[0].ID
+	return groups.Groups[0].ID, nil
+}
+
+// addToGroup adds the user to the group unless it already is a member, a user
+// belongs to one group at a time. userID is 0 for a user a dry run would
+// create.
+func (i *userImportService) addToGroup(run *importRun, userID uint, groupID uint) (err error) {
+	if userID == 0 {
+		return nil
+	}
+	memberships, err := i.groups.GetMemberships(userID)
+	if err != nil {
+		return err
+	}
+	for _, membership := range memberships {
+		if membership.RemovedAt != nil || (membership.ExpiresAt != nil && !membership.ExpiresAt.After(time.Now())) {
+			continue
+		}
+		if membership.GroupID == groupID {
+			return nil
+		}
+		return serviceerror.NewServiceError(serviceerror.InvalidUserGroupRequest,
+			fmt.Errorf("user %d is already in group %s", userID, membership.GroupName))
+	}
+	if run.dryRun {
+		return nil
+	}
+	return i.groups.AddUser(internal.AddUserRequest{UserID: userID, GroupID: groupID})
+}
+
+// AuthorizeGroups lets only admins who logged in import rows with a group,
+// the import adds users to groups without asking their owners.
+func (i *userImportService) AuthorizeGroups(caller internal.Caller) (err error) {
+	if caller.Scopes != nil {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, errors.New("access tokens can't import users into groups"))
+	}
+	if !caller.Admin {
+		return serviceerror.NewServiceError(serviceerror.Forbidden, fmt.Errorf("user %d can't import users into groups", caller.UserID))
+	}
+	return nil
+}
+
+// importFailure records a service error as the failure of the row, other
+// errors are returned to abort the import.
+func importFailure(result internal.ImportUserResult, err error) (internal.ImportUserResult, error) {
+	var srvError *serviceerror.ServiceError
+	if !errors.As(err, &srvError) {
+		return result, err
+	}
+	result.Action = internal.ImportFailed
+	result.Error = err.Error()
+	return result, nil
+}
+
+// ExportUsers streams the users matching filter to exporter, with the custom
+// attributes in name order.
+func (i *userImportService) ExportUsers(filter internal.UsersFilter, exporter internal.UserExporter) (err error) {
+	definitions, err := i.attributes.GetAttributeDefinitions()
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(definitions))
+	for n, definition := range definitions {
+		names[n] = definition.Name
+	}
+	sort.Strings(names)
+	if err := exporter.Begin(names); err != nil {
+		return err
+	}
+	return i.data.ExportUsers(filter, exporter.Export)
+}
+
+// ForOrganization returns the service importing and exporting the users of
+// the organization.
+func (i *userImportService) ForOrganization(organizationID uint) internal.UserImportService {
+	return i.forOrganization(organizationID)
+}
+
+func (i *userImportService) forOrganization(organizationID uint) *userImportService {
+	return &userImportService{
+		users:        i.users.ForOrganization(organizationID),
+		data:         i.data.ForOrganization(organizationID),
+		attributes:   i.attributes,
+		groups:       i.groups.ForOrganization(organizationID),
+		jobs:         i.jobs,
+		organization: &organizationID,
+	}
+}
@@ -0,0 +1,199 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"usermanagement/app/internal"
+	"usermanagement/app/internal/mock"
+	"usermanagement/app/internal/service"
+	"usermanagement/app/internal/serviceerror"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	users := mock.NewMockUserService(mockCtrl)
+	data := mock.NewMockUserData(mockCtrl)
+	attributes := mock.NewMockAttributeData(mockCtrl)
+	groups := mock.NewMockGroupData(mockCtrl)
+	handler := service.NewUserImportService(users, data, attributes, groups, mock.NewMockJobService(mockCtrl))
+	definitions := []internal.AttributeDefinition{
+		{Name: "employeeId", Type: internal.AttributeString, Unique: true},
+		{Name: "level", Type: internal.AttributeNumber},
+	}
+	notFound := func(email string) *gomock.Call {
+		return data.EXPECT().GetUserByEmail(email).
+			Return(internal.UserResponse{}, serviceerror.NewServiceError(serviceerror.UserNotFound, fmt.Errorf("user with email %s not found", email)))
+	}
+	existing := internal.UserResponse{ID: 2, Name: "old", Email: "old@gmail.com", Attributes: map[string]interface{}{"level": float64(1)}}
+
+	t.Run("create, update and add to group", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		groups.EXPECT().GetGroups(uint(0), uint(1), internal.GroupsFilter{Name: "eng"}).
+			Return(internal.GroupsResponse{Groups: []internal.GroupResponse{{ID: 5, Name: "eng"}}}, nil).Times(1)
+		notFound("new@gmail.com").Times(1)
+		users.EXPECT().CreateUser(internal.UserRequest{
+			Name: "new", Email: "new@gmail.com", Password: "12345678", Attributes: map[string]interface{}{"level": float64(3)},
+		}).Return(internal.UserResponse{ID: 1}, nil).Times(1)
+		groups.EXPECT().GetMemberships(uint(1)).Return(nil, nil).Times(1)
+		groups.EXPECT().AddUser(internal.AddUserRequest{UserID: 1, GroupID: 5}).Return(nil).Times(1)
+		data.EXPECT().GetUserByEmail("old@gmail.com").Return(existing, nil).Times(1)
+		users.EXPECT().UpdateUser(internal.UpdateUserRequest{ID: 2, Name: "renamed"}).Return(nil).Times(1)
+		groups.EXPECT().GetMemberships(uint(2)).Return([]internal.Membership{{GroupID: 5, GroupName: "eng"}}, nil).Times(1)
+
+		response, err := handler.ImportUsers(internal.ImportUsersRequest{Rows: []internal.ImportUserRow{
+			{Row: 1, Name: "new", Email: "new@gmail.com", Password: "12345678", Group: "eng", Attributes: map[string]interface{}{"level": "3"}},
+			{Row: 2, Name: "renamed", Email: "old@gmail.com", Group: "eng", Attributes: map[string]interface{}{"level": float64(1)}},
+			{Row: 3, Name: "again", Email: "old@gmail.com"},
+		}})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, response.Created)
+		assert.Equal(t, 1, response.Updated)
+		assert.Equal(t, 1, response.Failed)
+		assert.Equal(t, []internal.ImportUserResult{
+			{Row: 1, Email: "new@gmail.com", Action: internal.ImportCreated, UserID: 1},
+			{Row: 2, Email: "old@gmail.com", Action: internal.ImportUpdated, UserID: 2},
+			{Row: 3, Email: "old@gmail.com", Action: internal.ImportFailed, Error: "email old@gmail.com is already on row 2"},
+		}, response.Results)
+	})
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		notFound("new@gmail.com").Times(1)
+		data.EXPECT().GetUsers(uint(0), uint(2), internal.UsersFilter{Attributes: map[string]interface{}{"employeeId": "E1"}}).
+			Return(internal.UsersResponse{}, nil).Times(1)
+		data.EXPECT().GetUserByEmail("old@gmail.com").Return(existing, nil).Times(1)
+
+		response, err := handler.ImportUsers(internal.ImportUsersRequest{DryRun: true, Rows: []internal.ImportUserRow{
+			{Row: 1, Name: "new", Email: "new@gmail.com", Password: "12345678", Attributes: map[string]interface{}{"employeeId": "E1"}},
+			{Row: 2, Name: "old", Email: "old@gmail.com"},
+		}})
+		assert.NoError(t, err)
+		assert.True(t, response.DryRun)
+		assert.Equal(t, 1, response.Created)
+		assert.Equal(t, 1, response.Unchanged)
+	})
+
+	t.Run("report failing rows", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		groups.EXPECT().GetGroups(uint(0), uint(1), internal.GroupsFilter{Name: "ops"}).Return(internal.GroupsResponse{}, nil).Times(1)
+		notFound("nopassword@gmail.com").Times(1)
+		notFound("first@gmail.com").Times(1)
+		users.EXPECT().CreateUser(gomock.Any()).Return(internal.UserResponse{ID: 3}, nil).Times(1)
+
+		response, err := handler.ImportUsers(internal.ImportUsersRequest{Rows: []internal.ImportUserRow{
+			{Row: 1, Error: "invalid email"},
+			{Row: 2, Name: "test", Email: "nopassword@gmail.com"},
+			{Row: 3, Name: "test", Email: "ops@gmail.com", Password: "12345678", Group: "ops"},
+			{Row: 4, Name: "test", Email: "level@gmail.com", Password: "12345678", Attributes: map[string]interface{}{"level": "high"}},
+			{Row: 5, Name: "test", Email: "first@gmail.com", Password: "12345678", Attributes: map[string]interface{}{"employeeId": "E1"}},
+			{Row: 6, Name: "test", Email: "second@gmail.com", Password: "12345678", Attributes: map[string]interface{}{"employeeId": "E1"}},
+		}})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, response.Created)
+		assert.Equal(t, 5, response.Failed)
+		for _, result := range response.Results {
+			if result.Row != 5 {
+				assert.Equal(t, internal.ImportFailed, result.Action)
+				assert.NotEmpty(t, result.Error)
+			}
+		}
+	})
+
+	t.Run("abort on unknown error", func(t *testing.T) {
+		attributes.EXPECT().GetAttributeDefinitions().Return(definitions, nil).Times(1)
+		data.EXPECT().GetUserByEmail("new@gmail.com").Return(internal.UserResponse{}, errors.New("test")).Times(1)
+		_, err := handler.ImportUsers(internal.ImportUsersRequest{Rows: []internal.ImportUserRow{
+			{Row: 1, Name: "new", Email: "new@gmail.com", Password: "12345678"},
+		}})
+		assert.Error(t, err)
+	})
+}
+
+func TestImportUsersJob(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	attributes := mock.NewMockAttributeData(mockCtrl)
+	jobs := mock.NewMockJobService(mockCtrl)
+	handler := service.NewUserImportService(mock.NewMockUserService(mockCtrl), data, attributes, mock.NewMockGroupData(mockCtrl), jobs)
+	request := internal.ImportUsersRequest{DryRun: true, Rows: []internal.ImportUserRow{
+		{Row: 1, Name: "old", Email: "old@gmail.com"},
+		{Row: 2, Name: "other", Email: "other@gmail.com"},
+	}}
+
+	t.Run("start import as job", func(t *testing.T) {
+		jobs.EXPECT().Enqueue(internal.JobImportUsers, uint(0), request).Return(internal.Job{ID: 1}, nil).Times(1)
+		job, err := handler.StartImport(request)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), job.ID)
+	})
+
+	t.Run("queue hashed passwords for creator", func(t *testing.T) {
+		data.EXPECT().HashPassword("12345678").Return("hash", "salt").Times(1)
+		jobs.EXPECT().Enqueue(internal.JobImportUsers, uint(5), internal.ImportUsersRequest{CreatedBy: 5, Rows: []internal.ImportUserRow{
+			{Row: 1, Name: "new", Email: "new@gmail.com", PasswordHash: "hash", Salt: "salt"},
+		}}).Return(internal.Job{ID: 2}, nil).Times(1)
+		_, err := handler.StartImport(internal.ImportUsersRequest{CreatedBy: 5, Rows: []internal.ImportUserRow{
+			{Row: 1, Name: "new", Email: "new@gmail.com", Password: "12345678"},
+		}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("run import job with progress", func(t *testing.T) {
+		payload, _ := json.Marshal(request)
+		attributes.EXPECT().GetAttributeDefinitions().Return(nil, nil).Times(1)
+		data.EXPECT().GetUserByEmail("old@gmail.com").Return(internal.UserResponse{ID: 2, Name: "old"}, nil).Times(1)
+		data.EXPECT().GetUserByEmail("other@gmail.com").Return(internal.UserResponse{ID: 3, Name: "other"}, nil).Times(1)
+		var reported []internal.JobProgress
+		result, err := handler.RunImportJob(context.Background(), payload, func(progress internal.JobProgress) {
+			reported = append(reported, progress)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.(internal.ImportUsersResponse).Unchanged)
+		assert.Equal(t, internal.JobProgress{Done: 2, Total: 2}, reported[len(reported)-1])
+	})
+
+	t.Run("stop cancelled import", func(t *testing.T) {
+		payload, _ := json.Marshal(request)
+		attributes.EXPECT().GetAttributeDefinitions().Return(nil, nil).Times(1)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := handler.RunImportJob(ctx, payload, func(internal.JobProgress) {})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestExportUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	data := mock.NewMockUserData(mockCtrl)
+	attributes := mock.NewMockAttributeData(mockCtrl)
+	exporter := mock.NewMockUserExporter(mockCtrl)
+	handler := service.NewUserImportService(mock.NewMockUserService(mockCtrl), data, attributes, mock.NewMockGroupData(mockCtrl), mock.NewMockJobService(mockCtrl))
+
+	attributes.EXPECT().GetAttributeDefinitions().Return([]internal.AttributeDefinition{{Name: "level"}, {Name: "department"}}, nil).Times(1)
+	exporter.EXPECT().Begin([]string{"department", "level"}).Return(nil).Times(1)
+	data.EXPECT().ExportUsers(internal.UsersFilter{Status: internal.StatusActive}, gomock.Any()).
+		DoAndReturn(func(filter internal.UsersFilter, export func(internal.UserResponse) error) error {
+			return export(internal.UserResponse{ID: 1})
+		}).Times(1)
+	exporter.EXPECT().Export(internal.UserResponse{ID: 1}).Return(nil).Times(1)
+	assert.NoError(t, handler.ExportUsers(internal.UsersFilter{Status: internal.StatusActive}, exporter))
+}
+
+func TestAuthorizeGroups(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler := service.NewUserImportService(mock.NewMockUserService(mockCtrl), mock.NewMockUserData(mockCtrl), mock.NewMockAttributeData(mockCtrl),
+		mock.NewMockGroupData(mockCtrl), mock.NewMockJobService(mockCtrl))
+	assert.NoError(t, handler.AuthorizeGroups(internal.Caller{UserID: 1, Admin: true}))
+	assert.True(t, hasCode(handler.AuthorizeGroups(internal.Caller{UserID: 2}), serviceerror.Forbidden))
+	assert.True(t, hasCode(handler.AuthorizeGroups(internal.Caller{UserID: 1, Admin: true, Scopes: []string{"users:write"}}), serviceerror.Forbidden))
+}
@@ -1,10 +1,54 @@
 package serviceerror
 
 const (
-	InvalidUserRequest      ErrorCode = "Invalid User Request"
-	UserNotFound            ErrorCode = "UserNotFound"
-	InvalidGroupRequest     ErrorCode = "Invalid Group Request"
-	InvalidUserGroupRequest ErrorCode = "Invalid User Group Request"
-	DuplicateUser           ErrorCode = "Duplicate User"
-	DuplicateGroup          ErrorCode = "Duplicate Group"
+	InvalidUserRequest          ErrorCode = "Invalid User Request"
+	UserNotFound                ErrorCode = "UserNotFound"
+	InvalidGroupRequest         ErrorCode = "Invalid Group Request"
+	InvalidUserGroupRequest     ErrorCode = "Invalid User Group Request"
+	DuplicateUser               ErrorCode = "Duplicate User"
+	DuplicateGroup              ErrorCode = "Duplicate Group"
+	InvalidResetToken           ErrorCode = "Invalid Reset Token"
+	InvalidVerificationToken    ErrorCode = "Invalid Verification Token"
+	InvalidCredentials          ErrorCode = "Invalid Credentials"
+	InvalidMFACode              ErrorCode = "Invalid MFA Code"
+	MFANotEnrolled              ErrorCode = "MFA Not Enrolled"
+	MFAAlreadyEnabled           ErrorCode = "MFA Already Enabled"
+	InvalidPasskey              ErrorCode = "Invalid Passkey"
+	PasskeyNotFound             ErrorCode = "Passkey Not Found"
+	DuplicatePasskey            ErrorCode = "Duplicate Passkey"
+	TooManyAttempts             ErrorCode = "Too Many Attempts"
+	InvalidStatusTransition     ErrorCode = "Invalid Status Transition"
+	UserInactive                ErrorCode = "User Inactive"
+	InvalidAttribute            ErrorCode = "Invalid Attribute"
+	AttributeNotFound           ErrorCode = "Attribute Not Found"
+	DuplicateAttribute          ErrorCode = "Duplicate Attribute"
+	PreconditionFailed          ErrorCode = "Precondition Failed"
+	InvalidJobRequest           ErrorCode = "Invalid Job Request"
+	JobNotFound                 ErrorCode = "Job Not Found"
+	JobFinished                 ErrorCode = "Job Finished"
+	Unauthenticated             ErrorCode = "Unauthenticated"
+	Forbidden                   ErrorCode = "Forbidden"
+	LastGroupOwner              ErrorCode = "Last Group Owner"
+	DynamicGroupMembership      ErrorCode = "Dynamic Group Membership"
+	InvalidAccessRequest        ErrorCode = "Invalid Access Request"
+	AccessRequestNotFound       ErrorCode = "Access Request Not Found"
+	AccessRequestDecided        ErrorCode = "Access Request Decided"
+	InvalidReviewRequest        ErrorCode = "Invalid Review Request"
+	ReviewNotFound              ErrorCode = "Review Not Found"
+	ReviewDecided               ErrorCode = "Review Decided"
+	InvalidOrganizationRequest  ErrorCode = "Invalid Organization Request"
+	OrganizationNotFound        ErrorCode = "Organization Not Found"
+	DuplicateOrganization       ErrorCode = "Duplicate Organization"
+	CrossOrganizationMembership ErrorCode = "Cross Organization Membership"
+	InvalidAccessToken          ErrorCode = "Invalid Access Token"
+	AccessTokenNotFound         ErrorCode = "Access Token Not Found"
+	InvalidOAuthClientRequest   ErrorCode = "Invalid OAuth Client Request"
+	OAuthClientNotFound         ErrorCode = "OAuth Client Not Found"
+	OAuthInvalidRequest         ErrorCode = "OAuth Invalid Request"
+	OAuthInvalidClient          ErrorCode = "OAuth Invalid Client"
+	OAuthInvalidGrant           ErrorCode = "OAuth Invalid Grant"
+	OAuthUnauthorizedClient     ErrorCode = "OAuth Unauthorized Client"
+	OAuthUnsupportedGrantType   ErrorCode = "OAuth Unsupported Grant Type"
+	OAuthUnsupportedResponse    ErrorCode = "OAuth Unsupported Response Type"
+	OAuthInvalidScope           ErrorCode = "OAuth Invalid Scope"
 )
@@ -10,6 +10,22 @@ import (
 
 type ErrorCode string
 
+var statusCodes = map[ErrorCode]int{
+	InvalidCredentials:      http.StatusUnauthorized,
+	TooManyAttempts:         http.StatusTooManyRequests,
+	UserInactive:            http.StatusForbidden,
+	InvalidStatusTransition: http.StatusConflict,
+	PreconditionFailed:      http.StatusPreconditionFailed,
+	JobFinished:             http.StatusConflict,
+	Unauthenticated:         http.StatusUnauthorized,
+	Forbidden:               http.StatusForbidden,
+	LastGroupOwner:          http.StatusConflict,
+	DynamicGroupMembership:  http.StatusConflict,
+	AccessRequestDecided:    http.StatusConflict,
+	ReviewDecided:           http.StatusConflict,
+	OAuthInvalidClient:      http.StatusUnauthorized,
+}
+
 type ServiceError struct {
 	Code ErrorCode
 	Err  error
@@ -30,9 +46,23 @@ func AbortOnError(c *gin.Context, err error) {
 	var srvError *ServiceError
 	if ok := errors.As(err, &srvError); ok {
 		log.WithError(err).Error("service error")
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		c.AbortWithStatusJSON(StatusOf(err), gin.H{"message": err.Error()})
 		return
 	}
 	log.WithError(err).Error("unknown error")
 	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 }
+
+// StatusOf returns the HTTP status a service error is answered with, 500 for
+// other errors.
+func StatusOf(err error) int {
+	var srvError *ServiceError
+	if !errors.As(err, &srvError) {
+		return http.StatusInternalServerError
+	}
+	status, ok := statusCodes[srvError.Code]
+	if !ok {
+		return http.StatusBadRequest
+	}
+	return status
+}
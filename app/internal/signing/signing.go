@@ -0,0 +1,183 @@
+// Package signing handles the keys tokens are signed with: generating them,
+// naming them by their RFC 7638 thumbprint and publishing them as JSON Web
+// Keys (RFC 7517). RSA keys sign with RS256, Ed25519 keys with EdDSA. Private
+// keys are stored encrypted with AES-256-GCM under a master key.
+package signing
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"usermanagement/app/internal"
+)
+
+const (
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// Generate creates a private key for the algorithm, RSA keys have 2048 bits.
+func Generate(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case RS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case EdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+	return nil, fmt.Errorf("algorithm %s is not supported", algorithm)
+}
+
+// EncryptPrivateKey encrypts a private key with the master key, which any
+// secret of enough entropy can be, its SHA-256 is the AES key. The key ID is
+// authenticated, the encrypted key can't be passed off as another key.
+func EncryptPrivateKey(key crypto.Signer, keyID string, masterKey []byte) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(keyID)), nil
+}
+
+// DecryptPrivateKey decrypts a private key EncryptPrivateKey encrypted, the
+// key is PEM encoded PKCS #8.
+func DecryptPrivateKey(ciphertext []byte, keyID string, masterKey []byte) (crypto.Signer, error) {
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("encrypted key is too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("decrypting key %s: %w", keyID, err)
+	}
+	block, _ := pem.Decode(plaintext)
+	if block == nil {
+		return nil, fmt.Errorf("decrypted key %s is not PEM encoded", keyID)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok || Algorithm(signer.Public()) == "" {
+		return nil, fmt.Errorf("key of type %T is not supported", key)
+	}
+	return signer, nil
+}
+
+func newAEAD(masterKey []byte) (cipher.AEAD, error) {
+	if len(masterKey) == 0 {
+		return nil, errors.New("no master key")
+	}
+	sum := sha256.Sum256(masterKey)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Algorithm returns the algorithm a public key verifies, empty for keys of
+// other types.
+func Algorithm(public crypto.PublicKey) string {
+	switch public.(type) {
+	case *rsa.PublicKey:
+		return RS256
+	case ed25519.PublicKey:
+		return EdDSA
+	}
+	return ""
+}
+
+// Sign signs a message with the key, RSA keys with PKCS #1 v1.5 over its
+// SHA-256, Ed25519 keys the message itself.
+func Sign(key internal.SigningKey, message []byte) ([]byte, error) {
+	switch key.Algorithm {
+	case RS256:
+		sum := sha256.Sum256(message)
+		return key.Key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	case EdDSA:
+		return key.Key.Sign(rand.Reader, message, crypto.Hash(0))
+	}
+	return nil, fmt.Errorf("algorithm %s is not supported", key.Algorithm)
+}
+
+// Verify checks a signature Sign made with the private key of public.
+func Verify(public crypto.PublicKey, message []byte, signature []byte) error {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		sum := sha256.Sum256(message)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, message, signature) {
+			return errors.New("ed25519: invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("key of type %T is not supported", public)
+}
+
+// NewKey names a private key by the thumbprint of its public key.
+func NewKey(key crypto.Signer) (internal.SigningKey, error) {
+	jwk, err := PublicJWK(key.Public())
+	if err != nil {
+		return internal.SigningKey{}, err
+	}
+	return internal.SigningKey{ID: jwk.Kid, Algorithm: jwk.Alg, Key: key}, nil
+}
+
+// PublicJWK returns a public key as a JSON Web Key for signatures, its kid is
+// the RFC 7638 thumbprint of the key.
+func PublicJWK(public crypto.PublicKey) (jwk internal.JSONWebKey, err error) {
+	var thumbprint string
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		jwk = internal.JSONWebKey{
+			Kty: "RSA",
+			N:   encode(key.N.Bytes()),
+			E:   encode(big.NewInt(int64(key.E)).Bytes()),
+		}
+		thumbprint = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	case ed25519.PublicKey:
+		jwk = internal.JSONWebKey{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   encode(key),
+		}
+		thumbprint = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, jwk.X)
+	default:
+		return jwk, fmt.Errorf("key of type %T is not supported", public)
+	}
+	sum := sha256.Sum256([]byte(thumbprint))
+	jwk.Kid = encode(sum[:])
+	jwk.Use = "sig"
+	jwk.Alg = Algorithm(public)
+	return jwk, nil
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,71 @@
+package signing_test
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"usermanagement/app/internal/signing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the RSA key of RFC 7638 section 3.1, its thumbprint is NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs
+const rfcModulus = "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
+
+func TestPublicJWK(t *testing.T) {
+	modulus, err := base64.RawURLEncoding.DecodeString(rfcModulus)
+	assert.NoError(t, err)
+	jwk, err := signing.PublicJWK(&rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: 65537})
+	assert.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", jwk.Kid)
+	assert.Equal(t, "RSA", jwk.Kty)
+	assert.Equal(t, rfcModulus, jwk.N)
+	assert.Equal(t, "AQAB", jwk.E)
+	assert.Equal(t, signing.RS256, jwk.Alg)
+	assert.Equal(t, "sig", jwk.Use)
+}
+
+func TestEncryptPrivateKey(t *testing.T) {
+	for _, algorithm := range []string{signing.RS256, signing.EdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			key, err := signing.Generate(algorithm)
+			assert.NoError(t, err)
+			encrypted, err := signing.EncryptPrivateKey(key, "kid", []byte("master"))
+			assert.NoError(t, err)
+			decrypted, err := signing.DecryptPrivateKey(encrypted, "kid", []byte("master"))
+			assert.NoError(t, err)
+			assert.Equal(t, key.Public(), decrypted.Public())
+			signingKey, err := signing.NewKey(decrypted)
+			assert.NoError(t, err)
+			assert.Equal(t, algorithm, signingKey.Algorithm)
+		})
+	}
+
+	key, err := signing.Generate(signing.EdDSA)
+	assert.NoError(t, err)
+	encrypted, err := signing.EncryptPrivateKey(key, "kid", []byte("master"))
+	assert.NoError(t, err)
+
+	_, err = signing.DecryptPrivateKey(encrypted, "kid", []byte("other"))
+	assert.Error(t, err)
+	_, err = signing.DecryptPrivateKey(encrypted, "other", []byte("master"))
+	assert.Error(t, err)
+	_, err = signing.EncryptPrivateKey(key, "kid", nil)
+	assert.Error(t, err)
+}
+
+func TestSign(t *testing.T) {
+	for _, algorithm := range []string{signing.RS256, signing.EdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			generated, err := signing.Generate(algorithm)
+			assert.NoError(t, err)
+			key, err := signing.NewKey(generated)
+			assert.NoError(t, err)
+			signature, err := signing.Sign(key, []byte("report"))
+			assert.NoError(t, err)
+			assert.NoError(t, signing.Verify(generated.Public(), []byte("report"), signature))
+			assert.Error(t, signing.Verify(generated.Public(), []byte("changed"), signature))
+		})
+	}
+}
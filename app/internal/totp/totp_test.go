@@ -0,0 +1,54 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+	"usermanagement/app/internal/totp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 appendix B uses the ASCII secret "12345678901234567890" for SHA1.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+	for _, test := range tests {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(test.unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, test.code, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	t.Run("accept current and adjacent period", func(t *testing.T) {
+		step, ok := totp.Validate(rfcSecret, "081804", now)
+		assert.True(t, ok)
+		assert.Equal(t, totp.Step(now), step)
+		_, ok = totp.Validate(rfcSecret, "081804", now.Add(totp.Period*time.Second))
+		assert.True(t, ok)
+	})
+
+	t.Run("reject old and malformed codes", func(t *testing.T) {
+		_, ok := totp.Validate(rfcSecret, "081804", now.Add(3*totp.Period*time.Second))
+		assert.False(t, ok)
+		_, ok = totp.Validate(rfcSecret, "0818", now)
+		assert.False(t, ok)
+	})
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("usermanagement", "test@gmail.com", "JBSWY3DPEHPK3PXP")
+	assert.Equal(t, "otpauth://totp/usermanagement:test@gmail.com?algorithm=SHA1&digits=6&issuer=usermanagement&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
@@ -0,0 +1,134 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// COSE algorithm identifiers.
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// COSE key labels and values, RFC 8152 section 7 and 13.
+const (
+	coseKty     = 1
+	coseAlg     = 3
+	coseCrv     = -1
+	coseX       = -2
+	coseY       = -3
+	coseN       = -1
+	coseE       = -2
+	ktyOKP      = 1
+	ktyEC2      = 2
+	ktyRSA      = 3
+	crvP256     = 1
+	crvEd25519  = 6
+	minRSABytes = 256
+)
+
+type publicKey struct {
+	alg int
+	key crypto.PublicKey
+}
+
+func parsePublicKey(data []byte) (key publicKey, err error) {
+	var fields map[int]cbor.RawMessage
+	if err = cbor.Unmarshal(data, &fields); err != nil {
+		return key, invalid("credential public key is not a cose key: %v", err)
+	}
+	var kty, alg int
+	if err = decodeField(fields, coseKty, &kty); err != nil {
+		return key, err
+	}
+	if err = decodeField(fields, coseAlg, &alg); err != nil {
+		return key, err
+	}
+	key.alg = alg
+	switch {
+	case kty == ktyEC2 && alg == AlgES256:
+		var crv int
+		var x, y []byte
+		if err = decodeField(fields, coseCrv, &crv); err != nil {
+			return key, err
+		}
+		if err = decodeField(fields, coseX, &x); err != nil {
+			return key, err
+		}
+		if err = decodeField(fields, coseY, &y); err != nil {
+			return key, err
+		}
+		curve := elliptic.P256()
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if crv != crvP256 || len(x) != 32 || len(y) != 32 || !curve.IsOnCurve(pub.X, pub.Y) {
+			return key, invalid("es256 key is not a valid p-256 point")
+		}
+		key.key = pub
+	case kty == ktyOKP && alg == AlgEdDSA:
+		var crv int
+		var x []byte
+		if err = decodeField(fields, coseCrv, &crv); err != nil {
+			return key, err
+		}
+		if err = decodeField(fields, coseX, &x); err != nil {
+			return key, err
+		}
+		if crv != crvEd25519 || len(x) != ed25519.PublicKeySize {
+			return key, invalid("eddsa key is not a valid ed25519 key")
+		}
+		key.key = ed25519.PublicKey(x)
+	case kty == ktyRSA && alg == AlgRS256:
+		var n, e []byte
+		if err = decodeField(fields, coseN, &n); err != nil {
+			return key, err
+		}
+		if err = decodeField(fields, coseE, &e); err != nil {
+			return key, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) < minRSABytes || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return key, invalid("rs256 key is not a valid rsa key")
+		}
+		key.key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+	default:
+		return key, invalid("unsupported key type %d with algorithm %d", kty, alg)
+	}
+	return key, nil
+}
+
+func (k publicKey) verify(data []byte, signature []byte) error {
+	digest := sha256.Sum256(data)
+	ok := false
+	switch pub := k.key.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(pub, digest[:], signature)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(pub, data, signature)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) == nil
+	}
+	if !ok {
+		return invalid("signature does not verify")
+	}
+	return nil
+}
+
+func decodeField(fields map[int]cbor.RawMessage, label int, value interface{}) error {
+	raw, ok := fields[label]
+	if !ok {
+		return invalid("cose key field %d is missing", label)
+	}
+	if err := cbor.Unmarshal(raw, value); err != nil {
+		return invalid("cose key field %d is malformed: %v", label, err)
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+// Package webauthn implements the relying party side of the WebAuthn
+// registration and assertion ceremonies. Attestation is not requested, so
+// attestation statements are not verified, and ES256, EdDSA and RS256
+// credential keys are supported.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	CeremonyCreate = "webauthn.create"
+	CeremonyGet    = "webauthn.get"
+
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+
+	defaultTimeout     = 5 * time.Minute
+	challengeLength    = 32
+	maxCredentialIDLen = 1023
+)
+
+// ErrInvalidResponse is wrapped by every verification failure so callers can
+// tell a rejected ceremony from an internal error.
+var ErrInvalidResponse = errors.New("invalid webauthn response")
+
+type Config struct {
+	RPID    string
+	RPName  string
+	Origins []string
+	// UserVerification is "required", "preferred" or "discouraged". Only
+	// "required" is enforced when verifying responses.
+	UserVerification string
+	Timeout          time.Duration
+}
+
+type RelyingParty struct {
+	config Config
+}
+
+func New(config Config) *RelyingParty {
+	if config.RPName == "" {
+		config.RPName = config.RPID
+	}
+	if config.UserVerification == "" {
+		config.UserVerification = "preferred"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+	return &RelyingParty{
+		config: config,
+	}
+}
+
+func (r *RelyingParty) Timeout() time.Duration {
+	return r.config.Timeout
+}
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// AuthenticatorSelection requires discoverable credentials, logins don't list
+// the credentials of a user. RequireResidentKey is for browsers of level 1.
+type AuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey,omitempty"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification,omitempty"`
+}
+
+// CreationOptions is the JSON form of PublicKeyCredentialCreationOptions with
+// binary values base64url encoded.
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout,omitempty"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions is the JSON form of PublicKeyCredentialRequestOptions. An
+// empty AllowCredentials lets the authenticator offer discoverable credentials.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout,omitempty"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification,omitempty"`
+}
+
+type AttestationResponse struct {
+	ClientDataJSON    string   `json:"clientDataJSON"`
+	AttestationObject string   `json:"attestationObject"`
+	Transports        []string `json:"transports,omitempty"`
+}
+
+// Registration is the JSON form of the PublicKeyCredential returned by
+// navigator.credentials.create().
+type Registration struct {
+	ID       string              `json:"id"`
+	RawID    string              `json:"rawId"`
+	Type     string              `json:"type"`
+	Response AttestationResponse `json:"response"`
+}
+
+type AssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
+
+// Assertion is the JSON form of the PublicKeyCredential returned by
+// navigator.credentials.get().
+type Assertion struct {
+	ID       string            `json:"id"`
+	RawID    string            `json:"rawId"`
+	Type     string            `json:"type"`
+	Response AssertionResponse `json:"response"`
+}
+
+// Credential is what the relying party keeps of a registered authenticator.
+type Credential struct {
+	ID           string
+	PublicKey    []byte
+	SignCount    uint32
+	Transports   []string
+	UserVerified bool
+}
+
+type ClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin,omitempty"`
+}
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+}
+
+type attestationObject struct {
+	Fmt      string          `cbor:"fmt"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+// NewChallenge returns a random base64url encoded challenge.
+func NewChallenge() (string, error) {
+	b := make([]byte, challengeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return Encode(b), nil
+}
+
+func Encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode accepts base64url with or without padding.
+func Decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func (r *RelyingParty) CreationOptions(challenge string, user UserEntity, exclude []CredentialDescriptor) CreationOptions {
+	return CreationOptions{
+		Challenge: challenge,
+		RP: RelyingPartyEntity{
+			ID:   r.config.RPID,
+			Name: r.config.RPName,
+		},
+		User: user,
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            r.config.Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   r.config.UserVerification,
+		},
+		Attestation: "none",
+	}
+}
+
+func (r *RelyingParty) RequestOptions(challenge string, allow []CredentialDescriptor) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          r.config.Timeout.Milliseconds(),
+		RPID:             r.config.RPID,
+		AllowCredentials: allow,
+		UserVerification: r.config.UserVerification,
+	}
+}
+
+// ParseClientData decodes the base64url clientDataJSON of a response. The
+// challenge in it is used to look up the pending ceremony before verifying.
+func ParseClientData(clientDataJSON string) (clientData ClientData, err error) {
+	raw, err := Decode(clientDataJSON)
+	if err != nil {
+		return clientData, invalid("client data is not base64url: %v", err)
+	}
+	if err = json.Unmarshal(raw, &clientData); err != nil {
+		return clientData, invalid("client data is not json: %v", err)
+	}
+	return clientData, nil
+}
+
+// VerifyRegistration checks a navigator.credentials.create() response against
+// the challenge issued for it and returns the credential to store.
+func (r *RelyingParty) VerifyRegistration(registration Registration, challenge string) (credential Credential, err error) {
+	if registration.Type != "public-key" {
+		return credential, invalid("credential type %q is not public-key", registration.Type)
+	}
+	if _, err = r.verifyClientData(registration.Response.ClientDataJSON, CeremonyCreate, challenge); err != nil {
+		return credential, err
+	}
+	raw, err := Decode(registration.Response.AttestationObject)
+	if err != nil {
+		return credential, invalid("attestation object is not base64url: %v", err)
+	}
+	var attestation attestationObject
+	if err = cbor.Unmarshal(raw, &attestation); err != nil {
+		return credential, invalid("attestation object is not cbor: %v", err)
+	}
+	authData, err := parseAuthenticatorData(attestation.AuthData)
+	if err != nil {
+		return credential, err
+	}
+	if err = r.verifyAuthenticatorData(authData); err != nil {
+		return credential, err
+	}
+	if authData.flags&flagAttestedCredentialData == 0 {
+		return credential, invalid("attested credential data is missing")
+	}
+	if registration.RawID != "" {
+		rawID, err := Decode(registration.RawID)
+		if err != nil || !bytes.Equal(rawID, authData.credentialID) {
+			return credential, invalid("raw id does not match the attested credential")
+		}
+	}
+	if _, err = parsePublicKey(authData.publicKey); err != nil {
+		return credential, err
+	}
+	credential = Credential{
+		ID:           Encode(authData.credentialID),
+		PublicKey:    authData.publicKey,
+		SignCount:    authData.signCount,
+		Transports:   registration.Response.Transports,
+		UserVerified: authData.flags&flagUserVerified != 0,
+	}
+	return credential, nil
+}
+
+// VerifyAssertion checks a navigator.credentials.get() response signed by the
+// stored credential and returns the new sign counter. A counter that does not
+// increase means the authenticator may have been cloned and is rejected.
+func (r *RelyingParty) VerifyAssertion(assertion Assertion, challenge string, publicKey []byte, signCount uint32) (newSignCount uint32, err error) {
+	if assertion.Type != "public-key" {
+		return 0, invalid("credential type %q is not public-key", assertion.Type)
+	}
+	clientDataJSON, err := r.verifyClientData(assertion.Response.ClientDataJSON, CeremonyGet, challenge)
+	if err != nil {
+		return 0, err
+	}
+	rawAuthData, err := Decode(assertion.Response.AuthenticatorData)
+	if err != nil {
+		return 0, invalid("authenticator data is not base64url: %v", err)
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	if err = r.verifyAuthenticatorData(authData); err != nil {
+		return 0, err
+	}
+	signature, err := Decode(assertion.Response.Signature)
+	if err != nil {
+		return 0, invalid("signature is not base64url: %v", err)
+	}
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err = key.verify(signed, signature); err != nil {
+		return 0, err
+	}
+	if (authData.signCount != 0 || signCount != 0) && authData.signCount <= signCount {
+		return 0, invalid("sign count %d is not above %d", authData.signCount, signCount)
+	}
+	return authData.signCount, nil
+}
+
+func (r *RelyingParty) verifyClientData(clientDataJSON string, ceremony string, challenge string) (raw []byte, err error) {
+	raw, err = Decode(clientDataJSON)
+	if err != nil {
+		return nil, invalid("client data is not base64url: %v", err)
+	}
+	var clientData ClientData
+	if err = json.Unmarshal(raw, &clientData); err != nil {
+		return nil, invalid("client data is not json: %v", err)
+	}
+	if clientData.Type != ceremony {
+		return nil, invalid("client data type %q is not %q", clientData.Type, ceremony)
+	}
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return nil, invalid("challenge does not match")
+	}
+	for _, origin := range r.config.Origins {
+		if clientData.Origin == origin {
+			return raw, nil
+		}
+	}
+	return nil, invalid("origin %q is not allowed", clientData.Origin)
+}
+
+func (r *RelyingParty) verifyAuthenticatorData(authData authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(r.config.RPID))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return invalid("rp id hash does not match %q", r.config.RPID)
+	}
+	if authData.flags&flagUserPresent == 0 {
+		return invalid("user was not present")
+	}
+	if r.config.UserVerification == "required" && authData.flags&flagUserVerified == 0 {
+		return invalid("user was not verified")
+	}
+	return nil
+}
+
+func parseAuthenticatorData(data []byte) (authData authenticatorData, err error) {
+	if len(data) < 37 {
+		return authData, invalid("authenticator data is too short")
+	}
+	authData = authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if authData.flags&flagAttestedCredentialData == 0 {
+		return authData, nil
+	}
+	// attested credential data: aaguid(16) | length(2) | credential id | cose key
+	rest := data[37:]
+	if len(rest) < 18 {
+		return authData, invalid("attested credential data is too short")
+	}
+	length := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if length == 0 || length > maxCredentialIDLen || len(rest) < length {
+		return authData, invalid("credential id length %d is invalid", length)
+	}
+	authData.credentialID = rest[:length]
+	var key cbor.RawMessage
+	if err = cbor.NewDecoder(bytes.NewReader(rest[length:])).Decode(&key); err != nil {
+		return authData, invalid("credential public key is not cbor: %v", err)
+	}
+	authData.publicKey = key
+	return authData, nil
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidResponse}, args...)...)
+}
@@ -0,0 +1,146 @@
+package webauthn_test
+
+import (
+	"errors"
+	"testing"
+	"usermanagement/app/internal/webauthn"
+	"usermanagement/app/internal/webauthn/webauthntest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const origin = "https://login.example.com"
+
+func newRelyingParty(userVerification string) *webauthn.RelyingParty {
+	return webauthn.New(webauthn.Config{
+		RPID:             "example.com",
+		RPName:           "Example",
+		Origins:          []string{origin},
+		UserVerification: userVerification,
+	})
+}
+
+func register(t *testing.T, rp *webauthn.RelyingParty, authenticator *webauthntest.Authenticator) (webauthn.Credential, error) {
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	options := rp.CreationOptions(challenge, webauthn.UserEntity{ID: "MQ", Name: "test@gmail.com", DisplayName: "test"}, nil)
+	registration, err := authenticator.Register(options)
+	require.NoError(t, err)
+	return rp.VerifyRegistration(registration, challenge)
+}
+
+func TestCeremonies(t *testing.T) {
+	for _, alg := range []int{webauthn.AlgES256, webauthn.AlgEdDSA} {
+		rp := newRelyingParty("")
+		authenticator := webauthntest.NewAuthenticator(origin)
+		authenticator.Alg = alg
+
+		credential, err := register(t, rp, authenticator)
+		require.NoError(t, err)
+		assert.True(t, credential.UserVerified)
+		assert.Equal(t, uint32(0), credential.SignCount)
+		assert.Equal(t, []string{"internal"}, credential.Transports)
+
+		challenge, err := webauthn.NewChallenge()
+		require.NoError(t, err)
+		options := rp.RequestOptions(challenge, []webauthn.CredentialDescriptor{{Type: "public-key", ID: credential.ID}})
+		assertion, err := authenticator.Login(options)
+		require.NoError(t, err)
+		clientData, err := webauthn.ParseClientData(assertion.Response.ClientDataJSON)
+		require.NoError(t, err)
+		assert.Equal(t, challenge, clientData.Challenge)
+		assert.Equal(t, "MQ", assertion.Response.UserHandle)
+
+		signCount, err := rp.VerifyAssertion(assertion, challenge, credential.PublicKey, credential.SignCount)
+		assert.NoError(t, err, "alg %d", alg)
+		assert.Equal(t, uint32(1), signCount)
+
+		_, err = rp.VerifyAssertion(assertion, challenge, credential.PublicKey, signCount)
+		assert.True(t, errors.Is(err, webauthn.ErrInvalidResponse), "replayed assertion must fail the sign count check")
+	}
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	rp := newRelyingParty("required")
+
+	t.Run("fail on wrong origin", func(t *testing.T) {
+		_, err := register(t, rp, webauthntest.NewAuthenticator("https://evil.example.com"))
+		assert.True(t, errors.Is(err, webauthn.ErrInvalidResponse))
+	})
+
+	t.Run("fail on wrong rp id", func(t *testing.T) {
+		other := webauthn.New(webauthn.Config{RPID: "evil.example.com", Origins: []string{origin}})
+		challenge, err := webauthn.NewChallenge()
+		require.NoError(t, err)
+		registration, err := webauthntest.NewAuthenticator(origin).Register(other.CreationOptions(challenge, webauthn.UserEntity{ID: "MQ"}, nil))
+		require.NoError(t, err)
+		_, err = rp.VerifyRegistration(registration, challenge)
+		assert.True(t, errors.Is(err, webauthn.ErrInvalidResponse))
+	})
+
+	t.Run("fail on wrong challenge", func(t *testing.T) {
+		challenge, err := webauthn.NewChallenge()
+		require.NoError(t, err)
+		registration, err := webauthntest.NewAuthenticator(origin).Register(rp.CreationOptions(challenge, webauthn.UserEntity{ID: "MQ"}, nil))
+		require.NoError(t, err)
+		_, err = rp.VerifyRegistration(registration, "other")
+		assert.True(t, errors.Is(err, webauthn.ErrInvalidResponse))
+	})
+
+	t.Run("fail without user verification", func(t *testing.T) {
+		authenticator := webauthntest.NewAuthenticator(origin)
+		authenticator.UserVerified = false
+		_, err := register(t, rp, authenticator)
+		assert.True(t, errors.Is(err, webauthn.ErrInvalidResponse))
+	})
+
+	t.Run("fail on excluded credential", func(t *testing.T) {
+		authenticator := webauthntest.NewAuthenticator(origin)
+		credential, err := register(t, rp, authenticator)
+		require.NoError(t, err)
+		challenge, err := webauthn.NewChallenge()
+		require.NoError(t, err)
+		_, err = authenticator.Register(rp.CreationOptions(challenge, webauthn.UserEntity{ID: "MQ"},
+			[]webauthn.CredentialDescriptor{{Type: "public-key", ID: credential.ID}}))
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	rp := newRelyingParty("")
+	authenticator := webauthntest.NewAuthenticator(origin)
+	credential, err := register(t, rp, authenticator)
+	require.NoError(t, err)
+	login := func(challenge string) webauthn.Assertion {
+		assertion, err := authenticator.Login(rp.RequestOptions(challenge, nil))
+		require.NoError(t, err)
+		return assertion
+	}
+
+	t.Run("fail on tampered signature", func(t *testing.T) {
+		assertion := login("challenge")
+		other := login("other")
+		assertion.Response.Signature = other.Response.Signature
+		_, err := rp.VerifyAssertion(assertion, "challenge", credential.PublicKey, 0)
+		assert.True(t, errors.Is(err, webauthn.ErrInvalidResponse))
+	})
+
+	t.Run("fail on registration response", func(t *testing.T) {
+		challenge, err := webauthn.NewChallenge()
+		require.NoError(t, err)
+		registration, err := authenticator.Register(rp.CreationOptions(challenge, webauthn.UserEntity{ID: "MQ"}, nil))
+		require.NoError(t, err)
+		assertion := login(challenge)
+		assertion.Response.ClientDataJSON = registration.Response.ClientDataJSON
+		_, err = rp.VerifyAssertion(assertion, challenge, credential.PublicKey, 0)
+		assert.True(t, errors.Is(err, webauthn.ErrInvalidResponse))
+	})
+
+	t.Run("fail on another credential key", func(t *testing.T) {
+		other, err := register(t, rp, webauthntest.NewAuthenticator(origin))
+		require.NoError(t, err)
+		_, err = rp.VerifyAssertion(login("challenge"), "challenge", other.PublicKey, 0)
+		assert.True(t, errors.Is(err, webauthn.ErrInvalidResponse))
+	})
+}
@@ -0,0 +1,208 @@
+// Package webauthntest provides a software authenticator so WebAuthn
+// ceremonies can be driven in tests without hardware or a browser.
+package webauthntest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sync"
+	"usermanagement/app/internal/webauthn"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+)
+
+type credential struct {
+	id         []byte
+	userHandle string
+	signer     crypto.Signer
+	signCount  uint32
+}
+
+// Authenticator behaves like a platform authenticator holding discoverable
+// credentials. Alg selects the key type of new credentials and defaults to
+// ES256, Origin is what the client reports in clientDataJSON.
+type Authenticator struct {
+	Origin string
+	Alg    int
+	// UserVerified controls the UV flag of responses, it starts out set.
+	UserVerified bool
+	mu           sync.Mutex
+	credentials  []*credential
+}
+
+func NewAuthenticator(origin string) *Authenticator {
+	return &Authenticator{
+		Origin:       origin,
+		Alg:          webauthn.AlgES256,
+		UserVerified: true,
+	}
+}
+
+// Register runs navigator.credentials.create() for the given options.
+func (a *Authenticator) Register(options webauthn.CreationOptions) (registration webauthn.Registration, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, exclude := range options.ExcludeCredentials {
+		if a.find(exclude.ID) != nil {
+			return registration, errors.New("credential already registered")
+		}
+	}
+	signer, coseKey, err := a.newKey()
+	if err != nil {
+		return registration, err
+	}
+	id := make([]byte, 16)
+	if _, err = rand.Read(id); err != nil {
+		return registration, err
+	}
+	cred := &credential{
+		id:         id,
+		userHandle: options.User.ID,
+		signer:     signer,
+	}
+	authData := a.authenticatorData(options.RP.ID, flagAttestedCredentialData, 0)
+	authData = append(authData, make([]byte, 16)...)
+	authData = append(authData, byte(len(id)>>8), byte(len(id)))
+	authData = append(authData, id...)
+	authData = append(authData, coseKey...)
+	attestation, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	if err != nil {
+		return registration, err
+	}
+	clientData, err := a.clientData(webauthn.CeremonyCreate, options.Challenge)
+	if err != nil {
+		return registration, err
+	}
+	a.credentials = append(a.credentials, cred)
+	registration = webauthn.Registration{
+		ID:    webauthn.Encode(id),
+		RawID: webauthn.Encode(id),
+		Type:  "public-key",
+		Response: webauthn.AttestationResponse{
+			ClientDataJSON:    webauthn.Encode(clientData),
+			AttestationObject: webauthn.Encode(attestation),
+			Transports:        []string{"internal"},
+		},
+	}
+	return registration, nil
+}
+
+// Login runs navigator.credentials.get(). With an empty allow list the most
+// recently registered credential is used, like picking a discoverable passkey.
+func (a *Authenticator) Login(options webauthn.RequestOptions) (assertion webauthn.Assertion, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var cred *credential
+	for _, allow := range options.AllowCredentials {
+		if cred = a.find(allow.ID); cred != nil {
+			break
+		}
+	}
+	if cred == nil && len(options.AllowCredentials) == 0 && len(a.credentials) > 0 {
+		cred = a.credentials[len(a.credentials)-1]
+	}
+	if cred == nil {
+		return assertion, errors.New("no matching credential")
+	}
+	cred.signCount++
+	authData := a.authenticatorData(options.RPID, 0, cred.signCount)
+	clientData, err := a.clientData(webauthn.CeremonyGet, options.Challenge)
+	if err != nil {
+		return assertion, err
+	}
+	clientDataHash := sha256.Sum256(clientData)
+	signature, err := sign(cred.signer, append(append([]byte{}, authData...), clientDataHash[:]...))
+	if err != nil {
+		return assertion, err
+	}
+	assertion = webauthn.Assertion{
+		ID:    webauthn.Encode(cred.id),
+		RawID: webauthn.Encode(cred.id),
+		Type:  "public-key",
+		Response: webauthn.AssertionResponse{
+			ClientDataJSON:    webauthn.Encode(clientData),
+			AuthenticatorData: webauthn.Encode(authData),
+			Signature:         webauthn.Encode(signature),
+			UserHandle:        cred.userHandle,
+		},
+	}
+	return assertion, nil
+}
+
+func (a *Authenticator) find(id string) *credential {
+	for _, cred := range a.credentials {
+		if webauthn.Encode(cred.id) == id {
+			return cred
+		}
+	}
+	return nil
+}
+
+func (a *Authenticator) authenticatorData(rpID string, flags byte, signCount uint32) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	flags |= flagUserPresent
+	if a.UserVerified {
+		flags |= flagUserVerified
+	}
+	data := append(rpIDHash[:], flags)
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, signCount)
+	return append(data, counter...)
+}
+
+func (a *Authenticator) clientData(ceremony string, challenge string) ([]byte, error) {
+	return json.Marshal(webauthn.ClientData{
+		Type:      ceremony,
+		Challenge: challenge,
+		Origin:    a.Origin,
+	})
+}
+
+func (a *Authenticator) newKey() (signer crypto.Signer, coseKey []byte, err error) {
+	switch a.Alg {
+	case webauthn.AlgEdDSA:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		coseKey, err = cbor.Marshal(map[int]interface{}{1: 1, 3: webauthn.AlgEdDSA, -1: 6, -2: []byte(pub)})
+		return priv, coseKey, err
+	case webauthn.AlgES256, 0:
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		x := make([]byte, 32)
+		y := make([]byte, 32)
+		priv.X.FillBytes(x)
+		priv.Y.FillBytes(y)
+		coseKey, err = cbor.Marshal(map[int]interface{}{1: 2, 3: webauthn.AlgES256, -1: 1, -2: x, -3: y})
+		return priv, coseKey, err
+	}
+	return nil, nil, errors.New("unsupported algorithm")
+}
+
+func sign(signer crypto.Signer, data []byte) ([]byte, error) {
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		return signer.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(data)
+	return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
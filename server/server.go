@@ -70,6 +70,15 @@ func NewServer() *Server {
 	childRoutines, childCtx := errgroup.WithContext(rootCtx)
 	app := config.NewAppService(configurations)
 	RegisterService(app)
+	RegisterService(app.JobRunner())
+	RegisterService(app.MembershipExpiryService())
+	RegisterService(app.DynamicGroupService())
+	RegisterService(app.AccessRequestService())
+	RegisterService(app.AccessReviewService())
+	RegisterService(app.KeyManager())
+	for _, task := range app.BackgroundTasks() {
+		RegisterService(task)
+	}
 	return &Server{
 		context:       childCtx,
 		shutdownFn:    shutdownFn,